@@ -30,6 +30,8 @@ class GameConfig(BaseModel):
     target_fps: int = 30
     max_frameskip: int = 5
     ai_move_delay: float = 0.5  # Seconds between AI moves
+    ai_tick_budget_ms: float = 8.0  # AI time per tick before far monsters wait a tick
+    action_cadence: float = 0.0  # Minimum seconds between two player actions (0 = none)
 
     # Game settings
     player_start_x: int = 25
@@ -38,6 +40,7 @@ class GameConfig(BaseModel):
 
     # Pathfinding
     max_path_length: int = 100  # Maximum path length to calculate
+    auto_move_delay: float = 0.15  # Seconds between steps when walking to a destination
 
     # Paths
     paths: Dict[str, str] = {}
@@ -45,6 +48,75 @@ class GameConfig(BaseModel):
     # Controls
     controls: Dict[str, Any] = {}
 
+    # Economy (gold sinks and tracking)
+    economy: Dict[str, Any] = {}
+
+    # Tile occupancy rules (relation -> block/swap/pass)
+    occupancy: Dict[str, Any] = {}
+
+    # Waypoint travel pricing and cast time
+    travel: Dict[str, Any] = {}
+
+    # Day/night cycle
+    time: Dict[str, Any] = {}
+
+    # Games of chance against NPCs
+    gambling: Dict[str, Any] = {}
+
+    # Hardcore (permadeath) ruleset
+    hardcore: Dict[str, Any] = {}
+
+    # Seasonal ladders
+    season: Dict[str, Any] = {}
+
+    # New-player tutorial
+    tutorial: Dict[str, Any] = {}
+
+    # Telnet gateway for terminal clients
+    gateway: Dict[str, Any] = {}
+
+    # Shrinking views and far-off AI under load
+    load: Dict[str, Any] = {}
+
+    # Real-time or turn-based combat
+    combat: Dict[str, Any] = {}
+
+    # Allies raised by spells and charms
+    summons: Dict[str, Any] = {}
+
+    # Fishing bite timing
+    fishing: Dict[str, Any] = {}
+
+    # Client request handling (idempotency keys)
+    requests: Dict[str, Any] = {}
+
+    # Replay recording
+    replay: Dict[str, Any] = {}
+
+    # Admin runtime diagnostics
+    diagnostics: Dict[str, Any] = {}
+
+    # Discord chat bridge
+    discord: Dict[str, Any] = {}
+
+    # Chat channels
+    chat: Dict[str, Any] = {}
+
+    # Message of the day and announcements
+    announcements: Dict[str, Any] = {}
+
+    # Scheduled restarts
+    maintenance: Dict[str, Any] = {}
+
+    # Anti-cheat anomaly detection
+    anticheat: Dict[str, Any] = {}
+
+    # Third-party plugins
+    plugins: Dict[str, Any] = {}
+
+    # World generation passes (hydrology, caves, prefab structures)
+    worldgen: Dict[str, Any] = {}
+
     model_config = ConfigDict(extra="allow")
 
     @classmethod
@@ -55,24 +127,57 @@ class GameConfig(BaseModel):
             return cls()
 
         try:
-            with open(path, "r") as f:
-                data = toml.load(f)
-
-            # Flatten game settings for Pydantic
-            game_settings = data.get("game", {})
-
-            # Create instance with game settings
-            config = cls(**game_settings)
-
-            # Attach complex structures
-            config.paths = data.get("paths", {})
-            config.controls = data.get("controls", {})
-
-            return config
+            return cls.read_toml(path)
         except Exception as e:
             print(f"Error loading config: {e}")
             return cls()
 
+    @classmethod
+    def read_toml(cls, path: str) -> "GameConfig":
+        """Parse and validate a TOML config file, raising if it is bad."""
+        with open(path, "r") as f:
+            data = toml.load(f)
+
+        # Flatten game settings for Pydantic
+        game_settings = data.get("game", {})
+
+        # Create instance with game settings
+        config = cls(**game_settings)
+
+        # Attach complex structures
+        config.paths = data.get("paths", {})
+        config.controls = data.get("controls", {})
+        config.economy = data.get("economy", {})
+        config.occupancy = data.get("occupancy", {})
+        config.travel = data.get("travel", {})
+        config.time = data.get("time", {})
+        config.gambling = data.get("gambling", {})
+        config.hardcore = data.get("hardcore", {})
+        config.season = data.get("season", {})
+        config.tutorial = data.get("tutorial", {})
+        config.gateway = data.get("gateway", {})
+        config.load = data.get("load", {})
+        config.requests = data.get("requests", {})
+        config.combat = data.get("combat", {})
+        config.summons = data.get("summons", {})
+        config.fishing = data.get("fishing", {})
+        config.replay = data.get("replay", {})
+        config.diagnostics = data.get("diagnostics", {})
+        config.discord = data.get("discord", {})
+        config.chat = data.get("chat", {})
+        config.announcements = data.get("announcements", {})
+        config.maintenance = data.get("maintenance", {})
+        config.anticheat = data.get("anticheat", {})
+        config.plugins = data.get("plugins", {})
+        config.worldgen = data.get("worldgen", {})
+
+        return config
+
+    def replace_with(self, other: "GameConfig"):
+        """Take on every setting of other, so modules holding CONFIG see the change."""
+        for name, value in other:
+            setattr(self, name, value)
+
 
 # Global config instance
 CONFIG = GameConfig.load_from_toml()
@@ -0,0 +1,196 @@
+"""
+Command registry for the roguelike game.
+Player actions (move, pickup, stealth, ...) and data requests (map_request,
+season_info, ...) are registered here with a short description, so input,
+text frontends and clients all dispatch through the same table and the help
+reference is generated from what is actually registered.
+"""
+
+import json
+from dataclasses import dataclass
+from typing import Any, Callable, Dict, Iterable, Optional, Tuple, Union
+
+from core.recovery import internal_error, log_exception
+from core.validation import ActionError, ActionValidator
+
+MAX_MESSAGE_BYTES = 64 * 1024  # Larger request messages are refused unparsed
+
+
+@dataclass
+class Command:
+    """A registered action or request."""
+
+    name: str
+    handler: Callable[..., Any]
+    description: str
+    args: Tuple[str, ...] = ()
+    response: str = ""  # Message type a request replies with
+    optional: Tuple[str, ...] = ()  # Request args passed only when present
+    # What the validator checks before the handler runs (see core.validation)
+    role: str = ""  # Role the caller must have, e.g. "admin"
+    requires: Tuple[str, ...] = ()  # Named state prerequisites
+    target: Tuple[str, ...] = ()  # The two args that give a tile (x, y)...
+    reach: int = 0  # ...and how many tiles away it may be
+    relative: bool = False  # The target is an offset from the player, not a tile
+    paced: bool = False  # Counts against the action cadence
+    # Changes game state, so it takes an idempotency_key (see systems.idempotency)
+    changes_state: bool = False
+
+    def describe(self) -> Dict[str, Any]:
+        entry = {"name": self.name, "description": self.description, "args": list(self.args)}
+        if self.optional:
+            entry["optional"] = list(self.optional)
+        if self.role:
+            entry["role"] = self.role
+        if self.response:
+            entry["response"] = self.response
+        return entry
+
+
+def decode_request(raw: Union[str, bytes]) -> Dict[str, Any]:
+    """Parse a JSON request message; raises ValueError if it is not a usable request."""
+    if len(raw) > MAX_MESSAGE_BYTES:
+        raise ValueError(f"Message larger than {MAX_MESSAGE_BYTES} bytes")
+    try:
+        message = json.loads(raw)
+    except RecursionError:
+        raise ValueError("Message nested too deeply") from None
+    except UnicodeDecodeError as e:
+        raise ValueError(f"Message is not UTF-8: {e}") from None
+    if not isinstance(message, dict):
+        raise ValueError("Message must be a JSON object")
+    if not isinstance(message.get("type"), str):
+        raise ValueError("Message needs a string type")
+    return message
+
+
+class CommandRegistry:
+    """Named player actions and request handlers."""
+
+    def __init__(self, validator: Optional[ActionValidator] = None):
+        self.actions: Dict[str, Command] = {}
+        self.requests: Dict[str, Command] = {}
+        self.validator = validator
+        # Told about actions the validator refuses; requests reply with the error
+        self.on_refused: Optional[Callable[[Command, ActionError], None]] = None
+        # Told about each action that ran (turn-based combat ends the turn)
+        self.on_acted: Optional[Callable[[Command], None]] = None
+
+    def action(
+        self,
+        name: str,
+        handler: Callable[..., Any],
+        description: str,
+        args: Tuple[str, ...] = (),
+        requires: Tuple[str, ...] = (),
+        target: Tuple[str, ...] = (),
+        reach: int = 0,
+        relative: bool = False,
+    ):
+        """Register a player action; args name its positional parameters.
+
+        Actions count against the action cadence; requires, target and reach
+        are checked by the validator first.
+        """
+        self.actions[name] = Command(
+            name, handler, description, tuple(args),
+            requires=tuple(requires), target=tuple(target), reach=reach,
+            relative=relative, paced=True,
+        )
+
+    def request(
+        self,
+        name: str,
+        handler: Callable[..., dict],
+        description: str,
+        args: Tuple[str, ...] = (),
+        response: str = "",
+        optional: Tuple[str, ...] = (),
+        role: str = "",
+        requires: Tuple[str, ...] = (),
+        changes_state: bool = False,
+    ):
+        """Register a request; the handler takes the args as keywords and returns a message.
+
+        Requests that change state should say so, so that a retried one
+        carrying the same idempotency_key is not run twice.
+        """
+        self.requests[name] = Command(
+            name, handler, description, tuple(args), response or name, tuple(optional),
+            role=role, requires=tuple(requires), changes_state=changes_state,
+        )
+
+    def changes_state(self, name: str) -> bool:
+        """Whether the named request was registered as changing state."""
+        command = self.requests.get(name)
+        return command is not None and command.changes_state
+
+    def restrict(self, role: str, names: Iterable[str]):
+        """Require role for the named requests that are registered."""
+        for name in names:
+            if name in self.requests:
+                self.requests[name].role = role
+
+    def run_action(self, name: str, *args) -> bool:
+        """Perform an action; returns False if no action has that name or it was refused."""
+        command = self.actions.get(name)
+        if command is None:
+            return False
+        if self.validator:
+            try:
+                self.validator.check(command, dict(zip(command.args, args)))
+            except ActionError as e:
+                if self.on_refused:
+                    self.on_refused(command, e)
+                return False
+        command.handler(*args)
+        if self.on_acted:
+            self.on_acted(command)
+        return True
+
+    def handle_request(self, message: Dict[str, Any]) -> dict:
+        """Answer a request message ({"type": name, ...args}); errors come back as messages."""
+        if not isinstance(message, dict) or not isinstance(message.get("type", ""), str):
+            return {"type": "error", "error": "Malformed request"}
+        command = self.requests.get(message.get("type", ""))
+        if command is None:
+            return {"type": "error", "error": f"Unknown request: {message.get('type')}"}
+
+        missing = [arg for arg in command.args if arg not in message]
+        if missing:
+            return {"type": "error", "error": f"Missing {', '.join(missing)} for {command.name}"}
+        kwargs = {arg: message[arg] for arg in command.args}
+        kwargs.update({arg: message[arg] for arg in command.optional if arg in message})
+        if self.validator:
+            try:
+                self.validator.check(command, kwargs)
+            except ActionError as e:
+                return e.reply()
+        try:
+            return command.handler(**kwargs)
+        except (TypeError, ValueError, OverflowError) as e:
+            return {"type": "error", "error": f"Bad {command.name} request: {e}"}
+        except Exception:
+            # A handler bug fails this request only, never the game
+            log_exception(f"request {command.name}")
+            return internal_error(command.name)
+
+    def handle_raw(self, raw: Union[str, bytes]) -> dict:
+        """Decode and answer a JSON request as it arrived from a client."""
+        try:
+            message = decode_request(raw)
+        except ValueError as e:
+            return {"type": "error", "error": str(e)}
+        return self.handle_request(message)
+
+    def help(self) -> dict:
+        """Every registered action and request, plus the message types they use."""
+        message_types = set(self.requests)
+        message_types.update(command.response for command in self.requests.values())
+        message_types.add("error")
+        return {
+            "type": "help",
+            "actions": [command.describe() for _, command in sorted(self.actions.items())],
+            "requests": [command.describe() for _, command in sorted(self.requests.items())],
+            "message_types": sorted(message_types),
+        }
@@ -2,8 +2,9 @@
 Entity Component System (ECS) framework for the roguelike game.
 """
 
-from dataclasses import dataclass
-from typing import Dict, List, Type, Optional
+import sys
+from dataclasses import dataclass, fields
+from typing import Any, Dict, List, Type, Optional
 from collections import defaultdict
 
 
@@ -15,6 +16,57 @@ class Component:
     __slots__ = []
 
 
+def component_types() -> Dict[str, Type[Component]]:
+    """Every component class defined so far, by class name."""
+    found = {}
+    pending = list(Component.__subclasses__())
+    while pending:
+        cls = pending.pop()
+        pending.extend(cls.__subclasses__())
+        # dataclass(slots=True) replaces the class it decorates; the discarded
+        # original lingers as a subclass until collected, so only take the
+        # class its module actually exports
+        if getattr(sys.modules.get(cls.__module__), cls.__name__, None) is cls:
+            found[cls.__name__] = cls
+    return found
+
+
+def encode_value(value: Any) -> Any:
+    """Turn a component field into JSON-safe data.
+
+    Tuples and dicts with non-string keys are tagged so decode_value can
+    rebuild them exactly.
+    """
+    if isinstance(value, tuple):
+        return {"__tuple__": [encode_value(v) for v in value]}
+    if isinstance(value, list):
+        return [encode_value(v) for v in value]
+    if isinstance(value, dict):
+        if all(isinstance(k, str) for k in value):
+            return {k: encode_value(v) for k, v in value.items()}
+        return {"__pairs__": [[encode_value(k), encode_value(v)] for k, v in value.items()]}
+    return value
+
+
+def decode_value(data: Any) -> Any:
+    """Inverse of encode_value."""
+    if isinstance(data, list):
+        return [decode_value(v) for v in data]
+    if isinstance(data, dict):
+        if "__tuple__" in data:
+            return tuple(decode_value(v) for v in data["__tuple__"])
+        if "__pairs__" in data:
+            return {
+                _hashable(decode_value(k)): decode_value(v) for k, v in data["__pairs__"]
+            }
+        return {k: decode_value(v) for k, v in data.items()}
+    return data
+
+
+def _hashable(key: Any) -> Any:
+    return tuple(key) if isinstance(key, list) else key
+
+
 class Entity:
     """An entity in the game world."""
 
@@ -146,6 +198,43 @@ class EntityManager:
         """Get all entities that have a specific component."""
         return list(self.components_by_type.get(comp_type, {}).keys())
 
+    def serialize_entity(self, eid: int) -> Dict[str, Any]:
+        """Describe an entity and its components as JSON-safe data."""
+        entity = self.entities[eid]
+        return {
+            "eid": eid,
+            "components": {
+                comp_type.__name__: {
+                    f.name: encode_value(getattr(component, f.name)) for f in fields(component)
+                }
+                for comp_type, component in entity.components.items()
+            },
+        }
+
+    def restore_entity(
+        self, data: Dict[str, Any], types: Optional[Dict[str, Type[Component]]] = None
+    ) -> int:
+        """Recreate a serialized entity under its original ID.
+
+        Components whose class no longer exists are skipped, and fields they
+        no longer have are dropped, so old saves still load.
+        """
+        types = types or component_types()
+        eid = int(data["eid"])
+        if eid in self.entities:
+            raise ValueError(f"Entity {eid} already exists")
+        self.entities[eid] = Entity(eid)
+        self.next_id = max(self.next_id, eid + 1)
+
+        for name, values in data.get("components", {}).items():
+            comp_type = types.get(name)
+            if comp_type is None:
+                continue
+            known = {f.name for f in fields(comp_type)}
+            kwargs = {k: decode_value(v) for k, v in values.items() if k in known}
+            self.add_component(eid, comp_type(**kwargs))
+        return eid
+
 
 class System:
     """Base class for all systems."""
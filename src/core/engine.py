@@ -2,22 +2,187 @@
 Main game engine for the roguelike game.
 """
 
+import math
+import os
+import signal
 import time
-from typing import Optional
+from typing import Any, Callable, Dict, List, Optional, Tuple
 from collections import deque
 from rich.console import Console
 from core.ecs import EntityManager, SystemManager
-from config import CONFIG
-from world.map import GameMap, CHAR_MAP
+from core.commands import CommandRegistry
+from core.events import ChatSent, EntityDied, EventBus, FishCaught, ItemPickedUp, PlayerMoved
+from core.plugins import PluginManager
+from core.recovery import SessionError, log_exception
+from core.validation import (
+    NOT_AUTHORIZED,
+    NOT_YOUR_TURN,
+    RATE_LIMITED,
+    ActionError,
+    ActionValidator,
+)
+from input import text_commands
+from config import CONFIG, GameConfig
+from world.map import GameMap, CHAR_MAP, TILE_FLOOR, TILE_STAIRS_DOWN, TILE_STAIRS_UP
 from entities.entities import EntityManagerWrapper
 from entities.components import Position
 from ui.renderer import Renderer
 from input.handler import InputHandler, InputEvent
 from entities.spawn_system import SpawnSystem
 from entities.ai_system import AISystem
+from entities.behavior_tree import build_trees
+from entities.occupancy import OccupancyRules
 from entities.boss_system import BossSystem
+from entities.stealth_system import StealthSystem
+from entities.loot_system import LootSystem
+from entities.summon_system import (
+    DISMISSED,
+    EXPIRED,
+    LEFT_BEHIND,
+    REPLACED,
+    SummonError,
+    SummonSystem,
+)
+from entities.swimming_system import SwimmingSystem, struggle_chance
 from world.fov import calculate_fov
+from world.lighting import (
+    LIGHT_WINDOW,
+    MIN_VISIBLE_LIGHT,
+    NIGHT_VISION,
+    LightMap,
+    ambient_light,
+    compute_light,
+    lit_visibility,
+)
 from core.spatial import SpatialIndex
+from world.pathfinding import find_path
+from world.waypoints import TeleportNetwork
+from world.regions import RegionMap
+from world.overview import Overview, build_overview, fit_scale, to_png
+from world.poi import DISCOVERY_RADIUS, POIIndex
+from world.editor import EditorError, MapEditor, Spawners
+from world.settlements import SHOP_STOCK
+from world.worlds import DEFAULT_WORLD, WorldError, WorldInfo, WorldRegistry
+from world.transfer import Roster, TransferError, merge, remap_position, transfer
+from systems.currency import (
+    CURRENCIES,
+    GOLD,
+    CurrencyError,
+    balances,
+    coin_worth,
+    exchange,
+    format_coins,
+    restore_wallet,
+    wallet_profile,
+)
+from systems.economy import EconomyTracker
+from systems.glyphs import terminal_glyph
+from systems.markets import RECOVERY_PERIOD, Market, Markets
+from systems.narration import Narrator, Seen, describe
+from systems.gambling import Gambling
+from systems.factions import FactionSystem
+from systems.profile import load_profile, save_profile
+from systems.memorial import Memorial, character_record
+from systems.crafting import (
+    CRAFT_XP,
+    CraftingError,
+    apply_roll,
+    bonus_affix_chance,
+    check_recipe,
+    critical_chance,
+    roll_craft,
+    stat_range,
+)
+from systems.alchemy import AlchemyError, Knowledge
+from systems.fishing import BITE, ESCAPED, FISHING_XP, Fishing, FishingError
+from systems.buyback import BUYBACK_SLOTS, Buyback
+from systems.channels import SERVER_CHANNELS, ChannelError, ChannelRouter
+from systems.binding import BIND_ON_EQUIP, BIND_ON_PICKUP, bind_on, bind_status
+from systems.identification import is_cursed, is_identified, item_details, roll_drop
+from systems.homes import RECALL_COOLDOWN, HomePoint, Homes, bind_point_near
+from systems.seasons import SeasonSystem
+from systems.stash import STASH_CAPACITY, AccountStash
+from systems.tutorial import TutorialSystem
+from systems.connection_guard import ConnectionGuard
+from systems.telnet_gateway import TelnetGateway, render_ansi
+from systems.tokens import verify_token
+from systems.audit import AuditLog
+from systems.anticheat import AntiCheat
+from systems.discord_bridge import DiscordBridge, http_transport
+from systems.announcements import Announcements
+from systems.maintenance import WARNINGS, ScheduledRestart, next_daily
+from systems.cutscenes import (
+    Cutscene,
+    CutsceneError,
+    CutsceneLog,
+    resolve_target,
+    validate_cutscene,
+)
+from systems.scripting import ScriptError, ScriptHost, run_script, validate_script
+from systems.replay import ReplayPlayer, ReplayRecorder, load_replay
+from systems.diagnostics import Diagnostics
+from systems.latency import LatencyTracker
+from systems.load_scaling import ViewScaler
+from systems.idempotency import KEY_FIELD, IdempotencyCache, IdempotencyError
+from systems.turns import ENERGY, TURN_ACTIONS, TurnOrder
+from systems.energy import EnergyScheduler
+from systems.settings import LOW_RATE_SECONDS, PlayerSettings, SettingsError
+from systems.input_sequence import InputSequencer
+from systems.clock import DAY_PERIOD_MESSAGES, GameClock
+from entities.schedule_system import ScheduleSystem, nearest_walkable
+from systems.transactions import ItemTransaction, TransactionError, TransactionJournal
+
+
+
+
+CHAT_COLOR = (120, 140, 255)  # Lines relayed from the Discord channel
+ANNOUNCEMENT_COLOR = (255, 170, 60)
+CHANNEL_COLOR = (150, 220, 255)  # Lines on chat channels
+DESCRIBE_COLOR = (230, 230, 230)  # Describe mode, for screen readers
+DIALOGUE_COLOR = (255, 230, 160)  # Cutscene dialogue
+MIN_VIEW = 9  # Smallest telnet view side, however loaded the server is
+ENERGY_PRUNE_TICKS = 600  # How often energy kept for dead monsters is dropped
+SUMMON_COLOR = (180, 160, 255)
+# How the player is told a summon has gone, by the reason it went
+SUMMON_DISMISSALS = {
+    EXPIRED: "crumbles away",
+    REPLACED: "fades to make room for another",
+    LEFT_BEHIND: "is left behind and unravels",
+    DISMISSED: "returns whence it came",
+}
+
+# Requests only admins should use; each one is written to the audit log
+ADMIN_REQUESTS = {
+    "audit_log",
+    "runtime_stats",
+    "profile_start",
+    "profile_stop",
+    "set_motd",
+    "announce",
+    "cancel_announcement",
+    "schedule_restart",
+    "cancel_restart",
+    "reload_content",
+    "incidents",
+    "lift_restriction",
+    "edit_select",
+    "edit_tiles",
+    "edit_place",
+    "edit_remove",
+    "edit_poi",
+    "edit_save",
+    "create_world",
+    "archive_world",
+    "transfer_character",
+    "merge_worlds",
+    "grant_currency",
+    "netstat",
+    "kick",
+}
+RESTRICTED_MESSAGE = "Your character is restricted until a GM reviews it."
+LOCAL_ROLES = frozenset({"admin"})  # The local console and in-process clients
+LOCAL_CALLER = "console"  # How the audit log names them
+GATEWAY_ROLES = frozenset()  # Telnet players
 
 
 class GameEngine:
@@ -25,6 +190,7 @@ class GameEngine:
 
     def __init__(self):
         self.running = True
+        self.reload_requested = False  # Set by SIGHUP; handled between ticks
         self.entity_manager = EntityManager()
         self.system_manager = SystemManager(self.entity_manager)
         self.last_time = time.time()
@@ -32,13 +198,28 @@ class GameEngine:
         self.target_fps = 30
         self.frame_duration = 1.0 / self.target_fps
         self.fixed_timestep = 1.0 / CONFIG.target_fps
+        self.tick = 0  # Fixed updates run so far
+
+        # Replay log being written, or being played back
+        self.recorder: Optional[ReplayRecorder] = None
+        self.replay: Optional[ReplayPlayer] = None
 
         # Override configurations for testing
         self.override_map_path: Optional[str] = None
         self.override_start_pos: Optional[tuple[int, int]] = None
 
+        # Named worlds this server hosts; requested_world is chosen on the command line
+        self.worlds = WorldRegistry(
+            CONFIG.paths.get("worlds", "src/data/saves/worlds.json"),
+            CONFIG.paths.get("save_dir", "src/data/saves"),
+        )
+        self.requested_world: Optional[str] = None
+        self.world_name: Optional[str] = None  # The world being played in
+        self.world_options = []  # (world name, ruleset) shown in the world select menu
+
         # Initialize game components
         self.game_map: Optional[GameMap] = None
+        self.levels: Dict[int, GameMap] = {}  # Every Z-level's map; 0 is the surface
         self.entity_wrapper = EntityManagerWrapper(self.entity_manager)
         self.player_id: Optional[int] = None
 
@@ -65,8 +246,85 @@ class GameEngine:
             self.entity_manager, self.entity_wrapper.factory, self.spatial_index
         )
 
+        # Tile occupancy rules shared by player and AI movement
+        self.occupancy = OccupancyRules(
+            self.entity_manager, self.spatial_index, CONFIG.occupancy
+        )
+
+        # Factions; the player's standing is kept in their profile
+        self.factions = FactionSystem()
+        self.profile_path = CONFIG.paths.get("player_profile")
+
+        # Hardcore ruleset: death is permanent and fallen characters go to the memorial
+        self.hardcore = bool(CONFIG.hardcore.get("enabled", False))
+        self.memorial = Memorial(
+            CONFIG.paths.get("memorial", "src/data/saves/memorial.json"),
+            CONFIG.hardcore.get("memorial_size", 10),
+        )
+
+        # Seasonal ladder; checked for a season end once a minute
+        self.seasons = SeasonSystem(CONFIG.paths.get("seasons"), CONFIG.season)
+        self.season_timer = 0.0
+
+        # Home points at inns and shrines; kept per world in the player's profile
+        self.homes = Homes(CONFIG.travel.get("recall_cooldown", RECALL_COOLDOWN))
+        self.bind_points: List[HomePoint] = []
+
+        # Describe mode for screen readers; kept in the player's profile
+        self.narrator = Narrator()
+
+        # Chat filter, auto-loot, colours and the like; kept in the profile
+        self.settings = PlayerSettings()
+        self.view_sent_at = 0.0  # When telnet clients last got a map view
+
+        # Brews the player has discovered and the duds they have tried
+        self.alchemy = Knowledge()
+
+        # Items shared by every character on the account, loaded with the game
+        stash_capacity = CONFIG.economy.get("stash_capacity", STASH_CAPACITY)
+        self.stash = AccountStash(CONFIG.paths.get("stash"), stash_capacity)
+
+        # Guided tutorial for new players
+        self.tutorial = TutorialSystem(self.entity_manager)
+        self.in_tutorial = False
+
+        # What happened in play, for the subsystems that react to it
+        self.events = EventBus()
+        self.subscribe_events()
+
+        # Dice and cards with townsfolk, and how much each player may lose
+        self.gambling = Gambling(CONFIG.gambling)
+
+        # Hiding from monsters
+        self.stealth_system = StealthSystem(self.entity_manager)
+
+        # Kill credit and drops reserved for whoever dealt the most damage
+        self.loot_system = LootSystem(
+            self.entity_manager, CONFIG.economy.get("loot_window", 60.0)
+        )
+        self.swimming_system = SwimmingSystem(self.entity_manager)
+
+        # Allies called up by spells and charms, each on a timer
+        self.summons = SummonSystem(
+            self.entity_manager, self.entity_wrapper.factory, CONFIG.summons
+        )
+        self.summons.on_dismissed = self.summon_dismissed
+
+        # The player's fishing line; the server times the bite
+        self.fishing = Fishing(CONFIG.fishing)
+
         # Initialize AI system
-        self.ai_system = AISystem(self.entity_manager)
+        self.ai_system = AISystem(
+            self.entity_manager,
+            self.occupancy,
+            factions=self.factions,
+            stealth=self.stealth_system,
+        )
+
+        # Day/night cycle and NPC routines that follow it
+        self.clock = GameClock(CONFIG.time)
+        self.day_period = self.clock.period
+        self.schedule_system = ScheduleSystem(self.entity_manager, self.occupancy)
 
         # Initialize boss system
         self.boss_system = BossSystem(self.entity_manager, self.entity_wrapper.factory)
@@ -76,12 +334,72 @@ class GameEngine:
 
         self.vfx_system = VFXSystem(self.entity_manager)
 
+        # Economy tracking (gold faucets and sinks)
+        self.economy = EconomyTracker(CONFIG.economy)
+
+        # Each town's vendor prices, drifting with local supply; loaded per world
+        self.markets = Markets(
+            recovery=CONFIG.economy.get("market_recovery", RECOVERY_PERIOD)
+        )
+        self.towns: List = []
+
+        # Write-ahead journal for item/gold transactions
+        journal_path = CONFIG.paths.get("transaction_journal")
+        self.journal = TransactionJournal(journal_path) if journal_path else None
+
+        # Audit trail of admin requests, bans and large gold movements
+        audit_path = CONFIG.paths.get("audit_log")
+        self.audit: Optional[AuditLog] = None
+        if audit_path:
+            large_gold = CONFIG.economy.get("audit_large_gold", 1000)
+            self.audit = AuditLog(audit_path, large_gold)
+
+        # Flags player state the server's own events do not explain
+        self.anticheat: Optional[AntiCheat] = None
+        if CONFIG.anticheat.get("enabled", True):
+            self.anticheat = self.make_anticheat(CONFIG.paths.get("anticheat"))
+
+        # Chat channels: the server's own and those players make
+        self.channels = ChannelRouter(
+            CONFIG.paths.get("channels"),
+            CONFIG.chat.get("server_channels", SERVER_CHANNELS),
+        )
+
+        # Message of the day and scheduled announcements, managed by admins
+        self.announcements = Announcements(
+            CONFIG.paths.get("announcements"), CONFIG.announcements.get("motd", "")
+        )
+
         # Timers
         self.ai_timer = 0.0
         self.mana_regen_timer = 0.0
+        self.light_timer = 0.0
+
+        # Light levels around the player (see update_lighting)
+        self.light_map: Optional[LightMap] = None
+
+        # Click-to-move: remaining tiles to walk, one step per auto_move_delay
+        self.auto_path: deque = deque()
+        self.auto_move_timer = 0.0
+
+        # Waypoints and portals (filled in from the persistent world)
+        self.teleport = TeleportNetwork(settings=CONFIG.travel)
+        self.travel_options = []  # (waypoint name, gold cost) shown in the travel menu
+        self.travel_cast = None  # Pending jump: {"name", "cost", "remaining"}
 
-        # Message Log
+        # Points of interest (filled in from the persistent world)
+        self.pois = POIIndex()
+
+        # Named regions (built once the persistent world is loaded)
+        self.regions: Optional[RegionMap] = None
+        self.current_region_id = None
+
+        # World map overlay (rebuilt each time it is opened)
+        self.world_overview: Optional[Overview] = None
+
+        # Message Log (text commands also collect what they log)
         self.message_log = deque(maxlen=5)
+        self.log_capture: Optional[list] = None
         self.log("Welcome to the dungeon!", (255, 255, 0))
 
         # Game State
@@ -95,32 +413,1688 @@ class GameEngine:
         self.current_bank_id = None
         self._last_fov_pos = None
 
-    def update_fov(self):
-        """Update the field of view based on player position."""
+        # What the player sold this session, held back so it can be bought back
+        self.buyback = Buyback(CONFIG.economy.get("buyback_slots", BUYBACK_SLOTS))
+
+        # Runtime stats and profiling for admins, when enabled
+        self.diagnostics: Optional[Diagnostics] = None
+        if CONFIG.diagnostics.get("enabled", False):
+            self.diagnostics = Diagnostics(
+                profile_dir=CONFIG.paths.get("profiles", "src/data/saves/profiles")
+            )
+            self.diagnostics.install()
+        # Round trips clients report with their pings
+        self.latency = LatencyTracker()
+        # Smaller views and less far-off AI while ticks or client traffic run high
+        self.view_scaler = ViewScaler(CONFIG.load)
+        # Moves each predicting client has had processed, for acknowledgments
+        self.input_sequence = InputSequencer()
+        # Replies to keyed state-changing requests, so client retries are safe
+        self.idempotency = IdempotencyCache(
+            float(CONFIG.requests.get("idempotency_seconds", 300.0)),
+            int(CONFIG.requests.get("idempotency_keys", 1024)),
+        )
+
+        # Fights near the player run in initiative order when [combat] mode
+        # is "turns" (see systems.turns)
+        self.turns = TurnOrder(CONFIG.combat)
+        # ...or each creature acts as its energy allows, when it is "energy"
+        self.energy = EnergyScheduler(CONFIG.combat)
+
+        # Named actions and requests shared by keys, text commands and clients;
+        # every one is validated before its handler runs
+        self.caller_roles = LOCAL_ROLES
+        self.caller = LOCAL_CALLER
+        self.commands = CommandRegistry(self.action_validator())
+        self.commands.on_refused = self.action_refused
+        self.commands.on_acted = self.action_taken
+        self.register_commands()
+        self.commands.restrict("admin", ADMIN_REQUESTS)
+
+        # Optional telnet listener; started once the world is loaded
+        self.gateway: Optional[TelnetGateway] = None
+        if CONFIG.gateway.get("enabled", False):
+            self.gateway = TelnetGateway(
+                CONFIG.gateway.get("host", "127.0.0.1"),
+                CONFIG.gateway.get("port", 4000),
+                sessions=CONFIG.gateway.get("sessions", "kick_old"),
+                afk_after=CONFIG.gateway.get("afk_minutes", 5) * 60,
+                idle_timeout=CONFIG.gateway.get("idle_timeout_minutes", 30) * 60,
+                guard=ConnectionGuard(
+                    max_per_ip=CONFIG.gateway.get("max_connections_per_ip", 3),
+                    attempts=CONFIG.gateway.get("connect_attempts", 10),
+                    window=CONFIG.gateway.get("connect_window_seconds", 60),
+                    ban_seconds=CONFIG.gateway.get("ban_minutes", 10) * 60,
+                    audit=self.audit,
+                ),
+                authenticate=self.gateway_authenticator(),
+                login_timeout=CONFIG.gateway.get("login_timeout_seconds", 30),
+                audit=self.audit,
+                motd=lambda: self.announcements.motd,
+            )
+
+        # Quest and dialogue progress set by content scripts; saved in the profile
+        self.script_flags: set = set()
+
+        # The cutscene playing, if any, and the events streamed to clients
+        self.cutscene: Optional[Cutscene] = None
+        self.cutscene_log = CutsceneLog()
+
+        # Admin map editing of the persistent world; set up once it is loaded
+        self.editor: Optional[MapEditor] = None
+        self.spawners = Spawners()
+        self.placed_eids: Dict[int, int] = {}  # id(entry) -> entity it spawned
+
+        # Pending maintenance restart, if any; a daily one can be set in config
+        self.restart: Optional[ScheduledRestart] = None
+        if CONFIG.maintenance.get("daily_restart"):
+            deadline = next_daily(CONFIG.maintenance["daily_restart"], time.time())
+            self.restart = self.restart_countdown(deadline, "Daily maintenance.")
+
+        # Optional Discord chat relay; needs a bot token in the environment
+        self.discord: Optional[DiscordBridge] = None
+        token = os.environ.get(CONFIG.discord.get("token_env", "DISCORD_BOT_TOKEN"), "")
+        if CONFIG.discord.get("enabled", False) and token:
+            self.discord = DiscordBridge(
+                str(CONFIG.discord.get("channel_id", "")),
+                http_transport(token),
+                CONFIG.discord.get("poll_seconds", 3.0),
+            )
+
+        # Third-party extensions named in config; they may add commands too
+        self.plugins = PluginManager()
+        for name in self.plugins.load(CONFIG.plugins.get("modules", []), self):
+            print(f"Plugin {name} failed to load; see game_debug.log")
+
+    def action_validator(self) -> ActionValidator:
+        """The cadence, role and state checks every command goes through."""
+        validator = ActionValidator(
+            cadence=CONFIG.action_cadence,
+            clock=lambda: self.tick * self.fixed_timestep,
+            roles=lambda: self.caller_roles,
+            position=self.player_position,
+        )
+        validator.state("alive", self.player_alive, "You can't do that now.")
+        validator.state(
+            "playing", lambda: self.game_state == "PLAYING", "Close the open menu first."
+        )
+        validator.state(
+            "unrestricted",
+            lambda: not (self.anticheat and self.anticheat.restricted(self.player_name())),
+            RESTRICTED_MESSAGE,
+        )
+        validator.pacing = self.action_pacing
+        return validator
+
+    def player_position(self) -> Optional[Tuple[int, int]]:
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        return (pos.x, pos.y) if pos else None
+
+    def player_alive(self) -> bool:
+        from entities.components import Health
+
+        health = self.entity_manager.get_component(self.player_id, Health)
+        return health is not None and health.current > 0
+
+    def action_refused(self, command, error: ActionError):
+        # Held keys outrun the cadence all the time; only other refusals are news
+        if error.code != RATE_LIMITED:
+            self.log(error.message, (150, 150, 150))
+
+    def action_pacing(self, action: str) -> Optional[ActionError]:
+        """Why a world action must wait: in a turn-based fight it is not the
+        player's turn, or under energy scheduling they cannot pay for it yet."""
+        if self.turns.active and not self.turns.player_turn and action in TURN_ACTIONS:
+            return ActionError(NOT_YOUR_TURN, "Wait for your turn.")
+        if self.turns.mode == ENERGY:
+            now = self.tick * self.fixed_timestep
+            cost = self.energy.cost(action)
+            if not self.energy.ready(self.player_id, self.player_speed(), now, cost):
+                return ActionError(RATE_LIMITED, "You are not ready to act yet.")
+        return None
+
+    def action_taken(self, command):
+        # Fire only aims; the shot itself is the action (see fire_weapon)
+        if command.name != "fire":
+            self.player_acted(command.name)
+
+    def player_acted(self, action: str):
+        """A world action ends the player's turn, or spends their energy."""
+        # Doing anything else takes the line out of the water
+        if action in TURN_ACTIONS and action != "fish" and self.fishing.stop():
+            self.log("You reel in your line.", (150, 150, 150))
+        if self.turns.player_turn and action in TURN_ACTIONS:
+            self.turns.advance()
+        cost = self.energy.cost(action)
+        if self.turns.mode == ENERGY and cost:
+            now = self.tick * self.fixed_timestep
+            self.energy.spend(self.player_id, self.player_speed(), now, cost)
+
+    def player_speed(self) -> float:
+        from entities.components import Player
+
+        player = self.entity_manager.get_component(self.player_id, Player)
+        return player.speed if player else 1.0
+
+    def monster_ready(self, eid: int, monster) -> bool:
+        """Under energy scheduling, whether a monster may think this tick; its
+        turn is paid for if so."""
+        now = self.tick * self.fixed_timestep
+        if not self.energy.ready(eid, monster.speed, now):
+            return False
+        self.energy.spend(eid, monster.speed, now)
+        return True
+
+    def register_commands(self):
+        """Register every player action and request the game understands."""
+        action = self.commands.action
+        in_world = ("alive", "playing", "unrestricted")
+        action(
+            "move", self.move_player, "Step one tile in a direction", ("dx", "dy"),
+            requires=in_world, target=("dx", "dy"), reach=1, relative=True,
+        )
+        action(
+            "move_to", self.move_to, "Walk to a map position", ("x", "y"),
+            requires=in_world, target=("x", "y"), reach=CONFIG.max_path_length,
+        )
+        action(
+            "action_menu", self.interact,
+            "Talk, trade or attack next to you; otherwise swap weapons", requires=in_world,
+        )
+        action(
+            "pickup", self.pickup_item, "Pick up the item you are standing on",
+            requires=in_world,
+        )
+        action("inventory", self.open_inventory, "Open your inventory")
+        action("stats", self.open_stats, "Show your character sheet")
+        action("help", self.open_help, "Show the controls")
+        action(
+            "fire", self.start_targeting, "Aim an attack in a direction", requires=in_world
+        )
+        action(
+            "cast", self.handle_skill_cast, "Cast one of your skills", ("skill",),
+            requires=in_world,
+        )
+        action("wait", self.wait_turn, "Do nothing for a moment", requires=in_world)
+        action(
+            "travel", self.open_travel_menu, "Travel from a waypoint", requires=in_world
+        )
+        action("zone_info", self.show_zone_info, "Describe the current region")
+        action("world_map", self.open_world_map, "Show the explored world")
+        action("landmarks", self.show_landmarks, "List the nearest discovered landmarks")
+        action("reputation", self.show_reputation, "Show your standing with each faction")
+        action("wallet", self.show_wallet, "Show your gold and other currencies")
+        action(
+            "prices", self.describe_market, "Show what is cheap and dear in this town",
+            requires=in_world,
+        )
+        action(
+            "stealth", self.toggle_stealth, "Start or stop sneaking", requires=in_world
+        )
+        action("memorial", self.show_memorial, "Show fallen hardcore characters")
+        action("recall", self.recall, "Teleport to your home point", requires=in_world)
+        action("fish", self.start_fishing, "Cast a line into water beside you", requires=in_world)
+        action("reel", self.reel_in, "Reel in your fishing line", requires=in_world)
+        action(
+            "craft", self.craft, "Make an item from a recipe", ("recipe",),
+            requires=in_world,
+        )
+        action(
+            "brew", self.brew, "Mix reagents into a potion", ("reagents",),
+            requires=in_world,
+        )
+        action(
+            "stash_deposit", self.stash_deposit, "Put an item in the account stash",
+            ("item",), requires=in_world,
+        )
+        action(
+            "stash_withdraw", self.stash_withdraw, "Take an item out of the stash",
+            ("item",), requires=in_world,
+        )
+        action("worlds", self.open_world_select, "Choose a world to play in")
+        action("skip", self.skip_cutscene, "Skip the cutscene that is playing")
+        action("quit", self.quit, "Save and quit")
+
+        request = self.commands.request
+        request("help", self.commands.help, "List actions, requests and message types")
+        request(
+            "map_request", self.map_request, "Explored world map downsampled by scale",
+            ("scale",), response="map_response",
+        )
+        request("zone_info", self.zone_info_message, "Rules of the player's current region")
+        request(
+            "poi_query", self.poi_message, "Search discovered points of interest",
+            ("text",), response="poi_results",
+        )
+        request(
+            "gamble", self.gamble,
+            "Play dice or cards for gold with a townsperson beside you",
+            ("game", "stake"), response="gamble_result", requires=in_world,
+            changes_state=True,
+        )
+        request("season_info", self.season_info, "Current season, time left and ladder")
+        request("memorial", lambda: self.memorial.leaderboard(), "Best fallen hardcore characters")
+        request("who", self.who, "Connected telnet sessions and who is AFK")
+        request(
+            "turn_order", self.turn_order,
+            "The turn-based fight in progress, in initiative order, and whose turn it is",
+        )
+        request(
+            "netstat", self.netstat,
+            "Bytes, lines by command and queue backlog per telnet connection (admin)",
+        )
+        request(
+            "kick", self.kick,
+            "Disconnect a telnet session by user or address, telling it why; "
+            "ban_minutes also bans its address (admin)",
+            ("player",), response="kicked", optional=("reason", "ban_minutes"),
+            changes_state=True,
+        )
+        request("describe", self.describe_message, "Your surroundings, in words")
+        request("settings", self.settings_message, "Your saved settings")
+        request(
+            "set_settings", self.set_settings,
+            "Change settings (chat_filter, auto_loot, locale, colorblind, "
+            "update_rate); if any value is refused none change",
+            ("settings",), response="settings", changes_state=True,
+        )
+        request(
+            "describe_mode", self.set_describe_mode,
+            "Turn spoken descriptions in updates on or off, for screen readers",
+            ("enabled",), changes_state=True,
+        )
+        request("channels", self.channel_list, "Chat channels and which you are on")
+        request(
+            "channel_join", self.channel_join, "Join a chat channel",
+            ("name",), response="channels", optional=("password",), changes_state=True,
+        )
+        request(
+            "channel_leave", self.channel_leave,
+            "Leave a chat channel; an owner leaving closes it", ("name",),
+            response="channels", changes_state=True,
+        )
+        request(
+            "channel_create", self.channel_create,
+            "Make a private chat channel, with an optional password",
+            ("name",), response="channels", optional=("password",), changes_state=True,
+        )
+        request(
+            "channel_say", self.channel_say, "Speak on a chat channel you are on",
+            ("name", "text"), response="channel_message", changes_state=True,
+        )
+        request(
+            "channel_kick", self.channel_kick,
+            "Remove a member from a channel you own or moderate",
+            ("name", "player"), response="channels", changes_state=True,
+        )
+        request(
+            "channel_moderator", self.channel_moderator,
+            "Make a member of your channel a moderator", ("name", "player"),
+            response="channels", changes_state=True,
+        )
+        request(
+            "recipes", self.recipes_message, "Recipes and what your skill would make"
+        )
+        request("alchemy", self.alchemy_message, "Brews you know and mixtures tried")
+        request(
+            "inventory", self.inventory_message, "Worn gear and pack, and what binds"
+        )
+        request("stash", self.stash_message, "Items shared by all your characters")
+        request("wallet", self.wallet_message, "Gold and every other currency you hold")
+        request(
+            "exchange", self.exchange_coins,
+            "Change amount of one coin (copper, silver, gold) into another",
+            ("coin", "into", "amount"), response="wallet", requires=in_world,
+            changes_state=True,
+        )
+        request(
+            "grant_currency", self.grant_currency_request,
+            "Give a player some of a currency (admin)", ("currency", "amount"),
+            response="wallet", optional=("player",), changes_state=True,
+        )
+        request("market", self.market_message, "Prices in the town you are in")
+        request("buyback", self.buyback_message, "Recent vendor sales you can buy back")
+        request("home", self.home_info, "Your home point and when recall is ready")
+        request("worlds", self.list_worlds, "Worlds to enter and the one you are in")
+        request(
+            "select_world", self.select_world,
+            "Enter another world, where you last left it", ("name",),
+            response="world_entered", changes_state=True,
+        )
+        request(
+            "sequence", self.sequence_message,
+            "The cutscene playing and its events after seq since", optional=("since",),
+        )
+        request(
+            "move", self.sequenced_move,
+            "Step by dx, dy as the client's move seq; answered with a player_update",
+            ("dx", "dy", "seq"), response="player_update", optional=("client",),
+            changes_state=True,
+        )
+        request(
+            "item_update", self.item_update,
+            "Items on the ground within radius, with who owns them and for how long",
+            optional=("radius",),
+        )
+        request(
+            "player_update", self.player_update,
+            "Authoritative position and the client's last processed move seq",
+            optional=("client",),
+        )
+        request(
+            "ping", self.ping,
+            "Echo sent with the server's time and tick; report your last rtt_ms",
+            ("sent",), response="pong", optional=("rtt_ms",),
+        )
+        request("motd", self.motd, "The message of the day")
+        request(
+            "announcements", self.scheduled_announcements,
+            "Announcements waiting to be broadcast, soonest first",
+        )
+        request(
+            "text_command", self.text_command, "Run a typed command such as 'go north'",
+            ("text",), response="text_response", changes_state=True,
+        )
+        request(
+            "audit_log", self.audit_log,
+            "Search the audit log by action, actor, target or since (admin)",
+            optional=("action", "actor", "target", "since", "limit"),
+        )
+        request(
+            "set_motd", self.set_motd, "Change the message of the day (admin)",
+            ("text",), response="motd", changes_state=True,
+        )
+        request(
+            "announce", self.schedule_announcement,
+            "Broadcast text now, after delay_minutes, and every every_minutes (admin)",
+            ("text",), response="announcement_scheduled",
+            optional=("delay_minutes", "every_minutes"), changes_state=True,
+        )
+        request(
+            "reload_content", self.reload_content,
+            "Reload content definitions and config.toml without a restart (admin)",
+            response="content_reloaded",
+        )
+        request(
+            "schedule_restart", self.schedule_restart,
+            "Restart the server in minutes, warning players first (admin)",
+            ("minutes",), response="restart_scheduled", optional=("reason",),
+            changes_state=True,
+        )
+        request(
+            "cancel_restart", self.cancel_restart,
+            "Call off the scheduled restart (admin)", response="restart_cancelled",
+            changes_state=True,
+        )
+        request(
+            "incidents", self.anticheat_incidents,
+            "Anti-cheat incidents, newest first, optionally for one player (admin)",
+            optional=("player", "limit"),
+        )
+        request(
+            "lift_restriction", self.lift_restriction,
+            "Mark a player's incidents reviewed and lift their restriction (admin)",
+            ("player",), response="restriction_lifted", changes_state=True,
+        )
+        request(
+            "edit_select", self.edit_select,
+            "Select a map region to edit and list its tiles and placements; "
+            "encoding rle sends the tiles run-length encoded (admin)",
+            ("x", "y", "width", "height"), response="edit_region",
+            optional=("encoding",),
+        )
+        request(
+            "edit_tiles", self.edit_tiles,
+            "Paint rows of tile ids or map characters, or an rle grid, from x, y (admin)",
+            ("x", "y", "rows"), response="tiles_set", changes_state=True,
+        )
+        request(
+            "edit_place", self.edit_place,
+            "Place a monster, NPC, item, light or spawner in the region (admin)",
+            ("kind", "subtype", "x", "y"), response="placed", optional=("respawn",),
+            changes_state=True,
+        )
+        request(
+            "edit_remove", self.edit_remove,
+            "Remove what was placed on a tile, optionally of one kind (admin)",
+            ("x", "y"), response="removed", optional=("kind",), changes_state=True,
+        )
+        request(
+            "edit_poi", self.edit_poi,
+            "Add a point of interest in the region (admin)",
+            ("name", "kind", "x", "y"), response="poi_added", optional=("waypoint",),
+            changes_state=True,
+        )
+        request(
+            "edit_save", self.edit_save,
+            "Save map edits with the world (admin)", response="world_saved",
+            changes_state=True,
+        )
+        request(
+            "create_world", self.create_world,
+            "Add a world with its own seed and ruleset (admin)", ("name",),
+            response="world_created", optional=("seed", "ruleset"), changes_state=True,
+        )
+        request(
+            "archive_world", self.archive_world,
+            "Close a world to players, keeping its save (admin)", ("name",),
+            response="world_archived", changes_state=True,
+        )
+        request(
+            "transfer_character", self.transfer_character,
+            "Move a character to another world, renaming it if taken there (admin)",
+            ("username", "source", "target"), response="character_transferred",
+            changes_state=True,
+        )
+        request(
+            "merge_worlds", self.merge_worlds,
+            "Move every character from source into target and archive source (admin)",
+            ("source", "target"), response="worlds_merged", optional=("archive",),
+            changes_state=True,
+        )
+        request(
+            "cancel_announcement", self.cancel_announcement,
+            "Stop a scheduled announcement by its id (admin)", ("id",),
+            response="announcement_cancelled", changes_state=True,
+        )
+        if self.diagnostics:
+            request(
+                "runtime_stats",
+                lambda: dict(
+                    self.diagnostics.runtime_stats(self.tick),
+                    ai_shed=self.ai_system.shed_total,
+                    load=self.view_scaler.status(),
+                    latency=self.latency.summary(),
+                ),
+                "Threads, memory, GC pauses, tick lag and shed AI work (admin)",
+            )
+            request(
+                "profile_start", self.diagnostics.start_profile,
+                "Start profiling the game loop (admin)", response="profile_started",
+            )
+            request(
+                "profile_stop", self.diagnostics.stop_profile,
+                "Stop profiling and list the costliest functions (admin)",
+                response="profile_result",
+            )
+
+    def handle_request(self, message: dict) -> dict:
+        """Answer a client request message by its type.
+
+        A state-changing request with an idempotency_key runs at most once
+        per key (see systems.idempotency); actions are only covered when
+        they arrive as a keyed text_command.
+        """
+        if self.recorder:
+            self.recorder.request(self.tick, message)
+        key = message.get(KEY_FIELD) if isinstance(message, dict) else None
+        if key is None or not self.commands.changes_state(message.get("type")):
+            return self.run_request(message)
+
+        # A retried message gets its first reply instead of running twice
+        try:
+            kept = self.idempotency.replay(key, message)
+        except IdempotencyError as e:
+            return {"type": "error", "error": str(e)}
+        if kept is not None:
+            return kept
+        reply = self.run_request(message)
+        self.idempotency.remember(key, message, reply)
+        return reply
+
+    def run_request(self, message: dict) -> dict:
+        """Run a request that is not a retry, auditing it if it is an admin request.
+
+        The entry is written once the request has been checked, naming who
+        sent it and whether their role let it through.
+        """
+        reply = self.commands.handle_request(message)
+        if self.audit and isinstance(message, dict) and message.get("type") in ADMIN_REQUESTS:
+            args = {key: value for key, value in message.items() if key != "type"}
+            outcome = "refused" if reply.get("code") == NOT_AUTHORIZED else "allowed"
+            self.audit.record(
+                "admin_request", self.caller, message["type"], args=args, outcome=outcome
+            )
+        return reply
+
+    def audit_log(
+        self,
+        action: Optional[str] = None,
+        actor: Optional[str] = None,
+        target: Optional[str] = None,
+        since: Optional[float] = None,
+        limit: int = 100,
+    ) -> dict:
+        """Audit entries matching the filters, newest first."""
+        if self.audit is None:
+            return {"type": "error", "error": "The audit log is not enabled"}
+        entries = self.audit.query(
+            action, actor, target, None if since is None else float(since), int(limit)
+        )
+        return {"type": "audit_log", "entries": entries}
+
+    def motd(self) -> dict:
+        return {"type": "motd", "text": self.announcements.motd}
+
+    def set_motd(self, text: str) -> dict:
+        self.announcements.set_motd(str(text))
+        return self.motd()
+
+    def scheduled_announcements(self) -> dict:
+        return {"type": "announcements", "scheduled": self.announcements.pending()}
+
+    def schedule_announcement(
+        self, text: str, delay_minutes: float = 0, every_minutes: float = 0
+    ) -> dict:
+        """Queue an announcement; with no delay it goes out on the next tick."""
+        announcement_id = self.announcements.schedule(
+            str(text), float(delay_minutes) * 60, float(every_minutes) * 60
+        )
+        return {"type": "announcement_scheduled", "id": announcement_id}
+
+    def cancel_announcement(self, id: int) -> dict:
+        if not self.announcements.cancel(int(id)):
+            return {"type": "error", "error": f"No announcement {id}"}
+        return {"type": "announcement_cancelled", "id": int(id)}
+
+    def make_anticheat(self, path: Optional[str]) -> AntiCheat:
+        settings = CONFIG.anticheat
+        return AntiCheat(
+            path,
+            teleport_tiles=settings.get("teleport_tiles", 2),
+            max_attacks_per_second=settings.get("max_attacks_per_second", 20),
+            restrict_after=settings.get("restrict_after", 3),
+            audit=self.audit,
+        )
+
+    def anticheat_incidents(self, player: Optional[str] = None, limit: int = 50) -> dict:
+        if not self.anticheat:
+            return {"type": "error", "error": "Anti-cheat is disabled"}
+        found = self.anticheat.incidents(None if player is None else str(player), int(limit))
+        return {"type": "incidents", "incidents": found}
+
+    def lift_restriction(self, player: str) -> dict:
+        if not self.anticheat:
+            return {"type": "error", "error": "Anti-cheat is disabled"}
+        lifted = self.anticheat.lift(str(player))
+        return {"type": "restriction_lifted", "player": str(player), "was_restricted": lifted}
+
+    def editing(self, edit: Callable[[MapEditor], dict]) -> dict:
+        """Run an edit, turning its refusal into an error reply."""
+        if self.editor is None:
+            return {"type": "error", "error": "Map editing needs the persistent world"}
+        try:
+            return edit(self.editor)
+        except EditorError as e:
+            return {"type": "error", "error": str(e)}
+
+    def edit_select(
+        self, x: int, y: int, width: int, height: int, encoding: str = "raw"
+    ) -> dict:
+        return self.editing(
+            lambda editor: {
+                "type": "edit_region",
+                **editor.select(x, y, width, height, encoding),
+            }
+        )
+
+    def edit_tiles(self, x: int, y: int, rows: list) -> dict:
+        def paint(editor: MapEditor) -> dict:
+            changed = editor.set_tiles(x, y, rows)
+            if changed:
+                self.update_fov(force=True)
+            return {"type": "tiles_set", "changed": changed}
+
+        return self.editing(paint)
+
+    def edit_place(
+        self, kind: str, subtype: str, x: int, y: int, respawn: Optional[float] = None
+    ) -> dict:
+        def place(editor: MapEditor) -> dict:
+            entry = editor.place(kind, subtype, x, y, respawn)
+            eid = self.spawn_preplaced(entry)
+            return {"type": "placed", "entry": entry, "entity": eid}
+
+        return self.editing(place)
+
+    def edit_remove(self, x: int, y: int, kind: Optional[str] = None) -> dict:
+        def remove(editor: MapEditor) -> dict:
+            removed = editor.remove(x, y, kind)
+            live = [self.placed_eids.pop(id(entry), None) for entry in removed]
+            live += [spawner["eid"] for spawner in self.spawners.forget(removed)]
+            for eid in live:
+                # Only what still stands there; a picked-up item stays with its owner
+                pos = self.entity_manager.get_component(eid, Position)
+                if pos and (pos.x, pos.y) == (x, y):
+                    self.entity_manager.destroy_entity(eid)
+            return {"type": "removed", "entries": removed}
+
+        return self.editing(remove)
+
+    def edit_poi(
+        self, name: str, kind: str, x: int, y: int, waypoint: Optional[str] = None
+    ) -> dict:
+        return self.editing(
+            lambda editor: {
+                "type": "poi_added", "poi": editor.add_poi(name, kind, x, y, waypoint)
+            }
+        )
+
+    def edit_save(self) -> dict:
+        if self.replay:
+            return {"type": "error", "error": "The world is not saved during playback"}
+        return self.editing(
+            lambda editor: {"type": "world_saved", "saved": editor.save()}
+        )
+
+    def create_world(
+        self, name: str, seed: Optional[int] = None, ruleset: str = "standard"
+    ) -> dict:
+        """Register a world; it is generated when someone first enters it."""
+        seed = None if seed is None else int(seed)
+        world = self.worlds.create(str(name), seed, ruleset)
+        return {"type": "world_created", "world": world.to_dict()}
+
+    def archive_world(self, name: str) -> dict:
+        """Close a world to players; the one being played in must be left first."""
+        if name == self.world_name:
+            raise WorldError("that world is being played in; enter another first")
+        world = self.worlds.archive(str(name))
+        return {"type": "world_archived", "world": world.to_dict()}
+
+    def transfer_character(self, username: str, source: str, target: str) -> dict:
+        """Move one character's place to another world (admin)."""
+        names = self.move_characters(str(source), str(target), str(username))
+        return {
+            "type": "character_transferred",
+            "username": names[username],
+            "renamed": names[username] != username,
+            "source": source,
+            "target": target,
+        }
+
+    def merge_worlds(self, source: str, target: str, archive: bool = True) -> dict:
+        """Move a world's whole player base into another, then archive it (admin)."""
+        names = self.move_characters(str(source), str(target))
+        if archive and not self.worlds.find(source).archived:
+            self.worlds.archive(source)
+        return {
+            "type": "worlds_merged",
+            "source": source,
+            "target": target,
+            "moved": len(names),
+            "renamed": {old: new for old, new in names.items() if old != new},
+            "archived": bool(archive),
+        }
+
+    def move_characters(
+        self, source: str, target: str, username: Optional[str] = None
+    ) -> Dict[str, str]:
+        """Move one character, or all of them, between worlds; returns old -> new names.
+
+        Names taken in the target get the source world's name appended, and a
+        character keeps their spot only between worlds sharing a seed.
+        """
+        from world.persistent_world import load_named_world
+
+        source_info, target_info = self.worlds.find(source), self.worlds.get(target)
+        if source_info.name == target_info.name:
+            raise TransferError("source and target are the same world")
+        playing = username in (None, self.player_name())
+        if source_info.name == self.world_name and playing:
+            raise TransferError(f"{source} is being played in; leave it first")
+        source_roster = Roster(self.worlds.roster_file(source_info.name))
+        target_roster = Roster(self.worlds.roster_file(target_info.name))
+        if username is not None and source_roster.get(username) is None:
+            raise TransferError(f"no character named {username!r} in {source}")
+
+        world = load_named_world(
+            target_info.name, target_info.seed, self.worlds.world_file(target_info.name)
+        )
+        if target_info.name == self.world_name:
+            levels = self.levels
+        else:
+            levels = {0: world.get_full_game_map(), **world.get_cave_game_maps()}
+        same_map = source_info.seed == target_info.seed
+        start = nearest_walkable(levels[0], *self.world_start(world))
+
+        def walkable(x: int, y: int, z: int) -> bool:
+            return z in levels and levels[z].is_walkable(x, y)
+
+        def remap(record: dict) -> Tuple[int, int, int]:
+            return remap_position(record, same_map, start, walkable)
+
+        if username is None:
+            names = merge(source_roster, target_roster, source_info.name, remap)
+        else:
+            names = {
+                username: transfer(
+                    source_roster, target_roster, username, source_info.name, remap
+                )
+            }
+        # Target first: a failure in between leaves a character in both, not neither
+        target_roster.save()
+        source_roster.save()
+        return names
+
+    def content_exists(self, kind: str, subtype: str) -> bool:
+        """Whether content of a placeable kind is defined, for the map editor."""
+        from data.loader import DATA_LOADER
+
+        if kind == "monster":
+            return DATA_LOADER.get_monster_data(subtype) is not None
+        if kind == "item":
+            return DATA_LOADER.get_item_data(subtype) is not None
+        return subtype in DATA_LOADER.load_json("lights")
+
+    def player_name(self) -> str:
+        from entities.components import Name
+
+        name = self.entity_manager.get_component(self.player_id, Name)
+        return name.value if name else "Player"
+
+    def check_anticheat(self):
+        """Compare the player with what this tick's events explain."""
+        from entities.components import Equipment, Inventory
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        if not pos or not inventory:
+            return
+        owned = list(inventory.items)
+        equipment = self.entity_manager.get_component(self.player_id, Equipment)
+        if equipment:
+            slots = (equipment.weapon, equipment.head, equipment.body, equipment.legs, equipment.shield)
+            owned.extend(item for item in slots if item is not None)
+        incidents = self.anticheat.check(
+            self.player_name(), self.player_id, (pos.x, pos.y), inventory.gold, owned
+        )
+        self.report_incidents(incidents)
+
+    def report_incidents(self, incidents: list):
+        if any(incident.get("restricted") for incident in incidents):
+            self.log(RESTRICTED_MESSAGE, (255, 80, 80))
+
+    def server_moved_player(self, x: int, y: int):
+        """Tell the anti-cheat about a teleport the server itself made."""
+        if self.anticheat:
+            self.anticheat.moved(self.player_name(), x, y)
+
+    def transaction_committed(self, transaction: ItemTransaction):
+        """Bind what the player picked up and credit the anti-cheat with it."""
+        from entities.components import Inventory
+
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        if inventory is None:
+            return
+        for op, container, value in transaction.ops:
+            if op == "add_item" and container is inventory:
+                self.bind_item(value, BIND_ON_PICKUP)
+        if not self.anticheat:
+            return
+        gold, items = 0, []
+        for op, container, value in transaction.ops:
+            if container is not inventory:
+                continue
+            if op == "add_gold":
+                gold += value
+            elif op == "remove_gold":
+                gold -= value
+            elif op == "add_item":
+                items.append(value)
+        self.anticheat.credited(self.player_name(), gold, items)
+
+    def announce(self, text: str):
+        """Show an announcement in the log and on every telnet session.
+
+        Announcements run on wall-clock time, not game state, so like Discord
+        chat they bypass log() and stay out of replays.
+        """
+        self.message_log.append((f"[Announcement] {text}", ANNOUNCEMENT_COLOR))
+        if self.gateway:
+            self.gateway.broadcast(f"Announcement: {text}")
+
+    def reload_content(self) -> dict:
+        """Re-read items, monsters, behaviors and the rest, plus config.toml.
+
+        Everything is parsed and validated before anything is swapped in; if
+        any of it is bad the game carries on with what it had. New spawns and
+        config lookups see the new values at once; settings a system copied
+        when it was built keep their old values until a restart.
+        """
+        from data.loader import DATA_LOADER
+
+        def check(fresh):
+            build_trees(fresh.load_json("behaviors"))
+
+        try:
+            config = GameConfig.read_toml("config.toml")
+            files = DATA_LOADER.reload(check)
+        except (OSError, ValueError) as e:
+            return {"type": "error", "error": f"Reload failed: {e}"}
+        CONFIG.replace_with(config)
+        self.commands.validator.cadence = CONFIG.action_cadence
+        self.ai_system.trees = build_trees(DATA_LOADER.load_json("behaviors"))
+        self.boss_system.scripts = DATA_LOADER.load_json("bosses")
+        self.schedule_system.routines = DATA_LOADER.load_json("schedules")
+        return {"type": "content_reloaded", "files": files}
+
+    def run_content_script(self, steps: list, source: Optional[int] = None) -> bool:
+        """Run an on_talk or on_use script for the player; False if it is broken."""
+        try:
+            validate_script(steps)
+        except ScriptError:
+            log_exception("content script")
+            self.log("Nothing happens.", (150, 150, 150))
+            return False
+        run_script(steps, ScriptHost(self, self.player_id, source))
+        return True
+
+    def play_cutscene(self, name: str, subject: Optional[int] = None) -> bool:
+        """Start a cutscene from cutscenes.json; input is locked until it ends."""
+        from data.loader import DATA_LOADER
+
+        if self.cutscene:
+            return False
+        try:
+            entry = DATA_LOADER.load_json("cutscenes").get(name)
+            validate_cutscene(entry, name)
+        except (OSError, CutsceneError):
+            log_exception(f"cutscene {name}")
+            return False
+        self.cutscene = Cutscene(name, entry, subject)
+        self.auto_path.clear()
+        self.game_state = "CUTSCENE"
+        hint = " (skip to skip)" if self.cutscene.skippable else ""
+        self.commands.validator.lock(f"Wait for the scene to finish{hint}.", ("skip",))
+        self.cutscene_log.add("start", name=name, skippable=self.cutscene.skippable)
+        return True
+
+    def update_cutscene(self, dt: float):
+        for step in self.cutscene.advance(dt):
+            self.run_cutscene_step(step)
+        if self.cutscene.finished:
+            self.end_cutscene(skipped=False)
+
+    def run_cutscene_step(self, step: dict):
+        from entities.components import Monster, Name
+
+        def name_of(eid):
+            name = self.entity_manager.get_component(eid, Name)
+            monster = self.entity_manager.get_component(eid, Monster)
+            return name.value if name else monster.name if monster else "Someone"
+
+        kind = step["do"]
+        entity = resolve_target(step, self.cutscene.subject, self.player_id)
+        pos = self.entity_manager.get_component(entity, Position) if entity else None
+        if kind == "focus":
+            focus = (pos.x, pos.y) if pos else (step.get("x"), step.get("y"))
+            if None not in focus:
+                self.cutscene.focus = focus
+                self.cutscene_log.add("focus", x=focus[0], y=focus[1])
+        elif kind == "dialogue":
+            speaker, text = step.get("speaker", ""), step["text"]
+            for key, eid in (("{subject}", self.cutscene.subject), ("{player}", self.player_id)):
+                speaker = speaker.replace(key, name_of(eid))
+                text = text.replace(key, name_of(eid))
+            self.log(f"{speaker}: {text}" if speaker else text, DIALOGUE_COLOR)
+            self.cutscene_log.add("dialogue", speaker=speaker, text=text)
+        elif kind == "move" and pos:
+            x, y = pos.x + step["dx"], pos.y + step["dy"]
+            if self.game_map.is_walkable(x, y) and not self.spatial_index.is_occupied(x, y):
+                pos.x, pos.y = x, y
+                self.entity_manager.notify_component_change(entity, Position)
+                if entity == self.player_id:
+                    self.server_moved_player(x, y)
+                self.cutscene_log.add("move", eid=entity, x=x, y=y)
+
+    def end_cutscene(self, skipped: bool):
+        self.cutscene_log.add("end", name=self.cutscene.name, skipped=skipped)
+        self.cutscene = None
+        self.commands.validator.unlock()
+        if self.game_state == "CUTSCENE":
+            self.game_state = "PLAYING"
+
+    def skip_cutscene(self):
+        if not self.cutscene:
+            self.log("There is nothing to skip.", (150, 150, 150))
+        elif not self.cutscene.skippable:
+            self.log("This scene can't be skipped.", (150, 150, 150))
+        else:
+            # Whatever moved in the scene still ends up where it would have
+            for step in self.cutscene.skip():
+                self.run_cutscene_step(step)
+            self.end_cutscene(skipped=True)
+
+    def sequence_message(self, since: int = 0) -> dict:
+        """The cutscene playing (if any) and the events streamed after since."""
+        cutscene = self.cutscene
+        return {
+            "type": "sequence",
+            "active": cutscene is not None,
+            "name": cutscene.name if cutscene else None,
+            "skippable": cutscene.skippable if cutscene else False,
+            "focus": cutscene.focus if cutscene else None,
+            "events": self.cutscene_log.since(int(since)),
+        }
+
+    def request_reload(self):
+        self.reload_requested = True
+
+    def schedule_restart(self, minutes: float, reason: str = "") -> dict:
+        """Count down to a restart, replacing any already scheduled."""
+        minutes = float(minutes)
+        if minutes < 0:
+            raise ValueError("minutes must not be negative")
+        self.restart = self.restart_countdown(time.time() + minutes * 60, str(reason))
+        self.announce(self.restart.message())
+        return {
+            "type": "restart_scheduled",
+            "seconds": round(self.restart.seconds_left()),
+            "reason": self.restart.reason,
+        }
+
+    @staticmethod
+    def restart_countdown(deadline: float, reason: str) -> ScheduledRestart:
+        return ScheduledRestart(
+            deadline,
+            reason,
+            CONFIG.maintenance.get("warnings", WARNINGS),
+            CONFIG.maintenance.get("close_logins_seconds", 60),
+        )
+
+    def cancel_restart(self) -> dict:
+        if self.restart is None:
+            return {"type": "error", "error": "No restart is scheduled"}
+        self.restart = None
+        if self.gateway:
+            self.gateway.closed = ""
+        self.announce("The scheduled restart has been called off.")
+        return {"type": "restart_cancelled"}
+
+    def check_restart(self):
+        """Warn players, close logins and finally restart, as the countdown runs."""
+        if self.restart.expired:
+            self.announce("The server is restarting now.")
+            self.shutdown("The server is restarting for maintenance.")
+            return
+        for text in self.restart.warnings_due():
+            self.announce(text)
+        if self.gateway and self.restart.logins_closed:
+            self.gateway.closed = "The server is about to restart. Try again shortly."
+
+    def text_command(self, text: str) -> dict:
+        """Run a MUD-style command line; the reply carries everything it logged."""
+        self.log_capture = []
+        try:
+            self.run_text_command(str(text))
+        finally:
+            lines, self.log_capture = self.log_capture, None
+        return {"type": "text_response", "command": text, "lines": lines}
+
+    def gateway_authenticator(self):
+        """Checks gateway sign-in tokens, or None when no secret is set (open access).
+
+        The signing secret comes from the environment so it stays out of config.
+        """
+        secret = os.environ.get(CONFIG.gateway.get("token_secret_env", ""), "")
+        if not secret:
+            return None
+        issuer = CONFIG.gateway.get("token_issuer") or None
+        audience = CONFIG.gateway.get("token_audience") or None
+        return lambda token: verify_token(token, secret, issuer, audience)["sub"]
+
+    def gateway_reply(self, line: str) -> str:
+        """Run a line typed by a telnet client and answer with what it logged.
+
+        The map view is added by the gateway, once per tick (see ansi_view).
+        """
+        if not line:
+            return ""
+        # Telnet players never get the local console's admin role
+        self.caller_roles = GATEWAY_ROLES
+        self.caller = (self.gateway.caller if self.gateway else "") or "telnet"
+        try:
+            reply = self.handle_request({"type": "text_command", "text": line})
+        finally:
+            self.caller_roles = LOCAL_ROLES
+            self.caller = LOCAL_CALLER
+        if reply.get("fatal"):
+            raise SessionError(reply["error"])
+        return "\n".join(reply.get("lines", [reply.get("error", "")]))
+
+    def ansi_view(self) -> str:
+        """The map around the player as ANSI text, for terminal clients."""
+        from entities.components import Name, Render
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if pos is None or self.game_map is None:
+            return ""
+        # Players may ask for the map less often, or never (see systems.settings)
+        rate, now = self.settings["update_rate"], time.monotonic()
+        if rate == "text" or (rate == "low" and now - self.view_sent_at < LOW_RATE_SECONDS):
+            return ""
+        self.view_sent_at = now
+        # Under load the view shrinks (see systems.load_scaling)
+        width = self.view_scaler.scaled(CONFIG.gateway.get("view_width", 40), MIN_VIEW)
+        height = self.view_scaler.scaled(CONFIG.gateway.get("view_height", 15), MIN_VIEW)
+        # A cutscene may point the camera elsewhere
+        cx, cy = (self.cutscene and self.cutscene.focus) or (pos.x, pos.y)
+
+        glyphs = {}
+        nearby = self.spatial_index.query_radius(cx, cy, max(width, height))
+        for eid in reversed(nearby):
+            render = self.entity_manager.get_component(eid, Render)
+            e_pos = self.entity_manager.get_component(eid, Position)
+            if render is None or e_pos is None or e_pos.z != pos.z:
+                continue  # Only what is on the player's level is drawn
+            other = self.spatial_index.entity_to_pos[eid]
+            name = self.entity_manager.get_component(eid, Name)
+            char = terminal_glyph(render.char, name.value if name else None)
+            glyphs[other] = (char, render.fg_color)
+        glyphs[(pos.x, pos.y)] = ("@", (255, 255, 255))
+        recolor = self.settings.color if self.settings["colorblind"] != "none" else None
+        return render_ansi(self.game_map, cx, cy, width, height, glyphs, recolor)
+
+    def run_text_command(self, text: str):
+        """Carry out one parsed line of text input."""
+        command = text_commands.parse(text)
+        if command is None:
+            return
+        verb, args = command.verb, command.args
+
+        if verb == "go":
+            direction = text_commands.DIRECTIONS.get(args[0]) if args else None
+            if direction is None:
+                self.log("Go where? (north, south, east, west, ne, nw, se, sw)", (150, 150, 150))
+            else:
+                self.commands.run_action("move", *direction)
+        elif verb in ("attack", "talk"):
+            self.text_interact(verb, args)
+        elif verb == "say":
+            if command.text:
+                sender = self.player_name()
+                said = self.plugins.chat(self, sender, command.text)
+                if said is None:
+                    self.log("Your message was not sent.", (150, 150, 150))
+                    return
+                self.log(f"You say: '{said}'", (255, 255, 255))
+                self.events.publish(ChatSent(sender, said))
+            else:
+                self.log("Say what?", (150, 150, 150))
+        elif verb in ("chat", "join", "leave", "channels"):
+            self.text_channel(verb, args, command.text)
+        elif verb == "look":
+            self.describe_surroundings()
+        elif verb == "describe":
+            if args and args[0] in ("on", "off"):
+                self.set_describe_mode(args[0] == "on")
+                state = "on" if self.narrator.enabled else "off"
+                self.log(f"Describe mode is {state}.", DESCRIBE_COLOR)
+            if not args or self.narrator.enabled:
+                for line in self.surroundings():
+                    self.log(line, DESCRIBE_COLOR)
+        elif verb == "who":
+            self.describe_sessions()
+        elif verb == "netstat":
+            self.describe_netstat()
+        elif verb == "settings":
+            self.describe_settings()
+        elif verb == "set":
+            self.text_set(command.text)
+        elif verb == "dismiss":
+            self.dismiss_summons()
+        elif verb == "motd":
+            motd = self.announcements.motd
+            self.log(motd or "There is no message of the day.", ANNOUNCEMENT_COLOR)
+        elif verb == "cast":
+            if args and args[0].isdigit():
+                self.commands.run_action("cast", int(args[0]))
+            else:
+                self.log("Cast which skill? (cast 1, cast 2, ...)", (150, 150, 150))
+        elif verb == "brew":
+            if len(args) >= 2:
+                self.commands.run_action("brew", args)
+            else:
+                self.log("Brew what? (brew healing_herb healing_herb)", (150, 150, 150))
+        elif verb == "gamble":
+            if len(args) >= 2 and args[1].isdigit():
+                reply = self.commands.handle_request(
+                    {"type": "gamble", "game": args[0], "stake": int(args[1])}
+                )
+                if reply["type"] == "error":
+                    self.log(reply["error"], (255, 100, 100))
+            else:
+                self.log("Play what, for how much? (gamble dice 20)", (150, 150, 150))
+        elif verb == "help":
+            for entry in self.commands.help()["actions"]:
+                if not entry["args"]:
+                    self.log(f"{entry['name']}: {entry['description']}", (200, 200, 255))
+            self.log(
+                "Also: go <dir>, attack <target>, talk <target>, say <text>, look, "
+                "describe [on|off], chat <channel> <text>, join <channel>, "
+                "leave <channel>, channels, "
+                "cast <n>, brew <reagents>, "
+                "gamble <dice|cards> <gold>, who, motd, "
+                "settings, set <setting> <value>",
+                (200, 200, 255),
+            )
+            if self.plugins.text_commands:
+                verbs = ", ".join(sorted(self.plugins.text_commands))
+                self.log(f"Added by plugins: {verbs}", (200, 200, 255))
+        elif verb in self.commands.actions and not self.commands.actions[verb].args:
+            self.commands.run_action(verb)
+        elif self.plugins.run_text_command(self, verb, args):
+            pass
+        else:
+            self.log(f"Unknown command: {text.split()[0]}. Type 'help' for a list.", (150, 150, 150))
+
+    def channel_list(self) -> dict:
+        channels = self.channels.listing(self.player_name())
+        return {"type": "channels", "channels": channels}
+
+    def channel_change(self, change: Callable[[], Any]) -> dict:
+        """Make a change to the channels; answered with the channel list."""
+        try:
+            change()
+        except ChannelError as e:
+            return {"type": "error", "error": str(e)}
+        return self.channel_list()
+
+    def channel_join(self, name: str, password: str = "") -> dict:
+        return self.channel_change(
+            lambda: self.channels.join(name, self.player_name(), password)
+        )
+
+    def channel_leave(self, name: str) -> dict:
+        return self.channel_change(
+            lambda: self.channels.leave(name, self.player_name())
+        )
+
+    def channel_create(self, name: str, password: str = "") -> dict:
+        return self.channel_change(
+            lambda: self.channels.create(name, self.player_name(), password)
+        )
+
+    def channel_kick(self, name: str, player: str) -> dict:
+        return self.channel_change(
+            lambda: self.channels.kick(name, self.player_name(), str(player))
+        )
+
+    def channel_moderator(self, name: str, player: str) -> dict:
+        return self.channel_change(
+            lambda: self.channels.add_moderator(name, self.player_name(), str(player))
+        )
+
+    def channel_say(self, name: str, text: str) -> dict:
+        """Speak on a channel; only its members may."""
+        sender = self.player_name()
+        try:
+            channel = self.channels.route(name, sender)
+        except ChannelError as e:
+            return {"type": "error", "error": str(e)}
+        said = self.plugins.chat(self, sender, str(text))
+        if said is None:
+            return {"type": "error", "error": "Your message was not sent."}
+        self.log(f"[{channel.name}] {sender}: {said}", CHANNEL_COLOR)
+        self.events.publish(ChatSent(sender, said, channel.name))
+        return {
+            "type": "channel_message",
+            "channel": channel.name,
+            "sender": sender,
+            "text": said,
+        }
+
+    def text_channel(self, verb: str, args: List[str], text: str):
+        """Run the chat, join, leave and channels text commands."""
+        if verb == "channels":
+            for entry in self.channel_list()["channels"]:
+                mark = "*" if entry["joined"] else " "
+                lock = " (private)" if entry["private"] else ""
+                self.log(f"{mark} {entry['name']}{lock}", CHANNEL_COLOR)
+            return
+        if not args:
+            self.log(f"{verb.title()} which channel?", (150, 150, 150))
+            return
+        if verb == "chat":
+            words = text.split(None, 1)
+            if len(words) < 2:
+                self.log("Say what? (chat trade selling iron ore)", (150, 150, 150))
+                return
+            reply = self.channel_say(words[0], words[1])
+        elif verb == "join":
+            # Passwords are case-sensitive, so they are read as typed
+            words = text.split()
+            reply = self.channel_join(args[0], words[1] if len(words) > 1 else "")
+            if reply["type"] != "error":
+                self.log(f"You join {args[0].lower()}.", CHANNEL_COLOR)
+        else:
+            reply = self.channel_leave(args[0])
+            if reply["type"] != "error":
+                self.log(f"You leave {args[0].lower()}.", CHANNEL_COLOR)
+        if reply["type"] == "error":
+            self.log(reply["error"], (255, 100, 100))
+
+    def who(self) -> dict:
+        """Telnet sessions connected to the game, with idle time, AFK flags and
+        round trips, plus the round trip the player's client last reported."""
+        return {
+            "type": "who",
+            "sessions": self.gateway.who() if self.gateway else [],
+            "rtt_ms": self.latency.rtt_ms(self.player_name()),
+        }
+
+    def kick(self, player: str, reason: str = "", ban_minutes: float = 0) -> dict:
+        if self.gateway is None:
+            return {"type": "error", "error": "The telnet gateway is not running"}
+        ban_minutes = float(ban_minutes)
+        if ban_minutes < 0:
+            raise ValueError("ban_minutes must not be negative")
+        closed = self.gateway.kick(str(player), str(reason), ban_minutes * 60, self.caller)
+        if not closed:
+            return {"type": "error", "error": f"No session for {player}"}
+        return {"type": "kicked", "player": player, "sessions": closed}
+
+    def netstat(self) -> dict:
+        """Traffic per telnet connection, for finding flooding or starved clients."""
+        return {
+            "type": "netstat",
+            "connections": self.gateway.netstat() if self.gateway else [],
+        }
+
+    def sequenced_move(self, dx: int, dy: int, seq: int, client: str = "") -> dict:
+        """Apply a numbered move from a predicting client, once."""
+        applied = False
+        if self.input_sequence.accept(str(client), seq):
+            applied = self.commands.run_action("move", dx, dy)
+        return dict(self.player_update(client), applied=applied)
+
+    def item_update(self, radius: int = 10) -> dict:
+        """Items on the ground around the player and who may take them."""
+        from entities.components import Item
+
+        if isinstance(radius, bool) or not isinstance(radius, int) or radius < 0:
+            raise ValueError("radius must be a whole number of tiles")
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        now = self.tick * self.fixed_timestep
+        items = []
+        for eid in self.entity_manager.get_entities_with_components(Item, Position):
+            at = self.entity_manager.get_component(eid, Position)
+            if not pos or at.z != pos.z:
+                continue
+            if max(abs(at.x - pos.x), abs(at.y - pos.y)) > radius:
+                continue
+            item = self.entity_manager.get_component(eid, Item)
+            entry = {"id": eid, **item_details(self.entity_manager, eid)}
+            entry.update(x=at.x, y=at.y, bind=bind_status(item))
+            if item.crafted_by:
+                entry.update(quality=item.quality, crafted_by=item.crafted_by)
+            entry.update(self.loot_system.ownership(eid, now))
+            entry["can_take"] = self.loot_system.may_take(eid, self.player_id, now)
+            items.append(entry)
+        return {"type": "item_update", "items": items, "tick": self.tick}
+
+    def inventory_message(self) -> dict:
+        """The player's worn gear and pack, with how each item binds."""
+        from entities.components import Equipment, Inventory, Item
+
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        equipment = self.entity_manager.get_component(self.player_id, Equipment)
+        worn = {}
+        if equipment:
+            for slot in ("weapon", "head", "body", "legs", "shield"):
+                if getattr(equipment, slot) is not None:
+                    worn[getattr(equipment, slot)] = slot
+        items = []
+        for eid in list(worn) + list(inventory.items if inventory else []):
+            item = self.entity_manager.get_component(eid, Item)
+            if not item:
+                continue
+            entry = {"id": eid, **item_details(self.entity_manager, eid)}
+            entry.update(bind=bind_status(item), equipped=worn.get(eid))
+            items.append(entry)
+        return {
+            "type": "inventory",
+            "gold": inventory.gold if inventory else 0,
+            "capacity": inventory.capacity if inventory else 0,
+            "items": items,
+        }
+
+    def player_update(self, client: str = "") -> dict:
+        """Where the server has the player, and which of client's moves it has applied."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        update = {
+            "type": "player_update",
+            "ack": self.input_sequence.ack(str(client)),
+            "x": pos.x if pos else None,
+            "y": pos.y if pos else None,
+            "tick": self.tick,
+        }
+        if self.narrator.enabled:
+            update["description"] = self.surroundings()
+        # Sound cues, so clients can switch tracks as the player crosses regions
+        zone = self.zone_info()
+        if zone:
+            update["ambience"], update["music"] = zone["ambience"], zone["music"]
+        if self.turns.active:
+            update["turns"] = self.turn_order()
+        summons = self.summon_list()
+        if summons:
+            update["summons"] = summons
+        if self.fishing.active:
+            update["fishing"] = self.fishing.status(self.tick * self.fixed_timestep)
+        return update
+
+    def turn_order(self) -> dict:
+        """The turn-based fight in progress: who acts in what order, and who is up."""
+        from entities.components import Name
+
+        order = []
+        for eid in self.turns.order:
+            name = self.entity_manager.get_component(eid, Name)
+            if eid == self.player_id:
+                order.append({"id": eid, "name": self.player_name()})
+            else:
+                order.append({"id": eid, "name": name.value if name else str(eid)})
+        return {
+            "type": "turn_order",
+            **self.turns.status(),
+            "order": order,
+            "your_turn": self.turns.player_turn,
+        }
+
+    def ping(self, sent: float, rtt_ms: Optional[float] = None) -> dict:
+        """Answer a client's ping for round-trip and clock-offset measurement.
+
+        The client's RTT is its receive time minus sent, and its offset from
+        server time is server_time minus the midpoint of the two. tick and
+        next_tick_in place that moment on the fixed-update timeline.
+        """
+        if isinstance(sent, bool) or not isinstance(sent, (int, float)):
+            raise TypeError("sent must be the client's timestamp")
+        if rtt_ms is not None:
+            self.latency.record(self.player_name(), rtt_ms)
+        return {
+            "type": "pong",
+            "sent": sent,
+            "server_time": time.time(),
+            "tick": self.tick,
+            "tick_seconds": self.fixed_timestep,
+            "next_tick_in": round(max(0.0, self.fixed_timestep - self.accumulator), 6),
+        }
+
+    def describe_sessions(self):
+        sessions = self.who()["sessions"]
+        if not sessions:
+            self.log("Nobody is connected over telnet.", (150, 150, 150))
+            return
+        self.log(f"{len(sessions)} connected:", (200, 200, 255))
+        for session in sessions:
+            minutes = session["idle"] // 60
+            status = " [AFK]" if session["afk"] else ""
+            if session.get("rtt_ms") is not None:
+                status = f"  {session['rtt_ms']:.0f}ms{status}"
+            name = session["user"] or "guest"
+            self.log(f"  {name}  idle {minutes}m{status}", (200, 200, 255))
+
+    def settings_message(self) -> dict:
+        return {"type": "settings", "settings": self.settings.snapshot()}
+
+    def set_settings(self, settings: dict) -> dict:
+        """Change some settings and save them to the profile."""
+        try:
+            changed = self.settings.update(settings)
+        except SettingsError as e:
+            return {"type": "error", "error": str(e)}
+        if changed:
+            self.save_player_profile()
+        return {**self.settings_message(), "changed": changed}
+
+    def describe_settings(self):
+        for name, value in self.settings.snapshot().items():
+            if isinstance(value, bool):
+                value = "on" if value else "off"
+            elif isinstance(value, list):
+                value = ", ".join(value) or "(none)"
+            self.log(f"  {name}: {value}", (200, 200, 255))
+
+    def text_set(self, text: str):
+        """The set text command: set auto_loot on, set chat_filter word word."""
+        name, _, value = text.strip().partition(" ")
+        if not name:
+            self.log("Set what? (settings lists them)", (150, 150, 150))
+            return
+        value = value.strip()
+        if value.lower() in ("on", "off", "true", "false", "yes", "no"):
+            value = value.lower() in ("on", "true", "yes")
+        reply = self.set_settings({name.lower(): value})
+        if reply["type"] == "error":
+            self.log(reply["error"], (255, 100, 100))
+        else:
+            self.log(f"{name.lower()} is set.", (200, 200, 255))
+
+    def describe_netstat(self):
+        """GM view of each telnet connection's traffic; refused for players."""
+        reply = self.handle_request({"type": "netstat"})
+        if reply["type"] == "error":
+            self.log(reply["error"], (255, 100, 100))
+            return
+        if not reply["connections"]:
+            self.log("Nobody is connected over telnet.", (150, 150, 150))
+        for conn in reply["connections"]:
+            queue = conn["queue"]
+            dropped = sum(queue["dropped"].values())
+            busiest = sorted(conn["messages_in"].items(), key=lambda kv: -kv[1])[:3]
+            lines = ", ".join(f"{kind} {count}" for kind, count in busiest) or "none"
+            self.log(
+                f"  {conn['user'] or conn['address']}  in {conn['bytes_in']}B "
+                f"out {conn['bytes_out']}B  idle {conn['idle']}s  "
+                f"queue peak {queue['high_water']}, dropped {dropped}  ({lines})",
+                (200, 200, 255),
+            )
+
+    def nearby_creatures(self, radius: int = 10) -> list:
+        """Visible creatures around the player as (entity, name, distance)."""
+        from entities.components import Monster
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return []
+        creatures = []
+        for eid in self.spatial_index.monsters_near(pos.x, pos.y, radius):
+            monster = self.entity_manager.get_component(eid, Monster)
+            m_pos = self.entity_manager.get_component(eid, Position)
+            if not monster or not m_pos or m_pos.z != pos.z:
+                continue  # Creatures on other levels share the spatial index
+            if not self.game_map.visible[m_pos.y, m_pos.x]:
+                continue
+            distance = max(abs(m_pos.x - pos.x), abs(m_pos.y - pos.y))
+            creatures.append((eid, monster.name, distance))
+        return creatures
+
+    def text_interact(self, verb: str, args: list):
+        """Attack or talk to a creature named in a text command."""
+        from entities.components import Banker, Monster, Shop
+
+        if not args:
+            self.log(f"{verb.capitalize()} whom?", (150, 150, 150))
+            return
+        target = text_commands.resolve_target(args, self.nearby_creatures())
+        if target is None:
+            self.log(f"You see no '{' '.join(args)}' here.", (150, 150, 150))
+            return
+
+        monster = self.entity_manager.get_component(target, Monster)
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        t_pos = self.entity_manager.get_component(target, Position)
+        dx, dy = t_pos.x - pos.x, t_pos.y - pos.y
+        if max(abs(dx), abs(dy)) > 1:
+            where = text_commands.direction_name(dx, dy)
+            self.log(f"The {monster.name} is too far away ({where}).", (150, 150, 150))
+            return
+
+        if verb == "attack":
+            if monster.ai_type == "passive":
+                self.log(f"You have no reason to attack the {monster.name}.", (150, 150, 150))
+            else:
+                # As a bump, so it is paced and waits its turn like any move
+                self.commands.run_action("move", dx, dy)
+        elif any(self.entity_manager.has_component(target, c) for c in (Shop, Banker)):
+            self.interact()
+        elif monster.ai_type == "passive":
+            # Bumping a peaceful NPC talks to it
+            self.move_player(dx, dy)
+        else:
+            self.log(f"The {monster.name} has nothing to say to you.", (150, 150, 150))
+
+    def surroundings(self) -> List[str]:
+        """What the player can see, in sentences, from the field of view."""
+        from entities.components import Banker, Item, Shop
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos or self.game_map is None:
+            return []
+        info = self.zone_info()
+        place = self.town_at(pos.x, pos.y) or (info["name"] if info else "the wilds")
+        tile = self.game_map.get_tile(pos.x, pos.y)
+
+        creatures = []
+        for eid, name, _ in self.nearby_creatures():
+            at = self.entity_manager.get_component(eid, Position)
+            proper = self.entity_manager.has_component(
+                eid, Shop
+            ) or self.entity_manager.has_component(eid, Banker)
+            creatures.append(Seen(name, at.x - pos.x, at.y - pos.y, proper))
+        items = []
+        for eid in self.spatial_index.query_radius(
+            pos.x, pos.y, 10, self.spatial_index.items
+        ):
+            at = self.entity_manager.get_component(eid, Position)
+            item = self.entity_manager.get_component(eid, Item)
+            if item and at and at.z == pos.z and self.game_map.visible[at.y, at.x]:
+                items.append(Seen(item.name, at.x - pos.x, at.y - pos.y))
+        blocked = [
+            way
+            for way, (dx, dy) in text_commands.DIRECTIONS.items()
+            if len(way) > 2 and not self.game_map.is_walkable(pos.x + dx, pos.y + dy)
+        ]
+        ground = tile.name.lower() if tile and tile.name else "nothing"
+        return describe(place, ground, creatures, items, blocked)
+
+    def describe_message(self) -> dict:
+        return {"type": "describe", "lines": self.surroundings()}
+
+    def set_describe_mode(self, enabled: bool) -> dict:
+        """Switch describe mode; the reply describes where the player is now."""
+        if not isinstance(enabled, bool):
+            raise TypeError("enabled must be true or false")
+        self.narrator.enabled = enabled
+        self.narrator.last = self.surroundings() if enabled else []
+        self.save_player_profile()
+        return {
+            "type": "describe_mode",
+            "enabled": enabled,
+            "lines": self.narrator.last,
+        }
+
+    def narrate_changes(self):
+        """In describe mode, say what is new around the player."""
+        for line in self.narrator.changes(self.surroundings()) or []:
+            self.log(line, DESCRIBE_COLOR)
+
+    def describe_surroundings(self):
+        """Log the region, nearby creatures and items underfoot for text players."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return
+        info = self.zone_info()
+        tile = self.game_map.get_tile(pos.x, pos.y)
+        place = info["name"] if info else "the wilds"
+        self.log(f"You are in {place}, standing on {tile.name if tile else 'nothing'}.", (255, 215, 120))
+
+        for eid, name, distance in sorted(self.nearby_creatures(), key=lambda c: c[2])[:5]:
+            c_pos = self.entity_manager.get_component(eid, Position)
+            where = text_commands.direction_name(c_pos.x - pos.x, c_pos.y - pos.y)
+            self.log(f"{name}: {distance} tiles {where}", (200, 200, 200))
+
+        from entities.components import Item
+
+        for item_id in self.entity_wrapper.get_items_at_position(pos.x, pos.y):
+            item = self.entity_manager.get_component(item_id, Item)
+            if item:
+                self.log(f"Here lies {item.name}.", (100, 255, 100))
+
+    def update_fov(self, force: bool = False):
+        """Update the field of view based on player position and light."""
         if self.player_id is None or self.game_map is None:
             return
 
-        # If the map is not dark, everything is visible and explored
-        if not self.game_map.is_dark:
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return
+
+        # Check if player has moved (light changes force a refresh)
+        current_pos = (pos.x, pos.y)
+        if self._last_fov_pos == current_pos and not force:
+            return
+        self._last_fov_pos = current_pos
+
+        self.update_lighting()
+
+        # Overworld in daylight: everything is visible and explored
+        if not self.game_map.is_dark and self.light_map.ambient >= MIN_VISIBLE_LIGHT:
             self.game_map.visible.fill(True)
             self.game_map.explored.fill(True)
             return
 
+        # At night and underground only lit tiles in line of sight can be seen
+        fov_array = calculate_fov(self.game_map, pos.x, pos.y, LIGHT_WINDOW)
+        self.game_map.update_fov(lit_visibility(fov_array, self.light_map))
+
+    def update_lighting(self):
+        """Recompute light levels around the player from the sky and light sources."""
+        from entities.components import Inventory, LightSource
+
         pos = self.entity_manager.get_component(self.player_id, Position)
-        if pos:
-            # Check if player has moved
-            current_pos = (pos.x, pos.y)
-            if self._last_fov_pos == current_pos:
-                return
+        if not pos or self.game_map is None:
+            return
+
+        underground = self.game_map.is_dark or pos.z != 0
+        ambient = ambient_light(self.clock.daylight, underground)
+
+        # The player always makes out their immediate surroundings
+        sources = [(pos.x, pos.y, *NIGHT_VISION)]
+
+        # Placed lights and glowing items lying nearby
+        for eid, light in self.entity_manager.components_by_type.get(LightSource, {}).items():
+            light_pos = self.entity_manager.get_component(eid, Position)
+            if not light_pos or light_pos.z != pos.z:
+                continue
+            reach = LIGHT_WINDOW + light.radius
+            if abs(light_pos.x - pos.x) <= reach and abs(light_pos.y - pos.y) <= reach:
+                sources.append((light_pos.x, light_pos.y, light.radius, light.intensity))
+
+        # Carried lights shine from the player
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        for item_id in inv.items if inv else []:
+            light = self.entity_manager.get_component(item_id, LightSource)
+            if light:
+                sources.append((pos.x, pos.y, light.radius, light.intensity))
 
-            self._last_fov_pos = current_pos
-            fov_radius = 8  # Default radius
-            fov_array = calculate_fov(self.game_map, pos.x, pos.y, fov_radius)
-            self.game_map.update_fov(fov_array)
+        self.light_map = compute_light(self.game_map, pos.x, pos.y, ambient, sources)
 
     def log(self, text: str, color: tuple = (255, 255, 255)):
         """Add a message to the log."""
         self.message_log.append((text, color))
+        if self.log_capture is not None:
+            self.log_capture.append(text)
+
+        if self.recorder:
+            self.recorder.event(self.tick, text)
+        elif self.replay:
+            divergence = self.replay.check_event(self.tick, text)
+            if divergence:
+                self.message_log.append((f"Replay diverged at {divergence}", (255, 80, 80)))
 
     def run(self):
         """Run the main game loop."""
@@ -128,9 +2102,26 @@ class GameEngine:
         print("Controls: WASD/Arrows to move, Space/Enter for Actions, ? for Help")
         print("Note: Diagonal movement uses Q, E, Z, C around the WASD keys.")
 
+        # Seed the world so a replay of this session plays out the same way
+        if self.replay is None and self.recorder is None and CONFIG.replay.get("record", False):
+            name = time.strftime("replay-%Y%m%d-%H%M%S.jsonl")
+            self.start_recording(os.path.join(CONFIG.paths.get("replays", "src/data/saves/replays"), name))
+
         # Initialize the game
         self.initialize_game()
 
+        # With several worlds to choose from, the player picks one after logging in
+        if (
+            self.requested_world is None
+            and not self.override_map_path
+            and len(self.worlds.available()) > 1
+        ):
+            self.open_world_select()
+
+        # SIGHUP reloads content; the loop does it between ticks, not mid-update
+        if hasattr(signal, "SIGHUP"):
+            signal.signal(signal.SIGHUP, lambda signum, frame: self.request_reload())
+
         # Main game loop
         loop_count = 0
         while self.running:
@@ -145,17 +2136,44 @@ class GameEngine:
 
                 # Process fixed updates
                 while self.accumulator >= self.fixed_timestep:
+                    started = time.perf_counter()
                     self.update(self.fixed_timestep)
+                    self.view_scaler.record_tick(time.perf_counter() - started)
                     self.accumulator -= self.fixed_timestep
+                    if self.replay:
+                        self.play_replay()
 
-                # Check for input
+                # Check for input; while a replay plays, only quitting is accepted
                 input_event = self.input_handler.check_for_input()
-                if input_event:
+                if self.replay and not self.replay.finished:
+                    self.play_replay()
+                    if input_event and input_event.action_type == "quit":
+                        self.quit()
+                elif input_event:
+                    if self.recorder:
+                        self.recorder.input(self.tick, input_event)
                     self.handle_input(input_event)
 
+                if self.reload_requested:
+                    self.reload_requested = False
+                    result = self.handle_request({"type": "reload_content"})
+                    text = result.get("error") or "Content and config reloaded."
+                    self.message_log.append((text, (150, 150, 150)))
+
+                # Commands from telnet clients run here, on the game thread
+                if self.gateway and not (self.replay and not self.replay.finished):
+                    sent = self.gateway.reply_bytes
+                    self.gateway.poll(self.gateway_reply, snapshot=self.ansi_view)
+                    self.view_scaler.record_sent(
+                        self.gateway.reply_bytes - sent, self.gateway.connection_count()
+                    )
+
                 # Render (variable rate)
                 self.render()
 
+                if self.diagnostics:
+                    self.diagnostics.record_frame(time.time() - current_time, self.frame_duration)
+
                 # Control frame rate
                 self.throttle_framerate()
             except Exception as e:
@@ -193,6 +2211,7 @@ class GameEngine:
                 w = max(len(row) for row in layout) if h > 0 else 0
 
                 self.game_map = GameMap(w, h)
+                self.levels = {0: self.game_map}
 
                 for y, row in enumerate(layout):
                     for x, char in enumerate(row):
@@ -218,24 +2237,15 @@ class GameEngine:
 
                 sys.exit(1)
         else:
-            # Get the persistent world
-            from world.persistent_world import get_persistent_world
-
-            persistent_world = get_persistent_world()
-
-            # Load the FULL world map for seamless scrolling
-            self.game_map = persistent_world.get_full_game_map()
-            print("Loaded Full World Map")
+            # Load the world the player last played in (or the one asked for)
+            persistent_world = self.enter_world(self.starting_world())
 
             # Start player
             if self.override_start_pos:
                 start_x, start_y = self.override_start_pos
-            elif persistent_world.player_start_pos:
-                start_x, start_y = persistent_world.player_start_pos
             else:
-                # Fallback: Start player in the center of the Town (Chunk 0,0)
-                start_x = persistent_world.center_x + 25
-                start_y = persistent_world.center_y + 25
+                # New players begin at the centre of the spawn region's safe zone
+                start_x, start_y = self.world_start(persistent_world)
 
             # Ensure we don't spawn in a wall
         # Spiral search for free spot
@@ -255,13 +2265,33 @@ class GameEngine:
 
         self.player_id = self.entity_wrapper.factory.create_player(start_x, start_y)
 
+        from entities.components import Inventory
+
+        player_inv = self.entity_manager.get_component(self.player_id, Inventory)
+        if player_inv:
+            self.economy.record_created("starting_gold", player_inv.gold)
+
+        self.load_player_profile()
+        self.stash.load(self.entity_manager)
+        self.recover_transactions()
+        self.check_season()
+
+        # Back where the player left off in this world
+        saved = self.world_position(self.world_name)
+        if saved and not self.override_start_pos:
+            self.place_player(*saved)
+
+        # Attune to a waypoint the player starts on
+        self.check_teleport_tiles(allow_portal=False)
+        self.check_poi_discovery()
+
         print(f"Player created at ({start_x}, {start_y})")
 
         # Spawn pre-placed entities (Monsters/Items from Static Maps)
         self.spawn_preplaced_entities()
 
         # Spawn static shopkeeper nearby
-        self.entity_wrapper.factory.create_shopkeeper(
+        vendor = self.entity_wrapper.factory.create_shopkeeper(
             start_x + 5,
             start_y,
             "Market Vendor",
@@ -271,22 +2301,183 @@ class GameEngine:
                 ("shield", 50),
                 ("bow", 120),
                 ("wand", 150),
+                ("torch", 15),
+            ],
+            faction="crown",
+            restricted=[
+                ("iron_helmet", 80, "friendly"),
+                ("iron_chainmail", 200, "honored"),
             ],
         )
 
         # Spawn static banker nearby
-        self.entity_wrapper.factory.create_banker(
+        banker = self.entity_wrapper.factory.create_banker(
             start_x + 6,
             start_y,
             "Royal Vault"
         )
 
+        # Both go home for the night
+        self.schedule_system.assign(
+            vendor, "shopkeeper", nearest_walkable(self.game_map, start_x + 5, start_y - 8)
+        )
+        self.schedule_system.assign(
+            banker, "banker", nearest_walkable(self.game_map, start_x + 6, start_y - 8)
+        )
+
+        # New players begin in the tutorial
+        self.start_tutorial()
+
         # Initial monster spawn around player
         self.update_active_region()
         print("Initial monsters spawned")
 
-        # Initial FOV update
-        self.update_fov()
+        # Initial FOV update
+        self.update_fov()
+
+        if self.gateway:
+            try:
+                self.gateway.start()
+                print(f"Telnet gateway listening on {self.gateway.host}:{self.gateway.port}")
+            except OSError as e:
+                print(f"Telnet gateway disabled: {e}")
+                self.gateway = None
+        if self.discord:
+            try:
+                self.discord.start()
+            except Exception as e:
+                print(f"Discord bridge disabled: {e}")
+                self.discord = None
+
+        if self.announcements.motd:
+            self.message_log.append(
+                (f"Message of the day: {self.announcements.motd}", ANNOUNCEMENT_COLOR)
+            )
+
+        self.plugins.login(self, self.player_id)
+
+    def starting_world(self) -> WorldInfo:
+        """The world asked for on the command line, else the last one played."""
+        if self.requested_world:
+            return self.worlds.get(self.requested_world)
+        try:
+            last = load_profile(self.profile_path).get("world", DEFAULT_WORLD)
+            return self.worlds.get(last)
+        except WorldError:
+            # The last world was archived; fall back to the oldest still open
+            available = self.worlds.available()
+            return available[0] if available else self.worlds.get(DEFAULT_WORLD)
+
+    def enter_world(self, info: WorldInfo):
+        """Load a world's maps, travel network and landmarks; returns the world."""
+        from world.persistent_world import open_world
+
+        persistent_world = open_world(
+            info.name, info.seed, self.worlds.world_file(info.name)
+        )
+
+        # Load the FULL world map for seamless scrolling
+        self.game_map = persistent_world.get_full_game_map()
+        self.levels = {0: self.game_map, **persistent_world.get_cave_game_maps()}
+        print(f"Loaded world {info.name} and {len(self.levels) - 1} cave levels")
+        self.teleport = TeleportNetwork(
+            persistent_world.waypoints, persistent_world.portals, CONFIG.travel
+        )
+        self.regions = RegionMap.from_content(
+            persistent_world.center_x,
+            persistent_world.center_y,
+            biome_lookup=persistent_world.get_biome,
+        )
+        self.spawn_system.regions = self.regions
+        self.occupancy.safe_zone = self.regions.is_safe
+        self.pois = persistent_world.pois
+        self.bind_points = self.find_bind_points(persistent_world)
+        self.towns = persistent_world.settlements
+        self.markets.path = self.worlds.market_file(info.name)
+        self.markets.load()
+        self.editor = MapEditor(persistent_world, self.game_map, self.content_exists)
+
+        self.world_name = info.name
+        self.hardcore = bool(CONFIG.hardcore.get("enabled", False)) or info.hardcore
+        return persistent_world
+
+    def world_position(self, name: Optional[str]) -> Optional[Tuple[int, int, int]]:
+        """Where the player last was in a world, if they have been there."""
+        if not name:
+            return None
+        saved = Roster(self.worlds.roster_file(name)).get(self.player_name())
+        if not saved:
+            return None
+        return int(saved["x"]), int(saved["y"]), int(saved.get("z", 0))
+
+    def world_start(self, world) -> Tuple[int, int]:
+        """Where players new to a world arrive: the centre of its spawn region."""
+        spawn = RegionMap.from_content(world.center_x, world.center_y).spawn_point()
+        if spawn:
+            return spawn
+        # Fallback: the static map's start, else the center of the Town (Chunk 0,0)
+        return world.player_start_pos or (world.center_x + 25, world.center_y + 25)
+
+    def place_player(self, x: int, y: int, z: int = 0):
+        """Put the player on any level, on the open ground nearest to x, y."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return
+        pos.z = z if z in self.levels else 0
+        self.game_map = self.levels[pos.z]
+        self._last_fov_pos = None
+        self.teleport_player(*nearest_walkable(self.game_map, x, y))
+
+    def open_world_select(self):
+        """Show the worlds the player can enter."""
+        self.world_options = [(w.name, w.ruleset) for w in self.worlds.available()]
+        names = [name for name, _ in self.world_options]
+        current = self.world_name
+        self.inventory_selection = names.index(current) if current in names else 0
+        self.game_state = "WORLD_SELECT"
+
+    def list_worlds(self) -> dict:
+        return {
+            "type": "worlds",
+            "current": self.world_name,
+            "worlds": [world.to_dict() for world in self.worlds.available()],
+        }
+
+    def select_world(self, name: str) -> dict:
+        """Leave this world for another, arriving where the player last left it."""
+        if self.override_map_path:
+            raise WorldError("a test map is loaded")
+        info = self.worlds.get(name)
+        if info.name != self.world_name:
+            if self.in_tutorial:
+                raise WorldError("finish the tutorial first")
+            self.save_player_profile()  # Remembers where the player is in this one
+            self.clear_world_entities()
+            world = self.enter_world(info)
+            self.spawn_preplaced_entities()
+            start = self.world_start(world)
+            self.place_player(*(self.world_position(info.name) or (*start, 0)))
+            self.log(f"You enter the world of {info.name}.", (150, 200, 255))
+            self.save_player_profile()
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        return {
+            "type": "world_entered",
+            "world": info.to_dict(),
+            "x": pos.x,
+            "y": pos.y,
+            "z": pos.z,
+        }
+
+    def clear_world_entities(self):
+        """Remove everything on the map being left; what the player carries stays."""
+        for eid in self.entity_manager.get_all_entities_with_component(Position):
+            if eid != self.player_id:
+                self.entity_manager.destroy_entity(eid)
+        self.spawners = Spawners()
+        self.placed_eids = {}
+        self.auto_path.clear()
+        self.travel_cast = None
 
     def spawn_preplaced_entities(self):
         """Spawn entities that were hand-placed in static map chunks."""
@@ -296,20 +2487,56 @@ class GameEngine:
 
         count = 0
         for entry in world.preplaced_entities:
-            e_type = entry["type"]
-            e_subtype = entry["subtype"]
-            ex, ey = entry["x"], entry["y"]
-
-            if e_type == "monster":
-                self.entity_wrapper.factory.create_monster(ex, ey, e_subtype)
-                count += 1
-            elif e_type == "item":
-                self.entity_wrapper.factory.create_item(ex, ey, e_subtype)
+            if self.spawn_preplaced(entry) is not None:
                 count += 1
 
         if count > 0:
             print(f"Spawned {count} pre-placed entities from static maps.")
 
+    def spawn_preplaced(self, entry: dict) -> Optional[int]:
+        """Spawn one hand-placed entity; a spawner starts keeping its monster alive."""
+        import random
+        from data.loader import DATA_LOADER
+
+        e_type = entry["type"]
+        e_subtype = entry["subtype"]
+        ex, ey = entry["x"], entry["y"]
+
+        if e_type in ("monster", "spawner"):
+            eid = self.entity_wrapper.factory.create_monster(ex, ey, e_subtype)
+            routine = (DATA_LOADER.get_monster_data(e_subtype) or {}).get("schedule")
+            if routine:
+                home = nearest_walkable(self.game_map, ex + random.randint(-8, 8), ey - 8)
+                self.schedule_system.assign(eid, routine, home)
+            if e_type == "spawner":
+                self.spawners.track(entry, eid)
+                return eid
+        elif e_type == "item":
+            eid = self.entity_wrapper.factory.create_item(ex, ey, e_subtype)
+        elif e_type == "light":
+            eid = self.entity_wrapper.factory.create_light(ex, ey, e_subtype)
+        elif e_type == "shopkeeper":
+            eid = self.entity_wrapper.factory.create_shopkeeper(
+                ex, ey, entry.get("name", "Merchant"), list(SHOP_STOCK[e_subtype])
+            )
+            self.schedule_system.assign(eid, "shopkeeper", (ex, ey))
+        else:
+            return None
+        self.placed_eids[id(entry)] = eid
+        return eid
+
+    def update_spawners(self, dt: float):
+        """Replace spawner monsters that have been dead for their respawn time."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        for spawner in self.spawners.due(dt, self.entity_manager.entities.__contains__):
+            entry = spawner["entry"]
+            if pos and pos.z != 0:
+                continue  # Placed on the surface; waits for the player to return
+            eid = self.entity_wrapper.factory.create_monster(
+                entry["x"], entry["y"], entry["subtype"]
+            )
+            self.spawners.respawned(spawner, eid)
+
     def update_active_region(self):
         """
         Dynamically manage entities based on player position.
@@ -322,6 +2549,11 @@ class GameEngine:
         if not pos:
             return
 
+        # The tutorial has only its own hand-placed entities, and the town's
+        # stay put until the player arrives there
+        if self.in_tutorial:
+            return
+
         # Despawn Radius (e.g. 60 tiles)
         despawn_radius = 60
         # Spawn Radius (e.g. 40 tiles)
@@ -337,7 +2569,7 @@ class GameEngine:
             m_comp = self.entity_manager.get_component(eid, Monster)
             if m_pos:
                 dist = max(abs(pos.x - m_pos.x), abs(pos.y - m_pos.y))
-                if dist > despawn_radius:
+                if dist > despawn_radius or m_pos.z != pos.z:
                     # Don't despawn static NPCs like shopkeepers
                     if m_comp and m_comp.ai_type != "static":
                         self.entity_manager.destroy_entity(eid)
@@ -355,7 +2587,7 @@ class GameEngine:
             
             self.spawn_system.spawn_monsters_around_player(
                 self.game_map, pos.x, pos.y, radius=spawn_radius, num_monsters=needed,
-                player_level=player_level
+                player_level=player_level, depth=pos.z
             )
 
     def find_free_position(self) -> tuple[int, int]:
@@ -382,18 +2614,95 @@ class GameEngine:
 
     def update(self, dt: float):
         """Update game state."""
+        self.tick += 1
+
         # Update all systems
         self.system_manager.update_all(dt)
 
         # Update VFX system
         self.vfx_system.update(dt)
 
+        self.view_scaler.update(dt)
+
+        # Sample economy trends
+        self.economy.update(dt)
+        self.markets.update(dt)
+
         # Update Temperature System
         self.update_temperature(dt)
 
         # Handle any game-specific updates
         self.handle_updates(dt)
 
+        self.plugins.tick(self, dt)
+        if self.anticheat:
+            self.check_anticheat()
+
+        # Chat from Discord is not game state, so it stays out of replays
+        if self.discord:
+            for line in self.discord.poll():
+                self.message_log.append((self.settings.filter_chat(line), CHAT_COLOR))
+        for text in self.announcements.due():
+            self.announce(text)
+        if self.restart and not self.replay:
+            self.check_restart()
+
+    def monster_attacks(self, attacker_id: int, target: Optional[int] = None):
+        # Monsters go for the player; summoned allies name their own target
+        self.handle_combat(attacker_id, self.player_id if target is None else target)
+
+    def monster_calls_allies(self, caller_id: int, answered):
+        from entities.components import Monster
+
+        caller = self.entity_manager.get_component(caller_id, Monster)
+        self.log(f"The {caller.name} shouts for help!", (255, 150, 50))
+
+    def update_turns(self, dt: float, player_pos: Optional[Position]):
+        """Keep the turn-based fight up to date with the hostiles around the
+        player, and play monsters' turns as they come due."""
+        from entities.components import Monster
+
+        was_active = self.turns.active
+        hostiles, allies = {}, {}
+        if player_pos and not self.cutscene and self.player_alive():
+            near = self.spatial_index.monsters_near(player_pos.x, player_pos.y, self.turns.range)
+            for eid in near:
+                monster = self.entity_manager.get_component(eid, Monster)
+                pos = self.entity_manager.get_component(eid, Position)
+                if not monster or not pos or pos.z != player_pos.z:
+                    continue
+                if self.occupancy.is_hostile(eid):
+                    hostiles[eid] = monster.speed
+                elif self.occupancy.party_of(eid) == self.player_id:
+                    allies[eid] = monster.speed
+        # The player's summons take turns too, but only while there is a fight
+        self.turns.sync(self.player_id, {**hostiles, **allies} if hostiles else {})
+        if self.turns.active and not was_active:
+            self.log("Combat! You and your foes now act in turn.", (255, 200, 100))
+        elif was_active and not self.turns.active:
+            self.log("The fight is over.", (150, 200, 150))
+        if self.turns.active:
+            self.auto_path.clear()  # No walking off mid-fight between turns
+
+        while self.turns.due(dt):
+            dt = 0.0  # With no pause between turns, every monster goes this tick
+            eid = self.turns.current
+            monster = self.entity_manager.get_component(eid, Monster)
+            pos = self.entity_manager.get_component(eid, Position)
+            if monster and pos:
+                self.ai_system.think(
+                    eid,
+                    monster,
+                    pos,
+                    player_pos,
+                    self.game_map,
+                    self.spatial_index,
+                    self.monster_attacks,
+                    self.monster_calls_allies,
+                    self.player_id,
+                )
+            self.turns.advance()
+
     def update_temperature(self, dt: float):
         """Update entity temperatures and apply effects."""
         from entities.components import Temperature, Position, Health
@@ -441,7 +2750,7 @@ class GameEngine:
                         (255, 50, 50),
                     )
                     temp.lava_contact_time = 0  # Reset
-                    self.respawn_player()
+                    self.respawn_player("incinerated by lava")
                     continue
             else:
                 temp.lava_contact_time = max(0, temp.lava_contact_time - dt * 2)
@@ -480,11 +2789,22 @@ class GameEngine:
 
             # Check death from cumulative temp damage
             if health.current <= 0:
-                self.respawn_player()
+                self.respawn_player("succumbed to the elements")
 
     def render(self):
         """Render the game."""
         if self.game_map and self.player_id is not None:
+            shop_stock = None
+            if self.game_state == "SHOPPING" and self.current_shop_id is not None:
+                from entities.components import Shop
+
+                shop = self.entity_manager.get_component(self.current_shop_id, Shop)
+                shop_stock = self.shop_stock(shop) if shop else None
+                if self.shop_mode == "SELL":
+                    shop_stock = self.sell_offers()
+                elif self.shop_mode == "BUYBACK":
+                    shop_stock = self.buyback_stock()
+
             self.renderer.render(
                 self.game_map,
                 self.entity_manager,
@@ -496,9 +2816,16 @@ class GameEngine:
                 shop_id=self.current_shop_id,
                 shop_mode=self.shop_mode,
                 shop_selection=self.shop_selection,
+                shop_stock=shop_stock,
                 bank_id=self.current_bank_id,
                 bank_mode=self.bank_mode,
                 bank_selection=self.bank_selection,
+                travel_options=self.travel_options,
+                world_options=self.world_options,
+                world_overview=self.world_overview,
+                clock_text=self.clock.time_string(),
+                light_map=self.light_map,
+                focus=self.cutscene.focus if self.cutscene else None,
             )
 
     def handle_updates(self, dt: float):
@@ -506,26 +2833,83 @@ class GameEngine:
         # Get player position once for all updates
         player_pos = self.entity_manager.get_component(self.player_id, Position)
 
-        # Update AI for monsters (Batched across multiple frames)
-        if player_pos:
-
-            def combat_callback(attacker_id):
-                self.handle_combat(attacker_id, self.player_id)
+        # A cutscene runs on game time; monsters and bosses hold still meanwhile
+        if self.cutscene:
+            self.update_cutscene(dt)
+        self.update_spawners(dt)
+
+        # Time of day and NPC routines
+        self.clock.advance(dt)
+        if self.clock.period != self.day_period:
+            self.day_period = self.clock.period
+            self.log(DAY_PERIOD_MESSAGES[self.day_period], (200, 180, 255))
+
+        # Refresh light (time of day, moving lights, torches picked up)
+        self.light_timer += dt
+        if self.light_timer >= 1.0:
+            self.light_timer = 0.0
+            self.update_fov(force=True)
+        self.schedule_system.update(dt, self.clock, self.game_map)
+        self.gambling.update(dt)
+
+        self.season_timer += dt
+        if self.season_timer >= 60.0:
+            self.season_timer = 0.0
+            self.check_season()
+
+        # Perception checks against a hiding player
+        if self.stealth_system.update(dt, self.player_id, self.light_at):
+            from entities.components import Skills
+
+            skills = self.entity_manager.get_component(self.player_id, Skills)
+            self.log(f"Stealth Skill Up! {skills.stealth}", (180, 180, 220))
+
+        # Breath underwater
+        self.update_breath(dt)
+
+        # Summoned allies run out of time
+        self.summons.update(dt)
+
+        # A bite on the line, or one that got away
+        bite = self.fishing.update(self.tick * self.fixed_timestep)
+        if bite == BITE:
+            self.log("Something bites! Reel in now!", (120, 200, 255))
+        elif bite == ESCAPED:
+            self.log("It got away.", (150, 150, 150))
+
+        # Turn-based fights, when combat runs in turns
+        if self.turns.enabled:
+            self.update_turns(dt, player_pos)
 
+        # Update AI for monsters (Batched across multiple frames)
+        if player_pos and not self.cutscene:
             # Calculate number of batches based on move delay and target FPS
             # e.g., 0.5s delay @ 30fps = 15 batches
             num_batches = max(1, int(CONFIG.ai_move_delay * CONFIG.target_fps))
+            ready = None
+            if self.turns.mode == ENERGY:
+                # Energy paces every monster instead (see systems.energy)
+                num_batches, ready = 1, self.monster_ready
+                if self.tick % ENERGY_PRUNE_TICKS == 0:
+                    self.energy.prune(set(self.spatial_index.monsters) | {self.player_id})
 
             self.ai_system.update(
                 self.game_map,
                 player_pos,
                 self.spatial_index,
-                combat_callback,
+                self.monster_attacks,
                 num_batches=num_batches,
+                alert_callback=self.monster_calls_allies,
+                player_id=self.player_id,
+                # Less time for far-off monsters while the server is loaded
+                budget=CONFIG.ai_tick_budget_ms * self.view_scaler.scale / 1000,
+                # Those in a turn-based fight act on their turns instead
+                skip=self.turns.order,
+                ready=ready,
             )
 
         # Check for boss encounters
-        if player_pos:
+        if player_pos and not self.cutscene:
             boss_encounter = self.boss_system.check_for_boss_encounter(
                 player_pos.x, player_pos.y
             )
@@ -534,7 +2918,31 @@ class GameEngine:
                     f"Danger approaches: {boss_encounter.name} is nearby!",
                     (255, 50, 50),
                 )
-                self.boss_system.trigger_boss_encounter(boss_encounter)
+                boss = self.boss_system.trigger_boss_encounter(boss_encounter)
+                script = self.boss_system.scripts.get(boss_encounter.boss_type, {})
+                if script.get("intro"):
+                    self.play_cutscene(script["intro"], boss)
+
+        # Scripted boss fights (phases, abilities, enrage)
+        if self.boss_system.active_fights and not self.cutscene:
+            from entities.components import Health
+
+            self.boss_system.update(dt, self.game_map, self.player_id, self.log)
+            health = self.entity_manager.get_component(self.player_id, Health)
+            if health and health.current <= 0:
+                self.respawn_player("fell in a boss fight")
+
+        # Walk towards a click-to-move destination
+        if self.auto_path:
+            self.step_auto_path(dt)
+
+        # Channel a waypoint jump
+        if self.travel_cast:
+            self.update_travel_cast(dt)
+
+        # Announce region changes
+        if player_pos and self.regions:
+            self.check_region_entry(player_pos.x, player_pos.y)
 
         # Mana regeneration
         self.mana_regen_timer += dt
@@ -551,49 +2959,82 @@ class GameEngine:
     def handle_input(self, event: InputEvent):
         """Handle input events based on game state."""
         if self.game_state == "PLAYING":
+            # Any other command interrupts a walk in progress
+            if self.auto_path:
+                self.auto_path.clear()
+            if self.travel_cast:
+                self.travel_cast = None
+                self.log("Your travel is interrupted.", (150, 150, 150))
+
             if event.action_type == "move":
-                self.move_player(event.dx, event.dy)
-            elif event.action_type == "quit":
-                self.quit()
-            elif event.action_type == "action_menu":
-                # Check for shop interaction first
-                if self.check_for_interactables():
-                    return
-                # Check for adjacent enemies to attack
-                if self.check_for_attack():
-                    return
-                # Space bar - Swap Weapon
-                self.swap_weapon()
-            elif event.action_type == "select":
-                # Enter key - Interact/Select
-                pass
-            elif event.action_type == "pickup":
-                self.pickup_item()
-            elif event.action_type == "inventory":
-                self.game_state = "INVENTORY"
-                self.inventory_selection = 0
-            elif event.action_type == "stats":
-                self.game_state = "STATS"
-                self.inventory_selection = 0  # Re-use for menu index
-            elif event.action_type == "help":
-                self.game_state = "HELP"
-            elif event.action_type == "fire":
-                self.game_state = "TARGETING"
-                self.log("Select direction to attack...", (255, 255, 0))
-            elif event.action_type == "wait":
-                self.log("You wait...", (150, 150, 150))
+                self.commands.run_action("move", event.dx, event.dy)
+            elif event.action_type == "move_to":
+                target = self.renderer.screen_to_map(event.x, event.y)
+                if target:
+                    self.commands.run_action("move_to", *target)
             elif event.action_type.startswith("cast_"):
                 skill_num = int(event.action_type.split("_")[1])
-                self.handle_skill_cast(skill_num)
+                self.commands.run_action("cast", skill_num)
+            else:
+                # Enter ("select") has nothing to do outside menus
+                self.commands.run_action(event.action_type)
 
         elif self.game_state == "TARGETING":
             if event.action_type == "move":
                 self.fire_weapon(event.dx, event.dy)
                 self.game_state = "PLAYING"
+                self.player_acted("fire")
             else:
                 self.game_state = "PLAYING"
                 self.log("Canceled.", (150, 150, 150))
 
+        elif self.game_state == "CUTSCENE":
+            if event.action_type in ("select", "quit"):
+                self.commands.run_action("skip")
+
+        elif self.game_state == "TRAVEL":
+            if event.action_type == "move":
+                if event.dy > 0:
+                    self.inventory_selection += 1
+                elif event.dy < 0:
+                    self.inventory_selection -= 1
+                self.inventory_selection = max(
+                    0, min(self.inventory_selection, len(self.travel_options) - 1)
+                )
+            elif event.action_type == "select":
+                if self.travel_options:
+                    self.begin_travel(*self.travel_options[self.inventory_selection])
+                self.game_state = "PLAYING"
+            elif event.action_type in ("quit", "travel"):
+                self.game_state = "PLAYING"
+
+        elif self.game_state == "WORLD_SELECT":
+            if event.action_type == "move":
+                if event.dy > 0:
+                    self.inventory_selection += 1
+                elif event.dy < 0:
+                    self.inventory_selection -= 1
+                self.inventory_selection = max(
+                    0, min(self.inventory_selection, len(self.world_options) - 1)
+                )
+            elif event.action_type == "select":
+                self.game_state = "PLAYING"
+                if self.world_options:
+                    name = self.world_options[self.inventory_selection][0]
+                    try:
+                        self.select_world(name)
+                    except WorldError as e:
+                        self.log(f"You cannot enter {name}: {e}.", (255, 100, 100))
+            elif event.action_type in ("quit", "worlds"):
+                self.game_state = "PLAYING"
+
+        elif self.game_state == "WORLD_MAP":
+            if event.action_type == "select":
+                self.export_world_map()
+            elif event.action_type in ("quit", "world_map"):
+                self.game_state = "PLAYING"
+                self.world_overview = None
+
         elif self.game_state == "HELP":
             if event.action_type:
                 # Any key to close help
@@ -632,11 +3073,11 @@ class GameEngine:
                 self.game_state = "PLAYING"
                 self.log("You leave the shop.", (200, 200, 200))
             elif event.action_type == "move":
-                if event.dx > 0:
-                    self.shop_mode = "SELL"
-                    self.shop_selection = 0
-                elif event.dx < 0:
-                    self.shop_mode = "BUY"
+                if event.dx:
+                    # Left and right step through the tabs: buy, sell, buyback
+                    modes = ("BUY", "SELL", "BUYBACK")
+                    at = modes.index(self.shop_mode) + (1 if event.dx > 0 else -1)
+                    self.shop_mode = modes[max(0, min(at, len(modes) - 1))]
                     self.shop_selection = 0
                 elif event.dy > 0:
                     self.shop_selection += 1
@@ -663,6 +3104,38 @@ class GameEngine:
             elif event.action_type == "select":
                 self.handle_bank_transaction()
 
+    def interact(self):
+        """Talk to or trade with a neighbour, else attack one, else swap weapons."""
+        # Check for shop interaction first
+        if self.check_for_interactables():
+            return
+        # Then for an inn or shrine to bind to
+        if self.bind_home():
+            return
+        # Check for adjacent enemies to attack
+        if self.check_for_attack():
+            return
+        # Space bar - Swap Weapon
+        self.swap_weapon()
+
+    def open_inventory(self):
+        self.game_state = "INVENTORY"
+        self.inventory_selection = 0
+
+    def open_stats(self):
+        self.game_state = "STATS"
+        self.inventory_selection = 0  # Re-use for menu index
+
+    def open_help(self):
+        self.game_state = "HELP"
+
+    def start_targeting(self):
+        self.game_state = "TARGETING"
+        self.log("Select direction to attack...", (255, 255, 0))
+
+    def wait_turn(self):
+        self.log("You wait...", (150, 150, 150))
+
     def handle_skill_cast(self, skill_num: int):
         """Handle casting of active skills."""
         from entities.components import Mana, Health, Position, Monster
@@ -694,11 +3167,15 @@ class GameEngine:
         elif skill_num == 2:
             # Fireball (Cost 25 Mana, AoE damage around player)
             cost = 25
+            if self.in_safe_zone(self.player_id):
+                self.log("You cannot fight in a safe zone.", (150, 150, 150))
+                return
             if mana.current < cost:
                 self.log("Not enough mana for Fireball!", (150, 150, 255))
                 return
 
             mana.current -= cost
+            self.reveal_from_stealth(self.player_id)
             self.log("Cast Fireball! Flames erupt!", (255, 100, 50))
 
             # Find monsters in radius 3
@@ -710,11 +3187,16 @@ class GameEngine:
                         tx, ty = pos.x + dx, pos.y + dy
                         monsters = self.entity_wrapper.get_monsters_at_position(tx, ty)
                         for mid in monsters:
+                            if self.occupancy.party_of(mid) == self.player_id:
+                                continue  # The flames spare your allies
                             # Direct damage
                             m_hp = self.entity_manager.get_component(mid, Health)
-                            if m_hp:
+                            if m_hp and not self.in_safe_zone(mid):
                                 dmg = 30
                                 m_hp.current -= dmg
+                                self.loot_system.record_damage(
+                                    mid, self.player_id, dmg
+                                )
                                 self.vfx_system.add_floating_text(
                                     tx, ty, str(dmg), (255, 100, 50)
                                 )
@@ -731,24 +3213,11 @@ class GameEngine:
                                     name = m_comp.name if m_comp else "Monster"
                                     self.log(f"{name} is incinerated!", (255, 50, 50))
                                     if m_comp:
-                                        self.gain_xp(self.player_id, m_comp.xp_reward)
-                                        # Random chance to drop item
-                                        if random.random() < 0.2:
-                                            drop_type = random.choice(
-                                                [
-                                                    "health_potion",
-                                                    "sword",
-                                                    "shield",
-                                                    "bow",
-                                                    "wand",
-                                                ]
-                                            )
-                                            self.entity_wrapper.factory.create_item(
-                                                tx, ty, drop_type
-                                            )
-                                            self.log(
-                                                "Something dropped!", (255, 215, 0)
-                                            )
+                                        credited = self.loot_system.kill_credit(
+                                            mid, self.player_id
+                                        )
+                                        self.gain_xp(credited, m_comp.xp_reward)
+                                        self.drop_loot(mid, credited)
                                     self.entity_manager.destroy_entity(mid)
 
             if not hit:
@@ -786,12 +3255,74 @@ class GameEngine:
                     self.entity_manager.notify_component_change(
                         self.player_id, Position
                     )
+                    self.server_moved_player(tx, ty)
                     self.update_fov()
                     self.log("You blink to a new location!", (200, 200, 255))
                     return
                 tries += 1
             self.log("Blink failed, no safe spot found.", (150, 150, 150))
 
+        elif skill_num == 4:
+            # Raise Dead (Cost 30 Mana, a bone servant fights beside you)
+            cost = 30
+            if mana.current < cost:
+                self.log("Not enough mana for Raise Dead!", (150, 150, 255))
+                return
+            if self.summon_ally(self.player_id, "bone_servant") is not None:
+                mana.current -= cost
+
+    def summon_ally(self, owner: int, kind: str) -> Optional[int]:
+        """Call up a creature to fight for owner; None (and why, logged) if it
+        could not appear."""
+        pos = self.entity_manager.get_component(owner, Position)
+
+        def is_free(x: int, y: int) -> bool:
+            return (
+                0 <= x < self.game_map.width
+                and 0 <= y < self.game_map.height
+                and self.game_map.is_walkable(x, y)
+                and not self.spatial_index.is_occupied(x, y)
+            )
+
+        try:
+            eid = self.summons.summon(owner, kind, is_free)
+        except SummonError as e:
+            self.log(str(e), (150, 150, 150))
+            return None
+        name = self.summons.name_of(eid)
+        self.log(f"A {name} answers your call.", SUMMON_COLOR)
+        if pos:
+            self.vfx_system.add_floating_text(pos.x, pos.y, "Summon!", SUMMON_COLOR)
+        return eid
+
+    def summon_dismissed(self, eid: int, reason: str):
+        from entities.components import Summoned
+
+        summon = self.entity_manager.get_component(eid, Summoned)
+        if summon and summon.owner == self.player_id:
+            name = self.summons.name_of(eid)
+            self.log(f"Your {name} {SUMMON_DISMISSALS[reason]}.", SUMMON_COLOR)
+
+    def dismiss_summons(self):
+        summons = self.summons.summons_of(self.player_id)
+        if not summons:
+            self.log("You have no summoned allies.", (150, 150, 150))
+        for eid in summons:
+            self.summons.dismiss(eid, DISMISSED)
+
+    def summon_list(self) -> list:
+        """The player's summoned allies and the seconds each has left."""
+        from entities.components import Summoned
+
+        return [
+            {
+                "id": eid,
+                "name": self.summons.name_of(eid),
+                "remaining": round(self.entity_manager.get_component(eid, Summoned).remaining, 1),
+            }
+            for eid in self.summons.summons_of(self.player_id)
+        ]
+
     def allocate_stat(self):
         """Allocate an attribute point."""
         from entities.components import Level, Combat, Health, Mana
@@ -865,9 +3396,8 @@ class GameEngine:
             if monsters:
                 # Attack the first valid target
                 for mid in monsters:
-                    monster = self.entity_manager.get_component(mid, Monster)
-                    # Don't attack passive/static NPCs with Space (safety)
-                    if monster and monster.ai_type not in ["passive", "static"]:
+                    # Don't attack NPCs or your own allies with Space (safety)
+                    if self.occupancy.is_hostile(mid):
                         self.handle_combat(self.player_id, mid)
                         return True
         return False
@@ -899,13 +3429,16 @@ class GameEngine:
             # Check for entities at neighbor
             entities = self.entity_wrapper.get_monsters_at_position(nx, ny)
             for eid in entities:
+                if not self.is_npc_available(eid):
+                    return True
+
                 shop = self.entity_manager.get_component(eid, Shop)
                 if shop:
                     self.game_state = "SHOPPING"
                     self.log(f"Welcome to {shop.shop_name}!", (255, 215, 0))
                     # Store current shop entity ID if we want to buy things later
                     self.current_shop_id = eid
-                    self.shop_mode = "BUY" # BUY or SELL
+                    self.shop_mode = "BUY" # BUY, SELL or BUYBACK
                     self.shop_selection = 0
                     return True
                 
@@ -919,6 +3452,23 @@ class GameEngine:
                     return True
         return False
 
+    def is_npc_available(self, eid: int) -> bool:
+        """Check an NPC's schedule, telling the player when it opens if closed."""
+        from entities.components import Monster, Schedule
+
+        schedule = self.entity_manager.get_component(eid, Schedule)
+        if not schedule or schedule.available:
+            return True
+
+        monster = self.entity_manager.get_component(eid, Monster)
+        name = monster.name if monster else "They"
+        opens = self.schedule_system.next_open_hour(schedule.routine, self.clock.hour)
+        if opens is None:
+            self.log(f"{name} is not available.", (150, 150, 150))
+        else:
+            self.log(f"{name} is closed. Come back at {opens:02d}:00.", (150, 150, 150))
+        return False
+
     def fire_weapon(self, dx: int, dy: int):
         """Fire weapon in a direction."""
         from entities.components import Equipment, Position
@@ -952,8 +3502,12 @@ class GameEngine:
                 self.log("Blocked by wall.", (150, 150, 150))
                 break
 
-            # Check for monsters
-            monsters = self.entity_wrapper.get_monsters_at_position(tx, ty)
+            # Check for monsters; shots pass the player's own allies by
+            monsters = [
+                mid
+                for mid in self.entity_wrapper.get_monsters_at_position(tx, ty)
+                if self.occupancy.party_of(mid) != self.player_id
+            ]
             if monsters:
                 self.handle_combat(self.player_id, monsters[0])
                 target_found = True
@@ -967,7 +3521,7 @@ class GameEngine:
 
     def pickup_item(self):
         """Pick up an item at the player's location."""
-        from entities.components import Position, Inventory, Item
+        from entities.components import Position, Inventory, Item, LootOwner
 
         player_pos = self.entity_manager.get_component(self.player_id, Position)
         player_inv = self.entity_manager.get_component(self.player_id, Inventory)
@@ -976,14 +3530,35 @@ class GameEngine:
             return
 
         # Check for items at this position
-        items_on_ground = self.entity_wrapper.get_items_at_position(
-            player_pos.x, player_pos.y
-        )
+        items_on_ground = [
+            item_id
+            for item_id in self.entity_wrapper.get_items_at_position(
+                player_pos.x, player_pos.y
+            )
+            if self.entity_manager.get_component(item_id, Position).z == player_pos.z
+        ]
 
         if not items_on_ground:
             self.log("There is nothing here.", (150, 150, 150))
             return
 
+        # Another party's drops are theirs until the ownership window closes
+        now = self.tick * self.fixed_timestep
+        takeable = [
+            item_id
+            for item_id in items_on_ground
+            if self.loot_system.may_take(item_id, self.player_id, now)
+        ]
+        if not takeable:
+            owner = self.loot_system.ownership(items_on_ground[0], now)
+            self.log(
+                f"That belongs to {owner['owner']} for another "
+                f"{math.ceil(owner['free_in'])}s.",
+                (255, 100, 100),
+            )
+            return
+        items_on_ground = takeable
+
         if len(player_inv.items) >= player_inv.capacity:
             self.log("Your inventory is full!", (255, 100, 100))
             return
@@ -993,11 +3568,18 @@ class GameEngine:
         item_comp = self.entity_manager.get_component(item_id, Item)
 
         if item_comp:
-            # Remove Position component to "remove" it from the map
-            self.entity_manager.remove_component(item_id, Position)
-            # Add to inventory
-            player_inv.items.append(item_id)
+            # Take it off the map and into the inventory in one step
+            try:
+                self.transaction("pickup").pickup_item(player_inv, item_id).commit()
+            except TransactionError:
+                self.log("You fail to pick that up.", (255, 100, 100))
+                return
+            self.entity_manager.remove_component(item_id, LootOwner)
             self.log(f"You picked up {item_comp.name}.", (100, 255, 100))
+            picked_up = ItemPickedUp(
+                self.player_id, item_id, item_comp.name, item_comp.item_type
+            )
+            self.events.publish(picked_up)
 
 
     def handle_shop_transaction(self):
@@ -1014,18 +3596,27 @@ class GameEngine:
             return
 
         if self.shop_mode == "BUY":
-            if not shop.items:
+            stock = self.shop_stock(shop)
+            if not stock:
                 return
-            self.shop_selection = max(0, min(self.shop_selection, len(shop.items) - 1))
-            item_name, price = shop.items[self.shop_selection]
+            self.shop_selection = max(0, min(self.shop_selection, len(stock) - 1))
+            item_name, price = stock[self.shop_selection]
 
             if player_inv.gold >= price:
                 if len(player_inv.items) < player_inv.capacity:
-                    player_inv.gold -= price
                     new_item = self.entity_wrapper.factory.create_item(0, 0, item_name)
                     from entities.components import Position
                     self.entity_manager.remove_component(new_item, Position)
-                    player_inv.items.append(new_item)
+                    try:
+                        self.transaction("vendor_purchase").remove_gold(
+                            player_inv, price
+                        ).add_item(player_inv, new_item).commit()
+                    except TransactionError:
+                        self.entity_manager.destroy_entity(new_item)
+                        self.log("The trade falls through.", (255, 100, 100))
+                        return
+                    self.economy.record_destroyed("vendor_purchase", price)
+                    self.shop_market().bought(item_name)
                     self.log(f"Bought {item_name} for {price} gold.", (100, 255, 100))
                 else:
                     self.log("Inventory full!", (255, 100, 100))
@@ -1040,14 +3631,425 @@ class GameEngine:
             item_comp = self.entity_manager.get_component(item_id, Item)
             
             if item_comp:
-                sell_price = max(1, item_comp.value // 2)
-                player_inv.gold += sell_price
-                player_inv.items.pop(self.shop_selection)
-                self.entity_manager.destroy_entity(item_id)
+                market = self.shop_market()
+                gross_price = market.sell_price(item_comp.item_type, item_comp.value)
+                fee = self.economy.sink_fee("vendor_fee", gross_price)
+                sell_price = gross_price - fee
+                try:
+                    self.transaction("vendor_sale").remove_item(
+                        player_inv, item_id
+                    ).add_gold(player_inv, sell_price).commit()
+                except TransactionError:
+                    self.log("The trade falls through.", (255, 100, 100))
+                    return
+                # Held back for buyback; only sales pushed off the end are destroyed
+                for dropped in self.buyback.record(item_id, sell_price):
+                    self.entity_manager.destroy_entity(dropped)
+                self.economy.record_created("vendor_sale", gross_price)
+                self.economy.record_destroyed("vendor_fee", fee)
+                market.sold(item_comp.item_type)
                 self.log(f"Sold {item_comp.name} for {sell_price} gold.", (255, 215, 0))
                 if self.shop_selection >= len(player_inv.items):
                     self.shop_selection = max(0, len(player_inv.items) - 1)
 
+        elif self.shop_mode == "BUYBACK":
+            sales = self.buyback.entries()
+            if not sales:
+                return
+            self.shop_selection = max(0, min(self.shop_selection, len(sales) - 1))
+            self.buy_back(sales[self.shop_selection][0])
+            if self.shop_selection >= len(self.buyback.items):
+                self.shop_selection = max(0, len(self.buyback.items) - 1)
+
+    def buy_back(self, item_id: int) -> bool:
+        """Buy a sold item back for what the vendor paid for it."""
+        from entities.components import Inventory, Item
+
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        if not inventory or item_id not in self.buyback.items:
+            return False
+        price = self.buyback.price(item_id)
+        item = self.entity_manager.get_component(item_id, Item)
+        if inventory.gold < price:
+            self.log("Not enough gold.", (255, 100, 100))
+            return False
+        try:
+            self.transaction("vendor_buyback").remove_gold(inventory, price).add_item(
+                inventory, item_id
+            ).commit()
+        except TransactionError:
+            self.log("Inventory full!", (255, 100, 100))
+            return False
+        self.buyback.take(item_id)
+        self.economy.record_destroyed("vendor_buyback", price)
+        self.shop_market().bought(item.item_type)
+        self.log(f"Bought back {item.name} for {price} gold.", (100, 255, 100))
+        return True
+
+    def buyback_stock(self) -> list:
+        """The sales the player can buy back, as (name, price) like shop stock."""
+        from entities.components import Item
+
+        return [
+            (self.entity_manager.get_component(eid, Item).name, price)
+            for eid, price in self.buyback.entries()
+        ]
+
+    def buyback_message(self) -> dict:
+        """The player's recent vendor sales that can still be bought back."""
+        sales = [
+            {"id": eid, "price": price, **item_details(self.entity_manager, eid)}
+            for eid, price in self.buyback.entries()
+        ]
+        return {"type": "buyback", "slots": self.buyback.slots, "items": sales}
+
+    def shop_stock(self, shop) -> list:
+        """Items a shop offers the player at today's local prices; restricted
+        stock needs enough reputation."""
+        from entities.components import Reputation
+
+        reputation = self.entity_manager.get_component(self.player_id, Reputation)
+        stock = self.factions.stock_for(shop, reputation.standing if reputation else {})
+        market = self.shop_market()
+        return [(item, market.buy_price(item, base)) for item, base in stock]
+
+    def sell_offers(self) -> list:
+        """What the current shop would pay for each carried item, before its fee."""
+        from entities.components import Inventory, Item
+
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        market = self.shop_market()
+        offers = []
+        for item_id in inventory.items if inventory else []:
+            item = self.entity_manager.get_component(item_id, Item)
+            if item:
+                price = market.sell_price(item.item_type, item.value)
+                offers.append((item.name, price))
+        return offers
+
+    def town_at(self, x: int, y: int) -> Optional[str]:
+        """The name of the town a tile is in, if any."""
+        for town in self.towns:
+            if town.contains(x, y):
+                return town.name
+        return None
+
+    def shop_market(self, shop_id: Optional[int] = None) -> Market:
+        """The market a shop trades in: its town's, or its own when out of town."""
+        from entities.components import Shop
+
+        shop_id = self.current_shop_id if shop_id is None else shop_id
+        pos = self.entity_manager.get_component(shop_id, Position)
+        town = self.town_at(pos.x, pos.y) if pos else None
+        if town is None:
+            shop = self.entity_manager.get_component(shop_id, Shop)
+            town = shop.shop_name if shop else "Wilds"
+        return self.markets.market(town)
+
+    def market_message(self) -> dict:
+        """Prices in the town the player stands in, against the usual ones."""
+        from data.loader import DATA_LOADER
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        town = self.town_at(pos.x, pos.y) if pos else None
+        if town is None:
+            return {"type": "market", "town": None, "prices": []}
+        market = self.markets.market(town)
+        listed = {item: base for stock in SHOP_STOCK.values() for item, base in stock}
+        prices = []
+        for item_type in sorted(set(listed) | set(market.supply)):
+            value = (DATA_LOADER.get_item_data(item_type) or {}).get("value", 0)
+            entry = {
+                "item": item_type,
+                "factor": round(market.factor(item_type), 2),
+                "sell": market.sell_price(item_type, value),
+            }
+            if item_type in listed:
+                entry["buy"] = market.buy_price(item_type, listed[item_type])
+            prices.append(entry)
+        return {"type": "market", "town": town, "prices": prices}
+
+    def describe_market(self):
+        """Log which goods are cheap and which are dear in this town."""
+        reply = self.market_message()
+        if reply["town"] is None:
+            self.log("There is no market out here.", (150, 150, 150))
+            return
+        cheap = [p["item"] for p in reply["prices"] if p["factor"] <= 0.9]
+        dear = [p["item"] for p in reply["prices"] if p["factor"] >= 1.1]
+        self.log(f"Market prices in {reply['town']}:", (255, 215, 0))
+        self.log(f"  Cheap: {', '.join(cheap) or 'nothing'}", (150, 255, 150))
+        self.log(f"  Dear: {', '.join(dear) or 'nothing'}", (255, 150, 150))
+
+    def craft(self, recipe: str):
+        """Make an item from a recipe; how good it is depends on crafting skill."""
+        from data.loader import DATA_LOADER
+        from entities.components import Inventory, Item, Skills
+
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        skills = self.entity_manager.get_component(self.player_id, Skills)
+        if not inv or not skills:
+            return
+
+        carried: Dict[str, list] = {}
+        for item_id in inv.items:
+            item = self.entity_manager.get_component(item_id, Item)
+            if item:
+                carried.setdefault(item.item_type, []).append(item_id)
+        counts = {item_type: len(ids) for item_type, ids in carried.items()}
+        try:
+            entry = check_recipe(
+                DATA_LOADER.load_json("recipes"), recipe, skills.crafting, counts
+            )
+        except CraftingError as e:
+            self.log(str(e), (150, 150, 150))
+            return
+
+        required = int(entry.get("skill", 1))
+        roll = roll_craft(skills.crafting, required)
+        made = self.entity_wrapper.factory.create_item(0, 0, recipe, rarity="common")
+        self.entity_manager.remove_component(made, Position)
+        apply_roll(self.entity_manager, made, roll, self.player_name())
+
+        # The materials are used up and the item is made in one step
+        transaction = self.transaction("craft")
+        for material, count in entry.get("materials", {}).items():
+            for item_id in carried[material][:count]:
+                transaction.remove_item(inv, item_id).destroy_item(item_id)
+        try:
+            transaction.add_item(inv, made).commit()
+        except TransactionError:
+            self.entity_manager.destroy_entity(made)
+            self.log("The craft fails.", (255, 100, 100))
+            return
+
+        name = self.entity_manager.get_component(made, Item).name
+        if roll.critical:
+            self.log(f"A masterwork! You craft {name}.", (255, 215, 0))
+        else:
+            self.log(f"You craft {name}.", (100, 255, 100))
+
+        skills.crafting_xp += CRAFT_XP + required
+        if skills.crafting_xp >= skills.xp_for_next_level(skills.crafting):
+            skills.crafting += 1
+            skills.crafting_xp = 0
+            self.log(f"Crafting Skill Up! {skills.crafting}", (220, 180, 120))
+
+    def recipes_message(self) -> dict:
+        """Every recipe, with what the player's crafting skill would make of it."""
+        from data.loader import DATA_LOADER
+        from entities.components import Skills
+
+        skills = self.entity_manager.get_component(self.player_id, Skills)
+        skill = skills.crafting if skills else 1
+        recipes = []
+        for recipe_id, recipe in sorted(DATA_LOADER.load_json("recipes").items()):
+            margin = skill - int(recipe.get("skill", 1))
+            recipes.append(
+                {
+                    "id": recipe_id,
+                    "name": recipe["name"],
+                    "skill": recipe.get("skill", 1),
+                    "materials": recipe.get("materials", {}),
+                    "stat_range": list(stat_range(margin)),
+                    "bonus_affix_chance": bonus_affix_chance(margin),
+                    "critical_chance": critical_chance(margin),
+                }
+            )
+        return {"type": "recipes", "crafting": skill, "recipes": recipes}
+
+    def brew(self, reagents: List[str]):
+        """Mix carried reagents; a new combination may turn out to be a potion."""
+        from data.loader import DATA_LOADER
+        from entities.components import Inventory, Item, Skills
+
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        skills = self.entity_manager.get_component(self.player_id, Skills)
+        if not inv or not skills:
+            return
+        if not isinstance(reagents, list) or not all(
+            isinstance(r, str) for r in reagents
+        ):
+            self.log("Name the reagents to mix.", (150, 150, 150))
+            return
+
+        carried: Dict[str, list] = {}
+        for item_id in inv.items:
+            item = self.entity_manager.get_component(item_id, Item)
+            if item:
+                carried.setdefault(item.item_type, []).append(item_id)
+        missing = sorted(
+            r for r in set(reagents) if len(carried.get(r, [])) < reagents.count(r)
+        )
+        if missing:
+            short = ", ".join(missing)
+            self.log(f"You are not carrying enough {short}.", (150, 150, 150))
+            return
+        try:
+            result = self.alchemy.brew(
+                DATA_LOADER.load_json("brews"), reagents, skills.alchemy
+            )
+        except AlchemyError as e:
+            self.log(str(e), (150, 150, 150))
+            return
+
+        # The reagents are used up whether or not the mixture works
+        transaction = self.transaction("brew")
+        for reagent in set(reagents):
+            for item_id in carried[reagent][: reagents.count(reagent)]:
+                transaction.remove_item(inv, item_id).destroy_item(item_id)
+        made = None
+        if result.potion:
+            made = self.entity_wrapper.factory.create_item(0, 0, result.potion)
+            self.entity_manager.remove_component(made, Position)
+            transaction.add_item(inv, made)
+        try:
+            transaction.commit()
+        except TransactionError:
+            if made is not None:
+                self.entity_manager.destroy_entity(made)
+            self.log("The brew fails.", (255, 100, 100))
+            return
+
+        if made is None:
+            self.log("The mixture fizzles into nothing.", (150, 150, 150))
+        else:
+            name = self.entity_manager.get_component(made, Item).name
+            if result.discovered:
+                self.log(f"Discovery! You brew {name}.", (255, 215, 0))
+            else:
+                self.log(f"You brew {name}.", (100, 255, 100))
+            skills.alchemy_xp += result.xp
+            if skills.alchemy_xp >= skills.xp_for_next_level(skills.alchemy):
+                skills.alchemy += 1
+                skills.alchemy_xp = 0
+                self.log(f"Alchemy Skill Up! {skills.alchemy}", (180, 120, 220))
+        self.save_player_profile()
+
+    def alchemy_message(self) -> dict:
+        """The brews the player has discovered and the mixtures that came to nothing."""
+        from data.loader import DATA_LOADER
+        from entities.components import Skills
+
+        skills = self.entity_manager.get_component(self.player_id, Skills)
+        brews = DATA_LOADER.load_json("brews")
+        known = [
+            {
+                "id": brew_id,
+                "name": brews[brew_id]["name"],
+                "skill": brews[brew_id].get("skill", 1),
+                "reagents": sorted(brews[brew_id].get("reagents", [])),
+            }
+            for brew_id in sorted(self.alchemy.known)
+            if brew_id in brews
+        ]
+        return {
+            "type": "alchemy",
+            "alchemy": skills.alchemy if skills else 1,
+            "known": known,
+            "tried": [list(mixture) for mixture in sorted(self.alchemy.tried)],
+        }
+
+    def catch_table(self, x: int, y: int, z: int = 0) -> Dict[str, int]:
+        """What can be caught in the water at a tile: the region's own catches,
+        else its biome's; water underground has the cave table."""
+        from data.loader import DATA_LOADER
+        from world.persistent_world import get_persistent_world
+
+        tables = DATA_LOADER.load_json("catches")
+        if z != 0 or self.game_map.is_dark:
+            return tables.get("cave", {})
+        region = self.regions.region_at(x, y) if self.regions else None
+        if region and region.catches is not None:
+            return region.catches
+        biome = get_persistent_world().get_biome(x, y)
+        return tables.get(biome, tables.get("default", {}))
+
+    def start_fishing(self):
+        """Cast a line into water next to the player."""
+        from world.map import TILE_WATER
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos or self.game_map is None:
+            return
+        if self.turns.active:
+            self.log("This is no time for fishing.", (150, 150, 150))
+            return
+        water = next(
+            (
+                (pos.x + dx, pos.y + dy)
+                for dx in (-1, 0, 1)
+                for dy in (-1, 0, 1)
+                if (dx or dy)
+                and 0 <= pos.x + dx < self.game_map.width
+                and 0 <= pos.y + dy < self.game_map.height
+                and self.game_map.tiles[pos.y + dy, pos.x + dx] == TILE_WATER
+            ),
+            None,
+        )
+        if water is None:
+            self.log("There is no water here to fish in.", (150, 150, 150))
+            return
+        # A slow connection gets a little longer to answer the bite
+        grace = (self.latency.rtt_ms(self.player_name()) or 0) / 1000.0
+        try:
+            self.fishing.cast(
+                self.catch_table(water[0], water[1], pos.z),
+                self.tick * self.fixed_timestep,
+                grace,
+            )
+        except FishingError as e:
+            self.log(str(e), (150, 150, 150))
+            return
+        self.log("You cast your line into the water.", (120, 200, 255))
+
+    def reel_in(self):
+        """Reel the line in; a catch if something was on the hook."""
+        from data.loader import DATA_LOADER
+        from entities.components import Inventory, Item, Skills
+
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        skills = self.entity_manager.get_component(self.player_id, Skills)
+        if not inv or not skills:
+            return
+
+        def requirement(item_type: str) -> int:
+            return int((DATA_LOADER.get_item_data(item_type) or {}).get("fishing_skill", 1))
+
+        try:
+            caught = self.fishing.reel(
+                self.tick * self.fixed_timestep, skills.fishing, requirement
+            )
+        except FishingError as e:
+            self.log(str(e), (150, 150, 150))
+            return
+        if caught is None:
+            self.log("You reel in an empty hook.", (150, 150, 150))
+            return
+
+        made = self.entity_wrapper.factory.create_item(0, 0, caught)
+        self.entity_manager.remove_component(made, Position)
+        try:
+            self.transaction("fishing").add_item(inv, made).commit()
+        except TransactionError:
+            self.entity_manager.destroy_entity(made)
+            self.log("Your catch slips back into the water.", (150, 150, 150))
+            return
+
+        name = self.entity_manager.get_component(made, Item).name
+        rare = bool((DATA_LOADER.get_item_data(caught) or {}).get("rare"))
+        if rare:
+            self.log(f"A rare catch! You land {name}.", (255, 215, 0))
+        else:
+            self.log(f"You catch {name}.", (100, 255, 100))
+        self.events.publish(FishCaught(self.player_id, caught, name, rare))
+
+        skills.fishing_xp += FISHING_XP + requirement(caught)
+        if skills.fishing_xp >= skills.xp_for_next_level(skills.fishing):
+            skills.fishing += 1
+            skills.fishing_xp = 0
+            self.log(f"Fishing Skill Up! {skills.fishing}", (120, 200, 255))
+
     def handle_bank_transaction(self):
         """Handle depositing or withdrawing gold and items."""
         from entities.components import Inventory, BankAccount
@@ -1062,47 +4064,204 @@ class GameEngine:
             return
 
         if self.bank_mode == "DEPOSIT":
-            # Selection 0 is Gold deposit (10g chunks), otherwise items
-            if self.bank_selection == 0:
-                amount = min(10, player_inv.gold)
-                if amount > 0:
-                    player_inv.gold -= amount
-                    bank_acc.gold += amount
-                    self.log(f"Deposited {amount} gold.", (200, 200, 255))
-            else:
-                item_idx = self.bank_selection - 1
-                if 0 <= item_idx < len(player_inv.items):
-                    if len(bank_acc.items) < bank_acc.capacity:
-                        item_id = player_inv.items.pop(item_idx)
-                        bank_acc.items.append(item_id)
-                        self.log("Deposited item.", (200, 200, 255))
-                        if self.bank_selection > len(player_inv.items):
-                            self.bank_selection = len(player_inv.items)
-                    else:
-                        self.log("Bank vault is full!", (255, 100, 100))
-
+            source, target = player_inv, bank_acc
+            verb, full_msg = "Deposited", "Bank vault is full!"
         elif self.bank_mode == "WITHDRAW":
-            # Selection 0 is Gold withdraw (10g chunks), otherwise items
-            if self.bank_selection == 0:
-                amount = min(10, bank_acc.gold)
-                if amount > 0:
-                    bank_acc.gold -= amount
-                    player_inv.gold += amount
-                    self.log(f"Withdrew {amount} gold.", (200, 200, 255))
-            else:
-                item_idx = self.bank_selection - 1
-                if 0 <= item_idx < len(bank_acc.items):
-                    if len(player_inv.items) < player_inv.capacity:
-                        item_id = bank_acc.items.pop(item_idx)
-                        player_inv.items.append(item_id)
-                        self.log("Withdrew item.", (200, 200, 255))
-                        if self.bank_selection > len(bank_acc.items):
-                            self.bank_selection = len(bank_acc.items)
-                    else:
-                        self.log("Inventory is full!", (255, 100, 100))
+            source, target = bank_acc, player_inv
+            verb, full_msg = "Withdrew", "Inventory is full!"
+        else:
+            return
+
+        # Selection 0 moves gold (10g chunks), otherwise items
+        if self.bank_selection == 0:
+            amount = min(10, source.gold)
+            if amount > 0:
+                self.transaction("bank").transfer_gold(source, target, amount).commit()
+                self.log(f"{verb} {amount} gold.", (200, 200, 255))
+        else:
+            item_idx = self.bank_selection - 1
+            if 0 <= item_idx < len(source.items):
+                item_id = source.items[item_idx]
+                try:
+                    self.transaction("bank").move_item(source, target, item_id).commit()
+                except TransactionError:
+                    self.log(full_msg, (255, 100, 100))
+                    return
+                self.log(f"{verb} item.", (200, 200, 255))
+                if self.bank_selection > len(source.items):
+                    self.bank_selection = len(source.items)
+
+    def banker_nearby(self) -> bool:
+        """Whether the player stands next to a banker, where the stash is reached."""
+        from entities.components import Banker
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return False
+        for eid in self.entity_manager.get_entities_with_components(Banker, Position):
+            at = self.entity_manager.get_component(eid, Position)
+            if at.z == pos.z and max(abs(at.x - pos.x), abs(at.y - pos.y)) <= 1:
+                return True
+        return False
+
+    def stash_deposit(self, item: int):
+        """Move a carried item into the account stash; bound items are refused."""
+        self.move_stash_item(item, to_stash=True)
+
+    def stash_withdraw(self, item: int):
+        """Move an item from the account stash into the player's pack."""
+        self.move_stash_item(item, to_stash=False)
+
+    def move_stash_item(self, item_id: int, to_stash: bool):
+        from entities.components import Inventory, Item
+
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        if not inventory:
+            return
+        if not self.banker_nearby():
+            self.log("The account stash is kept by the bankers.", (150, 150, 150))
+            return
+        source, target = inventory, self.stash
+        if not to_stash:
+            source, target = target, source
+        if item_id not in source.items:
+            where = "carrying" if to_stash else "keeping in the stash"
+            self.log(f"You are not {where} that.", (150, 150, 150))
+            return
+        try:
+            self.transaction("stash").move_item(source, target, item_id).commit()
+        except TransactionError as e:
+            self.log(f"It will not go: {e}.", (255, 100, 100))
+            return
+        name = self.entity_manager.get_component(item_id, Item).name
+        verb = "stash" if to_stash else "take from the stash"
+        self.log(f"You {verb} {name}.", (200, 200, 255))
+        self.stash.save(self.entity_manager)
+
+    def stash_message(self) -> dict:
+        """What the account stash holds, and whether a banker is near to reach it."""
+        return {
+            "type": "stash",
+            "capacity": self.stash.capacity,
+            "reachable": self.banker_nearby(),
+            "items": [
+                {"id": eid, **item_details(self.entity_manager, eid)}
+                for eid in self.stash.items
+            ],
+        }
+
+    def wallet_message(self) -> dict:
+        """Every currency the player holds, and their coin in all."""
+        from entities.components import Inventory
+
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        held = balances(inv) if inv else {}
+        return {
+            "type": "wallet",
+            "gold": held.get(GOLD, 0),
+            "coins": format_coins(coin_worth(inv)) if inv else format_coins(0),
+            "currencies": {
+                cid: {"name": CURRENCIES[cid].name, "amount": amount}
+                for cid, amount in held.items()
+                if cid in CURRENCIES
+            },
+        }
+
+    def show_wallet(self):
+        """Log what the player holds of each currency."""
+        reply = self.wallet_message()
+        self.log(f"Coin: {reply['coins']}", (255, 215, 0))
+        for cid, entry in reply["currencies"].items():
+            if not CURRENCIES[cid].coin_value:
+                self.log(f"{entry['name']}: {entry['amount']}", (200, 200, 255))
+
+    def exchange_coins(self, coin: str, into: str, amount: int) -> dict:
+        """Change coins up or down; odd coins that make no whole coin are kept."""
+        from entities.components import Inventory
+
+        amount = int(amount)
+        try:
+            received, left = exchange(coin, into, amount)
+        except CurrencyError as e:
+            return {"type": "error", "error": str(e)}
+        if received <= 0:
+            return {"type": "error", "error": f"Not enough {coin} to make one {into}"}
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        try:
+            self.transaction("currency_exchange").remove_currency(
+                inv, coin, amount - left
+            ).add_currency(inv, into, received).commit()
+        except TransactionError as e:
+            return {"type": "error", "error": str(e)}
+        return self.wallet_message()
+
+    def grant_currency(self, eid: int, amounts: Dict[str, int], reason: str) -> bool:
+        """Give a player currencies in one transaction; for shops and events."""
+        from entities.components import Inventory
+
+        inv = self.entity_manager.get_component(eid, Inventory)
+        if not inv:
+            return False
+        try:
+            self.transaction(reason).grant(inv, amounts).commit()
+        except TransactionError:
+            return False
+        self.economy.record_created(reason, amounts.get(GOLD, 0))
+        return True
+
+    def spend_currency(self, eid: int, costs: Dict[str, int], reason: str) -> bool:
+        """Take a price in one or more currencies, all of it or none of it."""
+        from entities.components import Inventory
+
+        inv = self.entity_manager.get_component(eid, Inventory)
+        if not inv:
+            return False
+        try:
+            self.transaction(reason).spend(inv, costs).commit()
+        except TransactionError:
+            return False
+        self.economy.record_destroyed(reason, costs.get(GOLD, 0))
+        return True
+
+    def grant_currency_request(
+        self, currency: str, amount: int, player: Optional[str] = None
+    ) -> dict:
+        eid = self.player_id if player is None else self.find_player(player)
+        if eid is None:
+            return {"type": "error", "error": f"No player called {player}"}
+        if currency not in CURRENCIES:
+            return {"type": "error", "error": f"Unknown currency: {currency}"}
+        if not self.grant_currency(eid, {currency: int(amount)}, "admin_grant"):
+            return {"type": "error", "error": f"Could not grant {currency}"}
+        return self.wallet_message()
+
+    def recover_transactions(self):
+        """Roll back transactions a crash interrupted, once the player is loaded."""
+        if not self.journal:
+            return
+        rolled_back = self.journal.recover(
+            self.entity_manager, self.player_id, {"AccountStash": self.stash}
+        )
+        for record in rolled_back:
+            print(f"Rolled back interrupted transaction {record['txid']} ({record['reason']})")
+            if self.audit:
+                self.audit.record(
+                    "transaction_rolled_back", "server", record["txid"], reason=record["reason"]
+                )
+        if rolled_back:
+            self.save_player_profile()
+            self.stash.save(self.entity_manager)
+
+    def transaction(self, reason: str = "") -> ItemTransaction:
+        """Start a journaled item/gold transaction."""
+        return ItemTransaction(
+            self.entity_manager, self.journal, reason, self.audit, self.transaction_committed,
+            player=self.player_id,
+        )
 
     def use_inventory_item(self):
         """Use or equip the selected item."""
+        from data.loader import DATA_LOADER
         from entities.components import (
             Inventory,
             Consumable,
@@ -1126,6 +4285,18 @@ class GameEngine:
         item_id = player_inv.items[self.inventory_selection]
         item_comp = self.entity_manager.get_component(item_id, Item)
 
+        # Items with an on_use script in items.json do what it says
+        data = DATA_LOADER.get_item_data(item_comp.item_type) or {}
+        if data.get("on_use"):
+            if self.run_content_script(data["on_use"], item_id) and data.get(
+                "consumed", True
+            ):
+                player_inv.items.remove(item_id)
+                self.entity_manager.destroy_entity(item_id)
+                if self.inventory_selection >= len(player_inv.items):
+                    self.inventory_selection = max(0, len(player_inv.items) - 1)
+            return
+
         # Check for Consumable
         consumable = self.entity_manager.get_component(item_id, Consumable)
         if consumable:
@@ -1153,6 +4324,8 @@ class GameEngine:
             # Equip it
             equip = self.entity_manager.get_component(self.player_id, Equipment)
             if equip:
+                if not self.can_unequip(equip.weapon):
+                    return
                 # Unequip old weapon if any
                 if equip.weapon is not None:
                     old_weapon_name = "Unknown"
@@ -1174,6 +4347,8 @@ class GameEngine:
                     f"Equipped {item_comp.name} ({weapon_stats.weapon_type}).",
                     (100, 200, 255),
                 )
+                self.announce_curse(item_id)
+                self.bind_item(item_id, BIND_ON_EQUIP)
 
                 # Adjust selection
                 if self.inventory_selection >= len(player_inv.items):
@@ -1190,6 +4365,8 @@ class GameEngine:
 
                 # Get the current item in that slot
                 old_item_id = getattr(equip, slot, None)
+                if not self.can_unequip(old_item_id):
+                    return
 
                 # Unequip old armor if any
                 if old_item_id is not None:
@@ -1207,10 +4384,15 @@ class GameEngine:
                 # Equip new armor in the specific slot
                 setattr(equip, slot, item_id)
 
+                # An unidentified piece keeps its defense to itself
+                stats = f" (+{armor_stats.defense} Def)"
+                if not is_identified(self.entity_manager, item_id):
+                    stats = ""
                 self.log(
-                    f"Equipped {item_comp.name} to {slot} (+{armor_stats.defense} Def).",
-                    (100, 200, 255),
+                    f"Equipped {item_comp.name} to {slot}{stats}.", (100, 200, 255)
                 )
+                self.announce_curse(item_id)
+                self.bind_item(item_id, BIND_ON_EQUIP)
 
                 # Adjust selection
                 if self.inventory_selection >= len(player_inv.items):
@@ -1219,24 +4401,116 @@ class GameEngine:
 
         self.log(f"You can't use {item_comp.name}.", (150, 150, 150))
 
-    def swap_weapon(self):
-        """Cycle through weapon types (Rucoy style: Melee -> Distance -> Magic)."""
-        from entities.components import Equipment
+    def bind_item(self, item_id: int, event: str):
+        """Bind an item to the player if event (pickup or equip) is what binds it."""
+        from entities.components import Item
+
+        item = self.entity_manager.get_component(item_id, Item)
+        if item and bind_on(item, event, self.player_name()):
+            self.log(f"{item.name} is now bound to you.", (200, 170, 255))
+
+    def can_unequip(self, item_id: Optional[int]) -> bool:
+        """Whether a worn item can come off; cursed ones are bound to the wearer."""
+        from entities.components import Item
+
+        if item_id is None or not is_cursed(self.entity_manager, item_id):
+            return True
+        name = self.entity_manager.get_component(item_id, Item).name
+        self.log(f"Your {name} is cursed and will not come off!", (200, 80, 200))
+        return False
+
+    def announce_curse(self, item_id: int):
+        if is_cursed(self.entity_manager, item_id):
+            self.log("A chill runs through you: it is cursed!", (200, 80, 200))
+
+    def swap_weapon(self):
+        """Cycle through weapon types (Rucoy style: Melee -> Distance -> Magic)."""
+        from entities.components import Equipment
+
+        if self.player_id is None:
+            return
+
+        equip = self.entity_manager.get_component(self.player_id, Equipment)
+        if equip:
+            # Cycle types
+            if equip.weapon_type == "melee":
+                equip.weapon_type = "distance"
+            elif equip.weapon_type == "distance":
+                equip.weapon_type = "magic"
+            else:
+                equip.weapon_type = "melee"
+
+            self.log(f"Switched to {equip.weapon_type.capitalize()}.", (255, 255, 0))
+
+    def move_to(self, target_x: int, target_y: int) -> bool:
+        """Plan a walk to a destination; the player then steps along it each tick."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos or self.game_map is None:
+            return False
+
+        # Never route through hazards; the player has to step into those deliberately
+        swimming = self.get_swimming_skill()
+        path = find_path(
+            self.game_map,
+            (pos.x, pos.y),
+            (target_x, target_y),
+            blocked=self.occupancy.blocker_for(self.player_id),
+            max_nodes=CONFIG.max_path_length * 20,
+            passable=lambda x, y: self.game_map.can_enter(
+                x, y, swimming, allow_hazards=False
+            ),
+            move_cost=self.game_map.move_cost,
+        )
+        if not path or len(path) > CONFIG.max_path_length:
+            self.log("You can't find a way there.", (150, 150, 150))
+            return False
+
+        self.auto_path = deque(path)
+        # Take the first step on the next tick
+        self.auto_move_timer = CONFIG.auto_move_delay
+        return True
+
+    def step_auto_path(self, dt: float):
+        """Advance the player one tile along the planned path when the step timer is due."""
+        if self.game_state != "PLAYING":
+            self.auto_path.clear()
+            return
+
+        self.auto_move_timer += dt
+        if self.auto_move_timer < CONFIG.auto_move_delay:
+            return
+        self.auto_move_timer = 0.0
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            self.auto_path.clear()
+            return
+
+        next_x, next_y = self.auto_path.popleft()
+        dx, dy = next_x - pos.x, next_y - pos.y
 
-        if self.player_id is None:
+        # Stop rather than bump into whatever stepped into the way
+        rule, _ = self.occupancy.check_move(self.player_id, next_x, next_y)
+        if max(abs(dx), abs(dy)) != 1 or rule == "block":
+            self.auto_path.clear()
+            self.log("Something blocks your path.", (150, 150, 150))
             return
 
-        equip = self.entity_manager.get_component(self.player_id, Equipment)
-        if equip:
-            # Cycle types
-            if equip.weapon_type == "melee":
-                equip.weapon_type = "distance"
-            elif equip.weapon_type == "distance":
-                equip.weapon_type = "magic"
-            else:
-                equip.weapon_type = "melee"
+        self.move_player(dx, dy)
 
-            self.log(f"Switched to {equip.weapon_type.capitalize()}.", (255, 255, 0))
+        if (pos.x, pos.y) == (next_x - dx, next_y - dy):
+            # Struggled through deep terrain; try the same step again
+            self.auto_path.appendleft((next_x, next_y))
+        elif (pos.x, pos.y) != (next_x, next_y):
+            # Sliding, knockback or a respawn invalidates the rest of the path
+            self.auto_path.clear()
+
+    def get_swimming_skill(self) -> int:
+        """Get the player's swimming level (0 if they cannot swim)."""
+        from entities.components import Skills
+
+        skills = self.entity_manager.get_component(self.player_id, Skills)
+        return skills.swimming if skills else 0
 
     def move_player(self, dx: int, dy: int):
         """Move the player by the given amount."""
@@ -1256,49 +4530,34 @@ class GameEngine:
         new_x = max(0, min(new_x, self.game_map.width - 1))
         new_y = max(0, min(new_y, self.game_map.height - 1))
 
-        # Check for monsters at new position
-        monsters = self.entity_wrapper.get_monsters_at_position(new_x, new_y)
-        if monsters:
+        # Check who holds the new position: hostiles and bystanders block
+        # (bumping them attacks or talks), party members swap places
+        rule, occupant = self.occupancy.check_move(self.player_id, new_x, new_y)
+        if rule == "block":
+            if occupant not in self.spatial_index.monsters:
+                self.log("Something blocks your way.", (150, 150, 150))
+                return
+
             # Check AI type before attacking
-            monster_id = monsters[0]
+            monster_id = occupant
+            from data.loader import DATA_LOADER
             from entities.components import Monster
-            import random
 
             monster_comp = self.entity_manager.get_component(monster_id, Monster)
 
             if monster_comp and monster_comp.ai_type == "passive":
                 # Give passive NPCs a purpose through interaction
-                if monster_comp.name.lower() == "dog":
-                    self.log(
-                        "The Dog barks happily and wags its tail.", (200, 150, 100)
-                    )
-                    self.vfx_system.add_floating_text(
-                        new_x, new_y, "Woof!", (255, 255, 255)
-                    )
-                elif monster_comp.name.lower() == "citizen":
-                    tips = [
-                        "Stay on the paths to avoid danger.",
-                        "I hear the oasis in the desert has strange ruins.",
-                        "Watch out for the lava, it will melt you!",
-                        "Ice is slippery, be careful!",
-                        "Cacti hurt if you bump into them.",
-                        "Shopkeepers pay good gold for loot.",
-                    ]
-                    self.log(
-                        f"{monster_comp.name} says: '{random.choice(tips)}'",
-                        (150, 255, 150),
-                    )
-
-                    # Small chance to drop a random minor item when talked to for the first time?
-                    # Keep it simple: 5% chance to drop a potion
-                    if random.random() < 0.05:
+                data = DATA_LOADER.get_monster_data(monster_comp.monster_type) or {}
+                if monster_comp.monster_type == "tutor":
+                    if not self.tutorial_event("talk", "tutor"):
+                        hint = self.tutorial.hint(self.player_id)
                         self.log(
-                            f"{monster_comp.name} drops something for you!",
-                            (255, 215, 0),
-                        )
-                        self.entity_wrapper.factory.create_item(
-                            new_x, new_y, "health_potion"
+                            f"Tutor says: '{hint or 'Good luck out there!'}'",
+                            (150, 255, 200),
                         )
+                elif data.get("on_talk"):
+                    # What the NPC says and does is scripted in monsters.json
+                    self.run_content_script(data["on_talk"], monster_id)
                 else:
                     self.log(
                         f"{monster_comp.name} looks at you curiously.", (100, 255, 100)
@@ -1308,85 +4567,548 @@ class GameEngine:
                 self.handle_combat(self.player_id, monster_id)
             return
 
-        # Check if the new position is walkable
-        if self.game_map.is_walkable(new_x, new_y):
-            # Handle Ice sliding
-            from world.map import (
-                TILE_ICE,
-                TILE_LAVA,
-                TILE_CACTUS,
-                TILE_SNOW,
-                TILE_SAND,
-                TILE_ASH,
+        # Check if the terrain can be entered
+        target_def = self.game_map.get_tile(new_x, new_y)
+        if not self.game_map.can_enter(new_x, new_y, self.get_swimming_skill()):
+            self.bump_terrain(target_def, new_x, new_y)
+            return
+
+        import random
+
+        current_def = self.game_map.get_tile(pos.x, pos.y)
+
+        # Terrain Movement Penalties (Struggling to move)
+        swimming = self.get_swimming_skill()
+        if random.random() < struggle_chance(current_def, swimming):
+            self.log(
+                f"You struggle to move through the deep {current_def.name}...",
+                (150, 150, 150),
             )
-            import random
+            return
+
+        # Slippery tiles: keep sliding in the same direction until hitting
+        # a non-slippery tile, an obstacle or another entity
+        if target_def.slippery:
+            self.log(f"You slip on the {target_def.name}!", (150, 200, 255))
+            slide_x, slide_y = new_x, new_y
+            while True:
+                next_x, next_y = slide_x + dx, slide_y + dy
+                next_def = self.game_map.get_tile(next_x, next_y)
+                if (
+                    next_def is not None
+                    and next_def.slippery
+                    and self.game_map.is_walkable(next_x, next_y)
+                    and self.occupancy.check_move(self.player_id, next_x, next_y)[0]
+                    == "free"
+                ):
+                    slide_x, slide_y = next_x, next_y
+                else:
+                    break
+            new_x, new_y = slide_x, slide_y
+            target_def = self.game_map.get_tile(new_x, new_y)
+
+        # A swapped companion takes the tile the player is leaving
+        if rule == "swap":
+            occupant_pos = self.entity_manager.get_component(occupant, Position)
+            occupant_pos.x, occupant_pos.y = pos.x, pos.y
+            self.entity_manager.notify_component_change(occupant, Position)
+
+        # Update the player's position
+        from_x, from_y = pos.x, pos.y
+        pos.x = new_x
+        pos.y = new_y
+        self.entity_manager.notify_component_change(self.player_id, Position)
+        self.stealth_system.moved(self.player_id)
+        self.events.publish(PlayerMoved(self.player_id, new_x, new_y, from_x, from_y))
 
-            current_tile = self.game_map.tiles[pos.y, pos.x]
-            target_tile = self.game_map.tiles[new_y, new_x]
+        # Swimming practice
+        if target_def.swim_skill and self.swimming_system.swam(self.player_id):
+            self.log(f"Swimming Skill Up! {swimming + 1}", (100, 150, 255))
 
-            # Terrain Movement Penalties (Struggling to move)
-            if current_tile in [TILE_SNOW, TILE_SAND, TILE_ASH]:
-                if random.random() < 0.25:  # 25% chance to lose footing/struggle
-                    self.log(
-                        f"You struggle to move through the deep {self.game_map.tile_definitions[current_tile].name.lower()}...",
-                        (150, 150, 150),
-                    )
+        # Environmental Hazards
+        if target_def.damage > 0:
+            from entities.components import Health
+
+            health = self.entity_manager.get_component(self.player_id, Health)
+            if health:
+                damage = max(5, int(health.maximum * target_def.damage))
+                health.current -= damage
+                self.log(f"You step in the {target_def.name}! It burns!", (255, 50, 50))
+                intensity = 2.0 + (damage / health.maximum) * 30.0
+                self.renderer.trigger_shake(min(15.0, intensity), 0.2)
+                if health.current <= 0:
+                    self.log(f"You perished in the {target_def.name}...", (255, 50, 50))
+                    self.respawn_player(f"perished in the {target_def.name}")
                     return
 
-            # Slippery Ice logic: keep sliding in the same direction until hitting a non-ice tile or wall
-            if target_tile == TILE_ICE:
-                self.log("You slip on the ice!", (150, 200, 255))
-                slide_x, slide_y = new_x, new_y
-                while True:
-                    next_x, next_y = slide_x + dx, slide_y + dy
-                    if (
-                        self.game_map.is_walkable(next_x, next_y)
-                        and self.game_map.tiles[next_y, next_x] == TILE_ICE
-                    ):
-                        slide_x, slide_y = next_x, next_y
-                    else:
-                        break
-                new_x, new_y = slide_x, slide_y
-                target_tile = self.game_map.tiles[new_y, new_x]
+        # Auto-loot (a player setting) takes what lies on the new tile
+        if self.settings["auto_loot"] and self.entity_wrapper.get_items_at_position(
+            new_x, new_y
+        ):
+            self.pickup_item()
 
-            # Update the player's position
-            pos.x = new_x
-            pos.y = new_y
-            self.entity_manager.notify_component_change(self.player_id, Position)
+        # Waypoint attunement, portals and stairs to other levels
+        self.check_teleport_tiles()
+        self.check_stairs()
+        self.check_poi_discovery()
 
-            # Environmental Hazards
-            if target_tile == TILE_LAVA:
-                from entities.components import Health
+        # Update FOV after movement
+        self.update_fov()
 
-                health = self.entity_manager.get_component(self.player_id, Health)
-                if health:
-                    damage = max(5, int(health.maximum * 0.05))
-                    health.current -= damage
-                    self.log("You step in lava! It burns!", (255, 50, 50))
-                    intensity = 2.0 + (damage / health.maximum) * 30.0
-                    self.renderer.trigger_shake(min(15.0, intensity), 0.2)
-                    if health.current <= 0:
-                        self.log("You melted in the lava...", (255, 50, 50))
-                        self.respawn_player()
-                        return
-            elif target_tile == TILE_CACTUS:
-                from entities.components import Health
+        # Periodically update active region (e.g., every 10 steps)
+        if (pos.x % 10 == 0) or (pos.y % 10 == 0):
+            self.update_active_region()
 
-                health = self.entity_manager.get_component(self.player_id, Health)
-                if health:
-                    health.current -= 2
-                    self.log("You prick yourself on a cactus.", (200, 255, 100))
-                    self.renderer.trigger_shake(2.0, 0.1)
-                    if health.current <= 0:
-                        self.respawn_player()
-                        return
+    def check_teleport_tiles(self, allow_portal: bool = True):
+        """Attune to a waypoint or step through a portal on the player's tile."""
+        from entities.components import Attunement
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return
+
+        name = self.teleport.waypoint_at(pos.x, pos.y, pos.z)
+        attunement = self.entity_manager.get_component(self.player_id, Attunement)
+        if name and attunement and name not in attunement.waypoints:
+            attunement.waypoints.append(name)
+            self.log(f"You attune to the {name} waypoint.", (120, 220, 255))
+
+        target = self.teleport.portal_at(pos.x, pos.y, pos.z) if allow_portal else None
+        if not target:
+            return
+        tx, ty, tz = target
+        if tz not in self.levels:
+            self.log("The portal hums, but leads nowhere you can follow.", (200, 120, 255))
+            return
+        here = pos.z
+        self.set_level(tz)  # Whoever blocks the other side is on its level
+        if self.occupancy.check_move(self.player_id, tx, ty)[0] == "block":
+            self.set_level(here)
+            self.log("The portal flickers; something blocks the other side.", (200, 120, 255))
+            return
+        self.teleport_player(tx, ty)
+        self.log("You step through the portal.", (200, 120, 255))
+
+    def check_stairs(self):
+        """Climb or descend when the player steps onto stairs to another level.
+
+        Stairs line up between levels, so the way back is underfoot on arrival.
+        """
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return
+
+        tile = self.game_map.tiles[pos.y, pos.x]
+        if tile == TILE_STAIRS_DOWN:
+            z, arrival = pos.z + 1, TILE_STAIRS_UP
+        elif tile == TILE_STAIRS_UP:
+            z, arrival = pos.z - 1, TILE_STAIRS_DOWN
+        else:
+            return
+        level = self.levels.get(z)
+        if level is None or level.tiles[pos.y, pos.x] != arrival:
+            return
+
+        self.set_level(z)
+        self.teleport_player(pos.x, pos.y)
+        if z == 0:
+            self.log("You climb back up into the open air.", (200, 200, 160))
+        else:
+            self.log(f"You climb down into the caves (depth {z}).", (170, 150, 120))
+
+    def set_level(self, z: int):
+        """Put the player on another Z-level, swapping in that level's map."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos or z not in self.levels or pos.z == z:
+            return
+        pos.z = z
+        self.game_map = self.levels[z]
+        self.entity_manager.notify_component_change(self.player_id, Position)
+        self._last_fov_pos = None  # Same spot, different map
+
+    def teleport_player(self, x: int, y: int):
+        """Move the player instantly to a position and refresh the view."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return
+        pos.x, pos.y = x, y
+        self.entity_manager.notify_component_change(self.player_id, Position)
+        self.server_moved_player(x, y)
+        self.auto_path.clear()
+        self.check_poi_discovery()
+        self.update_active_region()
+        self.update_fov()
+
+    def open_travel_menu(self):
+        """Show attuned waypoints and their costs, if standing on a waypoint."""
+        from entities.components import Attunement
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        attunement = self.entity_manager.get_component(self.player_id, Attunement)
+        if not pos or not attunement:
+            return
+
+        if not self.teleport.waypoint_at(pos.x, pos.y, pos.z):
+            self.log("You must stand on a waypoint to travel.", (150, 150, 150))
+            return
+
+        self.travel_options = self.teleport.destinations(
+            attunement.waypoints, pos.x, pos.y, pos.z
+        )
+        if not self.travel_options:
+            self.log("You are not attuned to any other waypoints.", (150, 150, 150))
+            return
+
+        self.game_state = "TRAVEL"
+        self.inventory_selection = 0
+
+    def begin_travel(self, name: str, cost: int):
+        """Start channeling a jump to a waypoint."""
+        from entities.components import Inventory
+
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        if not inv or inv.gold < cost:
+            self.log(f"Travel to {name} costs {cost}g.", (255, 100, 100))
+            return
+
+        self.travel_cast = {"name": name, "cost": cost, "remaining": self.teleport.cast_time}
+        self.log(f"You begin channeling towards {name}...", (120, 220, 255))
+
+    def update_travel_cast(self, dt: float):
+        """Count down a waypoint jump and perform it when the channel completes."""
+        from entities.components import Inventory
+
+        if self.game_state != "PLAYING":
+            return
+
+        self.travel_cast["remaining"] -= dt
+        if self.travel_cast["remaining"] > 0:
+            return
+
+        cast, self.travel_cast = self.travel_cast, None
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        try:
+            self.transaction("travel").remove_gold(inv, cast["cost"]).commit()
+        except TransactionError:
+            self.log(f"Travel to {cast['name']} costs {cast['cost']}g.", (255, 100, 100))
+            return
+
+        self.economy.record_destroyed("travel", cast["cost"])
+        x, y, z = self.teleport.waypoints[cast["name"]]
+        self.set_level(z)
+        self.teleport_player(x, y)
+        self.log(f"You arrive at {cast['name']}. (-{cast['cost']}g)", (120, 220, 255))
+
+    def find_bind_points(self, world) -> List[HomePoint]:
+        """The shrines' altars and every town's inn, where players can bind."""
+        points = [
+            HomePoint(poi.name, "shrine", poi.x, poi.y)
+            for poi in world.pois.search(kind="shrine")
+            if poi.z == 0
+        ]
+        for town in world.settlements:
+            for building in town.buildings:
+                if building.use == "inn":
+                    name = f"{town.name} Inn"
+                    points.append(HomePoint(name, "inn", *building.inside))
+        return points
+
+    def bind_home(self) -> bool:
+        """Make the inn or shrine beside the player their home; False if none is."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos or pos.z != 0 or self.in_tutorial:
+            return False
+        point = bind_point_near(self.bind_points, pos.x, pos.y)
+        if not point:
+            return False
+
+        if self.homes.home(self.world_name) == point:
+            self.log(f"{point.name} is already your home.", (150, 150, 150))
+            return True
+        self.homes.bind(self.world_name, point)
+        self.log(
+            f"{point.name} is now your home. You will return here when you fall.",
+            (120, 220, 255),
+        )
+        self.save_player_profile()
+        return True
+
+    def recall(self):
+        """Teleport to the player's home point, then wait out a long cooldown."""
+        home = self.homes.home(self.world_name)
+        if not home:
+            self.log("You have no home. Bind at an inn or a shrine.", (150, 150, 150))
+            return
+        if self.in_tutorial:
+            self.log("Recall does not work in the Tutorial Grounds.", (150, 150, 150))
+            return
+        remaining = self.homes.recall_remaining()
+        if remaining > 0:
+            minutes = math.ceil(remaining / 60)
+            self.log(f"Recall is ready in {minutes} min.", (150, 150, 150))
+            return
+
+        self.homes.use_recall()
+        self.travel_cast = None
+        self.set_level(0)
+        self.teleport_player(*self.free_tile_near(home.x, home.y))
+        self.log(f"You recall to {home.name}.", (120, 220, 255))
+        self.save_player_profile()
+
+    def free_tile_near(self, x: int, y: int, radius: int = 10) -> Tuple[int, int]:
+        """Closest walkable tile to a position that nobody is standing on."""
+        for r in range(radius + 1):
+            for dy in range(-r, r + 1):
+                for dx in range(-r, r + 1):
+                    tx, ty = x + dx, y + dy
+                    if max(abs(dx), abs(dy)) != r:
+                        continue
+                    free = not self.spatial_index.is_occupied(tx, ty)
+                    if self.game_map.is_walkable(tx, ty) and free:
+                        return tx, ty
+        return nearest_walkable(self.game_map, x, y, radius)
+
+    def home_info(self) -> dict:
+        home = self.homes.home(self.world_name)
+        return {
+            "type": "home",
+            "home": home.to_dict() if home else None,
+            "recall_ready_in": math.ceil(self.homes.recall_remaining()),
+        }
+
+    def check_region_entry(self, x: int, y: int):
+        """Announce the region's name when the player crosses into it."""
+        region = self.regions.region_at(x, y)
+        if region.id == self.current_region_id:
+            return
+
+        self.current_region_id = region.id
+        rules = "PvP enabled" if region.pvp else "Safe from PvP"
+        if region.safe:
+            rules = "Safe zone"
+        self.log(f"Entering {region.name} ({rules})", (255, 215, 120))
+        self.vfx_system.add_floating_text(x, y - 1, region.name, (255, 215, 120), duration=2.0)
+
+    def zone_info(self) -> Optional[dict]:
+        """Describe the player's current region for clients and UI."""
+        if not self.regions:
+            return None
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return None
+        return self.regions.region_at(pos.x, pos.y).info()
+
+    def zone_info_message(self) -> dict:
+        """Build a zone_info message for the player's current region."""
+        info = self.zone_info() or {}
+        return dict(info, type="zone_info")
+
+    def show_zone_info(self):
+        """Log the rules of the player's current region."""
+        info = self.zone_info()
+        if not info:
+            self.log("You are nowhere in particular.", (150, 150, 150))
+            return
+
+        self.log(f"Region: {info['name']}", (255, 215, 120))
+        if info["safe"]:
+            self.log("Safe zone: no fighting, and monsters keep out.", (150, 255, 150))
+        self.log(
+            f"PvP: {'on' if info['pvp'] else 'off'}  Ambience: {info['ambience'] or 'none'}  "
+            f"Music: {info['music'] or 'none'}",
+            (200, 200, 200),
+        )
+        if info["spawns"]:
+            self.log(f"Creatures: {', '.join(info['spawns'])}", (200, 200, 200))
+
+    def check_poi_discovery(self):
+        """Discover nearby points of interest, unlocking any waypoint they carry."""
+        from entities.components import Attunement, Discoveries
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        discoveries = self.entity_manager.get_component(self.player_id, Discoveries)
+        if not pos or not discoveries:
+            return
+
+        attunement = self.entity_manager.get_component(self.player_id, Attunement)
+        for poi in self.pois.within(pos.x, pos.y, DISCOVERY_RADIUS, pos.z):
+            if poi.id in discoveries.pois:
+                continue
+            discoveries.pois.append(poi.id)
+            self.log(f"You discover {poi.name}.", (255, 215, 120))
+            if poi.waypoint and attunement and poi.waypoint not in attunement.waypoints:
+                attunement.waypoints.append(poi.waypoint)
+                self.log(f"You attune to the {poi.waypoint} waypoint.", (120, 220, 255))
+
+    def poi_query(self, text: str = "", kind: Optional[str] = None, limit: int = 10) -> list:
+        """Search discovered points of interest, nearest first."""
+        from entities.components import Discoveries
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        discoveries = self.entity_manager.get_component(self.player_id, Discoveries)
+        if not pos or not discoveries:
+            return []
+
+        results = []
+        for poi in self.pois.search(text, kind, near=(pos.x, pos.y)):
+            if poi.id not in discoveries.pois:
+                continue
+            entry = poi.to_dict()
+            entry["distance"] = int(poi.distance_to(pos.x, pos.y))
+            results.append(entry)
+            if len(results) >= limit:
+                break
+        return results
+
+    def poi_message(self, text: str = "", kind: Optional[str] = None) -> dict:
+        """Build a poi_results message for a landmark search."""
+        kind = None if kind is None else str(kind)
+        return {"type": "poi_results", "results": self.poi_query(str(text), kind)}
+
+    def show_landmarks(self):
+        """Log the nearest discovered points of interest."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        results = self.poi_query(limit=3)
+        if not pos or not results:
+            self.log("You have not discovered any landmarks yet.", (150, 150, 150))
+            return
+
+        for entry in results:
+            dx, dy = entry["x"] - pos.x, entry["y"] - pos.y
+            ns = "N" if dy < 0 else "S" if dy > 0 else ""
+            ew = "W" if dx < 0 else "E" if dx > 0 else ""
+            direction = ns + ew or "here"
+            self.log(
+                f"{entry['name']} ({entry['kind']}): {entry['distance']} tiles {direction}",
+                (255, 215, 120),
+            )
+
+    def light_at(self, x: int, y: int) -> float:
+        """Light level (0 dark to 1 full daylight) at a tile."""
+        if self.light_map is None:
+            return self.clock.daylight
+        return self.light_map.at(x, y)
+
+    def toggle_stealth(self):
+        """Start or stop sneaking."""
+        if self.stealth_system.toggle(self.player_id):
+            self.log("You slip into the shadows.", (150, 150, 200))
+        else:
+            self.log("You step out of the shadows.", (200, 200, 200))
+
+    def reveal_from_stealth(self, eid: int):
+        from entities.components import Stealth
+
+        stealth = self.entity_manager.get_component(eid, Stealth)
+        if stealth and stealth.active:
+            self.stealth_system.reveal(eid)
+            if eid == self.player_id:
+                self.log("You are revealed!", (255, 200, 100))
+
+    def show_reputation(self):
+        """Log the player's standing with every faction."""
+        from entities.components import Reputation
+
+        reputation = self.entity_manager.get_component(self.player_id, Reputation)
+        standing = reputation.standing if reputation else {}
+        for faction_id in self.factions.factions:
+            value = standing.get(faction_id, 0)
+            tier = self.factions.tier(value)
+            self.log(
+                f"{self.factions.name(faction_id)}: {tier.title()} ({value})",
+                (200, 200, 255),
+            )
+
+    def map_request(self, scale: int) -> dict:
+        """Build a map_response with the explored world downsampled by scale."""
+        # Past the map's size every scale gives the same single cell
+        scale = min(int(scale), max(self.game_map.width, self.game_map.height))
+        return build_overview(self.game_map, scale).to_message()
+
+    def open_world_map(self):
+        """Show the explored world scaled to fit the screen."""
+        scale = fit_scale(
+            self.game_map,
+            self.renderer.screen_width // 2 - 4,
+            self.renderer.screen_height - 6,
+        )
+        self.world_overview = build_overview(self.game_map, scale)
+        self.game_state = "WORLD_MAP"
+
+    def export_world_map(self):
+        """Save the open world map as a PNG in the save directory."""
+        import os
+
+        if not self.world_overview:
+            return
+
+        save_dir = CONFIG.paths.get("save_dir", "src/data/saves")
+        os.makedirs(save_dir, exist_ok=True)
+        path = os.path.join(save_dir, "world_map.png")
+        with open(path, "wb") as f:
+            f.write(to_png(self.world_overview, self.game_map))
+        self.log(f"World map saved to {path}", (150, 200, 255))
+
+    def update_breath(self, dt: float):
+        """Spend breath underwater and drown the player once it runs out."""
+        from entities.components import Breath, Health
+
+        breath = self.entity_manager.get_component(self.player_id, Breath)
+        was_underwater = breath.underwater if breath else False
+        damage = self.swimming_system.update(dt, self.player_id, self.game_map)
+        if not breath:
+            return
 
-            # Update FOV after movement
-            self.update_fov()
+        if breath.underwater and not was_underwater:
+            self.log("You dive beneath the surface and hold your breath.", (100, 150, 255))
+        elif was_underwater and not breath.underwater:
+            self.log("You surface and gasp for air.", (100, 150, 255))
 
-            # Periodically update active region (e.g., every 10 steps)
-            if (pos.x % 10 == 0) or (pos.y % 10 == 0):
-                self.update_active_region()
+        if damage:
+            self.log(f"You are drowning! (-{damage} HP)", (255, 50, 50))
+            self.renderer.trigger_shake(2.0, 0.1)
+            health = self.entity_manager.get_component(self.player_id, Health)
+            if health and health.current <= 0:
+                self.log("You drowned...", (255, 50, 50))
+                self.respawn_player("drowned")
+
+    def bump_terrain(self, tile_def, x: int, y: int):
+        """React to the player walking into terrain they cannot enter."""
+        if tile_def is None:
+            return
+
+        if tile_def.contact_damage:
+            from entities.components import Health
+
+            health = self.entity_manager.get_component(self.player_id, Health)
+            if health:
+                health.current -= tile_def.contact_damage
+                self.log(f"You hurt yourself on the {tile_def.name}.", (200, 255, 100))
+                self.renderer.trigger_shake(2.0, 0.1)
+                if health.current <= 0:
+                    self.respawn_player(f"impaled on a {tile_def.name}")
+        elif tile_def.swim_skill:
+            self.log(
+                f"The {tile_def.name} is too deep to cross without swimming.",
+                (100, 150, 255),
+            )
+        elif tile_def.ore:
+            self.mine(x, y, tile_def)
+
+    def mine(self, x: int, y: int, tile_def):
+        """Break open an ore seam, leaving its ore on the cave floor."""
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        self.game_map.tiles[y, x] = TILE_FLOOR
+        self.entity_wrapper.factory.create_item(x, y, tile_def.ore, z=pos.z)
+        self.log(f"You break open the {tile_def.name}.", (200, 170, 120))
+        self.update_fov(force=True)
+
+    def in_safe_zone(self, eid: int) -> bool:
+        """Check if an entity stands in a protected zone on the surface."""
+        pos = self.entity_manager.get_component(eid, Position)
+        if not pos or pos.z != 0 or not self.regions:
+            return False
+        return self.regions.is_safe(pos.x, pos.y)
 
     def handle_combat(
         self, attacker_id: int, defender_id: int, is_extra_attack: bool = False
@@ -1415,6 +5137,22 @@ class GameEngine:
         if not defender_health:
             return
 
+        # Nobody fights in a protected zone, from either side of its edge
+        if self.in_safe_zone(attacker_id) or self.in_safe_zone(defender_id):
+            if attacker_id == self.player_id:
+                self.log("You cannot fight in a safe zone.", (150, 150, 150))
+            return
+
+        if attacker_id == self.player_id and self.anticheat and not is_extra_attack:
+            now = self.tick * self.fixed_timestep
+            incident = self.anticheat.attacked(self.player_name(), now)
+            if incident:
+                self.report_incidents([incident])
+
+        # Fighting gives away a hiding player (and wakes a hidden defender)
+        self.reveal_from_stealth(attacker_id)
+        self.reveal_from_stealth(defender_id)
+
         # Determine Attack Power based on Weapon Type
         attack_power = 1
         skill_used = "melee"
@@ -1518,6 +5256,7 @@ class GameEngine:
             damage = max(1, int(final_dmg))  # Always do at least 1 damage on a hit
 
         defender_health.current -= damage
+        self.loot_system.record_damage(defender_id, attacker_id, damage)
 
         # Visual Effects
         def_pos = self.entity_manager.get_component(defender_id, Position)
@@ -1624,26 +5363,28 @@ class GameEngine:
         if defender_health.current <= 0:
             if self.entity_manager.has_component(defender_id, Player):
                 # Player death
-                self.respawn_player()
+                self.respawn_player(f"slain by {attacker_name}")
                 return
 
             self.log(f"{defender_name} is defeated!", (255, 100, 100))
 
-            # Handle Base Level XP gain (Mob Kill XP)
-            if self.entity_manager.has_component(attacker_id, Player) and monster_comp:
-                self.gain_xp(attacker_id, monster_comp.xp_reward)
-
-                # Loot Drop Chance
-                if random.random() < 0.2:  # 20% chance
-                    pos = self.entity_manager.get_component(defender_id, Position)
-                    if pos:
-                        drop_type = random.choice(
-                            ["health_potion", "sword", "shield", "bow", "wand"]
-                        )
-                        self.entity_wrapper.factory.create_item(pos.x, pos.y, drop_type)
-                        self.log("Something dropped!", (255, 215, 0))
+            # The kill (Mob Kill XP) and its loot go to the top damage dealer,
+            # not necessarily whoever landed the last blow
+            credited = self.loot_system.kill_credit(defender_id, attacker_id)
+            if credited is not None and monster_comp:
+                self.gain_xp(credited, monster_comp.xp_reward)
+                if credited == self.player_id:
+                    self.apply_kill_reputation(monster_comp.monster_type)
+                self.drop_loot(defender_id, credited)
+
+            kind = monster_comp.monster_type if monster_comp else ""
+            killer = attacker_id if credited is None else credited
+            self.events.publish(
+                EntityDied(defender_id, kind, killer, f"slain by {attacker_name}")
+            )
 
             # Destroy the entity
+            self.boss_system.mark_defeated(defender_id)
             self.entity_manager.destroy_entity(defender_id)
 
         # Extra attack from Swift affix
@@ -1656,6 +5397,308 @@ class GameEngine:
                 self.log("Swift weapon strikes again!", (255, 255, 0))
                 self.handle_combat(attacker_id, defender_id, is_extra_attack=True)
 
+    def drop_loot(self, monster: int, owner: int):
+        """Maybe drop an item where a monster fell, reserved for whoever earned it.
+
+        Dropped gear may be unidentified, and some of that cursed.
+        """
+        import random
+
+        from data.loader import DATA_LOADER
+
+        pos = self.entity_manager.get_component(monster, Position)
+        if not pos or random.random() >= 0.2:  # 20% chance
+            return
+        # Reagents drop as often as gear, feeding alchemy from the hunt
+        drop_type = random.choice(
+            ["health_potion", "sword", "shield", "bow", "wand"]
+            + ["healing_herb", "healing_herb", "bloodroot", "glowcap"]
+        )
+        item = self.entity_wrapper.factory.create_item(pos.x, pos.y, drop_type, z=pos.z)
+        base_name = (DATA_LOADER.get_item_data(drop_type) or {}).get("name", "Item")
+        roll_drop(self.entity_manager, item, base_name)
+        self.loot_system.claim(item, owner, self.tick * self.fixed_timestep)
+        if owner == self.player_id:
+            self.log("Something dropped!", (255, 215, 0))
+
+    def apply_kill_reputation(self, monster_type: str):
+        """Adjust the player's standing with the victim's faction and its enemies."""
+        from entities.components import Reputation
+
+        reputation = self.entity_manager.get_component(self.player_id, Reputation)
+        if not reputation:
+            return
+        changes = self.factions.on_kill(reputation.standing, monster_type)
+        for faction_id, amount, tier_change in changes:
+            self.report_reputation(faction_id, amount, tier_change)
+        if any(tier_change for _, _, tier_change in changes):
+            self.save_player_profile()
+
+    def reward_reputation(self, faction_id: str, amount: int):
+        """Grant (or take) reputation with a faction, e.g. as a quest reward."""
+        from entities.components import Reputation
+
+        reputation = self.entity_manager.get_component(self.player_id, Reputation)
+        if not reputation or faction_id not in self.factions.factions:
+            return
+        tier_change = self.factions.adjust(reputation.standing, faction_id, amount)
+        self.report_reputation(faction_id, amount, tier_change)
+        self.save_player_profile()
+
+    def report_reputation(self, faction_id: str, amount: int, tier_change):
+        """Log a reputation change, calling out new tiers."""
+        name = self.factions.name(faction_id)
+        if tier_change:
+            old_tier, new_tier = tier_change
+            rising = self.factions.tier_rank(new_tier) > self.factions.tier_rank(old_tier)
+            self.log(
+                f"{name} now regards you as {new_tier.title()}.",
+                (100, 255, 100) if rising else (255, 100, 100),
+            )
+        elif amount < 0:
+            self.log(f"Reputation with {name} decreased by {-amount}.", (200, 150, 150))
+        else:
+            self.log(f"Reputation with {name} increased by {amount}.", (150, 200, 150))
+
+    def load_player_profile(self):
+        """Restore per-player progress (reputation, season) from the profile file."""
+        from entities.components import Inventory, Reputation, Season
+
+        profile = load_profile(self.profile_path)
+        reputation = self.entity_manager.get_component(self.player_id, Reputation)
+        if reputation:
+            for faction_id, value in profile.get("reputation", {}).items():
+                reputation.standing[faction_id] = int(value)
+
+        self.tutorial.from_profile(self.player_id, profile.get("tutorial", {}))
+        self.script_flags = set(profile.get("flags", []))
+        self.homes.from_profile(profile.get("homes", {}))
+        self.alchemy.from_profile(profile.get("alchemy", {}))
+        self.narrator.enabled = bool(profile.get("describe", False))
+        self.settings.from_profile(profile.get("settings", {}))
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        if inventory:
+            restore_wallet(inventory, profile.get("wallet"))
+        if not CONFIG.tutorial.get("enabled", True):
+            self.tutorial.from_profile(self.player_id, {"complete": True})
+
+        # New characters are stamped with the current season
+        season = self.entity_manager.get_component(self.player_id, Season)
+        if season:
+            season.season_id = int(profile.get("season", self.seasons.season_id))
+            season.seasonal = bool(profile.get("seasonal", self.seasons.seasonal_by_default))
+
+    def save_player_profile(self):
+        """Write per-player progress to the profile file."""
+        from entities.components import Inventory, Reputation, Season
+
+        if not self.profile_path or self.player_id is None:
+            return
+        reputation = self.entity_manager.get_component(self.player_id, Reputation)
+        season = self.entity_manager.get_component(self.player_id, Season)
+        profile = load_profile(self.profile_path)
+        profile["reputation"] = dict(reputation.standing) if reputation else {}
+        if season:
+            profile["season"] = season.season_id
+            profile["seasonal"] = season.seasonal
+        profile["tutorial"] = self.tutorial.to_profile(self.player_id)
+        profile["flags"] = sorted(self.script_flags)
+        profile["homes"] = self.homes.to_profile()
+        profile["alchemy"] = self.alchemy.to_profile()
+        profile["describe"] = self.narrator.enabled
+        profile["settings"] = self.settings.to_profile()
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        if inventory:
+            profile["wallet"] = wallet_profile(inventory)
+        self.markets.save()
+
+        # The world's roster remembers where the player was in it
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        roster = None
+        if self.world_name and pos:
+            profile["world"] = self.world_name
+            roster = Roster(self.worlds.roster_file(self.world_name))
+            roster.put(self.player_name(), pos.x, pos.y, pos.z)
+        try:
+            save_profile(self.profile_path, profile)
+            if roster:
+                roster.save()
+        except OSError as e:
+            print(f"Could not save player profile: {e}")
+
+    def start_tutorial(self):
+        """Send a player who has not finished the tutorial to the Tutorial Grounds."""
+        from world.persistent_world import get_persistent_world
+
+        world = get_persistent_world()
+        if not self.tutorial.in_progress(self.player_id) or not world.tutorial_start_pos:
+            return
+        self.in_tutorial = True
+        self.teleport_player(*world.tutorial_start_pos)
+        self.log(self.tutorial.hint(self.player_id), (150, 255, 200))
+
+    def subscribe_events(self):
+        """Hook up the subsystems that react to what happens in play."""
+        on = self.events.subscribe
+        on(PlayerMoved, self.on_player_moved)
+        on(ItemPickedUp, self.on_item_picked_up)
+        on(EntityDied, self.on_entity_died)
+        on(ChatSent, self.relay_chat)
+
+    def on_player_moved(self, event: PlayerMoved):
+        if event.eid == self.player_id:
+            self.tutorial_event("move")
+            self.server_moved_player(event.x, event.y)
+            if self.narrator.enabled:
+                self.narrate_changes()
+
+    def on_item_picked_up(self, event: ItemPickedUp):
+        if event.eid == self.player_id:
+            self.tutorial_event("pickup", event.name)
+
+    def on_entity_died(self, event: EntityDied):
+        if event.killer == self.player_id and event.kind != "player":
+            self.tutorial_event("kill", event.kind)
+
+    def relay_chat(self, event: ChatSent):
+        if self.discord and not event.channel:
+            self.discord.say(event.sender, event.text)
+
+    def tutorial_event(self, event: str, subject: str = "") -> bool:
+        """Count an action towards the tutorial; returns True if a step was completed."""
+        from world.persistent_world import get_persistent_world
+
+        if not self.in_tutorial:
+            return False
+        result = self.tutorial.record(self.player_id, event, subject)
+        if result is None:
+            return False
+
+        if result == "step":
+            self.log(self.tutorial.hint(self.player_id), (150, 255, 200))
+        else:
+            self.log(self.tutorial.completion_text, (150, 255, 200))
+            self.in_tutorial = False
+            world = get_persistent_world()
+            self.teleport_player(
+                *nearest_walkable(self.game_map, *self.world_start(world))
+            )
+        self.save_player_profile()
+        return True
+
+    def record_season_standing(self):
+        """Put the player's current level on this season's ladder."""
+        from entities.components import Level, Name, Season
+
+        level = self.entity_manager.get_component(self.player_id, Level)
+        name = self.entity_manager.get_component(self.player_id, Name)
+        season = self.entity_manager.get_component(self.player_id, Season)
+        if level and name:
+            self.seasons.record(
+                name.value, level.current_level, level.current_xp, bool(season and season.seasonal)
+            )
+
+    def check_season(self):
+        """Archive the ladder and start a new season when the current one is over."""
+        from entities.components import Season
+
+        self.record_season_standing()
+        ended = self.seasons.rollover()
+        if ended is not None:
+            self.log(
+                f"Season {ended} has ended! Season {self.seasons.season_id} begins.",
+                (255, 215, 0),
+            )
+
+        # Seasonal characters from a finished season join the permanent pool
+        season = self.entity_manager.get_component(self.player_id, Season)
+        if season and season.seasonal and season.season_id < self.seasons.season_id:
+            season.seasonal = False
+            self.log("Your seasonal character joins the permanent realm.", (255, 215, 0))
+            self.save_player_profile()
+
+    def season_info(self) -> dict:
+        """Build a season_info message: current season, time left and ladder."""
+        return self.seasons.season_info()
+
+    def find_player(self, target) -> Optional[int]:
+        """A player entity by ID or by name."""
+        from entities.components import Name, Player
+
+        for eid in self.entity_manager.get_entities_with_components(Player, Name):
+            name = self.entity_manager.get_component(eid, Name).value
+            if target == eid or str(target).lower() == name.lower():
+                return eid
+        return None
+
+    def gambling_host(self, game: str) -> Optional[int]:
+        """A townsperson beside the player who plays game."""
+        from data.loader import DATA_LOADER
+        from entities.components import Monster
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        if not pos:
+            return None
+        for eid in self.spatial_index.monsters_near(pos.x, pos.y, 1):
+            monster = self.entity_manager.get_component(eid, Monster)
+            data = DATA_LOADER.get_monster_data(monster.monster_type) if monster else None
+            if game in (data or {}).get("games", []):
+                return eid
+        return None
+
+    def gamble(self, game: str, stake: int) -> dict:
+        """Play a round of dice or cards for gold with the townsperson beside
+        the player. The round is dealt from a fresh seed, and the seed and
+        outcome go in the audit log."""
+        from entities.components import Inventory, Monster
+
+        stake = int(stake)
+        host = self.gambling_host(game)
+        if host is None:
+            return {"type": "error", "error": f"There is nobody here to play {game} with."}
+        inv = self.entity_manager.get_component(self.player_id, Inventory)
+        if not inv:
+            return {"type": "error", "error": "You have no gold to bet."}
+        player = self.player_name()
+        refusal = self.gambling.refusal(player, game, stake, inv.gold)
+        if refusal:
+            return {"type": "error", "error": refusal}
+
+        result = self.gambling.play(game)
+        transaction = self.transaction("gambling")
+        if result.won:
+            transaction.add_gold(inv, stake)
+        else:
+            transaction.remove_gold(inv, stake)
+        try:
+            transaction.commit()
+        except TransactionError as e:
+            return {"type": "error", "error": f"The bet failed: {e}"}
+        self.gambling.record(player, stake, result.won)
+        if result.won:
+            self.economy.record_created("gambling_win", stake)
+        else:
+            self.economy.record_destroyed("gambling_loss", stake)
+        host_name = self.entity_manager.get_component(host, Monster).name
+        if self.audit:
+            self.audit.record(
+                "gamble", player, host_name, game=game, stake=stake, seed=result.seed,
+                rolled=result.player, house=result.house, won=result.won,
+            )
+
+        self.log(result.describe(host_name), (200, 200, 255))
+        if result.won:
+            self.log(f"You win {stake} gold.", (255, 215, 0))
+        else:
+            self.log(f"You lose {stake} gold.", (255, 150, 150))
+        return {
+            "type": "gamble_result",
+            "round": result.to_dict(),
+            "stake": stake,
+            "gold": inv.gold,
+            "lost": self.gambling.lost(player),
+        }
+
     def gain_xp(self, entity_id: int, amount: int):
         """Give XP to an entity and handle leveling up."""
         from entities.components import Level, Combat, Health, Mana
@@ -1703,12 +5746,20 @@ class GameEngine:
         if sleep_time > 0:
             time.sleep(sleep_time)
 
-    def respawn_player(self):
-        """Handle player death: lose XP and respawn at a safe location."""
-        from entities.components import Position, Health, Mana, Level
+    def respawn_player(self, cause: str = "died"):
+        """Handle player death: lose XP and respawn at a safe location.
+
+        Under the hardcore ruleset the character is archived in the memorial
+        instead and a brand new one takes its place at the spawn point.
+        """
+        from entities.components import Position, Health, Mana, Level, Breath
 
         if self.player_id is None:
             return
+        self.events.publish(EntityDied(self.player_id, "player", cause=cause))
+
+        if self.hardcore:
+            self.retire_character(cause)
 
         pos = self.entity_manager.get_component(self.player_id, Position)
         health = self.entity_manager.get_component(self.player_id, Health)
@@ -1719,21 +5770,26 @@ class GameEngine:
             return
 
         # 1. Experience Loss (10% of XP required for next level)
-        xp_loss = int(level.xp_to_next_level * 0.10)
-        level.current_xp = max(0, level.current_xp - xp_loss)
+        if not self.hardcore:
+            xp_loss = int(level.xp_to_next_level * 0.10)
+            level.current_xp = max(0, level.current_xp - xp_loss)
 
-        self.log(f"YOU DIED! Lost {xp_loss} XP.", (255, 50, 50))
+            self.log(f"YOU DIED! Lost {xp_loss} XP.", (255, 50, 50))
 
-        # 2. Teleport to Spawn Point (Town Center or persistent_world.player_start_pos)
+        # 2. Teleport to Spawn Point (the centre of the starter town's safe zone)
         from world.persistent_world import get_persistent_world
 
         world = get_persistent_world()
 
-        spawn_x, spawn_y = self.center_x, self.center_y  # Default to world center
-        if world and world.player_start_pos:
-            spawn_x, spawn_y = world.player_start_pos
-        elif hasattr(world, "center_x"):
-            spawn_x, spawn_y = world.center_x + 25, world.center_y + 25
+        spawn_x, spawn_y = self.world_start(world)
+        home = self.homes.home(self.world_name)
+        if home:
+            spawn_x, spawn_y = home.x, home.y
+        if self.in_tutorial and world.tutorial_start_pos:
+            spawn_x, spawn_y = world.tutorial_start_pos
+
+        # Spawn is on the surface, wherever the player fell
+        self.set_level(0)
 
         # Spiral search for a free spot near spawn
         found = False
@@ -1741,7 +5797,8 @@ class GameEngine:
             for dx in range(-r, r + 1):
                 for dy in range(-r, r + 1):
                     tx, ty = spawn_x + dx, spawn_y + dy
-                    if self.game_map.is_walkable(tx, ty):
+                    free = not self.spatial_index.is_occupied(tx, ty)
+                    if self.game_map.is_walkable(tx, ty) and free:
                         pos.x, pos.y = tx, ty
                         found = True
                         break
@@ -1754,15 +5811,176 @@ class GameEngine:
         health.current = health.maximum
         if mana:
             mana.current = mana.maximum
+        breath = self.entity_manager.get_component(self.player_id, Breath)
+        if breath:
+            breath.current = breath.maximum
+            breath.underwater = False
+            breath.drown_timer = 0.0
 
         # 4. Notify ECS and Update FOV
         self.entity_manager.notify_component_change(self.player_id, Position)
+        self.server_moved_player(pos.x, pos.y)
         self.update_fov()
         self.update_active_region()
 
-        self.log("You have been resurrected in the town center.", (200, 200, 255))
+        if self.hardcore:
+            self.log("A new adventurer arrives in the town center.", (200, 200, 255))
+        elif home and not self.in_tutorial:
+            self.log(f"You have been resurrected at {home.name}.", (200, 200, 255))
+        else:
+            self.log("You have been resurrected in the town center.", (200, 200, 255))
+
+    def retire_character(self, cause: str):
+        """Archive a dead hardcore character and replace it with a new one."""
+        from entities.components import BankAccount, Equipment, Inventory, Position
+
+        pos = self.entity_manager.get_component(self.player_id, Position)
+        start_x, start_y = (pos.x, pos.y) if pos else (self.center_x, self.center_y)
+
+        record = character_record(
+            self.entity_manager, self.player_id, cause, load_profile(self.profile_path)
+        )
+        onboarding = self.tutorial.to_profile(self.player_id)
+        self.log(f"YOU DIED! {record['name']} {cause}.", (255, 50, 50))
+        try:
+            rank = self.memorial.archive(record)
+            self.log(
+                f"Death is permanent. {record['name']} is remembered at #{rank} in the memorial.",
+                (255, 215, 0),
+            )
+        except OSError as e:
+            print(f"Could not archive character: {e}")
+
+        # The character's belongings go with it
+        held = []
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        account_wallet = {}
+        if inventory:
+            account_wallet = wallet_profile(inventory, account_only=True)
+            held.extend(inventory.items)
+            self.economy.record_destroyed("hardcore_death", inventory.gold)
+        bank = self.entity_manager.get_component(self.player_id, BankAccount)
+        if bank:
+            held.extend(bank.items)
+            self.economy.record_destroyed("hardcore_death", bank.gold)
+        equipment = self.entity_manager.get_component(self.player_id, Equipment)
+        if equipment:
+            slots = (equipment.weapon, equipment.head, equipment.body, equipment.legs, equipment.shield)
+            held.extend(item for item in slots if item is not None)
+        held.extend(self.buyback.clear())
+        for item_id in held:
+            self.entity_manager.destroy_entity(item_id)
+        self.entity_manager.destroy_entity(self.player_id)
+
+        # Start the next character from a fresh profile; the tutorial is not repeated
+        if self.profile_path:
+            try:
+                fresh = {"tutorial": onboarding, "wallet": account_wallet}
+                save_profile(self.profile_path, fresh)
+            except OSError as e:
+                print(f"Could not reset player profile: {e}")
+        self.auto_path.clear()
+        self.player_id = self.entity_wrapper.factory.create_player(start_x, start_y)
+        inventory = self.entity_manager.get_component(self.player_id, Inventory)
+        if inventory:
+            self.economy.record_created("starting_gold", inventory.gold)
+        self.load_player_profile()
+
+    def show_memorial(self):
+        """Log the best fallen hardcore characters."""
+        entries = self.memorial.leaderboard()["entries"]
+        if not entries:
+            self.log("The memorial is empty.", (150, 150, 150))
+            return
+        self.log("Memorial of the fallen:", (255, 215, 0))
+        for entry in entries:
+            self.log(
+                f"#{entry['rank']} {entry['name']} (Lvl {entry['level']}) - {entry['cause']}",
+                (200, 200, 200),
+            )
 
     def quit(self):
         """Quit the game."""
+        self.shutdown("The server is shutting down.")
+
+    def shutdown(self, reason: str):
+        """Save everything, disconnect clients with reason and leave the game loop.
+
+        The world map never changes in play, so the player profile, season
+        ladder and journal (written as it goes) are all there is to save.
+        """
+        self.save_player_profile()
+        self.record_season_standing()
+        if self.gateway:
+            self.gateway.closed = reason
+            self.gateway.disconnect_all(reason)
+            self.gateway.stop()
+        if self.discord:
+            self.discord.stop()
+        if self.recorder:
+            self.recorder.close()
         self.input_handler.restore_terminal()
         self.running = False
+
+    def start_recording(self, path: str):
+        """Record this session to a replay log, seeding the game so it can be replayed."""
+        import random
+
+        seed = random.randrange(2**32)
+        random.seed(seed)
+        # The account stash is recorded with the profile, as play can draw on it
+        profile = load_profile(self.profile_path)
+        profile["account_stash"] = load_profile(self.stash.path)
+        self.recorder = ReplayRecorder(path, seed, profile)
+        print(f"Recording replay to {path}")
+
+    def start_playback(self, path: str):
+        """Re-simulate a replay log against a fresh game (call before run).
+
+        The game starts from the recorded seed, profile and stash; saves made
+        during playback go to a scratch directory so the real ones are untouched.
+        """
+        import random
+        import tempfile
+
+        replay = load_replay(path)
+        random.seed(replay.seed)
+
+        scratch = tempfile.mkdtemp(prefix="replay-")
+        self.profile_path = os.path.join(scratch, "player_profile.json")
+        profile = dict(replay.profile)
+        stash = profile.pop("account_stash", {})
+        save_profile(self.profile_path, profile)
+        self.memorial = Memorial(os.path.join(scratch, "memorial.json"), self.memorial.size)
+        self.stash = AccountStash(
+            os.path.join(scratch, "stash.json"), self.stash.capacity
+        )
+        save_profile(self.stash.path, stash)
+        self.seasons = SeasonSystem(None, CONFIG.season)
+        self.journal = None
+        self.audit = None
+        self.discord = None
+        # Replayed incidents restrict as they did live, but are not written again
+        if self.anticheat:
+            self.anticheat = self.make_anticheat(None)
+        self.announcements = Announcements(None)
+        self.restart = None
+
+        self.replay = ReplayPlayer(replay)
+        print(f"Playing back {path} ({len(self.replay.inbound)} inputs)")
+
+    def play_replay(self):
+        """Feed in the recorded inputs and requests due by the current tick."""
+        if self.replay.finished:
+            return
+        for entry in self.replay.due(self.tick):
+            if entry["kind"] == "input":
+                self.handle_input(InputEvent(**entry["event"]))
+            else:
+                self.commands.handle_request(entry["message"])
+
+        if self.replay.finished:
+            result = self.replay.divergence or "no divergence"
+            self.message_log.append(
+                (f"Replay finished at tick {self.tick} ({result}); you have control.", (255, 215, 0))
+            )
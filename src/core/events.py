@@ -0,0 +1,90 @@
+"""
+Event bus for the game.
+Gameplay code publishes what happened (the player moved, something died,
+someone chatted, an item was picked up) and the subsystems that care (the
+tutorial, chat relays, plugins, analytics) subscribe, so the code where
+things happen does not need to know about every listener.
+
+Handlers run synchronously on the game thread, in subscription order. A
+handler that raises is logged and skipped; the publisher and the other
+handlers carry on.
+"""
+
+from collections import defaultdict
+from dataclasses import dataclass
+from typing import Callable, Dict, List, Optional, Type
+
+from core.recovery import log_exception
+
+
+@dataclass(frozen=True)
+class Event:
+    """Base class; subscribe to it to hear every event."""
+
+
+@dataclass(frozen=True)
+class PlayerMoved(Event):
+    eid: int
+    x: int
+    y: int
+    from_x: int
+    from_y: int
+
+
+@dataclass(frozen=True)
+class EntityDied(Event):
+    eid: int
+    kind: str  # Monster type, or "player"
+    killer: Optional[int] = None
+    cause: str = ""
+
+
+@dataclass(frozen=True)
+class ChatSent(Event):
+    sender: str
+    text: str
+    channel: str = ""  # Empty when said aloud
+
+
+@dataclass(frozen=True)
+class ItemPickedUp(Event):
+    eid: int
+    item_id: int
+    name: str
+    item_type: str = ""
+
+
+@dataclass(frozen=True)
+class FishCaught(Event):
+    eid: int
+    item_type: str
+    name: str
+    rare: bool = False
+
+
+Handler = Callable[[Event], None]
+
+
+class EventBus:
+    """Typed publish/subscribe between game systems."""
+
+    def __init__(self):
+        self._handlers: Dict[Type[Event], List[Handler]] = defaultdict(list)
+
+    def subscribe(self, event_type: Type[Event], handler: Handler) -> Handler:
+        """Call handler for every event of event_type or a subclass of it."""
+        self._handlers[event_type].append(handler)
+        return handler
+
+    def unsubscribe(self, event_type: Type[Event], handler: Handler):
+        if handler in self._handlers.get(event_type, []):
+            self._handlers[event_type].remove(handler)
+
+    def publish(self, event: Event):
+        for event_type in type(event).__mro__:
+            # Copied, so a handler may unsubscribe itself
+            for handler in list(self._handlers.get(event_type, ())):
+                try:
+                    handler(event)
+                except Exception:
+                    log_exception(f"{type(event).__name__} handler")
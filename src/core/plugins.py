@@ -0,0 +1,108 @@
+"""
+Plugin hooks for extending the game without patching the engine.
+A plugin is a Python module named in config ([plugins] modules) that
+defines a Plugin subclass as `plugin`. Plugins can add actions, requests
+(new message types) and typed commands when they are loaded, and are told
+when the player logs in, every tick, and when someone chats, so minigames,
+analytics or chat moderation can live outside the core handlers.
+
+A plugin that raises is logged and the game carries on without that call's
+result; one plugin can never take the game down.
+"""
+
+import importlib
+from typing import Any, Callable, Dict, List, Optional, Union
+
+from core.recovery import log_exception
+
+
+class Plugin:
+    """Base class for plugins; override only the hooks you need."""
+
+    name = "plugin"
+
+    def register(self, engine, plugins: "PluginManager"):
+        """Called once at load: register actions, requests and commands.
+
+        engine.commands is the CommandRegistry (action/request),
+        plugins.text_command adds verbs to the typed command line, and
+        engine.events is the EventBus for anything the hooks below miss.
+        """
+
+    def on_login(self, engine, player_id: int):
+        """The player has entered the world."""
+
+    def on_tick(self, engine, dt: float):
+        """One fixed update has run."""
+
+    def on_chat(self, engine, sender: str, text: str) -> Union[None, bool, str]:
+        """Someone said text. Return False to block it, a string to replace it,
+        or None to leave it alone."""
+        return None
+
+
+class PluginManager:
+    """Loaded plugins and the hooks that call them."""
+
+    def __init__(self):
+        self.plugins: List[Plugin] = []
+        # Verb -> handler(engine, args) for typed commands added by plugins
+        self.text_commands: Dict[str, Callable[[Any, List[str]], None]] = {}
+
+    def load(self, module_names: List[str], engine) -> List[str]:
+        """Import each module's plugin and register it; returns failures."""
+        failed = []
+        for name in module_names:
+            try:
+                plugin = importlib.import_module(name).plugin
+                if not isinstance(plugin, Plugin):
+                    raise TypeError(f"{name}.plugin is not a Plugin")
+                self.add(plugin, engine)
+            except Exception:
+                log_exception(f"loading plugin {name}")
+                failed.append(name)
+        return failed
+
+    def add(self, plugin: Plugin, engine):
+        plugin.register(engine, self)
+        self.plugins.append(plugin)
+
+    def text_command(self, verb: str, handler: Callable[[Any, List[str]], None]):
+        """Let players type verb; handler gets the engine and the other words."""
+        self.text_commands[verb.lower()] = handler
+
+    def run_text_command(self, engine, verb: str, args: List[str]) -> bool:
+        """Run a plugin's typed command; False if no plugin added verb."""
+        handler = self.text_commands.get(verb)
+        if handler is None:
+            return False
+        try:
+            handler(engine, args)
+        except Exception:
+            log_exception(f"plugin command {verb}")
+        return True
+
+    def _call(self, plugin: Plugin, hook: str, *args) -> Any:
+        try:
+            return getattr(plugin, hook)(*args)
+        except Exception:
+            log_exception(f"plugin {plugin.name} {hook}")
+            return None
+
+    def login(self, engine, player_id: int):
+        for plugin in self.plugins:
+            self._call(plugin, "on_login", engine, player_id)
+
+    def tick(self, engine, dt: float):
+        for plugin in self.plugins:
+            self._call(plugin, "on_tick", engine, dt)
+
+    def chat(self, engine, sender: str, text: str) -> Optional[str]:
+        """Pass a chat line through every plugin; None if one blocked it."""
+        for plugin in self.plugins:
+            result = self._call(plugin, "on_chat", engine, sender, text)
+            if result is False:
+                return None
+            if isinstance(result, str):
+                text = result
+        return text
@@ -0,0 +1,32 @@
+"""
+Failure isolation for client-facing handlers.
+A bug hit while answering one client must not bring the game down: the
+failure is written to the debug log with its stack, the client is told with
+an error message, and only that client's session is closed.
+"""
+
+import time
+import traceback
+from typing import Any, Dict, Optional
+
+DEBUG_LOG = "game_debug.log"
+
+
+class SessionError(Exception):
+    """Raised to close a client session after a failure was already handled."""
+
+
+def log_exception(context: str, path: Optional[str] = None):
+    """Append the exception being handled, with its stack, to the debug log."""
+    stamp = time.strftime("%Y-%m-%d %H:%M:%S")
+    try:
+        with open(path or DEBUG_LOG, "a", encoding="utf-8") as f:
+            f.write(f"[{stamp}] Error in {context}:\n{traceback.format_exc()}\n")
+    except OSError:
+        # The log is best effort; never fail harder than the original error
+        pass
+
+
+def internal_error(name: str) -> Dict[str, Any]:
+    """Error message for a request whose handler crashed; the session should end."""
+    return {"type": "error", "error": f"Internal error handling {name}", "fatal": True}
@@ -1,14 +1,22 @@
 """
 Spatial indexing system for the ECS.
 Now supports incremental updates via ECS callbacks.
+Entities are also bucketed into coarse cells so proximity queries only look
+at the cells around a point instead of every entity in the world.
 """
 
 from collections import defaultdict
-from typing import Dict, List, Set, Tuple, TYPE_CHECKING
+from typing import Dict, Iterable, List, Optional, Set, Tuple, TYPE_CHECKING
 
 if TYPE_CHECKING:
     from core.ecs import EntityManager
 
+CELL_SIZE = 16  # Tiles per side of a proximity bucket
+
+
+def cell_of(x: int, y: int) -> Tuple[int, int]:
+    return (x // CELL_SIZE, y // CELL_SIZE)
+
 
 class SpatialIndex:
     """A simple grid-based spatial index for entities with a Position component.
@@ -21,6 +29,8 @@ class SpatialIndex:
         self.pos_to_entities: Dict[Tuple[int, int], Set[int]] = defaultdict(set)
         # entity ID -> (x, y)
         self.entity_to_pos: Dict[int, Tuple[int, int]] = {}
+        # (cell x, cell y) -> set of entity IDs
+        self.cell_to_entities: Dict[Tuple[int, int], Set[int]] = defaultdict(set)
 
         # Track types for fast filtering
         self.monsters: Set[int] = set()
@@ -44,6 +54,7 @@ class SpatialIndex:
                     self.pos_to_entities[old_pos].discard(eid)
                     if not self.pos_to_entities[old_pos]:
                         del self.pos_to_entities[old_pos]
+                    self._leave_cell(eid, old_pos)
                     del self.entity_to_pos[eid]
             else:  # add or update
                 if old_pos:
@@ -52,6 +63,11 @@ class SpatialIndex:
                     self.pos_to_entities[old_pos].discard(eid)
                     if not self.pos_to_entities[old_pos]:
                         del self.pos_to_entities[old_pos]
+                    if cell_of(*old_pos) != cell_of(*new_pos):
+                        self._leave_cell(eid, old_pos)
+                        self.cell_to_entities[cell_of(*new_pos)].add(eid)
+                else:
+                    self.cell_to_entities[cell_of(*new_pos)].add(eid)
 
                 self.pos_to_entities[new_pos].add(eid)
                 self.entity_to_pos[eid] = new_pos
@@ -72,11 +88,20 @@ class SpatialIndex:
             else:
                 self.items.add(eid)
 
+    def _leave_cell(self, eid: int, pos: Tuple[int, int]):
+        cell = cell_of(*pos)
+        members = self.cell_to_entities.get(cell)
+        if members is not None:
+            members.discard(eid)
+            if not members:
+                del self.cell_to_entities[cell]
+
     def rebuild(self):
         """Deprecated: Rebuilding is now incremental.
         Only call if the entire manager is cleared or on initial load."""
         self.pos_to_entities.clear()
         self.entity_to_pos.clear()
+        self.cell_to_entities.clear()
         self.monsters.clear()
         self.players.clear()
         self.items.clear()
@@ -89,6 +114,7 @@ class SpatialIndex:
             coords = (pos.x, pos.y)
             self.pos_to_entities[coords].add(eid)
             self.entity_to_pos[eid] = coords
+            self.cell_to_entities[cell_of(*coords)].add(eid)
 
         # Categorize
         self.monsters = set(
@@ -99,6 +125,33 @@ class SpatialIndex:
         )
         self.items = set(self.entity_manager.components_by_type.get(Item, {}).keys())
 
+    def query_radius(
+        self, x: int, y: int, radius: int, within: Optional[Iterable[int]] = None
+    ) -> List[int]:
+        """Entities at most radius tiles away (Chebyshev distance), nearest first.
+
+        Only the cells overlapping the square are visited. within limits the
+        result to a set such as self.monsters.
+        """
+        min_cx, min_cy = cell_of(x - radius, y - radius)
+        max_cx, max_cy = cell_of(x + radius, y + radius)
+        found = []
+        for cx in range(min_cx, max_cx + 1):
+            for cy in range(min_cy, max_cy + 1):
+                for eid in self.cell_to_entities.get((cx, cy), ()):
+                    if within is not None and eid not in within:
+                        continue
+                    ex, ey = self.entity_to_pos[eid]
+                    distance = max(abs(ex - x), abs(ey - y))
+                    if distance <= radius:
+                        found.append((distance, eid))
+        found.sort()
+        return [eid for _, eid in found]
+
+    def monsters_near(self, x: int, y: int, radius: int) -> List[int]:
+        """Monsters at most radius tiles away, nearest first."""
+        return self.query_radius(x, y, radius, self.monsters)
+
     def get_entities_at(self, x: int, y: int) -> Set[int]:
         """Get all entities at a specific position."""
         return self.pos_to_entities.get((x, y), set())
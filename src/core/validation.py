@@ -0,0 +1,117 @@
+"""
+Checks every action and request passes before its handler runs.
+Commands declare what they need when they are registered (a role, game
+states, how far away their target may be) and the CommandRegistry asks the
+ActionValidator before dispatching, so a new feature gets cadence,
+permission, range and state checks by declaring them instead of writing
+them again. A refused command becomes an error with a standard code.
+"""
+
+from typing import Any, Callable, Dict, Mapping, Optional, Set, Tuple
+
+# Error codes clients can rely on
+RATE_LIMITED = "rate_limited"  # Acting faster than the action cadence allows
+NOT_AUTHORIZED = "not_authorized"  # The caller lacks the command's role
+INVALID_STATE = "invalid_state"  # A state prerequisite does not hold
+OUT_OF_RANGE = "out_of_range"  # The target is further away than the command reaches
+NOT_YOUR_TURN = "not_your_turn"  # Turn-based combat is waiting on someone else
+
+
+class ActionError(Exception):
+    """A command refused by validation, with its code."""
+
+    def __init__(self, code: str, message: str):
+        super().__init__(message)
+        self.code = code
+        self.message = message
+
+    def reply(self) -> Dict[str, Any]:
+        return {"type": "error", "code": self.code, "error": self.message}
+
+
+class ActionValidator:
+    """Cadence, role, state and range checks shared by every command.
+
+    clock gives the current time in seconds; the engine uses game time so
+    replays are refused exactly as the live game was. roles gives the
+    caller's roles and position the acting player's tile.
+    """
+
+    def __init__(
+        self,
+        cadence: float = 0.0,
+        clock: Callable[[], float] = lambda: 0.0,
+        roles: Callable[[], Set[str]] = set,
+        position: Callable[[], Optional[Tuple[int, int]]] = lambda: None,
+    ):
+        self.cadence = cadence  # Minimum seconds between two paced commands
+        self.clock = clock
+        self.roles = roles
+        self.position = position
+        self.states: Dict[str, Callable[[], bool]] = {}
+        self.messages: Dict[str, str] = {}  # State -> what the player is told
+        self.last_action: Dict[str, float] = {}  # Actor -> when it last acted
+        self.refused: Dict[str, int] = {}  # Code -> commands refused with it
+        self.locked = ""  # While set, actions are refused with this message...
+        self.unlocked: Tuple[str, ...] = ()  # ...except these
+        # Why a named action must wait, if it must: turn-based combat holds
+        # world actions back until the player's turn, energy scheduling until
+        # they can pay for them (see systems.turns and systems.energy)
+        self.pacing: Callable[[str], Optional[ActionError]] = lambda name: None
+
+    def state(self, name: str, test: Callable[[], bool], message: str = ""):
+        """Define a prerequisite commands can require by name."""
+        self.states[name] = test
+        if message:
+            self.messages[name] = message
+
+    def lock(self, message: str, allow: Tuple[str, ...] = ()):
+        """Refuse every action but those in allow until unlock (e.g. in a cutscene)."""
+        self.locked, self.unlocked = message, tuple(allow)
+
+    def unlock(self):
+        self.locked, self.unlocked = "", ()
+
+    def check(self, command, kwargs: Mapping[str, Any], actor: str = "player"):
+        """Raise ActionError if command may not run now with these args."""
+        try:
+            self._check(command, kwargs, actor)
+        except ActionError as e:
+            self.refused[e.code] = self.refused.get(e.code, 0) + 1
+            raise
+
+    def _check(self, command, kwargs: Mapping[str, Any], actor: str):
+        if command.role and command.role not in self.roles():
+            raise ActionError(NOT_AUTHORIZED, f"{command.name} needs the {command.role} role")
+
+        if self.locked and command.paced and command.name not in self.unlocked:
+            raise ActionError(INVALID_STATE, self.locked)
+
+        for name in command.requires:
+            test = self.states.get(name)
+            if test is None or not test():
+                message = self.messages.get(name, f"{command.name} needs {name}")
+                raise ActionError(INVALID_STATE, message)
+
+        if command.reach and command.target:
+            x, y = (kwargs.get(arg) for arg in command.target)
+            if not isinstance(x, int) or not isinstance(y, int):
+                raise ActionError(OUT_OF_RANGE, f"{command.name} needs a tile")
+            if not command.relative:
+                here = self.position()
+                if here is None:
+                    raise ActionError(INVALID_STATE, f"{command.name} needs a position")
+                x, y = x - here[0], y - here[1]
+            if max(abs(x), abs(y)) > command.reach:
+                raise ActionError(OUT_OF_RANGE, "That is too far away.")
+
+        held = self.pacing(command.name) if command.paced else None
+        if held:
+            raise held
+
+        if command.paced and self.cadence > 0:
+            now = self.clock()
+            last = self.last_action.get(actor)
+            if last is not None and now - last < self.cadence:
+                raise ActionError(RATE_LIMITED, "You are acting too quickly.")
+            self.last_action[actor] = now
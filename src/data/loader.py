@@ -4,9 +4,76 @@ Data loading system for the game.
 
 import json
 from pathlib import Path
-from typing import Dict, Any, Optional
+from typing import Any, Callable, Dict, List, Optional, Tuple
 import toml
 
+from systems.cutscenes import validate_cutscene
+from systems.glyphs import GlyphError, check_icon
+from systems.scripting import validate_script
+
+# Script hooks each content file may give its entries (see systems.scripting)
+SCRIPT_FIELDS: Dict[str, List[str]] = {"items": ["on_use"], "monsters": ["on_talk"]}
+
+# Fields that must be numbers in each entry of a content file, when present
+NUMERIC_FIELDS: Dict[str, List[str]] = {
+    "items": ["heal_amount", "attack_bonus", "defense_bonus", "value", "price", "fishing_skill"],
+    "monsters": ["health", "attack", "defense", "perception", "xp_reward", "summon_seconds"],
+    "recipes": ["skill"],
+    "brews": ["skill"],
+}
+
+# Fields that must be a single drawable glyph (see systems.glyphs), when present
+ICON_FIELDS: Dict[str, List[str]] = {"items": ["char"], "monsters": ["char"]}
+
+# Fields that must be one of a fixed set of words, when present
+CHOICE_FIELDS: Dict[str, Dict[str, Tuple[str, ...]]] = {
+    "items": {"bind": ("pickup", "equip")},
+}
+
+
+class ContentError(ValueError):
+    """A content file that cannot be read or does not make sense."""
+
+
+def validate_content(filename: str, data: Any):
+    """Check one loaded file's shape, raising ContentError on the first problem."""
+    if not isinstance(data, dict):
+        raise ContentError(f"{filename}: expected a table of entries")
+    if filename == "cutscenes":
+        for name, entry in data.items():
+            try:
+                validate_cutscene(entry, f"cutscenes: {name}")
+            except ValueError as e:
+                raise ContentError(str(e)) from None
+    numeric = NUMERIC_FIELDS.get(filename)
+    if numeric is None:
+        return
+    for entry_id, entry in data.items():
+        if not isinstance(entry, dict):
+            raise ContentError(f"{filename}: {entry_id} is not a table")
+        if not isinstance(entry.get("name"), str):
+            raise ContentError(f"{filename}: {entry_id} has no name")
+        for field in numeric:
+            value = entry.get(field, 0)
+            if isinstance(value, bool) or not isinstance(value, (int, float)):
+                raise ContentError(f"{filename}: {entry_id}.{field} must be a number")
+        for field, choices in CHOICE_FIELDS.get(filename, {}).items():
+            if field in entry and entry[field] not in choices:
+                allowed = ", ".join(choices)
+                raise ContentError(f"{filename}: {entry_id}.{field} must be {allowed}")
+        for field in ICON_FIELDS.get(filename, []):
+            if field in entry:
+                try:
+                    check_icon(entry[field])
+                except GlyphError as e:
+                    raise ContentError(f"{filename}: {entry_id}.{field}: {e}") from None
+        for field in SCRIPT_FIELDS.get(filename, []):
+            if field in entry:
+                try:
+                    validate_script(entry[field], f"{filename}: {entry_id}.{field}")
+                except ValueError as e:
+                    raise ContentError(str(e)) from None
+
 
 class DataLoader:
     """Handles loading game data from various file formats."""
@@ -100,6 +167,34 @@ class DataLoader:
         """Clear the data cache."""
         self._cache.clear()
 
+    def reload(
+        self, check: Optional[Callable[["DataLoader"], None]] = None
+    ) -> List[str]:
+        """Re-read every file loaded so far and swap them in together.
+
+        Everything is read and validated first (check may add its own tests
+        against the fresh loader); if anything fails, ContentError is raised and
+        the content in use is left untouched. Returns the reloaded file names.
+        """
+        fresh = DataLoader(str(self.data_dir))
+        for key in list(self._cache):
+            kind, filename = key.split("_", 1)
+            try:
+                data = getattr(fresh, f"load_{kind}")(filename)
+            except (OSError, ValueError, ImportError) as e:
+                raise ContentError(f"{filename}: {e}") from None
+            validate_content(filename, data)
+        if check:
+            try:
+                check(fresh)
+            except ContentError:
+                raise
+            except (KeyError, TypeError, ValueError) as e:
+                raise ContentError(str(e)) from None
+        # One assignment, so readers see the old content or the new, never a mix
+        self._cache = fresh._cache
+        return sorted(key.split("_", 1)[1] for key in self._cache)
+
 
 # Global data loader instance
 DATA_LOADER = DataLoader()
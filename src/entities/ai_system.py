@@ -1,20 +1,102 @@
 """
 AI system for NPCs and monsters in the roguelike game.
+Each monster runs a behavior tree from src/data/static/behaviors.json, chosen by
+its definition's "behavior" (or its ai_type); the leaves are registered below.
+
+A tick's monsters are worked through a spatial cell at a time, nearest the
+player first. Given a time budget, cells still waiting when it runs out are
+shed: they wait for the next tick and go first then. Cells next to the player
+are never shed, so a flood of monsters elsewhere cannot stall the fight.
 """
 
 import random
-import heapq
+import time
+from collections import defaultdict
+from dataclasses import dataclass
+from typing import Callable, Collection, Dict, List, Optional, Tuple
+
 from core.ecs import EntityManager
-from entities.components import Position, Monster
+from core.spatial import cell_of
+from data.loader import DATA_LOADER
+from entities.behavior_tree import FAILURE, SUCCESS, action, build_trees, condition
+from entities.components import PartyMember, Position, Monster, Reputation
+from entities.occupancy import PEACEFUL_AI_TYPES
 from world.map import GameMap
+from world.pathfinding import find_path
+
+AGGRO_RANGE = 10  # Tiles at which aggressive monsters notice the player
+ALERT_RANGE = 20  # Alerted monsters keep hunting out to this distance
+CHASE_LOOKAHEAD = 3  # Max tiles ahead of a moving player that chasers aim for
+
+DIRECTIONS = [(0, 1), (0, -1), (1, 0), (-1, 0), (1, 1), (1, -1), (-1, 1), (-1, -1)]
+
+
+def _sign(value: int) -> int:
+    return (value > 0) - (value < 0)
+
+
+@dataclass
+class AIContext:
+    """Everything a behavior tree leaf may need for one monster's turn."""
+
+    system: "AISystem"
+    eid: int
+    monster: Monster
+    pos: Position
+    player_pos: Position
+    game_map: GameMap
+    spatial_index: object = None
+    combat_callback: Optional[Callable] = None
+    alert_callback: Optional[Callable] = None
+    player_id: Optional[int] = None
+    target: Optional[int] = None  # The enemy an ally has picked to fight
+
+    @property
+    def player_distance(self) -> int:
+        return max(abs(self.player_pos.x - self.pos.x), abs(self.player_pos.y - self.pos.y))
+
+    def move_along_path_to(self, tx: int, ty: int) -> bool:
+        """Take one step on an A* path; False if there is no path."""
+        path = self.system._get_path_to(
+            self.pos.x, self.pos.y, tx, ty, self.game_map, self.spatial_index, self.eid
+        )
+        if not path:
+            return False
+        new_x, new_y = path[0]
+        self.system._try_move(self.eid, self.pos, new_x, new_y, self.game_map, self.spatial_index)
+        return True
 
 
 class AISystem:
     """System for managing AI behavior of NPCs and monsters."""
 
-    def __init__(self, entity_manager: EntityManager):
+    def __init__(
+        self,
+        entity_manager: EntityManager,
+        occupancy=None,
+        trees=None,
+        factions=None,
+        stealth=None,
+    ):
         self.entity_manager = entity_manager
+        # Optional OccupancyRules; without it any occupied tile blocks movement
+        self.occupancy = occupancy
+        # Optional FactionSystem; guards use it to judge the player's reputation
+        self.factions = factions
+        # Optional StealthSystem; monsters ignore a hidden player they have not spotted
+        self.stealth = stealth
         self.tick_counter = 0
+        # Monsters shed when a tick ran out of time; they run first next tick
+        self.deferred: List[int] = []
+        self.shed_total = 0
+
+        # Behavior trees by name
+        self.trees = trees if trees is not None else build_trees(DATA_LOADER.load_json("behaviors"))
+
+        # Player heading, used to cut off a fleeing player
+        self._last_player_pos = None
+        self._player_velocity = (0, 0)
+        self._player_still_ticks = 0
 
     def update(
         self,
@@ -23,238 +105,453 @@ class AISystem:
         spatial_index=None,
         combat_callback=None,
         num_batches: int = 1,
-    ):
-        """Update AI for all monsters, optionally batching across multiple frames."""
+        alert_callback=None,
+        player_id: Optional[int] = None,
+        budget: Optional[float] = None,
+        skip: Collection[int] = (),
+        ready: Optional[Callable[[int, Monster], bool]] = None,
+    ) -> int:
+        """Update AI for all monsters, optionally batching across multiple frames.
+
+        budget is the seconds this tick may spend before far cells are shed;
+        monsters in skip (fighting in turns) are left alone, and so are those
+        ready says cannot act yet (energy scheduling).
+        Returns how many monsters were shed.
+        """
         self.tick_counter = (self.tick_counter + 1) % num_batches
+        self._track_player(player_pos, num_batches)
 
         monster_components = self.entity_manager.components_by_type.get(Monster, {})
         pos_components = self.entity_manager.components_by_type.get(Position, {})
 
-        # Update loop for monsters
-        for eid in list(monster_components.keys()):
-            # Batching: Only update if it belongs to current tick's batch
-            if num_batches > 1 and (eid % num_batches) != self.tick_counter:
-                continue
-
-            monster = monster_components.get(eid)
-            pos = pos_components.get(eid)
-
-            if not monster or not pos:
-                continue
-
-            # Different AI based on monster type
-            if monster.ai_type == "aggressive":
-                self._aggressive_ai(
-                    eid, pos, player_pos, game_map, spatial_index, combat_callback
-                )
-            elif monster.ai_type == "passive":
-                self._passive_ai(eid, pos, game_map, spatial_index)
-            elif monster.ai_type == "patrol":
-                self._patrol_ai(eid, pos, game_map, spatial_index)
-            elif monster.ai_type == "static":
-                pass
-
-    def _get_path_to(self, start_x, start_y, end_x, end_y, game_map, spatial_index):
-        """Simple A* pathfinding."""
-
-        def heuristic(x1, y1, x2, y2):
-            return max(abs(x1 - x2), abs(y1 - y2))
-
-        frontier = []
-        heapq.heappush(frontier, (0, (start_x, start_y)))
-        came_from = {}
-        cost_so_far = {}
-        came_from[(start_x, start_y)] = None
-        cost_so_far[(start_x, start_y)] = 0
-
-        # Limit search depth for performance
-        max_nodes = 100
-        nodes_searched = 0
-
-        target = (end_x, end_y)
-
-        while frontier and nodes_searched < max_nodes:
-            nodes_searched += 1
-            current = heapq.heappop(frontier)[1]
-
-            if current == target:
-                break
-
-            for dx, dy in [
-                (0, 1),
-                (0, -1),
-                (1, 0),
-                (-1, 0),
-                (1, 1),
-                (1, -1),
-                (-1, 1),
-                (-1, -1),
-            ]:
-                nx, ny = current[0] + dx, current[1] + dy
-                next_node = (nx, ny)
-
-                if not (0 <= nx < game_map.width and 0 <= ny < game_map.height):
-                    continue
-                if not game_map.is_walkable(nx, ny):
+        # Batching: only monsters in the current tick's batch, plus any shed last tick
+        deferred = set(self.deferred)
+        batch = [eid for eid in self.deferred if eid not in skip] + [
+            eid
+            for eid in monster_components
+            if (num_batches <= 1 or eid % num_batches == self.tick_counter)
+            and eid not in deferred
+            and eid not in skip
+        ]
+        self.deferred = []
+
+        started = time.perf_counter()
+        chunks = self._partition(batch, pos_components, player_pos, deferred)
+        for index, (near, eids) in enumerate(chunks):
+            over = budget is not None and time.perf_counter() - started > budget
+            if over and not near:
+                for _, rest in chunks[index:]:
+                    self.deferred.extend(rest)
+                self.shed_total += len(self.deferred)
+                return len(self.deferred)
+
+            for eid in eids:
+                monster = monster_components.get(eid)
+                pos = pos_components.get(eid)
+
+                if not monster or not pos:
                     continue
-
-                # Check if occupied by something OTHER than the target or the start
-                if (
-                    spatial_index
-                    and spatial_index.is_occupied(nx, ny)
-                    and next_node != target
-                ):
+                if pos.z != player_pos.z:
+                    continue  # On another level; this map is not theirs
+                if ready and not ready(eid, monster):
                     continue
 
-                new_cost = cost_so_far[current] + 1
-                if next_node not in cost_so_far or new_cost < cost_so_far[next_node]:
-                    cost_so_far[next_node] = new_cost
-                    priority = new_cost + heuristic(nx, ny, end_x, end_y)
-                    heapq.heappush(frontier, (priority, next_node))
-                    came_from[next_node] = current
-
-        if target not in came_from:
-            return None
-
-        # Reconstruct path
-        path = []
-        current = target
-        while current != (start_x, start_y):
-            path.append(current)
-            current = came_from[current]
-        path.reverse()
-        return path
-
-    def _aggressive_ai(
+                self.think(
+                    eid,
+                    monster,
+                    pos,
+                    player_pos,
+                    game_map,
+                    spatial_index,
+                    combat_callback,
+                    alert_callback,
+                    player_id,
+                )
+        return 0
+
+    @staticmethod
+    def _partition(
+        eids: List[int], positions: Dict, player_pos: Position, deferred
+    ) -> List[Tuple[bool, List[int]]]:
+        """Group monsters by spatial cell, in the order the cells should run.
+
+        Each entry is (next to the player, monster IDs). Cells touching the
+        player's come first, then cells shed last tick, then the rest by
+        distance.
+        """
+        cells = defaultdict(list)
+        for eid in eids:
+            pos = positions.get(eid)
+            if pos is not None:
+                cells[cell_of(pos.x, pos.y)].append(eid)
+
+        home_x, home_y = cell_of(player_pos.x, player_pos.y)
+        ranked = []
+        for (cx, cy), members in cells.items():
+            distance = max(abs(cx - home_x), abs(cy - home_y))
+            waited = any(eid in deferred for eid in members)
+            ranked.append((distance > 1, not waited, distance, (cx, cy), members))
+        ranked.sort(key=lambda entry: entry[:4])
+        return [(not far, members) for far, _, _, _, members in ranked]
+
+    def think(
         self,
         eid: int,
-        monster_pos: Position,
+        monster: Monster,
+        pos: Position,
         player_pos: Position,
         game_map: GameMap,
-        spatial_index,
+        spatial_index=None,
         combat_callback=None,
-    ):
-        """Aggressive AI that moves toward the player using pathfinding."""
-        dist_x = abs(player_pos.x - monster_pos.x)
-        dist_y = abs(player_pos.y - monster_pos.y)
-        distance = max(dist_x, dist_y)
-
-        aggro_range = 10
-
-        if distance > aggro_range:
-            self._passive_ai(eid, monster_pos, game_map, spatial_index)
-            return
-
-        if distance == 1:
-            # Adjacent to player, attack
-            if combat_callback:
-                combat_callback(eid)
-            return
-
-        # Find path to player
-        path = self._get_path_to(
-            monster_pos.x,
-            monster_pos.y,
-            player_pos.x,
-            player_pos.y,
+        alert_callback=None,
+        player_id: Optional[int] = None,
+    ) -> Optional[str]:
+        """Tick a monster's behavior tree once."""
+        tree = self.trees.get(monster.behavior or monster.ai_type)
+        if tree is None:
+            return None
+        context = AIContext(
+            self,
+            eid,
+            monster,
+            pos,
+            player_pos,
             game_map,
             spatial_index,
+            combat_callback,
+            alert_callback,
+            player_id,
         )
-
-        if path:
-            new_x, new_y = path[0]
-
-            # Final check if new position is actually free now
-            if not spatial_index or not spatial_index.is_occupied(new_x, new_y):
-                monster_pos.x = new_x
-                monster_pos.y = new_y
-                self.entity_manager.notify_component_change(eid, Position)
+        return tree.tick(context)
+
+    def _track_player(self, player_pos: Position, num_batches: int):
+        """Estimate the player's heading; it resets after a full AI cycle standing still."""
+        current = (player_pos.x, player_pos.y)
+        if self._last_player_pos and current != self._last_player_pos:
+            self._player_velocity = (
+                _sign(current[0] - self._last_player_pos[0]),
+                _sign(current[1] - self._last_player_pos[1]),
+            )
+            self._player_still_ticks = 0
         else:
-            # Fallback: Simple vector approach if A* fails due to depth limit but player is close
-            dx = 0
-            if player_pos.x > monster_pos.x:
-                dx = 1
-            elif player_pos.x < monster_pos.x:
-                dx = -1
-
-            dy = 0
-            if player_pos.y > monster_pos.y:
-                dy = 1
-            elif player_pos.y < monster_pos.y:
-                dy = -1
-
-            new_x, new_y = monster_pos.x + dx, monster_pos.y + dy
-            if game_map.is_walkable(new_x, new_y) and (
-                not spatial_index or not spatial_index.is_occupied(new_x, new_y)
-            ):
-                monster_pos.x = new_x
-                monster_pos.y = new_y
-                self.entity_manager.notify_component_change(eid, Position)
-            else:
-                self._passive_ai(eid, monster_pos, game_map, spatial_index)
-
-    def _passive_ai(
+            self._player_still_ticks += 1
+            if self._player_still_ticks >= num_batches:
+                self._player_velocity = (0, 0)
+        self._last_player_pos = current
+
+    def _chase_target(self, monster_pos: Position, player_pos: Position, game_map: GameMap):
+        """Aim ahead of a moving player, by up to half the distance between them."""
+        distance = max(abs(player_pos.x - monster_pos.x), abs(player_pos.y - monster_pos.y))
+        vx, vy = self._player_velocity
+        for lead in range(min(CHASE_LOOKAHEAD, distance // 2), 0, -1):
+            tx, ty = player_pos.x + vx * lead, player_pos.y + vy * lead
+            if game_map.is_walkable(tx, ty):
+                return tx, ty
+        return player_pos.x, player_pos.y
+
+    def _nearby_monsters(
+        self, x: int, y: int, radius: int, spatial_index=None
+    ) -> List[int]:
+        """Monster IDs within radius tiles, via the spatial index when there is one."""
+        if spatial_index is not None:
+            return spatial_index.monsters_near(x, y, radius)
+        nearby = []
+        for other in self.entity_manager.components_by_type.get(Monster, {}):
+            pos = self.entity_manager.get_component(other, Position)
+            if pos and max(abs(pos.x - x), abs(pos.y - y)) <= radius:
+                nearby.append(other)
+        return nearby
+
+    def _flank_target(
         self,
         eid: int,
-        monster_pos: Position,
+        monster: Monster,
+        player_pos: Position,
         game_map: GameMap,
-        spatial_index,
+        spatial_index=None,
     ):
-        """Passive AI that occasionally moves randomly."""
-        if random.random() < 0.1:
-            directions = [
-                (0, 1),
-                (0, -1),
-                (1, 0),
-                (-1, 0),
-                (1, 1),
-                (1, -1),
-                (-1, 1),
-                (-1, -1),
-            ]
-            dx, dy = random.choice(directions)
-            new_x = monster_pos.x + dx
-            new_y = monster_pos.y + dy
-
-            if (
-                0 <= new_x < game_map.width
-                and 0 <= new_y < game_map.height
-                and game_map.is_walkable(new_x, new_y)
-            ):
-                if not spatial_index or not spatial_index.is_occupied(new_x, new_y):
-                    monster_pos.x = new_x
-                    monster_pos.y = new_y
-                    self.entity_manager.notify_component_change(eid, Position)
-
-    def _patrol_ai(
-        self,
-        eid: int,
-        monster_pos: Position,
-        game_map: GameMap,
-        spatial_index,
+        """Tile on the far side of the player from the pack's leader, or None to chase directly."""
+        leader = None
+        nearby = self._nearby_monsters(
+            player_pos.x, player_pos.y, AGGRO_RANGE, spatial_index
+        )
+        for other in nearby:
+            other_monster = self.entity_manager.get_component(other, Monster)
+            if other >= eid or other_monster is None:
+                continue
+            if other_monster.monster_type != monster.monster_type:
+                continue
+            if leader is None or other < leader:
+                leader = other
+
+        leader_pos = None
+        if leader is not None:
+            leader_pos = self.entity_manager.get_component(leader, Position)
+
+        if leader_pos is None:
+            return None
+
+        sx = _sign(leader_pos.x - player_pos.x)
+        sy = _sign(leader_pos.y - player_pos.y)
+        # Opposite side first, then either side
+        for fx, fy in ((-sx, -sy), (-sy, sx), (sy, -sx)):
+            if (fx, fy) == (0, 0):
+                continue
+            tx, ty = player_pos.x + fx, player_pos.y + fy
+            if game_map.is_walkable(tx, ty):
+                return tx, ty
+        return None
+
+    def _call_allies(
+        self, eid: int, monster: Monster, monster_pos: Position, spatial_index=None
+    ) -> int:
+        """Alert packmates within call_radius; returns how many answered."""
+        answered = 0
+        for other in self._nearby_monsters(
+            monster_pos.x, monster_pos.y, monster.call_radius, spatial_index
+        ):
+            other_monster = self.entity_manager.get_component(other, Monster)
+            if other == eid or other_monster is None or other_monster.alerted:
+                continue
+            if other_monster.monster_type != monster.monster_type:
+                continue
+            other_monster.alerted = True
+            answered += 1
+        return answered
+
+    def _owner_position(self, eid: int) -> Optional[Position]:
+        """Where the player an ally belongs to stands, if it has one."""
+        member = self.entity_manager.get_component(eid, PartyMember)
+        if member is None:
+            return None
+        return self.entity_manager.get_component(member.leader, Position)
+
+    def _is_enemy(self, eid: int) -> bool:
+        if self.occupancy is not None:
+            return self.occupancy.is_hostile(eid)
+        monster = self.entity_manager.get_component(eid, Monster)
+        return (
+            monster is not None
+            and monster.ai_type not in PEACEFUL_AI_TYPES
+            and not self.entity_manager.has_component(eid, PartyMember)
+        )
+
+    def _enemy_near(
+        self, eid: int, pos: Position, around: Position, tiles: int, spatial_index=None
+    ) -> Optional[int]:
+        """The hostile within tiles of around that is closest to pos, if any."""
+        best, best_distance = None, None
+        for other in self._nearby_monsters(around.x, around.y, tiles, spatial_index):
+            other_pos = self.entity_manager.get_component(other, Position)
+            if other == eid or other_pos is None or other_pos.z != pos.z:
+                continue
+            if not self._is_enemy(other):
+                continue
+            distance = max(abs(other_pos.x - pos.x), abs(other_pos.y - pos.y))
+            if best_distance is None or distance < best_distance:
+                best, best_distance = other, distance
+        return best
+
+    def _get_path_to(
+        self, start_x, start_y, end_x, end_y, game_map, spatial_index, eid=None
     ):
-        """Patrol AI that moves randomly but stays in a general area."""
-        if random.random() < 0.4:
-            directions = [
-                (0, 1),
-                (0, -1),
-                (1, 0),
-                (-1, 0),
-                (1, 1),
-                (1, -1),
-                (-1, 1),
-                (-1, -1),
-            ]
-            dx, dy = random.choice(directions)
-            new_x = monster_pos.x + dx
-            new_y = monster_pos.y + dy
-
-            if (
-                0 <= new_x < game_map.width
-                and 0 <= new_y < game_map.height
-                and game_map.is_walkable(new_x, new_y)
-            ):
-                if not spatial_index or not spatial_index.is_occupied(new_x, new_y):
-                    monster_pos.x = new_x
-                    monster_pos.y = new_y
-                    self.entity_manager.notify_component_change(eid, Position)
+        """A* path to a target, avoiding tiles occupied by other entities."""
+        if self.occupancy and eid is not None:
+            blocked = self.occupancy.blocker_for(eid)
+        else:
+            blocked = spatial_index.is_occupied if spatial_index else None
+        # Limit search depth for performance
+        return find_path(
+            game_map,
+            (start_x, start_y),
+            (end_x, end_y),
+            blocked=blocked,
+            max_nodes=100,
+            move_cost=game_map.move_cost,
+        )
+
+    def _try_move(self, eid, monster_pos, new_x, new_y, game_map, spatial_index):
+        """Move a monster onto a tile if terrain and occupancy rules allow it."""
+        if not game_map.is_walkable(new_x, new_y):
+            return False
+
+        if self.occupancy:
+            rule, occupant = self.occupancy.check_move(eid, new_x, new_y)
+            if rule == "block":
+                return False
+            if rule == "swap":
+                self.occupancy.swap(eid, occupant)
+                return True
+        elif spatial_index and spatial_index.is_occupied(new_x, new_y):
+            return False
+
+        monster_pos.x = new_x
+        monster_pos.y = new_y
+        self.entity_manager.notify_component_change(eid, Position)
+        return True
+
+
+# --- Conditions ---
+
+
+@condition("player_in_aggro_range")
+def _player_in_aggro_range(ctx: AIContext) -> bool:
+    """The player is close enough to hunt and not hidden; losing them clears the alert."""
+    stealth = ctx.system.stealth
+    hidden = stealth is not None and not stealth.perceives(ctx.eid, ctx.player_id)
+    aggro_range = ALERT_RANGE if ctx.monster.alerted else AGGRO_RANGE
+    if not hidden and ctx.player_distance <= aggro_range:
+        return True
+    ctx.monster.alerted = False
+    return False
+
+
+@condition("player_within")
+def _player_within(ctx: AIContext, tiles: int = 1) -> bool:
+    return ctx.player_distance <= tiles
+
+
+@condition("has_tactic")
+def _has_tactic(ctx: AIContext, tactic: str = "") -> bool:
+    return ctx.monster.tactics == tactic
+
+
+@condition("player_is_enemy")
+def _player_is_enemy(ctx: AIContext) -> bool:
+    """The player's reputation with this monster's faction has fallen to hostile."""
+    factions = ctx.system.factions
+    if factions is None or ctx.player_id is None:
+        return False
+    reputation = ctx.system.entity_manager.get_component(ctx.player_id, Reputation)
+    if reputation is None:
+        return False
+    return factions.is_hostile(reputation.standing, factions.faction_of(ctx.monster.monster_type))
+
+
+@condition("enemy_near_owner")
+def _enemy_near_owner(ctx: AIContext, tiles: int = 6) -> bool:
+    """A hostile is within tiles of the ally's owner; the nearest becomes its target."""
+    owner_pos = ctx.system._owner_position(ctx.eid)
+    if owner_pos is None:
+        return False
+    ctx.target = ctx.system._enemy_near(ctx.eid, ctx.pos, owner_pos, tiles, ctx.spatial_index)
+    return ctx.target is not None
+
+
+@condition("target_within")
+def _target_within(ctx: AIContext, tiles: int = 1) -> bool:
+    target_pos = ctx.system.entity_manager.get_component(ctx.target, Position)
+    if target_pos is None:
+        return False
+    return max(abs(target_pos.x - ctx.pos.x), abs(target_pos.y - ctx.pos.y)) <= tiles
+
+
+@condition("owner_beyond")
+def _owner_beyond(ctx: AIContext, tiles: int = 2) -> bool:
+    owner_pos = ctx.system._owner_position(ctx.eid)
+    if owner_pos is None:
+        return False
+    return max(abs(owner_pos.x - ctx.pos.x), abs(owner_pos.y - ctx.pos.y)) > tiles
+
+
+@condition("chance")
+def _chance(ctx: AIContext, chance: float = 0.5) -> bool:
+    return random.random() < chance
+
+
+# --- Actions ---
+
+
+@action("idle")
+def _idle(ctx: AIContext):
+    return SUCCESS
+
+
+@action("raise_alert")
+def _raise_alert(ctx: AIContext):
+    """Mark the monster as hunting; callers summon their packmates the first time."""
+    monster = ctx.monster
+    if not monster.alerted:
+        monster.alerted = True
+        if monster.tactics == "call_allies" and monster.call_radius > 0:
+            answered = ctx.system._call_allies(
+                ctx.eid, monster, ctx.pos, ctx.spatial_index
+            )
+            if answered and ctx.alert_callback:
+                ctx.alert_callback(ctx.eid, answered)
+    return SUCCESS
+
+
+@action("attack_player")
+def _attack_player(ctx: AIContext):
+    if ctx.combat_callback:
+        ctx.combat_callback(ctx.eid)
+    return SUCCESS
+
+
+@action("attack_target")
+def _attack_target(ctx: AIContext):
+    if ctx.combat_callback and ctx.target is not None:
+        ctx.combat_callback(ctx.eid, ctx.target)
+    return SUCCESS
+
+
+@action("chase_target")
+def _chase_target(ctx: AIContext):
+    target_pos = ctx.system.entity_manager.get_component(ctx.target, Position)
+    if target_pos is None:
+        return FAILURE
+    return ctx.move_along_path_to(target_pos.x, target_pos.y)
+
+
+@action("follow_owner")
+def _follow_owner(ctx: AIContext):
+    """Path back to the owner, or step straight at them when A* gives up."""
+    owner_pos = ctx.system._owner_position(ctx.eid)
+    if owner_pos is None:
+        return FAILURE
+    if ctx.move_along_path_to(owner_pos.x, owner_pos.y):
+        return SUCCESS
+    dx = _sign(owner_pos.x - ctx.pos.x)
+    dy = _sign(owner_pos.y - ctx.pos.y)
+    return ctx.system._try_move(
+        ctx.eid, ctx.pos, ctx.pos.x + dx, ctx.pos.y + dy, ctx.game_map, ctx.spatial_index
+    )
+
+
+@action("move_to_flank")
+def _move_to_flank(ctx: AIContext):
+    target = ctx.system._flank_target(
+        ctx.eid, ctx.monster, ctx.player_pos, ctx.game_map, ctx.spatial_index
+    )
+    if target is None:
+        return FAILURE
+    return ctx.move_along_path_to(*target)
+
+
+@action("chase_player")
+def _chase_player(ctx: AIContext):
+    """Path towards the player, leading them if they are on the move."""
+    target = ctx.system._chase_target(ctx.pos, ctx.player_pos, ctx.game_map)
+    return ctx.move_along_path_to(*target)
+
+
+@action("step_toward_player")
+def _step_toward_player(ctx: AIContext):
+    """Single straight step; used when A* gives up but the player is close."""
+    dx = _sign(ctx.player_pos.x - ctx.pos.x)
+    dy = _sign(ctx.player_pos.y - ctx.pos.y)
+    return ctx.system._try_move(
+        ctx.eid, ctx.pos, ctx.pos.x + dx, ctx.pos.y + dy, ctx.game_map, ctx.spatial_index
+    )
+
+
+@action("wander")
+def _wander(ctx: AIContext, chance: float = 0.1):
+    """Occasionally step in a random direction."""
+    if random.random() < chance:
+        dx, dy = random.choice(DIRECTIONS)
+        ctx.system._try_move(
+            ctx.eid, ctx.pos, ctx.pos.x + dx, ctx.pos.y + dy, ctx.game_map, ctx.spatial_index
+        )
+    return SUCCESS
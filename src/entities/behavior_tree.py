@@ -0,0 +1,132 @@
+"""
+Behavior tree engine for NPC and monster AI.
+Trees are plain data (see src/data/static/behaviors.json) built from composite
+nodes (selector, sequence, inverter) and named condition/action leaves that
+game code registers with @condition and @action.
+"""
+
+from typing import Any, Callable, Dict, List
+
+SUCCESS = "success"
+FAILURE = "failure"
+RUNNING = "running"
+
+# Leaf registries: name -> callable(context, **params)
+CONDITIONS: Dict[str, Callable[..., bool]] = {}
+ACTIONS: Dict[str, Callable[..., str]] = {}
+
+
+def condition(name: str):
+    """Register a condition leaf; the function returns True or False."""
+
+    def register(func):
+        CONDITIONS[name] = func
+        return func
+
+    return register
+
+
+def action(name: str):
+    """Register an action leaf; the function returns a status (True/False also accepted)."""
+
+    def register(func):
+        ACTIONS[name] = func
+        return func
+
+    return register
+
+
+def _status(result) -> str:
+    if result is True:
+        return SUCCESS
+    if result is False or result is None:
+        return FAILURE
+    return result
+
+
+class Node:
+    """Base class for behavior tree nodes."""
+
+    def tick(self, context) -> str:
+        raise NotImplementedError
+
+
+class Selector(Node):
+    """Runs children in order until one does not fail."""
+
+    def __init__(self, children: List[Node]):
+        self.children = children
+
+    def tick(self, context) -> str:
+        for child in self.children:
+            status = child.tick(context)
+            if status != FAILURE:
+                return status
+        return FAILURE
+
+
+class Sequence(Node):
+    """Runs children in order until one does not succeed."""
+
+    def __init__(self, children: List[Node]):
+        self.children = children
+
+    def tick(self, context) -> str:
+        for child in self.children:
+            status = child.tick(context)
+            if status != SUCCESS:
+                return status
+        return SUCCESS
+
+
+class Inverter(Node):
+    """Swaps the success and failure of its child."""
+
+    def __init__(self, child: Node):
+        self.child = child
+
+    def tick(self, context) -> str:
+        status = self.child.tick(context)
+        if status == SUCCESS:
+            return FAILURE
+        if status == FAILURE:
+            return SUCCESS
+        return status
+
+
+class Leaf(Node):
+    """Calls a registered condition or action with the node's parameters."""
+
+    def __init__(self, func: Callable, params: Dict[str, Any]):
+        self.func = func
+        self.params = params
+
+    def tick(self, context) -> str:
+        return _status(self.func(context, **self.params))
+
+
+def build_tree(spec: Dict[str, Any]) -> Node:
+    """Build a tree from its content-file definition."""
+    node_type = spec.get("type")
+
+    if node_type in ("selector", "sequence"):
+        children = [build_tree(child) for child in spec.get("children", [])]
+        return Selector(children) if node_type == "selector" else Sequence(children)
+
+    if node_type == "inverter":
+        return Inverter(build_tree(spec["child"]))
+
+    if node_type in ("condition", "action"):
+        registry = CONDITIONS if node_type == "condition" else ACTIONS
+        name = spec.get("name")
+        if name not in registry:
+            raise ValueError(f"Unknown {node_type} '{name}' in behavior tree")
+        params = {k: v for k, v in spec.items() if k not in ("type", "name")}
+        return Leaf(registry[name], params)
+
+    raise ValueError(f"Unknown behavior tree node type '{node_type}'")
+
+
+def build_trees(data: Dict[str, Dict[str, Any]]) -> Dict[str, Node]:
+    """Build every named tree in a behaviors file."""
+    return {name: build_tree(spec) for name, spec in data.items()}
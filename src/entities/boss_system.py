@@ -1,9 +1,14 @@
 """
 Boss system for special encounters in the roguelike game.
+Fights are scripted per boss type in src/data/static/bosses.json: phases that
+start at HP thresholds, timed abilities, add spawns, an enrage timer and
+arena-wide pulses, all driven from the game tick.
 """
 
-from typing import Dict, List, Tuple, Optional
+import random
+from typing import Any, Callable, Dict, List, Tuple, Optional
 from core.ecs import EntityManager
+from data.loader import DATA_LOADER
 from entities.entities import EntityFactory
 from entities.components import Position, Monster, Health, Combat, Render
 from world.persistent_world import get_persistent_world
@@ -35,6 +40,38 @@ class BossEncounter:
         self.is_spawned = False
 
 
+class BossFight:
+    """Runtime state of a spawned boss: its phase, ability cooldowns and enrage."""
+
+    def __init__(self, encounter: BossEncounter, eid: int, script: Dict[str, Any]):
+        self.encounter = encounter
+        self.eid = eid
+        self.script = script
+        self.phases: List[Dict[str, Any]] = sorted(
+            script.get("phases", [{"hp_below": 1.0}]),
+            key=lambda phase: -phase.get("hp_below", 1.0),
+        )
+        self.phase_index = 0
+        self.elapsed = 0.0
+        self.enraged = False
+        self.cooldowns: Dict[Tuple[int, int], float] = {}  # (phase, ability) -> seconds left
+        self.adds: List[int] = []
+
+    @property
+    def phase(self) -> Dict[str, Any]:
+        return self.phases[self.phase_index]
+
+    @property
+    def arena_radius(self) -> int:
+        return self.script.get("arena_radius", 10)
+
+    def attack_multiplier(self) -> float:
+        mult = self.phase.get("attack_mult", 1.0)
+        if self.enraged:
+            mult *= self.script.get("enrage", {}).get("attack_mult", 1.0)
+        return mult
+
+
 class BossSystem:
     """System for managing boss encounters."""
 
@@ -43,6 +80,8 @@ class BossSystem:
         self.entity_factory = entity_factory
         self.persistent_world = get_persistent_world()
         self.boss_encounters: Dict[Tuple[int, int], BossEncounter] = {}
+        self.scripts: Dict[str, Dict[str, Any]] = DATA_LOADER.load_json("bosses")
+        self.active_fights: Dict[int, BossFight] = {}
         self._setup_boss_encounters()
 
     def _setup_boss_encounters(self):
@@ -188,4 +227,132 @@ class BossSystem:
         """Trigger a boss encounter and spawn the boss."""
         print(f"BOSS ENCOUNTER: {boss_encounter.name} appears!")
         boss_entity_id = self.spawn_boss(boss_encounter)
+        self.active_fights[boss_entity_id] = BossFight(
+            boss_encounter, boss_entity_id, self.scripts.get(boss_encounter.boss_type, {})
+        )
         return boss_entity_id
+
+    def mark_defeated(self, eid: int):
+        """Record that a boss was killed (called before its entity is destroyed)."""
+        fight = self.active_fights.pop(eid, None)
+        if fight:
+            fight.encounter.defeated = True
+            fight.encounter.is_spawned = False
+
+    def update(
+        self,
+        dt: float,
+        game_map,
+        player_id: int,
+        log_callback: Optional[Callable[[str, Tuple[int, int, int]], None]] = None,
+    ):
+        """Advance every active fight: phases, enrage and ability timers."""
+        log = log_callback or (lambda text, color: None)
+
+        for eid, fight in list(self.active_fights.items()):
+            health = self.entity_manager.get_component(eid, Health)
+            if health is None:
+                # Despawned without being killed; the encounter can trigger again
+                fight.encounter.is_spawned = False
+                del self.active_fights[eid]
+                continue
+
+            fight.elapsed += dt
+            self._check_phase(fight, health, game_map, player_id, log)
+            self._check_enrage(fight, log)
+
+            for index, ability in enumerate(fight.phase.get("abilities", [])):
+                key = (fight.phase_index, index)
+                remaining = fight.cooldowns.get(key, ability.get("interval", 10)) - dt
+                if remaining <= 0:
+                    remaining = ability.get("interval", 10)
+                    self._use_ability(fight, ability, game_map, player_id, log)
+                fight.cooldowns[key] = remaining
+
+    def _check_phase(self, fight: BossFight, health: Health, game_map, player_id, log):
+        """Move to the deepest phase whose HP threshold has been crossed."""
+        fraction = health.current / max(1, health.maximum)
+        new_index = fight.phase_index
+        while (
+            new_index + 1 < len(fight.phases)
+            and fraction <= fight.phases[new_index + 1].get("hp_below", 0.0)
+        ):
+            new_index += 1
+
+        if new_index == fight.phase_index:
+            return
+
+        fight.phase_index = new_index
+        phase = fight.phase
+        if phase.get("message"):
+            log(phase["message"], (255, 80, 80))
+        for effect in phase.get("on_enter", []):
+            self._use_ability(fight, effect, game_map, player_id, log)
+        self._apply_attack(fight)
+
+    def _check_enrage(self, fight: BossFight, log):
+        enrage = fight.script.get("enrage")
+        if fight.enraged or not enrage or fight.elapsed < enrage.get("after", 0):
+            return
+        fight.enraged = True
+        log(enrage.get("message", f"{fight.encounter.name} becomes enraged!"), (255, 0, 0))
+        self._apply_attack(fight)
+
+    def _apply_attack(self, fight: BossFight):
+        combat = self.entity_manager.get_component(fight.eid, Combat)
+        if combat:
+            combat.attack_power = int(fight.encounter.attack * fight.attack_multiplier())
+
+    def _use_ability(self, fight: BossFight, ability: Dict[str, Any], game_map, player_id, log):
+        """Execute one scripted ability or phase effect."""
+        boss_pos = self.entity_manager.get_component(fight.eid, Position)
+        player_pos = self.entity_manager.get_component(player_id, Position)
+        if not boss_pos:
+            return
+
+        effect = ability.get("effect")
+        hit_player = False
+
+        if effect == "strike" and player_pos:
+            # Targeted attack on a player within range of the boss
+            distance = max(abs(player_pos.x - boss_pos.x), abs(player_pos.y - boss_pos.y))
+            hit_player = distance <= ability.get("range", 1)
+        elif effect == "pulse" and player_pos:
+            # Arena-wide attack centred on the encounter, wherever the boss stands
+            distance = max(
+                abs(player_pos.x - fight.encounter.x), abs(player_pos.y - fight.encounter.y)
+            )
+            hit_player = distance <= fight.arena_radius
+        elif effect == "heal":
+            health = self.entity_manager.get_component(fight.eid, Health)
+            if health:
+                health.current = min(
+                    health.maximum,
+                    health.current + int(health.maximum * ability.get("fraction", 0.1)),
+                )
+        elif effect == "summon":
+            self._spawn_adds(fight, boss_pos, game_map, ability)
+
+        if effect in ("strike", "pulse"):
+            if not hit_player:
+                return
+            player_health = self.entity_manager.get_component(player_id, Health)
+            if player_health:
+                player_health.current -= ability.get("damage", 0)
+
+        if ability.get("message"):
+            log(ability["message"], (255, 120, 60))
+
+    def _spawn_adds(self, fight: BossFight, boss_pos: Position, game_map, ability: Dict[str, Any]):
+        """Spawn minions on free walkable tiles around the boss."""
+        candidates = [
+            (boss_pos.x + dx, boss_pos.y + dy)
+            for dx in range(-2, 3)
+            for dy in range(-2, 3)
+            if (dx, dy) != (0, 0) and game_map.is_walkable(boss_pos.x + dx, boss_pos.y + dy)
+        ]
+        random.shuffle(candidates)
+        for x, y in candidates[: ability.get("count", 1)]:
+            fight.adds.append(
+                self.entity_factory.create_monster(x, y, ability.get("monster", "goblin"))
+            )
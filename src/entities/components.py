@@ -3,7 +3,7 @@ Component definitions for the ECS system.
 """
 
 from dataclasses import dataclass
-from typing import Tuple, Optional, List
+from typing import Dict, Tuple, Optional, List
 from core.ecs import Component
 
 
@@ -61,7 +61,7 @@ class Combat(Component):
 class Player(Component):
     """Player tag component."""
 
-    pass
+    speed: float = 1.0  # How fast energy builds up under energy scheduling
 
 
 @dataclass(slots=True)
@@ -71,11 +71,21 @@ class Skills(Component):
     melee: int = 5
     distance: int = 5
     magic: int = 5
+    swimming: int = 0  # 0 = cannot swim; deep water needs at least 1
+    stealth: int = 1
+    crafting: int = 1
+    alchemy: int = 1
+    fishing: int = 1
 
     # XP trackers for each skill
     melee_xp: int = 0
     distance_xp: int = 0
     magic_xp: int = 0
+    stealth_xp: int = 0
+    swimming_xp: int = 0
+    crafting_xp: int = 0
+    alchemy_xp: int = 0
+    fishing_xp: int = 0
 
     # XP thresholds (next level = current_level * 100 roughly)
     def xp_for_next_level(self, current_level: int) -> int:
@@ -101,6 +111,11 @@ class Monster(Component):
     name: str = "Unknown Monster"
     speed: float = 1.0  # Movement speed factor
     xp_reward: int = 10  # XP given when defeated
+    tactics: str = ""  # Pack behavior: "", flank, call_allies
+    call_radius: int = 0  # Tiles within which call_allies alerts packmates
+    behavior: str = ""  # Behavior tree name; empty uses ai_type
+    alerted: bool = False  # Hunting the player beyond normal aggro range
+    perception: int = 5  # Chance to spot a stealthed player
 
 
 @dataclass(slots=True)
@@ -115,6 +130,11 @@ class Item(Component):
     color: Tuple[int, int, int] = (255, 255, 255)
     rarity: str = "common"
     affixes: List[str] = None
+    item_type: str = ""  # Key in items.json
+    quality: str = ""  # Crafted items only: crude, standard, fine, superior, masterwork
+    crafted_by: str = ""  # Name of the player who crafted it
+    bind: str = ""  # "pickup" or "equip": when it binds to its owner, if ever
+    bound_to: str = ""  # Name of the player it is bound to
 
     def __post_init__(self):
         if self.affixes is None:
@@ -153,10 +173,13 @@ class Inventory(Component):
     capacity: int
     items: List[int]  # List of Entity IDs representing items
     gold: int = 0
+    currencies: Dict[str, int] = None  # Every other currency held (see currency.py)
 
     def __post_init__(self):
         if self.items is None:
             self.items = []
+        if self.currencies is None:
+            self.currencies = {}
 
 
 @dataclass(slots=True)
@@ -205,6 +228,87 @@ class FieldOfView(Component):
             self.visible_tiles = []
 
 
+@dataclass(slots=True)
+class Attunement(Component):
+    """Component listing the waypoints an entity can travel to."""
+
+    waypoints: List[str] = None
+
+    def __post_init__(self):
+        if self.waypoints is None:
+            self.waypoints = []
+
+
+@dataclass(slots=True)
+class Discoveries(Component):
+    """Component listing the points of interest an entity has found."""
+
+    pois: List[str] = None
+
+    def __post_init__(self):
+        if self.pois is None:
+            self.pois = []
+
+
+@dataclass(slots=True)
+class Reputation(Component):
+    """Component holding an entity's standing with each faction."""
+
+    standing: Dict[str, int] = None  # Faction id -> reputation points
+
+    def __post_init__(self):
+        if self.standing is None:
+            self.standing = {}
+
+
+@dataclass(slots=True)
+class Stealth(Component):
+    """Component for entities that can hide from monsters."""
+
+    active: bool = False
+    moved: float = 0.0  # Seconds since the entity last moved
+    spotted_by: Dict[int, float] = None  # Monster -> seconds it keeps track of the entity
+
+    def __post_init__(self):
+        if self.spotted_by is None:
+            self.spotted_by = {}
+
+
+@dataclass(slots=True)
+class Onboarding(Component):
+    """Component tracking a player's progress through the tutorial."""
+
+    step: int = 0  # Index of the current tutorial step
+    count: int = 0  # Progress towards the current step's count
+    complete: bool = False
+
+
+@dataclass(slots=True)
+class Season(Component):
+    """Component stamping a character with the season it was created in."""
+
+    season_id: int = 0
+    seasonal: bool = False  # Seasonal-only until its season ends
+
+
+@dataclass(slots=True)
+class Breath(Component):
+    """Component for entities that can hold their breath underwater."""
+
+    current: float = 10.0  # Seconds of air left
+    maximum: float = 10.0
+    underwater: bool = False
+    drown_timer: float = 0.0  # Seconds since the last drowning damage
+
+
+@dataclass(slots=True)
+class LightSource(Component):
+    """Component for entities and items that give off light."""
+
+    radius: int = 5
+    intensity: float = 1.0  # Brightness at the source, 0..1
+
+
 @dataclass(slots=True)
 class BlocksTile(Component):
     """Component indicating that an entity blocks movement."""
@@ -212,6 +316,56 @@ class BlocksTile(Component):
     pass
 
 
+@dataclass(slots=True)
+class PartyMember(Component):
+    """Component for companions travelling in a player's party."""
+
+    leader: int  # Entity ID of the party's player
+
+
+@dataclass(slots=True)
+class Summoned(Component):
+    """Component for an ally a player called up; it unravels when time runs out."""
+
+    owner: int  # Entity ID of the player who summoned it
+    remaining: float  # Seconds left before it despawns
+
+
+@dataclass(slots=True)
+class DamageTaken(Component):
+    """Component tallying the damage each player or party has dealt a monster."""
+
+    by: Dict[int, float] = None  # Credited player (party leader) -> damage dealt
+
+    def __post_init__(self):
+        if self.by is None:
+            self.by = {}
+
+
+@dataclass(slots=True)
+class LootOwner(Component):
+    """Component reserving a dropped item for whoever earned the kill."""
+
+    owner: int  # Entity ID of the credited player (party leader)
+    owner_name: str = ""
+    free_at: float = 0.0  # Game time after which anyone may take it
+
+
+@dataclass(slots=True)
+class Unidentified(Component):
+    """Component hiding an item's real name and stats until it is identified."""
+
+    name: str  # The item's real name, shown once identified
+    color: Tuple[int, int, int] = (255, 255, 255)  # Its rarity color, likewise
+
+
+@dataclass(slots=True)
+class Cursed(Component):
+    """Component for cursed gear, which cannot be taken off once worn."""
+
+    affix: str  # The harmful affix the curse brought
+
+
 @dataclass(slots=True)
 class BlocksVision(Component):
     """Component indicating that an entity blocks vision."""
@@ -225,10 +379,34 @@ class Shop(Component):
 
     shop_name: str
     items: List[Tuple[str, int]]  # List of (item_type, price)
+    faction: str = ""  # Faction whose reputation unlocks restricted stock
+    restricted: List[Tuple[str, int, str]] = None  # (item_type, price, minimum tier)
 
     def __post_init__(self):
         if self.items is None:
             self.items = []
+        if self.restricted is None:
+            self.restricted = []
+
+
+@dataclass(slots=True)
+class Schedule(Component):
+    """Component for NPCs that follow a daily routine."""
+
+    routine: str  # Key into schedules.json
+    anchors: Dict[str, Tuple[int, int]] = None  # Place name -> position (work, home)
+    target: str = ""  # Place the NPC is currently heading to
+    path: List[Tuple[int, int]] = None  # Remaining steps towards target
+    available: bool = True  # Whether shop/dialogue interactions are open
+    paths: Dict[Tuple, List[Tuple[int, int]]] = None  # Cached paths between places
+
+    def __post_init__(self):
+        if self.anchors is None:
+            self.anchors = {}
+        if self.path is None:
+            self.path = []
+        if self.paths is None:
+            self.paths = {}
 
 
 @dataclass(slots=True)
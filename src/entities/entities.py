@@ -1,7 +1,8 @@
-from typing import List, Tuple
+from typing import List, Optional, Tuple
 import random
 from core.ecs import EntityManager
 from data.loader import DATA_LOADER
+from systems.glyphs import normalize_icon
 from entities.components import (
     Position,
     Name,
@@ -23,6 +24,14 @@ from entities.components import (
     Temperature,
     Banker,
     BankAccount,
+    Attunement,
+    Discoveries,
+    Reputation,
+    Stealth,
+    LightSource,
+    Breath,
+    Season,
+    Onboarding,
 )
 
 # Loot Configuration
@@ -80,6 +89,8 @@ class EntityFactory:
         y: int,
         name: str = "Merchant",
         shop_items: List[Tuple[str, int]] = None,
+        faction: str = "",
+        restricted: List[Tuple[str, int, str]] = None,
     ) -> int:
         """Create a static shopkeeper entity."""
         eid = self.entity_manager.create_entity()
@@ -96,7 +107,13 @@ class EntityFactory:
 
         # Add Shop component
         self.entity_manager.add_component(
-            eid, Shop(shop_name=f"{name}'s Shop", items=shop_items)
+            eid,
+            Shop(
+                shop_name=f"{name}'s Shop",
+                items=shop_items,
+                faction=faction,
+                restricted=restricted,
+            ),
         )
 
         # Add Monster component with static AI so it can be interacted with
@@ -112,6 +129,28 @@ class EntityFactory:
 
         return eid
 
+    def create_light(self, x: int, y: int, light_type: str = "wall_torch") -> int:
+        """Create a fixed light source such as a torch or lamp post."""
+        data = DATA_LOADER.load_json("lights").get(light_type, {})
+
+        eid = self.entity_manager.create_entity()
+        self.entity_manager.add_component(eid, Position(x=x, y=y))
+        self.entity_manager.add_component(eid, Name(value=data.get("name", "Light")))
+        self.entity_manager.add_component(
+            eid,
+            Render(
+                char=normalize_icon(data.get("char", "🔥"), "lights"),
+                fg_color=tuple(data.get("fg_color", [255, 160, 60])),
+            ),
+        )
+        self.entity_manager.add_component(
+            eid,
+            LightSource(
+                radius=data.get("radius", 5), intensity=data.get("intensity", 1.0)
+            ),
+        )
+        return eid
+
     def create_banker(self, x: int, y: int, name: str = "Banker") -> int:
         """Create a static banker entity."""
         eid = self.entity_manager.create_entity()
@@ -159,7 +198,10 @@ class EntityFactory:
         self.entity_manager.add_component(
             eid, Combat(attack_power=0, defense=0)
         )  # Combat now derived from Skills
-        self.entity_manager.add_component(eid, Skills())
+        self.entity_manager.add_component(eid, Skills(swimming=1))
+        self.entity_manager.add_component(eid, Breath())
+        self.entity_manager.add_component(eid, Season())
+        self.entity_manager.add_component(eid, Onboarding())
 
         # Equip the sword
         self.entity_manager.add_component(
@@ -175,6 +217,10 @@ class EntityFactory:
         self.entity_manager.add_component(eid, Level())
         self.entity_manager.add_component(eid, Player())
         self.entity_manager.add_component(eid, Temperature())
+        self.entity_manager.add_component(eid, Attunement())
+        self.entity_manager.add_component(eid, Discoveries())
+        self.entity_manager.add_component(eid, Reputation())
+        self.entity_manager.add_component(eid, Stealth())
 
         return eid
 
@@ -185,6 +231,7 @@ class EntityFactory:
         monster_type: str = "goblin",
         is_elite: bool = False,
         player_level: int = 1,
+        z: int = 0,
     ) -> int:
         """Create a monster entity with optional elite scaling."""
         eid = self.entity_manager.create_entity()
@@ -227,12 +274,16 @@ class EntityFactory:
             fg_color = [255, 215, 0] # Gold
             
         # Add components
-        self.entity_manager.add_component(eid, Position(x=x, y=y))
+        self.entity_manager.add_component(eid, Position(x=x, y=y, z=z))
         self.entity_manager.add_component(eid, Name(value=name))
 
         # Color tuple conversion
         self.entity_manager.add_component(
-            eid, Render(char=data.get("char", "?"), fg_color=tuple(fg_color))
+            eid,
+            Render(
+                char=normalize_icon(data.get("char"), "monsters"),
+                fg_color=tuple(fg_color),
+            ),
         )
 
         self.entity_manager.add_component(eid, Health(current=hp, maximum=hp))
@@ -249,15 +300,22 @@ class EntityFactory:
                 monster_type=monster_type,
                 name=name,
                 xp_reward=xp,
+                speed=float(data.get("speed", 1.0)),
+                tactics=data.get("tactics", ""),
+                call_radius=data.get("call_radius", 0),
+                behavior=data.get("behavior", ""),
+                perception=data.get("perception", 5),
             ),
         )
 
         return eid
 
-    def create_item(self, x: int, y: int, item_type: str) -> int:
-        """Create an item entity with random rarity/affixes."""
+    def create_item(
+        self, x: int, y: int, item_type: str, z: int = 0, rarity: Optional[str] = None
+    ) -> int:
+        """Create an item entity with random rarity/affixes, unless rarity is given."""
         eid = self.entity_manager.create_entity()
-        self.entity_manager.add_component(eid, Position(x=x, y=y))
+        self.entity_manager.add_component(eid, Position(x=x, y=y, z=z))
 
         # Load item data
         data = DATA_LOADER.get_item_data(item_type)
@@ -279,9 +337,10 @@ class EntityFactory:
             return eid
 
         # Roll Rarity
-        rarities = list(RARITY_CONFIG.keys())
-        weights = [RARITY_CONFIG[r]["weight"] for r in rarities]
-        rarity = random.choices(rarities, weights=weights, k=1)[0]
+        if rarity is None:
+            rarities = list(RARITY_CONFIG.keys())
+            weights = [RARITY_CONFIG[r]["weight"] for r in rarities]
+            rarity = random.choices(rarities, weights=weights, k=1)[0]
         config = RARITY_CONFIG[rarity]
 
         # Base item properties
@@ -353,19 +412,21 @@ class EntityFactory:
                 affixes.append("Legendary Boost")
 
         # Create Components
-        self.entity_manager.add_component(
-            eid, Render(char=data.get("char", "?"), fg_color=fg_color)
-        )
+        char = normalize_icon(data.get("char"), "items")
+        self.entity_manager.add_component(eid, Render(char=char, fg_color=fg_color))
 
         self.entity_manager.add_component(
             eid,
             Item(
                 name=name,
                 description=data.get("description", ""),
-                char=data.get("char", "?"),
+                value=data.get("value", 0),
+                char=char,
                 color=fg_color,
                 rarity=rarity,
                 affixes=affixes,
+                item_type=item_type,
+                bind=data.get("bind", ""),
             ),
         )
 
@@ -395,6 +456,15 @@ class EntityFactory:
                 ),
             )
 
+        if data.get("light_radius"):
+            self.entity_manager.add_component(
+                eid,
+                LightSource(
+                    radius=data["light_radius"],
+                    intensity=data.get("light_intensity", 1.0),
+                ),
+            )
+
         return eid
 
 
@@ -0,0 +1,83 @@
+"""
+Kill credit and loot ownership for the roguelike game.
+Every hit a player or their party lands on a monster is tallied against
+that player; companions count for the party's leader. When the monster dies
+the kill goes to whoever dealt the most damage rather than whoever landed
+the last blow, and what it drops is reserved for them and their party for a
+short window before anyone may take it, so nobody can steal loot by
+finishing off someone else's fight.
+"""
+
+from typing import Any, Dict, Optional
+
+from core.ecs import EntityManager
+from entities.components import (
+    DamageTaken,
+    LootOwner,
+    Monster,
+    Name,
+    PartyMember,
+    Player,
+)
+
+OWNERSHIP_WINDOW = 60.0  # Seconds a drop stays reserved for the credited party
+
+
+class LootSystem:
+    """Tallies damage on monsters and guards the drops of their killers."""
+
+    def __init__(self, entity_manager: EntityManager, window: float = OWNERSHIP_WINDOW):
+        self.entity_manager = entity_manager
+        self.window = window
+
+    def credit_for(self, eid: int) -> Optional[int]:
+        """The player credited with eid's damage: eid itself or its party's leader."""
+        member = self.entity_manager.get_component(eid, PartyMember)
+        if member:
+            return member.leader
+        if self.entity_manager.has_component(eid, Player):
+            return eid
+        return None
+
+    def record_damage(self, monster: int, attacker: int, amount: float):
+        """Add a hit on a monster to the attacker's party's tally."""
+        credit = self.credit_for(attacker)
+        if credit is None or amount <= 0:
+            return
+        if not self.entity_manager.has_component(monster, Monster):
+            return
+        taken = self.entity_manager.get_component(monster, DamageTaken)
+        if taken is None:
+            taken = DamageTaken()
+            self.entity_manager.add_component(monster, taken)
+        taken.by[credit] = taken.by.get(credit, 0) + amount
+
+    def kill_credit(self, monster: int, killer: Optional[int] = None) -> Optional[int]:
+        """Who earned a kill: the top damage dealer, else the killer's party.
+
+        Ties go to whoever drew blood first.
+        """
+        taken = self.entity_manager.get_component(monster, DamageTaken)
+        if taken and taken.by:
+            return max(taken.by, key=taken.by.get)
+        return self.credit_for(killer) if killer is not None else None
+
+    def claim(self, item: int, owner: int, now: float):
+        """Reserve a dropped item for the credited player and their party."""
+        name = self.entity_manager.get_component(owner, Name)
+        loot = LootOwner(owner, name.value if name else "", now + self.window)
+        self.entity_manager.add_component(item, loot)
+
+    def may_take(self, item: int, eid: int, now: float) -> bool:
+        """Whether eid may pick an item up: it is unreserved, expired or theirs."""
+        loot = self.entity_manager.get_component(item, LootOwner)
+        if not loot or now >= loot.free_at:
+            return True
+        return self.credit_for(eid) == loot.owner
+
+    def ownership(self, item: int, now: float) -> Dict[str, Any]:
+        """Who an item is reserved for and for how much longer, for payloads."""
+        loot = self.entity_manager.get_component(item, LootOwner)
+        if not loot or now >= loot.free_at:
+            return {"owner": None, "free_in": 0}
+        return {"owner": loot.owner_name, "free_in": round(loot.free_at - now, 1)}
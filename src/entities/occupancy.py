@@ -0,0 +1,142 @@
+"""
+Tile occupancy rules for the roguelike game.
+Decides what happens when an entity tries to enter a tile held by another:
+hostiles block (the player attacks them by bumping), bystanders block and
+party members swap places. Each relation's rule is configurable. Hostile
+monsters are also kept out of protected zones on the surface.
+"""
+
+from typing import Any, Callable, Dict, Optional, Tuple
+
+from core.ecs import EntityManager
+from core.spatial import SpatialIndex
+from entities.components import BlocksTile, Monster, PartyMember, Player, Position
+
+# What a mover does when the tile is held by an entity of each relation
+OCCUPANCY_RULES = ("block", "swap", "pass")
+DEFAULT_OCCUPANCY = {
+    "hostile": "block",
+    "neutral": "block",
+    "party": "swap",
+}
+
+# Monster AI types that never fight the player
+PEACEFUL_AI_TYPES = ("passive", "static", "ally")
+
+
+class OccupancyRules:
+    """Resolves moves into occupied tiles according to configured rules."""
+
+    def __init__(
+        self,
+        entity_manager: EntityManager,
+        spatial_index: SpatialIndex,
+        settings: Optional[Dict[str, Any]] = None,
+    ):
+        self.entity_manager = entity_manager
+        self.spatial_index = spatial_index
+        # Surface tiles monsters may not enter (set once regions are loaded)
+        self.safe_zone: Optional[Callable[[int, int], bool]] = None
+
+        self.rules: Dict[str, str] = dict(DEFAULT_OCCUPANCY)
+        for relation, rule in (settings or {}).items():
+            if relation in self.rules and rule in OCCUPANCY_RULES:
+                self.rules[relation] = rule
+
+    def party_of(self, eid: int) -> Optional[int]:
+        """Return the leader of the entity's party, if it belongs to one."""
+        if self.entity_manager.has_component(eid, Player):
+            return eid
+        member = self.entity_manager.get_component(eid, PartyMember)
+        return member.leader if member else None
+
+    def is_hostile(self, eid: int) -> bool:
+        """Check if an entity is a monster that fights players."""
+        if self.entity_manager.has_component(eid, PartyMember):
+            return False
+        monster = self.entity_manager.get_component(eid, Monster)
+        return monster is not None and monster.ai_type not in PEACEFUL_AI_TYPES
+
+    def relation(self, mover: int, occupant: int) -> str:
+        """Classify two entities as 'party', 'hostile' or 'neutral'."""
+        party = self.party_of(mover)
+        if party is not None and party == self.party_of(occupant):
+            return "party"
+
+        mover_is_player = self.entity_manager.has_component(mover, Player)
+        occupant_is_player = self.entity_manager.has_component(occupant, Player)
+        if (mover_is_player and self.is_hostile(occupant)) or (
+            occupant_is_player and self.is_hostile(mover)
+        ):
+            return "hostile"
+        return "neutral"
+
+    def is_blocking(self, eid: int) -> bool:
+        """Check if an entity takes up its tile (items and effects do not)."""
+        return (
+            eid in self.spatial_index.players
+            or eid in self.spatial_index.monsters
+            or self.entity_manager.has_component(eid, BlocksTile)
+        )
+
+    def same_level(self, a: int, b: int) -> bool:
+        """Check if two entities stand on the same Z-level."""
+        a_pos = self.entity_manager.get_component(a, Position)
+        b_pos = self.entity_manager.get_component(b, Position)
+        return not a_pos or not b_pos or a_pos.z == b_pos.z
+
+    def can_swap(self, mover: int, occupant: int) -> bool:
+        """Check if the occupant may be displaced onto the mover's tile."""
+        # NPCs never push players around
+        if self.entity_manager.has_component(
+            occupant, Player
+        ) and not self.entity_manager.has_component(mover, Player):
+            return False
+        # Stationary entities (merchants, obstacles) stay put
+        monster = self.entity_manager.get_component(occupant, Monster)
+        if monster and monster.ai_type == "static":
+            return False
+        return not self.entity_manager.has_component(occupant, BlocksTile)
+
+    def check_move(self, mover: int, x: int, y: int) -> Tuple[str, Optional[int]]:
+        """
+        Decide how a mover may enter a tile.
+
+        Returns:
+            ("free", None) if the tile can be entered, ("swap", occupant) if the
+            mover trades places with the occupant, or ("block", occupant). A
+            hostile kept out of a protected zone is blocked by nobody.
+        """
+        if self.safe_zone and self.is_hostile(mover):
+            pos = self.entity_manager.get_component(mover, Position)
+            if pos and pos.z == 0 and self.safe_zone(x, y):
+                return "block", None
+        for occupant in list(self.spatial_index.get_entities_at(x, y)):
+            if occupant == mover or not self.is_blocking(occupant):
+                continue
+            if not self.same_level(mover, occupant):
+                continue
+
+            rule = self.rules[self.relation(mover, occupant)]
+            if rule == "swap" and not self.can_swap(mover, occupant):
+                rule = "block"
+            if rule != "pass":
+                return rule, occupant
+
+        return "free", None
+
+    def swap(self, mover: int, occupant: int):
+        """Exchange the positions of two entities."""
+        mover_pos = self.entity_manager.get_component(mover, Position)
+        occupant_pos = self.entity_manager.get_component(occupant, Position)
+        if not mover_pos or not occupant_pos:
+            return
+
+        mover_pos.x, occupant_pos.x = occupant_pos.x, mover_pos.x
+        mover_pos.y, occupant_pos.y = occupant_pos.y, mover_pos.y
+        self.entity_manager.notify_component_change(mover, Position)
+        self.entity_manager.notify_component_change(occupant, Position)
+
+    def blocker_for(self, mover: int) -> Callable[[int, int], bool]:
+        """Return a pathfinding callback marking tiles the mover cannot enter."""
+        return lambda x, y: self.check_move(mover, x, y)[0] == "block"
@@ -0,0 +1,115 @@
+"""
+Daily schedules for town NPCs.
+Routines in src/data/static/schedules.json say where an NPC should be at each
+hour and whether its shop or dialogue is open. NPCs walk between their places
+along paths that are computed once and reused every day.
+"""
+
+from typing import Any, Dict, List, Optional, Tuple
+
+from core.ecs import EntityManager
+from data.loader import DATA_LOADER
+from entities.components import Position, Schedule
+from systems.clock import GameClock, hour_in_window
+from world.pathfinding import find_path
+
+STEP_DELAY = 0.5  # Seconds between steps for walking NPCs
+
+
+class ScheduleSystem:
+    """Moves scheduled NPCs between their places and tracks their availability."""
+
+    def __init__(
+        self,
+        entity_manager: EntityManager,
+        occupancy=None,
+        routines: Optional[Dict[str, List[Dict[str, Any]]]] = None,
+    ):
+        self.entity_manager = entity_manager
+        self.occupancy = occupancy
+        self.routines = routines if routines is not None else DATA_LOADER.load_json("schedules")
+        self.step_timer = 0.0
+
+    def assign(self, eid: int, routine: str, home: Tuple[int, int]):
+        """Give an NPC a routine; its current position becomes its workplace."""
+        pos = self.entity_manager.get_component(eid, Position)
+        if not pos or routine not in self.routines:
+            return
+        self.entity_manager.add_component(
+            eid, Schedule(routine=routine, anchors={"work": (pos.x, pos.y), "home": home})
+        )
+
+    def entry_for(self, routine: str, hour: float) -> Optional[Dict[str, Any]]:
+        """The routine entry covering an hour of the day."""
+        for entry in self.routines.get(routine, []):
+            if hour_in_window(hour, entry["from"], entry["to"]):
+                return entry
+        return None
+
+    def next_open_hour(self, routine: str, hour: float) -> Optional[int]:
+        """Hour at which the NPC next becomes available."""
+        starts = [
+            entry["from"] for entry in self.routines.get(routine, []) if entry.get("available", True)
+        ]
+        if not starts:
+            return None
+        later = [start for start in starts if start > hour]
+        return min(later) if later else min(starts)
+
+    def update(self, dt: float, clock: GameClock, game_map):
+        """Follow each NPC's routine for the current hour."""
+        self.step_timer += dt
+        step = self.step_timer >= STEP_DELAY
+        if step:
+            self.step_timer = 0.0
+
+        for eid, schedule in list(self.entity_manager.components_by_type.get(Schedule, {}).items()):
+            entry = self.entry_for(schedule.routine, clock.hour)
+            if not entry:
+                continue
+
+            schedule.available = entry.get("available", True)
+            if entry["at"] != schedule.target:
+                self._set_target(eid, schedule, entry["at"], game_map)
+            if step and schedule.path:
+                self._step(eid, schedule)
+
+    def _set_target(self, eid: int, schedule: Schedule, place: str, game_map):
+        pos = self.entity_manager.get_component(eid, Position)
+        goal = schedule.anchors.get(place)
+        schedule.target = place
+        schedule.path = []
+        if not pos or not goal or (pos.x, pos.y) == goal:
+            return
+
+        key = ((pos.x, pos.y), goal)
+        if key not in schedule.paths:
+            # Paths ignore other entities; blockers are waited out while walking
+            schedule.paths[key] = (
+                find_path(game_map, key[0], goal, max_nodes=5000, move_cost=game_map.move_cost)
+                or []
+            )
+        schedule.path = list(schedule.paths[key])
+
+    def _step(self, eid: int, schedule: Schedule):
+        pos = self.entity_manager.get_component(eid, Position)
+        if not pos:
+            return
+
+        nx, ny = schedule.path[0]
+        if self.occupancy and self.occupancy.check_move(eid, nx, ny)[0] != "free":
+            return
+
+        pos.x, pos.y = nx, ny
+        self.entity_manager.notify_component_change(eid, Position)
+        schedule.path.pop(0)
+
+
+def nearest_walkable(game_map, x: int, y: int, radius: int = 10) -> Tuple[int, int]:
+    """Closest walkable tile to a position, searching outwards ring by ring."""
+    for r in range(radius + 1):
+        for dy in range(-r, r + 1):
+            for dx in range(-r, r + 1):
+                if max(abs(dx), abs(dy)) == r and game_map.is_walkable(x + dx, y + dy):
+                    return x + dx, y + dy
+    return x, y
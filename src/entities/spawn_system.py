@@ -29,6 +29,15 @@ BIOME_WEIGHTS = {
     "town": {"guard": 40, "merchant": 20, "citizen": 30, "dog": 10},
 }
 
+# Creatures of the caves by depth below the surface; deeper levels use the last
+DEPTH_WEIGHTS = [
+    {"bat": 40, "spider": 30, "goblin": 20, "skeleton": 10},
+    {"skeleton": 35, "orc": 30, "spider": 20, "bear": 15},
+    {"orc": 35, "fire_imp": 30, "skeleton": 20, "lava_golem": 15},
+]
+DEPTH_LEVELS = 2  # Monster levels added for every level below the surface
+DEPTH_ELITE_CHANCE = 0.02  # Extra elite chance for every level below the surface
+
 # Monsters that typically spawn in groups
 GROUP_SPAWN_CHANCE = {
     "goblin": 0.4,
@@ -47,10 +56,12 @@ class SpawnSystem:
         entity_manager: EntityManager,
         entity_factory: EntityFactory,
         spatial_index=None,
+        regions=None,
     ):
         self.entity_manager = entity_manager
         self.entity_factory = entity_factory
         self.spatial_index = spatial_index
+        self.regions = regions  # Optional RegionMap whose spawn tables override biomes
         self.persistent_world = get_persistent_world()
         self.global_spawn_rates = {
             "goblin": 0.3,
@@ -107,9 +118,15 @@ class SpawnSystem:
         radius: int = 10,
         num_monsters: int = 3,
         player_level: int = 1,
+        depth: int = 0,
     ):
-        """Spawn monsters around the player within a certain radius."""
+        """Spawn monsters around the player within a certain radius.
+
+        Below the surface (depth > 0) the cave creatures of that depth come
+        instead, tougher and more often elite the deeper they are.
+        """
         spawned = []
+        monster_level = player_level + depth * DEPTH_LEVELS
 
         # Try up to num_monsters * 2 times to find valid spots
         for _ in range(num_monsters * 2):
@@ -128,9 +145,10 @@ class SpawnSystem:
                 0 <= x < game_map.width
                 and 0 <= y < game_map.height
                 and game_map.is_walkable(x, y)
+                and not self._in_safe_zone(x, y, depth)
             ):
                 if not self.spatial_index or not self.spatial_index.is_occupied(x, y):
-                    monster_type = self._choose_monster_type(x, y)
+                    monster_type = self._choose_monster_type(x, y, depth)
                     
                     # Handle group spawning
                     group_size = 1
@@ -149,21 +167,41 @@ class SpawnSystem:
                             
                         if (0 <= gx < game_map.width and 0 <= gy < game_map.height and 
                             game_map.is_walkable(gx, gy) and 
+                            not self._in_safe_zone(gx, gy, depth) and
                             (not self.spatial_index or not self.spatial_index.is_occupied(gx, gy))):
                             
                             # Elite chance (3%)
-                            is_elite = random.random() < 0.03
+                            is_elite = random.random() < 0.03 + depth * DEPTH_ELITE_CHANCE
                             
                             monster_id = self.entity_factory.create_monster(
-                                gx, gy, monster_type, is_elite=is_elite, player_level=player_level
+                                gx, gy, monster_type, is_elite=is_elite,
+                                player_level=monster_level, z=depth,
                             )
                             spawned.append(monster_id)
 
         return spawned
 
-    def _choose_monster_type(self, x: int = None, y: int = None) -> str:
+    def _in_safe_zone(self, x: int, y: int, depth: int) -> bool:
+        """Check if a tile is in a protected zone, where nothing spawns."""
+        return depth == 0 and self.regions is not None and self.regions.is_safe(x, y)
+
+    def _choose_monster_type(self, x: int = None, y: int = None, depth: int = 0) -> str:
         """Choose a monster type based on weighted spawn rates and biome."""
+        if depth > 0:
+            weights_dict = DEPTH_WEIGHTS[min(depth, len(DEPTH_WEIGHTS)) - 1]
+            choices = list(weights_dict.keys())
+            weights = list(weights_dict.values())
+            return random.choices(choices, weights=weights)[0]
+
         if x is not None and y is not None:
+            # Named regions with their own spawn table take priority
+            if self.regions:
+                spawns = self.regions.region_at(x, y).spawns
+                if spawns:
+                    choices = list(spawns.keys())
+                    weights = list(spawns.values())
+                    return random.choices(choices, weights=weights)[0]
+
             biome = self.persistent_world.get_biome(x, y)
             
             # Use BIOME_WEIGHTS if available
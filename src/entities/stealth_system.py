@@ -0,0 +1,136 @@
+"""
+Stealth and detection for the roguelike game.
+A hiding player is invisible to monsters until one of them passes a
+perception check. Checks run a few times a second and get easier in good
+light, against a moving target and at short range.
+"""
+
+import random
+from typing import Callable, Optional
+
+from core.ecs import EntityManager
+from entities.components import Monster, Position, Skills, Stealth
+from entities.occupancy import PEACEFUL_AI_TYPES
+
+DETECTION_RANGE = 12  # Tiles beyond which hidden players cannot be spotted
+CHECK_INTERVAL = 0.5  # Seconds between perception checks
+AWARENESS = 4.0  # Seconds a monster keeps track of a player it has spotted
+MOVE_WINDOW = 1.0  # Seconds after moving that a player counts as moving
+STEALTH_XP_PER_CHECK = 1  # Stealth XP for every check passed unseen
+
+
+def detection_chance(
+    perception: int, stealth: int, distance: int, light: float, moving: bool
+) -> float:
+    """Chance that a monster spots a hidden player in one check."""
+    if distance <= 1:
+        return 1.0
+    if distance > DETECTION_RANGE:
+        return 0.0
+
+    chance = 0.5 + 0.05 * (perception - stealth)
+    chance *= 0.3 + 0.7 * max(0.0, min(1.0, light))
+    if moving:
+        chance *= 1.5
+    chance *= 1.0 - distance / DETECTION_RANGE
+    return max(0.0, min(0.95, chance))
+
+
+class StealthSystem:
+    """Runs perception checks for monsters near hidden players."""
+
+    def __init__(self, entity_manager: EntityManager, rng: Optional[random.Random] = None):
+        self.entity_manager = entity_manager
+        self.rng = rng or random.Random()
+        self._check_timer = 0.0
+
+    def toggle(self, eid: int) -> bool:
+        """Start or stop hiding; returns whether the entity is now hidden."""
+        stealth = self.entity_manager.get_component(eid, Stealth)
+        if not stealth:
+            return False
+        stealth.active = not stealth.active
+        stealth.spotted_by.clear()
+        return stealth.active
+
+    def reveal(self, eid: int):
+        """Break stealth (attacking, casting, taking damage)."""
+        stealth = self.entity_manager.get_component(eid, Stealth)
+        if stealth and stealth.active:
+            stealth.active = False
+            stealth.spotted_by.clear()
+
+    def moved(self, eid: int):
+        stealth = self.entity_manager.get_component(eid, Stealth)
+        if stealth:
+            stealth.moved = 0.0
+
+    def perceives(self, monster_id: int, player_id: Optional[int]) -> bool:
+        """Whether a monster can currently see the player."""
+        if player_id is None:
+            return True
+        stealth = self.entity_manager.get_component(player_id, Stealth)
+        if not stealth or not stealth.active:
+            return True
+        return monster_id in stealth.spotted_by
+
+    def update(
+        self,
+        dt: float,
+        player_id: Optional[int],
+        light_at: Callable[[int, int], float],
+    ) -> bool:
+        """Age awareness and, every CHECK_INTERVAL, let nearby monsters try to spot the player.
+
+        Returns True when the player's stealth skill went up.
+        """
+        stealth = self.entity_manager.get_component(player_id, Stealth)
+        if not stealth:
+            return False
+        stealth.moved += dt
+        if not stealth.active:
+            return False
+
+        for monster_id in list(stealth.spotted_by):
+            stealth.spotted_by[monster_id] -= dt
+            if stealth.spotted_by[monster_id] <= 0:
+                del stealth.spotted_by[monster_id]
+
+        self._check_timer += dt
+        if self._check_timer < CHECK_INTERVAL:
+            return False
+        self._check_timer = 0.0
+
+        pos = self.entity_manager.get_component(player_id, Position)
+        skills = self.entity_manager.get_component(player_id, Skills)
+        if not pos:
+            return False
+        skill = skills.stealth if skills else 1
+        light = light_at(pos.x, pos.y)
+        moving = stealth.moved < MOVE_WINDOW
+
+        unseen_checks = 0
+        positions = self.entity_manager.components_by_type.get(Position, {})
+        for monster_id, monster in self.entity_manager.components_by_type.get(Monster, {}).items():
+            # Bystanders never hunt the player, so their checks do not matter
+            if monster_id in stealth.spotted_by or monster.ai_type in PEACEFUL_AI_TYPES:
+                continue
+            monster_pos = positions.get(monster_id)
+            if not monster_pos:
+                continue
+            distance = max(abs(monster_pos.x - pos.x), abs(monster_pos.y - pos.y))
+            if distance > DETECTION_RANGE:
+                continue
+            chance = detection_chance(monster.perception, skill, distance, light, moving)
+            if self.rng.random() < chance:
+                stealth.spotted_by[monster_id] = AWARENESS
+            else:
+                unseen_checks += 1
+
+        if skills and unseen_checks:
+            skills.stealth_xp += unseen_checks * STEALTH_XP_PER_CHECK
+            if skills.stealth_xp >= skills.xp_for_next_level(skills.stealth):
+                skills.stealth += 1
+                skills.stealth_xp = 0
+                return True
+        return False
@@ -0,0 +1,106 @@
+"""
+Summoned allies for the roguelike game.
+Spells and charms can call up a creature from monsters.json (a bone servant,
+a bound flame) to fight for the player. A summon is an ordinary monster
+that also belongs to its owner's party, so it swaps places with them, never
+counts as hostile and earns them the kills it makes; its "ally" behavior
+tree follows the owner and goes for whatever threatens them.
+
+Each summon lasts its entry's summon_seconds (or [summons] duration) and
+unravels early if its owner is gone or has left its level. A player may
+keep max_per_player at once; calling up another replaces the oldest.
+"""
+
+from typing import Callable, List, Mapping, Optional
+
+from core.ecs import EntityManager
+from data.loader import DATA_LOADER
+from entities.components import Monster, PartyMember, Position, Summoned
+
+MAX_PER_PLAYER = 2
+DURATION = 60.0  # Seconds a summon lasts unless its entry says otherwise
+
+# Why a summon was dismissed, for on_dismissed
+EXPIRED, REPLACED, LEFT_BEHIND = "expired", "replaced", "left_behind"
+DISMISSED = "dismissed"  # Sent away by its owner
+
+NEIGHBOURS = [(1, 0), (-1, 0), (0, 1), (0, -1), (1, 1), (1, -1), (-1, 1), (-1, -1)]
+
+
+class SummonError(ValueError):
+    """A summon that cannot be called up: unknown creature or nowhere to stand."""
+
+
+class SummonSystem:
+    """Calls up allies next to their owner and sends them away when their time is up."""
+
+    def __init__(
+        self,
+        entity_manager: EntityManager,
+        factory,
+        settings: Optional[Mapping] = None,
+    ):
+        settings = settings or {}
+        self.entity_manager = entity_manager
+        self.factory = factory
+        self.max_per_player = int(settings.get("max_per_player", MAX_PER_PLAYER))
+        self.duration = float(settings.get("duration", DURATION))
+        # Called with (summon, reason) just before a summon is destroyed
+        self.on_dismissed: Optional[Callable[[int, str], None]] = None
+
+    def summons_of(self, owner: int) -> List[int]:
+        """The owner's summons, the one with least time left first."""
+        summons = self.entity_manager.components_by_type.get(Summoned, {})
+        mine = [eid for eid, summon in summons.items() if summon.owner == owner]
+        return sorted(mine, key=lambda eid: summons[eid].remaining)
+
+    def summon(self, owner: int, kind: str, is_free: Callable[[int, int], bool]) -> int:
+        """Call up a creature of kind beside its owner; returns the new entity.
+
+        is_free says whether a tile can take it. Raises SummonError if kind is
+        not a creature or no tile next to the owner is free.
+        """
+        data = DATA_LOADER.get_monster_data(kind)
+        if not data:
+            raise SummonError(f"There is no such creature as {kind}.")
+        pos = self.entity_manager.get_component(owner, Position)
+        if pos is None:
+            raise SummonError("There is nowhere for it to appear.")
+        spot = next(
+            ((pos.x + dx, pos.y + dy) for dx, dy in NEIGHBOURS if is_free(pos.x + dx, pos.y + dy)),
+            None,
+        )
+        if spot is None:
+            raise SummonError("There is no room beside you for it to appear.")
+
+        current = self.summons_of(owner)
+        for eid in current[: max(0, len(current) - self.max_per_player + 1)]:
+            self.dismiss(eid, REPLACED)
+
+        eid = self.factory.create_monster(spot[0], spot[1], kind, z=pos.z)
+        seconds = float(data.get("summon_seconds", self.duration))
+        self.entity_manager.add_component(eid, PartyMember(leader=owner))
+        self.entity_manager.add_component(eid, Summoned(owner=owner, remaining=seconds))
+        return eid
+
+    def update(self, dt: float):
+        """Count down every summon, dismissing those whose time is up or whose
+        owner is no longer with them."""
+        summons = self.entity_manager.components_by_type.get(Summoned, {})
+        for eid, summon in list(summons.items()):
+            summon.remaining -= dt
+            owner_pos = self.entity_manager.get_component(summon.owner, Position)
+            pos = self.entity_manager.get_component(eid, Position)
+            if summon.remaining <= 0:
+                self.dismiss(eid, EXPIRED)
+            elif owner_pos is None or pos is None or owner_pos.z != pos.z:
+                self.dismiss(eid, LEFT_BEHIND)
+
+    def dismiss(self, eid: int, reason: str = EXPIRED):
+        if self.on_dismissed:
+            self.on_dismissed(eid, reason)
+        self.entity_manager.destroy_entity(eid)
+
+    def name_of(self, eid: int) -> str:
+        monster = self.entity_manager.get_component(eid, Monster)
+        return monster.name if monster else "summon"
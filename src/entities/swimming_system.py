@@ -0,0 +1,90 @@
+"""
+Swimming and drowning for the roguelike game.
+Swimmers wade through water slowly. Water below the surface level (or in
+dark caves) is underwater: swimmers there hold their breath, and once it
+runs out they take damage every second until they surface.
+"""
+
+from typing import Optional
+
+from core.ecs import EntityManager
+from entities.components import Breath, Health, Position, Skills
+from world.map import GameMap, Tile
+
+BASE_BREATH = 10.0  # Seconds of air at swimming level 1
+BREATH_PER_LEVEL = 5.0  # Extra seconds of air per swimming level
+BREATH_RECOVERY = 5.0  # Seconds of air regained per second at the surface
+DROWN_INTERVAL = 1.0  # Seconds between drowning damage ticks
+DROWN_DAMAGE = 0.1  # Fraction of max HP lost per drowning tick
+SWIM_XP_PER_STEP = 2  # Swimming XP for every tile swum
+
+
+def is_underwater(game_map: GameMap, x: int, y: int, z: int) -> bool:
+    """Whether a position is submerged water rather than the open surface."""
+    tile_def = game_map.get_tile(x, y)
+    if tile_def is None or not tile_def.swim_skill:
+        return False
+    return z != 0 or game_map.is_dark
+
+
+def max_breath(swimming: int) -> float:
+    """Seconds a swimmer can hold their breath."""
+    return BASE_BREATH + BREATH_PER_LEVEL * max(0, swimming - 1)
+
+
+def struggle_chance(tile_def: Tile, swimming: int) -> float:
+    """Chance a step out of a tile fails; better swimmers struggle less in water."""
+    if tile_def.swim_skill and swimming > 1:
+        return tile_def.struggle_chance / swimming
+    return tile_def.struggle_chance
+
+
+class SwimmingSystem:
+    """Tracks breath underwater and trains swimming."""
+
+    def __init__(self, entity_manager: EntityManager):
+        self.entity_manager = entity_manager
+
+    def swam(self, eid: int) -> bool:
+        """Award swimming XP for a tile swum; returns True on a level-up."""
+        skills = self.entity_manager.get_component(eid, Skills)
+        if not skills or skills.swimming <= 0:
+            return False
+        skills.swimming_xp += SWIM_XP_PER_STEP
+        if skills.swimming_xp < skills.xp_for_next_level(skills.swimming):
+            return False
+        skills.swimming += 1
+        skills.swimming_xp = 0
+        breath = self.entity_manager.get_component(eid, Breath)
+        if breath:
+            breath.maximum = max_breath(skills.swimming)
+        return True
+
+    def update(self, dt: float, eid: Optional[int], game_map: GameMap) -> int:
+        """Spend or recover breath; returns drowning damage dealt this tick."""
+        breath = self.entity_manager.get_component(eid, Breath)
+        pos = self.entity_manager.get_component(eid, Position)
+        if not breath or not pos or game_map is None:
+            return 0
+
+        breath.underwater = is_underwater(game_map, pos.x, pos.y, pos.z)
+        if not breath.underwater:
+            breath.current = min(breath.maximum, breath.current + BREATH_RECOVERY * dt)
+            breath.drown_timer = 0.0
+            return 0
+
+        if breath.current > 0:
+            breath.current = max(0.0, breath.current - dt)
+            return 0
+
+        breath.drown_timer += dt
+        if breath.drown_timer < DROWN_INTERVAL:
+            return 0
+        breath.drown_timer = 0.0
+
+        health = self.entity_manager.get_component(eid, Health)
+        if not health:
+            return 0
+        damage = max(1, int(health.maximum * DROWN_DAMAGE))
+        health.current -= damage
+        return damage
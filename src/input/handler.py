@@ -19,6 +19,9 @@ class InputEvent:
     action_type: str
     dx: int = 0
     dy: int = 0
+    # Screen cell (1-based column/row) for mouse and tap events
+    x: int = 0
+    y: int = 0
 
 
 class InputHandler:
@@ -89,6 +92,16 @@ class InputHandler:
                 "5": "wait",  # Numpad 5
                 ".": "wait",
                 "?": "help",  # Help Menu
+                "T": "travel",  # Waypoint travel
+                "Z": "zone_info",  # Current region details
+                "M": "world_map",  # Explored world overview
+                "L": "landmarks",  # Nearest discovered points of interest
+                "R": "reputation",  # Standing with each faction
+                "H": "stealth",  # Hide / stop hiding
+                "G": "memorial",  # Fallen hardcore characters
+                "B": "recall",  # Teleport to your home point
+                "F": "fish",  # Cast a line into water beside you
+                "r": "reel",  # Reel it in when something bites
                 "1": "cast_1",
                 "2": "cast_2",
                 "3": "cast_3",
@@ -102,8 +115,9 @@ class InputHandler:
         try:
             self.original_settings = termios.tcgetattr(sys.stdin)
             tty.setcbreak(sys.stdin)
-            # Enter alternate screen, hide cursor, disable wrap
-            sys.stdout.write("\033[?1049h\033[?25l\033[?7l")
+            # Enter alternate screen, hide cursor, disable wrap,
+            # enable SGR mouse reporting for click-to-move
+            sys.stdout.write("\033[?1049h\033[?25l\033[?7l\033[?1000h\033[?1006h")
             sys.stdout.flush()
         except (termios.error, io.UnsupportedOperation):
             # Handle cases where stdin is not a TTY (e.g., when running tests)
@@ -113,14 +127,69 @@ class InputHandler:
         """Restore terminal to original settings and exit alternate screen."""
         if self.original_settings:
             try:
-                # Show cursor, exit alternate screen, enable wrap
-                sys.stdout.write("\033[?25h\033[?1049l\033[?7h")
+                # Disable mouse reporting, show cursor, exit alternate screen, enable wrap
+                sys.stdout.write("\033[?1006l\033[?1000l\033[?25h\033[?1049l\033[?7h")
                 sys.stdout.flush()
                 termios.tcsetattr(sys.stdin, termios.TCSADRAIN, self.original_settings)
             except termios.error:
                 # Handle cases where stdin is not a TTY
                 pass
 
+    def _read_escape_sequence(self) -> Optional[InputEvent]:
+        """
+        Read the rest of an escape sequence after ESC.
+        Arrow keys map to movement, a left click or tap maps to move_to,
+        and a lone or unrecognized ESC maps to quit.
+        """
+        # We use a small timeout to gather the sequence parts
+        if select.select([sys.stdin], [], [], 0.05) != ([sys.stdin], [], []):
+            # Single Esc key - return quit
+            return InputEvent("quit")
+
+        next1 = sys.stdin.read(1)
+        if next1 == "[" and select.select([sys.stdin], [], [], 0.05) == (
+            [sys.stdin],
+            [],
+            [],
+        ):
+            next2 = sys.stdin.read(1)
+            if next2 == "A":
+                return InputEvent("move", 0, -1)  # Up
+            elif next2 == "B":
+                return InputEvent("move", 0, 1)  # Down
+            elif next2 == "C":
+                return InputEvent("move", 1, 0)  # Right
+            elif next2 == "D":
+                return InputEvent("move", -1, 0)  # Left
+            elif next2 == "<":
+                return self._read_mouse_event()
+
+        # If it's an unrecognized escape sequence
+        return InputEvent("quit")
+
+    def _read_mouse_event(self) -> Optional[InputEvent]:
+        """Parse an SGR mouse report (ESC [ < button ; col ; row M/m)."""
+        report = ""
+        while len(report) < 16:
+            if select.select([sys.stdin], [], [], 0.05) != ([sys.stdin], [], []):
+                return None
+            ch = sys.stdin.read(1)
+            if ch in ("M", "m"):
+                break
+            report += ch
+        else:
+            return None
+
+        try:
+            button, col, row = (int(part) for part in report.split(";"))
+        except ValueError:
+            return None
+
+        # Only a left-button press starts a walk; releases, drags and wheel are ignored
+        if ch == "M" and button == 0:
+            return InputEvent("move_to", x=col, y=row)
+        return None
+
     def check_for_input(self) -> Optional[InputEvent]:
         """
         Check for input without blocking.
@@ -129,32 +198,9 @@ class InputHandler:
         if select.select([sys.stdin], [], [], 0) == ([sys.stdin], [], []):
             key = sys.stdin.read(1)
 
-            # Handle Escape Sequences (Arrow Keys)
+            # Handle Escape Sequences (Arrow Keys, Mouse)
             if key == "\x1b":
-                # Check for additional characters in the escape sequence
-                # We use a small timeout to gather the sequence parts
-                if select.select([sys.stdin], [], [], 0.05) == ([sys.stdin], [], []):
-                    next1 = sys.stdin.read(1)
-                    if next1 == "[":
-                        if select.select([sys.stdin], [], [], 0.05) == (
-                            [sys.stdin],
-                            [],
-                            [],
-                        ):
-                            next2 = sys.stdin.read(1)
-                            if next2 == "A":
-                                return InputEvent("move", 0, -1)  # Up
-                            elif next2 == "B":
-                                return InputEvent("move", 0, 1)  # Down
-                            elif next2 == "C":
-                                return InputEvent("move", 1, 0)  # Right
-                            elif next2 == "D":
-                                return InputEvent("move", -1, 0)  # Left
-                    # If it's an unrecognized escape sequence or just single Esc
-                    return InputEvent("quit")
-                else:
-                    # Single Esc key - return quit
-                    return InputEvent("quit")
+                return self._read_escape_sequence()
 
             # Handle movement keys from config/default
             if key in self.movement_keys:
@@ -182,6 +228,26 @@ class InputHandler:
                     return InputEvent("wait")
                 elif action == "help":
                     return InputEvent("help")
+                elif action == "travel":
+                    return InputEvent("travel")
+                elif action == "zone_info":
+                    return InputEvent("zone_info")
+                elif action == "world_map":
+                    return InputEvent("world_map")
+                elif action == "landmarks":
+                    return InputEvent("landmarks")
+                elif action == "reputation":
+                    return InputEvent("reputation")
+                elif action == "stealth":
+                    return InputEvent("stealth")
+                elif action == "memorial":
+                    return InputEvent("memorial")
+                elif action == "recall":
+                    return InputEvent("recall")
+                elif action == "fish":
+                    return InputEvent("fish")
+                elif action == "reel":
+                    return InputEvent("reel")
                 elif action.startswith("cast_"):
                     return InputEvent(action)
 
@@ -197,27 +263,9 @@ class InputHandler:
         # Read a single character
         key = sys.stdin.read(1)
 
-        # Handle Escape Sequences (Arrow Keys)
+        # Handle Escape Sequences (Arrow Keys, Mouse)
         if key == "\x1b":
-            # Check for sequence
-            if select.select([sys.stdin], [], [], 0.05) == ([sys.stdin], [], []):
-                next1 = sys.stdin.read(1)
-                if next1 == "[":
-                    if select.select([sys.stdin], [], [], 0.05) == (
-                        [sys.stdin],
-                        [],
-                        [],
-                    ):
-                        next2 = sys.stdin.read(1)
-                        if next2 == "A":
-                            return InputEvent("move", 0, -1)  # Up
-                        elif next2 == "B":
-                            return InputEvent("move", 0, 1)  # Down
-                        elif next2 == "C":
-                            return InputEvent("move", 1, 0)  # Right
-                        elif next2 == "D":
-                            return InputEvent("move", -1, 0)  # Left
-            return InputEvent("quit")
+            return self._read_escape_sequence()
 
         # Handle movement keys
         if key in self.movement_keys:
@@ -245,6 +293,26 @@ class InputHandler:
                 return InputEvent("wait")
             elif action == "help":
                 return InputEvent("help")
+            elif action == "travel":
+                return InputEvent("travel")
+            elif action == "zone_info":
+                return InputEvent("zone_info")
+            elif action == "world_map":
+                return InputEvent("world_map")
+            elif action == "landmarks":
+                return InputEvent("landmarks")
+            elif action == "reputation":
+                return InputEvent("reputation")
+            elif action == "stealth":
+                return InputEvent("stealth")
+            elif action == "memorial":
+                return InputEvent("memorial")
+            elif action == "recall":
+                return InputEvent("recall")
+            elif action == "fish":
+                return InputEvent("fish")
+            elif action == "reel":
+                return InputEvent("reel")
             elif action.startswith("cast_"):
                 return InputEvent(action)
 
@@ -0,0 +1,149 @@
+"""
+Text command parsing for MUD-style play.
+Turns raw lines such as "go north", "n", "attack goblin", "kill 2.goblin" or
+"say hello" into a verb and arguments, and resolves target names against
+the creatures around the player. Execution lives in the engine so text
+commands share the same actions as keyboard play.
+"""
+
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Sequence, Tuple
+
+DIRECTIONS: Dict[str, Tuple[int, int]] = {
+    "n": (0, -1),
+    "north": (0, -1),
+    "s": (0, 1),
+    "south": (0, 1),
+    "e": (1, 0),
+    "east": (1, 0),
+    "w": (-1, 0),
+    "west": (-1, 0),
+    "ne": (1, -1),
+    "northeast": (1, -1),
+    "nw": (-1, -1),
+    "northwest": (-1, -1),
+    "se": (1, 1),
+    "southeast": (1, 1),
+    "sw": (-1, 1),
+    "southwest": (-1, 1),
+}
+
+# Alias -> verb
+VERBS: Dict[str, str] = {
+    "go": "go",
+    "walk": "go",
+    "move": "go",
+    "attack": "attack",
+    "kill": "attack",
+    "k": "attack",
+    "hit": "attack",
+    "say": "say",
+    "chat": "chat",
+    "c": "chat",
+    "join": "join",
+    "leave": "leave",
+    "channels": "channels",
+    "talk": "talk",
+    "greet": "talk",
+    "get": "pickup",
+    "take": "pickup",
+    "pickup": "pickup",
+    "look": "look",
+    "describe": "describe",
+    "l": "look",
+    "cast": "cast",
+    "brew": "brew",
+    "mix": "brew",
+    "hide": "stealth",
+    "sneak": "stealth",
+    "rep": "reputation",
+    "gamble": "gamble",
+    "bet": "gamble",
+    "market": "prices",
+    "who": "who",
+    "netstat": "netstat",
+    "/netstat": "netstat",
+    "settings": "settings",
+    "options": "settings",
+    "set": "set",
+    "dismiss": "dismiss",
+    "motd": "motd",
+    "help": "help",
+    "?": "help",
+    "commands": "help",
+}
+
+# Words dropped from arguments ("attack the goblin", "talk to tutor")
+FILLER = {"the", "a", "an", "at", "to", "with"}
+
+
+@dataclass
+class TextCommand:
+    """A parsed line of text input."""
+
+    verb: str
+    args: List[str] = field(default_factory=list)
+    text: str = ""  # Everything after the verb, as typed (for say)
+
+
+def parse(line: str) -> Optional[TextCommand]:
+    """Parse a line of text into a command, or None for a blank line."""
+    line = line.strip()
+    if not line:
+        return None
+
+    # 'hello  ->  say hello
+    if line.startswith("'"):
+        return TextCommand("say", [], line[1:].strip())
+
+    words = line.split()
+    head = words[0].lower()
+    if head in DIRECTIONS:
+        return TextCommand("go", [head])
+
+    rest = line[len(words[0]) :].strip()
+    args = [word.lower() for word in words[1:] if word.lower() not in FILLER]
+    return TextCommand(VERBS.get(head, head), args, rest)
+
+
+def resolve_target(
+    query: Sequence[str], candidates: Sequence[Tuple[int, str, int]]
+) -> Optional[int]:
+    """Pick the entity a target phrase refers to.
+
+    Candidates are (entity, name, distance). Names match on their start or the
+    start of any word ("goblin" finds "Elite Goblin"); the nearest match wins
+    unless a MUD-style index picks another ("2.goblin" is the second nearest).
+    """
+    if not query:
+        return None
+    phrase = " ".join(query).lower()
+    index = 1
+    number, dot, name = phrase.partition(".")
+    if dot and number.isdecimal():
+        index, phrase = int(number), name
+    if not phrase or index < 1:
+        return None
+
+    matches = [
+        (distance, eid)
+        for eid, name, distance in candidates
+        if name.lower().startswith(phrase)
+        or any(word.startswith(phrase) for word in name.lower().split())
+    ]
+    matches.sort()
+    if index > len(matches):
+        return None
+    return matches[index - 1][1]
+
+
+def direction_name(dx: int, dy: int) -> str:
+    """Compass direction of an offset ("here" for none)."""
+    vertical = "north" if dy < 0 else "south" if dy > 0 else ""
+    horizontal = "west" if dx < 0 else "east" if dx > 0 else ""
+    # Mostly-straight offsets read as a single direction
+    if abs(dx) > 2 * abs(dy):
+        vertical = ""
+    elif abs(dy) > 2 * abs(dx):
+        horizontal = ""
+    return (vertical + horizontal) or "here"
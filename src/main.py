@@ -4,35 +4,81 @@ Main entry point for the roguelike game.
 
 import sys
 import os
+import time
 
 # Add the directory containing this file (src) to the Python path
 # This allows imports like 'from core.engine import ...' to work
 sys.path.append(os.path.dirname(os.path.abspath(__file__)))
 
+from config import CONFIG
 from core.engine import GameEngine
+from systems.tokens import issue_token
+from world.worlds import WorldError
+
+
+def issue_gateway_token(subject: str) -> str:
+    """Sign a telnet gateway access token for subject, as an admin."""
+    secret = os.environ.get(CONFIG.gateway.get("token_secret_env", ""), "")
+    if not secret:
+        raise ValueError(f"set {CONFIG.gateway.get('token_secret_env')} first")
+    now = int(time.time())
+    claims = {"sub": subject, "iat": now}
+    claims["exp"] = now + int(CONFIG.gateway.get("token_hours", 24) * 3600)
+    if CONFIG.gateway.get("token_issuer"):
+        claims["iss"] = CONFIG.gateway["token_issuer"]
+    if CONFIG.gateway.get("token_audience"):
+        claims["aud"] = CONFIG.gateway["token_audience"]
+    return issue_token(claims, secret)
 
 
 def main():
     """Entry point for the game."""
-    print("Starting the Roguelike Game...")
-
     # Initialize the game engine
     import argparse
 
     parser = argparse.ArgumentParser()
     parser.add_argument("--map", help="Path to map file to load (overrides default)")
     parser.add_argument("--pos", help="Starting position x,y")
+    parser.add_argument("--world", help="Name of the world to play in")
+    parser.add_argument("--record", help="Record this session to a replay log")
+    parser.add_argument("--replay", help="Play back a replay log")
+    parser.add_argument(
+        "--issue-token", metavar="NAME", help="Print a telnet gateway token for NAME"
+    )
     args = parser.parse_args()
 
+    if args.issue_token:
+        try:
+            print(issue_gateway_token(args.issue_token))
+        except ValueError as e:
+            print(f"Could not issue token: {e}")
+            sys.exit(1)
+        return
+
+    print("Starting the Roguelike Game...")
     engine = GameEngine()
     if args.map:
         engine.override_map_path = args.map
+    if args.world:
+        try:
+            engine.requested_world = engine.worlds.get(args.world).name
+        except WorldError as e:
+            print(f"Could not open world: {e}")
+            sys.exit(1)
     if args.pos:
         try:
             x, y = map(int, args.pos.split(","))
             engine.override_start_pos = (x, y)
         except ValueError:
             print("Invalid position format. Use x,y")
+    if args.replay:
+        try:
+            engine.start_playback(args.replay)
+        except (OSError, ValueError) as e:
+            print(f"Could not load replay: {e}")
+            sys.exit(1)
+    elif args.record:
+        engine.start_recording(args.record)
 
     # Start the main game loop
     try:
@@ -0,0 +1,4 @@
+"""
+Plugins shipped with the game. Enable one by adding its module name to
+[plugins] modules in config.toml (see core.plugins for the hooks).
+"""
@@ -0,0 +1,42 @@
+"""
+Chat filter plugin.
+Masks words from [plugins] chat_filter_words in what players say, and adds
+a "filter" command that shows how many lines it has cleaned up.
+"""
+
+import re
+
+from config import CONFIG
+from core.plugins import Plugin
+
+
+class ChatFilter(Plugin):
+    name = "chat_filter"
+
+    def __init__(self):
+        self.filtered = 0
+        self.pattern = None
+
+    def register(self, engine, plugins):
+        words = CONFIG.plugins.get("chat_filter_words", [])
+        if words:
+            alternatives = "|".join(re.escape(word) for word in words)
+            self.pattern = re.compile(rf"\b({alternatives})\b", re.IGNORECASE)
+        plugins.text_command("filter", self.report)
+
+    def on_chat(self, engine, sender, text):
+        if self.pattern is None:
+            return None
+        cleaned = self.pattern.sub(lambda match: "*" * len(match.group()), text)
+        if cleaned == text:
+            return None
+        self.filtered += 1
+        return cleaned
+
+    def report(self, engine, args):
+        engine.log(
+            f"The chat filter has cleaned {self.filtered} lines.", (200, 200, 255)
+        )
+
+
+plugin = ChatFilter()
@@ -0,0 +1,89 @@
+"""
+Alchemy for the roguelike game.
+Players brew potions by combining reagents gathered from the world: herbs
+dropped by monsters and ore mined from cave walls. The brews in brews.json
+are not shown to anyone up front; a player learns one by mixing its
+reagents for the first time, and every combination that came to nothing is
+remembered too, so experimenting narrows down what is left to try. What a
+player has learned is kept in their profile.
+"""
+
+from dataclasses import dataclass
+from typing import Any, Dict, Iterable, List, Mapping, Optional, Set, Tuple
+
+MIN_REAGENTS = 2
+MAX_REAGENTS = 4
+BREW_XP = 10  # Alchemy XP per potion, plus the brew's required skill
+DISCOVERY_XP = 25  # Extra XP for brewing something for the first time
+
+Combination = Tuple[str, ...]
+
+
+class AlchemyError(ValueError):
+    """A mixture that cannot be brewed."""
+
+
+def combination(reagents: Iterable[str]) -> Combination:
+    """A mixture's reagents in a canonical order; the order they go in is irrelevant."""
+    return tuple(sorted(reagents))
+
+
+def match_brew(brews: Mapping[str, Any], reagents: Iterable[str]) -> Optional[str]:
+    """The brew a mixture of reagents makes, if any."""
+    mixture = combination(reagents)
+    for brew_id, brew in brews.items():
+        if combination(brew.get("reagents", [])) == mixture:
+            return brew_id
+    return None
+
+
+@dataclass
+class BrewResult:
+    """What came of one attempt at a brew."""
+
+    potion: Optional[str]  # Item made, or None if the mixture was a dud
+    discovered: bool = False
+    xp: int = 0
+
+
+class Knowledge:
+    """The brews one player has discovered and the duds they have tried."""
+
+    def __init__(self):
+        self.known: Set[str] = set()
+        self.tried: Set[Combination] = set()
+
+    def brew(
+        self, brews: Mapping[str, Any], reagents: List[str], skill: int
+    ) -> BrewResult:
+        """Mix reagents, learning whatever the attempt teaches.
+
+        Raises AlchemyError, before anything is used up, for a mixture of the
+        wrong size or a brew beyond the player's skill.
+        """
+        if not MIN_REAGENTS <= len(reagents) <= MAX_REAGENTS:
+            raise AlchemyError(
+                f"A brew takes {MIN_REAGENTS} to {MAX_REAGENTS} reagents."
+            )
+        brew_id = match_brew(brews, reagents)
+        if brew_id is None:
+            self.tried.add(combination(reagents))
+            return BrewResult(None)
+
+        required = int(brews[brew_id].get("skill", 1))
+        if skill < required:
+            raise AlchemyError(f"This mixture needs alchemy {required} to brew.")
+        discovered = brew_id not in self.known
+        self.known.add(brew_id)
+        xp = BREW_XP + required + (DISCOVERY_XP if discovered else 0)
+        return BrewResult(brew_id, discovered, xp)
+
+    def to_profile(self) -> Dict[str, Any]:
+        return {
+            "known": sorted(self.known),
+            "tried": [list(mixture) for mixture in sorted(self.tried)],
+        }
+
+    def from_profile(self, data: Dict[str, Any]):
+        self.known = set(data.get("known", []))
+        self.tried = {combination(mixture) for mixture in data.get("tried", [])}
@@ -0,0 +1,106 @@
+"""
+Message of the day and scheduled announcements.
+The MOTD is shown to every player when they log in. Announcements (restart
+warnings, event notices) are broadcast once at a set time or repeatedly on
+an interval. Admins change both while the game runs; they are kept in a
+small JSON file so they survive a restart.
+"""
+
+import time
+from typing import Any, Callable, Dict, List, Optional
+
+from systems.profile import load_profile, save_profile
+
+
+class Announcements:
+    """The MOTD and the schedule of announcements still to be broadcast."""
+
+    def __init__(
+        self,
+        path: Optional[str],
+        motd: str = "",
+        clock: Callable[[], float] = time.time,
+    ):
+        self.path = path
+        self.clock = clock
+        self.data = load_profile(path) if path else {}
+        self.data.setdefault("motd", motd)  # An admin's MOTD wins over config
+        self.data.setdefault("scheduled", [])
+        self.data.setdefault("next_id", 1)
+
+    @property
+    def motd(self) -> str:
+        return self.data["motd"]
+
+    def set_motd(self, text: str):
+        self.data["motd"] = text.strip()
+        self._save()
+
+    def schedule(self, text: str, delay: float = 0.0, every: float = 0.0) -> int:
+        """Broadcast text after delay seconds, then every `every` seconds if set.
+
+        Returns the announcement's ID, for cancel.
+        """
+        text = text.strip()
+        if not text:
+            raise ValueError("An announcement needs some text")
+        if every < 0 or delay < 0:
+            raise ValueError("Times must not be negative")
+        announcement_id = self.data["next_id"]
+        self.data["next_id"] += 1
+        self.data["scheduled"].append(
+            {
+                "id": announcement_id,
+                "text": text,
+                "at": self.clock() + delay,
+                "every": every,
+            }
+        )
+        self._save()
+        return announcement_id
+
+    def cancel(self, announcement_id: int) -> bool:
+        scheduled = self.data["scheduled"]
+        kept = [a for a in scheduled if a["id"] != announcement_id]
+        if len(kept) == len(scheduled):
+            return False
+        self.data["scheduled"] = kept
+        self._save()
+        return True
+
+    def pending(self) -> List[Dict[str, Any]]:
+        """Scheduled announcements, soonest first."""
+        return sorted(self.data["scheduled"], key=lambda a: a["at"])
+
+    def due(self) -> List[str]:
+        """Texts to broadcast now; one-off announcements are then dropped.
+
+        A recurring announcement that fell behind (the game was not running)
+        is broadcast once and rescheduled from now, not repeated to catch up.
+        """
+        now = self.clock()
+        scheduled = self.data["scheduled"]
+        if not any(a["at"] <= now for a in scheduled):
+            return []
+        texts, kept = [], []
+        for announcement in self.pending():
+            if announcement["at"] > now:
+                kept.append(announcement)
+                continue
+            texts.append(announcement["text"])
+            if announcement["every"] > 0:
+                announcement["at"] += announcement["every"]
+                if announcement["at"] <= now:
+                    announcement["at"] = now + announcement["every"]
+                kept.append(announcement)
+        self.data["scheduled"] = kept
+        self._save()
+        return texts
+
+    def _save(self):
+        if not self.path:
+            return
+        try:
+            save_profile(self.path, self.data)
+        except OSError as e:
+            print(f"Could not save announcements: {e}")
@@ -0,0 +1,167 @@
+"""
+Anti-cheat anomaly detection.
+The server knows why the player's state changes: a move or teleport it
+made, a journaled transaction, an attack it resolved. Once a tick the
+player's position, gold and belongings are compared against what those
+events explain, and anything left over is an incident: a teleport-like
+jump, gold or items out of nowhere, attacks faster than the cap allows.
+
+Incidents are kept (with the audit log told as well) for a GM to review.
+A player with enough of them is restricted until a GM lifts it.
+"""
+
+import time
+from collections import deque
+from dataclasses import dataclass, field
+from typing import Any, Callable, Deque, Dict, Iterable, List, Optional, Set, Tuple
+
+from systems.profile import load_profile, save_profile
+
+MAX_INCIDENTS = 500  # Oldest are dropped beyond this
+
+
+@dataclass
+class Expected:
+    """What the server's own events say one player should look like."""
+
+    eid: int
+    position: Tuple[int, int]
+    gold: int
+    items: Set[int] = field(default_factory=set)
+    attacks: Deque[float] = field(default_factory=deque)  # Recent attack times
+
+
+class AntiCheat:
+    """Flags player state the server's events do not explain."""
+
+    def __init__(
+        self,
+        path: Optional[str],
+        teleport_tiles: int = 2,
+        max_attacks_per_second: float = 20.0,
+        restrict_after: int = 3,
+        audit=None,
+        clock: Callable[[], float] = time.time,
+    ):
+        self.path = path
+        self.teleport_tiles = teleport_tiles  # Unexplained moves up to this are drift
+        self.max_attacks_per_second = max_attacks_per_second
+        self.restrict_after = restrict_after  # 0 never restricts
+        self.audit = audit
+        self.clock = clock
+        self.expected: Dict[str, Expected] = {}
+        self.data = load_profile(path) if path else {}
+        self.data.setdefault("incidents", [])
+        self.data.setdefault("restricted", [])
+
+    # Events the server caused
+
+    def moved(self, who: str, x: int, y: int):
+        """The server moved or teleported the player here."""
+        if who in self.expected:
+            self.expected[who].position = (x, y)
+
+    def credited(self, who: str, gold: int, items: Iterable[int] = ()):
+        """A committed transaction changed the player's gold and added items."""
+        if who in self.expected:
+            self.expected[who].gold += gold
+            self.expected[who].items.update(items)
+
+    def attacked(self, who: str, now: float) -> Optional[Dict[str, Any]]:
+        """The player attacked at game time now; flags rates beyond the cap."""
+        expected = self.expected.get(who)
+        if expected is None or self.max_attacks_per_second <= 0:
+            return None
+        attacks = expected.attacks
+        attacks.append(now)
+        while attacks and attacks[0] <= now - 1.0:
+            attacks.popleft()
+        if len(attacks) > self.max_attacks_per_second:
+            attacks.clear()  # One incident per burst
+            cap = f"{self.max_attacks_per_second:g}"
+            return self.flag(who, "attack_rate", f"more than {cap} attacks in a second")
+        return None
+
+    # The once-a-tick comparison
+
+    def check(
+        self, who: str, eid: int, position: Tuple[int, int], gold: int, items: Iterable[int]
+    ) -> List[Dict[str, Any]]:
+        """Compare the player's actual state with what was expected; returns new incidents.
+
+        items are everything the player owns, carried or equipped. A new
+        entity (a fresh character) starts a new baseline.
+        """
+        items = set(items)
+        expected = self.expected.get(who)
+        if expected is None or expected.eid != eid:
+            self.expected[who] = Expected(eid, position, gold, items)
+            return []
+
+        incidents = []
+        dx = position[0] - expected.position[0]
+        dy = position[1] - expected.position[1]
+        if max(abs(dx), abs(dy)) > self.teleport_tiles:
+            incidents.append(
+                self.flag(who, "teleport", f"jumped from {expected.position} to {position}")
+            )
+        if gold != expected.gold:
+            incidents.append(
+                self.flag(who, "gold", f"{gold - expected.gold:+d} gold with no transaction")
+            )
+        unexplained = items - expected.items
+        if unexplained:
+            incidents.append(
+                self.flag(who, "items", f"items {sorted(unexplained)} with no transaction")
+            )
+
+        # Flagged once; the actual state is the baseline from here on
+        expected.position, expected.gold, expected.items = position, gold, items
+        return incidents
+
+    # Incidents and restrictions
+
+    def flag(self, who: str, kind: str, detail: str) -> Dict[str, Any]:
+        """Record an incident, restricting the player once they have enough."""
+        incident = {"time": self.clock(), "player": who, "kind": kind, "detail": detail}
+        self.data["incidents"].append(incident)
+        del self.data["incidents"][:-MAX_INCIDENTS]
+        if self.audit:
+            self.audit.record("anticheat_incident", "server", who, kind=kind, detail=detail)
+        open_count = sum(
+            1 for i in self.data["incidents"] if i["player"] == who and not i.get("reviewed")
+        )
+        if self.restrict_after and open_count >= self.restrict_after and not self.restricted(who):
+            self.data["restricted"].append(who)
+            incident["restricted"] = True
+            if self.audit:
+                self.audit.record("anticheat_restricted", "server", who, incidents=open_count)
+        self._save()
+        return incident
+
+    def restricted(self, who: str) -> bool:
+        return who in self.data["restricted"]
+
+    def lift(self, who: str) -> bool:
+        """A GM has reviewed the player: lift any restriction and mark incidents reviewed."""
+        for incident in self.data["incidents"]:
+            if incident["player"] == who:
+                incident["reviewed"] = True
+        was_restricted = self.restricted(who)
+        if was_restricted:
+            self.data["restricted"].remove(who)
+        self._save()
+        return was_restricted
+
+    def incidents(self, who: Optional[str] = None, limit: int = 50) -> List[Dict[str, Any]]:
+        """Most recent incidents first, optionally for one player."""
+        matching = [i for i in self.data["incidents"] if who is None or i["player"] == who]
+        return list(reversed(matching))[: max(0, limit)]
+
+    def _save(self):
+        if not self.path:
+            return
+        try:
+            save_profile(self.path, self.data)
+        except OSError as e:
+            print(f"Could not save anti-cheat incidents: {e}")
@@ -0,0 +1,81 @@
+"""
+Audit log of privileged and economy-sensitive actions.
+Admin requests, bans, kicked sessions, failed sign-ins and large gold
+movements are appended here as JSON lines (who did what to whom, when, and
+the details), so an incident can be reconstructed later with query().
+"""
+
+import json
+import os
+import threading
+import time
+from typing import Any, Dict, List, Optional
+
+
+class AuditLog:
+    """Append-only audit trail; safe to record from gateway threads."""
+
+    def __init__(self, path: str, large_gold: int = 1000):
+        self.path = path
+        self.large_gold = large_gold  # Gold moved in one transaction worth recording
+        self._lock = threading.Lock()
+        directory = os.path.dirname(path)
+        if directory:
+            os.makedirs(directory, exist_ok=True)
+
+    def record(self, action: str, actor: str, target: str = "", **details):
+        """Append one entry."""
+        entry = {
+            "time": time.time(),
+            "action": action,
+            "actor": actor,
+            "target": str(target),
+            "details": details,
+        }
+        with self._lock:
+            with open(self.path, "a", encoding="utf-8") as f:
+                f.write(json.dumps(entry, default=str) + "\n")
+                f.flush()
+                os.fsync(f.fileno())
+
+    def gold_moved(self, reason: str, txid: str, amount: int, actor: str = "game"):
+        """Record a transaction if it moved a large amount of gold."""
+        if self.large_gold and amount >= self.large_gold:
+            self.record("large_gold", actor, txid, reason=reason, amount=amount)
+
+    def read(self) -> List[Dict[str, Any]]:
+        """Every entry, oldest first, skipping a torn final line."""
+        if not os.path.exists(self.path):
+            return []
+        entries = []
+        with self._lock, open(self.path, "r", encoding="utf-8") as f:
+            for line in f:
+                try:
+                    entries.append(json.loads(line))
+                except json.JSONDecodeError:
+                    continue
+        return entries
+
+    def query(
+        self,
+        action: Optional[str] = None,
+        actor: Optional[str] = None,
+        target: Optional[str] = None,
+        since: Optional[float] = None,
+        limit: int = 100,
+    ) -> List[Dict[str, Any]]:
+        """Matching entries, newest first."""
+        matches = []
+        for entry in reversed(self.read()):
+            if action is not None and entry.get("action") != action:
+                continue
+            if actor is not None and entry.get("actor") != actor:
+                continue
+            if target is not None and entry.get("target") != str(target):
+                continue
+            if since is not None and entry.get("time", 0) < since:
+                continue
+            matches.append(entry)
+            if len(matches) >= limit:
+                break
+        return matches
@@ -0,0 +1,45 @@
+"""
+Item binding for the roguelike game.
+An item definition may say when the item binds to whoever has it: "pickup"
+items bind the moment a player takes them and "equip" items the first time
+they are worn. A bound item stays its owner's for good. They can still bank
+it, sell it to a vendor or throw it away, but it can never be handed to
+another player by trade, auction or mail, nor to one of their other
+characters through the account stash. Transactions refuse those moves
+for every path that makes them, so no feature has to remember to check.
+"""
+
+from typing import Optional
+
+from entities.components import Item
+
+BIND_ON_PICKUP = "pickup"
+BIND_ON_EQUIP = "equip"
+BIND_EVENTS = (BIND_ON_PICKUP, BIND_ON_EQUIP)
+
+# Transaction reasons that hand an item to another player or character
+TRANSFER_REASONS = ("trade", "auction", "mail", "stash")
+
+
+def bind_status(item: Item) -> str:
+    """How an item binds, for payloads: bound, binds_on_pickup, binds_on_equip or ""."""
+    if item.bound_to:
+        return "bound"
+    if item.bind in BIND_EVENTS:
+        return f"binds_on_{item.bind}"
+    return ""
+
+
+def bind_on(item: Item, event: str, owner: str) -> bool:
+    """Bind an item to owner if event is what binds it; True if it just bound."""
+    if item.bound_to or item.bind != event or not owner:
+        return False
+    item.bound_to = owner
+    return True
+
+
+def transfer_refusal(item: Optional[Item], reason: str) -> Optional[str]:
+    """Why an item cannot change hands for a transaction reason, or None."""
+    if item is None or not item.bound_to or reason not in TRANSFER_REASONS:
+        return None
+    return f"{item.name} is bound to {item.bound_to}"
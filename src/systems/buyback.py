@@ -0,0 +1,47 @@
+"""
+Vendor buyback for the roguelike game.
+An item sold to a vendor is not destroyed straight away: the last few sales
+of the session are held back, and the player can buy any of them back from
+any shop for exactly what they were paid. Older sales drop off the end and
+are gone for good, as is everything left when the session ends.
+"""
+
+from typing import Dict, List, Tuple
+
+BUYBACK_SLOTS = 10
+
+
+class Buyback:
+    """The player's recent vendor sales, newest first, with what each fetched."""
+
+    def __init__(self, slots: int = BUYBACK_SLOTS):
+        self.slots = slots
+        self.items: List[int] = []
+        self.prices: Dict[int, int] = {}
+
+    def record(self, item_id: int, price: int) -> List[int]:
+        """Hold a sold item back; returns the oldest sales pushed out, to destroy."""
+        self.items.insert(0, item_id)
+        self.prices[item_id] = price
+        dropped = self.items[self.slots :]
+        del self.items[self.slots :]
+        for eid in dropped:
+            del self.prices[eid]
+        return dropped
+
+    def price(self, item_id: int) -> int:
+        return self.prices[item_id]
+
+    def take(self, item_id: int):
+        """Forget a sale once it has been bought back."""
+        self.items.remove(item_id)
+        del self.prices[item_id]
+
+    def clear(self) -> List[int]:
+        """Forget every sale; returns the items, to destroy."""
+        items, self.items, self.prices = self.items, [], {}
+        return items
+
+    def entries(self) -> List[Tuple[int, int]]:
+        """(item, price) for each held sale, newest first."""
+        return [(eid, self.prices[eid]) for eid in self.items]
@@ -0,0 +1,203 @@
+"""
+Chat channels for the roguelike game.
+Besides saying something aloud, players can talk on channels. The server
+keeps a few open to everyone (trade and help by default), and players can
+make private channels of their own, optionally behind a password. Whoever
+makes a channel owns it and can name moderators; the owner and moderators
+can remove members, who then need the password again to come back.
+
+Only members may speak on a channel, and that is checked here, where every
+channel line is routed, not by whoever sends it. Player channels and their
+members are kept in a small JSON file so they survive a restart; server
+channels come from config and are open to whoever joins them. Passwords
+are only ever kept as salted hashes.
+"""
+
+import hashlib
+import hmac
+import os
+import re
+from dataclasses import dataclass, field, fields
+from typing import Any, Dict, Iterable, List, Optional
+
+from systems.profile import load_profile, save_profile
+
+SERVER_CHANNELS = ("trade", "help")
+CHANNEL_NAME = re.compile(r"^[a-z][a-z0-9_-]{1,19}$")
+MAX_OWNED = 3  # Private channels one player may own
+HASH_ITERATIONS = 20000  # PBKDF2 rounds; joins are checked on the game loop
+
+
+class ChannelError(ValueError):
+    """A channel that cannot be made, joined, left or spoken on."""
+
+
+@dataclass
+class Channel:
+    name: str
+    owner: str = ""  # Empty for channels the server keeps
+    password_hash: str = ""  # "salt$digest" in hex (see hash_password); empty if open
+    moderators: List[str] = field(default_factory=list)
+    members: List[str] = field(default_factory=list)
+
+    @property
+    def server(self) -> bool:
+        return not self.owner
+
+    def can_moderate(self, player: str) -> bool:
+        return player == self.owner or player in self.moderators
+
+    def describe(self, player: str) -> Dict[str, Any]:
+        """The channel as one player sees it in a channel list."""
+        return {
+            "name": self.name,
+            "owner": self.owner or None,
+            "private": bool(self.password_hash),
+            "members": len(self.members),
+            "joined": player in self.members,
+            "moderator": self.can_moderate(player),
+        }
+
+
+def hash_password(password: str, salt: Optional[bytes] = None) -> str:
+    """A password as it is kept: a random salt and its PBKDF2 digest."""
+    salt = os.urandom(16) if salt is None else salt
+    digest = hashlib.pbkdf2_hmac("sha256", password.encode(), salt, HASH_ITERATIONS)
+    return f"{salt.hex()}${digest.hex()}"
+
+
+def check_password(password: str, stored: str) -> bool:
+    """Whether password matches one kept by hash_password."""
+    salt, _, digest = stored.partition("$")
+    try:
+        expected = hash_password(password, bytes.fromhex(salt))
+    except ValueError:
+        return False
+    return hmac.compare_digest(expected.partition("$")[2], digest)
+
+
+def load_channel(data: Dict[str, Any]) -> Channel:
+    """A saved channel, skipping fields it no longer has.
+
+    Channels saved before passwords were hashed have theirs hashed now.
+    """
+    data = dict(data)
+    if "password" in data:
+        password = str(data.pop("password"))
+        data["password_hash"] = hash_password(password) if password else ""
+    known = {f.name for f in fields(Channel)}
+    return Channel(**{key: value for key, value in data.items() if key in known})
+
+
+class ChannelRouter:
+    """Every channel, who is on it, and who may speak."""
+
+    def __init__(self, path: Optional[str], server: Iterable[str] = SERVER_CHANNELS):
+        self.path = path
+        self.channels: Dict[str, Channel] = {name: Channel(name) for name in server}
+        saved = (load_profile(path) if path else {}).get("channels", [])
+        for data in saved:
+            channel = load_channel(data)
+            if channel.name in self.channels:
+                # A server channel keeps its rules; only its members are saved
+                self.channels[channel.name].members = channel.members
+            elif channel.owner:
+                self.channels[channel.name] = channel
+        if any("password" in data for data in saved):
+            self._save()  # Plaintext passwords do not stay on disk
+
+    def get(self, name: str) -> Channel:
+        channel = self.channels.get(str(name).lower())
+        if channel is None:
+            raise ChannelError(f"There is no channel called {name}.")
+        return channel
+
+    def create(self, name: str, owner: str, password: str = "") -> Channel:
+        """Make a private channel; its owner joins it at once."""
+        name = str(name).lower()
+        if not CHANNEL_NAME.match(name):
+            raise ChannelError(
+                "Channel names are 2-20 lowercase letters, digits, '-' or '_'."
+            )
+        if name in self.channels:
+            raise ChannelError(f"There is already a channel called {name}.")
+        owned = [c for c in self.channels.values() if c.owner == owner]
+        if len(owned) >= MAX_OWNED:
+            raise ChannelError(f"You can own at most {MAX_OWNED} channels.")
+        password_hash = hash_password(str(password)) if password else ""
+        channel = Channel(name, owner, password_hash, members=[owner])
+        self.channels[name] = channel
+        self._save()
+        return channel
+
+    def join(self, name: str, player: str, password: str = "") -> Channel:
+        channel = self.get(name)
+        if player in channel.members:
+            return channel
+        if channel.password_hash and not check_password(str(password), channel.password_hash):
+            raise ChannelError(f"Wrong password for {channel.name}.")
+        channel.members.append(player)
+        self._save()
+        return channel
+
+    def leave(self, name: str, player: str) -> Channel:
+        channel = self.get(name)
+        if player not in channel.members:
+            raise ChannelError(f"You are not on {channel.name}.")
+        channel.members.remove(player)
+        if channel.owner == player:
+            # An owner leaving closes their channel
+            del self.channels[channel.name]
+        self._save()
+        return channel
+
+    def kick(self, name: str, moderator: str, player: str) -> Channel:
+        """Remove a member; only the owner and moderators may, and not the owner."""
+        channel = self.get(name)
+        if not channel.can_moderate(moderator):
+            raise ChannelError(f"You do not moderate {channel.name}.")
+        if player == channel.owner or player not in channel.members:
+            raise ChannelError(f"{player} cannot be removed from {channel.name}.")
+        channel.members.remove(player)
+        if player in channel.moderators:
+            channel.moderators.remove(player)
+        self._save()
+        return channel
+
+    def add_moderator(self, name: str, owner: str, player: str) -> Channel:
+        channel = self.get(name)
+        if channel.owner != owner:
+            raise ChannelError(f"Only the owner of {channel.name} names moderators.")
+        if player not in channel.members:
+            raise ChannelError(f"{player} is not on {channel.name}.")
+        if player not in channel.moderators:
+            channel.moderators.append(player)
+            self._save()
+        return channel
+
+    def route(self, name: str, sender: str) -> Channel:
+        """The channel a line goes out on; raises unless the sender is a member."""
+        channel = self.get(name)
+        if sender not in channel.members:
+            raise ChannelError(f"Join {channel.name} before speaking on it.")
+        return channel
+
+    def listing(self, player: str) -> List[Dict[str, Any]]:
+        """Server channels first, then player channels, by name."""
+        ordered = sorted(self.channels.values(), key=lambda c: (not c.server, c.name))
+        return [channel.describe(player) for channel in ordered]
+
+    def _save(self):
+        if not self.path:
+            return
+        channels = [
+            {
+                "name": c.name,
+                "owner": c.owner,
+                "password_hash": c.password_hash,
+                "moderators": c.moderators,
+                "members": c.members,
+            }
+            for c in self.channels.values()
+        ]
+        save_profile(self.path, {"channels": channels})
@@ -0,0 +1,71 @@
+"""
+In-game clock for the roguelike game.
+Maps real play time onto a repeating day so that lighting, NPC schedules and
+other systems can follow dawn, day, dusk and night.
+"""
+
+from typing import Any, Dict, Optional
+
+DEFAULT_TIME = {
+    "day_length": 1200.0,  # Real seconds per in-game day
+    "start_hour": 8.0,  # Hour of day when a new game starts
+}
+
+# (period, first hour) in order through the day
+DAY_PERIODS = (("night", 0.0), ("dawn", 5.0), ("day", 7.0), ("dusk", 18.0), ("night", 20.0))
+
+# Share of full daylight in each period
+DAYLIGHT = {"night": 0.2, "dawn": 0.6, "day": 1.0, "dusk": 0.6}
+
+DAY_PERIOD_MESSAGES = {
+    "dawn": "Dawn breaks over the realm.",
+    "day": "The sun is up; the shops are opening.",
+    "dusk": "The sun begins to set.",
+    "night": "Night falls. The streets empty.",
+}
+
+
+def hour_in_window(hour: float, start: float, end: float) -> bool:
+    """Whether hour falls in [start, end), where windows may wrap past midnight."""
+    if start <= end:
+        return start <= hour < end
+    return hour >= start or hour < end
+
+
+class GameClock:
+    """Tracks the in-game day and hour."""
+
+    def __init__(self, settings: Optional[Dict[str, Any]] = None):
+        settings = settings or {}
+        self.day_length = float(settings.get("day_length", DEFAULT_TIME["day_length"]))
+        start_hour = float(settings.get("start_hour", DEFAULT_TIME["start_hour"]))
+        # Total in-game hours elapsed since day 1, 00:00
+        self.total_hours = start_hour
+
+    def advance(self, dt: float):
+        """Advance the clock by dt real seconds."""
+        self.total_hours += dt * 24.0 / self.day_length
+
+    @property
+    def day(self) -> int:
+        return int(self.total_hours // 24) + 1
+
+    @property
+    def hour(self) -> float:
+        return self.total_hours % 24
+
+    @property
+    def period(self) -> str:
+        current = DAY_PERIODS[0][0]
+        for name, first_hour in DAY_PERIODS:
+            if self.hour >= first_hour:
+                current = name
+        return current
+
+    @property
+    def daylight(self) -> float:
+        return DAYLIGHT[self.period]
+
+    def time_string(self) -> str:
+        minutes = int(self.hour * 60)
+        return f"Day {self.day} {minutes // 60:02d}:{minutes % 60:02d}"
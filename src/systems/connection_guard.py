@@ -0,0 +1,83 @@
+"""
+Per-IP connection limits for network listeners.
+Caps how many connections one address may hold open, and how often it may
+connect; an address that keeps reconnecting faster than that (a scripted
+flood or someone hammering the port) is banned for a while.
+"""
+
+import threading
+import time
+from collections import defaultdict, deque
+from typing import Callable, Deque, Dict, Optional
+
+
+class ConnectionGuard:
+    """Decides whether a new connection from an address is let in."""
+
+    def __init__(
+        self,
+        max_per_ip: int = 3,
+        attempts: int = 10,
+        window: float = 60.0,
+        ban_seconds: float = 600.0,
+        clock: Callable[[], float] = time.monotonic,
+        audit=None,
+    ):
+        self.max_per_ip = max_per_ip  # Open connections per address (0 = no cap)
+        self.attempts = attempts  # Connections allowed per window (0 = no limit)
+        self.window = window
+        self.ban_seconds = ban_seconds
+        self.clock = clock
+        self.open: Dict[str, int] = defaultdict(int)
+        self.recent: Dict[str, Deque[float]] = defaultdict(deque)
+        self.banned: Dict[str, float] = {}  # Address -> when the ban lifts
+        self.audit = audit  # Optional AuditLog that records each ban
+        self._lock = threading.Lock()
+
+    def admit(self, ip: str) -> Optional[str]:
+        """Let a connection in and count it, or return why it was refused.
+
+        Every admitted connection must be given back with release.
+        """
+        now = self.clock()
+        with self._lock:
+            until = self.banned.get(ip)
+            if until is not None:
+                if now < until:
+                    return "Too many connection attempts. Try again later."
+                del self.banned[ip]
+
+            if self.attempts:
+                recent = self.recent[ip]
+                while recent and now - recent[0] > self.window:
+                    recent.popleft()
+                recent.append(now)
+                if len(recent) > self.attempts:
+                    self.banned[ip] = now + self.ban_seconds
+                    recent.clear()
+                    if self.audit:
+                        self.audit.record(
+                            "ban", "connection_guard", ip, seconds=self.ban_seconds
+                        )
+                    return "Too many connection attempts. Try again later."
+
+            if self.max_per_ip and self.open[ip] >= self.max_per_ip:
+                return "Too many connections from your address."
+            self.open[ip] += 1
+            return None
+
+    def ban(self, ip: str, seconds: float, by: str = "admin"):
+        """Turn an address away for seconds, whatever its connection rate."""
+        with self._lock:
+            self.banned[ip] = self.clock() + seconds
+        if self.audit:
+            self.audit.record("ban", by, ip, seconds=seconds)
+
+    def release(self, ip: str):
+        with self._lock:
+            if self.open.get(ip, 0) > 1:
+                self.open[ip] -= 1
+            else:
+                self.open.pop(ip, None)
+                if not self.recent.get(ip):
+                    self.recent.pop(ip, None)
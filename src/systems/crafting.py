@@ -0,0 +1,134 @@
+"""
+Crafting for the roguelike game.
+Players turn ore into gear with the recipes in recipes.json. How good the
+result is depends on the crafter's skill beyond what the recipe needs: more
+skill lifts the range the item's stats are rolled in and the chance of a
+bonus affix. Any craft may also turn out critical, a masterwork rolled past
+the top of the range with a bonus affix guaranteed. Crafted items carry the
+name of whoever made them.
+"""
+
+import random
+from dataclasses import dataclass
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from core.ecs import EntityManager
+from entities.components import ArmorStats, Item, WeaponStats
+from entities.entities import ARMOR_PREFIXES, WEAPON_PREFIXES
+
+CRITICAL_MULTIPLIER = 1.25  # A masterwork's stats over the top of the normal range
+CRAFT_XP = 10  # Crafting XP per craft, plus the recipe's required skill
+# Quality names by the lowest stat multiplier that earns them, best first
+QUALITY_NAMES = (("superior", 1.2), ("fine", 1.05), ("standard", 0.9), ("crude", 0.0))
+
+
+class CraftingError(ValueError):
+    """A recipe that cannot be crafted."""
+
+
+@dataclass
+class CraftRoll:
+    """How a single craft turned out."""
+
+    multiplier: float  # Applied to the item's base stats and value
+    bonus_affix: bool
+    critical: bool
+
+    @property
+    def quality(self) -> str:
+        if self.critical:
+            return "masterwork"
+        for name, floor in QUALITY_NAMES:
+            if self.multiplier >= floor:
+                return name
+        return QUALITY_NAMES[-1][0]
+
+
+def stat_range(margin: int) -> Tuple[float, float]:
+    """Range of stat multipliers for a crafter margin levels above the recipe."""
+    margin = max(0, margin)
+    low = min(1.0, 0.7 + 0.03 * margin)
+    high = min(1.3, 1.0 + 0.02 * margin)
+    return round(low, 2), round(high, 2)
+
+
+def bonus_affix_chance(margin: int) -> float:
+    return round(min(0.5, 0.05 + 0.03 * max(0, margin)), 2)
+
+
+def critical_chance(margin: int) -> float:
+    return round(min(0.2, 0.02 + 0.01 * max(0, margin)), 2)
+
+
+def roll_craft(
+    skill: int, required: int, rng: Optional[random.Random] = None
+) -> CraftRoll:
+    """Roll the quality of one craft at a skill level."""
+    rng = rng or random.Random()
+    margin = skill - required
+    low, high = stat_range(margin)
+    if rng.random() < critical_chance(margin):
+        return CraftRoll(round(high * CRITICAL_MULTIPLIER, 2), True, True)
+    bonus = rng.random() < bonus_affix_chance(margin)
+    return CraftRoll(round(rng.uniform(low, high), 2), bonus, False)
+
+
+def check_recipe(
+    recipes: Mapping[str, Any], name: str, skill: int, carried: Mapping[str, int]
+) -> Dict[str, Any]:
+    """The recipe for name, if the crafter has the skill and materials for it."""
+    recipe = recipes.get(name)
+    if not isinstance(recipe, dict):
+        raise CraftingError(f"There is no recipe for {name!r}.")
+    required = int(recipe.get("skill", 1))
+    if skill < required:
+        raise CraftingError(f"You need crafting {required} to make that.")
+    missing = [
+        f"{count - carried.get(material, 0)} {material}"
+        for material, count in recipe.get("materials", {}).items()
+        if carried.get(material, 0) < count
+    ]
+    if missing:
+        raise CraftingError(f"You still need {', '.join(missing)}.")
+    return recipe
+
+
+def apply_roll(
+    entity_manager: EntityManager,
+    item_id: int,
+    roll: CraftRoll,
+    crafter: str,
+    rng: Optional[random.Random] = None,
+) -> List[str]:
+    """Scale a freshly made item by its roll and sign it; returns affixes added."""
+    rng = rng or random.Random()
+    item = entity_manager.get_component(item_id, Item)
+    weapon = entity_manager.get_component(item_id, WeaponStats)
+    armor = entity_manager.get_component(item_id, ArmorStats)
+    added = []
+    name = item.name
+
+    if weapon:
+        weapon.attack_power = max(1, round(weapon.attack_power * roll.multiplier))
+    if armor:
+        armor.defense = max(1, round(armor.defense * roll.multiplier))
+    # Bonus affixes are only ever good ones
+    if roll.bonus_affix and (weapon or armor):
+        table = WEAPON_PREFIXES if weapon else ARMOR_PREFIXES
+        stat = "attack" if weapon else "defense"
+        prefix = rng.choice(sorted(p for p, bonus in table.items() if bonus[stat] > 0))
+        if weapon:
+            weapon.attack_power += table[prefix][stat]
+        else:
+            armor.defense += table[prefix][stat]
+        name = f"{prefix} {name}"
+        added.append(prefix)
+
+    if roll.quality != "standard":
+        name = f"{roll.quality.title()} {name}"
+    item.name = name
+    item.affixes.extend(added)
+    item.value = round(item.value * roll.multiplier)
+    item.quality = roll.quality
+    item.crafted_by = crafter
+    return added
@@ -0,0 +1,130 @@
+"""
+Currencies for the roguelike game.
+A character's Inventory is their wallet. Gold stays where it always was, in
+Inventory.gold; every other currency is a balance in Inventory.currencies.
+Copper and silver are smaller coins of the same money as gold and can be
+changed up or down at fixed rates; event tokens and premium crowns are kept
+apart and never convert into coin.
+
+Coins are carried like gold and go with the character. Tokens and crowns
+are kept in the player's profile between sessions, and crowns belong to the
+account, so they also pass to whoever comes after a hardcore death.
+
+Systems grant and spend currencies through item transactions
+(ItemTransaction.grant and ItemTransaction.spend), so a price in several
+currencies, or in currency and items together, is paid all at once or not
+at all.
+"""
+
+from dataclasses import dataclass
+from typing import Dict, List, Optional, Tuple
+
+GOLD = "gold"
+
+
+@dataclass(frozen=True)
+class Currency:
+    name: str
+    coin_value: int = 0  # Worth in copper; 0 for currencies that are not coin
+    persistent: bool = False  # Kept in the player's profile between sessions
+    account: bool = False  # Survives a hardcore death
+    tradable: bool = True  # May be paid to another player
+
+
+CURRENCIES: Dict[str, Currency] = {
+    "copper": Currency("Copper", coin_value=1),
+    "silver": Currency("Silver", coin_value=100),
+    GOLD: Currency("Gold", coin_value=10000),
+    "event_token": Currency("Event Token", persistent=True),
+    "crown": Currency("Crown", persistent=True, account=True, tradable=False),
+}
+
+
+class CurrencyError(ValueError):
+    """An unknown currency, or a conversion between currencies that do not convert."""
+
+
+def currency(currency_id: str) -> Currency:
+    if currency_id not in CURRENCIES:
+        raise CurrencyError(f"Unknown currency: {currency_id}")
+    return CURRENCIES[currency_id]
+
+
+def balance(container, currency_id: str) -> int:
+    """How much of a currency a container holds."""
+    if currency_id == GOLD:
+        return container.gold
+    return (getattr(container, "currencies", None) or {}).get(currency_id, 0)
+
+
+def balances(container) -> Dict[str, int]:
+    """Every currency a container holds any of, gold included."""
+    held = {GOLD: container.gold} if container.gold else {}
+    held.update(
+        (cid, amount)
+        for cid, amount in (getattr(container, "currencies", None) or {}).items()
+        if amount
+    )
+    return held
+
+
+def coin_worth(container) -> int:
+    """All a container's coin, in copper."""
+    return sum(
+        balance(container, cid) * c.coin_value
+        for cid, c in CURRENCIES.items()
+        if c.coin_value
+    )
+
+
+def split_coins(copper: int) -> List[Tuple[str, int]]:
+    """A copper amount as the fewest coins, largest first."""
+    coins = sorted(
+        ((cid, c.coin_value) for cid, c in CURRENCIES.items() if c.coin_value),
+        key=lambda coin: -coin[1],
+    )
+    split = []
+    for cid, value in coins:
+        count, copper = divmod(copper, value)
+        if count:
+            split.append((cid, count))
+    return split
+
+
+def format_coins(copper: int) -> str:
+    """A copper amount for people: '3 gold, 4 silver, 2 copper'."""
+    split = split_coins(copper)
+    if not split:
+        return "0 copper"
+    return ", ".join(f"{count} {cid}" for cid, count in split)
+
+
+def exchange(from_id: str, to_id: str, amount: int) -> Tuple[int, int]:
+    """Change amount of one coin into another.
+
+    Returns (coins received, coins of the original kind left over that did
+    not make up a whole coin).
+    """
+    source, target = currency(from_id), currency(to_id)
+    if not source.coin_value or not target.coin_value:
+        raise CurrencyError(f"{source.name} cannot be changed into {target.name}")
+    received, left = divmod(amount * source.coin_value, target.coin_value)
+    return received, left // source.coin_value
+
+
+def wallet_profile(container, account_only: bool = False) -> Dict[str, int]:
+    """The balances kept in the profile: persistent ones, or only the account's."""
+    kept: Dict[str, int] = {}
+    for cid, amount in balances(container).items():
+        c = CURRENCIES.get(cid)
+        if c and c.persistent and (c.account or not account_only):
+            kept[cid] = amount
+    return kept
+
+
+def restore_wallet(container, data: Optional[Dict[str, int]]):
+    """Put the profile's balances back in a wallet."""
+    for cid, amount in (data or {}).items():
+        c = CURRENCIES.get(cid)
+        if c and c.persistent and cid != GOLD:
+            container.currencies[cid] = int(amount)
@@ -0,0 +1,135 @@
+"""
+Cutscenes: short scripted sequences for quest moments and boss introductions.
+While one plays the player's input is locked and the server streams its
+events in order: camera focus hints, lines of dialogue and NPC movement,
+each followed by a pause so they read at a sensible pace. Cutscenes live in
+src/data/static/cutscenes.json:
+
+    "dragon_intro": {
+        "skippable": true,
+        "steps": [
+            {"do": "focus", "target": "subject", "pause": 1.0},
+            {"do": "dialogue", "speaker": "{subject}", "text": "Who wakes me?"},
+            {"do": "move", "target": "subject", "dx": 0, "dy": 1},
+            {"do": "focus", "target": "player"}
+        ]
+    }
+
+Targets are "player", "subject" (the boss or NPC the cutscene was started
+for) or a tile given as x and y. A skippable cutscene can be skipped at any
+point; its remaining movement still happens, so the world ends up the same.
+"""
+
+from typing import Any, Dict, List, Optional, Tuple
+
+# Step -> fields it needs
+STEPS: Dict[str, Tuple[str, ...]] = {
+    "focus": (),
+    "dialogue": ("text",),
+    "move": ("target", "dx", "dy"),
+    "wait": ("pause",),
+}
+OPTIONAL_FIELDS = {"focus": ("target", "x", "y"), "dialogue": ("speaker",)}
+TARGETS = ("player", "subject")
+DEFAULT_PAUSE = {"focus": 0.5, "dialogue": 2.5, "move": 0.4, "wait": 0.0}
+MAX_STEPS = 64
+MAX_PAUSE = 10.0  # Seconds; a cutscene must never hold the player for long
+
+
+class CutsceneError(ValueError):
+    """A cutscene definition that is malformed."""
+
+
+def validate_cutscene(entry: Any, where: str = "cutscene"):
+    """Check a cutscene definition, raising CutsceneError on the first problem."""
+    if not isinstance(entry, dict) or not isinstance(entry.get("steps"), list):
+        raise CutsceneError(f"{where}: expected a table with a list of steps")
+    steps = entry["steps"]
+    if not steps or len(steps) > MAX_STEPS:
+        raise CutsceneError(f"{where}: needs between 1 and {MAX_STEPS} steps")
+    for i, step in enumerate(steps):
+        here = f"{where}.steps[{i}]"
+        if not isinstance(step, dict) or step.get("do") not in STEPS:
+            raise CutsceneError(f"{here}: 'do' must be one of {', '.join(STEPS)}")
+        kind = step["do"]
+        for name in STEPS[kind]:
+            if name not in step:
+                raise CutsceneError(f"{here}: {kind} needs '{name}'")
+        allowed = ("do", "pause") + STEPS[kind] + OPTIONAL_FIELDS.get(kind, ())
+        for name in step:
+            if name not in allowed:
+                raise CutsceneError(f"{here}: {kind} does not take '{name}'")
+        if "target" in step and step["target"] not in TARGETS:
+            raise CutsceneError(f"{here}: target must be one of {', '.join(TARGETS)}")
+        if kind == "focus" and "target" not in step and not ("x" in step and "y" in step):
+            raise CutsceneError(f"{here}: focus needs a target or x and y")
+        for name in ("dx", "dy", "x", "y"):
+            value = step.get(name, 0)
+            if isinstance(value, bool) or not isinstance(value, int):
+                raise CutsceneError(f"{here}: {name} must be a whole number")
+        pause = step.get("pause", 0)
+        if isinstance(pause, bool) or not isinstance(pause, (int, float)):
+            raise CutsceneError(f"{here}: pause must be a number")
+        if not 0 <= pause <= MAX_PAUSE:
+            raise CutsceneError(f"{here}: pause must be 0 to {MAX_PAUSE:g} seconds")
+
+
+class Cutscene:
+    """One cutscene playing out, step by step, on game time."""
+
+    def __init__(self, name: str, entry: Dict[str, Any], subject: Optional[int] = None):
+        self.name = name
+        self.steps: List[Dict[str, Any]] = list(entry["steps"])
+        self.skippable = bool(entry.get("skippable", True))
+        self.subject = subject
+        self.index = 0  # Next step to run
+        self.wait = 0.0  # Seconds until it runs
+        self.focus: Optional[Tuple[int, int]] = None  # Camera hint, if any
+
+    @property
+    def finished(self) -> bool:
+        return self.index >= len(self.steps) and self.wait <= 0
+
+    def advance(self, dt: float) -> List[Dict[str, Any]]:
+        """Steps whose time has come, in order; each is run by the caller."""
+        self.wait -= dt
+        due = []
+        while self.wait <= 0 and self.index < len(self.steps):
+            step = self.steps[self.index]
+            self.index += 1
+            self.wait += step.get("pause", DEFAULT_PAUSE[step["do"]])
+            due.append(step)
+        return due
+
+    def skip(self) -> List[Dict[str, Any]]:
+        """End now; returns the remaining move steps, which must still happen."""
+        remaining = self.steps[self.index :]
+        self.index, self.wait = len(self.steps), 0.0
+        return [step for step in remaining if step["do"] == "move"]
+
+
+class CutsceneLog:
+    """Numbered events of recent cutscenes, for clients that poll for them."""
+
+    def __init__(self, size: int = 200):
+        self.size = size
+        self.events: List[Dict[str, Any]] = []
+        self.next_seq = 1
+
+    def add(self, kind: str, **fields) -> Dict[str, Any]:
+        event = {"seq": self.next_seq, "kind": kind, **fields}
+        self.next_seq += 1
+        self.events.append(event)
+        del self.events[: -self.size]
+        return event
+
+    def since(self, seq: int) -> List[Dict[str, Any]]:
+        return [event for event in self.events if event["seq"] > seq]
+
+
+def resolve_target(
+    step: Dict[str, Any], subject: Optional[int], player: Optional[int]
+) -> Optional[int]:
+    """The entity a step's target names, if it names one."""
+    return {"player": player, "subject": subject}.get(step.get("target"))
+
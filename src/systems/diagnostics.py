@@ -0,0 +1,132 @@
+"""
+Runtime diagnostics for admins.
+Reports thread count, memory use, garbage collector activity (including how
+long collections paused the game) and how far frames run over their budget,
+and can profile the game loop on demand, all without restarting the game.
+"""
+
+import cProfile
+import gc
+import io
+import os
+import pstats
+import sys
+import threading
+import time
+from collections import deque
+from typing import Optional
+
+try:
+    import resource
+except ImportError:  # Not available on Windows
+    resource = None
+
+
+def _summary(values) -> dict:
+    """Last, max and mean of recent samples, in milliseconds."""
+    if not values:
+        return {"last": 0.0, "max": 0.0, "mean": 0.0, "samples": 0}
+    return {
+        "last": round(values[-1] * 1000, 3),
+        "max": round(max(values) * 1000, 3),
+        "mean": round(sum(values) / len(values) * 1000, 3),
+        "samples": len(values),
+    }
+
+
+class Diagnostics:
+    """Collects runtime statistics and runs the on-demand profiler."""
+
+    def __init__(self, history: int = 300, profile_dir: str = "src/data/saves/profiles"):
+        self.gc_pauses = deque(maxlen=history)
+        self.frame_lag = deque(maxlen=history)  # Seconds each frame ran over budget
+        self.frames = 0
+        self.profile_dir = profile_dir
+        self._gc_started: Optional[float] = None
+        self._profiler: Optional[cProfile.Profile] = None
+        self._profile_started = 0.0
+
+    def install(self):
+        """Start timing garbage collections."""
+        if self._on_gc not in gc.callbacks:
+            gc.callbacks.append(self._on_gc)
+
+    def uninstall(self):
+        if self._on_gc in gc.callbacks:
+            gc.callbacks.remove(self._on_gc)
+
+    def _on_gc(self, phase: str, info: dict):
+        if phase == "start":
+            self._gc_started = time.perf_counter()
+        elif self._gc_started is not None:
+            self.gc_pauses.append(time.perf_counter() - self._gc_started)
+            self._gc_started = None
+
+    def record_frame(self, duration: float, budget: float):
+        """Note how long a frame took against the time it had."""
+        self.frames += 1
+        self.frame_lag.append(max(0.0, duration - budget))
+
+    def runtime_stats(self, tick: int = 0) -> dict:
+        """Build a runtime_stats message."""
+        max_rss_kb, cpu_seconds = 0, time.process_time()
+        if resource:
+            usage = resource.getrusage(resource.RUSAGE_SELF)
+            # ru_maxrss is kilobytes on Linux but bytes on macOS
+            max_rss_kb = usage.ru_maxrss // 1024 if sys.platform == "darwin" else usage.ru_maxrss
+        return {
+            "type": "runtime_stats",
+            "threads": threading.active_count(),
+            "memory": {
+                "max_rss_kb": max_rss_kb,
+                "allocated_blocks": sys.getallocatedblocks(),
+            },
+            "gc": {
+                "collections": [gen["collections"] for gen in gc.get_stats()],
+                "tracked": list(gc.get_count()),
+                "pause_ms": _summary(self.gc_pauses),
+            },
+            "tick": tick,
+            "frames": self.frames,
+            "tick_lag_ms": _summary(self.frame_lag),
+            "cpu_seconds": round(cpu_seconds, 3),
+            "profiling": self._profiler is not None,
+        }
+
+    def start_profile(self) -> dict:
+        """Begin profiling the calling thread (the game loop)."""
+        if self._profiler is not None:
+            return {"type": "error", "error": "A profile is already running"}
+        self._profiler = cProfile.Profile()
+        self._profile_started = time.time()
+        self._profiler.enable()
+        return {"type": "profile_started"}
+
+    def stop_profile(self, top: int = 15) -> dict:
+        """Stop profiling, save the stats file and return the costliest functions."""
+        if self._profiler is None:
+            return {"type": "error", "error": "No profile is running"}
+        profiler, self._profiler = self._profiler, None
+        profiler.disable()
+
+        os.makedirs(self.profile_dir, exist_ok=True)
+        path = os.path.join(self.profile_dir, time.strftime("profile-%Y%m%d-%H%M%S.prof"))
+        profiler.dump_stats(path)
+
+        stats = pstats.Stats(profiler, stream=io.StringIO()).sort_stats("cumulative")
+        functions = []
+        for (filename, line, name), (_, calls, _, cumulative, _) in stats.stats.items():
+            functions.append(
+                {
+                    "function": f"{os.path.basename(filename)}:{line}({name})",
+                    "calls": calls,
+                    "cumulative_ms": round(cumulative * 1000, 3),
+                }
+            )
+        functions.sort(key=lambda f: f["cumulative_ms"], reverse=True)
+        return {
+            "type": "profile_result",
+            "path": path,
+            "seconds": round(time.time() - self._profile_started, 3),
+            "functions": functions[:top],
+        }
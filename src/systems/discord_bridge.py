@@ -0,0 +1,150 @@
+"""
+Discord chat bridge.
+Relays what players say in game to a Discord channel and shows that
+channel's messages in the game log as "[name] text", so the community can
+talk to people playing. It uses Discord's REST API with a bot token: a
+background thread posts outgoing lines and polls the channel for new ones,
+and the game loop collects them with poll(), so no game state is touched
+off the loop thread.
+"""
+
+import json
+import queue
+import threading
+import urllib.error
+import urllib.request
+from typing import Any, Callable, List, Optional, Tuple
+
+from core.recovery import log_exception
+
+API_URL = "https://discord.com/api/v10"
+MAX_CONTENT = 2000  # Discord's message length limit
+
+# (method, path, JSON body or None) -> decoded JSON reply
+Transport = Callable[[str, str, Optional[dict]], Any]
+
+
+class RateLimited(Exception):
+    def __init__(self, retry_after: float):
+        super().__init__(f"rate limited for {retry_after}s")
+        self.retry_after = retry_after
+
+
+def http_transport(token: str) -> Transport:
+    """Send requests to the Discord API authenticated as a bot."""
+
+    def send(method: str, path: str, body: Optional[dict] = None) -> Any:
+        data = json.dumps(body).encode() if body is not None else None
+        request = urllib.request.Request(API_URL + path, data=data, method=method)
+        request.add_header("Authorization", f"Bot {token}")
+        request.add_header("User-Agent", "TerminusRealm (discord bridge)")
+        if data is not None:
+            request.add_header("Content-Type", "application/json")
+        try:
+            with urllib.request.urlopen(request, timeout=10) as response:
+                payload = response.read()
+        except urllib.error.HTTPError as e:
+            if e.code == 429:
+                retry = json.loads(e.read() or b"{}").get("retry_after", 5)
+                raise RateLimited(float(retry)) from None
+            raise
+        return json.loads(payload) if payload else None
+
+    return send
+
+
+def format_incoming(message: dict) -> Optional[str]:
+    """A Discord message as a game log line, or None if it should not be shown."""
+    author = message.get("author") or {}
+    if author.get("bot"):
+        return None  # Includes our own relayed lines
+    content = " ".join(str(message.get("content", "")).split())
+    if not content:
+        return None
+    name = author.get("global_name") or author.get("username") or "someone"
+    return f"[{name}] {content}"
+
+
+class DiscordBridge:
+    """Relays chat between the game and one Discord channel."""
+
+    def __init__(
+        self, channel_id: str, transport: Transport, poll_seconds: float = 3.0
+    ):
+        self.channel_id = channel_id
+        self.transport = transport
+        self.poll_seconds = poll_seconds
+        self.last_id: Optional[str] = None  # Newest Discord message already seen
+        self.inbox: "queue.Queue[str]" = queue.Queue()
+        self.outbox: "queue.Queue[Tuple[str, str]]" = queue.Queue()
+        self.running = False
+        self._thread: Optional[threading.Thread] = None
+        self._wake = threading.Event()
+
+    def start(self):
+        """Skip the channel's history, then relay on a background thread."""
+        path = f"/channels/{self.channel_id}/messages?limit=1"
+        latest = self.transport("GET", path, None)
+        if latest:
+            self.last_id = latest[0]["id"]
+        self.running = True
+        self._thread = threading.Thread(target=self._run, daemon=True)
+        self._thread.start()
+
+    def stop(self):
+        self.running = False
+        self._wake.set()
+
+    def say(self, sender: str, text: str):
+        """Queue a line said in game for the Discord channel."""
+        self.outbox.put((sender, text))
+        self._wake.set()
+
+    def poll(self) -> List[str]:
+        """Log lines for Discord messages received since the last call."""
+        lines = []
+        while True:
+            try:
+                lines.append(self.inbox.get_nowait())
+            except queue.Empty:
+                return lines
+
+    def step(self):
+        """Send everything queued, then fetch new messages once."""
+        while True:
+            try:
+                sender, text = self.outbox.get_nowait()
+            except queue.Empty:
+                break
+            content = f"**{sender}**: {text}"[:MAX_CONTENT]
+            # No pings: players must not be able to @everyone the server
+            body = {"content": content, "allowed_mentions": {"parse": []}}
+            try:
+                self.transport("POST", f"/channels/{self.channel_id}/messages", body)
+            except RateLimited:
+                self.outbox.put((sender, text))  # Try again after the wait
+                raise
+
+        path = f"/channels/{self.channel_id}/messages?limit=50"
+        if self.last_id:
+            path += f"&after={self.last_id}"
+        messages = self.transport("GET", path, None) or []
+        # Discord lists newest first; snowflake IDs sort by time
+        for message in sorted(messages, key=lambda m: int(m["id"])):
+            self.last_id = message["id"]
+            line = format_incoming(message)
+            if line:
+                self.inbox.put(line)
+
+    def _run(self):
+        while self.running:
+            delay = self.poll_seconds
+            try:
+                self.step()
+            except RateLimited as e:
+                delay = max(delay, e.retry_after)
+            except Exception:
+                log_exception("discord bridge")
+                delay = max(delay, 30.0)  # Back off while Discord is unreachable
+            self._wake.wait(delay)
+            self._wake.clear()
@@ -0,0 +1,124 @@
+"""
+Economy tracking for the roguelike game.
+Records where gold enters (faucets) and leaves (sinks) the world so that
+inflation can be monitored and sinks tuned from config.
+"""
+
+from collections import defaultdict, deque
+from typing import Dict, List, Any, Optional
+
+
+# Known gold flows. Faucets create gold, sinks destroy it.
+GOLD_FAUCETS = ("starting_gold", "drop", "vendor_sale", "quest", "gambling_win")
+GOLD_SINKS = ("vendor_purchase", "vendor_fee", "repair", "tax", "travel", "gambling_loss")
+
+DEFAULT_SINK_RATES = {
+    "vendor_fee": 0.1,  # Fraction of a vendor sale price kept by the shop
+}
+
+
+class EconomyTracker:
+    """Tracks gold created and destroyed per source and samples inflation trends."""
+
+    def __init__(self, settings: Optional[Dict[str, Any]] = None):
+        settings = settings or {}
+
+        # Configurable sinks (rates are fractions, flat costs are gold)
+        self.sink_rates: Dict[str, float] = dict(DEFAULT_SINK_RATES)
+        for name in DEFAULT_SINK_RATES:
+            if name in settings:
+                self.sink_rates[name] = float(settings[name])
+
+        # Lifetime totals: source -> gold
+        self.created: Dict[str, int] = defaultdict(int)
+        self.destroyed: Dict[str, int] = defaultdict(int)
+
+        # Trend sampling
+        self.trend_period = float(settings.get("trend_period", 60.0))
+        self.trend_history: deque = deque(maxlen=int(settings.get("trend_history", 30)))
+        self._period_timer = 0.0
+        self._period_created = 0
+        self._period_destroyed = 0
+
+    @property
+    def money_supply(self) -> int:
+        """Net gold currently in circulation since tracking began."""
+        return sum(self.created.values()) - sum(self.destroyed.values())
+
+    def record_created(self, source: str, amount: int):
+        """Record gold entering the economy."""
+        if amount <= 0:
+            return
+        self.created[source] += amount
+        self._period_created += amount
+
+    def record_destroyed(self, sink: str, amount: int):
+        """Record gold leaving the economy."""
+        if amount <= 0:
+            return
+        self.destroyed[sink] += amount
+        self._period_destroyed += amount
+
+    def sink_fee(self, sink: str, gross: int) -> int:
+        """Calculate the fee a percentage sink takes from a gold amount."""
+        return int(gross * self.sink_rates.get(sink, 0.0))
+
+    def apply_sink(self, sink: str, gross: int) -> int:
+        """Apply a percentage sink to a gold amount.
+
+        Returns the amount left after the fee and records the fee as destroyed.
+        """
+        fee = self.sink_fee(sink, gross)
+        self.record_destroyed(sink, fee)
+        return gross - fee
+
+    def update(self, dt: float):
+        """Advance the trend timer and close a sample period when due."""
+        self._period_timer += dt
+        if self._period_timer >= self.trend_period:
+            self._period_timer -= self.trend_period
+            self.close_period()
+
+    def close_period(self):
+        """Store the current period's flows as a trend sample."""
+        supply = self.money_supply
+        previous = supply - self._period_created + self._period_destroyed
+        growth = (supply - previous) / previous if previous > 0 else 0.0
+
+        self.trend_history.append(
+            {
+                "created": self._period_created,
+                "destroyed": self._period_destroyed,
+                "net": self._period_created - self._period_destroyed,
+                "supply": supply,
+                "inflation": growth,
+            }
+        )
+        self._period_created = 0
+        self._period_destroyed = 0
+
+    def inflation_report(self) -> Dict[str, Any]:
+        """Summarize lifetime flows and recent inflation trends for admin tooling."""
+        samples = list(self.trend_history)
+        avg_inflation = (
+            sum(s["inflation"] for s in samples) / len(samples) if samples else 0.0
+        )
+        return {
+            "money_supply": self.money_supply,
+            "created": dict(self.created),
+            "destroyed": dict(self.destroyed),
+            "sink_rates": dict(self.sink_rates),
+            "average_inflation": avg_inflation,
+            "trend": samples,
+        }
+
+    def report_lines(self) -> List[str]:
+        """Format the inflation report as short human-readable lines."""
+        report = self.inflation_report()
+        lines = [f"Gold in circulation: {report['money_supply']}"]
+        for source, amount in sorted(report["created"].items()):
+            lines.append(f"  + {source}: {amount}")
+        for sink, amount in sorted(report["destroyed"].items()):
+            lines.append(f"  - {sink}: {amount}")
+        lines.append(f"Avg inflation/period: {report['average_inflation'] * 100:.1f}%")
+        return lines
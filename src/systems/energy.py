@@ -0,0 +1,70 @@
+"""
+Energy-based action scheduling.
+With [combat] mode = "energy" nobody acts on a fixed beat. Every creature
+gains energy in proportion to its speed (energy_per_second at speed 1), up
+to max_energy, and each action spends some: a monster thinks only once it
+has the energy for an action, and the player's world actions are refused
+until they can pay for them. A speed 2 wolf therefore acts twice for every
+step of a speed 1 player, and a cheap action (picking something up) comes
+round sooner than a dear one.
+
+Energy is worked out when it is asked for, from what was left after the
+entity last acted, so monsters nobody is watching cost nothing.
+"""
+
+from typing import Collection, Dict, Mapping, Optional, Tuple
+
+ACTION_COST = 100.0  # One ordinary action; a monster's turn costs this
+# What the player's world actions cost; others (menus, aiming) are free
+DEFAULT_COSTS = {
+    "move": ACTION_COST,
+    "cast": ACTION_COST,
+    "fire": ACTION_COST,
+    "wait": ACTION_COST,
+    "recall": ACTION_COST,
+    "craft": ACTION_COST,
+    "brew": ACTION_COST,
+    "fish": ACTION_COST,
+    "pickup": ACTION_COST / 2,
+    "stealth": ACTION_COST / 2,
+}
+
+
+class EnergyScheduler:
+    """How much energy each creature has, and whether it can afford to act."""
+
+    def __init__(self, settings: Optional[Mapping] = None):
+        settings = settings or {}
+        self.rate = float(settings.get("energy_per_second", 200.0))
+        self.max_energy = float(settings.get("max_energy", ACTION_COST))
+        self.costs: Dict[str, float] = dict(DEFAULT_COSTS)
+        self.costs.update(
+            {name: float(cost) for name, cost in settings.get("action_costs", {}).items()}
+        )
+        # Entity -> (energy left after it last acted, when); new ones start full
+        self._spent: Dict[int, Tuple[float, float]] = {}
+
+    def cost(self, action: str) -> float:
+        return self.costs.get(action, 0.0)
+
+    def energy(self, eid: int, speed: float, now: float) -> float:
+        left, since = self._spent.get(eid, (self.max_energy, now))
+        return min(self.max_energy, left + max(0.0, now - since) * self.rate * speed)
+
+    def ready(self, eid: int, speed: float, now: float, cost: float = ACTION_COST) -> bool:
+        return self.energy(eid, speed, now) >= cost
+
+    def spend(self, eid: int, speed: float, now: float, cost: float = ACTION_COST):
+        self._spent[eid] = (self.energy(eid, speed, now) - cost, now)
+
+    def wait_time(self, eid: int, speed: float, now: float, cost: float = ACTION_COST) -> float:
+        """Seconds until the entity can afford cost (0 if it already can)."""
+        short = cost - self.energy(eid, speed, now)
+        if short <= 0:
+            return 0.0
+        return short / (self.rate * speed) if speed > 0 else float("inf")
+
+    def prune(self, alive: Collection[int]):
+        """Forget entities that are gone."""
+        for eid in [eid for eid in self._spent if eid not in alive]:
+            del self._spent[eid]
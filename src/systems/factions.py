@@ -0,0 +1,125 @@
+"""
+Factions and reputation for the roguelike game.
+Faction membership, enemies and reputation tiers come from
+src/data/static/factions.json. Standing itself lives on the player's
+Reputation component; this module only interprets and adjusts it.
+"""
+
+from typing import Any, Dict, List, Optional, Tuple
+
+from data.loader import DATA_LOADER
+
+# Reputation is clamped to this range so tiers stay reachable in both directions
+REPUTATION_LIMIT = 3000
+
+
+class FactionSystem:
+    """Looks up factions and applies reputation changes."""
+
+    def __init__(self, data: Optional[Dict[str, Any]] = None):
+        if data is None:
+            try:
+                data = DATA_LOADER.load_json("factions")
+            except FileNotFoundError:
+                data = {}
+
+        # Tiers sorted from lowest to highest threshold
+        self.tiers: List[Tuple[str, int]] = sorted(
+            ((name, int(threshold)) for name, threshold in data.get("tiers", [])),
+            key=lambda tier: tier[1],
+        ) or [("neutral", 0)]
+        self.factions: Dict[str, Dict[str, Any]] = data.get("factions", {})
+
+        self._member_of: Dict[str, str] = {}
+        for faction_id, faction in self.factions.items():
+            for monster_type in faction.get("members", []):
+                self._member_of[monster_type] = faction_id
+
+    def faction_of(self, monster_type: str) -> Optional[str]:
+        """Faction a monster type belongs to, if any."""
+        return self._member_of.get(monster_type)
+
+    def name(self, faction_id: str) -> str:
+        return self.factions.get(faction_id, {}).get("name", faction_id)
+
+    def tier(self, value: int) -> str:
+        """Tier name for a reputation value; below the lowest threshold is still the lowest tier."""
+        current = self.tiers[0][0]
+        for name, threshold in self.tiers:
+            if value >= threshold:
+                current = name
+        return current
+
+    def tier_rank(self, tier_name: str) -> int:
+        """Position of a tier from lowest (0) to highest; unknown tiers rank as neutral."""
+        names = [name for name, _ in self.tiers]
+        if tier_name in names:
+            return names.index(tier_name)
+        return names.index("neutral") if "neutral" in names else 0
+
+    def standing_tier(self, standing: Dict[str, int], faction_id: str) -> str:
+        return self.tier(standing.get(faction_id, 0))
+
+    def meets(self, standing: Dict[str, int], faction_id: str, tier_name: str) -> bool:
+        """Whether standing with a faction is at least the given tier (vendor stock, quests)."""
+        if not faction_id or not tier_name:
+            return True
+        current = self.standing_tier(standing, faction_id)
+        return self.tier_rank(current) >= self.tier_rank(tier_name)
+
+    def stock_for(self, shop, standing: Dict[str, int]) -> List[Tuple[str, int]]:
+        """A shop's (item, price) list, plus restricted items the standing unlocks."""
+        stock = list(shop.items)
+        for item_name, price, tier_name in shop.restricted:
+            if self.meets(standing, shop.faction, tier_name):
+                stock.append((item_name, price))
+        return stock
+
+    def is_hostile(self, standing: Dict[str, int], faction_id: Optional[str]) -> bool:
+        """Whether a faction's members attack on sight at the current standing."""
+        faction = self.factions.get(faction_id or "")
+        if not faction or "hostile_at" not in faction:
+            return False
+        current = self.standing_tier(standing, faction_id)
+        return self.tier_rank(current) <= self.tier_rank(faction["hostile_at"])
+
+    def adjust(
+        self, standing: Dict[str, int], faction_id: str, amount: int
+    ) -> Optional[Tuple[str, str]]:
+        """Change standing with a faction; returns (old tier, new tier) if the tier changed."""
+        if faction_id not in self.factions or amount == 0:
+            return None
+        old_value = standing.get(faction_id, 0)
+        new_value = max(-REPUTATION_LIMIT, min(REPUTATION_LIMIT, old_value + amount))
+        standing[faction_id] = new_value
+
+        old_tier, new_tier = self.tier(old_value), self.tier(new_value)
+        return (old_tier, new_tier) if old_tier != new_tier else None
+
+    def on_kill(
+        self, standing: Dict[str, int], monster_type: str
+    ) -> List[Tuple[str, int, Optional[Tuple[str, str]]]]:
+        """Apply reputation for killing a monster: its faction minds, their enemies approve.
+
+        Returns (faction id, amount, tier change) for every faction affected.
+        """
+        faction_id = self.faction_of(monster_type)
+        if faction_id is None:
+            return []
+
+        faction = self.factions[faction_id]
+        changes = []
+        penalty = int(faction.get("kill_penalty", 0))
+        if penalty:
+            changes.append(
+                (faction_id, -penalty, self.adjust(standing, faction_id, -penalty))
+            )
+
+        reward = int(faction.get("enemy_reward", 0))
+        if reward:
+            for enemy_id in faction.get("enemies", []):
+                if enemy_id in self.factions:
+                    changes.append(
+                        (enemy_id, reward, self.adjust(standing, enemy_id, reward))
+                    )
+        return changes
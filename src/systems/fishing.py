@@ -0,0 +1,121 @@
+"""
+Fishing.
+A player standing by water casts a line with the fish action. The server
+decides when something bites, bite_min to bite_max seconds later, and tells
+the player; they then have reaction_window seconds (plus a little for a slow
+connection) to answer with reel. Reeling in time lands a catch; reeling too
+early, or too late, brings the line back empty. Any other world action
+(moving, fighting, casting) reels the line in.
+
+What can be caught depends on the water: catches.json holds a table per
+biome ("cave" for water below ground, "default" for the rest), and a region
+may give its own "catches". A catch whose item needs more fishing_skill than
+the player has is left out of the draw, so rare fish only come to practised
+anglers.
+"""
+
+import random
+from dataclasses import dataclass
+from typing import Any, Callable, Dict, Mapping, Optional
+
+WAITING, BITING = "waiting", "biting"
+BITE, ESCAPED = "bite", "escaped"  # What update reports
+BITE_MIN = 3.0
+BITE_MAX = 10.0
+REACTION_WINDOW = 2.0  # Seconds to reel in once something bites
+MAX_GRACE = 0.5  # Most a connection's round trip adds to the window
+FISHING_XP = 10  # Fishing XP per catch, plus what the catch needs
+
+
+class FishingError(ValueError):
+    """Fishing that cannot happen: no water, no line out."""
+
+
+@dataclass
+class Line:
+    """A line in the water and when its bite comes and goes."""
+
+    table: Dict[str, int]  # Item -> weight
+    bites_at: float
+    deadline: float  # Last moment to reel in
+    state: str = WAITING
+
+
+def draw_catch(
+    table: Mapping[str, int],
+    skill: int,
+    requirement: Callable[[str], int],
+    rng: random.Random,
+) -> Optional[str]:
+    """Pick a catch by weight from those the angler's skill can land."""
+    choices = {item: w for item, w in table.items() if w > 0 and requirement(item) <= skill}
+    if not choices:
+        return None
+    return rng.choices(list(choices), weights=list(choices.values()))[0]
+
+
+class Fishing:
+    """The player's fishing line: cast, bite, reel."""
+
+    def __init__(self, settings: Optional[Mapping] = None, rng: Optional[random.Random] = None):
+        settings = settings or {}
+        self.bite_min = float(settings.get("bite_min", BITE_MIN))
+        self.bite_max = max(self.bite_min, float(settings.get("bite_max", BITE_MAX)))
+        self.window = float(settings.get("reaction_window", REACTION_WINDOW))
+        self.rng = rng or random.Random()
+        self.line: Optional[Line] = None
+
+    @property
+    def active(self) -> bool:
+        return self.line is not None
+
+    def cast(self, table: Mapping[str, int], now: float, grace: float = 0.0) -> Line:
+        """Put a line in water with the given catch table; grace is the
+        player's round trip in seconds."""
+        if not table:
+            raise FishingError("Nothing lives in this water.")
+        bites_at = now + self.rng.uniform(self.bite_min, self.bite_max)
+        deadline = bites_at + self.window + min(MAX_GRACE, max(0.0, grace))
+        self.line = Line(dict(table), bites_at, deadline)
+        return self.line
+
+    def update(self, now: float) -> Optional[str]:
+        """BITE when something takes the bait, ESCAPED once it has got away."""
+        line = self.line
+        if line is None:
+            return None
+        if line.state == WAITING and now >= line.bites_at:
+            line.state = BITING
+            return BITE
+        if line.state == BITING and now > line.deadline:
+            self.line = None
+            return ESCAPED
+        return None
+
+    def reel(self, now: float, skill: int, requirement: Callable[[str], int]) -> Optional[str]:
+        """Reel the line in: the item caught, or None if nothing was on the hook.
+
+        Raises FishingError if there is no line out.
+        """
+        line = self.line
+        if line is None:
+            raise FishingError("You are not fishing.")
+        self.line = None
+        if not line.bites_at <= now <= line.deadline:
+            return None
+        return draw_catch(line.table, skill, requirement, self.rng)
+
+    def stop(self) -> bool:
+        """Take the line out of the water; False if there was none."""
+        had_line = self.line is not None
+        self.line = None
+        return had_line
+
+    def status(self, now: float) -> Dict[str, Any]:
+        line = self.line
+        if line is None:
+            return {"state": None}
+        status: Dict[str, Any] = {"state": line.state}
+        if line.state == BITING:
+            status["window"] = round(max(0.0, line.deadline - now), 2)
+        return status
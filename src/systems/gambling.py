@@ -0,0 +1,124 @@
+"""
+Games of chance against townsfolk.
+An NPC who keeps games ("games" in monsters.json; the innkeeper has dice
+and cards) will play the player for gold. At dice both sides roll two dice;
+at cards each draws one from a fresh deck, aces high. The higher wins even
+money and ties go to the house.
+
+Every round is decided on the server from a new random seed, and the
+outcome follows from that seed alone, so a round in the audit log can be
+dealt again with deal() to check it. Stakes must lie between min_stake and
+max_stake, and a player who has lost loss_limit gold (net of winnings) in
+the last window seconds is turned away until older rounds stop counting.
+"""
+
+import random
+import secrets
+from dataclasses import asdict, dataclass
+from typing import Any, Callable, Dict, List, Optional
+
+DEFAULT_GAMBLING = {
+    "min_stake": 1,
+    "max_stake": 100,  # Most a player may bet on one round
+    "loss_limit": 500,  # Net gold a player may lose per window
+    "window": 3600.0,  # Seconds the loss limit counts over
+}
+GAMES = ("dice", "cards")
+RANKS = "23456789TJQKA"
+SUITS = "cdhs"
+
+
+class GamblingError(ValueError):
+    """A game the house does not play."""
+
+
+@dataclass
+class Round:
+    """One round of a game: what each side rolled or drew, and who won."""
+
+    game: str
+    seed: int
+    player: List[Any]  # Dice rolled, or the card drawn (e.g. "Qs")
+    house: List[Any]
+    won: bool
+
+    def describe(self, host: str) -> str:
+        if self.game == "dice":
+            return (
+                f"You roll {' and '.join(map(str, self.player))}; "
+                f"the {host} rolls {' and '.join(map(str, self.house))}."
+            )
+        return f"You draw {self.player[0]}; the {host} draws {self.house[0]}."
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def deal(game: str, seed: int) -> Round:
+    """Play one round of game from seed; the same seed always deals the same."""
+    rng = random.Random(seed)
+    if game == "dice":
+        player = [rng.randint(1, 6), rng.randint(1, 6)]
+        house = [rng.randint(1, 6), rng.randint(1, 6)]
+        return Round(game, seed, player, house, sum(player) > sum(house))
+    if game == "cards":
+        deck = [rank + suit for rank in RANKS for suit in SUITS]
+        card, house_card = rng.sample(deck, 2)
+        won = RANKS.index(card[0]) > RANKS.index(house_card[0])
+        return Round(game, seed, [card], [house_card], won)
+    raise GamblingError(f"There is no game called {game}.")
+
+
+class Gambling:
+    """The house rules and each player's recent losses."""
+
+    def __init__(
+        self,
+        settings: Optional[Dict[str, Any]] = None,
+        seeds: Optional[Callable[[], int]] = None,
+    ):
+        self.settings = {**DEFAULT_GAMBLING, **(settings or {})}
+        self.seeds = seeds or (lambda: secrets.randbits(64))
+        # Player -> [seconds until it stops counting, gold lost] for each
+        # recent round; a round won counts as a negative loss
+        self.recent: Dict[str, List[List[float]]] = {}
+
+    def lost(self, player: str) -> int:
+        """Net gold the player has lost within the window."""
+        return max(0, int(sum(gold for _, gold in self.recent.get(player, []))))
+
+    def refusal(self, player: str, game: str, stake: int, balance: int) -> Optional[str]:
+        """Why this bet may not be placed, or None if it may."""
+        if game not in GAMES:
+            return f"There is no game called {game}."
+        if stake < int(self.settings["min_stake"]):
+            return f"Stakes start at {self.settings['min_stake']} gold."
+        if stake > int(self.settings["max_stake"]):
+            return f"The house takes bets of {self.settings['max_stake']} gold at most."
+        if stake > balance:
+            return "Not enough gold."
+        left = int(self.settings["loss_limit"]) - self.lost(player)
+        if left <= 0:
+            return "You have lost enough for now; the house will not take more."
+        if stake > left:
+            return f"You can only stake {left} more gold for now."
+        return None
+
+    def play(self, game: str) -> Round:
+        """Deal a round from a fresh seed."""
+        return deal(game, self.seeds())
+
+    def record(self, player: str, stake: int, won: bool):
+        """Count a settled round against the player's loss limit."""
+        window = float(self.settings["window"])
+        self.recent.setdefault(player, []).append([window, -stake if won else stake])
+
+    def update(self, dt: float):
+        """Let rounds older than the window stop counting."""
+        for player in list(self.recent):
+            rounds = self.recent[player]
+            for entry in rounds:
+                entry[0] -= dt
+            rounds[:] = [entry for entry in rounds if entry[0] > 0]
+            if not rounds:
+                del self.recent[player]
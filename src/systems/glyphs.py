@@ -0,0 +1,66 @@
+"""
+Icon validation for entity glyphs.
+Monsters, items and lights take their icon from content files, and one
+stray control character or a multi-codepoint emoji (a ZWJ family, a skin
+tone, a flag) can shift every following column in a terminal client. Icons
+are therefore checked when content is loaded and normalized when an entity
+is made: an icon must be a single character from the allowed Unicode
+categories, optionally followed by one variation selector, and anything
+else is replaced by a fallback glyph for its kind.
+"""
+
+import unicodedata
+from typing import Optional
+
+# Letters, numbers, punctuation and symbols (emoji are So); never controls,
+# format characters, private use, unassigned code points or lone marks
+ALLOWED_CATEGORIES = ("L", "N", "P", "S")
+# Text (FE0E) or emoji (FE0F) presentation; terminals draw either as one glyph
+VARIATION_SELECTORS = ("\ufe0e", "\ufe0f")
+
+# What an entity of each kind is drawn as when its icon is refused
+FALLBACK_GLYPHS = {
+    "monsters": "👾",
+    "items": "❔",
+    "lights": "🔥",
+    "player": "🧙",
+}
+DEFAULT_FALLBACK = "?"
+
+
+class GlyphError(ValueError):
+    """An icon that is not a single renderable glyph."""
+
+
+def check_icon(icon) -> str:
+    """The icon in NFC form, or GlyphError saying why it cannot be drawn."""
+    if not isinstance(icon, str) or not icon:
+        raise GlyphError("icon must be a non-empty string")
+    # Composes e + combining accent into é, so it counts as one character
+    text = unicodedata.normalize("NFC", icon)
+    base, rest = text[0], text[1:]
+    if unicodedata.category(base)[0] not in ALLOWED_CATEGORIES:
+        name = unicodedata.name(base, f"U+{ord(base):04X}")
+        raise GlyphError(f"icon character {name} cannot be drawn")
+    if rest and rest not in VARIATION_SELECTORS:
+        raise GlyphError("icon must be a single character")
+    return text
+
+
+def normalize_icon(icon, kind: str = "") -> str:
+    """The icon if it checks out, else the fallback glyph for its kind."""
+    try:
+        return check_icon(icon)
+    except GlyphError:
+        return FALLBACK_GLYPHS.get(kind, DEFAULT_FALLBACK)
+
+
+def terminal_glyph(icon: str, name: Optional[str] = None) -> str:
+    """One printable ASCII character for clients that cannot show emoji:
+    the icon itself when it is ASCII, else the first letter of the name."""
+    if len(icon) == 1 and icon.isascii() and icon.isprintable() and icon.strip():
+        return icon
+    for char in name or "":
+        if char.isascii() and char.isalnum():
+            return char
+    return DEFAULT_FALLBACK
@@ -0,0 +1,80 @@
+"""
+Home points for the roguelike game.
+A player binds their home at an inn or a shrine by interacting with it; on
+death they are resurrected there rather than in the starter town, and the
+recall ability takes them home from anywhere on a long cooldown. Each world
+keeps its own home, and both the homes and when recall is next ready are
+saved with the player's profile.
+"""
+
+import time
+from dataclasses import asdict, dataclass
+from typing import Any, Dict, Iterable, Optional
+
+RECALL_COOLDOWN = 1800.0  # Real seconds between recalls
+BIND_REACH = 1  # Tiles from an inn's keeper or a shrine's altar to bind
+
+
+@dataclass
+class HomePoint:
+    """A place a player can be bound to."""
+
+    name: str
+    kind: str  # "inn" or "shrine"
+    x: int
+    y: int
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "HomePoint":
+        return cls(data["name"], data["kind"], int(data["x"]), int(data["y"]))
+
+
+def bind_point_near(
+    points: Iterable[HomePoint], x: int, y: int, reach: int = BIND_REACH
+) -> Optional[HomePoint]:
+    """The bindable place within reach of (x, y), if any."""
+    for point in points:
+        if max(abs(point.x - x), abs(point.y - y)) <= reach:
+            return point
+    return None
+
+
+class Homes:
+    """One player's home in each world and their recall cooldown."""
+
+    def __init__(self, cooldown: float = RECALL_COOLDOWN):
+        self.cooldown = cooldown
+        self.points: Dict[str, HomePoint] = {}
+        self.recall_ready_at = 0.0
+
+    def bind(self, world: str, point: HomePoint):
+        self.points[world] = point
+
+    def home(self, world: str) -> Optional[HomePoint]:
+        return self.points.get(world)
+
+    def recall_remaining(self, now: Optional[float] = None) -> float:
+        """Seconds until recall can be used again; 0 when it is ready."""
+        now = time.time() if now is None else now
+        return max(0.0, self.recall_ready_at - now)
+
+    def use_recall(self, now: Optional[float] = None):
+        """Start the recall cooldown."""
+        now = time.time() if now is None else now
+        self.recall_ready_at = now + self.cooldown
+
+    def to_profile(self) -> Dict[str, Any]:
+        return {
+            "points": {world: p.to_dict() for world, p in self.points.items()},
+            "recall_ready_at": self.recall_ready_at,
+        }
+
+    def from_profile(self, data: Dict[str, Any]):
+        self.points = {
+            world: HomePoint.from_dict(point)
+            for world, point in data.get("points", {}).items()
+        }
+        self.recall_ready_at = float(data.get("recall_ready_at", 0.0))
@@ -0,0 +1,90 @@
+"""
+Idempotency keys for state-changing requests.
+A client that retries after a dropped connection cannot tell whether its
+first gamble or text_command went through. It may therefore put a key of its
+own choosing on any state-changing request:
+
+    {"type": "gamble", "game": "dice", "stake": 50, "idempotency_key": "b71e..."}
+
+The first message with a key runs as usual and its reply is kept for the
+dedupe window; the same message sent again with that key inside the window
+gets the kept reply (marked "duplicate": true) and is not run again. A key
+sent with a different message is refused rather than guessed at. Error
+replies are not kept, so a refused request can be retried with its key.
+
+Only requests are covered, and only those registered with changes_state.
+Actions (craft, brew, stash, pickup, buying and the rest) have no message
+to key: they run from the local keyboard, which never retries, or reach a
+client as a text_command request, which is keyed like any other. A client
+that wants a retried craft or purchase to run once must send it as a keyed
+text_command. Lines typed over telnet carry no key and are not covered.
+"""
+
+import json
+import time
+from collections import OrderedDict
+from typing import Any, Callable, Dict, Optional, Tuple
+
+KEY_FIELD = "idempotency_key"
+MAX_KEY_LENGTH = 64
+WINDOW_SECONDS = 300.0  # How long a reply is kept for retries
+MAX_KEYS = 1024  # Oldest keys are forgotten beyond this
+
+
+class IdempotencyError(ValueError):
+    """A key that is malformed or was already used for another message."""
+
+
+def fingerprint(message: Dict[str, Any]) -> str:
+    """The message without its key, in a form that compares reliably."""
+    body = {name: value for name, value in message.items() if name != KEY_FIELD}
+    return json.dumps(body, sort_keys=True, default=str)
+
+
+class IdempotencyCache:
+    """Replies to keyed requests, kept for the dedupe window."""
+
+    def __init__(
+        self,
+        window: float = WINDOW_SECONDS,
+        limit: int = MAX_KEYS,
+        clock: Callable[[], float] = time.monotonic,
+    ):
+        self.window = window
+        self.limit = limit
+        self.clock = clock
+        # Key -> (when, fingerprint, reply), oldest first
+        self.replies: "OrderedDict[str, Tuple[float, str, dict]]" = OrderedDict()
+        self.duplicates = 0  # Retries answered from the cache
+
+    def replay(self, key: Any, message: Dict[str, Any]) -> Optional[dict]:
+        """The kept reply if this message was already handled, else None.
+
+        Raises IdempotencyError for a bad key or one used for another message.
+        """
+        if not isinstance(key, str) or not 0 < len(key) <= MAX_KEY_LENGTH:
+            raise IdempotencyError(
+                f"{KEY_FIELD} must be a string of 1 to {MAX_KEY_LENGTH} characters"
+            )
+        self.expire()
+        kept = self.replies.get(key)
+        if kept is None:
+            return None
+        if kept[1] != fingerprint(message):
+            raise IdempotencyError(f"{KEY_FIELD} {key!r} was used for another message")
+        self.duplicates += 1
+        return dict(kept[2], duplicate=True)
+
+    def remember(self, key: str, message: Dict[str, Any], reply: dict):
+        """Keep a reply for retries of this message; errors are not kept."""
+        if reply.get("type") == "error":
+            return
+        self.replies[key] = (self.clock(), fingerprint(message), reply)
+        self.replies.move_to_end(key)
+        while len(self.replies) > self.limit:
+            self.replies.popitem(last=False)
+
+    def expire(self):
+        cutoff = self.clock() - self.window
+        while self.replies and next(iter(self.replies.values()))[0] < cutoff:
+            self.replies.popitem(last=False)
@@ -0,0 +1,172 @@
+"""
+Item identification and curses for the roguelike game.
+Some gear that monsters drop comes out unidentified: it shows only what kind
+of thing it is ("Unidentified Iron Sword") until a scroll of identify or a
+town sage reveals its real name, rarity and stats. A share of unidentified
+drops are cursed with a harmful affix, and a cursed item that is put on
+binds to its wearer: it will not come off again until the curse is lifted
+with a scroll of remove curse or by a sage.
+"""
+
+import random
+from typing import Any, Dict, List, Optional
+
+from core.ecs import EntityManager
+from entities.components import (
+    ArmorStats,
+    Cursed,
+    Equipment,
+    Inventory,
+    Item,
+    Render,
+    Unidentified,
+    WeaponStats,
+)
+
+UNIDENTIFIED_CHANCE = 0.5  # Dropped gear that comes out unidentified
+CURSE_CHANCE = 0.2  # Unidentified gear that is also cursed
+UNIDENTIFIED_COLOR = (190, 190, 190)  # Hides the rarity color until identified
+
+# Harmful affixes only ever found on cursed gear
+CURSED_WEAPON_AFFIXES = {
+    "Blighted": {"attack": -3},
+    "Wretched": {"attack": -2},
+    "of Frailty": {"attack": -4},
+}
+CURSED_ARMOR_AFFIXES = {
+    "Cracked": {"defense": -2},
+    "Rotting": {"defense": -3},
+    "of Brittleness": {"defense": -4},
+}
+
+
+def is_identified(entity_manager: EntityManager, item_id: int) -> bool:
+    return not entity_manager.has_component(item_id, Unidentified)
+
+
+def is_cursed(entity_manager: EntityManager, item_id: int) -> bool:
+    return entity_manager.has_component(item_id, Cursed)
+
+
+def carried_items(entity_manager: EntityManager, eid: int) -> List[int]:
+    """Everything eid is wearing, then everything in their pack."""
+    items = []
+    equipment = entity_manager.get_component(eid, Equipment)
+    if equipment:
+        slots = (equipment.weapon, equipment.head, equipment.body, equipment.legs)
+        items.extend(i for i in slots + (equipment.shield,) if i is not None)
+    inventory = entity_manager.get_component(eid, Inventory)
+    if inventory:
+        items.extend(inventory.items)
+    return items
+
+
+def curse(
+    entity_manager: EntityManager, item_id: int, rng: Optional[random.Random] = None
+) -> Optional[str]:
+    """Give a weapon or armor a harmful affix and a curse; returns the affix."""
+    rng = rng or random.Random()
+    item = entity_manager.get_component(item_id, Item)
+    weapon = entity_manager.get_component(item_id, WeaponStats)
+    armor = entity_manager.get_component(item_id, ArmorStats)
+    if not item or not (weapon or armor):
+        return None
+
+    table = CURSED_WEAPON_AFFIXES if weapon else CURSED_ARMOR_AFFIXES
+    affix = rng.choice(sorted(table))
+    if weapon:
+        weapon.attack_power = max(0, weapon.attack_power + table[affix]["attack"])
+    else:
+        armor.defense = max(0, armor.defense + table[affix]["defense"])
+    # Suffixes read "Iron Sword of Frailty", prefixes "Blighted Iron Sword"
+    if affix.startswith("of "):
+        item.name = f"{item.name} {affix}"
+    else:
+        item.name = f"{affix} {item.name}"
+    item.affixes.append(affix)
+    entity_manager.add_component(item_id, Cursed(affix))
+    return affix
+
+
+def conceal(entity_manager: EntityManager, item_id: int, base_name: str):
+    """Hide an item's real name behind what kind of thing it is."""
+    item = entity_manager.get_component(item_id, Item)
+    if not item or not is_identified(entity_manager, item_id):
+        return
+    entity_manager.add_component(item_id, Unidentified(item.name, item.color))
+    item.name = f"Unidentified {base_name}"
+    _recolor(entity_manager, item_id, UNIDENTIFIED_COLOR)
+
+
+def roll_drop(
+    entity_manager: EntityManager,
+    item_id: int,
+    base_name: str,
+    rng: Optional[random.Random] = None,
+) -> bool:
+    """Maybe leave a freshly dropped piece of gear unidentified, and maybe cursed.
+
+    Returns whether it was left unidentified.
+    """
+    rng = rng or random.Random()
+    gear = entity_manager.has_component(
+        item_id, WeaponStats
+    ) or entity_manager.has_component(item_id, ArmorStats)
+    if not gear or rng.random() >= UNIDENTIFIED_CHANCE:
+        return False
+    if rng.random() < CURSE_CHANCE:
+        curse(entity_manager, item_id, rng)
+    conceal(entity_manager, item_id, base_name)
+    return True
+
+
+def identify(entity_manager: EntityManager, item_id: int) -> bool:
+    """Reveal an item's real name; False if there was nothing to reveal."""
+    hidden = entity_manager.get_component(item_id, Unidentified)
+    item = entity_manager.get_component(item_id, Item)
+    if not hidden or not item:
+        return False
+    item.name = hidden.name
+    _recolor(entity_manager, item_id, hidden.color)
+    entity_manager.remove_component(item_id, Unidentified)
+    return True
+
+
+def _recolor(entity_manager: EntityManager, item_id: int, color):
+    entity_manager.get_component(item_id, Item).color = color
+    render = entity_manager.get_component(item_id, Render)
+    if render:
+        render.fg_color = color
+
+
+def remove_curse(entity_manager: EntityManager, item_id: int) -> bool:
+    """Lift a curse so the item can be taken off; its harmful affix stays."""
+    if not is_cursed(entity_manager, item_id):
+        return False
+    entity_manager.remove_component(item_id, Cursed)
+    return True
+
+
+def item_details(entity_manager: EntityManager, item_id: int) -> Dict[str, Any]:
+    """What a player can tell about an item, for payloads.
+
+    An unidentified item shows its kind and nothing else: no rarity, affixes,
+    stats or curse.
+    """
+    item = entity_manager.get_component(item_id, Item)
+    if not is_identified(entity_manager, item_id):
+        return {"name": item.name, "identified": False}
+    details = {
+        "name": item.name,
+        "identified": True,
+        "rarity": item.rarity,
+        "affixes": list(item.affixes),
+        "cursed": is_cursed(entity_manager, item_id),
+    }
+    weapon = entity_manager.get_component(item_id, WeaponStats)
+    armor = entity_manager.get_component(item_id, ArmorStats)
+    if weapon:
+        details["attack"] = weapon.attack_power
+    if armor:
+        details["defense"] = armor.defense
+    return details
@@ -0,0 +1,40 @@
+"""
+Sequence numbers for client-side prediction.
+A predicting client numbers each move it sends and applies it locally at
+once. Every player_update the server sends back acknowledges the highest
+sequence number it has processed along with the authoritative position, so
+the client drops the inputs up to the ack, snaps to the server's position
+and replays the rest, without guessing which of its moves have landed.
+
+Sequence numbers are per client (a client picks an id for its session), so
+a reconnecting client can start again from 1. A move that arrives with a
+number already processed is a duplicate or arrived late; it is acknowledged
+but not applied again.
+"""
+
+from collections import OrderedDict
+from typing import Any
+
+MAX_CLIENTS = 64  # Least recently active clients are forgotten beyond this
+
+
+class InputSequencer:
+    """The last processed sequence number of each client."""
+
+    def __init__(self):
+        self.last: "OrderedDict[str, int]" = OrderedDict()
+
+    def accept(self, client: str, seq: Any) -> bool:
+        """Record seq as processed for client; False if it already was (don't apply)."""
+        if isinstance(seq, bool) or not isinstance(seq, int) or seq < 1:
+            raise ValueError("seq must be a positive whole number")
+        previous = self.last.get(client, 0)
+        self.last[client] = max(previous, seq)
+        self.last.move_to_end(client)
+        while len(self.last) > MAX_CLIENTS:
+            self.last.popitem(last=False)
+        return seq > previous
+
+    def ack(self, client: str) -> int:
+        """The highest sequence number processed for client (0 before any)."""
+        return self.last.get(client, 0)
@@ -0,0 +1,72 @@
+"""
+Latency measurement and clock synchronization.
+Clients send ping messages with their own timestamp and get back a pong
+carrying it along with the server's time and tick, so they can work out the
+round trip and their offset from server time (offset = server_time minus the
+midpoint of sending and receiving) and interpolate between tick updates.
+Each ping may also report the round trip the client measured last time,
+which the server keeps per player for the who list and runtime stats.
+
+Telnet clients cannot ping, so their round trip is read from the kernel's
+own TCP estimate for the connection where the platform offers one.
+"""
+
+import socket
+import struct
+from typing import Dict, Optional
+
+SMOOTHING = 0.125  # Weight of each new sample, as in TCP's RTT estimator
+MAX_RTT_MS = 60_000.0  # Reports above a minute are nonsense and refused
+
+# struct tcp_info on Linux: eight u8 fields, then u32s; tcpi_rtt is the 16th
+_TCP_INFO = struct.Struct("8B24I")
+_TCP_INFO_RTT = 8 + 15
+
+
+def tcp_rtt_ms(sock: socket.socket) -> Optional[float]:
+    """The kernel's smoothed round trip for a TCP socket, or None if unavailable."""
+    option = getattr(socket, "TCP_INFO", None)
+    if option is None:
+        return None
+    try:
+        raw = sock.getsockopt(socket.IPPROTO_TCP, option, _TCP_INFO.size)
+    except OSError:
+        return None
+    if len(raw) < _TCP_INFO.size:
+        return None
+    return round(_TCP_INFO.unpack(raw)[_TCP_INFO_RTT] / 1000, 3)  # Microseconds
+
+
+class LatencyTracker:
+    """Smoothed round trip and jitter per player, from the RTTs clients report."""
+
+    def __init__(self):
+        self.players: Dict[str, Dict[str, float]] = {}
+
+    def record(self, who: str, rtt_ms: float):
+        rtt_ms = float(rtt_ms)
+        if not 0 <= rtt_ms <= MAX_RTT_MS:
+            raise ValueError(f"rtt_ms must be between 0 and {MAX_RTT_MS:g}")
+        stats = self.players.get(who)
+        if stats is None:
+            self.players[who] = {"rtt_ms": rtt_ms, "jitter_ms": rtt_ms / 2, "samples": 1}
+            return
+        # RFC 6298: jitter follows the deviation from the old average
+        stats["jitter_ms"] += SMOOTHING * (abs(rtt_ms - stats["rtt_ms"]) - stats["jitter_ms"])
+        stats["rtt_ms"] += SMOOTHING * (rtt_ms - stats["rtt_ms"])
+        stats["samples"] += 1
+
+    def rtt_ms(self, who: str) -> Optional[float]:
+        stats = self.players.get(who)
+        return round(stats["rtt_ms"], 3) if stats else None
+
+    def summary(self) -> Dict[str, Dict[str, float]]:
+        """Each player's smoothed RTT, jitter and sample count, for runtime stats."""
+        return {
+            who: {
+                "rtt_ms": round(stats["rtt_ms"], 3),
+                "jitter_ms": round(stats["jitter_ms"], 3),
+                "samples": int(stats["samples"]),
+            }
+            for who, stats in self.players.items()
+        }
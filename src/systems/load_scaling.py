@@ -0,0 +1,86 @@
+"""
+View scaling under load.
+When ticks start running long or each telnet client is being sent more than
+its share of bandwidth, the server shrinks how much it does per client: the
+map view sent with each reply gets smaller and monsters far from the player
+get less AI time each tick (the cells beside the player are never shed, see
+AISystem). The scale steps down once per second while the server is over a
+threshold, and back up only after it has stayed comfortably under both for a
+while, so a single slow tick does not make views flicker.
+"""
+
+from typing import Any, Dict, Mapping, Optional
+
+WINDOW = 1.0  # Seconds of ticks and traffic judged together
+SMOOTHING = 0.2  # Weight of each tick's time in the running average
+RECOVER_FRACTION = 0.6  # Load must drop below this share of a limit to recover
+
+
+class ViewScaler:
+    """Works out how much of the full view and AI budget the server can afford."""
+
+    def __init__(self, settings: Optional[Mapping[str, Any]] = None):
+        settings = settings or {}
+        self.enabled = bool(settings.get("enabled", True))
+        self.tick_ms = float(settings.get("tick_ms", 12.0))  # Smoothed tick time limit
+        # Bytes per second each telnet client may be sent before views shrink
+        self.client_bytes = float(settings.get("client_kb_per_second", 32.0)) * 1024
+        self.min_scale = float(settings.get("min_scale", 0.5))
+        self.step = float(settings.get("step", 0.1))
+        self.recover_after = float(settings.get("recover_seconds", 10.0))
+        self.scale = 1.0
+        self.tick_avg_ms = 0.0
+        self.client_rate = 0.0  # Bytes per second per client over the last window
+        self.reductions = 0  # Times the scale has stepped down
+        self._elapsed = 0.0
+        self._sent = 0.0  # Bytes per client this window
+        self._calm = 0.0  # Seconds spent under both limits since the last change
+
+    def record_tick(self, seconds: float):
+        """Note how long one fixed update took."""
+        self.tick_avg_ms += SMOOTHING * (seconds * 1000 - self.tick_avg_ms)
+
+    def record_sent(self, nbytes: int, clients: int):
+        """Note bytes sent to clients this frame, shared among clients."""
+        if clients > 0:
+            self._sent += nbytes / clients
+
+    def update(self, dt: float) -> bool:
+        """Step the scale once a window has passed; True if it changed."""
+        self._elapsed += dt
+        if not self.enabled or self._elapsed < WINDOW:
+            return False
+        self.client_rate = self._sent / self._elapsed
+        self._elapsed = self._sent = 0.0
+
+        over = self.tick_avg_ms > self.tick_ms or self.client_rate > self.client_bytes
+        if over:
+            self._calm = 0.0
+            if self.scale <= self.min_scale:
+                return False
+            self.scale = max(self.min_scale, round(self.scale - self.step, 3))
+            self.reductions += 1
+            return True
+
+        calm = (
+            self.tick_avg_ms < self.tick_ms * RECOVER_FRACTION
+            and self.client_rate < self.client_bytes * RECOVER_FRACTION
+        )
+        self._calm = self._calm + WINDOW if calm else 0.0
+        if self._calm < self.recover_after or self.scale >= 1.0:
+            return False
+        self._calm = 0.0
+        self.scale = min(1.0, round(self.scale + self.step, 3))
+        return True
+
+    def scaled(self, full: float, minimum: int = 1) -> int:
+        """A view size or radius shrunk by the current scale, never below minimum."""
+        return max(minimum, int(round(full * self.scale)))
+
+    def status(self) -> Dict[str, Any]:
+        return {
+            "scale": self.scale,
+            "tick_avg_ms": round(self.tick_avg_ms, 3),
+            "client_bytes_per_second": round(self.client_rate),
+            "reductions": self.reductions,
+        }
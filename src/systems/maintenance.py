@@ -0,0 +1,80 @@
+"""
+Scheduled restarts for maintenance.
+A restart counts down to a deadline: players are warned at set times
+before it, new logins are refused over the last stretch, and when it
+expires the game saves everything, disconnects clients with the reason and
+exits cleanly, so a supervisor can bring it back without anyone watching.
+"""
+
+import time
+from datetime import datetime, timedelta
+from typing import Callable, List, Optional, Sequence
+
+WARNINGS = (600, 300, 60, 30, 10)  # Seconds before the restart to warn players
+
+
+def describe(seconds: float) -> str:
+    """A duration the way a warning reads it: "5 minutes", "30 seconds"."""
+    seconds = max(0, round(seconds))
+    if seconds >= 60 and seconds % 60 == 0:
+        minutes = seconds // 60
+        return f"{minutes} minute{'s' if minutes != 1 else ''}"
+    return f"{seconds} second{'s' if seconds != 1 else ''}"
+
+
+def next_daily(hhmm: str, now: float) -> float:
+    """The next time (after now) the local clock reads hhmm, as a timestamp."""
+    hour, minute = (int(part) for part in hhmm.split(":"))
+    current = datetime.fromtimestamp(now)
+    target = current.replace(hour=hour, minute=minute, second=0, microsecond=0)
+    if target.timestamp() <= now:
+        target += timedelta(days=1)
+    return target.timestamp()
+
+
+class ScheduledRestart:
+    """Countdown to one restart."""
+
+    def __init__(
+        self,
+        deadline: float,
+        reason: str = "",
+        warnings: Sequence[float] = WARNINGS,
+        close_logins: float = 60.0,
+        clock: Callable[[], float] = time.time,
+    ):
+        self.deadline = deadline
+        self.reason = reason
+        self.close_logins = close_logins  # Seconds before the deadline logins stop
+        self.clock = clock
+        # Warnings that still lie ahead; any already passed are not replayed
+        left = self.seconds_left()
+        self.warnings: List[float] = sorted(
+            (w for w in warnings if w < left), reverse=True
+        )
+
+    def seconds_left(self) -> float:
+        return max(0.0, self.deadline - self.clock())
+
+    @property
+    def expired(self) -> bool:
+        return self.clock() >= self.deadline
+
+    @property
+    def logins_closed(self) -> bool:
+        return self.seconds_left() <= self.close_logins
+
+    def warnings_due(self) -> List[str]:
+        """Warning lines whose time has come since the last call (usually none)."""
+        left = self.seconds_left()
+        texts = []
+        while self.warnings and left <= self.warnings[0]:
+            self.warnings.pop(0)
+            texts.append(self.message(left))
+        # Only the most recent matters when several were skipped at once
+        return texts[-1:]
+
+    def message(self, left: Optional[float] = None) -> str:
+        left = self.seconds_left() if left is None else left
+        text = f"The server restarts in {describe(left)}."
+        return f"{text} {self.reason}" if self.reason else text
@@ -0,0 +1,109 @@
+"""
+Vendor markets for the roguelike game.
+Each town keeps its own market, and what its shops charge and pay drifts
+with local supply: every item sold to a shop in town leaves a surplus that
+lowers its price there, and every item bought away leaves a shortage that
+raises it. Surpluses and shortages wear off as play goes on.
+
+Towns also differ from the start. Every town has goods it is rich in and
+goods it lacks, fixed by its name, so the same ore or sword is cheap in one
+town and dear in another, and it pays to haul goods along the roads. Selling
+a whole load in one town floods its market, so a trader spreads their sales.
+
+Market state is kept per world, in a JSON file beside its roster of players.
+"""
+
+import random
+from typing import Dict, Optional
+
+from systems.profile import load_profile, save_profile
+
+PRICE_STEP = 0.05  # Price change per item of surplus or shortage
+REGIONAL_SPREAD = 0.25  # How far a town's own prices stray from the base
+MIN_FACTOR = 0.5
+MAX_FACTOR = 2.0
+RECOVERY_PERIOD = 120.0  # Seconds for a surplus or shortage to ease by one item
+
+
+def regional_bias(town: str, item_type: str) -> float:
+    """How much richer (negative) or poorer (positive) in an item a town is."""
+    rng = random.Random(f"{town}:{item_type}")
+    return rng.uniform(-REGIONAL_SPREAD, REGIONAL_SPREAD)
+
+
+class Market:
+    """Supply and demand for one town's shops."""
+
+    def __init__(self, town: str, supply: Optional[Dict[str, int]] = None):
+        self.town = town
+        self.supply: Dict[str, int] = dict(supply or {})  # + surplus, - shortage
+
+    def factor(self, item_type: str) -> float:
+        """What an item costs here as a multiple of its usual price."""
+        factor = 1.0 + regional_bias(self.town, item_type)
+        factor -= PRICE_STEP * self.supply.get(item_type, 0)
+        return max(MIN_FACTOR, min(MAX_FACTOR, factor))
+
+    def buy_price(self, item_type: str, base: int) -> int:
+        """What the shop charges for an item it lists at base."""
+        return max(1, round(base * self.factor(item_type)))
+
+    def sell_price(self, item_type: str, value: int) -> int:
+        """What the shop pays, before its fee, for an item worth value."""
+        return max(1, int(value // 2 * self.factor(item_type)))
+
+    def sold(self, item_type: str):
+        """An item was sold to a shop here."""
+        self._shift(item_type, 1)
+
+    def bought(self, item_type: str):
+        """An item was bought from a shop here."""
+        self._shift(item_type, -1)
+
+    def _shift(self, item_type: str, amount: int):
+        supply = self.supply.get(item_type, 0) + amount
+        if supply:
+            self.supply[item_type] = supply
+        else:
+            self.supply.pop(item_type, None)
+
+    def recover(self):
+        """Ease every surplus and shortage by one item."""
+        for item_type, supply in list(self.supply.items()):
+            self._shift(item_type, -1 if supply > 0 else 1)
+
+
+class Markets:
+    """Every town's market in one world."""
+
+    def __init__(self, path: Optional[str] = None, recovery: float = RECOVERY_PERIOD):
+        self.path = path
+        self.recovery = recovery
+        self.markets: Dict[str, Market] = {}
+        self._timer = 0.0
+
+    def market(self, town: str) -> Market:
+        if town not in self.markets:
+            self.markets[town] = Market(town)
+        return self.markets[town]
+
+    def update(self, dt: float):
+        """Let surpluses and shortages wear off as time passes."""
+        self._timer += dt
+        while self.recovery > 0 and self._timer >= self.recovery:
+            self._timer -= self.recovery
+            for market in self.markets.values():
+                market.recover()
+
+    def load(self):
+        data = load_profile(self.path) if self.path else {}
+        self.markets = {
+            town: Market(town, {item: int(n) for item, n in supply.items()})
+            for town, supply in data.get("towns", {}).items()
+        }
+
+    def save(self):
+        if not self.path:
+            return
+        towns = {town: m.supply for town, m in self.markets.items() if m.supply}
+        save_profile(self.path, {"towns": towns})
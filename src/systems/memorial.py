@@ -0,0 +1,89 @@
+"""
+Hardcore memorial for the roguelike game.
+Under the hardcore ruleset death is permanent: the fallen character is
+archived here (never deleted) and the player starts over with a new one.
+The archive doubles as a leaderboard of the best characters that died.
+"""
+
+import time
+from typing import Any, Dict, List, Optional
+
+from core.ecs import EntityManager
+from entities.components import Inventory, Level, Name, Reputation, Skills
+from systems.profile import load_profile, save_profile
+
+
+def character_record(
+    entity_manager: EntityManager,
+    eid: int,
+    cause: str,
+    profile: Optional[Dict[str, Any]] = None,
+) -> Dict[str, Any]:
+    """Snapshot a character at the moment of death for the archive."""
+    name = entity_manager.get_component(eid, Name)
+    level = entity_manager.get_component(eid, Level)
+    skills = entity_manager.get_component(eid, Skills)
+    inventory = entity_manager.get_component(eid, Inventory)
+    reputation = entity_manager.get_component(eid, Reputation)
+
+    return {
+        "name": name.value if name else "Unknown",
+        "level": level.current_level if level else 1,
+        "xp": level.current_xp if level else 0,
+        "skills": {
+            "melee": skills.melee,
+            "distance": skills.distance,
+            "magic": skills.magic,
+            "swimming": skills.swimming,
+            "stealth": skills.stealth,
+        }
+        if skills
+        else {},
+        "gold": inventory.gold if inventory else 0,
+        "reputation": dict(reputation.standing) if reputation else {},
+        "cause": cause,
+        "died_at": time.time(),
+        "profile": dict(profile or {}),
+    }
+
+
+class Memorial:
+    """Archive of dead hardcore characters, stored as a JSON file."""
+
+    def __init__(self, path: str, size: int = 10):
+        self.path = path
+        self.size = size  # Entries shown on the leaderboard
+
+    def characters(self) -> List[Dict[str, Any]]:
+        """Every archived character, oldest first."""
+        return load_profile(self.path).get("characters", [])
+
+    def archive(self, record: Dict[str, Any]) -> int:
+        """Add a fallen character; returns its rank on the leaderboard (1 = best)."""
+        data = load_profile(self.path)
+        characters = data.setdefault("characters", [])
+        record = dict(record, character=len(characters) + 1)
+        characters.append(record)
+        save_profile(self.path, data)
+        return self._ranked(characters).index(record) + 1
+
+    def leaderboard(self) -> dict:
+        """Best fallen characters, highest level (then XP) first."""
+        entries = [
+            {
+                "rank": rank,
+                "name": record["name"],
+                "character": record.get("character", 0),
+                "level": record["level"],
+                "xp": record["xp"],
+                "cause": record["cause"],
+            }
+            for rank, record in enumerate(self._ranked(self.characters())[: self.size], 1)
+        ]
+        return {"type": "memorial", "entries": entries}
+
+    @staticmethod
+    def _ranked(characters: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        return sorted(
+            characters, key=lambda r: (r["level"], r["xp"], -r["died_at"]), reverse=True
+        )
@@ -0,0 +1,103 @@
+"""
+Spoken descriptions of the player's surroundings for the roguelike game.
+Players using a screen reader can switch on a describe mode, kept in their
+profile, in which the game puts what they can see into plain sentences: "A
+goblin stands two tiles north." The engine gathers what is in the field of
+view and this module turns it into words, nearest things first. While the
+mode is on, the game reports what changed around the player after each
+step, and player updates carry the whole description.
+"""
+
+from dataclasses import dataclass
+from typing import List, Optional, Sequence
+
+from input.text_commands import direction_name
+
+NUMBER_WORDS = (
+    "no", "one", "two", "three", "four", "five",
+    "six", "seven", "eight", "nine", "ten",
+)
+MAX_CREATURES = 6  # Nearest creatures described; the rest are only counted
+MAX_ITEMS = 4
+
+
+@dataclass
+class Seen:
+    """Something in view, as far as describing it goes."""
+
+    name: str
+    dx: int
+    dy: int
+    proper: bool = False  # A proper name ("Ashford Smithy") takes no article
+
+
+def distance_words(dx: int, dy: int) -> str:
+    """How far and which way: "two tiles north", "beside you to the east"."""
+    distance = max(abs(dx), abs(dy))
+    where = direction_name(dx, dy)
+    if distance == 0:
+        return "here"
+    if distance == 1:
+        return f"beside you to the {where}"
+    count = NUMBER_WORDS[distance] if distance < len(NUMBER_WORDS) else str(distance)
+    return f"{count} tiles {where}"
+
+
+def noun(thing: Seen) -> str:
+    """A thing's name with an article: "a goblin", "an iron sword"."""
+    if thing.proper:
+        return thing.name
+    name = thing.name.lower()
+    return f"{'an' if name[:1] in 'aeiou' else 'a'} {name}"
+
+
+def sentence(text: str) -> str:
+    return text[:1].upper() + text[1:] + "."
+
+
+def describe(
+    place: str,
+    ground: str,
+    creatures: Sequence[Seen],
+    items: Sequence[Seen],
+    blocked: Sequence[str],
+) -> List[str]:
+    """The surroundings as sentences, nearest things first."""
+    lines = [f"You are in {place}, on {ground}."]
+
+    def nearest(thing: Seen):
+        return max(abs(thing.dx), abs(thing.dy))
+
+    creatures = sorted(creatures, key=nearest)
+    for creature in creatures[:MAX_CREATURES]:
+        where = distance_words(creature.dx, creature.dy)
+        lines.append(sentence(f"{noun(creature)} stands {where}"))
+    if len(creatures) > MAX_CREATURES:
+        more = len(creatures) - MAX_CREATURES
+        kind = "creature" if more == 1 else "creatures"
+        lines.append(f"{more} more {kind} further off.")
+
+    for item in sorted(items, key=nearest)[:MAX_ITEMS]:
+        lines.append(sentence(f"{noun(item)} lies {distance_words(item.dx, item.dy)}"))
+
+    if blocked:
+        ways = list(blocked)
+        listed = ways[-1]
+        if len(ways) > 1:
+            listed = f"{', '.join(ways[:-1])} and {listed}"
+        lines.append(f"The way is blocked to the {listed}.")
+    return lines
+
+
+class Narrator:
+    """One player's describe mode and what they were last told."""
+
+    def __init__(self):
+        self.enabled = False
+        self.last: List[str] = []
+
+    def changes(self, lines: List[str]) -> Optional[List[str]]:
+        """The lines that are new since the last description, or None."""
+        new = [line for line in lines if line not in self.last]
+        self.last = list(lines)
+        return new or None
@@ -0,0 +1,49 @@
+"""
+Traffic statistics for one client connection.
+The telnet gateway keeps one of these per connection: bytes each way, how
+many lines of each kind the client sent (by command word) and how many
+messages it was sent. Together with the connection's idle time and its
+outbound queue's high-water mark and drops, they let a GM spot a client
+flooding the server or one starved of bandwidth (see the netstat request
+and text command).
+"""
+
+import threading
+from typing import Any, Dict
+
+MAX_KINDS = 32  # Distinct command words counted; the rest go under "other"
+
+
+class ConnectionStats:
+    """Bytes and messages through one connection, safe to read from any thread."""
+
+    def __init__(self):
+        self._lock = threading.Lock()
+        self.bytes_in = 0
+        self.bytes_out = 0
+        self.messages_in: Dict[str, int] = {}
+        self.messages_out = 0
+
+    def received(self, nbytes: int, line: str = ""):
+        """Count a line from the client, by its first word."""
+        words = line.split(None, 1)
+        kind = words[0].lower()[:16] if words else "blank"
+        with self._lock:
+            self.bytes_in += nbytes
+            if kind not in self.messages_in and len(self.messages_in) >= MAX_KINDS:
+                kind = "other"
+            self.messages_in[kind] = self.messages_in.get(kind, 0) + 1
+
+    def sent(self, nbytes: int):
+        with self._lock:
+            self.bytes_out += nbytes
+            self.messages_out += 1
+
+    def snapshot(self) -> Dict[str, Any]:
+        with self._lock:
+            return {
+                "bytes_in": self.bytes_in,
+                "bytes_out": self.bytes_out,
+                "messages_in": dict(self.messages_in),
+                "messages_out": self.messages_out,
+            }
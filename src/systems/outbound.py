@@ -0,0 +1,118 @@
+"""
+Prioritized outbound queue for one client connection.
+Everything sent to a telnet client goes through its queue and is written by
+the connection's own writer thread, so a client that reads slowly only ever
+holds up itself. Each message has a priority:
+
+    critical  sign-in, errors, disconnect notices and replies to commands
+              (which carry combat results)
+    normal    chat and announcements
+    bulk      map views
+
+Messages go out in the order they were queued, so a reply still reads below
+its map view; priority decides what is given up when a client falls behind.
+A new map view replaces one still waiting, since only the latest matters,
+and when the queue is over its limit the oldest bulk messages are dropped
+first, then normal ones; critical messages are never dropped.
+"""
+
+import threading
+import time
+from collections import deque
+from typing import Any, Deque, Dict, Optional, Tuple
+
+CRITICAL, NORMAL, BULK = "critical", "normal", "bulk"
+PRIORITIES = (CRITICAL, NORMAL, BULK)
+MAX_QUEUED = 32  # Messages waiting before lower priorities are dropped
+
+
+class OutboundQueue:
+    """Messages waiting to be written to one client, and what may be dropped."""
+
+    def __init__(self, limit: int = MAX_QUEUED):
+        self.limit = limit
+        self._queue: Deque[Tuple[str, bytes]] = deque()
+        self._lock = threading.Condition()
+        self._writing = False  # The writer holds a message it has not finished
+        self.closed = False
+        self.dropped: Dict[str, int] = {NORMAL: 0, BULK: 0}
+        self.coalesced = 0  # Views replaced by a newer one before going out
+        self.high_water = 0  # Most messages ever waiting at once
+
+    def __len__(self) -> int:
+        with self._lock:
+            return len(self._queue)
+
+    def put(self, data: bytes, priority: str = NORMAL) -> bool:
+        """Queue data to send; False if the queue has been closed."""
+        if priority not in PRIORITIES:
+            raise ValueError(f"Unknown priority {priority!r}")
+        with self._lock:
+            if self.closed:
+                return False
+            if priority == BULK:
+                waiting = len(self._queue)
+                self._queue = deque(m for m in self._queue if m[0] != BULK)
+                self.coalesced += waiting - len(self._queue)
+            self._queue.append((priority, data))
+            self.high_water = max(self.high_water, len(self._queue))
+            while len(self._queue) > self.limit and self._drop_one():
+                pass
+            self._lock.notify_all()
+            return True
+
+    def get(self) -> Optional[bytes]:
+        """Block for the next message to write; None once closed and drained.
+
+        Call done() after writing it, so flush knows it has left.
+        """
+        with self._lock:
+            while not self._queue and not self.closed:
+                self._lock.wait()
+            if not self._queue:
+                return None
+            _, data = self._queue.popleft()
+            self._writing = True
+            return data
+
+    def done(self):
+        with self._lock:
+            self._writing = False
+            self._lock.notify_all()
+
+    def flush(self, timeout: float) -> bool:
+        """Wait until everything queued has been written; False on timeout."""
+        deadline = time.monotonic() + timeout
+        with self._lock:
+            while self._queue or self._writing:
+                remaining = deadline - time.monotonic()
+                if remaining <= 0:
+                    return False
+                self._lock.wait(remaining)
+            return True
+
+    def close(self, discard: bool = False):
+        """Refuse new messages; the writer finishes what is queued unless discard."""
+        with self._lock:
+            self.closed = True
+            if discard:
+                self._queue.clear()
+            self._lock.notify_all()
+
+    def stats(self) -> Dict[str, Any]:
+        with self._lock:
+            return {
+                "queued": len(self._queue),
+                "high_water": self.high_water,
+                "dropped": dict(self.dropped),
+                "coalesced": self.coalesced,
+            }
+
+    def _drop_one(self) -> bool:
+        for priority in (BULK, NORMAL):
+            for i, (queued, _) in enumerate(self._queue):
+                if queued == priority:
+                    del self._queue[i]
+                    self.dropped[priority] += 1
+                    return True
+        return False
@@ -0,0 +1,37 @@
+"""
+Per-player profile persistence.
+Progress that belongs to the player rather than the world (reputation and
+similar standing) is kept in a small JSON file, written atomically so a crash
+mid-save never leaves a truncated profile behind.
+"""
+
+import json
+import os
+from typing import Any, Dict
+
+
+def load_profile(path: str) -> Dict[str, Any]:
+    """Read a player profile, or an empty one if none exists or it is unreadable."""
+    if not path or not os.path.exists(path):
+        return {}
+    try:
+        with open(path, "r", encoding="utf-8") as f:
+            data = json.load(f)
+    except (OSError, ValueError) as e:
+        print(f"Could not read player profile {path}: {e}")
+        return {}
+    return data if isinstance(data, dict) else {}
+
+
+def save_profile(path: str, data: Dict[str, Any]):
+    """Write a player profile via a temporary file and an atomic rename."""
+    directory = os.path.dirname(path)
+    if directory:
+        os.makedirs(directory, exist_ok=True)
+
+    tmp_path = f"{path}.tmp"
+    with open(tmp_path, "w", encoding="utf-8") as f:
+        json.dump(data, f, indent=2, sort_keys=True)
+        f.flush()
+        os.fsync(f.fileno())
+    os.replace(tmp_path, path)
@@ -0,0 +1,118 @@
+"""
+Replay recording and playback.
+A replay log is a JSON-lines file: a header with the random seed and the
+player's starting profile, then every inbound input and request stamped
+with the simulation tick it arrived on, interleaved with the world events
+(log messages) the game produced. Playing a log back seeds a fresh game the
+same way and feeds the inputs in on the same ticks; the recorded events are
+compared as the game logs them so the first point of divergence is reported.
+"""
+
+import json
+import os
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional
+
+REPLAY_VERSION = 1
+INBOUND = ("input", "request")
+
+
+class ReplayRecorder:
+    """Appends a game's inbound messages and world events to a replay log."""
+
+    def __init__(self, path: str, seed: int, profile: Optional[Dict[str, Any]] = None):
+        self.path = path
+        directory = os.path.dirname(path)
+        if directory:
+            os.makedirs(directory, exist_ok=True)
+        self._file = open(path, "w", encoding="utf-8")
+        self._append(
+            {"kind": "header", "version": REPLAY_VERSION, "seed": seed, "profile": profile or {}}
+        )
+
+    def _append(self, record: Dict[str, Any]):
+        if self._file.closed:
+            return
+        self._file.write(json.dumps(record) + "\n")
+        self._file.flush()
+
+    def input(self, tick: int, event: Any):
+        """Record a player input event (an InputEvent dataclass)."""
+        self._append({"tick": tick, "kind": "input", "event": asdict(event)})
+
+    def request(self, tick: int, message: Dict[str, Any]):
+        """Record a request message from a client."""
+        self._append({"tick": tick, "kind": "request", "message": message})
+
+    def event(self, tick: int, text: str):
+        """Record something the world reported."""
+        self._append({"tick": tick, "kind": "event", "text": text})
+
+    def close(self):
+        self._file.close()
+
+
+@dataclass
+class Replay:
+    """A loaded replay log."""
+
+    seed: int
+    profile: Dict[str, Any] = field(default_factory=dict)
+    entries: List[Dict[str, Any]] = field(default_factory=list)
+
+
+def load_replay(path: str) -> Replay:
+    """Read a replay log; a torn final line (from a crash) is skipped."""
+    with open(path, "r", encoding="utf-8") as f:
+        records = []
+        for line in f:
+            try:
+                records.append(json.loads(line))
+            except json.JSONDecodeError:
+                continue
+
+    if not records or records[0].get("kind") != "header":
+        raise ValueError(f"{path} is not a replay log")
+    header = records[0]
+    if header.get("version") != REPLAY_VERSION:
+        raise ValueError(f"Unsupported replay version {header.get('version')}")
+    return Replay(int(header["seed"]), header.get("profile", {}), records[1:])
+
+
+class ReplayPlayer:
+    """Feeds a replay's inbound messages back tick by tick and checks its events."""
+
+    def __init__(self, replay: Replay):
+        self.replay = replay
+        self.inbound = [e for e in replay.entries if e.get("kind") in INBOUND]
+        self.events = [e for e in replay.entries if e.get("kind") == "event"]
+        self._next_inbound = 0
+        self._next_event = 0
+        self.divergence: Optional[str] = None
+
+    @property
+    def finished(self) -> bool:
+        return self._next_inbound >= len(self.inbound)
+
+    def due(self, tick: int) -> List[Dict[str, Any]]:
+        """Inbound entries recorded on or before a tick that have not been played yet."""
+        start = self._next_inbound
+        while self._next_inbound < len(self.inbound) and self.inbound[self._next_inbound]["tick"] <= tick:
+            self._next_inbound += 1
+        return self.inbound[start : self._next_inbound]
+
+    def check_event(self, tick: int, text: str) -> Optional[str]:
+        """Compare a logged event with the recording; returns a description of the first divergence."""
+        if self.divergence:
+            return None
+        if self._next_event >= len(self.events):
+            self.divergence = f"tick {tick}: unexpected '{text}' after the recording ended"
+            return self.divergence
+
+        expected = self.events[self._next_event]
+        self._next_event += 1
+        if expected["text"] != text or expected["tick"] != tick:
+            self.divergence = (
+                f"tick {tick}: expected '{expected['text']}' (tick {expected['tick']}), got '{text}'"
+            )
+        return self.divergence
@@ -0,0 +1,322 @@
+"""
+Content scripts.
+NPC talk callbacks ("on_talk" in monsters.json) and item on-use effects
+("on_use" in items.json) are written as small scripts in the content files
+rather than in engine code. A script is a list of steps; each step calls one
+effect from a fixed API, optionally guarded by conditions or a chance:
+
+    [{"do": "say", "lines": ["Mind the lava."]},
+     {"do": "drop_item", "item": "health_potion", "chance": 0.05},
+     {"if": {"not_flag": "met_elder"},
+      "then": [{"do": "give_gold", "amount": 10},
+               {"do": "set_flag", "flag": "met_elder"}]}]
+
+Scripts are data, never executed as code, so they can only do what the API
+below offers: no loops, no file or network access, nothing outside the
+player and the NPC or item the script belongs to. Scripts are checked
+against the API before they run and whenever content is reloaded, so a
+bad one is reported instead of reaching a running game.
+"""
+
+import random
+from typing import Any, Dict, List, Optional, Tuple
+
+from core.ecs import EntityManager
+
+# Effect -> fields it needs
+EFFECTS: Dict[str, Tuple[str, ...]] = {
+    "log": ("text",),
+    "say": ("lines",),
+    "float_text": ("text",),
+    "heal": ("amount",),
+    "damage": ("amount",),
+    "give_gold": ("amount",),
+    "give_xp": ("amount",),
+    "give_item": ("item",),
+    "drop_item": ("item",),
+    "set_flag": ("flag",),
+    "clear_flag": ("flag",),
+    "cutscene": ("name",),
+    "identify": (),
+    "remove_curse": (),
+    "summon": ("creature",),
+}
+OPTIONAL_FIELDS = {
+    "log": ("color",),
+    "say": ("color",),
+    "float_text": ("color",),
+    "identify": ("count", "price"),
+    "remove_curse": ("price",),
+}
+WHOLE_FIELDS = ("amount", "count", "price")
+STEP_FIELDS = ("do", "if", "chance")
+CONDITIONS = ("flag", "not_flag", "min_level", "has_item")
+MAX_STEPS = 64  # Steps in one script, counting nested branches
+MAX_DEPTH = 4  # Nested if/then levels
+
+DEFAULT_COLOR = (200, 200, 200)
+SAY_COLOR = (150, 255, 150)
+
+
+class ScriptError(ValueError):
+    """A script that uses something outside the API or is malformed."""
+
+
+def validate_script(steps: Any, where: str = "script"):
+    """Check a script against the API, raising ScriptError on the first problem."""
+    count = _validate_steps(steps, where, 0)
+    if count > MAX_STEPS:
+        raise ScriptError(f"{where}: {count} steps is more than {MAX_STEPS}")
+
+
+def _validate_steps(steps: Any, where: str, depth: int) -> int:
+    if depth > MAX_DEPTH:
+        raise ScriptError(f"{where}: branches nested too deeply")
+    if not isinstance(steps, list):
+        raise ScriptError(f"{where}: expected a list of steps")
+    count = 0
+    for i, step in enumerate(steps):
+        here = f"{where}[{i}]"
+        if not isinstance(step, dict):
+            raise ScriptError(f"{here}: a step must be a table")
+        count += 1
+        condition = step.get("if", {})
+        if not isinstance(condition, dict):
+            raise ScriptError(f"{here}: 'if' must be a table of conditions")
+        for name in condition:
+            if name not in CONDITIONS:
+                raise ScriptError(f"{here}: unknown condition '{name}'")
+        chance = step.get("chance", 1.0)
+        if isinstance(chance, bool) or not isinstance(chance, (int, float)):
+            raise ScriptError(f"{here}: chance must be a number")
+
+        if "do" in step:
+            effect = step["do"]
+            if effect not in EFFECTS:
+                raise ScriptError(f"{here}: unknown effect '{effect}'")
+            for field in EFFECTS[effect]:
+                if field not in step:
+                    raise ScriptError(f"{here}: {effect} needs '{field}'")
+            allowed = STEP_FIELDS + EFFECTS[effect] + OPTIONAL_FIELDS.get(effect, ())
+            for field in step:
+                if field not in allowed:
+                    raise ScriptError(f"{here}: {effect} does not take '{field}'")
+            for field in WHOLE_FIELDS:
+                value = step.get(field, 0)
+                if isinstance(value, bool) or not isinstance(value, int):
+                    raise ScriptError(f"{here}: {field} must be a whole number")
+        elif "then" in step or "else" in step:
+            for branch in ("then", "else"):
+                if branch in step:
+                    where_branch = f"{here}.{branch}"
+                    count += _validate_steps(step[branch], where_branch, depth + 1)
+        else:
+            raise ScriptError(f"{here}: a step needs 'do' or 'then'")
+    return count
+
+
+class ScriptHost:
+    """The API scripts run against, bound to the player and the script's owner.
+
+    actor is the player running the script; source is the NPC or item it
+    belongs to. The engine provides logging, items, gold and XP.
+    """
+
+    def __init__(self, engine, actor: int, source: Optional[int] = None):
+        self.engine = engine
+        self.entities: EntityManager = engine.entity_manager
+        self.actor = actor
+        self.source = source
+
+    def text(self, template: str) -> str:
+        """Fill in {name} (the NPC or item) and {player}; nothing else is expanded."""
+        from entities.components import Name
+
+        def name_of(eid):
+            name = self.entities.get_component(eid, Name) if eid is not None else None
+            return name.value if name else ""
+
+        return (
+            str(template)
+            .replace("{name}", name_of(self.source) or "Someone")
+            .replace("{player}", name_of(self.actor) or "you")
+        )
+
+    # Conditions
+
+    def check(self, condition: Dict[str, Any]) -> bool:
+        from entities.components import Inventory, Item, Level
+
+        for name, value in condition.items():
+            if name == "flag" and value not in self.engine.script_flags:
+                return False
+            if name == "not_flag" and value in self.engine.script_flags:
+                return False
+            if name == "min_level":
+                level = self.entities.get_component(self.actor, Level)
+                if (level.current_level if level else 1) < value:
+                    return False
+            if name == "has_item":
+                inventory = self.entities.get_component(self.actor, Inventory)
+                types = {
+                    getattr(self.entities.get_component(eid, Item), "item_type", "")
+                    for eid in (inventory.items if inventory else [])
+                }
+                if value not in types:
+                    return False
+        return True
+
+    # Effects
+
+    def log(self, text: str, color=DEFAULT_COLOR):
+        self.engine.log(self.text(text), tuple(color))
+
+    def say(self, lines: List[str], color=SAY_COLOR):
+        line = random.choice(lines) if lines else ""
+        self.engine.log(self.text(f"{{name}} says: '{line}'"), tuple(color))
+
+    def float_text(self, text: str, color=(255, 255, 255)):
+        from entities.components import Position
+
+        pos = self.entities.get_component(self.source, Position)
+        if pos:
+            self.engine.vfx_system.add_floating_text(
+                pos.x, pos.y, self.text(text), tuple(color)
+            )
+
+    def heal(self, amount: int):
+        from entities.components import Health
+
+        health = self.entities.get_component(self.actor, Health)
+        if health:
+            health.current = min(health.maximum, health.current + amount)
+
+    def damage(self, amount: int):
+        from entities.components import Health
+
+        health = self.entities.get_component(self.actor, Health)
+        if health:
+            health.current -= amount
+            if health.current <= 0 and self.actor == self.engine.player_id:
+                self.engine.respawn_player(self.text("slain by {name}"))
+
+    def give_gold(self, amount: int):
+        from entities.components import Inventory
+
+        inventory = self.entities.get_component(self.actor, Inventory)
+        if inventory and amount > 0:
+            self.engine.transaction("script").add_gold(inventory, amount).commit()
+            self.engine.economy.record_created("script", amount)
+
+    def give_xp(self, amount: int):
+        self.engine.gain_xp(self.actor, amount)
+
+    def give_item(self, item: str):
+        """Put an item in the player's pack, or at their feet if it will not fit."""
+        from entities.components import Inventory, Position
+        from systems.transactions import TransactionError
+
+        inventory = self.entities.get_component(self.actor, Inventory)
+        pos = self.entities.get_component(self.actor, Position)
+        if inventory and pos:
+            eid = self.engine.entity_wrapper.factory.create_item(pos.x, pos.y, item)
+            try:
+                self.engine.transaction("script").pickup_item(inventory, eid).commit()
+            except TransactionError:
+                pass
+
+    def drop_item(self, item: str):
+        """Put an item on the ground at the script owner's (or player's) feet."""
+        from entities.components import Position
+
+        pos = self.entities.get_component(self.source, Position)
+        pos = pos or self.entities.get_component(self.actor, Position)
+        if pos:
+            self.engine.entity_wrapper.factory.create_item(pos.x, pos.y, item)
+
+    def set_flag(self, flag: str):
+        self.engine.script_flags.add(str(flag))
+
+    def clear_flag(self, flag: str):
+        self.engine.script_flags.discard(str(flag))
+
+    def cutscene(self, name: str):
+        """Play a cutscene from cutscenes.json, with the script's owner as its subject."""
+        self.engine.play_cutscene(str(name), self.source)
+
+    def identify(self, count: int = 0, price: int = 0):
+        """Identify up to count of the player's items (0 for all), at price each."""
+        from entities.components import Item
+        from systems.identification import carried_items, identify, is_identified
+
+        items = [
+            eid
+            for eid in carried_items(self.entities, self.actor)
+            if not is_identified(self.entities, eid)
+        ]
+        if not items:
+            self.engine.log("You have nothing that needs identifying.", DEFAULT_COLOR)
+        for eid in items[:count] if count > 0 else items:
+            if not self.pay(price, "identify"):
+                break
+            identify(self.entities, eid)
+            name = self.entities.get_component(eid, Item).name
+            self.engine.log(f"It is {name}.", (180, 220, 255))
+
+    def remove_curse(self, price: int = 0):
+        """Lift the curse from each of the player's cursed items, at price each."""
+        from entities.components import Item
+        from systems.identification import carried_items, is_cursed, remove_curse
+
+        items = [
+            eid
+            for eid in carried_items(self.entities, self.actor)
+            if is_cursed(self.entities, eid)
+        ]
+        if not items:
+            self.engine.log("You feel no curse upon you.", DEFAULT_COLOR)
+        for eid in items:
+            if not self.pay(price, "remove_curse"):
+                break
+            remove_curse(self.entities, eid)
+            name = self.entities.get_component(eid, Item).name
+            self.engine.log(f"The curse on {name} lifts.", (180, 220, 255))
+
+    def summon(self, creature: str):
+        """Call up a creature from monsters.json to fight beside the player."""
+        self.engine.summon_ally(self.actor, str(creature))
+
+    def pay(self, price: int, reason: str) -> bool:
+        """Take a service's price from the player; False if they cannot afford it."""
+        from entities.components import Inventory
+        from systems.transactions import TransactionError
+
+        if price <= 0:
+            return True
+        inventory = self.entities.get_component(self.actor, Inventory)
+        if not inventory or inventory.gold < price:
+            self.engine.log(f"That costs {price} gold, which you lack.", DEFAULT_COLOR)
+            return False
+        try:
+            self.engine.transaction(reason).remove_gold(inventory, price).commit()
+        except TransactionError:
+            return False
+        self.engine.economy.record_destroyed(reason, price)
+        return True
+
+
+def run_script(steps: List[Dict[str, Any]], host: ScriptHost):
+    """Carry out a validated script against host."""
+    for step in steps:
+        if not host.check(step.get("if", {})):
+            if "else" in step:
+                run_script(step["else"], host)
+            continue
+        if random.random() >= step.get("chance", 1.0):
+            continue
+        if "do" in step:
+            effect = step["do"]
+            args = {k: v for k, v in step.items() if k not in STEP_FIELDS}
+            getattr(host, effect)(**args)
+        else:
+            run_script(step.get("then", []), host)
@@ -0,0 +1,111 @@
+"""
+Seasonal ladders for the roguelike game.
+Play is split into seasons of fixed real-world length. Characters are
+stamped with the season they were created in, and may be seasonal-only.
+When a season ends its ladder standings are archived, a new season starts
+and seasonal characters merge into the permanent pool.
+"""
+
+import time
+from typing import Any, Callable, Dict, List, Optional
+
+from systems.profile import load_profile, save_profile
+
+DAY = 86400.0
+
+
+class SeasonSystem:
+    """Tracks the current season, its ladder and the archive of past ones."""
+
+    def __init__(
+        self,
+        path: Optional[str],
+        settings: Optional[Dict[str, Any]] = None,
+        clock: Callable[[], float] = time.time,
+    ):
+        settings = settings or {}
+        self.path = path
+        self.length = float(settings.get("length_days", 90)) * DAY
+        self.ladder_size = int(settings.get("ladder_size", 10))
+        self.seasonal_by_default = bool(settings.get("seasonal_characters", False))
+        self.clock = clock
+
+        self.data = load_profile(path) if path else {}
+        if "season" not in self.data:
+            self._start(1, self.clock())
+
+    @property
+    def season_id(self) -> int:
+        return self.data["season"]["id"]
+
+    def _start(self, season_id: int, started_at: float):
+        self.data["season"] = {
+            "id": season_id,
+            "started_at": started_at,
+            "ends_at": started_at + self.length,
+        }
+        self.data["ladder"] = {}
+        self.data.setdefault("archive", [])
+        self._save()
+
+    def _save(self):
+        if not self.path:
+            return
+        try:
+            save_profile(self.path, self.data)
+        except OSError as e:
+            print(f"Could not save seasons: {e}")
+
+    def record(self, character: str, level: int, xp: int, seasonal: bool):
+        """Update a character's ladder standing for the current season."""
+        self.data["ladder"][character] = {"level": level, "xp": xp, "seasonal": seasonal}
+        self._save()
+
+    def ladder(self, standings: Optional[Dict[str, Dict[str, Any]]] = None) -> List[Dict[str, Any]]:
+        """Standings ranked by level then XP, up to the ladder size."""
+        standings = self.data["ladder"] if standings is None else standings
+        ranked = sorted(
+            standings.items(), key=lambda item: (item[1]["level"], item[1]["xp"]), reverse=True
+        )
+        return [
+            dict(standing, rank=rank, name=name)
+            for rank, (name, standing) in enumerate(ranked[: self.ladder_size], 1)
+        ]
+
+    def rollover(self) -> Optional[int]:
+        """End every season whose time is up; returns the last ended season's id, if any."""
+        now = self.clock()
+        ended = None
+        while now >= self.data["season"]["ends_at"]:
+            season = self.data["season"]
+            self.data["archive"].append(
+                {
+                    "id": season["id"],
+                    "started_at": season["started_at"],
+                    "ended_at": season["ends_at"],
+                    "ladder": self.ladder(),
+                }
+            )
+            ended = season["id"]
+            self._start(season["id"] + 1, season["ends_at"])
+        return ended
+
+    def season_info(self) -> dict:
+        """Current season, time left and ladder, plus the ids of archived seasons."""
+        season = self.data["season"]
+        return {
+            "type": "season_info",
+            "season": season["id"],
+            "started_at": season["started_at"],
+            "ends_at": season["ends_at"],
+            "remaining": max(0.0, season["ends_at"] - self.clock()),
+            "ladder": self.ladder(),
+            "past_seasons": [past["id"] for past in self.data["archive"]],
+        }
+
+    def archived_ladder(self, season_id: int) -> Optional[List[Dict[str, Any]]]:
+        """Final standings of a past season."""
+        for past in self.data["archive"]:
+            if past["id"] == season_id:
+                return past["ladder"]
+        return None
@@ -0,0 +1,151 @@
+"""
+Player settings.
+Preferences that follow the player rather than the world, kept in the player
+profile beside reputation and homes and read or changed with the settings
+and set_settings requests (or the "settings" and "set" text commands):
+
+    chat_filter  words masked in chat relayed from outside the game
+    auto_loot    pick up whatever lies on a tile the player steps onto
+    locale       language tag clients should present text in ("en", "pt-BR")
+    colorblind   shift terminal map colours for protanopia, deuteranopia or
+                 tritanopia, so red and green (or blue and yellow) differ
+    update_rate  how often terminal clients are sent the map: "normal" with
+                 every reply, "low" at most every few seconds, "text" never
+
+The server applies what it can (the filter, auto-loot, colours and map rate
+for telnet clients); locale is kept for clients to act on.
+"""
+
+import re
+from functools import lru_cache
+from typing import Any, Dict, List, Mapping, Tuple
+
+DEFAULTS: Dict[str, Any] = {
+    "chat_filter": [],
+    "auto_loot": False,
+    "locale": "en",
+    "colorblind": "none",
+    "update_rate": "normal",
+}
+COLORBLIND_MODES = ("none", "protanopia", "deuteranopia", "tritanopia")
+UPDATE_RATES = ("normal", "low", "text")
+LOW_RATE_SECONDS = 3.0  # Least time between map views at the "low" rate
+MAX_FILTER_WORDS = 20
+MAX_WORD_LENGTH = 24
+LOCALE_PATTERN = re.compile(r"^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$")
+
+# How each kind of colour blindness sees an RGB colour (Viénot, Brettel and
+# Mollon), and how the colours it loses are pushed into ones it can tell apart
+SIMULATION = {
+    "protanopia": ((0.567, 0.433, 0.0), (0.558, 0.442, 0.0), (0.0, 0.242, 0.758)),
+    "deuteranopia": ((0.625, 0.375, 0.0), (0.7, 0.3, 0.0), (0.0, 0.3, 0.7)),
+    "tritanopia": ((0.95, 0.05, 0.0), (0.0, 0.433, 0.567), (0.0, 0.475, 0.525)),
+}
+SHIFT = ((0.0, 0.0, 0.0), (0.7, 1.0, 0.0), (0.7, 0.0, 1.0))
+
+
+class SettingsError(ValueError):
+    """A setting that does not exist, or a value it cannot take."""
+
+
+def _check(name: str, value: Any) -> Any:
+    """The value a setting should store, or SettingsError saying why not."""
+    if name not in DEFAULTS:
+        raise SettingsError(f"unknown setting {name!r}")
+    if name == "auto_loot":
+        if not isinstance(value, bool):
+            raise SettingsError("auto_loot must be true or false")
+        return value
+    if name == "chat_filter":
+        if isinstance(value, str):
+            value = value.replace(",", " ").split()
+        if not isinstance(value, list) or not all(isinstance(w, str) for w in value):
+            raise SettingsError("chat_filter must be a list of words")
+        words = sorted({w.strip().lower() for w in value if w.strip()})
+        if len(words) > MAX_FILTER_WORDS:
+            raise SettingsError(f"chat_filter holds at most {MAX_FILTER_WORDS} words")
+        if any(len(w) > MAX_WORD_LENGTH for w in words):
+            raise SettingsError(f"filter words are at most {MAX_WORD_LENGTH} letters")
+        return words
+    if not isinstance(value, str):
+        raise SettingsError(f"{name} must be a string")
+    if name == "locale":
+        if not LOCALE_PATTERN.match(value):
+            raise SettingsError(f"{value!r} is not a language tag such as en or pt-BR")
+        return value
+    choices = COLORBLIND_MODES if name == "colorblind" else UPDATE_RATES
+    value = value.lower()
+    if value not in choices:
+        raise SettingsError(f"{name} must be one of {', '.join(choices)}")
+    return value
+
+
+class PlayerSettings:
+    """One player's settings, checked on the way in."""
+
+    def __init__(self):
+        self.values: Dict[str, Any] = dict(DEFAULTS)
+        self._filter = None
+
+    def __getitem__(self, name: str) -> Any:
+        return self.values[name]
+
+    def update(self, changes: Mapping[str, Any]) -> List[str]:
+        """Apply several settings at once; if any is refused, none change.
+
+        Returns the names whose value actually changed.
+        """
+        if not isinstance(changes, Mapping):
+            raise SettingsError("settings must be an object of name: value")
+        checked = {str(name): _check(str(name), value) for name, value in changes.items()}
+        changed = [name for name, value in checked.items() if self.values[name] != value]
+        self.values.update(checked)
+        if "chat_filter" in changed:
+            self._filter = None
+        return changed
+
+    def snapshot(self) -> Dict[str, Any]:
+        return {
+            name: list(value) if isinstance(value, list) else value
+            for name, value in self.values.items()
+        }
+
+    def filter_chat(self, text: str) -> str:
+        """Text with the player's filtered words masked."""
+        words = self.values["chat_filter"]
+        if not words:
+            return text
+        if self._filter is None:
+            alternatives = "|".join(re.escape(word) for word in words)
+            self._filter = re.compile(rf"\b({alternatives})\b", re.IGNORECASE)
+        return self._filter.sub(lambda match: "*" * len(match.group()), text)
+
+    def color(self, rgb: Tuple[int, int, int]) -> Tuple[int, int, int]:
+        """A map colour as it should be drawn for this player."""
+        mode = self.values["colorblind"]
+        return rgb if mode == "none" else daltonize(tuple(rgb), mode)
+
+    def from_profile(self, data: Mapping[str, Any]):
+        """Restore saved settings, skipping any that no longer check out."""
+        self.values = dict(DEFAULTS)
+        self._filter = None
+        for name, value in (data or {}).items():
+            try:
+                self.values[name] = _check(name, value)
+            except SettingsError:
+                pass
+
+    def to_profile(self) -> Dict[str, Any]:
+        return {name: value for name, value in self.values.items() if value != DEFAULTS[name]}
+
+
+@lru_cache(maxsize=4096)
+def daltonize(rgb: Tuple[int, int, int], mode: str) -> Tuple[int, int, int]:
+    """Shift a colour so someone with the given colour blindness can tell it
+    from its neighbours: what they cannot see is moved into what they can."""
+    seen = [sum(m * c for m, c in zip(row, rgb)) for row in SIMULATION[mode]]
+    lost = [c - s for c, s in zip(rgb, seen)]
+    return tuple(
+        max(0, min(255, int(round(c + sum(m * e for m, e in zip(row, lost))))))
+        for c, row in zip(rgb, SHIFT)
+    )
@@ -0,0 +1,46 @@
+"""
+The account stash for the roguelike game.
+Unlike a character's bank vault, the stash belongs to the account: every
+character on it, in any world and including whoever comes after a hardcore
+death, reaches the same stash at any banker. It holds items only, and never
+bound ones, so materials can be passed between alts without a trade.
+
+While the game runs, stashed items are ordinary item entities with no
+position, held in the stash as a BankAccount holds its items, so stash
+moves are item transactions like any other. The stash file keeps them
+serialized between sessions.
+"""
+
+from typing import Any, Dict, List
+
+from core.ecs import EntityManager
+from systems.profile import load_profile, save_profile
+
+STASH_CAPACITY = 40
+
+
+class AccountStash:
+    """The items an account shares between its characters."""
+
+    def __init__(self, path: str, capacity: int = STASH_CAPACITY):
+        self.path = path
+        self.capacity = capacity
+        self.items: List[int] = []
+        self.gold = 0  # Items only; transactions expect every container to have gold
+
+    def load(self, entity_manager: EntityManager):
+        """Recreate the stashed items as entities with fresh IDs."""
+        self.items = []
+        for record in load_profile(self.path).get("items", []):
+            record = dict(record, eid=entity_manager.next_id)
+            self.items.append(entity_manager.restore_entity(record))
+
+    def save(self, entity_manager: EntityManager):
+        if not self.path:
+            return
+        records: List[Dict[str, Any]] = [
+            entity_manager.serialize_entity(eid)
+            for eid in self.items
+            if eid in entity_manager.entities
+        ]
+        save_profile(self.path, {"items": records})
@@ -0,0 +1,587 @@
+"""
+Telnet gateway for classic terminal play.
+An optional listener on its own port lets a bare telnet client play through
+text commands. Each line a client types is handed to the game loop, which
+runs it like any other text_command and answers with the logged output and
+an ANSI-coloured view of the map, rendered here on the game side.
+
+Connections are served on background threads, but commands only ever run
+on the game loop thread (see poll), so no game state is touched concurrently.
+Replies are held until every line waiting that tick has run, then all go out
+with the same map snapshot, so the view is drawn at most once per tick no
+matter how many clients are typing.
+
+Every connection drives the same player, so by default only one may be
+signed in: a new connection either takes over (the old one is told and
+disconnected) or is turned away, depending on the session policy.
+Connections that stop typing are shown as AFK in who, and dropped once idle
+past the idle timeout; who also shows each connection's round trip. Any
+client may ask who, so it leaves out addresses; netstat, for admins, has
+them. An optional ConnectionGuard turns away addresses with too many
+connections open or that reconnect too fast, before any thread is started
+for them. When the gateway has an authenticate function, a client
+must first sign in with a bearer token (see systems.tokens).
+
+Each connection writes from its own thread, through a prioritized queue
+(see systems.outbound): when a client falls behind, map views are dropped
+before announcements, and replies, errors and sign-in text never are.
+
+A client that is disconnected is always told why, in one standard line
+ending with a reason code ("[disconnect: idle]"), and that line is flushed
+before the socket closes. Admins can kick (and ban) a session with kick.
+"""
+
+import queue
+import socket
+import socketserver
+import threading
+import time
+from functools import lru_cache
+from typing import Callable, Dict, List, Optional, Tuple
+
+from core.recovery import SessionError, log_exception
+from systems.connection_guard import ConnectionGuard
+from systems.latency import tcp_rtt_ms
+from systems.netstats import ConnectionStats
+from systems.outbound import BULK, CRITICAL, NORMAL, OutboundQueue
+from systems.tokens import TokenError
+from world.map import CHAR_MAP, TILE_PAVEMENT, TILE_PORTAL, TILE_WAYPOINT, GameMap
+
+IAC, DONT, DO, WONT, WILL, SB, SE = 255, 254, 253, 252, 251, 250, 240
+REPLY_TIMEOUT = 5.0  # Seconds a connection waits for the game loop to answer
+MAX_LINE = 1024  # Longer input lines are cut off
+LOGIN_ATTEMPTS = 3  # Bad tokens allowed before a client is disconnected
+LOGIN_TIMEOUT = 30.0  # Seconds a client has to send its token
+FLUSH_TIMEOUT = 2.0  # Seconds a closing connection waits for its queue to drain
+# What a second connection does: share the player, take over, or be refused
+SESSION_POLICIES = ("shared", "kick_old", "reject_new")
+RESET = "\x1b[0m"
+
+# Why a session was closed, sent at the end of its last line for clients to act on
+KICKED, BANNED, DUPLICATE_LOGIN = "kicked", "banned", "duplicate-login"
+SHUTDOWN, IDLE = "shutdown", "idle"
+DISCONNECT_REASONS = (KICKED, BANNED, DUPLICATE_LOGIN, SHUTDOWN, IDLE)
+
+# One ASCII character per tile; CHAR_MAP's first spelling is used otherwise
+TILE_GLYPHS: Dict[int, str] = {
+    tile: char for char, tile in reversed(list(CHAR_MAP.items())) if char != " "
+}
+TILE_GLYPHS.update({TILE_PAVEMENT: ".", TILE_WAYPOINT: "^", TILE_PORTAL: "O"})
+
+
+def disconnect_notice(text: str, code: str) -> str:
+    """The last line a disconnected client is sent: why, in words and as a code."""
+    if code not in DISCONNECT_REASONS:
+        raise ValueError(f"Unknown disconnect reason {code!r}")
+    return f"{text} Disconnecting. [disconnect: {code}]"
+
+
+def strip_telnet_commands(data: bytes) -> bytes:
+    """Remove telnet negotiation (IAC sequences) from client input."""
+    out = bytearray()
+    i = 0
+    while i < len(data):
+        byte = data[i]
+        if byte != IAC:
+            out.append(byte)
+            i += 1
+            continue
+        command = data[i + 1] if i + 1 < len(data) else None
+        if command == IAC:  # Escaped 0xFF
+            out.append(IAC)
+            i += 2
+        elif command == SB:  # Subnegotiation runs until IAC SE
+            end = data.find(bytes([IAC, SE]), i + 2)
+            i = len(data) if end < 0 else end + 2
+        elif command in (DO, DONT, WILL, WONT):
+            i += 3
+        else:
+            i += 2
+    return bytes(out)
+
+
+@lru_cache(maxsize=4096)
+def _fg_code(fg: Tuple[int, int, int]) -> str:
+    """Escape sequence for a foreground colour; a map uses few, so they are kept."""
+    return f"\x1b[38;2;{fg[0]};{fg[1]};{fg[2]}m"
+
+
+@lru_cache(maxsize=4096)
+def _dim_code(fg: Tuple[int, int, int]) -> str:
+    """Escape sequence for a remembered (out of view) tile's colour."""
+    return _fg_code(tuple(max(0, c - 100) for c in fg))
+
+
+def render_ansi(
+    game_map: GameMap,
+    center_x: int,
+    center_y: int,
+    width: int,
+    height: int,
+    glyphs: Optional[Dict[Tuple[int, int], Tuple[str, Tuple[int, int, int]]]] = None,
+    recolor: Optional[Callable[[Tuple[int, int, int]], Tuple[int, int, int]]] = None,
+) -> str:
+    """Render the visible map around a point as ANSI-coloured text.
+
+    Glyphs put entities over the terrain: (x, y) -> (char, fg color). Tiles
+    remembered but not in view are dimmed; unexplored tiles are blank.
+    Recolor, if given, maps every colour first (for colour-blind players).
+
+    This runs every tick a client is answered, so it avoids per-tile garbage:
+    colour codes come from a cache, a code is only sent when the colour
+    changes, and one cell buffer is reused for every row.
+    """
+    x0, y0 = center_x - width // 2, center_y - height // 2
+    explored, visible_tiles = game_map.explored, game_map.visible
+    cells = [""] * width + [RESET]
+    rows = [""] * height
+    for row in range(height):
+        y = y0 + row
+        current = None
+        for col in range(width):
+            x = x0 + col
+            tile_def = game_map.get_tile(x, y)
+            if tile_def is None or not explored[y, x]:
+                cells[col] = " "
+                continue
+            if visible_tiles[y, x]:
+                glyph = glyphs.get((x, y)) if glyphs else None
+                if glyph:
+                    char, fg = glyph[0], tuple(glyph[1])
+                else:
+                    char, fg = TILE_GLYPHS.get(tile_def.tile_type, "?"), tile_def.fg_color
+                code = _fg_code(recolor(fg) if recolor else fg)
+            else:
+                char = TILE_GLYPHS.get(tile_def.tile_type, "?")
+                fg = tile_def.fg_color
+                code = _dim_code(recolor(fg) if recolor else fg)
+            if code is current:
+                cells[col] = char
+            else:
+                cells[col] = code + char
+                current = code
+        rows[row] = "".join(cells)
+    return "\r\n".join(rows)
+
+
+class _TelnetHandler(socketserver.StreamRequestHandler):
+    """Reads lines from one telnet client and relays them to the game loop."""
+
+    def setup(self):
+        # StreamRequestHandler applies this to the socket; reads time out when idle
+        self.timeout = self.server.gateway.idle_timeout or None
+        super().setup()
+        self.outbox = OutboundQueue()
+        self.stats = ConnectionStats()
+        self._writer = threading.Thread(target=self._write_queued, daemon=True)
+        self._writer.start()
+        self.address = "%s:%s" % self.client_address[:2]
+        self.connected_at = self.last_active = time.time()
+        self.user = ""
+
+    def finish(self):
+        self.outbox.close()
+        self._writer.join(FLUSH_TIMEOUT)
+        super().finish()
+
+    def handle(self):
+        gateway: "TelnetGateway" = self.server.gateway
+        try:
+            if gateway.closed:
+                self._send(gateway.closed, prompt=False, priority=CRITICAL)
+                return
+            if gateway.authenticate and not self._log_in(gateway):
+                return
+            if not gateway.claim(self):
+                notice = disconnect_notice(
+                    "Someone is already playing from another connection. "
+                    "Try again later.",
+                    DUPLICATE_LOGIN,
+                )
+                self._send(notice, prompt=False, priority=CRITICAL)
+                return
+            self._serve(gateway)
+        except TimeoutError:
+            notice = disconnect_notice("You have been idle too long.", IDLE)
+            self._send(notice, prompt=False, priority=CRITICAL)
+        finally:
+            gateway.release(self)
+
+    def kick(self, reason: str, code: str = KICKED):
+        """Tell this client why and disconnect it, from any thread.
+
+        Returns at once: a closer thread flushes the notice (for up to
+        FLUSH_TIMEOUT) before the socket closes, so a stalled client never
+        holds up the caller, usually the game loop.
+        """
+        notice = disconnect_notice(reason, code)
+        self._send(notice, prompt=False, priority=CRITICAL)
+        self.outbox.close()
+        threading.Thread(target=self._close_when_flushed, daemon=True).start()
+
+    def _close_when_flushed(self):
+        """Closer thread: wait for the queue to drain, then close the socket."""
+        self.outbox.flush(FLUSH_TIMEOUT)
+        try:
+            self.request.shutdown(socket.SHUT_RDWR)
+        except OSError:
+            pass  # Already gone
+
+    def _log_in(self, gateway: "TelnetGateway") -> bool:
+        """Ask for a bearer token until one checks out or attempts run out.
+
+        The whole sign-in must finish within the gateway's login_timeout,
+        however slowly the client sends, so a client that never signs in
+        does not hold a connection (or its address's slot).
+        """
+        deadline = None
+        if gateway.login_timeout:
+            deadline = time.monotonic() + gateway.login_timeout
+        try:
+            for _ in range(LOGIN_ATTEMPTS):
+                self.outbox.put(b"Sign in with your access token (login <token>): ", CRITICAL)
+                raw = self._read_line(MAX_LINE * 8, deadline)
+                if not raw:
+                    return False
+                self.stats.received(len(raw), "login")
+                words = strip_telnet_commands(raw).decode("utf-8", "replace").split()
+                if words[:1] == ["login"]:
+                    words = words[1:]
+                try:
+                    if len(words) != 1:
+                        raise TokenError("expected a single token")
+                    self.user = gateway.authenticate(words[0])
+                    return True
+                except TokenError as e:
+                    if gateway.audit:
+                        gateway.audit.record("sign_in_failed", self.address, reason=str(e))
+                    self.outbox.put(f"Sign-in failed: {e}.\r\n".encode(), CRITICAL)
+        except TimeoutError:
+            self._send("Sign-in timed out. Disconnecting.", prompt=False, priority=CRITICAL)
+            return False
+        finally:
+            self.request.settimeout(self.timeout)
+        self._send(
+            "Too many failed sign-ins. Disconnecting.", prompt=False, priority=CRITICAL
+        )
+        return False
+
+    def _read_line(self, limit: int, deadline: Optional[float]) -> bytes:
+        """rfile.readline(limit), but timing out at deadline (a time.monotonic()
+        value) rather than after a quiet spell, so trickling bytes does not help."""
+        if deadline is None:
+            return self.rfile.readline(limit)
+        line = b""
+        while not line.endswith(b"\n") and len(line) < limit:
+            remaining = deadline - time.monotonic()
+            if remaining <= 0:
+                raise TimeoutError("sign-in took too long")
+            self.request.settimeout(remaining)
+            buffered = self.rfile.peek(1)  # At most one read from the socket
+            if not buffered:
+                break
+            end = buffered.find(b"\n") + 1 or len(buffered)
+            line += self.rfile.read(min(end, limit - len(line)))
+        return line
+
+    def _serve(self, gateway: "TelnetGateway"):
+        greeting = [gateway.banner]
+        if self.user:
+            greeting.insert(0, f"Signed in as {self.user}.")
+        motd = gateway.motd() if gateway.motd else ""
+        if motd:
+            greeting.append(f"Message of the day: {motd}")
+        self._send("\n".join(greeting), priority=CRITICAL)
+        while gateway.running:
+            raw = self.rfile.readline(MAX_LINE)
+            if not raw:
+                break
+            self.last_active = time.time()
+            if not raw.endswith(b"\n"):
+                # Discard the rest of an overlong line
+                while not raw.endswith(b"\n"):
+                    self.stats.received(len(raw), "overlong")
+                    raw = self.rfile.readline(MAX_LINE)
+                    if not raw:
+                        return
+                self._send("That line is too long.", priority=CRITICAL)
+                continue
+            line = strip_telnet_commands(raw).decode("utf-8", "replace").strip()
+            self.stats.received(len(raw), line)
+            if line.lower() in ("quit", "exit", "logout"):
+                self._send("Goodbye.", priority=CRITICAL)
+                break
+
+            reply = queue.Queue(maxsize=1)
+            gateway.inbox.put((line, reply, self.user or self.address))
+            try:
+                text, view, close = reply.get(timeout=REPLY_TIMEOUT)
+            except queue.Empty:
+                self._send("The realm is not answering; try again.", priority=CRITICAL)
+                continue
+            if view:
+                self._send(view, prompt=False, priority=BULK)
+            self._send(text, priority=CRITICAL)
+            if close:
+                break
+
+    def _send(self, text: str, prompt: bool = True, priority: str = NORMAL):
+        """Queue text for this client; dropped quietly once it is disconnecting."""
+        data = text.replace("\r\n", "\n").replace("\n", "\r\n")
+        data += "\r\n> " if prompt else "\r\n"
+        self.outbox.put(data.encode(), priority)
+
+    def _write_queued(self):
+        """Writer thread: send queued messages until the connection closes."""
+        while True:
+            data = self.outbox.get()
+            if data is None:
+                return
+            try:
+                self.wfile.write(data)
+                self.stats.sent(len(data))
+            except OSError:
+                self.outbox.close(discard=True)  # The client has gone
+            finally:
+                self.outbox.done()
+
+
+class _Server(socketserver.ThreadingTCPServer):
+    daemon_threads = True
+    allow_reuse_address = True
+
+    def verify_request(self, request, client_address) -> bool:
+        guard = self.gateway.guard
+        refusal = guard.admit(client_address[0]) if guard else None
+        if refusal:
+            try:
+                request.sendall(f"{refusal}\r\n".encode())
+            except OSError:
+                pass
+            return False
+        return True
+
+    def process_request_thread(self, request, client_address):
+        try:
+            super().process_request_thread(request, client_address)
+        finally:
+            if self.gateway.guard:
+                self.gateway.guard.release(client_address[0])
+
+
+class TelnetGateway:
+    """Optional telnet listener bridging terminal clients into the game loop."""
+
+    def __init__(
+        self,
+        host: str = "127.0.0.1",
+        port: int = 4000,
+        banner: str = "",
+        sessions: str = "shared",
+        afk_after: float = 0.0,
+        idle_timeout: float = 0.0,
+        guard: Optional[ConnectionGuard] = None,
+        authenticate: Optional[Callable[[str], str]] = None,
+        login_timeout: float = LOGIN_TIMEOUT,
+        audit=None,
+        motd: Optional[Callable[[], str]] = None,
+    ):
+        if sessions not in SESSION_POLICIES:
+            raise ValueError(f"Unknown session policy {sessions!r}")
+        self.host = host
+        self.port = port
+        self.banner = banner or "Welcome to Terminus Realm. Type 'help' for commands."
+        self.sessions = sessions
+        self.afk_after = afk_after  # Seconds without input before a session is AFK
+        self.idle_timeout = idle_timeout  # Seconds without input before disconnecting
+        self.guard = guard
+        # Token -> user name, raising TokenError; None lets anyone in
+        self.authenticate = authenticate
+        self.login_timeout = login_timeout  # Seconds to sign in before disconnecting
+        self.audit = audit  # Optional AuditLog for kicks and failed sign-ins
+        self.motd = motd  # Current message of the day, shown after the banner
+        self.closed = ""  # When set, new connections are refused with this text
+        self._active: Optional[_TelnetHandler] = None
+        self._connections: List[_TelnetHandler] = []
+        self._sessions_lock = threading.Lock()
+        self.inbox: "queue.Queue[Tuple[str, queue.Queue, str]]" = queue.Queue()
+        self.caller = ""  # Who typed the line poll is running (user, else address)
+        self.reply_bytes = 0  # Characters of replies sent so far, for load scaling
+        self.running = False
+        self._server: Optional[_Server] = None
+        self._thread: Optional[threading.Thread] = None
+
+    def start(self):
+        """Start listening on a background thread."""
+        self._server = _Server((self.host, self.port), _TelnetHandler)
+        self._server.gateway = self
+        self.port = self._server.server_address[1]
+        self.running = True
+        self._thread = threading.Thread(target=self._server.serve_forever, daemon=True)
+        self._thread.start()
+
+    def stop(self):
+        self.running = False
+        if self._server:
+            self._server.shutdown()
+            self._server.server_close()
+            self._server = None
+
+    def claim(self, handler: _TelnetHandler) -> bool:
+        """Make a new connection the one playing; False if it is refused."""
+        previous = None
+        with self._sessions_lock:
+            if self.sessions != "shared":
+                previous = self._active
+                if previous is not None and self.sessions == "reject_new":
+                    return False
+                self._active = handler
+            self._connections.append(handler)
+        if previous is not None:
+            if self.audit:
+                self.audit.record(
+                    "session_kicked",
+                    handler.user or handler.address,
+                    previous.user or previous.address,
+                    reason="signed in again",
+                )
+            previous.kick("You signed in from another connection.", DUPLICATE_LOGIN)
+        return True
+
+    def release(self, handler: _TelnetHandler):
+        with self._sessions_lock:
+            if self._active is handler:
+                self._active = None
+            if handler in self._connections:
+                self._connections.remove(handler)
+
+    def connection_count(self) -> int:
+        with self._sessions_lock:
+            return len(self._connections)
+
+    def broadcast(self, text: str):
+        """Send an announcement to every connected session, from any thread."""
+        with self._sessions_lock:
+            connections = list(self._connections)
+        for handler in connections:
+            handler._send(f"*** {text} ***")  # Ignored if it is disconnecting
+
+    def disconnect_all(self, reason: str, code: str = SHUTDOWN):
+        """Kick every connected session, telling each one why."""
+        with self._sessions_lock:
+            connections = list(self._connections)
+        for handler in connections:
+            handler.kick(reason, code)
+
+    def kick(
+        self, who: str, reason: str = "", ban_seconds: float = 0.0, by: str = "admin"
+    ) -> int:
+        """Disconnect every session signed in as who, or from that address
+        (host or host:port); with ban_seconds its address is also turned away
+        that long. Returns how many sessions were closed."""
+        with self._sessions_lock:
+            matching = [
+                handler
+                for handler in self._connections
+                if who in (handler.user, handler.address, handler.client_address[0])
+            ]
+        code = BANNED if ban_seconds > 0 else KICKED
+        if not reason:
+            reason = "You have been banned." if code == BANNED else "You have been kicked."
+        for handler in matching:
+            if ban_seconds > 0 and self.guard:
+                self.guard.ban(handler.client_address[0], ban_seconds, by)
+            if self.audit:
+                self.audit.record(
+                    "session_kicked", by, handler.user or handler.address,
+                    reason=reason, code=code,
+                )
+            handler.kick(reason, code)
+        return len(matching)
+
+    def who(self) -> List[Dict[str, object]]:
+        """Connected sessions, oldest first, with idle time, AFK status and the
+        connection's round trip (None where the platform cannot tell).
+
+        Every client can see this, so peer addresses are left out; netstat
+        has them for admins.
+        """
+        now = time.time()
+        with self._sessions_lock:
+            connections = list(self._connections)
+        sessions = []
+        for handler in connections:
+            idle = now - handler.last_active
+            sessions.append(
+                {
+                    "user": handler.user,
+                    "connected": round(now - handler.connected_at),
+                    "idle": round(idle),
+                    "afk": bool(self.afk_after) and idle >= self.afk_after,
+                    "rtt_ms": tcp_rtt_ms(handler.request),
+                }
+            )
+        return sessions
+
+    def netstat(self) -> List[Dict[str, object]]:
+        """Traffic per connection, oldest first: bytes each way, lines sent by
+        command word, the outbound queue's high-water mark and drops, and idle time."""
+        now = time.time()
+        with self._sessions_lock:
+            connections = list(self._connections)
+        return [
+            {
+                "address": handler.address,
+                "user": handler.user,
+                "connected": round(now - handler.connected_at),
+                "idle": round(now - handler.last_active),
+                **handler.stats.snapshot(),
+                "queue": handler.outbox.stats(),
+            }
+            for handler in connections
+        ]
+
+    def poll(
+        self,
+        handle: Callable[[str], str],
+        limit: int = 10,
+        snapshot: Optional[Callable[[], str]] = None,
+    ) -> int:
+        """Answer pending client lines on the calling (game loop) thread.
+
+        Every reply from this call is sent after the last line has run, below
+        one view built by snapshot. Each connection waits for its answer
+        before sending more, so a client gets at most one update per tick.
+
+        If handling a line fails, that client is sent an error and
+        disconnected; the game and every other session carry on.
+        """
+        answers = []
+        while len(answers) < limit:
+            try:
+                line, reply, self.caller = self.inbox.get_nowait()
+            except queue.Empty:
+                break
+            try:
+                answers.append((reply, handle(line), False))
+            except SessionError as e:
+                answers.append((reply, f"Error: {e}. Disconnecting.", True))
+            except Exception:
+                log_exception(f"telnet command {line!r}")
+                answers.append(
+                    (reply, "Error: something went wrong. Disconnecting.", True)
+                )
+        self.caller = ""
+
+        view = ""
+        if snapshot and any(not close for _, _, close in answers):
+            try:
+                view = snapshot()
+            except Exception:
+                log_exception("telnet snapshot")
+        for reply, text, close in answers:
+            # The view goes separately, so a client falling behind can skip it
+            sent_view = "" if close else view
+            self.reply_bytes += len(text) + len(sent_view)
+            reply.put((text, sent_view, close))
+        return len(answers)
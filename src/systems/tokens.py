@@ -0,0 +1,96 @@
+"""
+Bearer tokens for signing in to network sessions.
+Tokens are JWTs signed with HMAC-SHA256 (HS256) using a secret shared with
+whatever issues them: an external identity provider or the --issue-token
+command. Only HS256 is accepted, so a token cannot pick a weaker algorithm
+(or "none") for itself.
+"""
+
+import base64
+import hashlib
+import hmac
+import json
+import time
+from typing import Any, Dict, Optional
+
+ALGORITHM = "HS256"
+
+
+class TokenError(ValueError):
+    """A token that is malformed, badly signed, expired or meant for someone else."""
+
+
+def _b64encode(data: bytes) -> str:
+    return base64.urlsafe_b64encode(data).rstrip(b"=").decode("ascii")
+
+
+def _b64decode(text: str) -> bytes:
+    try:
+        return base64.urlsafe_b64decode(text + "=" * (-len(text) % 4))
+    except (ValueError, TypeError):
+        raise TokenError("malformed token") from None
+
+
+def _sign(signing_input: bytes, secret: str) -> bytes:
+    return hmac.new(secret.encode(), signing_input, hashlib.sha256).digest()
+
+
+def issue_token(claims: Dict[str, Any], secret: str) -> str:
+    """Sign claims into a token."""
+    def encode(data):
+        return _b64encode(json.dumps(data, separators=(",", ":")).encode())
+
+    header, payload = encode({"alg": ALGORITHM, "typ": "JWT"}), encode(claims)
+    signing_input = f"{header}.{payload}".encode("ascii")
+    return f"{header}.{payload}.{_b64encode(_sign(signing_input, secret))}"
+
+
+def verify_token(
+    token: str,
+    secret: str,
+    issuer: Optional[str] = None,
+    audience: Optional[str] = None,
+    leeway: float = 30.0,
+    now: Optional[float] = None,
+) -> Dict[str, Any]:
+    """Check a token's signature and claims and return the claims.
+
+    exp and nbf are enforced when present (give or take leeway seconds), iss
+    and aud when an issuer or audience is given, and a sub is required.
+    """
+    parts = token.strip().split(".")
+    if len(parts) != 3:
+        raise TokenError("malformed token")
+    try:
+        header = json.loads(_b64decode(parts[0]))
+        claims = json.loads(_b64decode(parts[1]))
+    except (ValueError, UnicodeDecodeError):
+        raise TokenError("malformed token") from None
+    if not isinstance(header, dict) or not isinstance(claims, dict):
+        raise TokenError("malformed token")
+    if header.get("alg") != ALGORITHM:
+        raise TokenError("unsupported signing algorithm")
+
+    expected = _sign(f"{parts[0]}.{parts[1]}".encode("ascii"), secret)
+    if not hmac.compare_digest(expected, _b64decode(parts[2])):
+        raise TokenError("bad signature")
+
+    now = time.time() if now is None else now
+    try:
+        expires = float(claims["exp"]) if "exp" in claims else None
+        not_before = float(claims["nbf"]) if "nbf" in claims else None
+    except (TypeError, ValueError):
+        raise TokenError("malformed token") from None
+    if expires is not None and now > expires + leeway:
+        raise TokenError("token expired")
+    if not_before is not None and now < not_before - leeway:
+        raise TokenError("token not valid yet")
+    if issuer is not None and claims.get("iss") != issuer:
+        raise TokenError("wrong issuer")
+    if audience is not None:
+        aud = claims.get("aud")
+        if aud != audience and not (isinstance(aud, list) and audience in aud):
+            raise TokenError("wrong audience")
+    if not isinstance(claims.get("sub"), str) or not claims["sub"]:
+        raise TokenError("token has no subject")
+    return claims
@@ -0,0 +1,437 @@
+"""
+Transactional item, gold and currency operations.
+Every change to an Inventory or BankAccount goes through an ItemTransaction so a
+failure part-way through can never duplicate or lose items, gold or other
+currencies.
+"""
+
+import itertools
+import json
+import os
+import time
+from typing import Any, Callable, Dict, List, Optional
+
+from core.ecs import EntityManager, component_types
+from entities.components import Item, Position
+from systems.binding import transfer_refusal
+from systems.currency import (
+    GOLD,
+    CurrencyError,
+    balance,
+    currency,
+    restore_wallet,
+    wallet_profile,
+)
+
+
+class TransactionError(Exception):
+    """Raised when a transaction fails validation or cannot be applied."""
+
+
+class TransactionJournal:
+    """Append-only write-ahead journal of item transactions (JSON lines).
+
+    A transaction is written as 'begin' before it is applied and 'commit' or
+    'abort' afterwards, so a crash mid-operation leaves a 'begin' record with
+    no outcome that can be found with incomplete(). Begin records name each
+    container by its owning entity ("player" for the player's own) and slot
+    (the component class, or e.g. "AccountStash" for containers no entity
+    owns) and keep what it held beforehand, items as their serialized
+    components rather than entity IDs, which do not survive a restart, so
+    recover() can roll those transactions back on load.
+    """
+
+    def __init__(self, path: str):
+        self.path = path
+        directory = os.path.dirname(path)
+        if directory:
+            os.makedirs(directory, exist_ok=True)
+
+    def _append(self, record: Dict[str, Any]):
+        with open(self.path, "a", encoding="utf-8") as f:
+            f.write(json.dumps(record) + "\n")
+            f.flush()
+            os.fsync(f.fileno())
+
+    def begin(
+        self,
+        txid: str,
+        reason: str,
+        ops: List[Dict[str, Any]],
+        before: Optional[List[Dict[str, Any]]] = None,
+    ):
+        self._append(
+            {
+                "txid": txid,
+                "status": "begin",
+                "time": time.time(),
+                "reason": reason,
+                "ops": ops,
+                "before": before or [],
+            }
+        )
+
+    def commit(self, txid: str):
+        self._append({"txid": txid, "status": "commit", "time": time.time()})
+
+    def abort(self, txid: str, error: str):
+        self._append(
+            {"txid": txid, "status": "abort", "time": time.time(), "error": error}
+        )
+
+    def read(self) -> List[Dict[str, Any]]:
+        """Read all journal records, skipping a torn final line."""
+        if not os.path.exists(self.path):
+            return []
+        records = []
+        with open(self.path, "r", encoding="utf-8") as f:
+            for line in f:
+                try:
+                    records.append(json.loads(line))
+                except json.JSONDecodeError:
+                    continue
+        return records
+
+    def incomplete(self) -> List[Dict[str, Any]]:
+        """Return 'begin' records that never reached commit or abort."""
+        pending: Dict[str, Dict[str, Any]] = {}
+        for record in self.read():
+            if record.get("status") == "begin":
+                pending[record["txid"]] = record
+            else:
+                pending.pop(record.get("txid"), None)
+        return list(pending.values())
+
+    def recover(
+        self,
+        entity_manager: EntityManager,
+        player: Optional[int] = None,
+        containers: Optional[Dict[str, Any]] = None,
+    ) -> List[Dict[str, Any]]:
+        """Roll back every transaction that began but never finished.
+
+        Only what is saved between sessions is put back. Containers named in
+        containers (the stash) are saved whole, so they get back their gold
+        and have their items rebuilt from the journal under new IDs. Of the
+        player's own containers only the profile wallet is saved; their gold
+        and carried items are made afresh with the character, so only their
+        persistent currencies are restored. Other entities are recreated
+        under new IDs when a world loads and are left alone. Transactions are
+        undone newest first, so a container touched twice ends up as it was
+        before the first, and each is then journaled as aborted so it is not
+        rolled back twice. Returns the begin records that were rolled back.
+        """
+        types = component_types()
+        rolled_back = self.incomplete()
+        for record in reversed(rolled_back):
+            for held in record.get("before", []):
+                if held.get("entity") is None:
+                    container = (containers or {}).get(held["slot"])
+                    if container is not None:
+                        _restore_items(entity_manager, types, container, held)
+                elif held["entity"] == "player" and held["slot"] in types:
+                    container = entity_manager.get_component(player, types[held["slot"]])
+                    if getattr(container, "currencies", None) is not None:
+                        _restore_wallet(container, held.get("currencies"))
+            self.abort(record["txid"], "rolled back after an unclean shutdown")
+        return rolled_back
+
+
+def _restore_items(entity_manager, types, container, held):
+    """Give a saved container back its gold and rebuild the items it held."""
+    for eid in container.items:
+        if eid in entity_manager.entities:
+            entity_manager.destroy_entity(eid)
+    container.items[:] = [
+        entity_manager.restore_entity(dict(item, eid=entity_manager.next_id), types)
+        for item in held["items"]
+    ]
+    container.gold = held["gold"]
+
+
+def _restore_wallet(container, before):
+    """Put a wallet's profile currencies back as they were."""
+    for cid in wallet_profile(container):
+        container.currencies.pop(cid, None)
+    restore_wallet(container, before)
+
+
+def _identity(record: Dict[str, Any]) -> Dict[str, Any]:
+    """An item as the journal keeps it: its components, without its entity ID."""
+    return {k: v for k, v in record.items() if k != "eid"}
+
+
+class ItemTransaction:
+    """Stages item and gold operations and applies them all-or-nothing.
+
+    Containers are Inventory or BankAccount components (anything with
+    `items`, `gold` and `capacity`); only those with `currencies` can hold
+    currencies other than gold. Usable as a context manager: the
+    transaction commits on a clean exit and is discarded on an exception.
+    """
+
+    _ids = itertools.count(1)
+
+    def __init__(
+        self,
+        entity_manager: EntityManager,
+        journal: Optional[TransactionJournal] = None,
+        reason: str = "",
+        audit=None,
+        listener: Optional[Callable[["ItemTransaction"], None]] = None,
+        player: Optional[int] = None,
+    ):
+        self.entity_manager = entity_manager
+        self.journal = journal
+        self.reason = reason
+        self.audit = audit  # Optional AuditLog, told about large gold movements
+        self.listener = listener  # Optional callback, told about each commit
+        self.player = player  # Journaled as "player" so recovery can find it again
+        self.txid = f"{int(time.time() * 1000)}-{next(self._ids)}"
+        self.ops: List[tuple] = []
+        self.committed = False
+
+    # --- Staging ---
+
+    def add_gold(self, container, amount: int):
+        self.ops.append(("add_gold", container, amount))
+        return self
+
+    def remove_gold(self, container, amount: int):
+        self.ops.append(("remove_gold", container, amount))
+        return self
+
+    def transfer_gold(self, source, target, amount: int):
+        self.remove_gold(source, amount)
+        return self.add_gold(target, amount)
+
+    def add_currency(self, container, currency_id: str, amount: int):
+        if currency_id == GOLD:
+            return self.add_gold(container, amount)
+        self.ops.append(("add_currency", container, (currency_id, amount)))
+        return self
+
+    def remove_currency(self, container, currency_id: str, amount: int):
+        if currency_id == GOLD:
+            return self.remove_gold(container, amount)
+        self.ops.append(("remove_currency", container, (currency_id, amount)))
+        return self
+
+    def grant(self, container, amounts: Dict[str, int]):
+        """Add several currencies at once, e.g. {"gold": 5, "event_token": 2}."""
+        for currency_id, amount in amounts.items():
+            self.add_currency(container, currency_id, amount)
+        return self
+
+    def spend(self, container, costs: Dict[str, int]):
+        """Take a price in one or more currencies; all of it or none."""
+        for currency_id, amount in costs.items():
+            self.remove_currency(container, currency_id, amount)
+        return self
+
+    def add_item(self, container, item_id: int):
+        self.ops.append(("add_item", container, item_id))
+        return self
+
+    def remove_item(self, container, item_id: int):
+        self.ops.append(("remove_item", container, item_id))
+        return self
+
+    def move_item(self, source, target, item_id: int):
+        self.remove_item(source, item_id)
+        return self.add_item(target, item_id)
+
+    def pickup_item(self, container, item_id: int):
+        """Take an item off the map and into a container."""
+        self.ops.append(("take_from_ground", None, item_id))
+        return self.add_item(container, item_id)
+
+    def destroy_item(self, item_id: int):
+        """Destroy an item entity once the transaction has been applied."""
+        self.ops.append(("destroy_item", None, item_id))
+        return self
+
+    # --- Execution ---
+
+    def validate(self):
+        """Simulate all staged operations and raise if any would fail."""
+        gold: Dict[int, int] = {}
+        items: Dict[int, List[int]] = {}
+        held: Dict[tuple, int] = {}  # (container, currency) -> balance
+
+        for op, container, value in self.ops:
+            if container is None:
+                continue
+            key = id(container)
+            gold.setdefault(key, container.gold)
+            items.setdefault(key, list(container.items))
+
+            if value is None or (op.endswith("gold") and value < 0):
+                raise TransactionError(f"Invalid amount for {op}")
+            if op.endswith("currency"):
+                currency_id, amount = value
+                try:
+                    currency(currency_id)
+                except CurrencyError as e:
+                    raise TransactionError(str(e)) from e
+                if amount < 0:
+                    raise TransactionError(f"Invalid amount for {op}")
+                if getattr(container, "currencies", None) is None:
+                    raise TransactionError(f"Container cannot hold {currency_id}")
+                held.setdefault((key, currency_id), balance(container, currency_id))
+
+            if op == "add_gold":
+                gold[key] += value
+            elif op == "remove_gold":
+                if gold[key] < value:
+                    raise TransactionError("Not enough gold")
+                gold[key] -= value
+            elif op == "add_item":
+                if len(items[key]) >= container.capacity:
+                    raise TransactionError("Container is full")
+                if value in items[key]:
+                    raise TransactionError(f"Item {value} is already in container")
+                refusal = transfer_refusal(
+                    self.entity_manager.get_component(value, Item), self.reason
+                )
+                if refusal:
+                    raise TransactionError(refusal)
+                items[key].append(value)
+            elif op == "remove_item":
+                if value not in items[key]:
+                    raise TransactionError(f"Item {value} is not in container")
+                items[key].remove(value)
+            elif op == "add_currency":
+                held[(key, value[0])] += value[1]
+            elif op == "remove_currency":
+                if held[(key, value[0])] < value[1]:
+                    raise TransactionError(f"Not enough {value[0]}")
+                held[(key, value[0])] -= value[1]
+
+    def commit(self):
+        """Validate, journal and apply the transaction atomically."""
+        if self.committed:
+            return
+        self.validate()
+
+        if self.journal:
+            self.journal.begin(self.txid, self.reason, self._describe(), self._holdings())
+
+        # Snapshot every touched container and grounded item for rollback
+        snapshots = {}
+        ground_positions = {}
+        for op, container, value in self.ops:
+            if container is not None and id(container) not in snapshots:
+                currencies = getattr(container, "currencies", None)
+                snapshots[id(container)] = (
+                    container,
+                    list(container.items),
+                    container.gold,
+                    dict(currencies) if currencies is not None else None,
+                )
+            if op == "take_from_ground":
+                ground_positions[value] = self.entity_manager.get_component(value, Position)
+
+        try:
+            for op, container, value in self.ops:
+                if op == "add_gold":
+                    container.gold += value
+                elif op == "remove_gold":
+                    container.gold -= value
+                elif op == "add_item":
+                    container.items.append(value)
+                elif op == "remove_item":
+                    container.items.remove(value)
+                elif op == "add_currency":
+                    currency_id, amount = value
+                    container.currencies[currency_id] = (
+                        container.currencies.get(currency_id, 0) + amount
+                    )
+                elif op == "remove_currency":
+                    currency_id, amount = value
+                    left = container.currencies[currency_id] - amount
+                    if left:
+                        container.currencies[currency_id] = left
+                    else:
+                        del container.currencies[currency_id]
+                elif op == "take_from_ground":
+                    self.entity_manager.remove_component(value, Position)
+        except Exception as e:
+            for container, item_list, gold, currencies in snapshots.values():
+                container.items[:] = item_list
+                container.gold = gold
+                if currencies is not None:
+                    container.currencies.clear()
+                    container.currencies.update(currencies)
+            for item_id, pos in ground_positions.items():
+                if pos is not None and item_id in self.entity_manager.entities:
+                    self.entity_manager.add_component(item_id, pos)
+            if self.journal:
+                self.journal.abort(self.txid, str(e))
+            raise TransactionError(str(e)) from e
+
+        # Destruction is irreversible, so it only happens after everything applied
+        for op, _, value in self.ops:
+            if op == "destroy_item":
+                self.entity_manager.destroy_entity(value)
+
+        if self.journal:
+            self.journal.commit(self.txid)
+        self.committed = True
+
+        if self.audit:
+            added = sum(v for op, _, v in self.ops if op == "add_gold")
+            removed = sum(v for op, _, v in self.ops if op == "remove_gold")
+            self.audit.gold_moved(self.reason, self.txid, max(added, removed))
+        if self.listener:
+            self.listener(self)
+
+    def _owner(self, container):
+        """The entity a container is a component of ("player" for the player's),
+        or None if it stands alone."""
+        owners = self.entity_manager.components_by_type.get(type(container), {})
+        eid = next((eid for eid, comp in owners.items() if comp is container), None)
+        return "player" if eid is not None and eid == self.player else eid
+
+    def _describe(self) -> List[Dict[str, Any]]:
+        """Serializable description of the staged operations for the journal."""
+        return [
+            {
+                "op": op,
+                "entity": self._owner(container) if container is not None else None,
+                "slot": type(container).__name__ if container is not None else None,
+                "value": value,
+            }
+            for op, container, value in self.ops
+        ]
+
+    def _holdings(self) -> List[Dict[str, Any]]:
+        """What each touched container held before, for rolling back after a crash."""
+        holdings, seen = [], set()
+        for _, container, _ in self.ops:
+            if container is None or id(container) in seen:
+                continue
+            seen.add(id(container))
+            currencies = getattr(container, "currencies", None)
+            holdings.append(
+                {
+                    "entity": self._owner(container),
+                    "slot": type(container).__name__,
+                    "gold": container.gold,
+                    "items": [
+                        _identity(self.entity_manager.serialize_entity(eid))
+                        for eid in container.items
+                        if eid in self.entity_manager.entities
+                    ],
+                    "currencies": dict(currencies) if currencies is not None else None,
+                }
+            )
+        return holdings
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, exc_type, exc, tb):
+        if exc_type is None:
+            self.commit()
+        return False
@@ -0,0 +1,145 @@
+"""
+Turn-based combat.
+With [combat] mode = "turns" the world still runs in real time, but a fight
+does not: once a hostile monster comes within turn_range tiles of the
+player, it and every other hostile in range join an encounter and act in
+initiative order with the player, classic roguelike style. Monsters in the
+encounter get no real-time AI; each acts once on its turn, turn_seconds
+apart so clients can follow. While a monster's turn is pending the server
+refuses the player's world actions (moving, attacking, casting, ...) with
+not_your_turn; menus and requests are never held up. Monsters that come into
+range join at their initiative and first act next round, and the encounter
+ends when none is left.
+
+Initiative is a d20 roll plus ten per point of a monster's speed (the player
+counts as speed 1); ties go to the player.
+"""
+
+import random
+from typing import Callable, Dict, List, Mapping, Optional
+
+REALTIME, TURNS = "realtime", "turns"
+ENERGY = "energy"  # Creatures act as their energy allows (see systems.energy)
+MODES = (REALTIME, TURNS, ENERGY)
+# Actions that use up the player's turn; the rest (menus, aiming a shot
+# before it is fired) are free
+TURN_ACTIONS = frozenset(
+    {"move", "pickup", "cast", "wait", "stealth", "recall", "craft", "brew", "fire", "fish"}
+)
+PLAYER_SPEED = 1.0
+
+
+def roll_d20() -> int:
+    return random.randint(1, 20)
+
+
+class TurnOrder:
+    """Who is in the current encounter, in initiative order, and whose turn it is."""
+
+    def __init__(
+        self,
+        settings: Optional[Mapping] = None,
+        roll: Callable[[], int] = roll_d20,
+    ):
+        settings = settings or {}
+        self.mode = str(settings.get("mode", REALTIME))
+        if self.mode not in MODES:
+            raise ValueError(f"combat mode must be one of {', '.join(MODES)}")
+        self.range = int(settings.get("turn_range", 8))
+        self.turn_seconds = float(settings.get("turn_seconds", 0.25))
+        self.roll = roll
+        self.order: List[int] = []
+        self.initiative: Dict[int, float] = {}
+        self.index = 0
+        self.round = 0
+        self.player: Optional[int] = None
+        self._waited = 0.0
+
+    @property
+    def enabled(self) -> bool:
+        return self.mode == TURNS
+
+    @property
+    def active(self) -> bool:
+        return bool(self.order)
+
+    @property
+    def current(self) -> Optional[int]:
+        return self.order[self.index] if self.order else None
+
+    @property
+    def player_turn(self) -> bool:
+        return self.active and self.current == self.player
+
+    def sync(self, player: int, hostiles: Mapping[int, float]) -> List[int]:
+        """Bring the encounter up to date with the hostiles (id -> speed) in range.
+
+        Starts an encounter, adds newcomers, drops those gone and ends it when
+        none are left. Returns the monsters that joined.
+        """
+        if not hostiles:
+            self.end()
+            return []
+        starting = not self.active
+        if starting:
+            self.player, self.round = player, 1
+            self._join(player, PLAYER_SPEED)
+        for eid in [e for e in self.order if e != player and e not in hostiles]:
+            self._leave(eid)
+        joined = [eid for eid in hostiles if eid not in self.initiative]
+        for eid in joined:
+            self._join(eid, hostiles[eid])
+        if starting:
+            self.index = 0  # The first round starts at the top
+        return joined
+
+    def advance(self):
+        """End the current turn; after the last in the order a new round starts."""
+        if not self.active:
+            return
+        self.index += 1
+        self._waited = 0.0
+        if self.index >= len(self.order):
+            self.index = 0
+            self.round += 1
+
+    def due(self, dt: float) -> bool:
+        """Whether the monster whose turn it is should act now."""
+        self._waited += dt
+        return self.active and not self.player_turn and self._waited >= self.turn_seconds
+
+    def end(self):
+        self.order, self.initiative = [], {}
+        self.index = self.round = 0
+        self._waited = 0.0
+
+    def status(self) -> dict:
+        return {
+            "mode": self.mode,
+            "active": self.active,
+            "round": self.round,
+            "order": list(self.order),
+            "turn": self.current,
+        }
+
+    def _join(self, eid: int, speed: float):
+        self.initiative[eid] = self.roll() + 10 * float(speed)
+        # Highest initiative first; the player wins ties
+        rank = lambda e: (-self.initiative[e], e != self.player)
+        position = 0
+        while position < len(self.order) and rank(self.order[position]) <= rank(eid):
+            position += 1
+        self.order.insert(position, eid)
+        # Joining ahead of whoever is acting means waiting for the next round
+        if position <= self.index and len(self.order) > 1:
+            self.index += 1
+
+    def _leave(self, eid: int):
+        position = self.order.index(eid)
+        del self.order[position]
+        del self.initiative[eid]
+        if position < self.index:
+            self.index -= 1
+        if self.index >= len(self.order):
+            self.index = 0
+            self.round += 1
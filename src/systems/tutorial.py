@@ -0,0 +1,79 @@
+"""
+New-player tutorial for the roguelike game.
+The tutorial is a list of guided steps (walk, fight the training dummy,
+pick something up, talk to the Tutor) loaded from tutorial.json. Each step
+completes when the player does the matching action often enough; progress
+lives on the player's Onboarding component and is saved in their profile.
+"""
+
+from typing import Any, Dict, List, Optional
+
+from core.ecs import EntityManager
+from data.loader import DATA_LOADER
+from entities.components import Onboarding
+
+
+class TutorialSystem:
+    """Advances players through the tutorial steps."""
+
+    def __init__(self, entity_manager: EntityManager, data: Optional[Dict[str, Any]] = None):
+        self.entity_manager = entity_manager
+        if data is None:
+            try:
+                data = DATA_LOADER.load_json("tutorial")
+            except FileNotFoundError:
+                data = {}
+        self.steps: List[Dict[str, Any]] = data.get("steps", [])
+        self.completion_text: str = data.get("complete", "Tutorial complete!")
+
+    def in_progress(self, eid: int) -> bool:
+        onboarding = self.entity_manager.get_component(eid, Onboarding)
+        return bool(onboarding and not onboarding.complete and self.steps)
+
+    def current_step(self, eid: int) -> Optional[Dict[str, Any]]:
+        """The step the player is on, or None once the tutorial is done."""
+        onboarding = self.entity_manager.get_component(eid, Onboarding)
+        if not onboarding or onboarding.complete or onboarding.step >= len(self.steps):
+            return None
+        return self.steps[onboarding.step]
+
+    def hint(self, eid: int) -> str:
+        step = self.current_step(eid)
+        return step["hint"] if step else ""
+
+    def record(self, eid: int, event: str, subject: str = "") -> Optional[str]:
+        """Count a player action towards the current step.
+
+        Returns "step" when the player moved on to a new step, "complete" when
+        the last step was finished, or None if nothing changed.
+        """
+        step = self.current_step(eid)
+        if step is None or step["event"] != event:
+            return None
+        if step.get("target") and step["target"] != subject:
+            return None
+
+        onboarding = self.entity_manager.get_component(eid, Onboarding)
+        onboarding.count += 1
+        if onboarding.count < step.get("count", 1):
+            return None
+
+        onboarding.step += 1
+        onboarding.count = 0
+        if onboarding.step >= len(self.steps):
+            onboarding.complete = True
+            return "complete"
+        return "step"
+
+    def to_profile(self, eid: int) -> Dict[str, Any]:
+        onboarding = self.entity_manager.get_component(eid, Onboarding)
+        if not onboarding:
+            return {}
+        return {"step": onboarding.step, "count": onboarding.count, "complete": onboarding.complete}
+
+    def from_profile(self, eid: int, data: Dict[str, Any]):
+        onboarding = self.entity_manager.get_component(eid, Onboarding)
+        if onboarding:
+            onboarding.step = int(data.get("step", 0))
+            onboarding.count = int(data.get("count", 0))
+            onboarding.complete = bool(data.get("complete", False))
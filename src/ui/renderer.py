@@ -5,7 +5,7 @@ Terminal rendering system for the roguelike game using rich.
 from rich.console import Console
 from rich.text import Text
 from rich.panel import Panel
-from typing import TYPE_CHECKING
+from typing import Optional, Tuple, TYPE_CHECKING
 import numpy as np
 import shutil
 
@@ -51,6 +51,10 @@ class Renderer:
         self._last_cam_x = -1
         self._last_cam_y = -1
 
+        # Last drawn camera and map origin (buffer cells), for mapping clicks to tiles
+        self.camera = (0, 0)
+        self.map_origin = (1, 1)
+
     def _draw_text_packed(self, buffer, x, y, text, fg_color, max_width=None):
         """Draw text to the buffer, packing 2 chars per cell for normal width."""
         if len(text) % 2 != 0:
@@ -106,11 +110,18 @@ class Renderer:
         shop_id: int = None,
         shop_mode: str = "BUY",
         shop_selection: int = 0,
+        shop_stock: list = None,
         bank_id: int = None,
         bank_mode: str = "DEPOSIT",
         bank_selection: int = 0,
+        travel_options: list = None,
+        world_options: list = None,
+        world_overview=None,
+        clock_text: str = "",
+        light_map=None,
+        focus: Optional[Tuple[int, int]] = None,
     ):
-        """Render the current game state."""
+        """Render the current game state; focus centres the camera off the player."""
         # Update dimensions to match current terminal size
         t_cols, t_lines = shutil.get_terminal_size()
 
@@ -192,8 +203,9 @@ class Renderer:
         player_pos = entity_manager.get_component(player_id, Position)
         camera_x, camera_y = 0, 0
         if player_pos:
-            camera_x = player_pos.x - self.map_render_width // 2
-            camera_y = player_pos.y - self.map_render_height // 2
+            center_x, center_y = focus or (player_pos.x, player_pos.y)
+            camera_x = center_x - self.map_render_width // 2
+            camera_y = center_y - self.map_render_height // 2
 
             # Apply Screen Shake
             if time.time() < self.shake_end_time:
@@ -226,8 +238,13 @@ class Renderer:
             render_buffer, start_x, start_y, content_w, content_h, (100, 100, 100)
         )
 
+        self.camera = (camera_x, camera_y)
+        self.map_origin = (start_x + 1, start_y + 1)
+
         # Render Map & Entities
-        self._render_map(render_buffer, game_map, camera_x, camera_y, start_x, start_y)
+        self._render_map(
+            render_buffer, game_map, camera_x, camera_y, start_x, start_y, light_map
+        )
         self._render_entities(
             render_buffer,
             entity_manager,
@@ -236,11 +253,18 @@ class Renderer:
             camera_y,
             start_x,
             start_y,
+            player_pos.z if player_pos else 0,
         )
 
         # UI (inside the box)
         self._render_ui(
-            render_buffer, entity_manager, player_id, messages, start_x, start_y
+            render_buffer,
+            entity_manager,
+            player_id,
+            messages,
+            start_x,
+            start_y,
+            clock_text=clock_text,
         )
 
         # Render ambient particles (Overlay on top of map but behind UI)
@@ -258,11 +282,41 @@ class Renderer:
         elif game_state == "HELP":
             self._render_help(render_buffer)
         elif game_state == "SHOPPING":
-            self._render_shop(render_buffer, entity_manager, shop_id)
+            self._render_shop(
+                render_buffer,
+                entity_manager,
+                player_id,
+                shop_id,
+                shop_mode,
+                shop_selection,
+                shop_stock,
+            )
+        elif game_state == "TRAVEL":
+            self._render_travel(render_buffer, travel_options or [], inventory_selection)
+        elif game_state == "WORLD_SELECT":
+            self._render_world_select(
+                render_buffer, world_options or [], inventory_selection
+            )
+        elif game_state == "WORLD_MAP" and world_overview is not None:
+            self._render_world_map(
+                render_buffer, game_map, entity_manager, player_id, world_overview
+            )
 
         # Output
         self._output_buffer(render_buffer)
 
+    def screen_to_map(self, col: int, row: int) -> Optional[Tuple[int, int]]:
+        """Convert a 1-based terminal cell to map coordinates, or None if off the map view."""
+        # Each buffer cell is two terminal columns wide
+        buffer_x = (col - 1) // 2
+        buffer_y = row - 1
+
+        view_x = buffer_x - self.map_origin[0]
+        view_y = buffer_y - self.map_origin[1]
+        if not (0 <= view_x < self.map_render_width and 0 <= view_y < self.map_render_height):
+            return None
+        return self.camera[0] + view_x, self.camera[1] + view_y
+
     def _render_map(
         self,
         buffer: np.ndarray,
@@ -271,6 +325,7 @@ class Renderer:
         cam_y: int,
         offset_x: int = 1,
         offset_y: int = 1,
+        light_map=None,
     ):
         """Render the game map to the buffer with camera offset using vectorized operations."""
         import time
@@ -617,9 +672,15 @@ class Renderer:
                     chars[hide_mask] = "  "
                     fg_colors[hide_mask] = 0
                     bg_colors[hide_mask] = -1
-        else:
-            # Overworld: No vignette, full visibility
-            pass
+        elif light_map is not None and light_map.ambient < 1.0:
+            # Overworld after dark: shade by light level, unseen tiles fall to darkness
+            shade = np.clip(light_map.slice(cam_x, cam_y, x_end, y_end), 0.15, 1.0)
+            shade[~visible_slice] = 0.15
+            fg_colors[:] = (fg_colors * shade[:, :, None]).astype(np.int16)
+            bg_valid = bg_colors[:, :, 0] != -1
+            bg_colors[bg_valid] = (
+                bg_colors[bg_valid] * shade[bg_valid][:, None]
+            ).astype(np.int16)
 
         # Assign to buffers
         by_end = buffer_y_offset + slice_h
@@ -648,8 +709,9 @@ class Renderer:
         cam_y: int,
         offset_x: int = 1,
         offset_y: int = 1,
+        level: int = 0,
     ):
-        """Render entities to the buffer with camera offset."""
+        """Render entities on one Z-level to the buffer with camera offset."""
         # Import the actual component classes
         from entities.components import Position, Render
 
@@ -672,6 +734,17 @@ class Renderer:
             render_comp = entity_manager.get_component(eid, Render)
 
             if pos_comp and render_comp:
+                if pos_comp.z != level:
+                    continue
+
+                # Entities in darkness or out of sight are not drawn
+                if (
+                    0 <= pos_comp.y < game_map.height
+                    and 0 <= pos_comp.x < game_map.width
+                    and not game_map.visible[pos_comp.y, pos_comp.x]
+                ):
+                    continue
+
                 # Calculate screen position relative to camera
                 screen_x = pos_comp.x - cam_x
                 screen_y = pos_comp.y - cam_y
@@ -727,7 +800,7 @@ class Renderer:
                     self.fg_color_buffer[y, bx] = bg_color
 
     def _render_shop(
-        self, buffer, entity_manager, player_id, shop_id, mode, selection
+        self, buffer, entity_manager, player_id, shop_id, mode, selection, stock=None
     ):
         from entities.components import Shop, Inventory, Item
         win_w, win_h = 50, 30
@@ -765,6 +838,7 @@ class Renderer:
         # Tabs
         tab_buy = "[ BUY ]" if mode == "BUY" else "  BUY  "
         tab_sell = "[ SELL ]" if mode == "SELL" else "  SELL  "
+        tab_back = "[ BUYBACK ]" if mode == "BUYBACK" else "  BUYBACK  "
         for i, c in enumerate(tab_buy):
             buffer[start_y + 2, start_x + 2 + i] = c
             self.fg_color_buffer[start_y + 2, start_x + 2 + i] = (
@@ -775,6 +849,11 @@ class Renderer:
             self.fg_color_buffer[start_y + 2, start_x + 12 + i] = (
                 (255, 255, 0) if mode == "SELL" else (150, 150, 150)
             )
+        for i, c in enumerate(tab_back):
+            buffer[start_y + 2, start_x + 23 + i] = c
+            self.fg_color_buffer[start_y + 2, start_x + 23 + i] = (
+                (255, 255, 0) if mode == "BUYBACK" else (150, 150, 150)
+            )
 
         # Gold
         g_txt = f"Gold: {inv.gold if inv else 0}g"
@@ -784,8 +863,10 @@ class Renderer:
         buffer[start_y + 4, start_x + 1] = "─" * (win_w - 2)
 
         y_off = start_y + 6
-        if mode == "BUY" and shop:
-            for i, (iname, price) in enumerate(shop.items):
+        if mode in ("BUY", "BUYBACK") and shop:
+            # Stock unlocked by reputation, or the player's recent sales when
+            # buying back, comes from the engine; default to base items
+            for i, (iname, price) in enumerate(stock if stock is not None else shop.items):
                 if y_off >= start_y + win_h - 2:
                     break
                 prefix = ">>" if i == selection else "  "
@@ -802,7 +883,8 @@ class Renderer:
                 item = entity_manager.get_component(item_id, Item)
                 if not item:
                     continue
-                price = max(1, item.value // 2)
+                # Local prices come from the engine, like buy stock
+                price = stock[i][1] if stock else max(1, item.value // 2)
                 prefix = ">>" if i == selection else "  "
                 txt = f"{prefix} {item.name} - {price}g"
                 color = (255, 255, 0) if i == selection else item.color
@@ -811,6 +893,118 @@ class Renderer:
                     self.fg_color_buffer[y_off, start_x + 2 + j] = color
                 y_off += 1
 
+    def _render_travel(self, buffer, options, selection):
+        """Render the waypoint travel menu."""
+        entries = [f"{name} - {cost}g" for name, cost in options]
+        footer = " Enter: travel  Esc: close "
+        self._render_menu(buffer, " WAYPOINT TRAVEL ", entries, selection, footer)
+
+    def _render_world_select(self, buffer, options, selection):
+        """Render the list of worlds to play in."""
+        entries = [f"{name} ({ruleset})" for name, ruleset in options]
+        self._render_menu(
+            buffer, " CHOOSE A WORLD ", entries, selection, " Enter: play  Esc: stay "
+        )
+
+    def _render_menu(self, buffer, title, entries, selection, footer):
+        """Render a boxed list with the selected entry highlighted."""
+        win_w, win_h = 40, min(20, len(entries) + 6)
+        buffer_w = self.screen_width // 2
+        start_x = max(0, (buffer_w - win_w) // 2)
+        start_y = max(0, (self.screen_height - win_h) // 2)
+
+        for y in range(win_h):
+            for x in range(win_w):
+                bx, by = start_x + x, start_y + y
+                if 0 <= bx < buffer_w and 0 <= by < self.screen_height - 1:
+                    buffer[by, bx] = " "
+                    self.bg_color_buffer[by, bx] = (15, 25, 40)
+                    if x == 0 or x == win_w - 1 or y == 0 or y == win_h - 1:
+                        buffer[by, bx] = "*"
+                        self.fg_color_buffer[by, bx] = (120, 220, 255)
+
+        for i, c in enumerate(title):
+            bx = start_x + (win_w - len(title)) // 2 + i
+            if bx < buffer_w:
+                buffer[start_y, bx] = c
+
+        y_off = start_y + 2
+        for i, entry in enumerate(entries):
+            if y_off >= start_y + win_h - 2:
+                break
+            prefix = ">>" if i == selection else "  "
+            txt = f"{prefix} {entry}"[: win_w - 4]
+            color = (255, 255, 0) if i == selection else (200, 200, 200)
+            for j, c in enumerate(txt):
+                bx = start_x + 2 + j
+                if bx < buffer_w and y_off < self.screen_height - 1:
+                    buffer[y_off, bx] = c
+                    self.fg_color_buffer[y_off, bx] = color
+            y_off += 1
+
+        for i, c in enumerate(footer):
+            bx = start_x + (win_w - len(footer)) // 2 + i
+            by = start_y + win_h - 2
+            if bx < buffer_w and by < self.screen_height - 1:
+                buffer[by, bx] = c
+                self.fg_color_buffer[by, bx] = (150, 150, 150)
+
+    def _render_world_map(self, buffer, game_map, entity_manager, player_id, overview):
+        """Render the explored world overview with the player's location."""
+        from entities.components import Position
+        from world.overview import cell_color
+
+        win_w, win_h = overview.width + 2, overview.height + 4
+        buffer_w = self.screen_width // 2
+        start_x = max(0, (buffer_w - win_w) // 2)
+        start_y = max(0, (self.screen_height - win_h) // 2)
+
+        for y in range(win_h):
+            for x in range(win_w):
+                bx, by = start_x + x, start_y + y
+                if 0 <= bx < buffer_w and 0 <= by < self.screen_height - 1:
+                    buffer[by, bx] = " "
+                    self.bg_color_buffer[by, bx] = (10, 10, 20)
+                    if x == 0 or x == win_w - 1 or y == 0 or y == win_h - 1:
+                        buffer[by, bx] = "#"
+                        self.fg_color_buffer[by, bx] = (150, 200, 255)
+
+        title = " WORLD MAP "
+        for i, c in enumerate(title):
+            bx = start_x + (win_w - len(title)) // 2 + i
+            if 0 <= bx < buffer_w:
+                buffer[start_y, bx] = c
+
+        colors = {}
+        for y in range(overview.height):
+            by = start_y + 1 + y
+            if by >= self.screen_height - 1:
+                break
+            for x in range(overview.width):
+                bx = start_x + 1 + x
+                if bx >= buffer_w:
+                    break
+                tile_id = int(overview.cells[y, x])
+                if tile_id not in colors:
+                    colors[tile_id] = cell_color(game_map, tile_id)
+                self.bg_color_buffer[by, bx] = colors[tile_id]
+
+        pos = entity_manager.get_component(player_id, Position)
+        if pos:
+            bx = start_x + 1 + pos.x // overview.scale
+            by = start_y + 1 + pos.y // overview.scale
+            if 0 <= bx < buffer_w and 0 <= by < self.screen_height - 1:
+                buffer[by, bx] = "@"
+                self.fg_color_buffer[by, bx] = (255, 255, 0)
+
+        footer = f" 1:{overview.scale}  Enter: save PNG  Esc: close "
+        for i, c in enumerate(footer):
+            bx = start_x + (win_w - len(footer)) // 2 + i
+            by = start_y + win_h - 2
+            if 0 <= bx < buffer_w and by < self.screen_height - 1:
+                buffer[by, bx] = c
+                self.fg_color_buffer[by, bx] = (150, 150, 150)
+
     def _render_bank(
         self, buffer, entity_manager, player_id, bank_id, mode, selection
     ):
@@ -921,7 +1115,7 @@ class Renderer:
         """Render the help screen overlay."""
         # Window dimensions
         win_w = 46
-        win_h = 28
+        win_h = 38
 
         # Center the window
         buffer_w = self.screen_width // 2
@@ -949,12 +1143,21 @@ class Renderer:
         controls = [
             ("WASD/Arrows/Vi/Num", "Movement"),
             ("QEZC / YUBN / 1-9", "Diagonal Movement"),
+            ("Click / Tap", "Walk to Location"),
             ("Enter/Space/x", "Select/Interact"),
             ("Space / o", "Action Menu / Attack"),
             ("i / I", "Toggle Inventory"),
             ("C / K (Shift)", "Stat Allocation"),
             ("g / ,", "Pick up Item"),
             ("t / f", "Target/Fire Weapon"),
+            ("T (Shift)", "Waypoint Travel"),
+            ("Z (Shift)", "Zone Info"),
+            ("M (Shift)", "World Map"),
+            ("L (Shift)", "Nearby Landmarks"),
+            ("R (Shift)", "Faction Reputation"),
+            ("H (Shift)", "Sneak (Stealth)"),
+            ("G (Shift)", "Hardcore Memorial"),
+            ("B (Shift)", "Recall Home"),
             (". / 5", "Wait/Rest"),
             ("1, 2, 3", "Cast Skills"),
             ("?", "Show this Help"),
@@ -1259,9 +1462,10 @@ class Renderer:
         messages: list = None,
         offset_x: int = 1,
         offset_y: int = 1,
+        clock_text: str = "",
     ):
         """Render UI elements to the buffer."""
-        from entities.components import Health, Mana, Position, Level, Skills
+        from entities.components import Health, Mana, Position, Level, Skills, Breath
 
         # Use the map render width plus borders for UI width
         buffer_width = self.map_render_width + 2
@@ -1338,6 +1542,24 @@ class Renderer:
                 buffer, offset_x + 1, skills_y, skill_info, (200, 200, 255)
             )
 
+        # Time of day beside the skills
+        if clock_text and skills_y < self.screen_height - 1:
+            self._draw_text_packed(buffer, offset_x + 12, skills_y, clock_text, (200, 180, 255))
+
+        # Air left while underwater
+        player_breath = entity_manager.get_component(player_id, Breath)
+        if player_breath and player_breath.underwater and skills_y < self.screen_height - 1:
+            self._draw_bar(
+                buffer,
+                offset_x + 32,
+                skills_y,
+                8,
+                player_breath.current,
+                player_breath.maximum,
+                (150, 220, 255),
+                (20, 60, 100),
+            )
+
         # Draw Message Log (shifted down by 1 line)
         log_start_y = skills_y + 1
         if messages and log_start_y < self.screen_height - 1:
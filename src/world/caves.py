@@ -0,0 +1,290 @@
+"""
+Cave systems on the Z-levels beneath the overworld.
+Each level below the surface (z = 1 is the first, counting down) holds a
+number of cave systems, one under every way down from the level above: the
+dungeon entrances and natural cave mouths on the overworld, and the stairs
+further down inside each cave. A system is grown by cellular automata into
+rounded caverns, and any pocket cut off from its entrance is tunnelled back
+to it so the whole system can be walked. Walls facing the caves are seamed
+with ore, richer the deeper the level.
+
+Stairs line up between levels: the stairs down at (x, y) on one level arrive
+on the stairs up at the same (x, y) on the level below.
+"""
+
+import random
+from collections import deque
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+import numpy as np
+
+from world.map import (
+    TILE_FLOOR,
+    TILE_GRASS,
+    TILE_ORE_GOLD,
+    TILE_ORE_IRON,
+    TILE_STAIRS_DOWN,
+    TILE_STAIRS_UP,
+    TILE_WALL,
+)
+
+FILL = 0.45  # Share of a fresh cave that starts as rock
+SMOOTH_STEPS = 4
+MIN_POCKET = 12  # Smaller cut-off pockets are filled in rather than tunnelled to
+CHAMBER = 2  # Tiles from the entrance kept open around the stairs
+# Chance per cave-facing wall of a seam, by ore; gold is scaled by depth
+ORE_CHANCE = {TILE_ORE_IRON: 0.04, TILE_ORE_GOLD: 0.006}
+DEEP_ORES = (TILE_ORE_GOLD,)
+MOUTH_BIOMES = ("hill", "forest", "grassland")
+EIGHT = [(dx, dy) for dy in (-1, 0, 1) for dx in (-1, 0, 1) if dx or dy]
+
+Point = Tuple[int, int]
+
+
+@dataclass
+class CaveSystem:
+    """One connected cave on a level, entered by stairs from the level above."""
+
+    z: int
+    x: int
+    y: int
+    width: int
+    height: int
+    entrance: Point
+    exits: List[Point] = field(default_factory=list)  # Stairs further down
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "CaveSystem":
+        return cls(
+            **dict(
+                data,
+                entrance=tuple(data["entrance"]),
+                exits=[tuple(point) for point in data.get("exits", [])],
+            )
+        )
+
+
+def _smooth(rock: List[List[bool]]) -> List[List[bool]]:
+    """One cellular automata step: rock where most neighbours are rock."""
+    height, width = len(rock), len(rock[0])
+    smoothed = []
+    for y in range(height):
+        row = []
+        for x in range(width):
+            walls = 0
+            for dx, dy in EIGHT:
+                nx, ny = x + dx, y + dy
+                # Beyond the edge counts as rock, so caves stay inside
+                if not (0 <= nx < width and 0 <= ny < height) or rock[ny][nx]:
+                    walls += 1
+            row.append(walls >= 5 or (rock[y][x] and walls >= 4))
+        smoothed.append(row)
+    return smoothed
+
+
+def _pockets(open_: List[List[bool]]) -> List[List[Point]]:
+    """The separate open areas of a cave, each as a list of its tiles."""
+    height, width = len(open_), len(open_[0])
+    seen = set()
+    pockets = []
+    for y in range(height):
+        for x in range(width):
+            if not open_[y][x] or (x, y) in seen:
+                continue
+            pocket, queue = [], deque([(x, y)])
+            seen.add((x, y))
+            while queue:
+                px, py = queue.popleft()
+                pocket.append((px, py))
+                for dx, dy in ((1, 0), (-1, 0), (0, 1), (0, -1)):
+                    nx, ny = px + dx, py + dy
+                    if (
+                        0 <= nx < width
+                        and 0 <= ny < height
+                        and open_[ny][nx]
+                        and (nx, ny) not in seen
+                    ):
+                        seen.add((nx, ny))
+                        queue.append((nx, ny))
+            pockets.append(pocket)
+    return pockets
+
+
+def _tunnel(open_: List[List[bool]], start: Point, end: Point):
+    """Dig an L-shaped passage between two points."""
+    (x, y), (ex, ey) = start, end
+    while x != ex:
+        open_[y][x] = True
+        x += 1 if ex > x else -1
+    while y != ey:
+        open_[y][x] = True
+        y += 1 if ey > y else -1
+    open_[y][x] = True
+
+
+def carve_cave(
+    width: int,
+    height: int,
+    start: Point,
+    rng: random.Random,
+    fill: float = FILL,
+    steps: int = SMOOTH_STEPS,
+) -> List[List[bool]]:
+    """Open tiles of one cave system, all reachable from start."""
+    rock = [[rng.random() < fill for _ in range(width)] for _ in range(height)]
+    for _ in range(steps):
+        rock = _smooth(rock)
+    # The rim stays rock, keeping each cave inside its area
+    open_ = [
+        [not tile and 0 < x < width - 1 for x, tile in enumerate(row)]
+        for row in rock
+    ]
+    open_[0] = [False] * width
+    open_[-1] = [False] * width
+
+    sx, sy = start
+    for y in range(max(1, sy - CHAMBER), min(height - 1, sy + CHAMBER + 1)):
+        for x in range(max(1, sx - CHAMBER), min(width - 1, sx + CHAMBER + 1)):
+            open_[y][x] = True
+
+    for pocket in _pockets(open_):
+        if start in pocket:
+            continue
+        if len(pocket) < MIN_POCKET:
+            for x, y in pocket:
+                open_[y][x] = False
+        else:
+            _tunnel(open_, pocket[0], start)
+    return open_
+
+
+def seam_ore(
+    open_: List[List[bool]], depth: int, rng: random.Random
+) -> Dict[Point, int]:
+    """Ore tiles for the walls of a cave that face into it."""
+    height, width = len(open_), len(open_[0])
+    ores = {}
+    for y in range(height):
+        for x in range(width):
+            if open_[y][x]:
+                continue
+            if not any(
+                0 <= x + dx < width and 0 <= y + dy < height and open_[y + dy][x + dx]
+                for dx, dy in EIGHT
+            ):
+                continue
+            for ore, chance in ORE_CHANCE.items():
+                if rng.random() < chance * (depth if ore in DEEP_ORES else 1):
+                    ores[(x, y)] = ore
+                    break
+    return ores
+
+
+def carve_system(
+    level,
+    entrance: Point,
+    z: int,
+    rng: random.Random,
+    size: Tuple[int, int],
+    exits: int = 1,
+) -> CaveSystem:
+    """Grow a cave system around an entrance on a level; returns the system.
+
+    The caves are added to the level without filling in any already there.
+    Stairs are left to the caller, once every system on the level is carved.
+    """
+    level_height, level_width = level.shape
+    width = min(size[0], level_width - 2)
+    height = min(size[1], level_height - 2)
+    ex, ey = entrance
+    x0 = max(1, min(ex - width // 2, level_width - width - 1))
+    y0 = max(1, min(ey - height // 2, level_height - height - 1))
+    start = (ex - x0, ey - y0)
+
+    open_ = carve_cave(width, height, start, rng)
+    for (x, y), ore in seam_ore(open_, z, rng).items():
+        if level[y0 + y, x0 + x] == TILE_WALL:
+            level[y0 + y, x0 + x] = ore
+    floor = []
+    for y in range(height):
+        for x in range(width):
+            if open_[y][x]:
+                level[y0 + y, x0 + x] = TILE_FLOOR
+                floor.append((x0 + x, y0 + y))
+
+    # Ways further down lie well away from the way in
+    far = [
+        (x, y)
+        for x, y in floor
+        if abs(x - ex) + abs(y - ey) >= min(width, height) // 3
+    ]
+    chosen = rng.sample(far, min(exits, len(far)))
+    return CaveSystem(z, x0, y0, width, height, entrance, chosen)
+
+
+def generate_caves(
+    width: int,
+    height: int,
+    entrances: Sequence[Point],
+    depth: int,
+    rng: random.Random,
+    size: Tuple[int, int] = (80, 60),
+) -> Tuple[Dict[int, np.ndarray], List[CaveSystem]]:
+    """Carve depth levels of caves below the given overworld entrances.
+
+    Returns each level's tiles by z and every cave system carved.
+    """
+    levels: Dict[int, np.ndarray] = {}
+    systems: List[CaveSystem] = []
+    for z in range(1, depth + 1):
+        if not entrances:
+            break
+        level = np.full((height, width), TILE_WALL, dtype=np.uint8)
+        carved = [
+            carve_system(level, point, z, rng, size, exits=1 if z < depth else 0)
+            for point in entrances
+        ]
+        ups = {system.entrance for system in carved}
+        for system in carved:
+            system.exits = [point for point in system.exits if point not in ups]
+            level[system.entrance[1], system.entrance[0]] = TILE_STAIRS_UP
+            for x, y in system.exits:
+                level[y, x] = TILE_STAIRS_DOWN
+        levels[z] = level
+        systems.extend(carved)
+        entrances = list(dict.fromkeys(p for s in carved for p in s.exits))
+    return levels, systems
+
+
+def find_cave_mouths(
+    world_map,
+    biome_map,
+    rng: random.Random,
+    count: int,
+    margin: int = 50,
+    avoid: Optional[Sequence[Point]] = None,
+) -> List[Point]:
+    """Open ground in the hills and woods to open as natural cave mouths.
+
+    Mouths keep margin tiles from the world's edge and from each other and
+    anything in avoid.
+    """
+    height, width = world_map.shape
+    taken = list(avoid or [])
+    mouths: List[Point] = []
+    for _ in range(count * 50):
+        if len(mouths) >= count or width <= 2 * margin or height <= 2 * margin:
+            break
+        x = rng.randrange(margin, width - margin)
+        y = rng.randrange(margin, height - margin)
+        if world_map[y, x] != TILE_GRASS or biome_map[y, x] not in MOUTH_BIOMES:
+            continue
+        if any(abs(x - tx) + abs(y - ty) < margin for tx, ty in taken):
+            continue
+        mouths.append((x, y))
+        taken.append((x, y))
+    return mouths
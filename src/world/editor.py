@@ -0,0 +1,232 @@
+"""
+World map editing for handcrafted towns and dungeons.
+An external editor tool drives this through admin requests: it selects a
+region to work on, reads back its tiles and what is placed there, paints
+tiles, places monsters, NPCs, items, lights, spawners and points of interest
+on top of the procedural terrain, and saves the result with the world.
+
+Edits go straight into the live world map, so players see them at once.
+Nothing outside the selected region can be changed, which keeps a stray
+coordinate in the tool from scribbling over the rest of the world.
+"""
+
+from typing import Any, Callable, Dict, List, Optional, Sequence
+
+from world.map import CHAR_MAP
+from world.poi import POI_KINDS
+from world.tile_codec import ENCODINGS, RLE, TileCodecError, decode_tiles, encode_tiles
+
+MAX_REGION = 64  # Largest selection, in tiles per side
+PLACEABLE = ("monster", "item", "light", "spawner")  # NPCs are monsters
+DEFAULT_RESPAWN = 60.0  # Seconds before a spawner replaces its monster
+
+
+class EditorError(ValueError):
+    """An edit that cannot be made."""
+
+
+class MapEditor:
+    """Edits the persistent world within a selected region."""
+
+    def __init__(
+        self, world, game_map, known: Optional[Callable[[str, str], bool]] = None
+    ):
+        self.world = world  # PersistentWorld: tiles, placed entities and POIs
+        self.game_map = game_map  # Shares the world's tiles; knows valid tile ids
+        self.known = known  # (kind, subtype) -> whether that content exists
+        self.selection: Optional[tuple] = None  # (x, y, width, height)
+        self.dirty = False  # Edits not yet saved
+
+    def select(
+        self, x: int, y: int, width: int, height: int, encoding: str = "raw"
+    ) -> Dict[str, Any]:
+        """Choose the region to edit and describe what is in it."""
+        if encoding not in ENCODINGS:
+            raise EditorError(f"Tile encoding must be one of {', '.join(ENCODINGS)}")
+        for value in (x, y, width, height):
+            _whole(value)
+        if not (1 <= width <= MAX_REGION and 1 <= height <= MAX_REGION):
+            raise EditorError(f"A region is 1 to {MAX_REGION} tiles on each side")
+        if x < 0 or y < 0 or x + width > self.game_map.width:
+            raise EditorError("The region must lie inside the world")
+        if y + height > self.game_map.height:
+            raise EditorError("The region must lie inside the world")
+        self.selection = (x, y, width, height)
+        return self.region(encoding)
+
+    def region(self, encoding: str = "raw") -> Dict[str, Any]:
+        """The selected region's tiles (rows of ids, or encoded as in
+        world.tile_codec), placed entities and POIs."""
+        x, y, width, height = self._selected()
+        tiles = self.world.world_map[y : y + height, x : x + width]
+        placed = self.world.preplaced_entities
+        pois = [poi.to_dict() for poi in self.world.pois.pois.values()]
+        rows = [[int(tile) for tile in row] for row in tiles]
+        return {
+            "x": x,
+            "y": y,
+            "width": width,
+            "height": height,
+            "tiles": encode_tiles(rows) if encoding == RLE else rows,
+            "placed": [dict(entry) for entry in placed if self._inside(entry)],
+            "pois": [poi for poi in pois if self._inside(poi)],
+        }
+
+    def set_tiles(self, x: int, y: int, rows: Sequence[Any]) -> int:
+        """Paint rows of tiles from (x, y); returns how many changed.
+
+        A row is either a string of map characters, as in maps.toml, or a
+        list of tile ids where None leaves a tile as it is. rows may also be
+        a whole grid encoded by world.tile_codec.
+        """
+        _whole(x)
+        _whole(y)
+        if isinstance(rows, dict):
+            try:
+                rows = decode_tiles(rows)
+            except TileCodecError as e:
+                raise EditorError(f"Encoded tiles: {e}") from None
+        if not isinstance(rows, list) or not rows:
+            raise EditorError("rows must be a non-empty list")
+        painted = []
+        for dy, row in enumerate(rows):
+            cells = list(row) if isinstance(row, (str, list)) else None
+            if cells is None:
+                raise EditorError(f"Row {dy} must be a string or a list of tile ids")
+            for dx, cell in enumerate(cells):
+                if cell is None:
+                    continue
+                tile = self._tile_id(cell)
+                self._inside_or_raise(x + dx, y + dy)
+                painted.append((x + dx, y + dy, tile))
+
+        # Validated in full first, so a bad cell leaves the map untouched
+        changed = 0
+        for tx, ty, tile in painted:
+            if self.world.world_map[ty, tx] != tile:
+                self.world.world_map[ty, tx] = tile
+                changed += 1
+        self.dirty = self.dirty or changed > 0
+        return changed
+
+    def place(
+        self, kind: str, subtype: str, x: int, y: int, respawn: Optional[float] = None
+    ) -> Dict[str, Any]:
+        """Place a monster, item, light or spawner; returns its entry for spawning."""
+        if kind not in PLACEABLE:
+            raise EditorError(f"kind must be one of {', '.join(PLACEABLE)}")
+        if kind != "spawner" and respawn is not None:
+            raise EditorError("Only spawners take respawn")
+        content = "monster" if kind == "spawner" else kind
+        if self.known and not self.known(content, str(subtype)):
+            raise EditorError(f"Unknown {content} '{subtype}'")
+        self._inside_or_raise(x, y)
+
+        entry = {"type": kind, "subtype": str(subtype), "x": x, "y": y}
+        if kind == "spawner":
+            respawn = DEFAULT_RESPAWN if respawn is None else float(respawn)
+            if respawn <= 0:
+                raise EditorError("respawn must be a positive number of seconds")
+            entry["respawn"] = respawn
+        self.world.preplaced_entities.append(entry)
+        self.dirty = True
+        return entry
+
+    def remove(self, x: int, y: int, kind: Optional[str] = None) -> List[Dict[str, Any]]:
+        """Take placed entries (of one kind, if given) off a tile; returns them."""
+        self._inside_or_raise(x, y)
+        removed = [
+            entry
+            for entry in self.world.preplaced_entities
+            if entry["x"] == x and entry["y"] == y and kind in (None, entry["type"])
+        ]
+        if removed:
+            self.world.preplaced_entities[:] = [
+                entry for entry in self.world.preplaced_entities if entry not in removed
+            ]
+            self.dirty = True
+        return removed
+
+    def add_poi(
+        self, name: str, kind: str, x: int, y: int, waypoint: Optional[str] = None
+    ) -> Dict[str, Any]:
+        """Register a point of interest in the region."""
+        if kind not in POI_KINDS:
+            raise EditorError(f"kind must be one of {', '.join(POI_KINDS)}")
+        if not str(name).strip():
+            raise EditorError("A point of interest needs a name")
+        self._inside_or_raise(x, y)
+        poi = self.world.pois.add(str(name).strip(), kind, x, y, waypoint=waypoint)
+        self.dirty = True
+        return poi.to_dict()
+
+    def save(self) -> bool:
+        """Write the world to storage; False if there was nothing to save."""
+        if not self.dirty:
+            return False
+        self.world.save_world()
+        self.dirty = False
+        return True
+
+    def _selected(self) -> tuple:
+        if self.selection is None:
+            raise EditorError("Select a region first")
+        return self.selection
+
+    def _inside(self, point: Dict[str, Any]) -> bool:
+        x, y, width, height = self._selected()
+        return x <= point["x"] < x + width and y <= point["y"] < y + height
+
+    def _inside_or_raise(self, x: int, y: int):
+        _whole(x)
+        _whole(y)
+        if not self._inside({"x": x, "y": y}):
+            raise EditorError(f"({x}, {y}) is outside the selected region")
+
+    def _tile_id(self, cell: Any) -> int:
+        if isinstance(cell, str):
+            if cell not in CHAR_MAP:
+                raise EditorError(f"Unknown map character {cell!r}")
+            return CHAR_MAP[cell]
+        if isinstance(cell, bool) or not isinstance(cell, int):
+            raise EditorError(f"Tile {cell!r} must be a tile id or map character")
+        if cell not in self.game_map.tile_definitions:
+            raise EditorError(f"Unknown tile id {cell}")
+        return cell
+
+
+class Spawners:
+    """Placed spawners and the monster each keeps alive."""
+
+    def __init__(self):
+        self.active: List[Dict[str, Any]] = []  # {"entry", "eid", "timer"}
+
+    def track(self, entry: Dict[str, Any], eid: int):
+        self.active.append({"entry": entry, "eid": eid, "timer": 0.0})
+
+    def forget(self, entries: Sequence[Dict[str, Any]]) -> List[Dict[str, Any]]:
+        """Stop replacing monsters for spawners that were removed; returns them."""
+        removed = {id(entry) for entry in entries}
+        gone = [s for s in self.active if id(s["entry"]) in removed]
+        self.active = [s for s in self.active if id(s["entry"]) not in removed]
+        return gone
+
+    def due(self, dt: float, alive: Callable[[int], bool]) -> List[Dict[str, Any]]:
+        """Spawners whose monster has been gone for their respawn time."""
+        ready = []
+        for spawner in self.active:
+            if alive(spawner["eid"]):
+                spawner["timer"] = 0.0
+                continue
+            spawner["timer"] += dt
+            if spawner["timer"] >= spawner["entry"].get("respawn", DEFAULT_RESPAWN):
+                ready.append(spawner)
+        return ready
+
+    def respawned(self, spawner: Dict[str, Any], eid: int):
+        spawner["eid"], spawner["timer"] = eid, 0.0
+
+
+def _whole(value: Any):
+    if isinstance(value, bool) or not isinstance(value, int):
+        raise EditorError("Coordinates and sizes must be whole numbers")
@@ -0,0 +1,143 @@
+"""
+Hydrology for world generation: rivers, lakes and beaches.
+Rivers start on high ground and run downhill, always to the lowest
+neighbouring tile, until they reach the sea, a lake or another river. A
+river that runs into a hollow with no way down fills it as a lake until the
+water finds an outlet lower than its surface, and carries on from there (or
+stays a lake if the hollow is too large to fill). Coasts get a strip of sand
+between the sea and the land behind it.
+
+Roads cross rivers on bridges (see world.settlements.lay_road).
+"""
+
+import heapq
+import random
+from typing import List, Optional, Set, Tuple
+
+import numpy as np
+
+from world.map import TILE_SAND, TILE_TREE, TILE_WALL, TILE_WATER
+
+NEIGHBOURS = ((1, 0), (-1, 0), (0, 1), (0, -1))  # Four-way, so rivers never leak
+WIDE_BELOW = 0.3  # Rivers widen to two tiles in lowland below this elevation
+MAX_RIVER_LENGTH = 2000
+
+Point = Tuple[int, int]
+
+
+def fill_lake(
+    elevation, start: Point, max_tiles: int
+) -> Tuple[Set[Point], Optional[Point]]:
+    """Fill the hollow around start; returns its tiles and the outlet, if any.
+
+    Tiles are flooded lowest first (a priority flood). The first tile lower
+    than the water already risen to is where the lake spills over.
+    """
+    height, width = elevation.shape
+    level = elevation[start[1], start[0]]
+    lake: Set[Point] = set()
+    seen = {start}
+    frontier = [(level, start)]
+    while frontier and len(lake) < max_tiles:
+        value, (x, y) = heapq.heappop(frontier)
+        if value < level:
+            return lake, (x, y)
+        level = value
+        lake.add((x, y))
+        for dx, dy in NEIGHBOURS:
+            nx, ny = x + dx, y + dy
+            if 0 <= nx < width and 0 <= ny < height and (nx, ny) not in seen:
+                seen.add((nx, ny))
+                heapq.heappush(frontier, (elevation[ny, nx], (nx, ny)))
+    return lake, None
+
+
+def trace_river(
+    elevation, water, start: Point, max_lake: int
+) -> Tuple[List[Point], List[Set[Point]]]:
+    """Follow the water downhill from start; returns the river and its lakes.
+
+    water marks tiles that are already water; the river ends on reaching one.
+    """
+    height, width = elevation.shape
+    river: List[Point] = []
+    lakes: List[Set[Point]] = []
+    visited = set()
+    x, y = start
+    while len(river) < MAX_RIVER_LENGTH:
+        if water[y, x]:
+            break
+        river.append((x, y))
+        visited.add((x, y))
+        downhill = [
+            (elevation[y + dy, x + dx], (x + dx, y + dy))
+            for dx, dy in NEIGHBOURS
+            if 0 <= x + dx < width and 0 <= y + dy < height
+            and (x + dx, y + dy) not in visited
+        ]
+        if not downhill:
+            break
+        lowest, (lx, ly) = min(downhill)
+        if lowest < elevation[y, x] or water[ly, lx]:
+            x, y = lx, ly
+            continue
+
+        # A hollow: pool up until the water spills over somewhere lower
+        lake, outlet = fill_lake(elevation, (x, y), max_lake)
+        lakes.append(lake)
+        visited |= lake
+        if outlet is None:
+            break
+        x, y = outlet
+    return river, lakes
+
+
+def carve_rivers(
+    world_map,
+    elevation,
+    rng: random.Random,
+    count: int,
+    source_elevation: float = 0.6,
+    max_lake: int = 300,
+) -> Tuple[int, int]:
+    """Carve up to count rivers from high ground; returns rivers and lakes made."""
+    height, width = elevation.shape
+    rivers = lakes = 0
+    for _ in range(count * 20):
+        if rivers >= count:
+            break
+        x, y = rng.randrange(width), rng.randrange(height)
+        if elevation[y, x] < source_elevation or world_map[y, x] == TILE_WATER:
+            continue
+        water = world_map == TILE_WATER
+        river, pools = trace_river(elevation, water, (x, y), max_lake)
+        if len(river) < 10:
+            continue  # A trickle; not worth a river
+        for rx, ry in river:
+            world_map[ry, rx] = TILE_WATER
+            if elevation[ry, rx] < WIDE_BELOW and rx + 1 < width:
+                world_map[ry, rx + 1] = TILE_WATER
+        for pool in pools:
+            for lx, ly in pool:
+                world_map[ly, lx] = TILE_WATER
+        rivers += 1
+        lakes += len(pools)
+    return rivers, lakes
+
+
+def add_beaches(world_map, biome_map, width: int = 2) -> int:
+    """Turn land within width tiles of the sea into sand; returns tiles changed."""
+    sea = biome_map == "ocean"
+    near = sea.copy()
+    for _ in range(width):
+        grown = near.copy()
+        grown[1:, :] |= near[:-1, :]
+        grown[:-1, :] |= near[1:, :]
+        grown[:, 1:] |= near[:, :-1]
+        grown[:, :-1] |= near[:, 1:]
+        near = grown
+    # Mountains and trees meet the sea as cliffs and woods, not beaches
+    beach = near & ~sea & (world_map != TILE_WALL) & (world_map != TILE_TREE)
+    beach &= (world_map != TILE_WATER) & (world_map != TILE_SAND)
+    world_map[beach] = TILE_SAND
+    return int(np.count_nonzero(beach))
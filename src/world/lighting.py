@@ -0,0 +1,136 @@
+"""
+Light levels for the roguelike game.
+Light comes from the sky (scaled by the time of day, none underground),
+from glowing tiles such as lava and from light-source entities like torches.
+Levels run from 0 (pitch dark) to 1 (full daylight) and are computed for a
+window around the player, which is all FOV and rendering need.
+"""
+
+from typing import Iterable, List, Tuple
+
+import numpy as np
+
+from world.map import GameMap
+
+LIGHT_WINDOW = 24  # Tiles either side of the player that get a light level
+MIN_VISIBLE_LIGHT = 0.25  # Tiles darker than this cannot be seen
+NIGHT_VISION = (2, 0.6)  # (radius, intensity) the player always sees by
+
+
+class LightMap:
+    """Light levels for a rectangle of the map."""
+
+    def __init__(self, levels: np.ndarray, x0: int, y0: int, ambient: float):
+        self.levels = levels  # (height, width) float32
+        self.x0 = x0
+        self.y0 = y0
+        self.ambient = ambient  # Level outside the computed window
+
+    def at(self, x: int, y: int) -> float:
+        lx, ly = x - self.x0, y - self.y0
+        if 0 <= ly < self.levels.shape[0] and 0 <= lx < self.levels.shape[1]:
+            return float(self.levels[ly, lx])
+        return self.ambient
+
+    def slice(self, x0: int, y0: int, x1: int, y1: int) -> np.ndarray:
+        """Levels for map rows y0:y1 and columns x0:x1, ambient where not computed."""
+        out = np.full((y1 - y0, x1 - x0), self.ambient, dtype=np.float32)
+        sx0, sy0 = max(x0, self.x0), max(y0, self.y0)
+        sx1 = min(x1, self.x0 + self.levels.shape[1])
+        sy1 = min(y1, self.y0 + self.levels.shape[0])
+        if sx0 < sx1 and sy0 < sy1:
+            out[sy0 - y0 : sy1 - y0, sx0 - x0 : sx1 - x0] = self.levels[
+                sy0 - self.y0 : sy1 - self.y0, sx0 - self.x0 : sx1 - self.x0
+            ]
+        return out
+
+
+def ambient_light(daylight: float, underground: bool) -> float:
+    """Light from the sky: none below ground."""
+    return 0.0 if underground else max(0.0, min(1.0, daylight))
+
+
+def tile_light_sources(
+    game_map: GameMap, x0: int, y0: int, x1: int, y1: int
+) -> List[Tuple[int, int, int, float]]:
+    """Glowing tiles (tile definitions with a light radius) in a map rectangle."""
+    glowing = {
+        tile_id: tile_def.light
+        for tile_id, tile_def in game_map.tile_definitions.items()
+        if tile_def.light > 0
+    }
+    if not glowing:
+        return []
+
+    x0, y0 = max(0, x0), max(0, y0)
+    x1, y1 = min(game_map.width, x1), min(game_map.height, y1)
+    tiles = game_map.tiles[y0:y1, x0:x1]
+    sources = []
+    for tile_id, radius in glowing.items():
+        ys, xs = np.nonzero(tiles == tile_id)
+        sources.extend((x0 + int(x), y0 + int(y), radius, 1.0) for x, y in zip(xs, ys))
+    return sources
+
+
+def compute_light(
+    game_map: GameMap,
+    center_x: int,
+    center_y: int,
+    ambient: float,
+    sources: Iterable[Tuple[int, int, int, float]],
+    window: int = LIGHT_WINDOW,
+) -> LightMap:
+    """Light levels around a point from ambient light, entity sources and glowing tiles.
+
+    Sources are (x, y, radius, intensity); light fades linearly to nothing just
+    past the radius. Walls do not cast shadows, but the player still needs a
+    line of sight (FOV) to see a lit tile.
+    """
+    x0, y0 = center_x - window, center_y - window
+    size = window * 2 + 1
+    levels = np.full((size, size), ambient, dtype=np.float32)
+
+    # Glowing tiles just outside the window can still light its edge
+    max_tile_radius = max(
+        (tile_def.light for tile_def in game_map.tile_definitions.values()), default=0
+    )
+    all_sources = list(sources) + tile_light_sources(
+        game_map,
+        x0 - max_tile_radius,
+        y0 - max_tile_radius,
+        x0 + size + max_tile_radius,
+        y0 + size + max_tile_radius,
+    )
+
+    for sx, sy, radius, intensity in all_sources:
+        if radius <= 0:
+            continue
+        # Bounding box of the source clipped to the window
+        bx0, by0 = max(sx - radius, x0), max(sy - radius, y0)
+        bx1, by1 = min(sx + radius + 1, x0 + size), min(sy + radius + 1, y0 + size)
+        if bx0 >= bx1 or by0 >= by1:
+            continue
+        ys, xs = np.mgrid[by0:by1, bx0:bx1]
+        distance = np.sqrt((xs - sx) ** 2 + (ys - sy) ** 2)
+        light = np.clip(1.0 - distance / (radius + 1), 0.0, 1.0) * intensity
+        region = levels[by0 - y0 : by1 - y0, bx0 - x0 : bx1 - x0]
+        np.maximum(region, light, out=region)
+
+    np.clip(levels, 0.0, 1.0, out=levels)
+    return LightMap(levels, x0, y0, ambient)
+
+
+def lit_visibility(
+    fov: np.ndarray, light_map: LightMap, threshold: float = MIN_VISIBLE_LIGHT
+) -> np.ndarray:
+    """Restrict a full-map FOV array to tiles bright enough to see."""
+    visible = np.zeros_like(fov)
+    h, w = fov.shape
+    x0, y0 = max(0, light_map.x0), max(0, light_map.y0)
+    x1 = min(w, light_map.x0 + light_map.levels.shape[1])
+    y1 = min(h, light_map.y0 + light_map.levels.shape[0])
+    if x0 < x1 and y0 < y1:
+        lit = light_map.slice(x0, y0, x1, y1) >= threshold
+        visible[y0:y1, x0:x1] = fov[y0:y1, x0:x1] & lit
+    return visible
+
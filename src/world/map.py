@@ -25,6 +25,11 @@ TILE_MUSHROOM = 18
 TILE_ROCK_SMALL = 19
 TILE_BUSH = 20
 TILE_WALL_RUINED = 21
+TILE_WAYPOINT = 22
+TILE_PORTAL = 23
+TILE_BRIDGE = 24
+TILE_ORE_IRON = 25
+TILE_ORE_GOLD = 26
 
 # Centralized character mapping for loading maps from text
 CHAR_MAP = {
@@ -51,6 +56,9 @@ CHAR_MAP = {
     "R": TILE_ROCK_SMALL,
     "B": TILE_BUSH,
     "%": TILE_WALL_RUINED,
+    "H": TILE_BRIDGE,
+    "O": TILE_ORE_IRON,
+    "$": TILE_ORE_GOLD,
     # Box drawing walls
     "║": TILE_WALL,
     "═": TILE_WALL,
@@ -68,9 +76,25 @@ CHAR_MAP = {
 
 
 class Tile:
-    """Represents a single tile in the game world."""
-
-    __slots__ = ["tile_type", "walkable", "transparent", "char", "fg_color", "bg_color"]
+    """Represents a single tile in the game world and its terrain properties."""
+
+    __slots__ = [
+        "tile_type",
+        "walkable",
+        "transparent",
+        "char",
+        "fg_color",
+        "bg_color",
+        "name",
+        "move_cost",
+        "struggle_chance",
+        "slippery",
+        "damage",
+        "contact_damage",
+        "swim_skill",
+        "light",
+        "ore",
+    ]
 
     def __init__(
         self,
@@ -80,6 +104,15 @@ class Tile:
         char: str,
         fg_color: Tuple[int, int, int],
         bg_color: Optional[Tuple[int, int, int]] = None,
+        name: str = "",
+        move_cost: float = 1.0,
+        struggle_chance: float = 0.0,
+        slippery: bool = False,
+        damage: float = 0.0,
+        contact_damage: int = 0,
+        swim_skill: int = 0,
+        light: int = 0,
+        ore: str = "",
     ):
         self.tile_type = tile_type
         self.walkable = walkable
@@ -87,6 +120,23 @@ class Tile:
         self.char = char
         self.fg_color = fg_color
         self.bg_color = bg_color
+        self.name = name
+        # Pathfinding cost of entering this tile (never below 1)
+        self.move_cost = max(1.0, move_cost)
+        # Chance that a step out of this tile fails (deep sand, snow)
+        self.struggle_chance = struggle_chance
+        # Entities keep sliding across slippery tiles
+        self.slippery = slippery
+        # Fraction of max HP lost when stepping in (hazards such as lava)
+        self.damage = damage
+        # Flat HP lost when bumping into this tile
+        self.contact_damage = contact_damage
+        # Swimming level needed to enter (0 = cannot be swum)
+        self.swim_skill = swim_skill
+        # Radius of light the tile gives off (lava, portals)
+        self.light = light
+        # Item mined out of the tile by walking into it (ore seams)
+        self.ore = ore
 
 
 class GameMap:
@@ -145,6 +195,15 @@ class GameMap:
                 char=data.get("char", "??"),
                 fg_color=tuple(data.get("fg", [255, 255, 255])),
                 bg_color=tuple(data.get("bg", [0, 0, 0])) if data.get("bg") else None,
+                name=data.get("name", ""),
+                move_cost=data.get("move_cost", 1.0),
+                struggle_chance=data.get("struggle_chance", 0.0),
+                slippery=data.get("slippery", False),
+                damage=data.get("damage", 0.0),
+                contact_damage=data.get("contact_damage", 0),
+                swim_skill=data.get("swim_skill", 0),
+                light=data.get("light", 0),
+                ore=data.get("ore", ""),
             )
             self.tile_definitions[tile_id] = tile_def
 
@@ -182,6 +241,34 @@ class GameMap:
             return tile_def.walkable
         return False
 
+    def get_tile(self, x: int, y: int) -> Optional[Tile]:
+        """Get the tile definition at a position, or None if out of bounds."""
+        if 0 <= x < self.width and 0 <= y < self.height:
+            return self.tile_definitions[self.tiles[y, x]]
+        return None
+
+    def can_enter(
+        self, x: int, y: int, swimming: int = 0, allow_hazards: bool = True
+    ) -> bool:
+        """
+        Check if a mover may step onto a tile.
+        Deep water needs enough swimming skill; hazards such as lava can be
+        stepped into deliberately even though they are not walkable ground.
+        """
+        tile_def = self.get_tile(x, y)
+        if tile_def is None:
+            return False
+        if tile_def.damage > 0:
+            return allow_hazards
+        if tile_def.walkable:
+            return True
+        return tile_def.swim_skill > 0 and swimming >= tile_def.swim_skill
+
+    def move_cost(self, x: int, y: int) -> float:
+        """Get the pathfinding cost of entering a tile."""
+        tile_def = self.get_tile(x, y)
+        return tile_def.move_cost if tile_def else 1.0
+
     def is_transparent(self, x: int, y: int) -> bool:
         """Check if a tile is transparent."""
         if 0 <= x < self.width and 0 <= y < self.height:
@@ -0,0 +1,111 @@
+"""
+Overview (world map) generation for the roguelike game.
+Downsamples the explored part of a GameMap into a small grid of tile ids
+that can be drawn as a world map, sent as a compact message or saved as PNG.
+"""
+
+import struct
+import zlib
+from dataclasses import dataclass
+from typing import Dict, Tuple
+
+import numpy as np
+
+from world.map import GameMap
+
+# Cell value for blocks the player has not explored
+UNEXPLORED = 255
+
+UNEXPLORED_COLOR = (0, 0, 0)
+
+
+@dataclass
+class Overview:
+    """A downsampled map; each cell holds the most common explored tile id of its block."""
+
+    cells: np.ndarray  # (height, width) uint8
+    scale: int  # World tiles per cell side
+
+    @property
+    def width(self) -> int:
+        return self.cells.shape[1]
+
+    @property
+    def height(self) -> int:
+        return self.cells.shape[0]
+
+    def to_message(self) -> Dict:
+        """Compact map_response payload: one hex string per row, two digits per cell."""
+        return {
+            "type": "map_response",
+            "width": self.width,
+            "height": self.height,
+            "scale": self.scale,
+            "unexplored": UNEXPLORED,
+            "rows": [row.tobytes().hex() for row in self.cells],
+        }
+
+
+def build_overview(game_map: GameMap, scale: int) -> Overview:
+    """Downsample a map into scale x scale blocks, respecting fog of war."""
+    scale = max(1, int(scale))
+    out_h = -(-game_map.height // scale)
+    out_w = -(-game_map.width // scale)
+
+    # Pad to whole blocks; padding counts as unexplored
+    pad_h = out_h * scale - game_map.height
+    pad_w = out_w * scale - game_map.width
+    tiles = np.pad(game_map.tiles, ((0, pad_h), (0, pad_w)))
+    explored = np.pad(game_map.explored, ((0, pad_h), (0, pad_w)))
+
+    # Count explored tiles of each type per block and keep the most common
+    best_tile = np.full((out_h, out_w), UNEXPLORED, dtype=np.uint8)
+    best_count = np.zeros((out_h, out_w), dtype=np.int32)
+    for tile_id in np.unique(tiles[explored]):
+        mask = (tiles == tile_id) & explored
+        counts = mask.reshape(out_h, scale, out_w, scale).sum(axis=(1, 3))
+        better = counts > best_count
+        best_tile[better] = tile_id
+        best_count[better] = counts[better]
+
+    return Overview(cells=best_tile, scale=scale)
+
+
+def fit_scale(game_map: GameMap, max_width: int, max_height: int) -> int:
+    """Smallest scale at which the whole map fits in max_width x max_height cells."""
+    scale_x = -(-game_map.width // max(1, max_width))
+    scale_y = -(-game_map.height // max(1, max_height))
+    return max(1, scale_x, scale_y)
+
+
+def cell_color(game_map: GameMap, tile_id: int) -> Tuple[int, int, int]:
+    """Display color of an overview cell."""
+    if tile_id == UNEXPLORED:
+        return UNEXPLORED_COLOR
+    tile_def = game_map.tile_definitions.get(int(tile_id))
+    if tile_def is None:
+        return UNEXPLORED_COLOR
+    return tile_def.bg_color or tile_def.fg_color
+
+
+def to_png(overview: Overview, game_map: GameMap) -> bytes:
+    """Encode an overview as an RGB PNG, one pixel per cell."""
+    palette = np.zeros((256, 3), dtype=np.uint8)
+    for tile_id in np.unique(overview.cells):
+        palette[tile_id] = cell_color(game_map, int(tile_id))
+    pixels = palette[overview.cells]
+
+    # Each scanline is prefixed with filter type 0 (None)
+    raw = b"".join(b"\x00" + row.tobytes() for row in pixels)
+
+    def chunk(kind: bytes, data: bytes) -> bytes:
+        body = kind + data
+        return struct.pack(">I", len(data)) + body + struct.pack(">I", zlib.crc32(body))
+
+    header = struct.pack(">IIBBBBB", overview.width, overview.height, 8, 2, 0, 0, 0)
+    return (
+        b"\x89PNG\r\n\x1a\n"
+        + chunk(b"IHDR", header)
+        + chunk(b"IDAT", zlib.compress(raw))
+        + chunk(b"IEND", b"")
+    )
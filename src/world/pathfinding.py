@@ -0,0 +1,119 @@
+"""
+A* pathfinding over the tile map.
+Shared by click-to-move for the player and by monster AI.
+"""
+
+import heapq
+from typing import Callable, List, Optional, Tuple, TYPE_CHECKING
+
+if TYPE_CHECKING:
+    from world.map import GameMap
+
+# Cost of a diagonal step relative to an orthogonal one (approx. sqrt(2))
+DIAGONAL_COST = 1.414
+
+ORTHOGONAL_STEPS = [(0, 1), (0, -1), (1, 0), (-1, 0)]
+DIAGONAL_STEPS = [(1, 1), (1, -1), (-1, 1), (-1, -1)]
+
+
+def octile_distance(x1: int, y1: int, x2: int, y2: int) -> float:
+    """Admissible heuristic for 8-directional movement with diagonal cost."""
+    dx = abs(x1 - x2)
+    dy = abs(y1 - y2)
+    return max(dx, dy) + (DIAGONAL_COST - 1.0) * min(dx, dy)
+
+
+def find_path(
+    game_map: "GameMap",
+    start: Tuple[int, int],
+    goal: Tuple[int, int],
+    blocked: Optional[Callable[[int, int], bool]] = None,
+    max_nodes: int = 2000,
+    allow_diagonal: bool = True,
+    passable: Optional[Callable[[int, int], bool]] = None,
+    move_cost: Optional[Callable[[int, int], float]] = None,
+) -> Optional[List[Tuple[int, int]]]:
+    """
+    Find a path from start to goal using A*.
+
+    Args:
+        game_map: Map providing is_walkable(x, y).
+        start: Starting tile (not included in the result).
+        goal: Destination tile (included in the result).
+        blocked: Optional callback for extra obstacles such as occupied tiles.
+            The goal tile is never treated as blocked so entities can path
+            towards a target standing on it.
+        max_nodes: Search budget; the search gives up once it is exhausted.
+        allow_diagonal: Whether diagonal steps are permitted. Diagonals may not
+            cut wall corners.
+        passable: Terrain check for a tile; defaults to game_map.is_walkable.
+        move_cost: Terrain cost multiplier for entering a tile (at least 1),
+            such as game_map.move_cost. Defaults to uniform cost.
+
+    Returns:
+        A list of tiles from the first step to the goal, or None if no path
+        was found within the budget.
+    """
+    if start == goal:
+        return []
+
+    if passable is None:
+        passable = game_map.is_walkable
+
+    gx, gy = goal
+    if not passable(gx, gy):
+        return None
+
+    steps = [(dx, dy, 1.0) for dx, dy in ORTHOGONAL_STEPS]
+    if allow_diagonal:
+        steps += [(dx, dy, DIAGONAL_COST) for dx, dy in DIAGONAL_STEPS]
+
+    frontier = [(0.0, start)]
+    came_from = {start: None}
+    cost_so_far = {start: 0.0}
+    nodes_searched = 0
+
+    while frontier and nodes_searched < max_nodes:
+        nodes_searched += 1
+        _, current = heapq.heappop(frontier)
+
+        if current == goal:
+            break
+
+        cx, cy = current
+        for dx, dy, step_cost in steps:
+            nx, ny = cx + dx, cy + dy
+            next_node = (nx, ny)
+
+            if not passable(nx, ny):
+                continue
+
+            # Diagonals may not squeeze between two blocked orthogonal tiles
+            if dx != 0 and dy != 0:
+                if not (passable(cx + dx, cy) and passable(cx, cy + dy)):
+                    continue
+
+            if blocked and next_node != goal and blocked(nx, ny):
+                continue
+
+            if move_cost:
+                new_cost = cost_so_far[current] + step_cost * move_cost(nx, ny)
+            else:
+                new_cost = cost_so_far[current] + step_cost
+            if next_node not in cost_so_far or new_cost < cost_so_far[next_node]:
+                cost_so_far[next_node] = new_cost
+                priority = new_cost + octile_distance(nx, ny, gx, gy)
+                heapq.heappush(frontier, (priority, next_node))
+                came_from[next_node] = current
+
+    if goal not in came_from:
+        return None
+
+    # Reconstruct path
+    path = []
+    current = goal
+    while current != start:
+        path.append(current)
+        current = came_from[current]
+    path.reverse()
+    return path
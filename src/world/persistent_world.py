@@ -23,11 +23,23 @@ from world.map import (
     TILE_ASH,
     TILE_STAIRS_DOWN,
     TILE_STAIRS_UP,
+    TILE_WAYPOINT,
+    TILE_PORTAL,
 )
 from world.generator import (
     generate_perlin_noise,
 )
-from world.static_maps import STATIC_CHUNKS
+from world.static_maps import ENTITY_CHARS, STATIC_CHUNKS, STATIC_CHUNK_NAMES
+from world.caves import CaveSystem, find_cave_mouths, generate_caves
+from world.hydrology import add_beaches, carve_rivers
+from world.poi import POIIndex
+from world.prefabs import load_prefabs, place_prefabs
+from world.settlements import Settlement, build_town, lay_road, road_network
+
+
+# Saved with the world, and raised whenever generate_world starts making
+# something an older save does not have, so such saves are regenerated
+WORLD_FORMAT = 2
 
 
 class WorldArea:
@@ -81,7 +93,10 @@ class PersistentWorld:
         world_seed: int = 12345,
         world_width: int = CONFIG.world_width,
         world_height: int = CONFIG.world_height,
+        world_file: str = "src/data/saves/persistent_world.pkl",
+        name: str = "main",
     ):
+        self.name = name
         self.world_seed = world_seed
         self.world_width = world_width
         self.world_height = world_height
@@ -91,13 +106,30 @@ class PersistentWorld:
         self.world_map: Optional[np.ndarray] = None
         self.biome_map: Optional[np.ndarray] = None
         self.area_map: Optional[np.ndarray] = None  # Maps each tile to its area type
-        self.world_file = "src/data/saves/persistent_world.pkl"
+        self.world_file = world_file
 
         # Entity data from static maps
         self.preplaced_entities: List[Dict] = []
 
         # Player start position from static maps
         self.player_start_pos: Optional[Tuple[int, int]] = None
+        # Where new players begin the tutorial ('&' in a static chunk)
+        self.tutorial_start_pos: Optional[Tuple[int, int]] = None
+
+        # Teleport network: waypoint name -> (x, y), portal (x, y) -> (x, y, z)
+        self.waypoints: Dict[str, Tuple[int, int, int]] = {}
+        self.portals: Dict[Tuple[int, int, int], Tuple[int, int, int]] = {}
+        self._shrine_sites: List[Tuple[int, int]] = []
+
+        # Searchable towns, dungeons, shrines and landmarks
+        self.pois = POIIndex()
+
+        # Generated towns, their buildings and safe-zone flags
+        self.settlements: List[Settlement] = []
+
+        # Underground levels by z (1 is just below the surface) and their caves
+        self.cave_levels: Dict[int, np.ndarray] = {}
+        self.caves: List[CaveSystem] = []
 
         # Create saves directory if it doesn't exist
         os.makedirs(os.path.dirname(self.world_file), exist_ok=True)
@@ -233,15 +265,40 @@ class PersistentWorld:
         ) & (self.world_map == TILE_GRASS)
         self.world_map[mask_bushes & (decor_noise < 0.04)] = TILE_BUSH
 
+        # Beaches along the coast, then rivers running down to the sea
+        import random
+
+        beach = add_beaches(
+            self.world_map, self.biome_map, CONFIG.worldgen.get("beach_width", 2)
+        )
+        rivers, lakes = carve_rivers(
+            self.world_map,
+            elevation_map,
+            random.Random(self.world_seed),
+            CONFIG.worldgen.get("rivers", 40),
+            CONFIG.worldgen.get("river_source_elevation", 0.6),
+            CONFIG.worldgen.get("max_lake_tiles", 300),
+        )
+        print(f"Carved {rivers} rivers and {lakes} lakes; {beach} tiles of beach")
+
         # Define special areas (towns, dungeons, etc.)
         self._define_special_areas(elevation_map, moisture_map)
+        self.preplaced_entities = []
+
+        # Build the towns before anything else claims their ground
+        self._build_settlements()
 
         # Place procedural ruins/landmarks
         self._generate_procedural_structures()
 
         # Apply STATIC_CHUNKS (overwriting procedural generation)
         chunk_size = 50  # Fixed size for static chunks
-        self.preplaced_entities = []
+
+        # Stamp handcrafted prefabs, leaving static chunks to their own layouts
+        self._place_prefabs(chunk_size)
+
+        # Roads between the towns, around whatever was built in their way
+        self._lay_roads()
 
         # Legend mapping for static chunks
         char_map = {
@@ -263,36 +320,6 @@ class PersistentWorld:
             "<": TILE_STAIRS_UP,
         }
 
-        # Entity char mapping
-        entity_map = {
-            "g": ("monster", "goblin"),
-            "o": ("monster", "orc"),
-            "k": ("monster", "skeleton"),
-            "r": ("monster", "spider"),
-            "b": ("monster", "bat"),
-            "U": ("monster", "guard"),
-            "m": ("monster", "merchant"),
-            "h": ("monster", "citizen"),
-            "d": ("monster", "dog"),
-            "w": ("monster", "wolf"),
-            "E": ("monster", "bear"),
-            "q": ("monster", "scorpion"),
-            "n": ("monster", "giant_ant"),
-            "j": ("monster", "ice_slime"),
-            "y": ("monster", "yeti"),
-            "p": ("monster", "fire_imp"),
-            "v": ("monster", "lava_golem"),
-            "!": ("item", "health_potion"),
-            "/": ("item", "sword"),
-            "[": ("item", "shield"),
-            "(": ("item", "iron_helmet"),
-            ")": ("item", "leather_helmet"),
-            "{": ("item", "iron_chainmail"),
-            "}": ("item", "leather_tunic"),
-            "_": ("item", "iron_greaves"),
-            "-": ("item", "leather_boots"),
-        }
-
         for (cx, cy), map_data in STATIC_CHUNKS.items():
             layout, fg_layout = (
                 map_data if isinstance(map_data, tuple) else (map_data, None)
@@ -312,6 +339,9 @@ class PersistentWorld:
                         if char == "@":
                             self.player_start_pos = (wx, wy)
                             char = "."  # Treat as floor
+                        elif char == "&":
+                            self.tutorial_start_pos = (wx, wy)
+                            char = "."
 
                         # Set biome to town for town-like tiles, or special biomes
                         if char in ("#", ".", "+", "P"):
@@ -331,12 +361,20 @@ class PersistentWorld:
             if fg_layout:
                 for r, row in enumerate(fg_layout):
                     for c, char in enumerate(row):
-                        if char in entity_map:
+                        if char in ENTITY_CHARS:
                             wx, wy = start_x + c, start_y + r
-                            e_type, e_subtype = entity_map[char]
+                            e_type, e_subtype = ENTITY_CHARS[char]
                             self.preplaced_entities.append(
                                 {"type": e_type, "subtype": e_subtype, "x": wx, "y": wy}
                             )
+
+        # Caves go below whatever entrances are left once the surface is done
+        self._generate_caves()
+
+        # Waypoints and portals go last so static chunks cannot overwrite them
+        self._register_points_of_interest()
+        self._place_teleport_network()
+
                 # Save the world to file
         self.save_world()
         print(
@@ -447,6 +485,7 @@ class PersistentWorld:
 
         # 2. Generate Shrines (Sparse landmarks)
         num_shrines = 8
+        self._shrine_sites = []
         for _ in range(num_shrines):
             sx = random.randint(100, self.world_width - 100)
             sy = random.randint(100, self.world_height - 100)
@@ -466,9 +505,195 @@ class PersistentWorld:
                             self.world_map[wy, wx] = TILE_WALL
                         elif dx == 0 and dy == 0:
                             self.world_map[wy, wx] = TILE_FLOOR
+                            self._shrine_sites.append((wx, wy))
                         else:
                             self.world_map[wy, wx] = TILE_PAVEMENT
 
+    def _build_settlements(self):
+        """Lay out a town with buildings and townsfolk in every town area."""
+        import random
+
+        rng = random.Random(self.world_seed)
+        names = set()
+        self.settlements = []
+        for area in self.areas.values():
+            if area.area_type != "town":
+                continue
+            if area.x < 0 or area.x + area.width > self.world_width:
+                continue
+            if area.y < 0 or area.y + area.height > self.world_height:
+                continue
+            cx, cy = area.x + area.width // 2, area.y + area.height // 2
+            base = name = f"{self._compass_name(cx, cy)} Town"
+            suffix = 2
+            while name in names:
+                name = f"{base} {suffix}"
+                suffix += 1
+            names.add(name)
+            town = Settlement(name, area.x, area.y, area.width, area.height)
+            entities = build_town(self.world_map, self.biome_map, town, rng)
+            self.preplaced_entities.extend(entities)
+            self.settlements.append(town)
+        print(f"Built {len(self.settlements)} towns")
+
+    def _lay_roads(self):
+        """Join the towns and the central town into one road network."""
+        # The middle of the Town Center static chunk
+        hub = (self.center_x + 25, self.center_y + 25)
+        points = [hub] + [town.center for town in self.settlements]
+        paved = 0
+        for a, b in road_network(points):
+            paved += lay_road(self.world_map, points[a], points[b])
+        print(f"Paved {paved} road tiles")
+
+    def settlement_at(self, x: int, y: int) -> Optional[Settlement]:
+        """The generated town containing a position, if any."""
+        for town in self.settlements:
+            if town.contains(x, y):
+                return town
+        return None
+
+    def _generate_caves(self):
+        """Carve cave levels below the dungeon entrances and natural cave mouths."""
+        import random
+
+        settings = CONFIG.worldgen
+        rng = random.Random(self.world_seed)
+        entrances = []
+        for area in self.areas.values():
+            if area.area_type != "dungeon":
+                continue
+            x, y = area.x + area.width // 2, area.y + area.height // 2
+            if 0 <= x < self.world_width and 0 <= y < self.world_height:
+                if self.world_map[y, x] == TILE_STAIRS_DOWN:
+                    entrances.append((x, y))
+        mouths = find_cave_mouths(
+            self.world_map,
+            self.biome_map,
+            rng,
+            settings.get("cave_mouths", 12),
+            avoid=entrances + [(self.center_x + 25, self.center_y + 25)],
+        )
+        for x, y in mouths:
+            self.world_map[y, x] = TILE_STAIRS_DOWN
+        self.cave_levels, self.caves = generate_caves(
+            self.world_width,
+            self.world_height,
+            entrances + mouths,
+            settings.get("cave_depth", 3),
+            rng,
+            tuple(settings.get("cave_size", (80, 60))),
+        )
+        print(
+            f"Carved {len(self.caves)} cave systems on {len(self.cave_levels)} levels "
+            f"({len(mouths)} cave mouths)"
+        )
+
+    def _place_prefabs(self, chunk_size: int):
+        """Stamp prefab structures by biome at the configured density."""
+        import random
+
+        settings = CONFIG.worldgen
+        density = dict(settings.get("prefab_biome_density", {}))
+        density.setdefault("default", settings.get("prefab_density", 0.0))
+        skip = {
+            (cx * chunk_size + self.center_x, cy * chunk_size + self.center_y)
+            for cx, cy in STATIC_CHUNKS
+        }
+        entities, placed = place_prefabs(
+            self.world_map,
+            self.biome_map,
+            load_prefabs(),
+            density,
+            random.Random(self.world_seed),
+            chunk_size,
+            skip,
+        )
+        self.preplaced_entities.extend(entities)
+        print(f"Stamped {len(placed)} prefabs")
+
+    def _compass_name(self, x: int, y: int) -> str:
+        """Describe a position by its direction from the world centre."""
+        dx, dy = x - self.center_x, y - self.center_y
+        if abs(dx) < self.world_width // 10 and abs(dy) < self.world_height // 10:
+            return "Central"
+        ns = "North" if dy <= -self.world_height // 10 else "South" if dy >= self.world_height // 10 else ""
+        ew = "West" if dx <= -self.world_width // 10 else "East" if dx >= self.world_width // 10 else ""
+        return "-".join(part for part in (ns, ew) if part)
+
+    def _register_points_of_interest(self):
+        """Index dungeon entrances and static landmarks (towns and shrines come with waypoints)."""
+        chunk_size = 50  # Matches the static chunk grid
+        self.pois = POIIndex()
+
+        for (cx, cy), name in STATIC_CHUNK_NAMES.items():
+            x = cx * chunk_size + self.center_x + chunk_size // 2
+            y = cy * chunk_size + self.center_y + chunk_size // 2
+            if 0 <= x < self.world_width and 0 <= y < self.world_height:
+                self.pois.add(name, "landmark", x, y)
+
+        for area in self.areas.values():
+            if area.area_type == "dungeon":
+                x, y = area.x + area.width // 2, area.y + area.height // 2
+                self.pois.add(f"{self._compass_name(x, y)} Dungeon", "dungeon", x, y)
+
+        for cave in self.caves:
+            x, y = cave.entrance
+            if cave.z == 1 and not self.get_area_at(x, y):
+                self.pois.add(f"{self._compass_name(x, y)} Cave", "cave", x, y)
+
+    def _add_waypoint(self, base_name: str, x: int, y: int):
+        """Place a waypoint tile with a unique name."""
+        name = base_name
+        suffix = 2
+        while name in self.waypoints:
+            name = f"{base_name} {suffix}"
+            suffix += 1
+        self.world_map[y, x] = TILE_WAYPOINT
+        self.waypoints[name] = (x, y, 0)
+        return name
+
+    def _place_teleport_network(self):
+        """Place waypoints at the start, towns and shrines, and link distant biomes with portals."""
+        import random
+
+        self.waypoints = {}
+        self.portals = {}
+
+        if self.player_start_pos:
+            name = self._add_waypoint("Town Square", *self.player_start_pos)
+            self.pois.add(name, "town", *self.player_start_pos, waypoint=name)
+
+        for town in self.settlements:
+            cx, cy = town.center
+            name = self._add_waypoint(town.name, cx, cy)
+            self.pois.add(name, "town", cx, cy, waypoint=name)
+
+        for sx, sy in self._shrine_sites:
+            name = self._add_waypoint(f"{self._compass_name(sx, sy)} Shrine", sx, sy)
+            self.pois.add(name, "shrine", sx, sy, waypoint=name)
+
+        # Portal pairs linking distant regions of contrasting biomes
+        min_distance = self.world_width // 4
+        for biome_a, biome_b in (("desert", "hill"), ("swamp", "jungle"), ("plains", "forest")):
+            sites_a = np.argwhere((self.biome_map == biome_a) & (self.world_map == TILE_GRASS))
+            sites_b = np.argwhere((self.biome_map == biome_b) & (self.world_map == TILE_GRASS))
+            if len(sites_a) == 0 or len(sites_b) == 0:
+                continue
+
+            for _ in range(10):
+                ay, ax = sites_a[random.randrange(len(sites_a))]
+                by, bx = sites_b[random.randrange(len(sites_b))]
+                if abs(int(ax) - int(bx)) + abs(int(ay) - int(by)) >= min_distance:
+                    a, b = (int(ax), int(ay), 0), (int(bx), int(by), 0)
+                    self.world_map[a[1], a[0]] = TILE_PORTAL
+                    self.world_map[b[1], b[0]] = TILE_PORTAL
+                    self.portals[a] = b
+                    self.portals[b] = a
+                    break
+
+        print(f"Placed {len(self.waypoints)} waypoints and {len(self.portals) // 2} portal pairs")
+
     def load_world(self):
         """Load the persistent world from file, or generate if it doesn't exist."""
         if os.path.exists(self.world_file):
@@ -484,6 +709,11 @@ class PersistentWorld:
                 ):
                     print("World dimensions changed in config. Regenerating world...")
                     self.generate_world()
+                elif data.get("format", 1) < WORLD_FORMAT:
+                    # Waypoints, portals, towns, caves and the tutorial start
+                    # would all be missing, so an older save is made again
+                    print("World was saved in an older format. Regenerating world...")
+                    self.generate_world()
                 else:
                     self.world_map = data["world_map"]
                     self.biome_map = data["biome_map"]
@@ -491,7 +721,19 @@ class PersistentWorld:
                     self.world_width = data["world_width"]
                     self.world_height = data["world_height"]
                     self.player_start_pos = data.get("player_start_pos")
+                    self.tutorial_start_pos = data.get("tutorial_start_pos")
                     self.preplaced_entities = data.get("preplaced_entities", [])
+                    self.waypoints = data.get("waypoints", {})
+                    self.portals = data.get("portals", {})
+                    self.pois = POIIndex.from_list(data.get("pois", []))
+                    self.settlements = [
+                        Settlement.from_dict(town)
+                        for town in data.get("settlements", [])
+                    ]
+                    self.cave_levels = data.get("cave_levels", {})
+                    self.caves = [
+                        CaveSystem.from_dict(cave) for cave in data.get("caves", [])
+                    ]
                     self.center_x = self.world_width // 2
                     self.center_y = self.world_height // 2
                     print(
@@ -507,6 +749,7 @@ class PersistentWorld:
     def save_world(self):
         """Save the persistent world to file."""
         data = {
+            "format": WORLD_FORMAT,
             "world_map": self.world_map,
             "biome_map": self.biome_map,
             "areas": self.areas,
@@ -514,7 +757,14 @@ class PersistentWorld:
             "world_height": self.world_height,
             "world_seed": self.world_seed,
             "player_start_pos": self.player_start_pos,
+            "tutorial_start_pos": self.tutorial_start_pos,
             "preplaced_entities": self.preplaced_entities,
+            "waypoints": self.waypoints,
+            "portals": self.portals,
+            "pois": self.pois.to_list(),
+            "settlements": [town.to_dict() for town in self.settlements],
+            "cave_levels": self.cave_levels,
+            "caves": [cave.to_dict() for cave in self.caves],
         }
         with open(self.world_file, "wb") as f:
             pickle.dump(data, f)
@@ -580,6 +830,13 @@ class PersistentWorld:
 
         return game_map
 
+    def get_cave_game_maps(self) -> Dict[int, GameMap]:
+        """Every underground level as a dark GameMap, by z."""
+        return {
+            z: GameMap(self.world_width, self.world_height, tiles=tiles, is_dark=True)
+            for z, tiles in self.cave_levels.items()
+        }
+
     def get_creatures_for_biome(self, biome: str) -> List[str]:
         """Get a list of creatures that spawn in the given biome."""
         return BIOME_CREATURES.get(
@@ -587,9 +844,33 @@ class PersistentWorld:
         )  # Default creatures if biome not found
 
 
+# Worlds loaded so far by name; a server hosting several keeps each in memory
+_loaded_worlds: Dict[str, PersistentWorld] = {}
+
+
 def get_persistent_world() -> PersistentWorld:
-    """Get the singleton persistent world instance."""
+    """Get the world being played in (the main world until another is opened)."""
     if not hasattr(get_persistent_world, "_instance"):
-        get_persistent_world._instance = PersistentWorld()
-        get_persistent_world._instance.load_world()
+        get_persistent_world._instance = open_world("main", 12345)
     return get_persistent_world._instance
+
+
+def load_named_world(
+    name: str, seed: int, world_file: str = "src/data/saves/persistent_world.pkl"
+) -> PersistentWorld:
+    """Load (or generate) a named world without playing in it."""
+    world = _loaded_worlds.get(name)
+    if world is None:
+        world = PersistentWorld(seed, world_file=world_file, name=name)
+        world.load_world()
+        _loaded_worlds[name] = world
+    return world
+
+
+def open_world(
+    name: str, seed: int, world_file: str = "src/data/saves/persistent_world.pkl"
+) -> PersistentWorld:
+    """Load (or generate) a named world and make it the one being played in."""
+    world = load_named_world(name, seed, world_file)
+    get_persistent_world._instance = world
+    return world
@@ -0,0 +1,102 @@
+"""
+Points of interest for the roguelike game.
+World generation registers towns, dungeons, caves, shrines and landmarks here
+so they can be searched, discovered by the player and linked to waypoints.
+"""
+
+import math
+from dataclasses import asdict, dataclass
+from typing import Dict, List, Optional, Tuple
+
+POI_KINDS = ("town", "dungeon", "cave", "shrine", "landmark")
+
+# Tiles from a point of interest at which the player discovers it
+DISCOVERY_RADIUS = 8
+
+
+@dataclass
+class PointOfInterest:
+    """A named place in the world."""
+
+    id: str
+    name: str
+    kind: str
+    x: int
+    y: int
+    z: int = 0
+    waypoint: Optional[str] = None  # Waypoint unlocked by discovering this place
+
+    def distance_to(self, x: int, y: int) -> float:
+        return math.hypot(self.x - x, self.y - y)
+
+    def to_dict(self) -> Dict:
+        return asdict(self)
+
+
+class POIIndex:
+    """Searchable collection of points of interest."""
+
+    def __init__(self, pois: Optional[List[PointOfInterest]] = None):
+        self.pois: Dict[str, PointOfInterest] = {}
+        for poi in pois or []:
+            self.pois[poi.id] = poi
+
+    def add(
+        self,
+        name: str,
+        kind: str,
+        x: int,
+        y: int,
+        z: int = 0,
+        waypoint: Optional[str] = None,
+    ) -> PointOfInterest:
+        """Register a point of interest under a unique id."""
+        base_id = f"{kind}:{name.lower().replace(' ', '_')}"
+        poi_id = base_id
+        suffix = 2
+        while poi_id in self.pois:
+            poi_id = f"{base_id}_{suffix}"
+            suffix += 1
+
+        poi = PointOfInterest(poi_id, name, kind, x, y, z, waypoint)
+        self.pois[poi_id] = poi
+        return poi
+
+    def get(self, poi_id: str) -> Optional[PointOfInterest]:
+        return self.pois.get(poi_id)
+
+    def search(
+        self,
+        text: str = "",
+        kind: Optional[str] = None,
+        near: Optional[Tuple[int, int]] = None,
+        limit: Optional[int] = None,
+    ) -> List[PointOfInterest]:
+        """Find places whose name contains text, nearest first when near is given."""
+        text = text.lower()
+        results = [
+            poi
+            for poi in self.pois.values()
+            if text in poi.name.lower() and (kind is None or poi.kind == kind)
+        ]
+        if near:
+            results.sort(key=lambda poi: poi.distance_to(*near))
+        else:
+            results.sort(key=lambda poi: poi.name)
+        return results[:limit] if limit is not None else results
+
+    def within(self, x: int, y: int, radius: float, z: int = 0) -> List[PointOfInterest]:
+        """Places within radius tiles of a position on the same Z-level."""
+        return [
+            poi
+            for poi in self.pois.values()
+            if poi.z == z and poi.distance_to(x, y) <= radius
+        ]
+
+    def to_list(self) -> List[Dict]:
+        """Plain data for saving with the world."""
+        return [poi.to_dict() for poi in self.pois.values()]
+
+    @classmethod
+    def from_list(cls, data: List[Dict]) -> "POIIndex":
+        return cls([PointOfInterest(**entry) for entry in data])
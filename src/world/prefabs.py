@@ -0,0 +1,210 @@
+"""
+Prefabs: small handcrafted structures stamped into the procedural world.
+Houses, ruins, shrines and camps are drawn in src/data/static/prefabs.toml
+like static maps, with a tile layout and an optional entity layout:
+
+    [[prefabs]]
+    name = "Hunter's Hut"
+    biomes = ["forest", "grassland"]
+    weight = 2
+    layout = '''
+    ####
+    #..+
+    ####'''
+    fg_layout = '''
+
+     t!
+    '''
+
+Tiles use the map characters (see world.map.CHAR_MAP) and entities the
+static map legend; a space in the tile layout leaves the terrain beneath as
+it is, for irregular shapes. During world generation each chunk of a
+biome rolls its density, then a prefab for that biome is stamped where its
+whole footprint lies on open ground.
+"""
+
+import os
+import random
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional, Tuple
+
+import toml
+
+from world.map import (
+    CHAR_MAP,
+    TILE_LAVA,
+    TILE_PORTAL,
+    TILE_STAIRS_DOWN,
+    TILE_STAIRS_UP,
+    TILE_WATER,
+    TILE_WAYPOINT,
+)
+from world.static_maps import ENTITY_CHARS
+
+PREFABS_PATH = os.path.join("src", "data", "static", "prefabs.toml")
+KEEP = " "  # Leaves the terrain beneath as it is
+MAX_SIZE = 24  # Largest prefab, in tiles per side
+ATTEMPTS = 8  # Positions tried in a chunk before giving up on it
+
+# A prefab never covers these, so it cannot cut off stairs or flood a lake
+BLOCKING_TILES = (
+    TILE_WATER, TILE_LAVA, TILE_STAIRS_DOWN, TILE_STAIRS_UP, TILE_WAYPOINT, TILE_PORTAL
+)
+EXCLUDED_BIOMES = ("ocean", "town", "void")
+
+
+class PrefabError(ValueError):
+    """A prefab definition that is malformed."""
+
+
+@dataclass
+class Prefab:
+    """A structure template of tiles and entities."""
+
+    name: str
+    layout: List[str]
+    fg_layout: List[str]
+    biomes: Tuple[str, ...]
+    weight: float = 1.0
+
+    @property
+    def width(self) -> int:
+        return max(len(row) for row in self.layout)
+
+    @property
+    def height(self) -> int:
+        return len(self.layout)
+
+
+def _rows(text: Any, where: str) -> List[str]:
+    if text is None:
+        return []
+    if not isinstance(text, str):
+        raise PrefabError(f"{where} must be a string")
+    # TOML drops the newline after the opening quotes; blank rows and leading
+    # spaces after it are part of the shape
+    return text.rstrip("\n").split("\n")
+
+
+def parse_prefab(entry: Any, where: str = "prefab") -> Prefab:
+    """Build a prefab from its TOML table, raising PrefabError on a problem."""
+    if not isinstance(entry, dict) or not isinstance(entry.get("name"), str):
+        raise PrefabError(f"{where}: needs a name")
+    where = f"{where} {entry['name']!r}"
+    biomes = entry.get("biomes")
+    if not isinstance(biomes, list) or not biomes:
+        raise PrefabError(f"{where}: needs a list of biomes")
+    weight = entry.get("weight", 1)
+    if isinstance(weight, bool) or not isinstance(weight, (int, float)) or weight <= 0:
+        raise PrefabError(f"{where}: weight must be a positive number")
+
+    layout = _rows(entry.get("layout"), f"{where}: layout")
+    fg_layout = _rows(entry.get("fg_layout"), f"{where}: fg_layout")
+    if not layout or not any(row.strip() for row in layout):
+        raise PrefabError(f"{where}: needs a layout")
+    prefab = Prefab(entry["name"], layout, fg_layout, tuple(biomes), float(weight))
+    if prefab.width > MAX_SIZE or prefab.height > MAX_SIZE:
+        raise PrefabError(f"{where}: larger than {MAX_SIZE}x{MAX_SIZE}")
+    for row in layout:
+        for char in row:
+            if char != KEEP and char not in CHAR_MAP:
+                raise PrefabError(f"{where}: unknown map character {char!r}")
+    if len(fg_layout) > prefab.height:
+        raise PrefabError(f"{where}: fg_layout is taller than the layout")
+    for row in fg_layout:
+        for char in row:
+            if char != " " and char not in ENTITY_CHARS:
+                raise PrefabError(f"{where}: unknown entity character {char!r}")
+    return prefab
+
+
+def load_prefabs(path: str = PREFABS_PATH) -> List[Prefab]:
+    """Load the prefab library, skipping (and reporting) malformed entries."""
+    if not os.path.exists(path):
+        return []
+    try:
+        with open(path, "r") as f:
+            data = toml.load(f)
+    except Exception as e:
+        print(f"Error loading prefabs: {e}")
+        return []
+
+    prefabs = []
+    for i, entry in enumerate(data.get("prefabs", [])):
+        try:
+            prefabs.append(parse_prefab(entry, f"prefabs[{i}]"))
+        except PrefabError as e:
+            print(f"Skipping prefab: {e}")
+    return prefabs
+
+
+def fits(world_map, biome_map, prefab: Prefab, x: int, y: int) -> bool:
+    """Whether every tile the prefab sets lies on open ground of the world."""
+    height, width = world_map.shape
+    if x < 0 or y < 0 or x + prefab.width > width or y + prefab.height > height:
+        return False
+    for r, row in enumerate(prefab.layout):
+        for c, char in enumerate(row):
+            if char == KEEP:
+                continue
+            if world_map[y + r, x + c] in BLOCKING_TILES:
+                return False
+            if biome_map[y + r, x + c] in EXCLUDED_BIOMES:
+                return False
+    return True
+
+
+def stamp(world_map, prefab: Prefab, x: int, y: int) -> List[Dict[str, Any]]:
+    """Write the prefab's tiles at (x, y); returns its entities to pre-place."""
+    for r, row in enumerate(prefab.layout):
+        for c, char in enumerate(row):
+            if char != KEEP:
+                world_map[y + r, x + c] = CHAR_MAP[char]
+    entities = []
+    for r, row in enumerate(prefab.fg_layout):
+        for c, char in enumerate(row):
+            if char in ENTITY_CHARS:
+                e_type, e_subtype = ENTITY_CHARS[char]
+                entities.append(
+                    {"type": e_type, "subtype": e_subtype, "x": x + c, "y": y + r}
+                )
+    return entities
+
+
+def place_prefabs(
+    world_map,
+    biome_map,
+    prefabs: List[Prefab],
+    density: Dict[str, float],
+    rng: random.Random,
+    chunk_size: int = 50,
+    skip: Optional[set] = None,
+) -> Tuple[List[Dict[str, Any]], List[Tuple[str, int, int]]]:
+    """Stamp prefabs chunk by chunk across the world.
+
+    density maps a biome to the chance of a prefab in one of its chunks,
+    with "default" for the rest. skip holds (x, y) chunk origins to leave
+    alone, such as static map chunks. Returns the entities to pre-place and
+    each stamped prefab's name and position.
+    """
+    height, width = world_map.shape
+    entities, placed = [], []
+    skip = skip or set()
+    for cy in range(0, height - chunk_size + 1, chunk_size):
+        for cx in range(0, width - chunk_size + 1, chunk_size):
+            if (cx, cy) in skip:
+                continue
+            biome = biome_map[cy + chunk_size // 2, cx + chunk_size // 2]
+            chance = density.get(biome, density.get("default", 0.0))
+            candidates = [p for p in prefabs if biome in p.biomes]
+            if not candidates or rng.random() >= chance:
+                continue
+            prefab = rng.choices(candidates, [p.weight for p in candidates])[0]
+            for _ in range(ATTEMPTS):
+                x = cx + rng.randint(0, max(0, chunk_size - prefab.width))
+                y = cy + rng.randint(0, max(0, chunk_size - prefab.height))
+                if fits(world_map, biome_map, prefab, x, y):
+                    entities += stamp(world_map, prefab, x, y)
+                    placed.append((prefab.name, x, y))
+                    break
+    return entities, placed
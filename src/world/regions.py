@@ -0,0 +1,157 @@
+"""
+Named regions overlaid on the chunk grid.
+Regions are loaded from content files and carry rules and presentation hints
+(display name, PvP, spawn and catch tables, ambience and music). A safe
+region is a protected zone: nobody fights there and monsters keep out. New
+players start at the centre of the spawn region. Tiles outside every defined
+region fall back to a region named after their biome, which takes its
+ambience and music from soundscapes.json.
+
+Ambience and music are track names for clients that play sound; the server
+sends no audio.
+"""
+
+from dataclasses import dataclass, field
+from typing import Any, Callable, Dict, List, Optional, Tuple
+
+from data.loader import DATA_LOADER
+
+# Side length of a chunk on the static map grid (see PersistentWorld static chunks)
+REGION_CHUNK_SIZE = 50
+
+
+@dataclass
+class Region:
+    """A named area of the world and the rules that apply inside it."""
+
+    id: str
+    name: str
+    chunks: List[int] = field(default_factory=list)  # [x0, y0, x1, y1], inclusive
+    pvp: bool = False
+    spawns: Optional[Dict[str, int]] = None  # monster type -> weight; None uses biome
+    catches: Optional[Dict[str, int]] = None  # item caught fishing -> weight; None uses biome
+    ambience: str = ""
+    music: str = ""
+    safe: bool = False  # No combat inside, and monsters never enter
+    spawn: bool = False  # New players start at its centre
+
+    def contains_chunk(self, chunk_x: int, chunk_y: int) -> bool:
+        if len(self.chunks) != 4:
+            return False
+        x0, y0, x1, y1 = self.chunks
+        return x0 <= chunk_x <= x1 and y0 <= chunk_y <= y1
+
+    def info(self) -> Dict[str, Any]:
+        """Serializable zone_info payload for clients and UI."""
+        return {
+            "id": self.id,
+            "name": self.name,
+            "pvp": self.pvp,
+            "safe": self.safe,
+            "ambience": self.ambience,
+            "music": self.music,
+            "spawns": dict(self.spawns) if self.spawns else None,
+        }
+
+
+class RegionMap:
+    """Looks up the region for a world position."""
+
+    def __init__(
+        self,
+        regions: List[Region],
+        center_x: int,
+        center_y: int,
+        chunk_size: int = REGION_CHUNK_SIZE,
+        biome_lookup: Optional[Callable[[int, int], str]] = None,
+        soundscapes: Optional[Dict[str, Dict[str, str]]] = None,
+    ):
+        # Earlier regions take priority where definitions overlap
+        self.regions = regions
+        self.chunk_size = chunk_size
+        self.center_x = center_x
+        self.center_y = center_y
+        self.biome_lookup = biome_lookup
+        self.soundscapes = soundscapes or {}  # biome -> ambience and music
+        self._biome_regions: Dict[str, Region] = {}
+
+    @classmethod
+    def from_content(
+        cls,
+        center_x: int,
+        center_y: int,
+        chunk_size: int = REGION_CHUNK_SIZE,
+        biome_lookup: Optional[Callable[[int, int], str]] = None,
+    ) -> "RegionMap":
+        """Build the region map from src/data/static/regions.json, with biome
+        sound from soundscapes.json."""
+        try:
+            data = DATA_LOADER.load_json("regions")
+        except FileNotFoundError:
+            data = {}
+        try:
+            soundscapes = DATA_LOADER.load_json("soundscapes")
+        except FileNotFoundError:
+            soundscapes = {}
+
+        regions = [
+            Region(
+                id=region_id,
+                name=entry.get("name", region_id),
+                chunks=list(entry.get("chunks", [])),
+                pvp=bool(entry.get("pvp", False)),
+                spawns=entry.get("spawns"),
+                catches=entry.get("catches"),
+                ambience=entry.get("ambience", ""),
+                music=entry.get("music", ""),
+                safe=bool(entry.get("safe", False)),
+                spawn=bool(entry.get("spawn", False)),
+            )
+            for region_id, entry in data.items()
+        ]
+        return cls(regions, center_x, center_y, chunk_size, biome_lookup, soundscapes)
+
+    def chunk_of(self, x: int, y: int):
+        """Chunk coordinates of a world position (chunk (0, 0) starts at the world centre)."""
+        return (
+            (x - self.center_x) // self.chunk_size,
+            (y - self.center_y) // self.chunk_size,
+        )
+
+    def spawn_point(self) -> Optional[Tuple[int, int]]:
+        """Centre of the first spawn region, where new players start."""
+        for region in self.regions:
+            if region.spawn and len(region.chunks) == 4:
+                x0, y0, x1, y1 = region.chunks
+                return (
+                    self.center_x + (x0 + x1 + 1) * self.chunk_size // 2,
+                    self.center_y + (y0 + y1 + 1) * self.chunk_size // 2,
+                )
+        return None
+
+    def is_safe(self, x: int, y: int) -> bool:
+        """Check if a surface position lies in a protected zone."""
+        return self.region_at(x, y).safe
+
+    def region_at(self, x: int, y: int) -> Region:
+        """Get the region containing a world position."""
+        chunk_x, chunk_y = self.chunk_of(x, y)
+        for region in self.regions:
+            if region.contains_chunk(chunk_x, chunk_y):
+                return region
+
+        biome = self.biome_lookup(x, y) if self.biome_lookup else "void"
+        return self._biome_region(biome)
+
+    def _biome_region(self, biome: str) -> Region:
+        """Fallback region for tiles outside every defined region."""
+        if biome not in self._biome_regions:
+            name = "The Wilds" if biome == "void" else f"The {biome.replace('_', ' ').title()}"
+            sound = self.soundscapes.get(biome, {})
+            self._biome_regions[biome] = Region(
+                id=f"biome:{biome}",
+                name=name,
+                ambience=sound.get("ambience", biome),
+                music=sound.get("music", ""),
+            )
+        return self._biome_regions[biome]
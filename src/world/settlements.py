@@ -0,0 +1,260 @@
+"""
+Settlement generation: towns with streets, buildings and townsfolk, joined
+by roads.
+Each town site chosen during world generation is cleared and laid out
+around a central plaza, with a street running through it each way. Houses,
+shops, an inn, a sage's study and the elder's hall fill the blocks between
+the streets, each with a door onto the street and a lit interior with someone
+living or working inside; travellers can make the inn their home. Roads of
+pavement then link every town into one network, on bridges where they cross
+rivers and lakes.
+
+Towns are safe zones; the flag is kept with each settlement so systems that
+enforce it can look it up by position.
+"""
+
+import random
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional, Tuple
+
+from world.map import (
+    TILE_BRIDGE,
+    TILE_DOOR,
+    TILE_FLOOR,
+    TILE_GRASS,
+    TILE_LAVA,
+    TILE_PAVEMENT,
+    TILE_PORTAL,
+    TILE_STAIRS_DOWN,
+    TILE_STAIRS_UP,
+    TILE_WALL,
+    TILE_WATER,
+    TILE_WAYPOINT,
+)
+
+STREET_WIDTH = 3
+PLAZA = 3  # Tiles from the centre to the plaza's edge
+# What each kind of shop sells; every town has a general store and then the rest
+SHOP_STOCK: Dict[str, List[Tuple[str, int]]] = {
+    "general": [
+        ("health_potion", 25),
+        ("torch", 15),
+        ("leather_boots", 40),
+        ("scroll_of_identify", 40),
+        ("scroll_of_remove_curse", 80),
+    ],
+    "smith": [
+        ("sword", 110),
+        ("shield", 60),
+        ("iron_helmet", 90),
+        ("iron_greaves", 120),
+    ],
+    "outfitter": [
+        ("leather_helmet", 35),
+        ("leather_tunic", 60),
+        ("leather_boots", 40),
+    ],
+}
+SHOP_NAMES = {"general": "General Store", "smith": "Smithy", "outfitter": "Outfitter"}
+
+# Roads go around these rather than through them
+UNPAVED = (
+    TILE_WALL,
+    TILE_DOOR,
+    TILE_STAIRS_DOWN,
+    TILE_STAIRS_UP,
+    TILE_WAYPOINT,
+    TILE_PORTAL,
+    TILE_LAVA,
+)
+
+
+@dataclass
+class Building:
+    """A walled building with one door.
+
+    use is "shop:<kind>", "elder", "sage", "inn" or "house".
+    """
+
+    x: int
+    y: int
+    width: int
+    height: int
+    door: Tuple[int, int]
+    use: str = "house"
+
+    @property
+    def inside(self) -> Tuple[int, int]:
+        """The middle of the floor, where whoever lives or works here stands."""
+        return (self.x + self.width // 2, self.y + self.height // 2)
+
+
+@dataclass
+class Settlement:
+    """A generated town and its buildings."""
+
+    name: str
+    x: int
+    y: int
+    width: int
+    height: int
+    safe: bool = True  # No fighting inside
+    buildings: List[Building] = field(default_factory=list)
+
+    @property
+    def center(self) -> Tuple[int, int]:
+        return (self.x + self.width // 2, self.y + self.height // 2)
+
+    def contains(self, x: int, y: int) -> bool:
+        return self.x <= x < self.x + self.width and self.y <= y < self.y + self.height
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "Settlement":
+        buildings = [
+            Building(**dict(b, door=tuple(b["door"])))
+            for b in data.get("buildings", [])
+        ]
+        return cls(**dict(data, buildings=buildings))
+
+
+def _entity(kind: str, subtype: str, x: int, y: int, **extra) -> Dict[str, Any]:
+    return {"type": kind, "subtype": subtype, "x": x, "y": y, **extra}
+
+
+def _lots(start: int, end: int, rng: random.Random) -> List[Tuple[int, int]]:
+    """Split [start, end) into building widths of 5 to 8 with a tile between."""
+    lots, pos = [], start
+    while end - pos >= 5:
+        size = min(rng.randint(5, 8), end - pos)
+        lots.append((pos, size))
+        pos += size + 1
+    return lots
+
+
+def build_town(
+    world_map, biome_map, settlement: Settlement, rng: random.Random
+) -> List[Dict[str, Any]]:
+    """Lay out a town in its area; returns the entities to pre-place there."""
+    x0, y0, w, h = settlement.x, settlement.y, settlement.width, settlement.height
+    cx, cy = settlement.center
+    half = STREET_WIDTH // 2
+
+    # Clear the ground and pave the streets and plaza; a river through town
+    # keeps flowing, under bridges where the streets cross it
+    for y in range(y0, y0 + h):
+        for x in range(x0, x0 + w):
+            biome_map[y, x] = "town"
+            on_street = abs(x - cx) <= half or abs(y - cy) <= half
+            in_plaza = abs(x - cx) <= PLAZA and abs(y - cy) <= PLAZA
+            if world_map[y, x] == TILE_WATER:
+                world_map[y, x] = TILE_BRIDGE if on_street or in_plaza else TILE_WATER
+            else:
+                world_map[y, x] = TILE_PAVEMENT if on_street or in_plaza else TILE_GRASS
+    entities = [
+        _entity("light", "lamp_post", cx + dx, cy + dy)
+        for dx in (-PLAZA, PLAZA)
+        for dy in (-PLAZA, PLAZA)
+    ]
+
+    # One row of buildings per block, facing the street across the middle
+    uses = ["elder", "sage"] + [f"shop:{kind}" for kind in list(SHOP_STOCK)[1:]]
+    rng.shuffle(uses)
+    uses[:0] = ["shop:general", "inn"]
+    blocks = []
+    for left, right in ((x0, cx - PLAZA - 1), (cx + PLAZA + 2, x0 + w)):
+        blocks.append((left, right, y0, cy - PLAZA - 1, "south"))
+        blocks.append((left, right, cy + PLAZA + 2, y0 + h, "north"))
+    for left, right, top, bottom, facing in blocks:
+        depth = min(bottom - top, 6)
+        if depth < 4:
+            continue
+        by = bottom - depth if facing == "south" else top
+        for bx, width in _lots(left, right, rng):
+            use = uses.pop(0) if uses else "house"
+            building = _building(world_map, bx, by, width, depth, facing, use)
+            settlement.buildings.append(building)
+            entities += _furnish(building, settlement.name)
+    return entities
+
+
+def _building(world_map, x, y, width, height, facing, use) -> Building:
+    for by in range(y, y + height):
+        for bx in range(x, x + width):
+            edge = by in (y, y + height - 1) or bx in (x, x + width - 1)
+            world_map[by, bx] = TILE_WALL if edge else TILE_FLOOR
+    door = (x + width // 2, y + height - 1 if facing == "south" else y)
+    world_map[door[1], door[0]] = TILE_DOOR
+    return Building(x, y, width, height, door, use)
+
+
+def _furnish(building: Building, town: str) -> List[Dict[str, Any]]:
+    """A torch and whoever lives or works inside."""
+    x, y = building.x, building.y
+    inside = building.inside
+    entities = [_entity("light", "wall_torch", x + 1, y + 1)]
+    if building.use.startswith("shop:"):
+        kind = building.use.split(":", 1)[1]
+        entities.append(
+            _entity("shopkeeper", kind, *inside, name=f"{town} {SHOP_NAMES[kind]}")
+        )
+    elif building.use == "elder":
+        entities.append(_entity("monster", "town_elder", *inside))
+    elif building.use == "inn":
+        entities.append(_entity("monster", "innkeeper", *inside))
+    elif building.use == "sage":
+        entities.append(_entity("monster", "sage", *inside))
+    else:
+        entities.append(_entity("monster", "citizen", *inside))
+    return entities
+
+
+def lay_road(world_map, start: Tuple[int, int], end: Tuple[int, int]) -> int:
+    """Pave a four-connected road between two points; returns tiles paved.
+
+    Water is crossed on a bridge; walls, doors, stairs, waypoints, portals
+    and lava are left as they are and the road resumes beyond them.
+    """
+    height, width = world_map.shape
+    (x, y), (ex, ey) = start, end
+    dx, dy = ex - x, ey - y
+    steps = abs(dx) + abs(dy)
+    paved = 0
+    for i in range(steps + 1):
+        if 0 <= x < width and 0 <= y < height:
+            tile = world_map[y, x]
+            if tile not in UNPAVED and tile not in (TILE_PAVEMENT, TILE_BRIDGE):
+                world_map[y, x] = TILE_BRIDGE if tile == TILE_WATER else TILE_PAVEMENT
+                paved += 1
+        if i == steps:
+            break
+        # Step along whichever axis lags behind the straight line
+        done_x = abs(x - start[0]) * abs(dy)
+        done_y = abs(y - start[1]) * abs(dx)
+        if x != ex and (done_x <= done_y or y == ey):
+            x += 1 if dx > 0 else -1
+        else:
+            y += 1 if dy > 0 else -1
+    return paved
+
+
+def road_network(points: List[Tuple[int, int]]) -> List[Tuple[int, int]]:
+    """Pairs of point indices joining every point with the least total length."""
+    if not points:
+        return []
+    joined, edges = {0}, []
+    while len(joined) < len(points):
+        best: Optional[Tuple[int, int, int]] = None
+        for a in joined:
+            for b in range(len(points)):
+                if b in joined:
+                    continue
+                (ax, ay), (bx, by) = points[a], points[b]
+                length = abs(ax - bx) + abs(ay - by)
+                if best is None or length < best[0]:
+                    best = (length, a, b)
+        joined.add(best[2])
+        edges.append((best[1], best[2]))
+    return edges
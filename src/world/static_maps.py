@@ -9,6 +9,43 @@ from typing import Dict, List, Tuple
 # Path to the maps configuration file
 MAPS_CONFIG_PATH = os.path.join("src", "data", "static", "maps.toml")
 
+# Entity characters in fg_layout, shared by static maps and prefabs
+ENTITY_CHARS = {
+    "g": ("monster", "goblin"),
+    "o": ("monster", "orc"),
+    "k": ("monster", "skeleton"),
+    "r": ("monster", "spider"),
+    "b": ("monster", "bat"),
+    "U": ("monster", "guard"),
+    "m": ("monster", "merchant"),
+    "h": ("monster", "citizen"),
+    "d": ("monster", "dog"),
+    "w": ("monster", "wolf"),
+    "E": ("monster", "bear"),
+    "q": ("monster", "scorpion"),
+    "n": ("monster", "giant_ant"),
+    "j": ("monster", "ice_slime"),
+    "y": ("monster", "yeti"),
+    "p": ("monster", "fire_imp"),
+    "v": ("monster", "lava_golem"),
+    "!": ("item", "health_potion"),
+    "/": ("item", "sword"),
+    "[": ("item", "shield"),
+    "(": ("item", "iron_helmet"),
+    ")": ("item", "leather_helmet"),
+    "{": ("item", "iron_chainmail"),
+    "}": ("item", "leather_tunic"),
+    "_": ("item", "iron_greaves"),
+    "-": ("item", "leather_boots"),
+    "t": ("light", "wall_torch"),
+    "L": ("light", "lamp_post"),
+    "x": ("monster", "training_dummy"),
+    "u": ("monster", "tutor"),
+}
+
+# Display names of static chunks, keyed like STATIC_CHUNKS
+STATIC_CHUNK_NAMES: Dict[Tuple[int, int], str] = {}
+
 
 def load_static_chunks() -> Dict[Tuple[int, int], List[str]]:
     """Load static map chunks from the TOML configuration file."""
@@ -37,6 +74,8 @@ def load_static_chunks() -> Dict[Tuple[int, int], List[str]]:
                     fg_layout = fg_layout.strip().split("\n")
 
                 static_chunks[(x, y)] = (layout, fg_layout)
+                if map_entry.get("name"):
+                    STATIC_CHUNK_NAMES[(x, y)] = map_entry["name"]
 
         print(f"Loaded {len(static_chunks)} static map chunks from config.")
         return static_chunks
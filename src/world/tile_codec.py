@@ -0,0 +1,73 @@
+"""
+Compact encoding of tile grids for client messages.
+A grid of tile ids sent as nested JSON lists spends most of its bytes on
+repeated ids, commas and brackets. Here a grid is turned into a palette of
+the distinct tile ids, in the order they first appear, and run-length pairs
+of palette index and count read row by row:
+
+    {"encoding": "rle", "width": 3, "height": 2, "palette": [4, 0],
+     "runs": [0, 4, 1, 2]}
+
+is [[4, 4, 4], [4, 0, 0]]. Runs carry on across row ends, so open ground or
+sea costs a few numbers however wide it is. Clients (and tools sending
+tiles back) decode it with decode_tiles.
+"""
+
+from typing import Any, Dict, List, Sequence
+
+RAW = "raw"  # Nested lists of ids, one list per row
+RLE = "rle"
+ENCODINGS = (RAW, RLE)
+
+
+class TileCodecError(ValueError):
+    """An encoded grid that does not decode to width x height tiles."""
+
+
+def encode_tiles(rows: Sequence[Sequence[int]]) -> Dict[str, Any]:
+    """Palette and run-length encode a rectangular grid of tile ids."""
+    palette: Dict[int, int] = {}  # Tile id -> palette index
+    runs: List[int] = []
+    for row in rows:
+        for tile in row:
+            index = palette.setdefault(int(tile), len(palette))
+            if runs and runs[-2] == index:
+                runs[-1] += 1
+            else:
+                runs += [index, 1]
+    return {
+        "encoding": RLE,
+        "width": len(rows[0]) if len(rows) else 0,
+        "height": len(rows),
+        "palette": list(palette),
+        "runs": runs,
+    }
+
+
+def decode_tiles(encoded: Dict[str, Any]) -> List[List[int]]:
+    """The rows of tile ids an encode_tiles payload stands for."""
+    if not isinstance(encoded, dict) or encoded.get("encoding") != RLE:
+        raise TileCodecError("expected an rle-encoded tile grid")
+    width, height = encoded.get("width"), encoded.get("height")
+    palette, runs = encoded.get("palette"), encoded.get("runs")
+    for value in (width, height):
+        if isinstance(value, bool) or not isinstance(value, int) or value < 0:
+            raise TileCodecError("width and height must be whole numbers")
+    if not isinstance(palette, list) or not isinstance(runs, list) or len(runs) % 2:
+        raise TileCodecError("palette and runs must be lists, runs in pairs")
+
+    tiles: List[int] = []
+    for i in range(0, len(runs), 2):
+        index, count = runs[i], runs[i + 1]
+        if isinstance(index, bool) or not isinstance(index, int):
+            raise TileCodecError(f"run {i // 2} has no palette index")
+        if not 0 <= index < len(palette):
+            raise TileCodecError(f"run {i // 2} points past the palette")
+        if isinstance(count, bool) or not isinstance(count, int) or count < 1:
+            raise TileCodecError(f"run {i // 2} must repeat at least once")
+        tiles.extend([palette[index]] * count)
+        if len(tiles) > width * height:
+            break
+    if len(tiles) != width * height:
+        raise TileCodecError(f"runs give {len(tiles)} tiles, not {width * height}")
+    return [tiles[row * width : (row + 1) * width] for row in range(height)]
@@ -0,0 +1,108 @@
+"""
+Moving characters between worlds.
+Every world keeps a roster of the characters who play in it and where each
+one last stood, as a small JSON file beside the list of worlds. Admins move a
+single character to another world, or fold one world's whole player base
+into another when consolidating servers. A name already taken in the target
+world gets the source world's name appended, and positions only carry over
+between copies of the same map; anywhere else the character arrives at the
+target world's start.
+"""
+
+import time
+from typing import Any, Callable, Dict, Iterable, Optional, Tuple
+
+from systems.profile import load_profile, save_profile
+
+Point3 = Tuple[int, int, int]
+
+
+class TransferError(ValueError):
+    """A character that cannot be moved."""
+
+
+class Roster:
+    """The characters playing in one world, by username."""
+
+    def __init__(self, path: str):
+        self.path = path
+        self.players: Dict[str, Dict[str, Any]] = load_profile(path).get("players", {})
+
+    def get(self, username: str) -> Optional[Dict[str, Any]]:
+        return self.players.get(username)
+
+    def put(self, username: str, x: int, y: int, z: int = 0, **extra):
+        """Record where a character is, keeping when they first arrived."""
+        record = self.players.get(username, {"joined": time.time()})
+        record.update(extra, x=int(x), y=int(y), z=int(z))
+        self.players[username] = record
+
+    def remove(self, username: str) -> Dict[str, Any]:
+        if username not in self.players:
+            raise TransferError(f"no character named {username!r} in this world")
+        return self.players.pop(username)
+
+    def save(self):
+        save_profile(self.path, {"players": self.players})
+
+
+def unique_name(username: str, taken: Iterable[str], tag: str) -> str:
+    """username, or username-tag (then -tag2, -tag3...) if it is taken."""
+    taken = set(taken)
+    if username not in taken:
+        return username
+    candidate, n = f"{username}-{tag}", 2
+    while candidate in taken:
+        candidate, n = f"{username}-{tag}{n}", n + 1
+    return candidate
+
+
+def remap_position(
+    record: Dict[str, Any],
+    same_map: bool,
+    start: Tuple[int, int],
+    is_walkable: Callable[[int, int, int], bool],
+) -> Point3:
+    """Where a character from another world arrives.
+
+    Between copies of the same map they keep their spot, unless it is now
+    blocked; otherwise they arrive at the target world's start.
+    """
+    x, y, z = int(record["x"]), int(record["y"]), int(record.get("z", 0))
+    if same_map and is_walkable(x, y, z):
+        return x, y, z
+    return start[0], start[1], 0
+
+
+def transfer(
+    source: Roster,
+    target: Roster,
+    username: str,
+    tag: str,
+    remap: Callable[[Dict[str, Any]], Point3],
+) -> str:
+    """Move one character between rosters; returns its name in the target.
+
+    Neither roster is saved; the caller saves both once the move is done.
+    """
+    record = source.remove(username)
+    name = unique_name(username, target.players, tag)
+    x, y, z = remap(record)
+    moved = {"joined": time.time(), "from": tag}
+    if name != username:
+        moved["renamed_from"] = username
+    target.players[name] = dict(moved, x=x, y=y, z=z)
+    return name
+
+
+def merge(
+    source: Roster,
+    target: Roster,
+    tag: str,
+    remap: Callable[[Dict[str, Any]], Point3],
+) -> Dict[str, str]:
+    """Move every character from source into target; returns old -> new names."""
+    return {
+        username: transfer(source, target, username, tag, remap)
+        for username in sorted(source.players)
+    }
@@ -0,0 +1,87 @@
+"""
+Teleportation network for the roguelike game.
+Waypoints are named tiles that players attune to by visiting and can then
+travel between for gold; portals are tile pairs that link distant regions.
+"""
+
+import math
+from typing import Any, Dict, List, Optional, Tuple
+
+# Waypoints, portals and portal destinations are (x, y, z) so links can lead below ground
+Place = Tuple[int, int, int]
+PortalTarget = Place
+
+DEFAULT_TRAVEL = {
+    "base_cost": 10,  # Flat gold cost of any waypoint jump
+    "cost_per_100_tiles": 5,  # Extra gold per 100 tiles travelled
+    "cast_time": 3.0,  # Seconds the player must stand still before the jump
+}
+
+
+def place(position) -> Place:
+    """An (x, y, z) place; (x, y) from worlds saved before Z-levels is on the surface."""
+    x, y, *z = position
+    return int(x), int(y), int(z[0]) if z else 0
+
+
+class TeleportNetwork:
+    """Waypoint and portal locations for the world, plus travel pricing."""
+
+    def __init__(
+        self,
+        waypoints: Optional[Dict[str, Tuple[int, ...]]] = None,
+        portals: Optional[Dict[Tuple[int, ...], PortalTarget]] = None,
+        settings: Optional[Dict[str, Any]] = None,
+    ):
+        self.waypoints: Dict[str, Place] = {
+            name: place(pos) for name, pos in (waypoints or {}).items()
+        }
+        self.portals: Dict[Place, PortalTarget] = {
+            place(pos): place(target) for pos, target in (portals or {}).items()
+        }
+        self._waypoint_positions = {pos: name for name, pos in self.waypoints.items()}
+
+        settings = settings or {}
+        self.base_cost = int(settings.get("base_cost", DEFAULT_TRAVEL["base_cost"]))
+        self.cost_per_100_tiles = int(
+            settings.get("cost_per_100_tiles", DEFAULT_TRAVEL["cost_per_100_tiles"])
+        )
+        self.cast_time = float(settings.get("cast_time", DEFAULT_TRAVEL["cast_time"]))
+
+    def add_waypoint(self, name: str, x: int, y: int, z: int = 0):
+        """Register a named waypoint."""
+        self.waypoints[name] = (x, y, z)
+        self._waypoint_positions[(x, y, z)] = name
+
+    def add_portal(self, a: Place, b: Place, two_way: bool = True):
+        """Link two portal tiles given as (x, y, z)."""
+        self.portals[place(a)] = place(b)
+        if two_way:
+            self.portals[place(b)] = place(a)
+
+    def waypoint_at(self, x: int, y: int, z: int = 0) -> Optional[str]:
+        """Get the name of the waypoint on a tile of a level, if any."""
+        return self._waypoint_positions.get((x, y, z))
+
+    def portal_at(self, x: int, y: int, z: int = 0) -> Optional[PortalTarget]:
+        """Get the destination of the portal on a tile of a level, if any."""
+        return self.portals.get((x, y, z))
+
+    def travel_cost(self, from_x: int, from_y: int, name: str) -> int:
+        """Gold cost of travelling from a position to a waypoint."""
+        wx, wy, _ = self.waypoints[name]
+        distance = math.hypot(wx - from_x, wy - from_y)
+        return self.base_cost + int(distance / 100 * self.cost_per_100_tiles)
+
+    def destinations(
+        self, attuned: List[str], from_x: int, from_y: int, from_z: int = 0
+    ) -> List[Tuple[str, int]]:
+        """List (name, cost) for every attuned waypoint, nearest first."""
+        here = (from_x, from_y, from_z)
+        options = [
+            (name, self.travel_cost(from_x, from_y, name))
+            for name in attuned
+            if name in self.waypoints and self.waypoints[name] != here
+        ]
+        options.sort(key=lambda option: option[1])
+        return options
@@ -0,0 +1,145 @@
+"""
+Named worlds hosted by one server.
+Each world has its own seed and ruleset and is generated and saved on its
+own. The registry of worlds is a small JSON file; the original world, "main",
+is always there and keeps the save file and seed it had before there were
+several. A world that is retired is archived rather than deleted: its save
+and roster of players stay on disk but it can no longer be entered.
+"""
+
+import os
+import random
+import re
+import time
+from dataclasses import asdict, dataclass
+from typing import Any, Dict, List, Optional
+
+from systems.profile import load_profile, save_profile
+
+DEFAULT_WORLD = "main"
+DEFAULT_SEED = 12345  # The seed the single world was always generated from
+RULESETS = ("standard", "hardcore")
+NAME_PATTERN = re.compile(r"^[a-z][a-z0-9_-]{1,23}$")
+
+
+class WorldError(ValueError):
+    """A world that cannot be created, found or archived."""
+
+
+@dataclass
+class WorldInfo:
+    """One world: what it is called and how it is generated and played."""
+
+    name: str
+    seed: int
+    ruleset: str = "standard"
+    created: float = 0.0
+    archived: bool = False
+
+    @property
+    def hardcore(self) -> bool:
+        return self.ruleset == "hardcore"
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "WorldInfo":
+        return cls(
+            name=data["name"],
+            seed=int(data["seed"]),
+            ruleset=data.get("ruleset", "standard"),
+            created=float(data.get("created", 0.0)),
+            archived=bool(data.get("archived", False)),
+        )
+
+
+class WorldRegistry:
+    """The worlds this server hosts, stored as a JSON file beside the saves."""
+
+    def __init__(self, path: str, save_dir: str = "src/data/saves"):
+        self.path = path
+        self.save_dir = save_dir
+
+    def _load(self) -> Dict[str, WorldInfo]:
+        worlds = {
+            data["name"]: WorldInfo.from_dict(data)
+            for data in load_profile(self.path).get("worlds", [])
+        }
+        worlds.setdefault(DEFAULT_WORLD, WorldInfo(DEFAULT_WORLD, DEFAULT_SEED))
+        return worlds
+
+    def _save(self, worlds: Dict[str, WorldInfo]):
+        save_profile(self.path, {"worlds": [w.to_dict() for w in worlds.values()]})
+
+    def worlds(self) -> List[WorldInfo]:
+        """Every world, archived ones included, oldest first."""
+        return sorted(self._load().values(), key=lambda world: world.created)
+
+    def available(self) -> List[WorldInfo]:
+        """Worlds that can be entered, oldest first."""
+        return [world for world in self.worlds() if not world.archived]
+
+    def find(self, name: str) -> WorldInfo:
+        """Any world, archived or not; raises WorldError if there is none."""
+        world = self._load().get(name)
+        if world is None:
+            raise WorldError(f"no world named {name!r}")
+        return world
+
+    def get(self, name: str) -> WorldInfo:
+        """A world that can be entered; raises WorldError otherwise."""
+        world = self.find(name)
+        if world.archived:
+            raise WorldError(f"world {name!r} is archived")
+        return world
+
+    def create(
+        self,
+        name: str,
+        seed: Optional[int] = None,
+        ruleset: str = "standard",
+        rng: Optional[random.Random] = None,
+    ) -> WorldInfo:
+        """Register a new world; it is generated the first time it is entered."""
+        if not NAME_PATTERN.match(name or ""):
+            raise WorldError(
+                "world names are 2-24 lowercase letters, digits, '-' or '_'"
+            )
+        if ruleset not in RULESETS:
+            raise WorldError(f"ruleset must be one of {', '.join(RULESETS)}")
+        worlds = self._load()
+        if name in worlds:
+            raise WorldError(f"world {name!r} already exists")
+        if seed is None:
+            seed = (rng or random.Random()).randrange(1, 2**31)
+        world = WorldInfo(name, int(seed), ruleset, created=time.time())
+        worlds[name] = world
+        self._save(worlds)
+        return world
+
+    def archive(self, name: str) -> WorldInfo:
+        """Retire a world; its save is kept but it can no longer be entered."""
+        worlds = self._load()
+        world = worlds.get(name)
+        if world is None:
+            raise WorldError(f"no world named {name!r}")
+        if world.archived:
+            raise WorldError(f"world {name!r} is already archived")
+        world.archived = True
+        self._save(worlds)
+        return world
+
+    def world_file(self, name: str) -> str:
+        """Where a world's generated map is saved."""
+        if name == DEFAULT_WORLD:
+            return os.path.join(self.save_dir, "persistent_world.pkl")
+        return os.path.join(self.save_dir, "worlds", f"{name}.pkl")
+
+    def roster_file(self, name: str) -> str:
+        """Where the characters playing in a world are listed, beside the registry."""
+        return os.path.join(os.path.dirname(self.path), "players", f"{name}.json")
+
+    def market_file(self, name: str) -> str:
+        """Where a world's town markets are kept, beside its roster."""
+        return os.path.join(os.path.dirname(self.path), "markets", f"{name}.json")
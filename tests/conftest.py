@@ -42,6 +42,14 @@ def game_engine():
     return engine
 
 
+@pytest.fixture
+def harness(tmp_path):
+    """Start an in-process game with its saves in a temporary directory."""
+    from testutil import GameHarness
+
+    return GameHarness(str(tmp_path))
+
+
 @pytest.fixture
 def chunk_manager():
     """Create a ChunkManager with default settings."""
@@ -0,0 +1,95 @@
+#!/usr/bin/env python
+"""
+Benchmark for spatial index proximity queries.
+
+Compares finding the monsters near a point by scanning every monster against
+SpatialIndex.monsters_near, for growing numbers of monsters spread over a
+large world.
+Run with: python tests/spatial_benchmark.py
+"""
+
+import os
+import random
+import sys
+import timeit
+
+# Add src to path
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), "..", "src"))
+
+from core.ecs import EntityManager
+from core.spatial import SpatialIndex
+from entities.components import Monster, Position
+
+WORLD_SIZE = 2000
+RADIUS = 10
+
+
+def populate(count: int, seed: int = 1):
+    """An entity manager and index holding count monsters at random spots."""
+    rng = random.Random(seed)
+    entity_manager = EntityManager()
+    index = SpatialIndex(entity_manager)
+    for _ in range(count):
+        eid = entity_manager.create_entity()
+        entity_manager.add_component(
+            eid, Position(rng.randrange(WORLD_SIZE), rng.randrange(WORLD_SIZE))
+        )
+        entity_manager.add_component(eid, Monster(ai_type="aggressive"))
+    return entity_manager, index
+
+
+def full_scan(entity_manager: EntityManager, x: int, y: int, radius: int):
+    """How proximity queries worked before: check every monster."""
+    found = []
+    for eid in entity_manager.components_by_type.get(Monster, {}):
+        pos = entity_manager.get_component(eid, Position)
+        if pos and max(abs(pos.x - x), abs(pos.y - y)) <= radius:
+            found.append(eid)
+    return found
+
+
+def main():
+    import argparse
+
+    parser = argparse.ArgumentParser(description="Benchmark spatial index queries")
+    parser.add_argument(
+        "-q",
+        "--queries",
+        type=int,
+        default=200,
+        help="Queries to time per population size (default: 200)",
+    )
+    args = parser.parse_args()
+
+    print("=" * 70)
+    print(f"PROXIMITY QUERIES: radius {RADIUS} in a {WORLD_SIZE}x{WORLD_SIZE} world")
+    print("=" * 70)
+    print(f"{'Monsters':>10} {'Full scan (us)':>16} {'Index (us)':>12} {'Speedup':>9}")
+
+    rng = random.Random(2)
+    points = [
+        (rng.randrange(WORLD_SIZE), rng.randrange(WORLD_SIZE))
+        for _ in range(args.queries)
+    ]
+    for count in (100, 1000, 10000, 50000):
+        entity_manager, index = populate(count)
+        for x, y in points[:20]:
+            assert sorted(full_scan(entity_manager, x, y, RADIUS)) == sorted(
+                index.monsters_near(x, y, RADIUS)
+            )
+
+        scan = timeit.timeit(
+            lambda: [full_scan(entity_manager, x, y, RADIUS) for x, y in points],
+            number=1,
+        )
+        indexed = timeit.timeit(
+            lambda: [index.monsters_near(x, y, RADIUS) for x, y in points], number=1
+        )
+        per_scan = scan / len(points) * 1e6
+        per_index = indexed / len(points) * 1e6
+        speedup = per_scan / per_index
+        print(f"{count:>10} {per_scan:>16.1f} {per_index:>12.1f} {speedup:>8.0f}x")
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,131 @@
+"""
+Tests for monster chase prediction and pack tactics.
+"""
+
+from entities.ai_system import AISystem
+from entities.components import Monster, Position
+
+
+class OpenMap:
+    """Unbounded map where every tile is walkable."""
+
+    def is_walkable(self, x, y):
+        return True
+
+    def move_cost(self, x, y):
+        return 1.0
+
+
+def make_monster(entity_manager, x, y, monster_type="wolf", **kwargs):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, y))
+    entity_manager.add_component(
+        eid, Monster(ai_type="aggressive", monster_type=monster_type, name=monster_type, **kwargs)
+    )
+    return eid
+
+
+class TestAITactics:
+    """Test how aggressive monsters pick their targets."""
+
+    def test_chase_leads_moving_player(self, entity_manager):
+        """Test that chasers aim ahead of a player who is on the move."""
+        ai = AISystem(entity_manager)
+        ai._track_player(Position(10, 10), 1)
+        ai._track_player(Position(11, 10), 1)
+
+        target = ai._chase_target(Position(0, 10), Position(11, 10), OpenMap())
+
+        assert target == (14, 10)
+
+    def test_chase_targets_still_player(self, entity_manager):
+        """Test that the lead is dropped once the player stops."""
+        ai = AISystem(entity_manager)
+        ai._track_player(Position(10, 10), 1)
+        ai._track_player(Position(11, 10), 1)
+        ai._track_player(Position(11, 10), 1)
+
+        assert ai._chase_target(Position(0, 10), Position(11, 10), OpenMap()) == (11, 10)
+
+    def test_flank_opposite_leader(self, entity_manager):
+        """Test that a packmate circles to the far side of the player."""
+        ai = AISystem(entity_manager)
+        make_monster(entity_manager, 3, 5)
+        flanker = make_monster(entity_manager, 2, 4)
+        monster = entity_manager.get_component(flanker, Monster)
+
+        target = ai._flank_target(flanker, monster, Position(5, 5), OpenMap())
+
+        assert target == (6, 5)
+
+    def test_pack_leader_chases_directly(self, entity_manager):
+        """Test that the first wolf of a pack has no flank target."""
+        ai = AISystem(entity_manager)
+        leader = make_monster(entity_manager, 3, 5)
+        make_monster(entity_manager, 2, 4)
+        monster = entity_manager.get_component(leader, Monster)
+
+        assert ai._flank_target(leader, monster, Position(5, 5), OpenMap()) is None
+
+    def test_call_allies_alerts_packmates(self, entity_manager):
+        """Test that aggroing a caller alerts nearby monsters of its kind."""
+        ai = AISystem(entity_manager)
+        caller = make_monster(
+            entity_manager, 5, 0, monster_type="goblin", tactics="call_allies", call_radius=8
+        )
+        near = make_monster(entity_manager, 12, 0, monster_type="goblin")
+        far = make_monster(entity_manager, 30, 0, monster_type="goblin")
+        calls = []
+
+        ai.think(
+            caller,
+            entity_manager.get_component(caller, Monster),
+            entity_manager.get_component(caller, Position),
+            Position(0, 0),
+            OpenMap(),
+            alert_callback=lambda eid, answered: calls.append((eid, answered)),
+        )
+
+        assert calls == [(caller, 1)]
+        assert entity_manager.get_component(near, Monster).alerted
+        assert not entity_manager.get_component(far, Monster).alerted
+
+
+class TestAIBudget:
+    """Test that AI work is split by cell and sheds far monsters when over budget."""
+
+    def counting_ai(self, entity_manager):
+        ai = AISystem(entity_manager)
+        ai.thought = []
+        ai.think = lambda eid, *args: ai.thought.append(eid)
+        return ai
+
+    def test_nearest_cells_run_first(self, entity_manager):
+        """Test that monsters near the player think before distant ones."""
+        ai = self.counting_ai(entity_manager)
+        far = make_monster(entity_manager, 500, 500)
+        near = make_monster(entity_manager, 12, 10)
+
+        shed = ai.update(OpenMap(), Position(10, 10))
+
+        assert shed == 0
+        assert ai.thought == [near, far]
+
+    def test_over_budget_sheds_far_cells_until_next_tick(self, entity_manager):
+        """Test that far monsters wait a tick and go first; near ones always run."""
+        ai = self.counting_ai(entity_manager)
+        near = make_monster(entity_manager, 12, 10)
+        far = [make_monster(entity_manager, 100 * i, 900) for i in range(1, 4)]
+
+        shed = ai.update(OpenMap(), Position(10, 10), budget=-1)
+
+        assert shed == 3
+        assert ai.thought == [near]
+        assert ai.deferred == far
+
+        ai.thought.clear()
+        shed = ai.update(OpenMap(), Position(10, 10))
+
+        assert shed == 0
+        assert ai.thought == [near] + far
+        assert ai.shed_total == 3
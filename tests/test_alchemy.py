@@ -0,0 +1,124 @@
+"""
+Tests for brewing potions and discovering brews by experiment.
+"""
+
+import pytest
+
+from data.loader import DATA_LOADER
+from systems.alchemy import (
+    BREW_XP,
+    DISCOVERY_XP,
+    AlchemyError,
+    Knowledge,
+    combination,
+    match_brew,
+)
+
+BREWS = {
+    "health_potion": {
+        "name": "Health Potion",
+        "skill": 1,
+        "reagents": ["healing_herb", "healing_herb"],
+    },
+    "elixir_of_insight": {
+        "name": "Elixir of Insight",
+        "skill": 6,
+        "reagents": ["glowcap", "gold_ore"],
+    },
+}
+
+
+class TestMixtures:
+    """Test matching reagents against the brews."""
+
+    def test_order_does_not_matter(self):
+        """Test that the same reagents in any order make the same brew."""
+        assert combination(["b", "a", "c"]) == combination(["c", "b", "a"])
+        assert match_brew(BREWS, ["gold_ore", "glowcap"]) == "elixir_of_insight"
+        assert match_brew(BREWS, ["glowcap", "gold_ore"]) == "elixir_of_insight"
+
+    def test_counts_matter(self):
+        """Test that a reagent too many or too few matches nothing."""
+        assert match_brew(BREWS, ["healing_herb"]) is None
+        assert match_brew(BREWS, ["healing_herb"] * 3) is None
+        assert match_brew(BREWS, ["healing_herb", "glowcap"]) is None
+
+
+class TestKnowledge:
+    """Test what a player learns from brewing."""
+
+    def test_first_brew_is_a_discovery(self):
+        """Test that a brew is discovered once and pays extra XP that time only."""
+        knowledge = Knowledge()
+
+        first = knowledge.brew(BREWS, ["healing_herb", "healing_herb"], 1)
+        again = knowledge.brew(BREWS, ["healing_herb", "healing_herb"], 1)
+
+        assert first.potion == "health_potion" and first.discovered
+        assert first.xp == BREW_XP + 1 + DISCOVERY_XP
+        assert again.potion == "health_potion" and not again.discovered
+        assert again.xp == BREW_XP + 1
+        assert knowledge.known == {"health_potion"}
+
+    def test_duds_are_remembered(self):
+        """Test that a mixture that makes nothing is recorded as tried."""
+        knowledge = Knowledge()
+
+        result = knowledge.brew(BREWS, ["glowcap", "healing_herb"], 1)
+
+        assert result.potion is None and result.xp == 0
+        assert knowledge.tried == {("glowcap", "healing_herb")}
+        assert not knowledge.known
+
+    def test_skill_gates_brews(self):
+        """Test that a brew beyond the player's skill is refused and not learned."""
+        knowledge = Knowledge()
+
+        with pytest.raises(AlchemyError):
+            knowledge.brew(BREWS, ["glowcap", "gold_ore"], 5)
+        assert not knowledge.known and not knowledge.tried
+        assert knowledge.brew(BREWS, ["glowcap", "gold_ore"], 6).discovered
+
+    def test_mixture_size_is_limited(self):
+        """Test that too few or too many reagents are refused."""
+        knowledge = Knowledge()
+
+        with pytest.raises(AlchemyError):
+            knowledge.brew(BREWS, ["healing_herb"], 10)
+        with pytest.raises(AlchemyError):
+            knowledge.brew(BREWS, ["healing_herb"] * 5, 10)
+        assert not knowledge.tried
+
+    def test_profile_round_trip(self):
+        """Test that discoveries and duds survive a save and load."""
+        knowledge = Knowledge()
+        knowledge.brew(BREWS, ["healing_herb", "healing_herb"], 1)
+        knowledge.brew(BREWS, ["healing_herb", "glowcap"], 1)
+
+        restored = Knowledge()
+        restored.from_profile(knowledge.to_profile())
+
+        assert restored.known == {"health_potion"}
+        assert restored.tried == {("glowcap", "healing_herb")}
+
+
+class TestBrewContent:
+    """Test the shipped brews."""
+
+    def test_brews_use_known_items(self):
+        """Test that every brew makes a real item out of real reagents."""
+        brews = DATA_LOADER.load_json("brews")
+        items = DATA_LOADER.load_json("items")
+
+        assert brews
+        for brew_id, brew in brews.items():
+            assert brew_id in items
+            assert 2 <= len(brew["reagents"]) <= 4
+            assert all(reagent in items for reagent in brew["reagents"])
+
+    def test_no_two_brews_share_a_mixture(self):
+        """Test that each combination of reagents makes at most one brew."""
+        brews = DATA_LOADER.load_json("brews")
+        mixtures = [combination(brew["reagents"]) for brew in brews.values()]
+
+        assert len(set(mixtures)) == len(mixtures)
@@ -0,0 +1,83 @@
+"""
+Tests for the message of the day and scheduled announcements.
+"""
+
+import pytest
+
+from systems.announcements import Announcements
+
+
+class FakeClock:
+    def __init__(self, now=1000.0):
+        self.now = now
+
+    def __call__(self):
+        return self.now
+
+
+class TestAnnouncements:
+    """Test scheduling, recurring and cancelling announcements."""
+
+    def test_one_off_announcement_is_broadcast_once(self):
+        """Test that an announcement is due at its time and then dropped."""
+        clock = FakeClock()
+        announcements = Announcements(None, clock=clock)
+        announcements.schedule("Restart in 5 minutes", delay=60)
+
+        assert announcements.due() == []
+        clock.now += 60
+        assert announcements.due() == ["Restart in 5 minutes"]
+        assert announcements.due() == []
+        assert announcements.pending() == []
+
+    def test_recurring_announcement_does_not_catch_up(self):
+        """Test that a recurring notice repeats, and goes out once after a gap."""
+        clock = FakeClock()
+        announcements = Announcements(None, clock=clock)
+        announcements.schedule("Vote for the realm!", every=600)
+
+        assert announcements.due() == ["Vote for the realm!"]
+        clock.now += 599
+        assert announcements.due() == []
+        clock.now += 1
+        assert announcements.due() == ["Vote for the realm!"]
+        clock.now += 6000  # The game was down for a while
+        assert announcements.due() == ["Vote for the realm!"]
+        assert announcements.pending()[0]["at"] == clock.now + 600
+
+    def test_cancel(self):
+        """Test that a cancelled announcement is never broadcast."""
+        clock = FakeClock()
+        announcements = Announcements(None, clock=clock)
+        first = announcements.schedule("Event soon", delay=10)
+        second = announcements.schedule("Event now", delay=20)
+
+        assert announcements.cancel(first)
+        assert not announcements.cancel(first)
+        clock.now += 30
+        assert announcements.due() == ["Event now"]
+        assert second != first
+
+    def test_blank_or_negative_times_are_refused(self):
+        """Test that bad announcements are rejected with ValueError."""
+        announcements = Announcements(None)
+
+        with pytest.raises(ValueError):
+            announcements.schedule("   ")
+        with pytest.raises(ValueError):
+            announcements.schedule("Soon", delay=-5)
+
+    def test_admin_changes_survive_a_restart(self, tmp_path):
+        """Test that the MOTD and schedule are saved and win over config."""
+        path = str(tmp_path / "announcements.json")
+        clock = FakeClock()
+        announcements = Announcements(path, motd="From config", clock=clock)
+        assert announcements.motd == "From config"
+        announcements.set_motd("  Double XP weekend ")
+        announcements.schedule("Weekly reset", delay=3600, every=604800)
+
+        reloaded = Announcements(path, motd="From config", clock=clock)
+
+        assert reloaded.motd == "Double XP weekend"
+        assert [a["text"] for a in reloaded.pending()] == ["Weekly reset"]
+        assert reloaded.schedule("Another") == 2
@@ -0,0 +1,100 @@
+"""
+Tests for anti-cheat anomaly detection.
+"""
+
+from core.ecs import EntityManager
+from entities.components import Inventory
+from systems.anticheat import AntiCheat
+from systems.transactions import ItemTransaction
+
+
+def make_anticheat(path=None, **settings):
+    anticheat = AntiCheat(path, clock=lambda: 1000.0, **settings)
+    anticheat.check("hero", 1, (10, 10), 100, [5, 6])  # Baseline
+    return anticheat
+
+
+def kinds(incidents):
+    return [incident["kind"] for incident in incidents]
+
+
+class TestAntiCheat:
+    """Test that state the server's events do not explain is flagged."""
+
+    def test_explained_changes_pass(self):
+        """Test that moves, teleports and transactions the server made are not flagged."""
+        anticheat = make_anticheat()
+
+        anticheat.moved("hero", 11, 10)
+        anticheat.moved("hero", 40, 40)
+        anticheat.credited("hero", -30, [7])
+
+        assert anticheat.check("hero", 1, (40, 40), 70, [5, 6, 7]) == []
+        assert anticheat.incidents() == []
+
+    def test_unexplained_changes_are_flagged(self):
+        """Test that jumps, gold and items with no event behind them are incidents."""
+        anticheat = make_anticheat()
+
+        incidents = anticheat.check("hero", 1, (30, 10), 600, [5, 6, 9])
+
+        assert kinds(incidents) == ["teleport", "gold", "items"]
+        assert incidents[1]["detail"] == "+500 gold with no transaction"
+        # The new state is the baseline, so each anomaly is reported once
+        assert anticheat.check("hero", 1, (30, 10), 600, [5, 6, 9]) == []
+
+    def test_drift_and_losses_are_allowed(self):
+        """Test that a one-tile shove and items used up are not incidents."""
+        anticheat = make_anticheat()
+
+        assert anticheat.check("hero", 1, (11, 9), 100, [5]) == []
+
+    def test_new_character_starts_a_new_baseline(self):
+        """Test that a new player entity is not compared with the old one."""
+        anticheat = make_anticheat()
+
+        assert anticheat.check("hero", 2, (50, 50), 0, []) == []
+
+    def test_attack_rate(self):
+        """Test that attacks beyond the per-second cap are flagged once per burst."""
+        anticheat = make_anticheat(max_attacks_per_second=5)
+
+        flagged = [anticheat.attacked("hero", i * 0.25) for i in range(8)]
+        assert [f for f in flagged if f] == []
+
+        flagged = [anticheat.attacked("hero", 10 + i * 0.1) for i in range(8)]
+        assert kinds(f for f in flagged if f) == ["attack_rate"]
+
+    def test_restriction_and_review(self, tmp_path):
+        """Test that enough incidents restrict a player until a GM lifts it, and that
+        incidents survive a restart."""
+        path = str(tmp_path / "anticheat.json")
+        anticheat = make_anticheat(path, restrict_after=2)
+
+        anticheat.flag("hero", "gold", "test")
+        assert not anticheat.restricted("hero")
+        incident = anticheat.flag("hero", "gold", "test")
+        assert incident["restricted"]
+        assert anticheat.restricted("hero")
+
+        reloaded = AntiCheat(path)
+        assert reloaded.restricted("hero")
+        assert len(reloaded.incidents("hero")) == 2
+        assert reloaded.incidents("someone else") == []
+
+        assert reloaded.lift("hero")
+        assert not reloaded.restricted("hero")
+        # Reviewed incidents no longer count towards a restriction
+        reloaded.flag("hero", "gold", "test")
+        assert not reloaded.restricted("hero")
+
+    def test_transaction_listener(self):
+        """Test that committed transactions are reported to the listener."""
+        manager = EntityManager()
+        inventory = Inventory(capacity=5, items=[], gold=50)
+        committed = []
+
+        ItemTransaction(manager, listener=committed.append).remove_gold(inventory, 20).commit()
+
+        assert [t.ops[0][0] for t in committed] == ["remove_gold"]
+        assert inventory.gold == 30
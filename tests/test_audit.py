@@ -0,0 +1,63 @@
+"""
+Tests for the audit log of privileged and economy-sensitive actions.
+"""
+
+from entities.components import BankAccount, Inventory
+from systems.audit import AuditLog
+from systems.connection_guard import ConnectionGuard
+from systems.transactions import ItemTransaction
+
+
+class TestAuditLog:
+    """Test recording, querying and the hooks that feed the audit log."""
+
+    def test_query_filters_newest_first(self, tmp_path):
+        """Test that entries come back newest first, filtered and limited."""
+        audit = AuditLog(str(tmp_path / "audit.jsonl"))
+        audit.record("ban", "connection_guard", "10.0.0.1", seconds=600)
+        audit.record("admin_request", "admin", "profile_start")
+        audit.record("ban", "connection_guard", "10.0.0.2", seconds=600)
+
+        bans = audit.query(action="ban")
+
+        assert [entry["target"] for entry in bans] == ["10.0.0.2", "10.0.0.1"]
+        assert bans[0]["details"] == {"seconds": 600}
+        assert [e["target"] for e in audit.query(actor="admin")] == ["profile_start"]
+        assert len(audit.query(limit=1)) == 1
+        assert audit.query(since=bans[0]["time"] + 1) == []
+
+    def test_torn_last_line_is_skipped(self, tmp_path):
+        """Test that a half-written entry after a crash does not hide the rest."""
+        path = tmp_path / "audit.jsonl"
+        audit = AuditLog(str(path))
+        audit.record("ban", "connection_guard", "10.0.0.1")
+        with open(path, "a") as f:
+            f.write('{"action": "ba')
+
+        assert [entry["action"] for entry in audit.read()] == ["ban"]
+
+    def test_only_large_gold_transactions_recorded(self, entity_manager, tmp_path):
+        """Test that transactions at or over the threshold are audited with their reason."""
+        audit = AuditLog(str(tmp_path / "audit.jsonl"), large_gold=500)
+        inv = Inventory(capacity=5, items=[], gold=2000)
+        bank = BankAccount()
+
+        ItemTransaction(entity_manager, reason="bank", audit=audit).transfer_gold(
+            inv, bank, 10
+        ).commit()
+        big = ItemTransaction(entity_manager, reason="vendor_purchase", audit=audit)
+        big.remove_gold(inv, 500).commit()
+
+        entries = audit.query(action="large_gold")
+        assert [entry["target"] for entry in entries] == [big.txid]
+        assert entries[0]["details"] == {"reason": "vendor_purchase", "amount": 500}
+
+    def test_bans_are_recorded(self, tmp_path):
+        """Test that the connection guard writes each ban to the audit log."""
+        audit = AuditLog(str(tmp_path / "audit.jsonl"))
+        guard = ConnectionGuard(max_per_ip=0, attempts=1, audit=audit)
+        guard.admit("10.0.0.9")
+        guard.release("10.0.0.9")
+        guard.admit("10.0.0.9")
+
+        assert [entry["target"] for entry in audit.query(action="ban")] == ["10.0.0.9"]
@@ -0,0 +1,95 @@
+"""
+Tests for the behavior tree engine.
+"""
+
+import pytest
+
+from entities.behavior_tree import (
+    FAILURE,
+    RUNNING,
+    SUCCESS,
+    action,
+    build_tree,
+    build_trees,
+    condition,
+)
+from data.loader import DATA_LOADER
+
+
+@condition("test_flag")
+def _flag(ctx, key="flag"):
+    return ctx[key]
+
+
+@action("test_record")
+def _record(ctx, label="", status=SUCCESS):
+    ctx["log"].append(label)
+    return status
+
+
+def leaf(name, **params):
+    node_type = "condition" if name == "test_flag" else "action"
+    return {"type": node_type, "name": name, **params}
+
+
+class TestBehaviorTree:
+    """Test composite nodes and loading trees from content."""
+
+    def test_selector_stops_at_first_success(self):
+        """Test that a selector skips failing children and stops on success."""
+        tree = build_tree(
+            {
+                "type": "selector",
+                "children": [
+                    leaf("test_record", label="a", status=FAILURE),
+                    leaf("test_record", label="b"),
+                    leaf("test_record", label="c"),
+                ],
+            }
+        )
+        ctx = {"log": []}
+
+        assert tree.tick(ctx) == SUCCESS
+        assert ctx["log"] == ["a", "b"]
+
+    def test_sequence_stops_at_failed_condition(self):
+        """Test that a sequence stops when a condition fails."""
+        tree = build_tree(
+            {
+                "type": "sequence",
+                "children": [leaf("test_flag"), leaf("test_record", label="ran")],
+            }
+        )
+        ctx = {"log": [], "flag": False}
+
+        assert tree.tick(ctx) == FAILURE
+        assert ctx["log"] == []
+
+        ctx["flag"] = True
+        assert tree.tick(ctx) == SUCCESS
+        assert ctx["log"] == ["ran"]
+
+    def test_inverter_and_running(self):
+        """Test that inverters flip results but pass running through."""
+        inverted = build_tree({"type": "inverter", "child": leaf("test_flag")})
+        running = build_tree(
+            {"type": "inverter", "child": leaf("test_record", status=RUNNING)}
+        )
+
+        assert inverted.tick({"flag": True}) == FAILURE
+        assert running.tick({"log": []}) == RUNNING
+
+    def test_unknown_nodes_rejected(self):
+        """Test that typos in content files fail at load time."""
+        with pytest.raises(ValueError):
+            build_tree({"type": "action", "name": "no_such_action"})
+        with pytest.raises(ValueError):
+            build_tree({"type": "parallel", "children": []})
+
+    def test_content_trees_build(self):
+        """Test that the shipped behavior trees only use registered leaves."""
+        import entities.ai_system  # noqa: F401  (registers the monster leaves)
+
+        trees = build_trees(DATA_LOADER.load_json("behaviors"))
+
+        assert {"aggressive", "passive", "patrol", "static"} <= set(trees)
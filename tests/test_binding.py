@@ -0,0 +1,57 @@
+"""
+Tests for bind-on-pickup and bind-on-equip items.
+"""
+
+from entities.components import Item
+from systems.binding import (
+    BIND_ON_EQUIP,
+    BIND_ON_PICKUP,
+    bind_on,
+    bind_status,
+    transfer_refusal,
+)
+
+
+def item(bind=""):
+    return Item(name="Iron Greaves", description="", bind=bind)
+
+
+class TestBinding:
+    """Test when items bind and what binding forbids."""
+
+    def test_binds_only_on_its_event(self):
+        """Test that a bind-on-equip item ignores pickups and binds when worn."""
+        greaves = item(BIND_ON_EQUIP)
+
+        assert not bind_on(greaves, BIND_ON_PICKUP, "Ayla")
+        assert bind_status(greaves) == "binds_on_equip"
+        assert bind_on(greaves, BIND_ON_EQUIP, "Ayla")
+        assert bind_status(greaves) == "bound" and greaves.bound_to == "Ayla"
+
+    def test_binding_is_for_good(self):
+        """Test that a bound item never rebinds to someone else."""
+        draught = item(BIND_ON_PICKUP)
+        bind_on(draught, BIND_ON_PICKUP, "Ayla")
+
+        assert not bind_on(draught, BIND_ON_PICKUP, "Bram")
+        assert draught.bound_to == "Ayla"
+
+    def test_unbound_items(self):
+        """Test that plain items never bind and report no status."""
+        sword = item()
+
+        assert not bind_on(sword, BIND_ON_PICKUP, "Ayla")
+        assert not bind_on(sword, BIND_ON_EQUIP, "Ayla")
+        assert bind_status(sword) == ""
+
+    def test_refusals(self):
+        """Test that only bound items are refused, and only when changing hands."""
+        greaves = item(BIND_ON_EQUIP)
+        assert transfer_refusal(greaves, "trade") is None
+
+        bind_on(greaves, BIND_ON_EQUIP, "Ayla")
+        for reason in ("trade", "auction", "mail"):
+            assert transfer_refusal(greaves, reason) == "Iron Greaves is bound to Ayla"
+        for reason in ("bank", "vendor_sale", "pickup"):
+            assert transfer_refusal(greaves, reason) is None
+        assert transfer_refusal(None, "trade") is None
@@ -0,0 +1,81 @@
+"""
+Tests for scripted boss fights.
+"""
+
+from entities.boss_system import BossSystem
+from entities.components import Combat, Health, Monster, Position
+
+
+class OpenMap:
+    """Map where every tile is walkable."""
+
+    def is_walkable(self, x, y):
+        return True
+
+
+def start_fight(entity_manager, entity_factory, boss_type="forest_guardian"):
+    bosses = BossSystem(entity_manager, entity_factory)
+    encounter = next(
+        e for e in bosses.boss_encounters.values() if e.boss_type == boss_type
+    )
+    eid = bosses.trigger_boss_encounter(encounter)
+    player = entity_manager.create_entity()
+    entity_manager.add_component(player, Position(encounter.x + 50, encounter.y))
+    entity_manager.add_component(player, Health(current=100, maximum=100))
+    return bosses, encounter, eid, player
+
+
+class TestBossFights:
+    """Test phase transitions, enrage and fight bookkeeping."""
+
+    def test_phase_transition_at_threshold(self, entity_manager, entity_factory):
+        """Test that dropping below an HP threshold starts the next phase."""
+        bosses, encounter, eid, player = start_fight(entity_manager, entity_factory)
+        health = entity_manager.get_component(eid, Health)
+        monsters_before = len(entity_manager.get_all_entities_with_component(Monster))
+
+        health.current = int(health.maximum * 0.4)
+        bosses.update(0.1, OpenMap(), player)
+
+        fight = bosses.active_fights[eid]
+        assert fight.phase_index == 1
+        assert entity_manager.get_component(eid, Combat).attack_power == int(
+            encounter.attack * 1.3
+        )
+        assert len(entity_manager.get_all_entities_with_component(Monster)) == monsters_before + 2
+
+    def test_enrage_after_timer(self, entity_manager, entity_factory):
+        """Test that a long fight enrages the boss."""
+        bosses, encounter, eid, player = start_fight(entity_manager, entity_factory)
+        messages = []
+
+        bosses.update(121, OpenMap(), player, lambda text, color: messages.append(text))
+
+        assert bosses.active_fights[eid].enraged
+        assert entity_manager.get_component(eid, Combat).attack_power == encounter.attack * 2
+        assert any("rage" in text for text in messages)
+
+    def test_strike_needs_range(self, entity_manager, entity_factory):
+        """Test that targeted abilities only hit a player in range."""
+        bosses, encounter, eid, player = start_fight(entity_manager, entity_factory)
+        player_health = entity_manager.get_component(player, Health)
+
+        bosses.update(6, OpenMap(), player)
+        assert player_health.current == 100
+
+        entity_manager.get_component(player, Position).x = encounter.x + 2
+        bosses.update(6, OpenMap(), player)
+        assert player_health.current < 100
+
+    def test_defeat_and_despawn(self, entity_manager, entity_factory):
+        """Test that kills are recorded and despawned bosses can return."""
+        bosses, encounter, eid, player = start_fight(entity_manager, entity_factory)
+
+        entity_manager.destroy_entity(eid)
+        bosses.update(0.1, OpenMap(), player)
+        assert not encounter.is_spawned and not encounter.defeated
+
+        eid = bosses.trigger_boss_encounter(encounter)
+        bosses.mark_defeated(eid)
+        assert encounter.defeated
+        assert eid not in bosses.active_fights
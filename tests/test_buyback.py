@@ -0,0 +1,45 @@
+"""
+Tests for buying back items sold to vendors.
+"""
+
+from systems.buyback import Buyback
+
+
+class TestBuyback:
+    """Test holding back recent sales."""
+
+    def test_newest_sale_comes_first(self):
+        """Test that sales are listed newest first with what they fetched."""
+        buyback = Buyback()
+        buyback.record(7, 25)
+        buyback.record(9, 4)
+
+        assert buyback.entries() == [(9, 4), (7, 25)]
+        assert buyback.price(7) == 25
+
+    def test_oldest_sale_is_pushed_out(self):
+        """Test that only the last few sales are kept, handing back the rest."""
+        buyback = Buyback(slots=2)
+        assert buyback.record(1, 10) == []
+        assert buyback.record(2, 20) == []
+
+        assert buyback.record(3, 30) == [1]
+        assert buyback.items == [3, 2] and 1 not in buyback.prices
+
+    def test_take_forgets_the_sale(self):
+        """Test that a sale bought back can't be bought back again."""
+        buyback = Buyback()
+        buyback.record(1, 10)
+        buyback.record(2, 20)
+        buyback.take(1)
+
+        assert buyback.entries() == [(2, 20)]
+
+    def test_clear_hands_back_everything(self):
+        """Test that clearing the sales returns every held item."""
+        buyback = Buyback()
+        buyback.record(1, 10)
+        buyback.record(2, 20)
+
+        assert buyback.clear() == [2, 1]
+        assert buyback.entries() == []
@@ -0,0 +1,155 @@
+"""
+Tests for cave systems on the underground Z-levels.
+"""
+
+import random
+
+import numpy as np
+
+from world.caves import (
+    CaveSystem,
+    carve_cave,
+    find_cave_mouths,
+    generate_caves,
+    seam_ore,
+)
+from world.map import (
+    TILE_FLOOR,
+    TILE_GRASS,
+    TILE_ORE_GOLD,
+    TILE_ORE_IRON,
+    TILE_STAIRS_DOWN,
+    TILE_STAIRS_UP,
+    TILE_WALL,
+    TILE_WATER,
+)
+
+
+def reachable(open_, start):
+    """Open tiles reachable from start by four-way steps."""
+    seen, stack = {start}, [start]
+    while stack:
+        x, y = stack.pop()
+        for nx, ny in ((x + 1, y), (x - 1, y), (x, y + 1), (x, y - 1)):
+            if (
+                0 <= ny < len(open_)
+                and 0 <= nx < len(open_[0])
+                and open_[ny][nx]
+                and (nx, ny) not in seen
+            ):
+                seen.add((nx, ny))
+                stack.append((nx, ny))
+    return seen
+
+
+class TestCaveShape:
+    """Test carving a single cave system."""
+
+    def test_one_connected_cave(self):
+        """Test that every open tile can be reached from the entrance."""
+        for seed in range(5):
+            open_ = carve_cave(40, 30, (20, 15), random.Random(seed))
+            tiles = {(x, y) for y in range(30) for x in range(40) if open_[y][x]}
+
+            assert len(tiles) > 100
+            assert reachable(open_, (20, 15)) == tiles
+
+    def test_edges_stay_rock(self):
+        """Test that a cave never opens onto the edge of its area."""
+        open_ = carve_cave(40, 30, (1, 1), random.Random(3))
+
+        assert not any(open_[0]) and not any(open_[-1])
+        assert not any(row[0] or row[-1] for row in open_)
+
+    def test_ore_faces_the_cave(self):
+        """Test that ore only seams walls beside open ground, with gold deeper."""
+        open_ = carve_cave(60, 40, (30, 20), random.Random(1))
+        shallow = seam_ore(open_, 1, random.Random(2))
+        deep = seam_ore(open_, 10, random.Random(2))
+
+        assert shallow
+        for x, y in shallow:
+            assert not open_[y][x]
+            assert any(
+                open_[y + dy][x + dx]
+                for dx in (-1, 0, 1)
+                for dy in (-1, 0, 1)
+                if 0 <= y + dy < 40 and 0 <= x + dx < 60
+            )
+        gold = list(deep.values()).count(TILE_ORE_GOLD)
+        assert gold > list(shallow.values()).count(TILE_ORE_GOLD)
+        assert set(deep.values()) <= {TILE_ORE_IRON, TILE_ORE_GOLD}
+
+
+class TestCaveLevels:
+    """Test stacking cave systems into levels."""
+
+    def test_stairs_line_up(self):
+        """Test that each level's way down arrives on the next level's way up."""
+        levels, systems = generate_caves(
+            120, 100, [(40, 40)], 3, random.Random(4), size=(50, 40)
+        )
+
+        assert sorted(levels) == [1, 2, 3]
+        assert [system.z for system in systems] == [1, 2, 3]
+        for system in systems:
+            x, y = system.entrance
+            assert levels[system.z][y, x] == TILE_STAIRS_UP
+            for ex, ey in system.exits:
+                assert levels[system.z][ey, ex] == TILE_STAIRS_DOWN
+                assert levels[system.z + 1][ey, ex] == TILE_STAIRS_UP
+        assert systems[0].entrance == (40, 40)
+        assert systems[-1].exits == []
+
+    def test_systems_keep_to_their_area(self):
+        """Test that a level is solid rock outside its cave systems."""
+        levels, (system,) = generate_caves(
+            100, 100, [(2, 97)], 1, random.Random(5), size=(30, 20)
+        )
+        level = levels[1]
+
+        assert (system.x, system.y) == (1, 79)
+        for y in range(100):
+            for x in range(100):
+                inside = (
+                    system.x <= x < system.x + system.width
+                    and system.y <= y < system.y + system.height
+                )
+                if not inside:
+                    assert level[y, x] == TILE_WALL
+        assert level[97, 2] == TILE_STAIRS_UP
+        assert level[96, 3] == TILE_FLOOR
+
+    def test_no_entrances(self):
+        """Test that a world without entrances has no caves."""
+        assert generate_caves(50, 50, [], 3, random.Random(1)) == ({}, [])
+
+    def test_round_trip(self):
+        """Test that a cave system survives saving as plain data."""
+        system = CaveSystem(2, 10, 20, 30, 40, (25, 35), [(12, 22)])
+
+        assert CaveSystem.from_dict(system.to_dict()) == system
+
+
+class TestCaveMouths:
+    """Test choosing natural cave entrances on the overworld."""
+
+    def test_mouths_on_open_hills(self):
+        """Test that mouths are on grass in cave biomes, spread apart."""
+        world_map = np.full((200, 200), TILE_GRASS, dtype=np.uint8)
+        biome_map = np.full((200, 200), "hill", dtype=object)
+        for y in range(200):
+            for x in range(100):
+                biome_map[y, x] = "desert"
+        world_map[150, 150] = TILE_WATER
+
+        mouths = find_cave_mouths(
+            world_map, biome_map, random.Random(1), 5, margin=20, avoid=[(150, 100)]
+        )
+
+        assert mouths
+        for i, (x, y) in enumerate(mouths):
+            assert x >= 100 and (x, y) != (150, 150)
+            assert abs(x - 150) + abs(y - 100) >= 20
+            for ox, oy in mouths[i + 1 :]:
+                assert abs(x - ox) + abs(y - oy) >= 20
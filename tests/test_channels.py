@@ -0,0 +1,110 @@
+"""
+Tests for server and player chat channels.
+"""
+
+import os
+
+import pytest
+
+from systems.channels import MAX_OWNED, ChannelError, ChannelRouter
+from systems.profile import save_profile
+
+
+class TestChannels:
+    """Test joining, leaving and speaking on channels."""
+
+    def test_server_channels_are_open(self):
+        """Test that anyone can join the server's channels and then speak."""
+        router = ChannelRouter(None)
+
+        with pytest.raises(ChannelError, match="Join trade"):
+            router.route("trade", "Wren")
+        router.join("Trade", "Wren")
+
+        assert router.route("trade", "Wren").name == "trade"
+        assert [c["name"] for c in router.listing("Wren")] == ["help", "trade"]
+
+    def test_private_channel_password(self):
+        """Test that a private channel keeps out anyone without its password."""
+        router = ChannelRouter(None)
+        router.create("hunters", "Wren", "s3cret")
+
+        with pytest.raises(ChannelError, match="Wrong password"):
+            router.join("hunters", "Rook", "guess")
+        router.join("hunters", "Rook", "s3cret")
+
+        entry = [c for c in router.listing("Rook") if c["name"] == "hunters"][0]
+        assert entry["joined"] and entry["private"] and entry["owner"] == "Wren"
+
+    def test_moderators_remove_members(self):
+        """Test that only the owner and moderators can remove members."""
+        router = ChannelRouter(None)
+        router.create("hunters", "Wren")
+        for player in ("Rook", "Ash"):
+            router.join("hunters", player)
+
+        with pytest.raises(ChannelError, match="do not moderate"):
+            router.kick("hunters", "Rook", "Ash")
+        with pytest.raises(ChannelError, match="Only the owner"):
+            router.add_moderator("hunters", "Rook", "Rook")
+        router.add_moderator("hunters", "Wren", "Rook")
+        router.kick("hunters", "Rook", "Ash")
+        with pytest.raises(ChannelError):
+            router.kick("hunters", "Rook", "Wren")
+
+        assert router.get("hunters").members == ["Wren", "Rook"]
+        with pytest.raises(ChannelError, match="Join hunters"):
+            router.route("hunters", "Ash")
+
+    def test_owner_leaving_closes_channel(self):
+        """Test that a channel goes away when its owner leaves it."""
+        router = ChannelRouter(None)
+        router.create("hunters", "Wren")
+        router.leave("hunters", "Wren")
+
+        with pytest.raises(ChannelError, match="no channel"):
+            router.get("hunters")
+
+    def test_bad_channels_refused(self):
+        """Test names, duplicates and how many channels one player may own."""
+        router = ChannelRouter(None)
+
+        with pytest.raises(ChannelError, match="Channel names"):
+            router.create("No Spaces", "Wren")
+        with pytest.raises(ChannelError, match="already"):
+            router.create("trade", "Wren")
+        for i in range(MAX_OWNED):
+            router.create(f"room{i}", "Wren")
+        with pytest.raises(ChannelError, match="at most"):
+            router.create("extra", "Wren")
+
+    def test_channels_are_saved(self, tmp_path):
+        """Test that player channels and memberships survive a restart."""
+        path = os.path.join(tmp_path, "channels.json")
+        router = ChannelRouter(path)
+        router.create("hunters", "Wren", "s3cret")
+        router.join("trade", "Wren")
+
+        restored = ChannelRouter(path)
+        assert "s3cret" not in open(path).read()
+        restored.join("hunters", "Rook", "s3cret")
+        assert "Rook" in restored.get("hunters").members
+        assert restored.get("trade").members == ["Wren"]
+        # A server channel dropped from config is gone, members and all
+        without_trade = ChannelRouter(path, server=("help",))
+        assert sorted(without_trade.channels) == ["help", "hunters"]
+
+    def test_older_saves_still_load(self, tmp_path):
+        """Test that a plaintext password from an older save is hashed, and
+        fields a channel no longer has are skipped."""
+        path = os.path.join(tmp_path, "channels.json")
+        old = {"name": "hunters", "owner": "Wren", "password": "s3cret", "members": ["Wren"]}
+        save_profile(path, {"channels": [dict(old, topic="Boars")]})
+
+        router = ChannelRouter(path)
+        assert "s3cret" not in open(path).read()
+        with pytest.raises(ChannelError, match="Wrong password"):
+            router.join("hunters", "Rook", "guess")
+        router.join("hunters", "Rook", "s3cret")
+
+        assert router.get("hunters").members == ["Wren", "Rook"]
@@ -0,0 +1,85 @@
+"""
+Tests for the command registry and help reference.
+"""
+
+from core import recovery
+from core.commands import CommandRegistry
+
+
+def make_registry(calls):
+    commands = CommandRegistry()
+    commands.action("move", lambda dx, dy: calls.append(("move", dx, dy)), "Step", ("dx", "dy"))
+    commands.action("wait", lambda: calls.append(("wait",)), "Wait")
+    commands.request("help", commands.help, "List commands")
+    commands.request(
+        "map_request", lambda scale: {"type": "map_response", "scale": scale}, "Map",
+        ("scale",), response="map_response",
+    )
+    return commands
+
+
+class TestCommands:
+    """Test action dispatch, request handling and the generated help."""
+
+    def test_run_action(self):
+        """Test that registered actions run with their arguments and unknown ones are refused."""
+        calls = []
+        commands = make_registry(calls)
+
+        assert commands.run_action("move", 1, 0)
+        assert commands.run_action("wait")
+        assert not commands.run_action("dance")
+        assert calls == [("move", 1, 0), ("wait",)]
+
+    def test_requests_and_errors(self):
+        """Test that requests get their arguments and bad requests get error messages."""
+        commands = make_registry([])
+
+        assert commands.handle_request({"type": "map_request", "scale": 4}) == {
+            "type": "map_response",
+            "scale": 4,
+        }
+        assert commands.handle_request({"type": "map_request"})["type"] == "error"
+        assert commands.handle_request({"type": "teleport"})["type"] == "error"
+
+    def test_help_lists_registered_commands(self):
+        """Test that help is generated from what is registered, without key bindings."""
+        commands = make_registry([])
+
+        help_message = commands.handle_request({"type": "help"})
+
+        assert help_message["type"] == "help"
+        assert [a["name"] for a in help_message["actions"]] == ["move", "wait"]
+        assert help_message["actions"][0]["args"] == ["dx", "dy"]
+        assert {"name": "map_request", "description": "Map", "args": ["scale"], "response": "map_response"} in help_message["requests"]
+        assert help_message["message_types"] == ["error", "help", "map_request", "map_response"]
+
+    def test_optional_request_args(self):
+        """Test that optional args are passed only when the message has them."""
+        commands = CommandRegistry()
+        commands.request(
+            "search",
+            lambda text, limit=10: {"type": "search", "text": text, "limit": limit},
+            "Search",
+            ("text",),
+            optional=("limit",),
+        )
+
+        assert commands.handle_request({"type": "search", "text": "x"})["limit"] == 10
+        assert commands.handle_request({"type": "search", "text": "x", "limit": 2})[
+            "limit"
+        ] == 2
+        assert commands.help()["requests"][0]["optional"] == ["limit"]
+
+    def test_handler_crash_is_contained(self, monkeypatch, tmp_path):
+        """Test that a crashing handler is logged with its stack and answered with a fatal error."""
+        log = tmp_path / "debug.log"
+        monkeypatch.setattr(recovery, "DEBUG_LOG", str(log))
+        commands = make_registry([])
+        commands.request("boom", lambda: {}["missing"], "Broken handler")
+
+        reply = commands.handle_request({"type": "boom"})
+
+        assert reply == {"type": "error", "error": "Internal error handling boom", "fatal": True}
+        assert "KeyError" in log.read_text()
+        assert commands.handle_request({"type": "help"})["type"] == "help"
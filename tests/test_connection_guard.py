@@ -0,0 +1,72 @@
+"""
+Tests for per-IP connection limits.
+"""
+
+from systems.connection_guard import ConnectionGuard
+
+
+class FakeClock:
+    def __init__(self):
+        self.now = 0.0
+
+    def __call__(self):
+        return self.now
+
+
+class TestConnectionGuard:
+    """Test connection caps, attempt throttling and temporary bans."""
+
+    def test_open_connections_capped_per_address(self):
+        """Test that one address cannot hold more than its share open."""
+        guard = ConnectionGuard(max_per_ip=2, attempts=0)
+
+        assert guard.admit("10.0.0.1") is None
+        assert guard.admit("10.0.0.1") is None
+        assert "Too many connections" in guard.admit("10.0.0.1")
+        assert guard.admit("10.0.0.2") is None
+
+        guard.release("10.0.0.1")
+
+        assert guard.admit("10.0.0.1") is None
+
+    def test_rapid_reconnects_are_banned(self):
+        """Test that going over the attempt limit bans the address until it expires."""
+        clock = FakeClock()
+        guard = ConnectionGuard(
+            max_per_ip=0, attempts=3, window=10, ban_seconds=60, clock=clock
+        )
+        for _ in range(3):
+            assert guard.admit("10.0.0.1") is None
+            guard.release("10.0.0.1")
+
+        assert "Try again later" in guard.admit("10.0.0.1")
+        clock.now = 59
+        assert "Try again later" in guard.admit("10.0.0.1")
+        assert guard.admit("10.0.0.2") is None
+
+        clock.now = 61
+        assert guard.admit("10.0.0.1") is None
+
+    def test_attempts_spread_out_are_fine(self):
+        """Test that reconnecting slower than the window allows is never banned."""
+        clock = FakeClock()
+        guard = ConnectionGuard(max_per_ip=0, attempts=2, window=10, clock=clock)
+        for step in range(10):
+            clock.now = step * 6
+            assert guard.admit("10.0.0.1") is None
+            guard.release("10.0.0.1")
+
+        assert not guard.banned
+        assert not guard.open
+
+    def test_admin_ban(self):
+        """Test that an address banned by hand is refused until the ban lifts."""
+        clock = FakeClock()
+        guard = ConnectionGuard(attempts=0, clock=clock)
+
+        guard.ban("10.0.0.1", 30)
+
+        assert "Try again later" in guard.admit("10.0.0.1")
+        assert guard.admit("10.0.0.2") is None
+        clock.now = 31
+        assert guard.admit("10.0.0.1") is None
@@ -0,0 +1,105 @@
+"""
+Tests for crafting quality, critical crafts and crafters' signatures.
+"""
+
+import random
+
+import pytest
+
+from entities.components import Item, WeaponStats
+from systems.crafting import (
+    CraftingError,
+    CraftRoll,
+    apply_roll,
+    check_recipe,
+    roll_craft,
+    stat_range,
+)
+
+RECIPES = {"sword": {"name": "Iron Sword", "skill": 5, "materials": {"iron_ore": 3}}}
+
+
+class TestCraftRoll:
+    """Test how skill shapes the quality of a craft."""
+
+    def test_skill_lifts_the_stat_range(self):
+        """Test that crafting above a recipe's level rolls better stats."""
+        low, high = stat_range(0)
+        skilled_low, skilled_high = stat_range(10)
+
+        assert low < skilled_low and high < skilled_high
+        assert stat_range(-3) == stat_range(0)
+        assert stat_range(100) == (1.0, 1.3)
+
+    def test_skilled_crafters_make_better_items(self):
+        """Test that more skill means better rolls, more affixes and more criticals."""
+
+        def rolls(skill):
+            rng = random.Random(7)
+            return [roll_craft(skill, 5, rng) for _ in range(500)]
+
+        novice, master = rolls(5), rolls(20)
+
+        def average(results):
+            return sum(r.multiplier for r in results) / len(results)
+
+        assert average(master) > average(novice)
+        assert sum(r.bonus_affix for r in master) > sum(r.bonus_affix for r in novice)
+        assert sum(r.critical for r in master) > sum(r.critical for r in novice)
+        criticals = [r for r in master if r.critical]
+        assert all(r.bonus_affix and r.quality == "masterwork" for r in criticals)
+
+    def test_quality_names(self):
+        """Test that the stat multiplier names the quality."""
+        assert CraftRoll(0.75, False, False).quality == "crude"
+        assert CraftRoll(1.0, False, False).quality == "standard"
+        assert CraftRoll(1.1, False, False).quality == "fine"
+        assert CraftRoll(1.25, False, False).quality == "superior"
+        assert CraftRoll(1.6, True, True).quality == "masterwork"
+
+
+class TestRecipes:
+    """Test checking a recipe's skill and materials."""
+
+    def test_check_recipe(self):
+        """Test that a crafter with the skill and materials gets the recipe."""
+        assert check_recipe(RECIPES, "sword", 5, {"iron_ore": 4}) is RECIPES["sword"]
+
+    def test_refusals(self):
+        """Test that unknown recipes, low skill and missing materials are refused."""
+        for name, skill, carried, message in (
+            ("bow", 5, {"iron_ore": 3}, "no recipe"),
+            ("sword", 4, {"iron_ore": 3}, "crafting 5"),
+            ("sword", 5, {"iron_ore": 1}, "2 iron_ore"),
+        ):
+            with pytest.raises(CraftingError, match=message):
+                check_recipe(RECIPES, name, skill, carried)
+
+
+class TestApplyRoll:
+    """Test stamping a roll onto a crafted item."""
+
+    def test_scales_and_signs(self, entity_factory):
+        """Test that a masterwork is scaled, given a good affix and signed."""
+        em = entity_factory.entity_manager
+        sword = entity_factory.create_item(0, 0, "sword", rarity="common")
+        base = em.get_component(sword, WeaponStats).attack_power
+
+        roll = CraftRoll(2.0, True, True)
+        added = apply_roll(em, sword, roll, "Rook", random.Random(1))
+
+        item = em.get_component(sword, Item)
+        assert item.crafted_by == "Rook" and item.quality == "masterwork"
+        assert item.name.startswith("Masterwork ") and added[0] in item.name
+        assert item.affixes == added and added[0] != "Rusty"
+        assert em.get_component(sword, WeaponStats).attack_power > base * 2
+
+    def test_standard_keeps_its_name(self, entity_factory):
+        """Test that a standard craft keeps the plain name but is still signed."""
+        em = entity_factory.entity_manager
+        sword = entity_factory.create_item(0, 0, "sword", rarity="common")
+
+        apply_roll(em, sword, CraftRoll(1.0, False, False), "Rook")
+
+        item = em.get_component(sword, Item)
+        assert (item.name, item.crafted_by, item.affixes) == ("Iron Sword", "Rook", [])
@@ -0,0 +1,75 @@
+"""
+Tests for coins, tokens and the wallet kept in the profile.
+"""
+
+import pytest
+
+from entities.components import Inventory
+from systems.currency import (
+    CurrencyError,
+    balance,
+    coin_worth,
+    exchange,
+    format_coins,
+    restore_wallet,
+    split_coins,
+    wallet_profile,
+)
+
+
+def make_wallet(gold=0, **currencies):
+    inventory = Inventory(capacity=5, items=[], gold=gold)
+    inventory.currencies.update(currencies)
+    return inventory
+
+
+class TestCoins:
+    """Test changing and counting copper, silver and gold."""
+
+    def test_coin_worth(self):
+        """Test that every coin counts towards the wallet's worth in copper."""
+        wallet = make_wallet(gold=2, silver=3, copper=4)
+
+        assert coin_worth(wallet) == 20304
+        assert format_coins(coin_worth(wallet)) == "2 gold, 3 silver, 4 copper"
+
+    def test_split_uses_fewest_coins(self):
+        """Test that an amount is made of the largest coins that fit."""
+        assert split_coins(10105) == [("gold", 1), ("silver", 1), ("copper", 5)]
+        assert format_coins(0) == "0 copper"
+
+    def test_exchange(self):
+        """Test changing coins up and down, keeping odd coins."""
+        assert exchange("gold", "silver", 2) == (200, 0)
+        assert exchange("silver", "gold", 250) == (2, 50)
+        assert exchange("copper", "silver", 99) == (0, 99)
+
+    def test_tokens_are_not_coin(self):
+        """Test that event tokens never change into coin."""
+        with pytest.raises(CurrencyError):
+            exchange("event_token", "gold", 10)
+        with pytest.raises(CurrencyError):
+            exchange("copper", "doubloon", 10)
+
+
+class TestWallet:
+    """Test the balances kept between sessions."""
+
+    def test_balance(self):
+        """Test that gold is read from the inventory's gold field."""
+        wallet = make_wallet(gold=7, event_token=3)
+
+        assert balance(wallet, "gold") == 7
+        assert balance(wallet, "event_token") == 3
+        assert balance(wallet, "crown") == 0
+
+    def test_only_persistent_currencies_are_kept(self):
+        """Test that coin goes with the character while tokens are saved."""
+        wallet = make_wallet(gold=50, silver=2, event_token=3, crown=9)
+
+        assert wallet_profile(wallet) == {"event_token": 3, "crown": 9}
+        assert wallet_profile(wallet, account_only=True) == {"crown": 9}
+
+        restored = make_wallet()
+        restore_wallet(restored, {"event_token": 3, "gold": 500, "silver": 4})
+        assert restored.currencies == {"event_token": 3} and restored.gold == 0
@@ -0,0 +1,92 @@
+"""
+Tests for cutscene definitions, timing and skipping.
+"""
+
+import json
+
+import pytest
+
+from systems.cutscenes import (
+    Cutscene,
+    CutsceneError,
+    CutsceneLog,
+    resolve_target,
+    validate_cutscene,
+)
+
+SCENE = {
+    "steps": [
+        {"do": "focus", "target": "subject", "pause": 1.0},
+        {"do": "dialogue", "speaker": "{subject}", "text": "Hello.", "pause": 2.0},
+        {"do": "move", "target": "subject", "dx": 0, "dy": 1, "pause": 0},
+        {"do": "focus", "x": 3, "y": 4},
+    ]
+}
+
+
+def kinds(steps):
+    return [step["do"] for step in steps]
+
+
+class TestCutscenes:
+    """Test that cutscenes are checked, paced on game time and skippable."""
+
+    def test_shipped_cutscenes_are_valid(self):
+        """Test that every cutscene in the content files passes validation."""
+        with open("src/data/static/cutscenes.json", encoding="utf-8") as f:
+            for name, entry in json.load(f).items():
+                validate_cutscene(entry, name)
+
+    def test_bad_cutscenes(self):
+        """Test that malformed cutscenes are refused with the step at fault."""
+        bad = [
+            {},
+            {"steps": []},
+            {"steps": [{"do": "explode"}]},
+            {"steps": [{"do": "dialogue"}]},
+            {"steps": [{"do": "focus"}]},
+            {"steps": [{"do": "move", "target": "everyone", "dx": 1, "dy": 0}]},
+            {"steps": [{"do": "move", "target": "player", "dx": 0.5, "dy": 0}]},
+            {"steps": [{"do": "wait", "pause": 600}]},
+            {"steps": [{"do": "dialogue", "text": "hi", "sound": "boom"}]},
+        ]
+        for entry in bad:
+            with pytest.raises(CutsceneError):
+                validate_cutscene(entry)
+
+    def test_steps_run_after_their_pauses(self):
+        """Test that each step waits for the pause of the one before it."""
+        scene = Cutscene("intro", SCENE, subject=7)
+
+        assert kinds(scene.advance(0.0)) == ["focus"]
+        assert scene.advance(0.5) == []
+        assert kinds(scene.advance(0.5)) == ["dialogue"]
+        assert kinds(scene.advance(2.0)) == ["move", "focus"]
+        assert not scene.finished
+        scene.advance(0.5)
+        assert scene.finished
+
+    def test_skip_keeps_remaining_moves(self):
+        """Test that skipping ends the scene but still returns its movement."""
+        scene = Cutscene("intro", SCENE, subject=7)
+        scene.advance(0.0)
+
+        assert kinds(scene.skip()) == ["move"]
+        assert scene.finished
+        assert scene.skippable
+        assert not Cutscene("locked", dict(SCENE, skippable=False)).skippable
+
+    def test_targets(self):
+        """Test that step targets name the subject or the player."""
+        assert resolve_target({"target": "subject"}, 7, 1) == 7
+        assert resolve_target({"target": "player"}, 7, 1) == 1
+        assert resolve_target({"x": 3, "y": 4}, 7, 1) is None
+
+    def test_event_log(self):
+        """Test that events are numbered and clients can ask for those they missed."""
+        log = CutsceneLog(size=3)
+        for i in range(5):
+            log.add("dialogue", text=str(i))
+
+        assert [e["seq"] for e in log.since(0)] == [3, 4, 5]
+        assert [e["text"] for e in log.since(4)] == ["4"]
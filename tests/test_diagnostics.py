@@ -0,0 +1,64 @@
+"""
+Tests for admin runtime diagnostics.
+"""
+
+import gc
+import os
+
+from systems.diagnostics import Diagnostics
+
+
+class TestDiagnostics:
+    """Test runtime stats, GC pause timing, tick lag and the profiler."""
+
+    def test_runtime_stats_message(self):
+        """Test that runtime_stats reports threads, memory, GC and the tick."""
+        stats = Diagnostics().runtime_stats(tick=42)
+
+        assert stats["type"] == "runtime_stats"
+        assert stats["threads"] >= 1
+        assert stats["memory"]["allocated_blocks"] > 0
+        assert len(stats["gc"]["collections"]) == 3
+        assert stats["tick"] == 42
+        assert not stats["profiling"]
+
+    def test_gc_pauses_are_timed(self):
+        """Test that collections are timed once installed, and not after uninstalling."""
+        diagnostics = Diagnostics()
+        diagnostics.install()
+        try:
+            gc.collect()
+        finally:
+            diagnostics.uninstall()
+        pauses = len(diagnostics.gc_pauses)
+        gc.collect()
+
+        assert pauses >= 1
+        assert len(diagnostics.gc_pauses) == pauses
+        assert diagnostics.runtime_stats()["gc"]["pause_ms"]["samples"] == pauses
+
+    def test_tick_lag_counts_only_overruns(self):
+        """Test that frames within budget add no lag."""
+        diagnostics = Diagnostics()
+        diagnostics.record_frame(0.01, 0.033)
+        diagnostics.record_frame(0.053, 0.033)
+
+        lag = diagnostics.runtime_stats()["tick_lag_ms"]
+
+        assert lag["max"] == 20.0
+        assert lag["last"] == 20.0
+        assert lag["mean"] == 10.0
+
+    def test_profile_round_trip(self, tmp_path):
+        """Test that a profile saves a stats file and lists what ran."""
+        diagnostics = Diagnostics(profile_dir=str(tmp_path))
+
+        assert diagnostics.stop_profile()["type"] == "error"
+        assert diagnostics.start_profile()["type"] == "profile_started"
+        assert diagnostics.start_profile()["type"] == "error"
+        sorted(range(1000), key=lambda n: -n)
+        result = diagnostics.stop_profile()
+
+        assert result["type"] == "profile_result"
+        assert os.path.exists(result["path"])
+        assert any("sorted" in f["function"] for f in result["functions"])
@@ -0,0 +1,82 @@
+"""
+Tests for the Discord chat bridge.
+"""
+
+from systems.discord_bridge import MAX_CONTENT, DiscordBridge, RateLimited
+
+
+class FakeDiscord:
+    """Answers the bridge's API calls from a list of channel messages."""
+
+    def __init__(self, messages=()):
+        self.messages = list(messages)
+        self.posted = []
+        self.rate_limit_posts = 0
+
+    def __call__(self, method, path, body):
+        if method == "POST":
+            if self.rate_limit_posts:
+                self.rate_limit_posts -= 1
+                raise RateLimited(1.0)
+            self.posted.append(body)
+            return {"id": "999"}
+        after = int(path.split("after=")[1]) if "after=" in path else 0
+        newer = [m for m in self.messages if int(m["id"]) > after]
+        newer.sort(key=lambda m: -int(m["id"]))  # Discord lists newest first
+        return newer[:1] if "limit=1" in path and "after=" not in path else newer
+
+
+def message(mid, content, name="mira", bot=False):
+    author = {"username": name, "bot": bot}
+    return {"id": str(mid), "content": content, "author": author}
+
+
+class TestDiscordBridge:
+    """Test relaying chat between the game and a Discord channel."""
+
+    def test_new_messages_reach_the_game_in_order(self):
+        """Test that history is skipped and new messages arrive oldest first."""
+        discord = FakeDiscord([message(1, "old news")])
+        bridge = DiscordBridge("42", discord)
+        bridge.last_id = discord("GET", "/channels/42/messages?limit=1", None)[0]["id"]
+
+        discord.messages += [
+            message(3, "second"),
+            message(2, "hello  there\nadventurer"),
+            message(4, "**Wren**: relayed", name="bridge", bot=True),
+            message(5, "   "),
+        ]
+        bridge.step()
+
+        assert bridge.poll() == ["[mira] hello there adventurer", "[mira] second"]
+        assert bridge.last_id == "5"
+        bridge.step()
+        assert bridge.poll() == []
+
+    def test_game_chat_is_posted_without_pings(self):
+        """Test that said lines carry the sender and cannot mention anyone."""
+        discord = FakeDiscord()
+        bridge = DiscordBridge("42", discord)
+
+        bridge.say("Wren", "@everyone come help")
+        bridge.say("Wren", "x" * 3000)
+        bridge.step()
+
+        assert discord.posted[0]["content"] == "**Wren**: @everyone come help"
+        assert discord.posted[0]["allowed_mentions"] == {"parse": []}
+        assert len(discord.posted[1]["content"]) == MAX_CONTENT
+
+    def test_rate_limited_lines_are_kept(self):
+        """Test that a line refused by the rate limit is sent on the next try."""
+        discord = FakeDiscord()
+        discord.rate_limit_posts = 1
+        bridge = DiscordBridge("42", discord)
+        bridge.say("Wren", "hi")
+
+        try:
+            bridge.step()
+        except RateLimited:
+            pass
+        bridge.step()
+
+        assert [body["content"] for body in discord.posted] == ["**Wren**: hi"]
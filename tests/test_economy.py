@@ -0,0 +1,55 @@
+"""
+Tests for economy tracking and gold sinks.
+"""
+
+from systems.economy import EconomyTracker
+
+
+class TestEconomyTracker:
+    """Test gold faucet/sink bookkeeping."""
+
+    def test_money_supply(self):
+        """Test that money supply is created minus destroyed."""
+        economy = EconomyTracker()
+        economy.record_created("vendor_sale", 100)
+        economy.record_destroyed("vendor_purchase", 30)
+
+        assert economy.money_supply == 70
+        assert economy.created["vendor_sale"] == 100
+        assert economy.destroyed["vendor_purchase"] == 30
+
+    def test_ignores_non_positive_amounts(self):
+        """Test that zero or negative flows are not recorded."""
+        economy = EconomyTracker()
+        economy.record_created("drop", 0)
+        economy.record_destroyed("tax", -5)
+
+        assert economy.money_supply == 0
+        assert "drop" not in economy.created
+
+    def test_vendor_fee_sink(self):
+        """Test that the configured vendor fee is withheld and destroyed."""
+        economy = EconomyTracker({"vendor_fee": 0.2})
+        net = economy.apply_sink("vendor_fee", 50)
+
+        assert net == 40
+        assert economy.destroyed["vendor_fee"] == 10
+
+    def test_unknown_sink_is_free(self):
+        """Test that a sink without a rate does not take a fee."""
+        economy = EconomyTracker()
+        assert economy.apply_sink("auction_tax", 100) == 100
+
+    def test_trend_sampling(self):
+        """Test that periods are closed and inflation is computed."""
+        economy = EconomyTracker({"trend_period": 1.0})
+        economy.record_created("starting_gold", 100)
+        economy.update(1.0)
+
+        economy.record_created("vendor_sale", 50)
+        economy.update(1.0)
+
+        report = economy.inflation_report()
+        assert len(report["trend"]) == 2
+        assert report["trend"][1]["supply"] == 150
+        assert abs(report["trend"][1]["inflation"] - 0.5) < 1e-9
@@ -0,0 +1,106 @@
+"""
+Tests for saving and restoring entities through the ECS.
+"""
+
+import json
+
+import pytest
+
+from core.ecs import EntityManager, decode_value, encode_value
+from entities.components import (
+    Equipment,
+    Health,
+    Inventory,
+    Name,
+    Position,
+    Render,
+    Schedule,
+    Stealth,
+)
+
+
+def round_trip(entity_manager, eids=None, restored=None):
+    """Serialize entities (all by default), pass them through JSON as a save
+    file would, and restore them into another EntityManager."""
+    restored = EntityManager() if restored is None else restored
+    for eid in sorted(entity_manager.entities) if eids is None else eids:
+        data = json.loads(json.dumps(entity_manager.serialize_entity(eid)))
+        restored.restore_entity(data)
+    return restored
+
+
+class TestECSSerialization:
+    """Test entity serialization and component value encoding."""
+
+    def test_entities_round_trip(self, entity_wrapper, entity_manager):
+        """Test that a player and their items come back with the same IDs and data."""
+        player = entity_wrapper.factory.create_player(5, 6)
+        sword = entity_wrapper.factory.create_item(0, 0, "sword")
+        inventory = entity_manager.get_component(player, Inventory)
+        inventory.items.append(sword)
+        inventory.gold = 42
+        entity_manager.add_component(player, Equipment(weapon=sword))
+
+        restored = round_trip(entity_manager, [player, sword])
+
+        assert sorted(restored.entities) == sorted([player, sword])
+        assert restored.get_component(player, Position) == Position(5, 6)
+        assert restored.get_component(player, Inventory).items == [sword]
+        assert restored.get_component(player, Inventory).gold == 42
+        assert restored.get_component(player, Equipment).weapon == sword
+        assert restored.get_component(player, Render).fg_color == entity_manager.get_component(
+            player, Render
+        ).fg_color
+        assert restored.next_id > max(player, sword)
+
+    def test_restored_entities_notify_systems(self, entity_manager):
+        """Test that restoring goes through add_component, so indexes hear about it."""
+        eid = entity_manager.create_entity()
+        entity_manager.add_component(eid, Position(1, 2))
+
+        restored = EntityManager()
+        seen = []
+        restored.callbacks.append(lambda change, e, comp_type, c: seen.append((change, comp_type)))
+        round_trip(entity_manager, restored=restored)
+
+        assert seen == [("add", Position)]
+
+    def test_unknown_components_and_fields_are_skipped(self):
+        """Test that saves from other versions still load."""
+        restored = EntityManager()
+        restored.restore_entity(
+            {
+                "eid": 3,
+                "components": {
+                    "Name": {"value": "Wren", "nickname": "W"},
+                    "Retired": {"since": 1},
+                },
+            }
+        )
+
+        assert restored.get_component(3, Name) == Name("Wren")
+        assert restored.next_id == 4
+
+    def test_existing_entity_is_not_overwritten(self, entity_manager):
+        """Test that restoring over a live entity is refused."""
+        eid = entity_manager.create_entity()
+        entity_manager.add_component(eid, Health(10, 10))
+
+        with pytest.raises(ValueError):
+            entity_manager.restore_entity({"eid": eid, "components": {}})
+
+    def test_awkward_values_survive_json(self, entity_manager):
+        """Test that tuples and dicts keyed by ints or tuples come back exactly."""
+        for value in ({7: 2.5}, {("home", "work"): [(1, 2)]}, (255, 0, 0), {"a": (1,)}):
+            assert decode_value(json.loads(json.dumps(encode_value(value)))) == value
+
+        eid = entity_manager.create_entity()
+        schedule = Schedule("shopkeeper", anchors={"home": (3, 4)}, path=[(1, 1)])
+        schedule.paths[("home", "work")] = [(1, 2), (3, 4)]
+        entity_manager.add_component(eid, schedule)
+        entity_manager.add_component(eid, Stealth(active=True, spotted_by={9: 1.5}))
+
+        restored = round_trip(entity_manager)
+
+        assert restored.get_component(eid, Schedule) == schedule
+        assert restored.get_component(eid, Stealth).spotted_by == {9: 1.5}
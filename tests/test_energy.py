@@ -0,0 +1,51 @@
+"""
+Tests for energy-based action scheduling.
+"""
+
+from systems.energy import ACTION_COST, EnergyScheduler
+
+
+class TestEnergyScheduler:
+    """Test that speed decides how often a creature can act."""
+
+    def test_new_creatures_start_ready(self):
+        """Test that a creature that has not acted yet can act at once."""
+        energy = EnergyScheduler()
+
+        assert energy.ready(7, 1.0, 0.0)
+        assert energy.energy(7, 1.0, 0.0) == ACTION_COST
+
+    def test_faster_creatures_act_more_often(self):
+        """Test that at twice the speed a creature is ready again in half the time."""
+        energy = EnergyScheduler({"energy_per_second": 100.0})
+        energy.spend(1, 1.0, 0.0)
+        energy.spend(2, 2.0, 0.0)
+
+        assert not energy.ready(1, 1.0, 0.5) and energy.ready(2, 2.0, 0.5)
+        assert energy.wait_time(1, 1.0, 0.5) == 0.5
+        assert energy.ready(1, 1.0, 1.0)
+
+    def test_energy_is_capped(self):
+        """Test that idling does not bank more than max_energy."""
+        energy = EnergyScheduler({"energy_per_second": 100.0, "max_energy": 150.0})
+        energy.spend(1, 1.0, 0.0)
+
+        assert energy.energy(1, 1.0, 60.0) == 150.0
+
+    def test_action_costs(self):
+        """Test that actions cost what config says, and menus cost nothing."""
+        energy = EnergyScheduler({"action_costs": {"move": 40}})
+
+        assert energy.cost("move") == 40.0
+        assert energy.cost("pickup") == ACTION_COST / 2
+        assert energy.cost("inventory") == 0.0
+
+    def test_prune_forgets_the_dead(self):
+        """Test that entities no longer alive are dropped."""
+        energy = EnergyScheduler()
+        energy.spend(1, 1.0, 0.0)
+        energy.spend(2, 1.0, 0.0)
+
+        energy.prune({2})
+
+        assert energy.ready(1, 1.0, 0.0) and not energy.ready(2, 1.0, 0.0)
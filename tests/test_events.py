@@ -0,0 +1,93 @@
+"""
+Tests for the event bus.
+"""
+
+import types
+
+from core import recovery
+from core.engine import GameEngine
+from core.events import (
+    ChatSent,
+    EntityDied,
+    Event,
+    EventBus,
+    ItemPickedUp,
+    PlayerMoved,
+)
+
+
+class TestEventBus:
+    """Test publishing and subscribing to typed events."""
+
+    def test_subscribers_get_their_events(self):
+        """Test that handlers hear their own type, and Event hears everything."""
+        bus = EventBus()
+        moves, everything = [], []
+        bus.subscribe(PlayerMoved, moves.append)
+        bus.subscribe(Event, everything.append)
+
+        moved = PlayerMoved(1, 5, 6, 5, 5)
+        bus.publish(moved)
+        bus.publish(ChatSent("Wren", "hi"))
+
+        assert moves == [moved]
+        assert everything == [moved, ChatSent("Wren", "hi")]
+
+    def test_unsubscribe_during_publish(self):
+        """Test that a one-shot handler can remove itself while being called."""
+        bus = EventBus()
+        seen = []
+
+        def once(event):
+            seen.append(event)
+            bus.unsubscribe(ChatSent, once)
+
+        bus.subscribe(ChatSent, once)
+        bus.publish(ChatSent("Wren", "one"))
+        bus.publish(ChatSent("Wren", "two"))
+
+        assert [event.text for event in seen] == ["one"]
+
+    def test_failing_handler_is_isolated(self, monkeypatch, tmp_path):
+        """Test that a handler that raises does not stop the others."""
+        monkeypatch.setattr(recovery, "DEBUG_LOG", str(tmp_path / "debug.log"))
+        bus = EventBus()
+        seen = []
+
+        def broken(event):
+            raise RuntimeError("subscriber bug")
+
+        bus.subscribe(EntityDied, broken)
+        bus.subscribe(EntityDied, seen.append)
+        bus.publish(EntityDied(4, "goblin", killer=1))
+
+        assert seen == [EntityDied(4, "goblin", killer=1)]
+        assert "subscriber bug" in (tmp_path / "debug.log").read_text()
+
+
+class TestEngineSubscribers:
+    """Test the engine's own subscribers (tutorial progress and chat relay)."""
+
+    def test_tutorial_and_relay(self):
+        """Test that the player's events reach the tutorial and chat reaches Discord."""
+        recorded, relayed = [], []
+        engine = types.SimpleNamespace(
+            events=EventBus(),
+            player_id=1,
+            discord=types.SimpleNamespace(say=lambda *line: relayed.append(line)),
+        )
+        engine.tutorial_event = lambda *event: recorded.append(event)
+        for name in ("on_player_moved", "on_item_picked_up", "on_entity_died"):
+            setattr(engine, name, getattr(GameEngine, name).__get__(engine))
+        engine.relay_chat = GameEngine.relay_chat.__get__(engine)
+        GameEngine.subscribe_events(engine)
+
+        engine.events.publish(PlayerMoved(1, 2, 2, 1, 2))
+        engine.events.publish(PlayerMoved(9, 2, 2, 1, 2))  # Someone else
+        engine.events.publish(ItemPickedUp(1, 30, "Iron Sword", "sword"))
+        engine.events.publish(EntityDied(8, "goblin", killer=1))
+        engine.events.publish(EntityDied(1, "player", cause="drowned"))
+        engine.events.publish(ChatSent("Wren", "hello"))
+
+        assert recorded == [("move",), ("pickup", "Iron Sword"), ("kill", "goblin")]
+        assert relayed == [("Wren", "hello")]
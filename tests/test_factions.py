@@ -0,0 +1,186 @@
+"""
+Tests for factions, reputation tiers and player profiles.
+"""
+
+from entities.ai_system import AISystem
+from entities.components import Monster, Position, Reputation, Shop
+from systems.factions import FactionSystem
+from systems.profile import load_profile, save_profile
+
+FACTIONS = {
+    "tiers": [
+        ["hostile", -1000],
+        ["unfriendly", -100],
+        ["neutral", 0],
+        ["friendly", 100],
+    ],
+    "factions": {
+        "crown": {
+            "name": "The Crown",
+            "members": ["guard"],
+            "enemies": ["greenskins"],
+            "kill_penalty": 150,
+            "enemy_reward": 25,
+            "hostile_at": "hostile",
+        },
+        "greenskins": {
+            "name": "Greenskin Clans",
+            "members": ["goblin"],
+            "enemies": ["crown"],
+            "kill_penalty": 10,
+            "enemy_reward": 5,
+        },
+    },
+}
+
+
+class OpenMap:
+    """Unbounded map where every tile is walkable."""
+
+    def is_walkable(self, x, y):
+        return True
+
+    def move_cost(self, x, y):
+        return 1.0
+
+
+class TestFactions:
+    """Test reputation tiers and how kills change standing."""
+
+    def test_tiers_from_thresholds(self):
+        """Test that each tier starts at its threshold; the lowest has no floor."""
+        factions = FactionSystem(FACTIONS)
+
+        assert factions.tier(0) == "neutral"
+        assert factions.tier(99) == "neutral"
+        assert factions.tier(100) == "friendly"
+        assert factions.tier(-100) == "unfriendly"
+        assert factions.tier(-101) == "hostile"
+        assert factions.tier(-5000) == "hostile"
+
+    def test_kill_penalises_faction_and_rewards_enemies(self):
+        """Test that killing a member costs standing and pleases its enemies."""
+        factions = FactionSystem(FACTIONS)
+        standing = {}
+
+        changes = factions.on_kill(standing, "goblin")
+
+        assert standing == {"greenskins": -10, "crown": 5}
+        assert [(f, amount) for f, amount, _ in changes] == [
+            ("greenskins", -10),
+            ("crown", 5),
+        ]
+
+    def test_adjust_reports_tier_change(self):
+        """Test that crossing a threshold reports the old and new tier."""
+        factions = FactionSystem(FACTIONS)
+        standing = {"crown": 90}
+
+        assert factions.adjust(standing, "crown", 5) is None
+        assert factions.adjust(standing, "crown", 5) == ("neutral", "friendly")
+
+    def test_unaffiliated_kill_changes_nothing(self):
+        """Test that monsters outside every faction leave standing alone."""
+        factions = FactionSystem(FACTIONS)
+        standing = {}
+
+        assert factions.on_kill(standing, "wolf") == []
+        assert standing == {}
+
+    def test_hostility_threshold(self):
+        """Test that guards only turn on players at the hostile tier."""
+        factions = FactionSystem(FACTIONS)
+
+        assert not factions.is_hostile({"crown": -100}, "crown")
+        assert factions.is_hostile({"crown": -101}, "crown")
+        assert not factions.is_hostile({"greenskins": -5000}, "greenskins")
+
+    def test_tier_gate(self):
+        """Test that vendor and quest gates compare tiers."""
+        factions = FactionSystem(FACTIONS)
+
+        assert factions.meets({"crown": 150}, "crown", "friendly")
+        assert not factions.meets({"crown": 50}, "crown", "friendly")
+        assert factions.meets({}, "", "friendly")
+
+
+class TestGuards:
+    """Test that guards react to the player's reputation."""
+
+    def make_world(self, entity_manager, standing):
+        factions = FactionSystem(FACTIONS)
+        ai = AISystem(entity_manager, factions=factions)
+        player = entity_manager.create_entity()
+        entity_manager.add_component(player, Position(5, 5))
+        entity_manager.add_component(player, Reputation(standing=standing))
+        guard = entity_manager.create_entity()
+        entity_manager.add_component(guard, Position(6, 5))
+        entity_manager.add_component(
+            guard,
+            Monster(ai_type="patrol", monster_type="guard", name="Guard", behavior="guard"),
+        )
+        return ai, player, guard
+
+    def tick_guard(self, entity_manager, ai, player, guard):
+        attacks = []
+        ai.think(
+            guard,
+            entity_manager.get_component(guard, Monster),
+            entity_manager.get_component(guard, Position),
+            entity_manager.get_component(player, Position),
+            OpenMap(),
+            combat_callback=attacks.append,
+            player_id=player,
+        )
+        return attacks
+
+    def test_guard_attacks_hostile_player(self, entity_manager):
+        """Test that a guard attacks an adjacent player the crown hates."""
+        ai, player, guard = self.make_world(entity_manager, {"crown": -600})
+
+        assert self.tick_guard(entity_manager, ai, player, guard) == [guard]
+
+    def test_guard_ignores_neutral_player(self, entity_manager):
+        """Test that a guard leaves players in good standing alone."""
+        ai, player, guard = self.make_world(entity_manager, {"crown": 0})
+
+        assert self.tick_guard(entity_manager, ai, player, guard) == []
+
+
+class TestShopStock:
+    """Test reputation-gated vendor stock."""
+
+    def test_restricted_stock_needs_tier(self):
+        """Test that restricted items appear only at the required tier."""
+        factions = FactionSystem(FACTIONS)
+        shop = Shop(
+            shop_name="Armory",
+            items=[("sword", 100)],
+            faction="crown",
+            restricted=[("iron_helmet", 80, "friendly")],
+        )
+
+        assert factions.stock_for(shop, {"crown": 0}) == [("sword", 100)]
+        assert factions.stock_for(shop, {"crown": 100}) == [
+            ("sword", 100),
+            ("iron_helmet", 80),
+        ]
+
+
+class TestProfile:
+    """Test per-player profile persistence."""
+
+    def test_round_trip(self, tmp_path):
+        """Test that a saved profile loads back unchanged."""
+        path = str(tmp_path / "profile.json")
+        save_profile(path, {"reputation": {"crown": 120}})
+
+        assert load_profile(path) == {"reputation": {"crown": 120}}
+
+    def test_missing_or_corrupt_profile_is_empty(self, tmp_path):
+        """Test that unreadable profiles start fresh instead of failing."""
+        path = tmp_path / "profile.json"
+        assert load_profile(str(path)) == {}
+
+        path.write_text("{not json")
+        assert load_profile(str(path)) == {}
@@ -0,0 +1,99 @@
+"""
+Tests for fishing: the bite, the reaction window and what can be caught.
+"""
+
+import random
+
+import pytest
+
+from data.loader import DATA_LOADER
+from systems.fishing import BITE, BITING, ESCAPED, Fishing, FishingError, draw_catch
+
+TABLE = {"perch": 50, "glimmerscale_koi": 50}
+SKILLS = {"perch": 1, "glimmerscale_koi": 8}
+
+
+def fishing(**settings):
+    settings = {"bite_min": 3.0, "bite_max": 3.0, "reaction_window": 2.0, **settings}
+    return Fishing(settings, random.Random(1))
+
+
+class TestLine:
+    """Test the bite timing and reeling in."""
+
+    def test_bite_then_escape(self):
+        """Test that the fish bites on time and gets away once the window closes."""
+        line = fishing()
+        line.cast(TABLE, now=0.0)
+
+        assert line.update(2.9) is None
+        assert line.update(3.0) == BITE
+        assert line.status(3.5) == {"state": BITING, "window": 1.5}
+        assert line.update(5.0) is None
+        assert line.update(5.1) == ESCAPED
+        assert not line.active
+
+    def test_reel_in_the_window_catches(self):
+        """Test that reeling during the bite lands something the angler can land."""
+        line = fishing()
+        line.cast(TABLE, now=0.0)
+        line.update(3.0)
+
+        assert line.reel(4.0, 1, SKILLS.get) == "perch"
+        assert not line.active
+
+    def test_reel_early_or_late_is_empty(self):
+        """Test that reeling before the bite or after the window catches nothing."""
+        line = fishing()
+        line.cast(TABLE, now=0.0)
+        assert line.reel(1.0, 10, SKILLS.get) is None
+
+        line.cast(TABLE, now=0.0)
+        assert line.reel(5.5, 10, SKILLS.get) is None
+
+    def test_latency_grace_is_capped(self):
+        """Test that a slow connection widens the window, but only by half a second."""
+        line = fishing()
+        line.cast(TABLE, now=0.0, grace=0.3)
+        assert line.reel(5.3, 1, SKILLS.get) == "perch"
+
+        line.cast(TABLE, now=0.0, grace=5.0)
+        assert line.reel(5.6, 1, SKILLS.get) is None
+
+    def test_reel_and_stop_need_a_line(self):
+        """Test that there is nothing to reel in or stop before a cast."""
+        line = fishing()
+
+        with pytest.raises(FishingError):
+            line.reel(0.0, 1, SKILLS.get)
+        assert not line.stop()
+        line.cast(TABLE, now=0.0)
+        assert line.stop()
+
+    def test_empty_water(self):
+        """Test that a cast into water with no catch table is refused."""
+        with pytest.raises(FishingError):
+            fishing().cast({}, now=0.0)
+
+
+class TestCatches:
+    """Test what the water gives up."""
+
+    def test_skill_keeps_rare_fish_out_of_reach(self):
+        """Test that a catch needing more skill than the angler has never comes up."""
+        rng = random.Random(3)
+        caught = {draw_catch(TABLE, 1, SKILLS.get, rng) for _ in range(50)}
+        assert caught == {"perch"}
+
+        caught = {draw_catch(TABLE, 8, SKILLS.get, rng) for _ in range(50)}
+        assert caught == {"perch", "glimmerscale_koi"}
+        assert draw_catch({"glimmerscale_koi": 1}, 1, SKILLS.get, rng) is None
+
+    def test_catch_tables_use_known_items(self):
+        """Test that every catch table has a default and names real items."""
+        tables = DATA_LOADER.load_json("catches")
+        items = DATA_LOADER.load_json("items")
+
+        assert "default" in tables and "cave" in tables
+        for table in tables.values():
+            assert table and all(item in items for item in table)
@@ -0,0 +1,150 @@
+"""
+Fuzz tests for the inbound message pipeline.
+Random and adversarial input is thrown at request decoding, dispatch, text
+command parsing and telnet input cleanup; none of it may raise or hang.
+Runs a fixed seed by default; set FUZZ_ITERATIONS (and FUZZ_SEED) to fuzz
+for longer.
+"""
+
+import json
+import os
+import random
+
+import pytest
+
+from core.commands import MAX_MESSAGE_BYTES, CommandRegistry, decode_request
+from input import text_commands
+from systems.telnet_gateway import strip_telnet_commands
+
+ITERATIONS = int(os.environ.get("FUZZ_ITERATIONS", "300"))
+SEED = int(os.environ.get("FUZZ_SEED", "3635"))
+
+REQUEST_TYPES = ["help", "map_request", "text_command", "poi_query", "nope", ""]
+ARG_NAMES = ["scale", "text", "kind", "type", ""]
+
+
+def random_text(rng, max_len=20):
+    alphabet = "abcdefgh .'2²١\x00\n\t‮\U0001f480goblin"
+    return "".join(rng.choice(alphabet) for _ in range(rng.randint(0, max_len)))
+
+
+def random_value(rng, depth=0):
+    """Any JSON value, biased towards awkward ones."""
+    choice = rng.randint(0, 9 if depth < 3 else 5)
+    if choice == 0:
+        return None
+    if choice == 1:
+        return rng.choice([True, False])
+    if choice == 2:
+        return rng.choice([0, -1, 1, 2**63, -(2**63), 10**30, rng.randint(-1000, 1000)])
+    if choice == 3:
+        return rng.choice([0.5, -0.0, 1e308, -1e308, rng.random()])
+    if choice in (4, 5):
+        return random_text(rng)
+    if choice in (6, 7):
+        return [random_value(rng, depth + 1) for _ in range(rng.randint(0, 3))]
+    return {random_text(rng, 6): random_value(rng, depth + 1) for _ in range(rng.randint(0, 3))}
+
+
+def random_message(rng):
+    message = {"type": rng.choice(REQUEST_TYPES + [random_value(rng)])}
+    for _ in range(rng.randint(0, 3)):
+        message[rng.choice(ARG_NAMES)] = random_value(rng)
+    return message
+
+
+def make_registry():
+    """A registry with handlers shaped like the game's own."""
+    registry = CommandRegistry()
+    registry.request("help", registry.help, "List requests")
+    registry.request(
+        "map_request",
+        lambda scale: {"type": "map_response", "scale": max(1, int(scale))},
+        "Map",
+        ("scale",),
+    )
+    registry.request(
+        "text_command",
+        lambda text: {"type": "text_response", "lines": [str(text)]},
+        "Text",
+        ("text",),
+    )
+    return registry
+
+
+@pytest.fixture
+def rng():
+    return random.Random(SEED)
+
+
+class TestFuzzRequests:
+    """Fuzz request decoding and dispatch."""
+
+    def test_random_messages_always_get_a_reply(self, rng):
+        """Test that every decodable message is answered with a typed message."""
+        registry = make_registry()
+        for _ in range(ITERATIONS):
+            raw = json.dumps(random_message(rng))
+            reply = registry.handle_raw(raw)
+            assert isinstance(reply, dict) and isinstance(reply.get("type"), str), raw
+
+    def test_garbage_bytes_are_refused(self, rng):
+        """Test that arbitrary bytes come back as error messages."""
+        registry = make_registry()
+        for _ in range(ITERATIONS):
+            raw = bytes(rng.randrange(256) for _ in range(rng.randint(0, 40)))
+            reply = registry.handle_raw(raw)
+            assert isinstance(reply, dict), raw
+
+    def test_truncated_messages_are_refused(self, rng):
+        """Test that every prefix of a valid message is handled."""
+        registry = make_registry()
+        raw = json.dumps({"type": "map_request", "scale": 4, "extra": [1, {"a": "b"}]})
+        for end in range(len(raw)):
+            assert registry.handle_raw(raw[:end])["type"] == "error"
+
+    def test_non_object_messages(self):
+        """Test that messages that are not objects, or lack a string type, are errors."""
+        registry = make_registry()
+        for message in (None, [], "help", 7, {"type": ["help"]}, {"type": {"a": 1}}):
+            assert registry.handle_request(message)["type"] == "error"
+            assert registry.handle_raw(json.dumps(message))["type"] == "error"
+
+    def test_hostile_sizes(self):
+        """Test that deep nesting, huge numbers and oversized messages are refused quickly."""
+        registry = make_registry()
+
+        with pytest.raises(ValueError):
+            decode_request("[" * 100000 + "]" * 100000)
+        with pytest.raises(ValueError):
+            decode_request('{"type": "help", "pad": "' + "x" * MAX_MESSAGE_BYTES + '"}')
+        assert registry.handle_raw('{"type": "map_request", "scale": 1e999}')["type"] == "error"
+        huge = '{"type": "map_request", "scale": ' + "9" * 5000 + "}"
+        assert registry.handle_raw(huge)["type"] == "error"
+
+
+class TestFuzzTextInput:
+    """Fuzz text command parsing and telnet input cleanup."""
+
+    def test_parse_and_resolve_never_raise(self, rng):
+        """Test that any line parses and any target phrase resolves or misses."""
+        candidates = [(1, "Goblin", 2), (2, "Elite Goblin", 5), (3, "Tutor", 1)]
+        for _ in range(ITERATIONS):
+            line = random_text(rng, 40)
+            command = text_commands.parse(line)
+            if command is not None:
+                assert text_commands.resolve_target(command.args, candidates) in (None, 1, 2, 3)
+
+    def test_unusual_digits_in_target_index(self):
+        """Test that digit-like characters int() rejects do not break targeting."""
+        candidates = [(1, "Goblin", 2)]
+
+        assert text_commands.resolve_target(["².goblin"], candidates) is None
+        assert text_commands.resolve_target(["١.goblin"], candidates) == 1
+
+    def test_telnet_cleanup_never_grows_input(self, rng):
+        """Test that stripping negotiation from random bytes never raises or adds data."""
+        for _ in range(ITERATIONS):
+            length = rng.randint(0, 30)
+            data = bytes(rng.choice([255, 250, 240, 251, 1, 65, 10]) for _ in range(length))
+            assert len(strip_telnet_commands(data)) <= len(data)
@@ -0,0 +1,87 @@
+"""
+Tests for games of chance against townsfolk.
+"""
+
+import itertools
+
+import pytest
+
+from systems.gambling import Gambling, GamblingError, deal
+
+
+class TestDeal:
+    """Test that rounds follow from their seed."""
+
+    def test_same_seed_same_round(self):
+        """Test that a round from the audit log can be dealt again exactly."""
+        for game in ("dice", "cards"):
+            assert deal(game, 1234) == deal(game, 1234)
+
+    def test_higher_wins_and_ties_go_to_the_house(self):
+        """Test who wins each game, across many seeds."""
+        for seed in range(200):
+            dice = deal("dice", seed)
+            assert all(1 <= die <= 6 for die in dice.player + dice.house)
+            assert dice.won == (sum(dice.player) > sum(dice.house))
+
+            cards = deal("cards", seed)
+            assert cards.player != cards.house
+            rank = lambda card: "23456789TJQKA".index(card[0])
+            assert cards.won == (rank(cards.player[0]) > rank(cards.house[0]))
+
+    def test_unknown_game(self):
+        """Test that only the house's games can be dealt."""
+        with pytest.raises(GamblingError):
+            deal("roulette", 1)
+
+
+class TestGambling:
+    """Test stake limits and the loss limit."""
+
+    def test_stakes_are_limited(self):
+        """Test that bets must fit the house limits and the player's purse."""
+        gambling = Gambling({"min_stake": 5, "max_stake": 50})
+
+        assert gambling.refusal("Wren", "roulette", 10, 100) is not None
+        assert gambling.refusal("Wren", "dice", 2, 100) is not None
+        assert gambling.refusal("Wren", "dice", 60, 100) is not None
+        assert gambling.refusal("Wren", "dice", 40, 30) == "Not enough gold."
+        assert gambling.refusal("Wren", "cards", 50, 100) is None
+
+    def test_losses_are_limited(self):
+        """Test that a player is turned away once their net losses reach the limit."""
+        gambling = Gambling({"loss_limit": 100, "window": 60.0})
+        gambling.record("Wren", 80, won=False)
+
+        refusal = gambling.refusal("Wren", "dice", 30, 1000)
+        assert refusal == "You can only stake 20 more gold for now."
+        gambling.record("Wren", 20, won=False)
+        assert "lost enough" in gambling.refusal("Wren", "dice", 1, 1000)
+        assert gambling.refusal("Ash", "dice", 30, 1000) is None
+
+    def test_winnings_offset_losses(self):
+        """Test that the loss limit counts losses net of winnings."""
+        gambling = Gambling({"loss_limit": 100})
+        gambling.record("Wren", 80, won=False)
+        gambling.record("Wren", 50, won=True)
+
+        assert gambling.lost("Wren") == 30
+        gambling.record("Wren", 100, won=True)
+        assert gambling.lost("Wren") == 0
+
+    def test_limit_lifts_after_the_window(self):
+        """Test that old rounds stop counting once the window has passed."""
+        gambling = Gambling({"loss_limit": 100, "window": 60.0})
+        gambling.record("Wren", 100, won=False)
+
+        gambling.update(59.0)
+        assert gambling.refusal("Wren", "dice", 10, 1000) is not None
+        gambling.update(1.0)
+        assert gambling.refusal("Wren", "dice", 10, 1000) is None
+
+    def test_each_round_gets_a_fresh_seed(self):
+        """Test that rounds are dealt from the seed source, in turn."""
+        gambling = Gambling(seeds=itertools.count(7).__next__)
+
+        assert gambling.play("dice") == deal("dice", 7)
+        assert gambling.play("cards") == deal("cards", 8)
@@ -0,0 +1,54 @@
+"""
+Tests for checking and normalizing entity icons.
+"""
+
+import pytest
+
+from systems.glyphs import (
+    DEFAULT_FALLBACK,
+    FALLBACK_GLYPHS,
+    GlyphError,
+    check_icon,
+    normalize_icon,
+    terminal_glyph,
+)
+
+
+class TestGlyphs:
+    """Test that only single drawable glyphs reach clients."""
+
+    def test_single_glyphs_pass(self):
+        """Test letters, symbols and emoji with a presentation selector."""
+        assert check_icon("g") == "g"
+        assert check_icon("🐺") == "🐺"
+        assert check_icon("⚔️") == "⚔️"
+        assert check_icon("é") == "é"  # Composed into one character
+
+    def test_unsafe_icons_are_refused(self):
+        """Test controls, clusters and empty icons."""
+        with pytest.raises(GlyphError, match="cannot be drawn"):
+            check_icon("\x1b")
+        with pytest.raises(GlyphError, match="single character"):
+            check_icon("👨\u200d👩\u200d👧")  # A ZWJ family
+        with pytest.raises(GlyphError, match="single character"):
+            check_icon("👍🏽")  # Skin tone modifier
+        with pytest.raises(GlyphError, match="single character"):
+            check_icon("ab")
+        with pytest.raises(GlyphError, match="non-empty"):
+            check_icon("")
+        with pytest.raises(GlyphError, match="cannot be drawn"):
+            check_icon(" ")
+
+    def test_fallbacks_by_kind(self):
+        """Test that refused icons fall back to their kind's glyph."""
+        assert normalize_icon("\u200b", "monsters") == FALLBACK_GLYPHS["monsters"]
+        assert normalize_icon(None, "items") == FALLBACK_GLYPHS["items"]
+        assert normalize_icon("", "statues") == DEFAULT_FALLBACK
+        assert normalize_icon("🦇", "monsters") == "🦇"
+
+    def test_terminal_glyph(self):
+        """Test the ASCII stand-in for telnet clients."""
+        assert terminal_glyph("D") == "D"
+        assert terminal_glyph("🐺", "Wolf") == "W"
+        assert terminal_glyph("🐺", "  9 lives") == "9"
+        assert terminal_glyph("🐺", "") == DEFAULT_FALLBACK
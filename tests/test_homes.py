@@ -0,0 +1,64 @@
+"""
+Tests for binding home points at inns and shrines and recalling to them.
+"""
+
+from systems.homes import HomePoint, Homes, bind_point_near
+
+INN = HomePoint("Test Town Inn", "inn", 10, 10)
+SHRINE = HomePoint("North Shrine", "shrine", 40, 5)
+
+
+class TestBindPoints:
+    """Test finding the inn or shrine a player is beside."""
+
+    def test_within_reach(self):
+        """Test that only a place within a tile of the player can be bound."""
+        points = [INN, SHRINE]
+
+        assert bind_point_near(points, 11, 9) == INN
+        assert bind_point_near(points, 40, 5) == SHRINE
+        assert bind_point_near(points, 12, 10) is None
+
+    def test_round_trip(self):
+        """Test that a home point survives saving as plain data."""
+        assert HomePoint.from_dict(SHRINE.to_dict()) == SHRINE
+
+
+class TestHomes:
+    """Test a player's homes and recall cooldown."""
+
+    def test_home_per_world(self):
+        """Test that each world keeps its own home."""
+        homes = Homes()
+
+        homes.bind("main", INN)
+        homes.bind("isles", SHRINE)
+        homes.bind("main", SHRINE)
+
+        assert homes.home("main") == SHRINE and homes.home("isles") == SHRINE
+        assert homes.home("frontier") is None
+
+    def test_recall_cooldown(self):
+        """Test that recall is ready at first and then waits out its cooldown."""
+        homes = Homes(cooldown=600.0)
+
+        assert homes.recall_remaining(now=1000.0) == 0
+        homes.use_recall(now=1000.0)
+
+        assert homes.recall_remaining(now=1100.0) == 500.0
+        assert homes.recall_remaining(now=1600.0) == 0
+
+    def test_profile_round_trip(self):
+        """Test that homes and the cooldown are kept in the player's profile."""
+        homes = Homes()
+        homes.bind("main", INN)
+        homes.use_recall(now=50.0)
+
+        restored = Homes()
+        restored.from_profile(homes.to_profile())
+
+        assert restored.home("main") == INN
+        assert restored.recall_ready_at == homes.recall_ready_at
+
+        restored.from_profile({})
+        assert restored.home("main") is None and restored.recall_remaining() == 0
@@ -0,0 +1,121 @@
+"""
+Tests for reloading content definitions and config at runtime.
+"""
+
+import json
+
+import pytest
+
+from config import GameConfig
+from data.loader import ContentError, DataLoader
+
+
+def write_json(path, data):
+    path.write_text(json.dumps(data), encoding="utf-8")
+
+
+def sword(attack_bonus):
+    return {"sword": {"name": "Sword", "attack_bonus": attack_bonus}}
+
+
+def make_loader(tmp_path):
+    """A loader that has read an items file and a monsters file."""
+    write_json(tmp_path / "items.json", sword(5))
+    write_json(tmp_path / "monsters.json", {"rat": {"name": "Rat", "health": 4}})
+    loader = DataLoader(str(tmp_path))
+    loader.load_json("items")
+    loader.load_json("monsters")
+    return loader
+
+
+class TestContentReload:
+    """Test that content is swapped only when every file checks out."""
+
+    def test_changes_are_picked_up(self, tmp_path):
+        """Test that reload returns the files and serves the new definitions."""
+        loader = make_loader(tmp_path)
+        write_json(tmp_path / "items.json", sword(7))
+
+        assert loader.reload() == ["items", "monsters"]
+        assert loader.get_item_data("sword")["attack_bonus"] == 7
+
+    def test_broken_file_keeps_old_content(self, tmp_path):
+        """Test that a syntax error in one file leaves every file as it was."""
+        loader = make_loader(tmp_path)
+        write_json(tmp_path / "monsters.json", {"rat": {"name": "Rat", "health": 9}})
+        (tmp_path / "items.json").write_text("{ not json", encoding="utf-8")
+
+        with pytest.raises(ContentError, match="items"):
+            loader.reload()
+
+        assert loader.get_monster_data("rat")["health"] == 4
+
+    def test_bad_values_are_rejected(self, tmp_path):
+        """Test that entries with missing names or non-numeric stats fail validation."""
+        loader = make_loader(tmp_path)
+        write_json(tmp_path / "monsters.json", {"rat": {"name": "Rat", "health": "x"}})
+        with pytest.raises(ContentError, match="rat.health"):
+            loader.reload()
+
+        write_json(tmp_path / "monsters.json", {"rat": {"health": 4}})
+        with pytest.raises(ContentError, match="no name"):
+            loader.reload()
+
+    def test_unknown_bind_is_rejected(self, tmp_path):
+        """Test that an item can only bind on pickup or on equip."""
+        loader = make_loader(tmp_path)
+        write_json(tmp_path / "items.json", {"ring": {"name": "Ring", "bind": "use"}})
+
+        with pytest.raises(ContentError, match="ring.bind"):
+            loader.reload()
+
+    def test_bad_icons_are_rejected(self, tmp_path):
+        """Test that a monster icon must be a single drawable glyph."""
+        loader = make_loader(tmp_path)
+        write_json(tmp_path / "monsters.json", {"rat": {"name": "Rat", "char": "r\x07"}})
+
+        with pytest.raises(ContentError, match="rat.char"):
+            loader.reload()
+
+    def test_broken_scripts_are_rejected(self, tmp_path):
+        """Test that an NPC script outside the scripting API fails validation."""
+        loader = make_loader(tmp_path)
+        rat = {"name": "Rat", "on_talk": [{"do": "teleport", "x": 0, "y": 0}]}
+        write_json(tmp_path / "monsters.json", {"rat": rat})
+
+        with pytest.raises(ContentError, match="rat.on_talk"):
+            loader.reload()
+
+    def test_extra_check_can_veto(self, tmp_path):
+        """Test that a failing caller check keeps the old content."""
+        loader = make_loader(tmp_path)
+
+        def check(fresh):
+            raise ValueError("Unknown condition 'sleepy' in behavior tree")
+
+        with pytest.raises(ContentError, match="sleepy"):
+            loader.reload(check)
+        assert loader.get_item_data("sword")["attack_bonus"] == 5
+
+
+class TestConfigReload:
+    """Test re-reading config.toml."""
+
+    def test_new_settings_replace_old_in_place(self, tmp_path):
+        """Test that the same config object takes on the file's values."""
+        path = tmp_path / "config.toml"
+        path.write_text('[game]\ntarget_fps = 20\n\n[economy]\nvendor_fee = 0.2\n')
+        config = GameConfig()
+
+        config.replace_with(GameConfig.read_toml(str(path)))
+
+        assert config.target_fps == 20
+        assert config.economy == {"vendor_fee": 0.2}
+
+    def test_broken_file_raises(self, tmp_path):
+        """Test that a bad file is reported rather than replaced by defaults."""
+        path = tmp_path / "config.toml"
+        path.write_text("[game\n")
+
+        with pytest.raises(ValueError):
+            GameConfig.read_toml(str(path))
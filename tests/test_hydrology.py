@@ -0,0 +1,112 @@
+"""
+Tests for rivers, lakes and beaches made during world generation.
+"""
+
+import random
+
+import numpy as np
+
+from world.hydrology import add_beaches, carve_rivers, fill_lake, trace_river
+from world.map import TILE_GRASS, TILE_SAND, TILE_TREE, TILE_WATER
+
+
+def slope(width=20, height=5):
+    """Elevation falling from 1.0 on the left to 0.0 on the right."""
+    elevation = np.full((height, width), 0.0, dtype=float)
+    for x in range(width):
+        elevation[:, x] = 1.0 - x / (width - 1)
+    return elevation
+
+
+def no_water(elevation):
+    height, width = elevation.shape
+    return np.full((height, width), False, dtype=bool)
+
+
+class TestLakes:
+    """Test filling hollows."""
+
+    def test_spills_at_the_lowest_rim(self):
+        """Test that a hollow fills up to its lowest rim and spills over it."""
+        elevation = np.full((5, 5), 0.9, dtype=float)
+        elevation[2, 2] = 0.1
+        elevation[2, 3] = 0.5
+        elevation[2, 4] = 0.2
+
+        lake, outlet = fill_lake(elevation, (2, 2), 50)
+
+        assert lake == {(2, 2), (3, 2)}
+        assert outlet == (4, 2)
+
+    def test_too_large_to_fill(self):
+        """Test that a hollow larger than the limit stays a lake."""
+        elevation = np.full((5, 5), 0.5, dtype=float)
+
+        lake, outlet = fill_lake(elevation, (2, 2), 4)
+
+        assert len(lake) == 4
+        assert outlet is None
+
+
+class TestRivers:
+    """Test tracing and carving rivers."""
+
+    def test_runs_downhill_to_the_sea(self):
+        """Test that a river follows the slope and stops at the water."""
+        elevation = slope()
+        water = no_water(elevation)
+        water[:, 15:] = True
+
+        river, lakes = trace_river(elevation, water, (0, 2), 300)
+
+        assert river == [(x, 2) for x in range(15)]
+        assert lakes == []
+
+    def test_pools_in_a_hollow(self):
+        """Test that a river fills a hollow and carries on from its outlet."""
+        elevation = slope()
+        elevation[2, 5] = 0.1  # A dip lower than the ground just past it
+        water = no_water(elevation)
+        water[:, 19] = True
+
+        river, lakes = trace_river(elevation, water, (0, 2), 300)
+
+        assert lakes == [{(5, 2), (6, 2)}]
+        assert river[-1] == (18, 2) and (7, 2) in river
+
+    def test_carve(self):
+        """Test that rivers are written as water from high ground only."""
+        world_map = np.full((5, 20), TILE_GRASS, dtype=np.uint8)
+        world_map[:, 18:] = TILE_WATER
+
+        rivers, _ = carve_rivers(world_map, slope(), random.Random(1), 2, 0.95)
+
+        assert rivers == 2
+        assert sum(1 for y in range(5) if world_map[y, 0] == TILE_WATER) == 2
+        assert sum(1 for y in range(5) if world_map[y, 10] == TILE_WATER) == 2
+
+    def test_no_source(self):
+        """Test that flat lowland grows no rivers."""
+        world_map = np.full((5, 20), TILE_GRASS, dtype=np.uint8)
+        elevation = np.full((5, 20), 0.1, dtype=float)
+
+        assert carve_rivers(world_map, elevation, random.Random(1), 5) == (0, 0)
+
+
+class TestBeaches:
+    """Test sand along the coast."""
+
+    def test_beach_strip(self):
+        """Test that land near the sea turns to sand, but not trees."""
+        world_map = np.full((6, 10), TILE_GRASS, dtype=np.uint8)
+        biome_map = np.full((6, 10), "plains", dtype=object)
+        world_map[:, :3] = TILE_WATER
+        biome_map[:, :3] = "ocean"
+        world_map[0, 3] = TILE_TREE
+
+        changed = add_beaches(world_map, biome_map, 2)
+
+        assert changed == 6 * 2 - 1
+        assert world_map[1, 3] == TILE_SAND and world_map[1, 4] == TILE_SAND
+        assert world_map[1, 5] == TILE_GRASS
+        assert world_map[0, 3] == TILE_TREE
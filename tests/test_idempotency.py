@@ -0,0 +1,72 @@
+"""
+Tests for idempotency keys on state-changing requests.
+"""
+
+import pytest
+
+from systems.idempotency import IdempotencyCache, IdempotencyError
+
+BET = {"type": "gamble", "game": "dice", "stake": 50, "idempotency_key": "k1"}
+
+
+class FakeClock:
+    def __init__(self):
+        self.now = 100.0
+
+    def __call__(self):
+        return self.now
+
+
+class TestIdempotencyCache:
+    """Test that keyed messages are answered once and retries get that answer."""
+
+    def test_retry_gets_the_first_reply(self):
+        """Test that a message seen before is answered from the cache, marked duplicate."""
+        cache = IdempotencyCache()
+        assert cache.replay("k1", BET) is None
+        cache.remember("k1", BET, {"type": "gamble_result", "stake": 50})
+
+        again = cache.replay("k1", dict(BET))
+
+        assert again == {"type": "gamble_result", "stake": 50, "duplicate": True}
+        assert cache.duplicates == 1
+
+    def test_key_reused_for_another_message_is_refused(self):
+        """Test that a key sent with different arguments is an error, not a replay."""
+        cache = IdempotencyCache()
+        cache.remember("k1", BET, {"type": "gamble_result", "stake": 50})
+
+        with pytest.raises(IdempotencyError, match="another message"):
+            cache.replay("k1", dict(BET, stake=500))
+
+    @pytest.mark.parametrize("key", ["", 7, None, "x" * 65])
+    def test_bad_keys_are_refused(self, key):
+        """Test that keys must be short non-empty strings."""
+        with pytest.raises(IdempotencyError):
+            IdempotencyCache().replay(key, BET)
+
+    def test_errors_are_not_kept(self):
+        """Test that a refused request can be retried with the same key."""
+        cache = IdempotencyCache()
+        cache.remember("k1", BET, {"type": "error", "error": "Not enough gold"})
+
+        assert cache.replay("k1", BET) is None
+
+    def test_replies_expire_after_the_window(self):
+        """Test that a key is forgotten once the dedupe window has passed."""
+        clock = FakeClock()
+        cache = IdempotencyCache(window=60, clock=clock)
+        cache.remember("k1", BET, {"type": "gamble_result"})
+
+        clock.now += 59
+        assert cache.replay("k1", BET) is not None
+        clock.now += 2
+        assert cache.replay("k1", BET) is None
+
+    def test_oldest_keys_are_dropped_over_the_limit(self):
+        """Test that the cache holds at most limit replies."""
+        cache = IdempotencyCache(limit=2)
+        for key in ("a", "b", "c"):
+            cache.remember(key, BET, {"type": "gamble_result"})
+
+        assert list(cache.replies) == ["b", "c"]
@@ -0,0 +1,131 @@
+"""
+Tests for unidentified drops, curses and what payloads reveal of them.
+"""
+
+import random
+
+from entities.components import ArmorStats, Cursed, Item, Render, WeaponStats
+from systems.identification import (
+    UNIDENTIFIED_COLOR,
+    carried_items,
+    conceal,
+    curse,
+    identify,
+    is_cursed,
+    is_identified,
+    item_details,
+    remove_curse,
+    roll_drop,
+)
+
+
+def sword(entity_factory):
+    return entity_factory.create_item(0, 0, "sword", rarity="rare")
+
+
+class TestIdentification:
+    """Test hiding and revealing what an item is."""
+
+    def test_conceal_hides_name_and_color(self, entity_factory):
+        """Test that an unidentified item shows only its kind until identified."""
+        em = entity_factory.entity_manager
+        item_id = sword(entity_factory)
+        item = em.get_component(item_id, Item)
+        name, color = item.name, item.color
+
+        conceal(em, item_id, "Iron Sword")
+
+        assert not is_identified(em, item_id)
+        assert item.name == "Unidentified Iron Sword"
+        assert em.get_component(item_id, Render).fg_color == UNIDENTIFIED_COLOR
+        assert identify(em, item_id)
+        assert is_identified(em, item_id)
+        assert (item.name, item.color) == (name, color)
+        assert not identify(em, item_id)
+
+    def test_payloads_hide_stats(self, entity_factory):
+        """Test that an unidentified item's details give away nothing but its kind."""
+        em = entity_factory.entity_manager
+        item_id = sword(entity_factory)
+        attack = em.get_component(item_id, WeaponStats).attack_power
+        curse(em, item_id, random.Random(1))
+        conceal(em, item_id, "Iron Sword")
+
+        assert item_details(em, item_id) == {
+            "name": "Unidentified Iron Sword",
+            "identified": False,
+        }
+        identify(em, item_id)
+        details = item_details(em, item_id)
+        assert details["cursed"] and details["rarity"] == "rare"
+        assert details["attack"] < attack
+
+    def test_roll_drop_only_touches_gear(self, entity_factory):
+        """Test that potions are never left unidentified."""
+        em = entity_factory.entity_manager
+        potion = entity_factory.create_item(0, 0, "health_potion")
+
+        assert not roll_drop(em, potion, "Health Potion", random.Random(0))
+        assert is_identified(em, potion)
+
+    def test_some_drops_are_hidden_and_some_cursed(self, entity_factory):
+        """Test that gear drops come out as a mix of plain, unidentified and cursed."""
+        em = entity_factory.entity_manager
+        rng = random.Random(3)
+        hidden = cursed = 0
+        for _ in range(200):
+            item_id = sword(entity_factory)
+            hidden += roll_drop(em, item_id, "Iron Sword", rng)
+            cursed += is_cursed(em, item_id)
+
+        assert 0 < cursed < hidden < 200
+
+
+class TestCurses:
+    """Test harmful affixes and lifting curses."""
+
+    def test_curse_weakens_armor(self, entity_factory):
+        """Test that a curse adds a harmful affix to armor."""
+        em = entity_factory.entity_manager
+        helmet = entity_factory.create_item(0, 0, "iron_helmet", rarity="common")
+        defense = em.get_component(helmet, ArmorStats).defense
+
+        affix = curse(em, helmet, random.Random(2))
+
+        assert affix in em.get_component(helmet, Item).affixes
+        assert affix in em.get_component(helmet, Item).name
+        assert em.get_component(helmet, ArmorStats).defense < defense
+        assert em.get_component(helmet, Cursed).affix == affix
+
+    def test_only_gear_can_be_cursed(self, entity_factory):
+        """Test that a curse needs a weapon or armor to hold it."""
+        em = entity_factory.entity_manager
+        potion = entity_factory.create_item(0, 0, "health_potion")
+
+        assert curse(em, potion) is None
+        assert not is_cursed(em, potion)
+
+    def test_remove_curse(self, entity_factory):
+        """Test that lifting a curse keeps the item but frees it."""
+        em = entity_factory.entity_manager
+        item_id = sword(entity_factory)
+        curse(em, item_id, random.Random(4))
+
+        assert remove_curse(em, item_id)
+        assert not is_cursed(em, item_id)
+        assert not remove_curse(em, item_id)
+        assert em.get_component(item_id, Item).affixes
+
+    def test_carried_items_include_worn_gear(self, entity_wrapper):
+        """Test that worn gear comes before the pack."""
+        from entities.components import Equipment, Inventory
+
+        em = entity_wrapper.entity_manager
+        player = entity_wrapper.factory.create_player(0, 0)
+        worn = entity_wrapper.factory.create_item(0, 0, "sword")
+        packed = entity_wrapper.factory.create_item(0, 0, "shield")
+        em.get_component(player, Equipment).weapon = worn
+        em.get_component(player, Inventory).items.append(packed)
+
+        assert carried_items(em, player)[0] == worn
+        assert packed in carried_items(em, player)
@@ -0,0 +1,54 @@
+"""
+Tests for the move sequence numbers behind client-side prediction.
+"""
+
+import pytest
+
+from systems.input_sequence import MAX_CLIENTS, InputSequencer
+
+
+class TestInputSequencer:
+    """Test that each client's moves are processed once and acknowledged."""
+
+    def test_new_moves_are_accepted(self):
+        """Test that increasing sequence numbers are applied and acknowledged, gaps too."""
+        sequencer = InputSequencer()
+
+        assert sequencer.ack("a") == 0
+        assert sequencer.accept("a", 1)
+        assert sequencer.accept("a", 3)
+        assert sequencer.ack("a") == 3
+
+    def test_duplicates_and_late_moves_are_not_applied(self):
+        """Test that a number already processed is not applied again or lowers the ack."""
+        sequencer = InputSequencer()
+        sequencer.accept("a", 5)
+
+        assert not sequencer.accept("a", 5)
+        assert not sequencer.accept("a", 2)
+        assert sequencer.ack("a") == 5
+
+    def test_clients_are_separate(self):
+        """Test that a new client id starts its own numbering."""
+        sequencer = InputSequencer()
+        sequencer.accept("old", 40)
+
+        assert sequencer.accept("new", 1)
+        assert sequencer.ack("new") == 1
+
+    def test_bad_sequence_numbers(self):
+        """Test that sequence numbers must be positive whole numbers."""
+        sequencer = InputSequencer()
+
+        for bad in (0, -1, 1.5, "2", True, None):
+            with pytest.raises(ValueError):
+                sequencer.accept("a", bad)
+
+    def test_idle_clients_are_forgotten(self):
+        """Test that only the most recently active clients are remembered."""
+        sequencer = InputSequencer()
+        for i in range(MAX_CLIENTS + 1):
+            sequencer.accept(f"c{i}", 1)
+
+        assert sequencer.ack("c0") == 0
+        assert sequencer.ack(f"c{MAX_CLIENTS}") == 1
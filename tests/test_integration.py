@@ -0,0 +1,865 @@
+"""
+End-to-end tests driving a real game through the integration harness.
+"""
+
+import os
+
+
+class TestJoinMoveFight:
+    """Test the join, move and combat flows against a running game."""
+
+    def test_joined_player_starts_outside_tutorial(self, harness):
+        """Test that the harness player is placed, healthy and past the tutorial."""
+        assert harness.player is not None
+        assert harness.health() > 0
+        assert not harness.engine.in_tutorial
+
+    def test_client_sees_registered_requests(self, harness):
+        """Test that a connection's requests are answered and captured."""
+        client = harness.connect()
+
+        reply = client.request("help")
+        client.request("no_such_request")
+
+        assert "text_command" in [r["name"] for r in reply["requests"]]
+        assert client.last("error")["error"].startswith("Unknown request")
+        assert len(client.messages()) == 2
+
+    def test_player_moves(self, harness):
+        """Test that stepping onto an open tile moves the player there."""
+        x, y = harness.position()
+        dx, dy = harness.open_direction()
+
+        assert harness.move(dx, dy)
+        assert harness.position() == (x + dx, y + dy)
+
+    def test_map_editor_places_a_spawner(self, harness):
+        """Test that a placed spawner brings its monster back once it is killed."""
+        client = harness.connect()
+        x, y = harness.position()
+
+        region = client.request("edit_select", x=x - 5, y=y - 5, width=11, height=11)
+        placed = client.request(
+            "edit_place", kind="spawner", subtype="goblin", x=x + 2, y=y, respawn=0.1
+        )
+        harness.engine.entity_manager.destroy_entity(placed["entity"])
+        harness.tick(10)
+        (spawner,) = harness.engine.spawners.active
+        removed = client.request("edit_remove", x=x + 2, y=y)
+        unknown = client.request("edit_place", kind="item", subtype="nope", x=x, y=y)
+
+        assert region["type"] == "edit_region" and len(region["tiles"]) == 11
+        assert spawner["eid"] != placed["entity"]
+        assert removed["entries"] == [placed["entry"]]
+        assert spawner["eid"] not in harness.engine.entity_manager.entities
+        assert unknown["type"] == "error"
+
+    def test_text_commands_move_and_look(self, harness):
+        """Test that typed commands act on the same player."""
+        client = harness.connect()
+        x, y = harness.position()
+        dx, dy = harness.open_direction()
+        direction = {(0, -1): "n", (1, 0): "e", (0, 1): "s", (-1, 0): "w"}.get((dx, dy), "")
+
+        if direction:
+            client.type(direction)
+            assert harness.position() == (x + dx, y + dy)
+        assert client.type("look")
+        assert client.last("text_response")["command"] == "look"
+
+    def test_player_kills_monster(self, harness):
+        """Test that bump attacks wear a monster down until it dies."""
+        goblin = harness.spawn_monster("goblin", health=1)
+
+        assert harness.fight(goblin)
+        assert goblin not in harness.engine.spatial_index.monsters
+        assert harness.health() > 0
+
+    def test_saves_stay_in_harness_directory(self, harness, tmp_path):
+        """Test that the game writes its profile to the temporary directory."""
+        harness.engine.save_player_profile()
+
+        assert os.path.exists(tmp_path / "player_profile.json")
+
+    def test_admin_requests_are_audited(self, harness):
+        """Test that admin requests are written to the audit log and can be searched."""
+        client = harness.connect()
+        client.request("audit_log", action="ban")
+
+        reply = client.request("audit_log", action="admin_request")
+
+        assert reply["type"] == "audit_log"
+        # The query itself is written once it has run, after these entries
+        (entry,) = reply["entries"]
+        assert (entry["actor"], entry["target"]) == ("console", "audit_log")
+        assert entry["details"] == {"args": {"action": "ban"}, "outcome": "allowed"}
+
+    def test_admin_announcement_is_broadcast(self, harness):
+        """Test that an announcement scheduled by an admin shows up in the log."""
+        client = harness.connect()
+
+        client.request("set_motd", text="Double XP weekend")
+        scheduled = client.request("announce", text="Restart in 5 minutes")
+        harness.tick()
+
+        assert client.request("motd")["text"] == "Double XP weekend"
+        assert scheduled["type"] == "announcement_scheduled"
+        assert ("[Announcement] Restart in 5 minutes", (255, 170, 60)) in (
+            harness.engine.message_log
+        )
+        assert client.request("announcements")["scheduled"] == []
+
+    def test_scheduled_restart_saves_and_stops(self, harness, tmp_path):
+        """Test that an expired restart countdown saves the player and ends the loop."""
+        client = harness.connect()
+
+        reply = client.request("schedule_restart", minutes=0, reason="Patch day.")
+        harness.tick()
+
+        assert reply["type"] == "restart_scheduled"
+        assert not harness.engine.running
+        assert os.path.exists(tmp_path / "player_profile.json")
+
+    def test_content_reload(self, harness):
+        """Test that reloading content and config succeeds on the shipped files."""
+        client = harness.connect()
+
+        reply = client.request("reload_content")
+
+        assert reply["type"] == "content_reloaded"
+        assert "monsters" in reply["files"]
+
+    def test_anticheat_flags_unexplained_gold(self, harness):
+        """Test that gold appearing without a transaction is an incident, and a
+        restricted player cannot act until an admin lifts it."""
+        from entities.components import Inventory
+
+        client = harness.connect()
+        harness.tick()
+        inventory = harness.engine.entity_manager.get_component(harness.player, Inventory)
+
+        for _ in range(3):
+            inventory.gold += 500
+            harness.tick()
+        dx, dy = harness.open_direction()
+
+        assert client.request("incidents")["incidents"][0]["kind"] == "gold"
+        assert not harness.move(dx, dy)
+        assert client.request("lift_restriction", player=harness.engine.player_name())[
+            "was_restricted"
+        ]
+        assert harness.move(dx, dy)
+
+    def test_ping_reports_time_and_tracks_round_trip(self, harness):
+        """Test that a ping is echoed with server time and tick, and a reported
+        round trip shows up in who."""
+        client = harness.connect()
+        harness.tick(3)
+
+        pong = client.request("ping", sent=12.5)
+        client.request("ping", sent=13.0, rtt_ms=40)
+
+        assert pong["type"] == "pong"
+        assert pong["sent"] == 12.5
+        assert pong["tick"] == harness.engine.tick
+        assert pong["server_time"] > 0
+        assert client.request("who")["rtt_ms"] == 40
+        assert client.request("ping", sent="now")["type"] == "error"
+
+    def test_netstat_is_for_gms_only(self, harness):
+        """Test that netstat answers admins and is refused to telnet players."""
+        client = harness.connect()
+
+        local = client.request("netstat")
+        typed = harness.engine.gateway_reply("/netstat")
+
+        assert local == {"type": "netstat", "connections": []}
+        assert "Nobody is connected" in "\n".join(client.type("netstat"))
+        assert "Nobody is connected" not in typed
+
+        entries = harness.engine.audit.query(action="admin_request", target="netstat")
+        outcomes = [(entry["actor"], entry["details"]["outcome"]) for entry in entries]
+        assert outcomes == [("console", "allowed"), ("telnet", "refused"), ("console", "allowed")]
+
+    def test_sequenced_moves_are_acknowledged(self, harness):
+        """Test that numbered moves come back with the ack and authoritative position,
+        and a repeated move is not applied twice."""
+        client = harness.connect()
+        dx, dy = harness.open_direction()
+        x, y = harness.position()
+
+        update = client.request("move", dx=dx, dy=dy, seq=1, client="c1")
+        repeat = client.request("move", dx=dx, dy=dy, seq=1, client="c1")
+
+        assert update["type"] == "player_update"
+        assert update["ack"] == 1 and update["applied"]
+        assert (update["x"], update["y"]) == (x + dx, y + dy) == harness.position()
+        assert not repeat["applied"]
+        assert client.request("player_update", client="c1")["ack"] == 1
+
+    def test_retried_requests_run_once(self, harness):
+        """Test that a state-changing request retried with its idempotency_key
+        is answered again without being applied again."""
+        client = harness.connect()
+        x, y = harness.position()
+
+        text = {"text": "describe on", "idempotency_key": "retry-1"}
+        first = client.request("text_command", **text)
+        retry = client.request("text_command", **text)
+        moved = client.request("text_command", text="go north", idempotency_key="retry-1")
+
+        assert first["type"] == "text_response" and "duplicate" not in first
+        assert retry == dict(first, duplicate=True)
+        assert moved["type"] == "error" and "another message" in moved["error"]
+        assert harness.position() == (x, y)
+
+    def test_retried_admin_request_is_audited_once(self, harness):
+        """Test that a replayed admin request is answered from the cache, not audited again."""
+        client = harness.connect()
+
+        for _ in range(2):
+            client.request("set_motd", text="Welcome back", idempotency_key="motd-1")
+        entries = harness.engine.audit.query(action="admin_request", target="set_motd")
+
+        assert len(entries) == 1
+        assert harness.engine.commands.changes_state("set_motd")
+        assert not harness.engine.commands.changes_state("motd")
+
+    def test_turn_based_fight(self, harness):
+        """Test that with turn-based combat a nearby monster starts a fight, the
+        player's actions wait for their turn and monsters act on theirs."""
+        from systems.turns import TurnOrder
+
+        engine = harness.engine
+        engine.turns = TurnOrder({"mode": "turns", "turn_seconds": 0}, roll=lambda: 10)
+        goblin = harness.spawn_monster("goblin", health=500)
+        harness.tick()
+
+        order = harness.connect().request("turn_order")
+        assert order["active"] and goblin in [entry["id"] for entry in order["order"]]
+        assert order["your_turn"]
+
+        assert engine.commands.run_action("wait")
+        assert not engine.commands.run_action("wait")
+        assert engine.message_log[-1][0] == "Wait for your turn."
+        harness.tick()
+        assert engine.turns.player_turn
+
+    def test_energy_paces_the_player(self, harness):
+        """Test that under energy scheduling a world action waits until the player
+        can pay for it, while menus stay free."""
+        from systems.energy import EnergyScheduler
+        from systems.turns import ENERGY, TurnOrder
+
+        engine = harness.engine
+        engine.turns = TurnOrder({"mode": ENERGY})
+        engine.energy = EnergyScheduler({"energy_per_second": 100.0})
+
+        assert engine.commands.run_action("wait")
+        assert not engine.commands.run_action("wait")
+        assert engine.commands.run_action("reputation")
+        harness.tick(int(1 / engine.fixed_timestep) + 1)
+        assert engine.commands.run_action("wait")
+
+    def test_summoned_ally_fights_for_the_player(self, harness):
+        """Test that a summon earns its owner the kill, is capped and unravels."""
+        from entities.components import Level, Summoned
+
+        engine = harness.engine
+        goblin = harness.spawn_monster("goblin", health=1)
+        engine.summons.max_per_player = 1
+        level = engine.entity_manager.get_component(harness.player, Level)
+        xp = level.current_xp
+
+        first = engine.summon_ally(harness.player, "bone_servant")
+        ally = engine.summon_ally(harness.player, "bone_servant")
+        for _ in range(20):
+            if harness.health(goblin) is None:
+                break
+            engine.monster_attacks(ally, goblin)
+
+        assert first is not None and harness.health(first) is None
+        assert harness.health(goblin) is None
+        assert level.current_xp > xp
+        assert [s["id"] for s in engine.player_update()["summons"]] == [ally]
+
+        engine.entity_manager.get_component(ally, Summoned).remaining = 0.01
+        harness.tick()
+        assert harness.health(ally) is None
+        assert "summons" not in engine.player_update()
+
+    def test_cutscene_locks_input_until_skipped(self, harness):
+        """Test that a cutscene refuses moves, streams its events and can be skipped."""
+        client = harness.connect()
+        dx, dy = harness.open_direction()
+
+        assert harness.engine.play_cutscene("desert_sphinx_intro")
+        harness.tick()
+        locked = harness.move(dx, dy)
+        client.type("skip")
+
+        events = client.request("sequence")["events"]
+        assert not locked
+        assert [e["kind"] for e in events][:2] == ["start", "focus"]
+        assert events[-1] == dict(events[-1], kind="end", skipped=True)
+        assert not client.request("sequence")["active"]
+        assert harness.move(dx, dy)
+
+    def test_stairs_lead_down_to_mine_and_back(self, harness, monkeypatch):
+        """Test that stairs swap in the level below, where an ore seam can be mined."""
+        from entities.components import Inventory, Item, Position
+        from world.map import GameMap, TILE_ORE_IRON, TILE_STAIRS_DOWN, TILE_STAIRS_UP
+
+        engine = harness.engine
+        monkeypatch.setattr(  # An empty cave stays empty
+            engine.spawn_system, "spawn_monsters_around_player", lambda *a, **k: []
+        )
+        dx, dy = harness.open_direction()
+        x, y = harness.position()
+        sx, sy = x + dx, y + dy
+        surface = engine.game_map
+        cave = GameMap(surface.width, surface.height, is_dark=True)
+        cave.tiles[sy, sx] = TILE_STAIRS_UP
+        cave.tiles[sy + dy, sx + dx] = TILE_ORE_IRON
+        engine.levels[1] = cave
+        before = surface.tiles[sy, sx]
+        surface.tiles[sy, sx] = TILE_STAIRS_DOWN
+        pos = engine.entity_manager.get_component(harness.player, Position)
+
+        try:
+            harness.move(dx, dy)
+            arrived = (pos.z, engine.game_map)
+            mined = harness.move(dx, dy)
+            assert harness.move(dx, dy)
+            engine.pickup_item()
+            harness.move(-dx, -dy)
+        finally:
+            surface.tiles[sy, sx] = before
+
+        inventory = engine.entity_manager.get_component(harness.player, Inventory)
+        items = [engine.entity_manager.get_component(i, Item) for i in inventory.items]
+        assert arrived == (1, cave)
+        assert not mined
+        assert "iron_ore" in [item.item_type for item in items]
+        assert (pos.z, engine.game_map) == (0, surface)
+        assert harness.position() == (sx, sy)
+
+    def test_portal_leads_to_another_level(self, harness, monkeypatch):
+        """Test that a portal into a cave swaps in the cave's map, and that
+        creatures left on the surface are not seen from down there."""
+        from entities.components import Position
+        from world.map import GameMap
+
+        engine = harness.engine
+        monkeypatch.setattr(
+            engine.spawn_system, "spawn_monsters_around_player", lambda *a, **k: []
+        )
+        dx, dy = harness.open_direction()
+        x, y = harness.position()
+        px, py = x + dx, y + dy
+        surface = engine.game_map
+        cave = GameMap(surface.width, surface.height, is_dark=True)
+        engine.levels[1] = cave
+        engine.teleport.add_portal((px, py, 0), (px, py, 1))
+        pos = engine.entity_manager.get_component(harness.player, Position)
+
+        assert harness.move(dx, dy)
+        goblin = engine.entity_wrapper.factory.create_monster(x, y, "goblin", z=0)
+
+        assert (pos.z, engine.game_map) == (1, cave)
+        assert harness.position() == (px, py)
+        assert engine.teleport.portal_at(px, py, 1) == (px, py, 0)
+        assert goblin not in [eid for eid, _, _ in engine.nearby_creatures()]
+
+    def test_worlds_keep_their_own_positions(self, harness, monkeypatch):
+        """Test entering a new world and coming back to where the player left off."""
+        import world.persistent_world as persistent_world
+        from systems.profile import load_profile
+        from world.transfer import Roster
+
+        engine = harness.engine
+        main = persistent_world.get_persistent_world()
+        opened = []
+        monkeypatch.setattr(  # Every world shares the loaded map; only names differ
+            persistent_world,
+            "open_world",
+            lambda name, seed, world_file: opened.append((name, seed)) or main,
+        )
+        admin = harness.connect()
+        created = admin.request(
+            "create_world", name="frontier", seed=99, ruleset="hardcore"
+        )
+        harness.move(*harness.open_direction())
+        left_at = harness.position()
+
+        entered = admin.request("select_world", name="frontier")
+        arrived = harness.position()
+        hardcore = engine.hardcore
+        refused = admin.request("archive_world", name="frontier")
+        back = admin.request("select_world", name="main")
+
+        assert created["world"]["ruleset"] == "hardcore"
+        names = [world["name"] for world in admin.request("worlds")["worlds"]]
+        assert names == ["main", "frontier"]
+        assert entered["type"] == "world_entered" and opened[0] == ("frontier", 99)
+        assert hardcore and refused["type"] == "error"
+        assert back["type"] == "world_entered" and not engine.hardcore
+        assert harness.position() == left_at != arrived
+        assert load_profile(engine.profile_path)["world"] == "main"
+        for name in ("main", "frontier"):
+            assert Roster(engine.worlds.roster_file(name)).get(engine.player_name())
+        archived = admin.request("archive_world", name="frontier")
+        assert archived["type"] == "world_archived"
+        assert admin.request("select_world", name="frontier")["type"] == "error"
+
+    def test_merge_worlds_renames_and_remaps(self, harness):
+        """Test folding a world's players into this one, renaming a taken name."""
+        from entities.schedule_system import nearest_walkable
+        from world.persistent_world import get_persistent_world
+        from world.transfer import Roster
+
+        engine = harness.engine
+        admin = harness.connect()
+        admin.request("create_world", name="isles", seed=7)
+        engine.save_player_profile()  # Puts the player on this world's roster
+        isles = Roster(engine.worlds.roster_file("isles"))
+        isles.put(engine.player_name(), 3, 4, 2)
+        isles.put("Rook", 5, 6)
+        isles.save()
+
+        merged = admin.request("merge_worlds", source="isles", target="main")
+        main = Roster(engine.worlds.roster_file("main"))
+        renamed = f"{engine.player_name()}-isles"
+        start = engine.world_start(get_persistent_world())
+        arrival = nearest_walkable(engine.levels[0], *start)
+
+        assert merged["moved"] == 2
+        assert merged["renamed"] == {engine.player_name(): renamed}
+        assert main.get(renamed)["renamed_from"] == engine.player_name()
+        assert main.get(renamed)["z"] == 0 and main.get("Rook")["from"] == "isles"
+        assert (main.get("Rook")["x"], main.get("Rook")["y"]) == arrival
+        assert Roster(engine.worlds.roster_file("isles")).players == {}
+        assert admin.request("select_world", name="isles")["type"] == "error"
+        back = admin.request("merge_worlds", source="main", target="isles")
+        assert back["type"] == "error"
+
+    def test_starter_town_is_a_safe_zone(self, harness):
+        """Test that new players start in the safe zone, where nobody fights."""
+        from entities.components import Health
+
+        engine = harness.engine
+        spawn_x, spawn_y = engine.regions.spawn_point()
+        x, y = harness.position()
+        dx, dy = harness.open_direction()
+        goblin = engine.entity_wrapper.factory.create_monster(x + dx, y + dy, "goblin")
+        goblin_health = engine.entity_manager.get_component(goblin, Health)
+        before = (goblin_health.current, harness.health())
+
+        engine.handle_combat(harness.player, goblin)
+        engine.handle_combat(goblin, harness.player)
+
+        assert engine.in_safe_zone(harness.player)
+        assert max(abs(x - spawn_x), abs(y - spawn_y)) <= 10
+        assert (goblin_health.current, harness.health()) == before
+        assert engine.occupancy.check_move(goblin, x + 2 * dx, y + 2 * dy)[0] == "block"
+
+    def test_updates_carry_the_regions_sound(self, harness):
+        """Test that zone_info and player updates name the region's ambience and music."""
+        client = harness.connect()
+
+        zone = client.request("zone_info")
+        update = client.request("player_update")
+
+        assert zone["music"] == "hearthside"
+        assert (update["ambience"], update["music"]) == (zone["ambience"], zone["music"])
+
+    def test_bind_home_respawn_and_recall(self, harness):
+        """Test that players respawn and recall to the inn or shrine they bound."""
+        from systems.profile import load_profile
+
+        engine = harness.engine
+        spawn = engine.regions.spawn_point()
+        home = max(
+            engine.bind_points,
+            key=lambda point: abs(point.x - spawn[0]) + abs(point.y - spawn[1]),
+        )
+
+        def near_home():
+            x, y = harness.position()
+            return max(abs(x - home.x), abs(y - home.y)) <= 20
+
+        engine.place_player(home.x, home.y)
+        engine.commands.run_action("action_menu")
+        engine.place_player(*spawn)
+        engine.respawn_player()
+        respawned = near_home()
+        engine.place_player(*spawn)
+        engine.commands.run_action("recall")
+        recalled = near_home()
+        engine.place_player(*spawn)
+        engine.commands.run_action("recall")
+
+        assert engine.homes.home(engine.world_name) == home
+        assert respawned and recalled and not near_home()
+        reply = harness.connect().request("home")
+        assert reply["home"]["name"] == home.name and reply["recall_ready_in"] > 0
+        saved = load_profile(engine.profile_path)["homes"]
+        assert saved["points"][engine.world_name]["name"] == home.name
+
+    def test_top_damage_dealer_owns_kill_and_loot(self, harness):
+        """Test that stealing the last blow earns neither the kill nor the drop."""
+        from entities.components import Level, Name
+
+        engine = harness.engine
+        goblin = harness.spawn_monster("goblin", health=5)
+        x, y = harness.position()
+        rival = engine.entity_wrapper.factory.create_player(x + 5, y)
+        engine.entity_manager.get_component(rival, Name).value = "Rival"
+        engine.loot_system.record_damage(goblin, rival, 100)
+        level = engine.entity_manager.get_component(harness.player, Level)
+        xp = level.current_xp
+
+        assert harness.fight(goblin)
+        assert level.current_xp == xp
+
+        item = engine.entity_wrapper.factory.create_item(*harness.position(), "sword")
+        engine.loot_system.claim(item, rival, engine.tick * engine.fixed_timestep)
+        engine.pickup_item()
+        (entry,) = [e for e in engine.item_update()["items"] if e["id"] == item]
+
+        assert entry["owner"] == "Rival" and not entry["can_take"]
+        assert entry["free_in"] > 0
+
+    def test_craft_signs_the_item_and_uses_the_ore(self, harness):
+        """Test that crafting turns carried ore into a signed item of some quality."""
+        from entities.components import Inventory, Item, Position
+
+        engine = harness.engine
+        inventory = engine.entity_manager.get_component(harness.player, Inventory)
+        for _ in range(3):
+            ore = engine.entity_wrapper.factory.create_item(0, 0, "iron_ore")
+            engine.entity_manager.remove_component(ore, Position)
+            inventory.items.append(ore)
+        before = len(inventory.items)
+
+        engine.commands.run_action("craft", "sword")
+
+        made = engine.entity_manager.get_component(inventory.items[-1], Item)
+        assert len(inventory.items) == before - 2
+        assert made.item_type == "sword" and made.crafted_by == engine.player_name()
+        assert made.quality in ("crude", "standard", "fine", "superior", "masterwork")
+        reply = harness.connect().request("recipes")
+        assert "sword" in [recipe["id"] for recipe in reply["recipes"]]
+
+    def test_brew_discovers_a_potion_and_saves_it(self, harness):
+        """Test that brewing uses the reagents and the discovery reaches the profile."""
+        from entities.components import Inventory, Item, Position
+        from systems.profile import load_profile
+
+        engine = harness.engine
+        inventory = engine.entity_manager.get_component(harness.player, Inventory)
+        for reagent in ("healing_herb", "healing_herb", "glowcap", "bloodroot"):
+            herb = engine.entity_wrapper.factory.create_item(0, 0, reagent)
+            engine.entity_manager.remove_component(herb, Position)
+            inventory.items.append(herb)
+        before = len(inventory.items)
+
+        engine.commands.run_action("brew", ["healing_herb", "healing_herb"])
+        made = engine.entity_manager.get_component(inventory.items[-1], Item)
+        assert len(inventory.items) == before - 1
+        assert made.item_type == "health_potion"
+
+        engine.commands.run_action("brew", ["glowcap", "bloodroot"])
+        assert len(inventory.items) == before - 3
+
+        saved = load_profile(engine.profile_path)["alchemy"]
+        assert saved["known"] == ["health_potion"]
+        assert saved["tried"] == [["bloodroot", "glowcap"]]
+        reply = harness.connect().request("alchemy")
+        assert [brew["id"] for brew in reply["known"]] == ["health_potion"]
+
+    def test_fishing_catch_lands_in_the_pack(self, harness):
+        """Test that reeling in on the bite lands a catch and some fishing XP."""
+        from entities.components import Inventory, Skills
+        from world.map import TILE_WATER
+
+        engine = harness.engine
+        engine.fishing.bite_min = engine.fishing.bite_max = 0.0
+        inventory = engine.entity_manager.get_component(harness.player, Inventory)
+        skills = engine.entity_manager.get_component(harness.player, Skills)
+        before = len(inventory.items)
+        x, y = harness.position()
+        engine.game_map.tiles[y, x + 1] = TILE_WATER
+
+        engine.commands.run_action("fish")
+        assert engine.player_update()["fishing"]["state"] == "waiting"
+        harness.tick()
+        assert engine.player_update()["fishing"]["state"] == "biting"
+        engine.commands.run_action("reel")
+
+        assert len(inventory.items) == before + 1
+        assert skills.fishing_xp > 0
+        assert "fishing" not in engine.player_update()
+
+    def test_cursed_gear_binds_until_the_curse_lifts(self, harness):
+        """Test that a worn cursed sword stays on until remove curse is read."""
+        from entities.components import Equipment, Inventory, Position
+        from systems.identification import conceal, curse
+
+        engine = harness.engine
+        em = engine.entity_manager
+        inventory = em.get_component(harness.player, Inventory)
+        equipment = em.get_component(harness.player, Equipment)
+        cursed, spare = (
+            engine.entity_wrapper.factory.create_item(0, 0, "sword") for _ in range(2)
+        )
+        curse(em, cursed)
+        conceal(em, cursed, "Iron Sword")
+        for item_id in (cursed, spare):
+            em.remove_component(item_id, Position)
+            inventory.items.append(item_id)
+
+        engine.inventory_selection = inventory.items.index(cursed)
+        engine.use_inventory_item()
+        assert equipment.weapon == cursed
+        engine.inventory_selection = inventory.items.index(spare)
+        engine.use_inventory_item()
+        assert equipment.weapon == cursed
+
+        pos = em.get_component(harness.player, Position)
+        factory = engine.entity_wrapper.factory
+        found = factory.create_item(pos.x, pos.y, "iron_helmet", z=pos.z)
+        conceal(em, found, "Iron Helmet")
+        nearby = harness.connect().request("item_update")
+        entry = next(e for e in nearby["items"] if e["id"] == found)
+        assert entry["name"] == "Unidentified Iron Helmet" and not entry["identified"]
+        assert "attack" not in entry and "rarity" not in entry
+
+        engine.run_content_script([{"do": "remove_curse"}])
+        engine.use_inventory_item()
+        assert equipment.weapon == spare
+        assert cursed in inventory.items
+
+    def test_items_bind_on_pickup_and_equip(self, harness):
+        """Test that binding happens on the right event and shows in the inventory."""
+        from entities.components import Inventory, Item, Position
+
+        engine = harness.engine
+        em = engine.entity_manager
+        inventory = em.get_component(harness.player, Inventory)
+        pos = em.get_component(harness.player, Position)
+        factory = engine.entity_wrapper.factory
+        draught = factory.create_item(pos.x, pos.y, "troll_draught", z=pos.z)
+        greaves = factory.create_item(0, 0, "iron_greaves")
+        em.remove_component(greaves, Position)
+
+        engine.transaction("pickup").pickup_item(inventory, draught).commit()
+        engine.transaction("pickup").add_item(inventory, greaves).commit()
+        assert em.get_component(draught, Item).bound_to == engine.player_name()
+        assert not em.get_component(greaves, Item).bound_to
+
+        engine.inventory_selection = inventory.items.index(greaves)
+        engine.use_inventory_item()
+        assert em.get_component(greaves, Item).bound_to == engine.player_name()
+
+        reply = harness.connect().request("inventory")
+        status = {entry["id"]: entry for entry in reply["items"]}
+        assert status[greaves]["bind"] == "bound"
+        assert status[greaves]["equipped"] == "legs"
+        assert status[draught]["bind"] == "bound"
+
+    def test_stash_is_shared_with_the_next_character(self, harness, tmp_path):
+        """Test that a stashed item waits at the banker for another character."""
+        from entities.components import Inventory, Item, Position
+        from testutil import GameHarness
+
+        engine = harness.engine
+        em = engine.entity_manager
+        inventory = em.get_component(harness.player, Inventory)
+        x, y = harness.position()
+        ore = engine.entity_wrapper.factory.create_item(0, 0, "gold_ore")
+        em.remove_component(ore, Position)
+        inventory.items.append(ore)
+
+        engine.commands.run_action("stash_deposit", ore)
+        assert ore in inventory.items  # No banker in reach
+
+        engine.entity_wrapper.factory.create_banker(x + 1, y)
+        engine.commands.run_action("stash_deposit", ore)
+        assert ore not in inventory.items and engine.stash.items == [ore]
+
+        alt = GameHarness(str(tmp_path))
+        reply = alt.connect().request("stash")
+        assert [entry["name"] for entry in reply["items"]] == ["Gold Ore"]
+        assert not reply["reachable"]
+        stash = alt.engine.stash
+        stashed = alt.engine.entity_manager.get_component(stash.items[0], Item)
+        assert stashed.item_type == "gold_ore"
+
+    def test_buy_back_a_vendor_sale(self, harness):
+        """Test that a sold item can be bought back for what it fetched."""
+        from entities.components import Inventory, Item, Position
+
+        engine = harness.engine
+        em = engine.entity_manager
+        inventory = em.get_component(harness.player, Inventory)
+        x, y = harness.position()
+        sword = engine.entity_wrapper.factory.create_item(0, 0, "sword")
+        em.remove_component(sword, Position)
+        inventory.items[:] = [sword]
+        engine.current_shop_id = engine.entity_wrapper.factory.create_shopkeeper(
+            x + 1, y
+        )
+        engine.shop_mode, engine.shop_selection = "SELL", 0
+        gold = inventory.gold
+
+        engine.handle_shop_transaction()
+        paid = inventory.gold - gold
+        assert sword not in inventory.items and paid > 0
+        reply = harness.connect().request("buyback")
+        assert [(entry["id"], entry["price"]) for entry in reply["items"]] == [
+            (sword, paid)
+        ]
+
+        engine.shop_mode, engine.shop_selection = "BUYBACK", 0
+        engine.handle_shop_transaction()
+        assert inventory.items == [sword] and inventory.gold == gold
+        assert em.get_component(sword, Item).item_type == "sword"
+        assert engine.buyback.entries() == []
+
+    def test_selling_a_load_lowers_the_price(self, harness):
+        """Test that a shop pays less for each of a load of the same goods."""
+        from entities.components import Inventory, Position
+        from systems.markets import Markets
+
+        engine = harness.engine
+        em = engine.entity_manager
+        inventory = em.get_component(harness.player, Inventory)
+        x, y = harness.position()
+        factory = engine.entity_wrapper.factory
+        ores = [factory.create_item(0, 0, "gold_ore") for _ in range(5)]
+        for ore in ores:
+            em.remove_component(ore, Position)
+        inventory.items[:] = ores
+        engine.current_shop_id = factory.create_shopkeeper(x + 1, y)
+        engine.shop_mode = "SELL"
+
+        paid = []
+        for _ in ores:
+            gold = inventory.gold
+            engine.shop_selection = 0
+            engine.handle_shop_transaction()
+            paid.append(inventory.gold - gold)
+        assert paid[-1] < paid[0]
+
+        engine.save_player_profile()
+        saved = Markets(engine.markets.path)
+        saved.load()
+        assert saved.market(engine.shop_market().town).supply == {"gold_ore": 5}
+
+    def test_dice_with_the_innkeeper_is_audited(self, harness):
+        """Test that a bet settles in gold, is audited with its seed and can be replayed."""
+        from entities.components import Inventory
+        from systems.gambling import deal
+
+        engine = harness.engine
+        inventory = engine.entity_manager.get_component(harness.player, Inventory)
+        client = harness.connect()
+        reply = client.request("gamble", game="dice", stake=10)
+        assert reply["type"] == "error"
+
+        harness.spawn_monster("innkeeper")
+        harness.tick()
+        inventory.gold = 100
+        reply = client.request("gamble", game="dice", stake=10)
+
+        played = reply["round"]
+        assert reply["gold"] == (110 if played["won"] else 90)
+        assert deal("dice", played["seed"]).to_dict() == played
+        entry = client.request("audit_log", action="gamble")["entries"][0]
+        assert entry["target"] == "Innkeeper"
+        assert entry["details"]["seed"] == played["seed"]
+        assert client.request("gamble", game="dice", stake=500)["type"] == "error"
+
+    def test_wallet_exchange_and_tokens(self, harness, tmp_path):
+        """Test changing coins and that event tokens outlast the session."""
+        from entities.components import Inventory
+        from testutil import GameHarness
+
+        engine = harness.engine
+        inventory = engine.entity_manager.get_component(harness.player, Inventory)
+        inventory.gold = 3
+        client = harness.connect()
+
+        reply = client.request("exchange", coin="gold", into="silver", amount=2)
+        assert reply["type"] == "wallet" and reply["gold"] == 1
+        assert reply["currencies"]["silver"]["amount"] == 200
+
+        assert engine.grant_currency(harness.player, {"event_token": 4}, "event")
+        assert not engine.spend_currency(harness.player, {"event_token": 5}, "event")
+        engine.save_player_profile()
+
+        later = GameHarness(str(tmp_path))
+        wallet = later.connect().request("wallet")
+        assert wallet["currencies"]["event_token"]["amount"] == 4
+        assert "silver" not in wallet["currencies"]
+
+    def test_chat_on_a_channel(self, harness):
+        """Test that only members speak on a channel, by request or typed."""
+        engine = harness.engine
+        client = harness.connect()
+
+        reply = client.request("channel_say", name="trade", text="selling ore")
+        assert reply["type"] == "error"
+
+        reply = client.request("channel_join", name="trade")
+        joined = {c["name"]: c["joined"] for c in reply["channels"]}
+        assert joined == {"help": False, "trade": True}
+        reply = client.request("channel_say", name="trade", text="selling ore")
+        assert reply["type"] == "channel_message" and reply["text"] == "selling ore"
+
+        engine.run_text_command("chat trade Iron ore, cheap")
+        assert engine.message_log[-1][0].endswith(": Iron ore, cheap")
+        engine.run_text_command("leave trade")
+        engine.run_text_command("chat trade anyone?")
+        assert "Join trade" in engine.message_log[-1][0]
+
+    def test_describe_mode(self, harness, tmp_path):
+        """Test that describe mode adds descriptions and is kept in the profile."""
+        from testutil import GameHarness
+
+        engine = harness.engine
+        client = harness.connect()
+
+        reply = client.request("describe_mode", enabled=True)
+        assert reply["enabled"] and reply["lines"][0].startswith("You are in ")
+        assert client.request("describe")["lines"] == engine.surroundings()
+
+        later = GameHarness(str(tmp_path))
+        assert later.engine.narrator.enabled
+        later.engine.run_text_command("describe off")
+        assert later.engine.message_log[-1][0] == "Describe mode is off."
+
+    def test_settings_are_kept_and_applied(self, harness, tmp_path):
+        """Test that settings are checked, saved in the profile and change what
+        telnet players are sent."""
+        from testutil import GameHarness
+
+        engine = harness.engine
+        client = harness.connect()
+
+        refused = client.request("set_settings", settings={"update_rate": "fast"})
+        reply = client.request(
+            "set_settings", settings={"update_rate": "text", "chat_filter": ["boss"]}
+        )
+
+        assert refused["type"] == "error"
+        assert reply["changed"] == ["update_rate", "chat_filter"]
+        assert client.request("settings")["settings"]["chat_filter"] == ["boss"]
+        assert engine.ansi_view() == ""
+        assert engine.settings.filter_chat("the boss is up") == "the **** is up"
+
+        client.type("set auto_loot on")
+        later = GameHarness(str(tmp_path))
+        assert later.engine.settings["auto_loot"] is True
+        assert later.engine.settings["update_rate"] == "text"
@@ -0,0 +1,68 @@
+"""
+Tests for latency tracking and the round trip shown for telnet sessions.
+"""
+
+import socket
+import time
+
+import pytest
+
+from systems.latency import LatencyTracker, tcp_rtt_ms
+from systems.telnet_gateway import TelnetGateway
+
+
+class TestLatency:
+    """Test reported round trips are smoothed per player and sessions show theirs."""
+
+    def test_first_sample_then_smoothing(self):
+        """Test that the first report is taken as is and later ones are smoothed."""
+        tracker = LatencyTracker()
+
+        tracker.record("hero", 80)
+        assert tracker.rtt_ms("hero") == 80
+        tracker.record("hero", 160)
+
+        assert tracker.rtt_ms("hero") == 90
+        assert tracker.rtt_ms("nobody") is None
+        summary = tracker.summary()["hero"]
+        assert summary["samples"] == 2
+        assert summary["jitter_ms"] == 45
+
+    def test_nonsense_reports_are_refused(self):
+        """Test that negative or huge round trips raise instead of skewing the average."""
+        tracker = LatencyTracker()
+
+        for bad in (-1, 10**9, "fast"):
+            with pytest.raises(ValueError):
+                tracker.record("hero", bad)
+        assert tracker.summary() == {}
+
+    def test_tcp_rtt(self):
+        """Test that a connected TCP socket's round trip is a number, where available."""
+        server = socket.create_server(("127.0.0.1", 0))
+        client = socket.create_connection(server.getsockname(), timeout=5)
+        try:
+            rtt = tcp_rtt_ms(client)
+        finally:
+            client.close()
+            server.close()
+
+        assert rtt is None or rtt >= 0
+
+    def test_who_shows_round_trip(self):
+        """Test that each telnet session in who carries an rtt_ms entry."""
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello")
+        gateway.start()
+        try:
+            client = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            deadline = time.time() + 5
+            received = b""
+            while b"hello" not in received and time.time() < deadline:
+                received += client.recv(1024)
+            sessions = gateway.who()
+            client.close()
+        finally:
+            gateway.stop()
+
+        assert len(sessions) == 1
+        assert "rtt_ms" in sessions[0]
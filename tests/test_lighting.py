@@ -0,0 +1,67 @@
+"""
+Tests for light levels and lit visibility.
+"""
+
+import numpy as np
+import pytest
+
+from world.lighting import (
+    MIN_VISIBLE_LIGHT,
+    ambient_light,
+    compute_light,
+    lit_visibility,
+)
+from world.map import GameMap, TILE_GRASS, TILE_LAVA
+
+
+def open_field(width=40, height=40):
+    game_map = GameMap(width, height)
+    game_map.tiles[:, :] = TILE_GRASS
+    return game_map
+
+
+class TestLighting:
+    """Test light from the sky, torches and glowing tiles."""
+
+    def test_no_daylight_underground(self):
+        """Test that the sky does not light tiles below ground."""
+        assert ambient_light(1.0, underground=True) == 0.0
+        assert ambient_light(0.6, underground=False) == 0.6
+
+    def test_torch_falls_off_with_distance(self):
+        """Test that a light source is brightest at its tile and fades out past its radius."""
+        light_map = compute_light(open_field(), 20, 20, 0.0, [(20, 20, 4, 1.0)], window=10)
+
+        assert light_map.at(20, 20) == 1.0
+        assert 0.0 < light_map.at(23, 20) < light_map.at(21, 20)
+        assert light_map.at(26, 20) == 0.0
+
+    def test_ambient_outside_window(self):
+        """Test that tiles outside the computed window get the ambient level."""
+        light_map = compute_light(open_field(), 20, 20, 0.2, [], window=5)
+
+        assert light_map.at(0, 0) == pytest.approx(0.2)
+
+    def test_lava_glows(self):
+        """Test that glowing tiles light their surroundings."""
+        game_map = open_field()
+        game_map.tiles[20, 24] = TILE_LAVA
+
+        light_map = compute_light(game_map, 20, 20, 0.0, [], window=10)
+
+        assert light_map.at(24, 20) == 1.0
+        assert light_map.at(22, 20) > 0.0
+
+    def test_dark_tiles_hidden(self):
+        """Test that only lit tiles in the field of view are visible."""
+        game_map = open_field()
+        fov = np.ones((game_map.height, game_map.width), dtype=bool)
+        light_map = compute_light(game_map, 20, 20, 0.0, [(20, 20, 2, 1.0)], window=10)
+
+        visible = lit_visibility(fov, light_map)
+
+        assert visible[20, 20]
+        assert visible[20, 21]
+        assert not visible[20, 25]
+        assert light_map.at(20, 25) < MIN_VISIBLE_LIGHT
+
@@ -0,0 +1,58 @@
+"""
+Tests for shrinking views while the server is under load.
+"""
+
+from systems.load_scaling import ViewScaler
+
+
+def busy_second(scaler, tick_seconds):
+    """Run one second of 20 ticks that each took tick_seconds."""
+    changed = False
+    for _ in range(20):
+        scaler.record_tick(tick_seconds)
+        changed = scaler.update(0.05) or changed
+    return changed
+
+
+class TestViewScaler:
+    """Test stepping the view scale down under load and back up after."""
+
+    def test_slow_ticks_shrink_views(self):
+        """Test that long ticks take a step off each second down to the floor."""
+        scaler = ViewScaler({"tick_ms": 10, "min_scale": 0.7, "step": 0.1})
+
+        for _ in range(5):
+            busy_second(scaler, 0.05)
+
+        assert scaler.scale == 0.7
+        assert scaler.reductions == 3
+        assert scaler.scaled(40, 9) == 28
+        assert scaler.scaled(10, 9) == 9
+
+    def test_recovers_only_after_calm(self):
+        """Test that views grow back a step only after recover_seconds of calm."""
+        scaler = ViewScaler({"tick_ms": 10, "step": 0.25, "recover_seconds": 3})
+        busy_second(scaler, 0.05)
+        assert scaler.scale == 0.75
+
+        grew = [busy_second(scaler, 0.0) for _ in range(5)]
+
+        # Three calm seconds earn the step back; at full size it stops there
+        assert grew.count(True) == 1
+        assert scaler.scale == 1.0
+
+    def test_client_traffic_shrinks_views(self):
+        """Test that bandwidth per client over the limit counts as load."""
+        scaler = ViewScaler({"client_kb_per_second": 1})
+        scaler.record_sent(4096, 2)
+
+        assert scaler.update(1.0)
+        assert scaler.status()["client_bytes_per_second"] == 2048
+        assert scaler.scale == 0.9
+
+    def test_disabled(self):
+        """Test that a disabled scaler keeps full views."""
+        scaler = ViewScaler({"enabled": False, "tick_ms": 1})
+        busy_second(scaler, 1.0)
+
+        assert scaler.scale == 1.0
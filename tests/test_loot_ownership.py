@@ -0,0 +1,87 @@
+"""
+Tests for kill credit and the window in which drops belong to the killer.
+"""
+
+from entities.components import LootOwner, Monster, Name, PartyMember, Player
+from entities.loot_system import LootSystem
+
+
+def make_world(entity_manager):
+    """A loot system, two players (one with a companion) and a monster."""
+    players = []
+    for name in ("Rook", "Ash"):
+        eid = entity_manager.create_entity()
+        entity_manager.add_component(eid, Player())
+        entity_manager.add_component(eid, Name(value=name))
+        players.append(eid)
+    companion = entity_manager.create_entity()
+    entity_manager.add_component(companion, PartyMember(leader=players[0]))
+    monster = entity_manager.create_entity()
+    entity_manager.add_component(monster, Monster())
+    return LootSystem(entity_manager, window=30.0), players, companion, monster
+
+
+class TestKillCredit:
+    """Test who is credited with a kill."""
+
+    def test_top_damage_wins(self, entity_manager):
+        """Test that the most damage earns the kill, not the last blow."""
+        loot, (rook, ash), _, monster = make_world(entity_manager)
+
+        loot.record_damage(monster, rook, 30)
+        loot.record_damage(monster, ash, 5)
+
+        assert loot.kill_credit(monster, killer=ash) == rook
+
+    def test_party_damage_counts_for_the_leader(self, entity_manager):
+        """Test that a companion's hits are added to its leader's tally."""
+        loot, (rook, ash), companion, monster = make_world(entity_manager)
+
+        loot.record_damage(monster, rook, 10)
+        loot.record_damage(monster, ash, 15)
+        loot.record_damage(monster, companion, 10)
+
+        assert loot.kill_credit(monster) == rook
+
+    def test_ties_and_untouched_monsters(self, entity_manager):
+        """Test that ties go to the first hit and no damage falls back to the killer."""
+        loot, (rook, ash), _, monster = make_world(entity_manager)
+        other = entity_manager.create_entity()
+        entity_manager.add_component(other, Monster())
+
+        assert loot.kill_credit(monster, killer=ash) == ash
+        loot.record_damage(monster, ash, 10)
+        loot.record_damage(monster, rook, 10)
+        loot.record_damage(monster, other, 50)
+
+        assert loot.kill_credit(monster) == ash
+        assert loot.kill_credit(other, killer=other) is None
+
+
+class TestLootOwnership:
+    """Test reserving drops for the credited party."""
+
+    def test_reserved_then_free(self, entity_manager):
+        """Test that only the owner's party may take a drop until the window ends."""
+        loot, (rook, ash), companion, _ = make_world(entity_manager)
+        item = entity_manager.create_entity()
+
+        loot.claim(item, rook, now=100.0)
+
+        assert loot.may_take(item, rook, 110.0)
+        assert loot.may_take(item, companion, 110.0)
+        assert not loot.may_take(item, ash, 110.0)
+        assert loot.may_take(item, ash, 130.0)
+
+    def test_ownership_payload(self, entity_manager):
+        """Test the ownership details sent with item updates."""
+        loot, (rook, _), _, _ = make_world(entity_manager)
+        item = entity_manager.create_entity()
+        unclaimed = entity_manager.create_entity()
+
+        loot.claim(item, rook, now=100.0)
+
+        assert entity_manager.get_component(item, LootOwner).owner == rook
+        assert loot.ownership(item, 110.0) == {"owner": "Rook", "free_in": 20.0}
+        assert loot.ownership(item, 131.0) == {"owner": None, "free_in": 0}
+        assert loot.ownership(unclaimed, 110.0) == {"owner": None, "free_in": 0}
@@ -0,0 +1,61 @@
+"""
+Tests for scheduled maintenance restarts.
+"""
+
+from datetime import datetime
+
+from systems.maintenance import ScheduledRestart, describe, next_daily
+
+
+class FakeClock:
+    def __init__(self, now=1000.0):
+        self.now = now
+
+    def __call__(self):
+        return self.now
+
+
+class TestScheduledRestart:
+    """Test the restart countdown, its warnings and the login cut-off."""
+
+    def test_warnings_count_down(self):
+        """Test that each warning is given once, as its time comes."""
+        clock = FakeClock()
+        restart = ScheduledRestart(clock.now + 400, "Patch day.", clock=clock)
+
+        assert restart.warnings_due() == []
+        clock.now += 100
+        warning = "The server restarts in 5 minutes. Patch day."
+        assert restart.warnings_due() == [warning]
+        assert restart.warnings_due() == []
+        clock.now += 240
+        assert restart.warnings_due() == ["The server restarts in 1 minute. Patch day."]
+        clock.now += 55  # Slept through the 30 and 10 second warnings
+        assert restart.warnings_due() == [restart.message(5)]
+        assert not restart.expired
+        clock.now += 5
+        assert restart.expired
+
+    def test_logins_close_near_the_deadline(self):
+        """Test that logins stay open until the last stretch before the restart."""
+        clock = FakeClock()
+        restart = ScheduledRestart(clock.now + 120, close_logins=60, clock=clock)
+
+        assert not restart.logins_closed
+        clock.now += 60
+        assert restart.logins_closed
+
+    def test_describe(self):
+        """Test that durations read naturally."""
+        assert describe(600) == "10 minutes"
+        assert describe(60) == "1 minute"
+        assert describe(90) == "90 seconds"
+        assert describe(1) == "1 second"
+
+    def test_next_daily(self):
+        """Test that a daily restart lands on the next matching local time."""
+        morning = datetime(2026, 3, 10, 4, 30).timestamp()
+        evening = datetime(2026, 3, 10, 18, 0).timestamp()
+
+        assert next_daily("05:00", morning) == datetime(2026, 3, 10, 5, 0).timestamp()
+        assert next_daily("05:00", evening) == datetime(2026, 3, 11, 5, 0).timestamp()
@@ -0,0 +1,160 @@
+"""
+Tests for the admin map editor and the spawners it places.
+"""
+
+import pytest
+
+from world.editor import EditorError, MapEditor, Spawners
+from world.map import TILE_FLOOR, TILE_WALL, TILE_WATER, GameMap
+from world.poi import POIIndex
+
+
+class FakeWorld:
+    """The parts of the persistent world the editor changes."""
+
+    def __init__(self, game_map):
+        self.world_map = game_map.tiles
+        self.preplaced_entities = []
+        self.pois = POIIndex()
+        self.saves = 0
+
+    def save_world(self):
+        self.saves += 1
+
+
+def make_editor():
+    game_map = GameMap(20, 20)
+    world = FakeWorld(game_map)
+    known = lambda kind, subtype: subtype in ("goblin", "sword", "wall_torch")
+    return MapEditor(world, game_map, known), world
+
+
+class TestMapEditor:
+    """Test region selection, painting, placement and saving."""
+
+    def test_select(self):
+        """Test that a selection describes its tiles and what is placed there."""
+        editor, world = make_editor()
+        world.preplaced_entities.append({"type": "item", "subtype": "sword", "x": 3, "y": 3})
+        world.preplaced_entities.append({"type": "item", "subtype": "sword", "x": 9, "y": 9})
+
+        region = editor.select(2, 2, 3, 2)
+
+        assert region["tiles"] == [[TILE_WALL] * 3] * 2
+        assert [entry["x"] for entry in region["placed"]] == [3]
+        with pytest.raises(EditorError):
+            editor.select(18, 0, 5, 5)
+        with pytest.raises(EditorError):
+            editor.select(0, 0, 100, 1)
+
+    def test_edits_need_a_selection(self):
+        """Test that nothing can be edited before a region is selected."""
+        editor, _ = make_editor()
+
+        with pytest.raises(EditorError):
+            editor.set_tiles(0, 0, ["."])
+
+    def test_set_tiles(self):
+        """Test painting with map characters and tile ids, None leaving tiles alone."""
+        editor, world = make_editor()
+        editor.select(0, 0, 4, 4)
+
+        changed = editor.set_tiles(1, 1, ["..", [TILE_WATER, None]])
+
+        assert changed == 3
+        assert world.world_map[1, 1] == TILE_FLOOR
+        assert world.world_map[2, 1] == TILE_WATER
+        assert world.world_map[2, 2] == TILE_WALL
+        assert editor.dirty
+
+    def test_encoded_tiles(self):
+        """Test reading a region run-length encoded and painting one back."""
+        editor, world = make_editor()
+
+        region = editor.select(0, 0, 4, 2, encoding="rle")
+        region["tiles"]["runs"] = [0, 4, 0, 4]
+        region["tiles"]["palette"] = [TILE_FLOOR]
+        changed = editor.set_tiles(0, 0, region["tiles"])
+
+        assert changed == 8
+        assert (world.world_map[:2, :4] == TILE_FLOOR).all()
+        with pytest.raises(EditorError, match="Encoded tiles"):
+            editor.set_tiles(0, 0, {"encoding": "rle", "runs": [0]})
+        with pytest.raises(EditorError, match="encoding"):
+            editor.select(0, 0, 4, 2, encoding="zip")
+
+    def test_set_tiles_is_all_or_nothing(self):
+        """Test that a bad cell or one outside the region leaves the map untouched."""
+        editor, world = make_editor()
+        editor.select(0, 0, 4, 4)
+
+        for rows in (["..?"], ["....."], [[TILE_FLOOR, 999]]):
+            with pytest.raises(EditorError):
+                editor.set_tiles(0, 0, rows)
+
+        assert (world.world_map[0, :4] == TILE_WALL).all()
+        assert not editor.dirty
+
+    def test_place_and_remove(self):
+        """Test placing content in the region, refusing unknown content."""
+        editor, world = make_editor()
+        editor.select(0, 0, 5, 5)
+
+        monster = editor.place("monster", "goblin", 1, 1)
+        spawner = editor.place("spawner", "goblin", 2, 2, respawn=30)
+
+        assert spawner["respawn"] == 30.0
+        assert world.preplaced_entities == [monster, spawner]
+        with pytest.raises(EditorError):
+            editor.place("monster", "dragon_king", 1, 1)
+        with pytest.raises(EditorError):
+            editor.place("item", "sword", 8, 8)
+        with pytest.raises(EditorError):
+            editor.place("item", "sword", 1, 1, respawn=5)
+
+        assert editor.remove(2, 2, kind="monster") == []
+        assert editor.remove(2, 2) == [spawner]
+        assert world.preplaced_entities == [monster]
+
+    def test_add_poi(self):
+        """Test that points of interest are added to the world's index."""
+        editor, world = make_editor()
+        editor.select(0, 0, 5, 5)
+
+        poi = editor.add_poi("Mill Town", "town", 2, 3)
+
+        assert world.pois.get(poi["id"]).name == "Mill Town"
+        with pytest.raises(EditorError):
+            editor.add_poi("Mill Town", "castle", 2, 3)
+
+    def test_save_only_when_dirty(self):
+        """Test that saving writes the world once per batch of edits."""
+        editor, world = make_editor()
+        editor.select(0, 0, 5, 5)
+
+        assert not editor.save()
+        editor.set_tiles(0, 0, ["."])
+        assert editor.save()
+        assert not editor.save()
+        assert world.saves == 1
+
+
+class TestSpawners:
+    """Test that spawners replace their monster after it has been gone a while."""
+
+    def test_respawn(self):
+        """Test the timer only runs while the monster is dead."""
+        spawners = Spawners()
+        entry = {"type": "spawner", "subtype": "goblin", "x": 0, "y": 0, "respawn": 10}
+        spawners.track(entry, 7)
+        alive = {7}
+
+        assert spawners.due(20, alive.__contains__) == []
+        alive.clear()
+        assert spawners.due(6, alive.__contains__) == []
+        (spawner,) = spawners.due(4, alive.__contains__)
+        spawners.respawned(spawner, 8)
+
+        assert spawner["eid"] == 8
+        assert spawners.forget([entry]) == [spawner]
+        assert spawners.due(60, alive.__contains__) == []
@@ -0,0 +1,90 @@
+"""
+Tests for town markets whose vendor prices follow local supply.
+"""
+
+import os
+
+from systems.markets import (
+    MAX_FACTOR,
+    MIN_FACTOR,
+    PRICE_STEP,
+    Market,
+    Markets,
+    regional_bias,
+)
+
+
+class TestMarket:
+    """Test prices drifting with what is sold and bought in a town."""
+
+    def test_selling_floods_the_market(self):
+        """Test that items sold to a town's shops lower its prices."""
+        market = Market("Ashford")
+        before = market.factor("iron_ore")
+        for _ in range(4):
+            market.sold("iron_ore")
+
+        assert market.factor("iron_ore") == before - 4 * PRICE_STEP
+        assert market.buy_price("iron_ore", 100) < round(100 * before)
+
+    def test_buying_makes_goods_scarce(self):
+        """Test that items bought away from a town raise its prices."""
+        market = Market("Ashford")
+        before = market.sell_price("sword", 100)
+        for _ in range(4):
+            market.bought("sword")
+
+        assert market.sell_price("sword", 100) > before
+
+    def test_prices_stay_in_bounds(self):
+        """Test that no glut or shortage pushes prices past the limits."""
+        glut = Market("Ashford", {"torch": 1000})
+        shortage = Market("Ashford", {"torch": -1000})
+
+        assert glut.factor("torch") == MIN_FACTOR
+        assert shortage.factor("torch") == MAX_FACTOR
+        assert glut.buy_price("torch", 1) == 1
+
+    def test_towns_differ(self):
+        """Test that each town has its own prices, the same every time."""
+        biases = {regional_bias(town, "gold_ore") for town in ("A", "B", "C", "D")}
+
+        assert len(biases) > 1
+        assert regional_bias("Ashford", "gold_ore") == regional_bias(
+            "Ashford", "gold_ore"
+        )
+
+
+class TestMarkets:
+    """Test every town's market over time and between sessions."""
+
+    def test_surplus_wears_off(self):
+        """Test that surpluses and shortages ease back as time passes."""
+        markets = Markets(recovery=10.0)
+        markets.market("Ashford").supply = {"torch": 2, "sword": -1}
+
+        markets.update(10.0)
+        assert markets.market("Ashford").supply == {"torch": 1}
+        markets.update(25.0)
+        assert markets.market("Ashford").supply == {}
+
+    def test_state_is_saved(self, tmp_path):
+        """Test that each town's supply is kept between sessions."""
+        path = os.path.join(tmp_path, "markets", "main.json")
+        markets = Markets(path)
+        markets.market("Ashford").sold("iron_ore")
+        markets.market("Brindle").bought("sword")
+        markets.save()
+
+        restored = Markets(path)
+        restored.load()
+        assert restored.market("Ashford").supply == {"iron_ore": 1}
+        assert restored.market("Brindle").supply == {"sword": -1}
+
+    def test_towns_are_separate(self):
+        """Test that a glut in one town leaves another's prices alone."""
+        markets = Markets()
+        before = markets.market("Brindle").factor("iron_ore")
+        markets.market("Ashford").sold("iron_ore")
+
+        assert markets.market("Brindle").factor("iron_ore") == before
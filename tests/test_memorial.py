@@ -0,0 +1,66 @@
+"""
+Tests for the hardcore memorial.
+"""
+
+import os
+import tempfile
+
+from entities.components import Inventory, Level, Name, Reputation, Skills
+from systems.memorial import Memorial, character_record
+
+
+def make_character(entity_manager, name, level, xp=0):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Name(value=name))
+    entity_manager.add_component(eid, Level(current_level=level, current_xp=xp))
+    entity_manager.add_component(eid, Skills())
+    entity_manager.add_component(eid, Inventory(capacity=20, items=[], gold=75))
+    entity_manager.add_component(eid, Reputation(standing={"crown": 250}))
+    return eid
+
+
+class TestMemorial:
+    """Test archiving fallen characters and ranking them."""
+
+    def test_record_captures_character(self, entity_manager):
+        """Test that the archived record keeps the character's progress and profile."""
+        eid = make_character(entity_manager, "Wren", 7, xp=40)
+
+        record = character_record(entity_manager, eid, "drowned", {"reputation": {"crown": 250}})
+
+        assert record["name"] == "Wren"
+        assert record["level"] == 7
+        assert record["xp"] == 40
+        assert record["gold"] == 75
+        assert record["reputation"] == {"crown": 250}
+        assert record["cause"] == "drowned"
+        assert record["profile"] == {"reputation": {"crown": 250}}
+
+    def test_archive_keeps_every_character(self, entity_manager):
+        """Test that archived characters are appended, never replaced."""
+        with tempfile.TemporaryDirectory() as tmp:
+            memorial = Memorial(os.path.join(tmp, "memorial.json"))
+            first = make_character(entity_manager, "Wren", 3)
+            second = make_character(entity_manager, "Rook", 5)
+
+            memorial.archive(character_record(entity_manager, first, "slain by Goblin"))
+            memorial.archive(character_record(entity_manager, second, "drowned"))
+
+            characters = memorial.characters()
+            assert [c["name"] for c in characters] == ["Wren", "Rook"]
+            assert [c["character"] for c in characters] == [1, 2]
+
+    def test_leaderboard_ranks_by_level(self, entity_manager):
+        """Test that the leaderboard lists the highest characters first, up to its size."""
+        with tempfile.TemporaryDirectory() as tmp:
+            memorial = Memorial(os.path.join(tmp, "memorial.json"), size=2)
+            ranks = []
+            for name, level in (("Wren", 3), ("Rook", 9), ("Ash", 5)):
+                eid = make_character(entity_manager, name, level)
+                ranks.append(memorial.archive(character_record(entity_manager, eid, "died")))
+
+            board = memorial.leaderboard()
+
+            assert ranks == [1, 1, 2]
+            assert board["type"] == "memorial"
+            assert [(e["rank"], e["name"]) for e in board["entries"]] == [(1, "Rook"), (2, "Ash")]
@@ -0,0 +1,51 @@
+"""
+Tests for describe mode's spoken descriptions of the surroundings.
+"""
+
+from systems.narration import MAX_CREATURES, Narrator, Seen, describe, distance_words
+
+
+class TestNarration:
+    """Test turning what the player sees into sentences."""
+
+    def test_distance_words(self):
+        """Test distances and directions in words."""
+        assert distance_words(0, -2) == "two tiles north"
+        assert distance_words(1, 0) == "beside you to the east"
+        assert distance_words(0, 0) == "here"
+        assert distance_words(-12, 12) == "12 tiles southwest"
+
+    def test_nearest_first_with_articles(self):
+        """Test that creatures are listed nearest first, named for speaking."""
+        lines = describe(
+            "Ashford",
+            "cobblestone",
+            [Seen("Orc", 3, 0), Seen("Goblin", 0, -2), Seen("Smithy", 1, 1, True)],
+            [Seen("Iron Sword", 0, 0)],
+            ["north", "west"],
+        )
+
+        assert lines == [
+            "You are in Ashford, on cobblestone.",
+            "Smithy stands beside you to the southeast.",
+            "A goblin stands two tiles north.",
+            "An orc stands three tiles east.",
+            "An iron sword lies here.",
+            "The way is blocked to the north and west.",
+        ]
+
+    def test_crowds_are_counted(self):
+        """Test that creatures past the limit are only counted."""
+        crowd = [Seen("Rat", i, 0) for i in range(1, MAX_CREATURES + 3)]
+
+        lines = describe("the wilds", "grass", crowd, [], [])
+
+        assert lines[-1] == "2 more creatures further off."
+        assert len(lines) == MAX_CREATURES + 2
+
+    def test_changes_since_last(self):
+        """Test that only new sentences are reported after a step."""
+        narrator = Narrator()
+        assert narrator.changes(["A", "B"]) == ["A", "B"]
+        assert narrator.changes(["A", "C"]) == ["C"]
+        assert narrator.changes(["A", "C"]) is None
@@ -0,0 +1,103 @@
+"""
+Tests for tile occupancy rules.
+"""
+
+from core.spatial import SpatialIndex
+from entities.components import Monster, PartyMember, Player, Position
+from entities.occupancy import OccupancyRules
+
+
+def make_player(entity_manager, x, y):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, y))
+    entity_manager.add_component(eid, Player())
+    return eid
+
+
+def make_monster(entity_manager, x, y, ai_type="aggressive"):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, y))
+    entity_manager.add_component(eid, Monster(ai_type=ai_type, name="Goblin"))
+    return eid
+
+
+class TestOccupancyRules:
+    """Test how moves into occupied tiles are resolved."""
+
+    def test_hostile_blocks(self, entity_manager):
+        """Test that a hostile monster blocks the player."""
+        rules = OccupancyRules(entity_manager, SpatialIndex(entity_manager))
+        player = make_player(entity_manager, 0, 0)
+        goblin = make_monster(entity_manager, 1, 0)
+
+        assert rules.check_move(player, 1, 0) == ("block", goblin)
+        assert rules.check_move(goblin, 0, 0) == ("block", player)
+
+    def test_empty_tile_is_free(self, entity_manager):
+        """Test that items and empty tiles never block."""
+        rules = OccupancyRules(entity_manager, SpatialIndex(entity_manager))
+        player = make_player(entity_manager, 0, 0)
+
+        assert rules.check_move(player, 5, 5) == ("free", None)
+
+    def test_party_members_swap(self, entity_manager):
+        """Test that the player swaps places with a companion."""
+        rules = OccupancyRules(entity_manager, SpatialIndex(entity_manager))
+        player = make_player(entity_manager, 0, 0)
+        dog = make_monster(entity_manager, 1, 0, ai_type="passive")
+        entity_manager.add_component(dog, PartyMember(leader=player))
+
+        rule, occupant = rules.check_move(player, 1, 0)
+        assert (rule, occupant) == ("swap", dog)
+
+        rules.swap(player, dog)
+        player_pos = entity_manager.get_component(player, Position)
+        dog_pos = entity_manager.get_component(dog, Position)
+        assert (player_pos.x, player_pos.y) == (1, 0)
+        assert (dog_pos.x, dog_pos.y) == (0, 0)
+
+    def test_companion_cannot_push_player(self, entity_manager):
+        """Test that NPCs never displace the player they follow."""
+        rules = OccupancyRules(entity_manager, SpatialIndex(entity_manager))
+        player = make_player(entity_manager, 0, 0)
+        dog = make_monster(entity_manager, 1, 0, ai_type="passive")
+        entity_manager.add_component(dog, PartyMember(leader=player))
+
+        assert rules.check_move(dog, 0, 0) == ("block", player)
+
+    def test_configurable_rules(self, entity_manager):
+        """Test that a relation can be configured to let entities pass."""
+        rules = OccupancyRules(
+            entity_manager, SpatialIndex(entity_manager), {"neutral": "pass"}
+        )
+        player = make_player(entity_manager, 0, 0)
+        make_monster(entity_manager, 1, 0, ai_type="passive")
+
+        assert rules.check_move(player, 1, 0) == ("free", None)
+
+    def test_static_npcs_never_swap(self, entity_manager):
+        """Test that merchants stay put even when swapping is allowed."""
+        rules = OccupancyRules(
+            entity_manager, SpatialIndex(entity_manager), {"neutral": "swap"}
+        )
+        player = make_player(entity_manager, 0, 0)
+        merchant = make_monster(entity_manager, 1, 0, ai_type="static")
+
+        assert rules.check_move(player, 1, 0) == ("block", merchant)
+
+    def test_monsters_kept_out_of_safe_zone(self, entity_manager):
+        """Test that hostiles are kept out of a surface safe zone, and nobody else."""
+        rules = OccupancyRules(entity_manager, SpatialIndex(entity_manager))
+        rules.safe_zone = lambda x, y: x >= 5
+        player = make_player(entity_manager, 4, 0)
+        goblin = make_monster(entity_manager, 4, 1)
+        rabbit = make_monster(entity_manager, 4, 2, ai_type="passive")
+        digger = make_monster(entity_manager, 4, 3)
+        entity_manager.get_component(digger, Position).z = 1
+
+        assert rules.check_move(goblin, 5, 1) == ("block", None)
+        assert rules.check_move(goblin, 3, 1) == ("free", None)
+        assert rules.check_move(player, 5, 0) == ("free", None)
+        assert rules.check_move(rabbit, 5, 2) == ("free", None)
+        assert rules.check_move(digger, 5, 3) == ("free", None)
+        assert rules.blocker_for(goblin)(6, 1)
@@ -0,0 +1,87 @@
+"""
+Tests for the prioritized outbound queue each telnet connection writes from.
+"""
+
+import threading
+
+import pytest
+
+from systems.outbound import BULK, CRITICAL, NORMAL, OutboundQueue
+
+
+def drain(outbox):
+    sent = []
+    outbox.close()
+    while True:
+        data = outbox.get()
+        if data is None:
+            return sent
+        sent.append(data)
+        outbox.done()
+
+
+class TestOutboundQueue:
+    """Test ordering, coalescing and dropping under backpressure."""
+
+    def test_sent_in_order(self):
+        """Test that a reply still follows the view queued before it."""
+        outbox = OutboundQueue()
+        outbox.put(b"view", BULK)
+        outbox.put(b"reply", CRITICAL)
+        outbox.put(b"chat", NORMAL)
+
+        assert drain(outbox) == [b"view", b"reply", b"chat"]
+
+    def test_views_coalesce(self):
+        """Test that only the newest waiting view is kept."""
+        outbox = OutboundQueue()
+        outbox.put(b"view1", BULK)
+        outbox.put(b"reply", CRITICAL)
+        outbox.put(b"view2", BULK)
+
+        assert drain(outbox) == [b"reply", b"view2"]
+        assert outbox.coalesced == 1
+
+    def test_backpressure_drops_bulk_then_normal(self):
+        """Test that a full queue gives up chat before critical messages."""
+        outbox = OutboundQueue(limit=3)
+        outbox.put(b"view", BULK)
+        outbox.put(b"chat1", NORMAL)
+        outbox.put(b"error", CRITICAL)
+        outbox.put(b"chat2", NORMAL)
+        outbox.put(b"reply", CRITICAL)
+        outbox.put(b"kick", CRITICAL)
+
+        assert drain(outbox) == [b"error", b"reply", b"kick"]
+        assert outbox.dropped == {NORMAL: 2, BULK: 1}
+
+    def test_closed_queue_refuses(self):
+        """Test that nothing is queued once the connection is closing."""
+        outbox = OutboundQueue()
+        outbox.put(b"bye", CRITICAL)
+        outbox.close()
+
+        assert not outbox.put(b"late")
+        assert drain(outbox) == [b"bye"]
+        with pytest.raises(ValueError):
+            OutboundQueue().put(b"x", "urgent")
+
+    def test_flush_waits_for_the_writer(self):
+        """Test that flush returns once the writer has sent everything."""
+        outbox = OutboundQueue()
+        written = []
+
+        def writer():
+            while (data := outbox.get()) is not None:
+                written.append(data)
+                outbox.done()
+
+        thread = threading.Thread(target=writer, daemon=True)
+        thread.start()
+        outbox.put(b"one", CRITICAL)
+        outbox.put(b"two", CRITICAL)
+
+        assert outbox.flush(2.0)
+        assert written == [b"one", b"two"]
+        outbox.close()
+        thread.join(2.0)
@@ -0,0 +1,63 @@
+"""
+Tests for world overview generation.
+"""
+
+import zlib
+
+from world.map import GameMap, TILE_FLOOR, TILE_GRASS, TILE_WATER
+from world.overview import UNEXPLORED, build_overview, fit_scale, to_png
+
+
+class TestOverview:
+    """Test downsampling, fog of war and encoding of the world map."""
+
+    def test_majority_tile_per_block(self):
+        """Test that each cell takes the most common tile of its block."""
+        game_map = GameMap(4, 2)
+        game_map.tiles[:, :] = TILE_GRASS
+        game_map.tiles[0, 0] = TILE_WATER
+        game_map.tiles[:, 2:] = TILE_WATER
+        game_map.tiles[0, 3] = TILE_FLOOR
+
+        overview = build_overview(game_map, 2)
+
+        assert (overview.width, overview.height) == (2, 1)
+        assert overview.cells[0, 0] == TILE_GRASS
+        assert overview.cells[0, 1] == TILE_WATER
+
+    def test_unexplored_blocks_hidden(self):
+        """Test that blocks with no explored tiles stay unexplored."""
+        game_map = GameMap(4, 2)
+        game_map.tiles[:, :] = TILE_GRASS
+        game_map.explored[:, :] = False
+        game_map.explored[1, 3] = True
+
+        overview = build_overview(game_map, 2)
+
+        assert overview.cells[0, 0] == UNEXPLORED
+        assert overview.cells[0, 1] == TILE_GRASS
+
+    def test_partial_blocks_and_fit(self):
+        """Test that map edges are padded and the fit scale covers the map."""
+        game_map = GameMap(5, 3)
+
+        scale = fit_scale(game_map, 2, 2)
+        overview = build_overview(game_map, scale)
+
+        assert scale == 3
+        assert (overview.width, overview.height) == (2, 1)
+
+    def test_message_and_png(self):
+        """Test the compact message rows and the PNG encoding."""
+        game_map = GameMap(2, 2)
+        game_map.tiles[:, :] = TILE_GRASS
+        overview = build_overview(game_map, 1)
+
+        message = overview.to_message()
+        png = to_png(overview, game_map)
+
+        assert message["rows"] == [f"{TILE_GRASS:02x}" * 2] * 2
+        assert png.startswith(b"\x89PNG\r\n\x1a\n")
+        idat = png.index(b"IDAT")
+        length = int.from_bytes(png[idat - 4 : idat], "big")
+        assert len(zlib.decompress(png[idat + 4 : idat + 4 + length])) == 2 * (1 + 2 * 3)
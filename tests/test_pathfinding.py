@@ -0,0 +1,93 @@
+"""
+Tests for A* pathfinding.
+"""
+
+from world.pathfinding import find_path
+
+
+class GridMap:
+    """Minimal map built from rows of text; only '.' is walkable."""
+
+    def __init__(self, rows):
+        self.rows = rows
+        self.width = len(rows[0])
+        self.height = len(rows)
+
+    def is_walkable(self, x, y):
+        return 0 <= x < self.width and 0 <= y < self.height and self.rows[y][x] == "."
+
+
+class TestFindPath:
+    """Test path search over walkable tiles."""
+
+    def test_straight_line(self):
+        """Test an unobstructed orthogonal path."""
+        game_map = GridMap(["....."])
+        path = find_path(game_map, (0, 0), (4, 0))
+
+        assert path == [(1, 0), (2, 0), (3, 0), (4, 0)]
+
+    def test_prefers_diagonal(self):
+        """Test that diagonal steps are used when they shorten the path."""
+        game_map = GridMap(["....", "....", "...."])
+        path = find_path(game_map, (0, 0), (2, 2))
+
+        assert path == [(1, 1), (2, 2)]
+
+    def test_routes_around_walls(self):
+        """Test that walls are avoided."""
+        game_map = GridMap([".#.", ".#.", "..."])
+        path = find_path(game_map, (0, 0), (2, 0))
+
+        assert path is not None
+        assert path[-1] == (2, 0)
+        assert all(game_map.is_walkable(x, y) for x, y in path)
+
+    def test_no_corner_cutting(self):
+        """Test that a diagonal cannot squeeze between two walls."""
+        game_map = GridMap([".#", "#."])
+
+        assert find_path(game_map, (0, 0), (1, 1)) is None
+
+    def test_blocked_callback(self):
+        """Test that occupied tiles are avoided but the goal may be occupied."""
+        game_map = GridMap(["...", "..."])
+        occupied = {(1, 0), (2, 0)}
+
+        path = find_path(game_map, (0, 0), (2, 0), blocked=lambda x, y: (x, y) in occupied)
+
+        assert path is not None
+        assert (1, 0) not in path
+        assert path[-1] == (2, 0)
+
+    def test_unreachable_goal(self):
+        """Test that a wall goal or exhausted budget returns None."""
+        game_map = GridMap(["..#", "...", "..."])
+
+        assert find_path(game_map, (0, 0), (2, 0)) is None
+        assert find_path(game_map, (0, 0), (2, 2), max_nodes=1) is None
+
+    def test_prefers_cheaper_terrain(self):
+        """Test that slow terrain is avoided when a cheap detour exists."""
+        game_map = GridMap(["...", "...", "..."])
+        slow = {(1, 0), (1, 1)}
+
+        path = find_path(
+            game_map,
+            (0, 0),
+            (2, 0),
+            move_cost=lambda x, y: 10.0 if (x, y) in slow else 1.0,
+            allow_diagonal=False,
+        )
+
+        assert not slow & set(path)
+
+    def test_custom_passable(self):
+        """Test that a passable callback can open up non-walkable tiles."""
+        game_map = GridMap([".~."])
+
+        assert find_path(game_map, (0, 0), (2, 0)) is None
+        path = find_path(
+            game_map, (0, 0), (2, 0), passable=lambda x, y: 0 <= x < 3 and y == 0
+        )
+        assert path == [(1, 0), (2, 0)]
@@ -2,8 +2,10 @@
 Tests for Phase 3: World Expansion - Chunk System and Procedural Generation.
 """
 
+import pickle
+
 from world.chunk_manager import Chunk
-from world.persistent_world import PersistentWorld
+from world.persistent_world import WORLD_FORMAT, PersistentWorld
 
 
 class TestChunkCreation:
@@ -308,6 +310,29 @@ class TestPersistentWorld:
         # Maps should be identical
         assert (world1.world_map == world2.world_map).all()
 
+    def test_older_save_regenerated(self, tmp_path):
+        """Test that a world saved before waypoints, towns and caves is made again."""
+        save_file = str(tmp_path / "test_world.pkl")
+        world1 = PersistentWorld(world_width=50, world_height=50, world_file=save_file)
+        world1.generate_world()
+
+        with open(save_file, "rb") as f:
+            data = pickle.load(f)
+        old = {
+            key: data[key]
+            for key in ("world_map", "biome_map", "areas", "world_width", "world_height")
+        }
+        with open(save_file, "wb") as f:
+            pickle.dump(old, f)
+
+        world2 = PersistentWorld(world_width=50, world_height=50, world_file=save_file)
+        world2.load_world()
+
+        with open(save_file, "rb") as f:
+            data = pickle.load(f)
+        assert data["format"] == WORLD_FORMAT
+        assert {"waypoints", "portals", "settlements", "caves"} <= set(data)
+
 
 class TestChunkEntities:
     """Test entity management in chunks."""
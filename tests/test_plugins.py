@@ -0,0 +1,116 @@
+"""
+Tests for plugin loading and hooks.
+"""
+
+import sys
+import types
+
+from config import CONFIG
+from core import recovery
+from core.plugins import Plugin, PluginManager
+
+
+class Recorder(Plugin):
+    name = "recorder"
+
+    def __init__(self):
+        self.events = []
+
+    def register(self, engine, plugins):
+        engine.commands.request("ping", lambda: {"type": "pong"}, "Answer with pong")
+        plugins.text_command("Wave", self.wave)
+
+    def wave(self, engine, args):
+        self.events.append(("wave", args))
+
+    def on_login(self, engine, player_id):
+        self.events.append(("login", player_id))
+
+    def on_tick(self, engine, dt):
+        self.events.append(("tick", dt))
+
+    def on_chat(self, engine, sender, text):
+        if "spam" in text:
+            return False
+        return text.upper() if text.startswith("!") else None
+
+
+class Broken(Plugin):
+    name = "broken"
+
+    def on_tick(self, engine, dt):
+        raise RuntimeError("plugin bug")
+
+    def on_chat(self, engine, sender, text):
+        raise RuntimeError("plugin bug")
+
+
+def fake_engine():
+    from core.commands import CommandRegistry
+
+    return types.SimpleNamespace(commands=CommandRegistry(), logged=[])
+
+
+class TestPlugins:
+    """Test that plugins extend commands and hear about game events."""
+
+    def test_hooks_and_registration(self):
+        """Test that a plugin's request, command and hooks all take effect."""
+        engine, plugins, recorder = fake_engine(), PluginManager(), Recorder()
+        plugins.add(recorder, engine)
+
+        plugins.login(engine, 7)
+        plugins.tick(engine, 0.1)
+        handled = plugins.run_text_command(engine, "wave", ["hi"])
+
+        assert engine.commands.handle_request({"type": "ping"}) == {"type": "pong"}
+        assert handled and not plugins.run_text_command(engine, "dance", [])
+        assert recorder.events == [("login", 7), ("tick", 0.1), ("wave", ["hi"])]
+
+    def test_chat_can_be_blocked_or_rewritten(self):
+        """Test that on_chat may drop, replace or pass a line through."""
+        engine, plugins = fake_engine(), PluginManager()
+        plugins.add(Recorder(), engine)
+
+        assert plugins.chat(engine, "Wren", "hello") == "hello"
+        assert plugins.chat(engine, "Wren", "!loud") == "!LOUD"
+        assert plugins.chat(engine, "Wren", "buy spam") is None
+
+    def test_failing_plugin_is_isolated(self, monkeypatch, tmp_path):
+        """Test that a plugin that raises is logged and the others still run."""
+        monkeypatch.setattr(recovery, "DEBUG_LOG", str(tmp_path / "debug.log"))
+        engine, plugins, recorder = fake_engine(), PluginManager(), Recorder()
+        plugins.add(Broken(), engine)
+        plugins.add(recorder, engine)
+
+        plugins.tick(engine, 0.1)
+
+        assert plugins.chat(engine, "Wren", "hello") == "hello"
+        assert recorder.events == [("tick", 0.1)]
+        assert "plugin bug" in (tmp_path / "debug.log").read_text()
+
+    def test_load_by_module_name(self, monkeypatch, tmp_path):
+        """Test loading plugins from config module names, skipping bad ones."""
+        monkeypatch.setattr(recovery, "DEBUG_LOG", str(tmp_path / "debug.log"))
+        module = types.ModuleType("test_plugin_module")
+        module.plugin = Recorder()
+        monkeypatch.setitem(sys.modules, "test_plugin_module", module)
+        engine, plugins = fake_engine(), PluginManager()
+
+        failed = plugins.load(["test_plugin_module", "no_such_plugin"], engine)
+
+        assert failed == ["no_such_plugin"]
+        assert plugins.plugins == [module.plugin]
+
+    def test_chat_filter_plugin(self, monkeypatch):
+        """Test the bundled chat filter masks configured words."""
+        from plugins.chat_filter import ChatFilter
+
+        monkeypatch.setitem(CONFIG.plugins, "chat_filter_words", ["darn"])
+        engine, plugins, chat_filter = fake_engine(), PluginManager(), ChatFilter()
+        plugins.add(chat_filter, engine)
+
+        assert plugins.chat(engine, "Wren", "Darn it, darned rats") == (
+            "**** it, darned rats"
+        )
+        assert chat_filter.filtered == 1
@@ -0,0 +1,50 @@
+"""
+Tests for the points of interest index.
+"""
+
+from world.poi import POIIndex
+
+
+class TestPOIIndex:
+    """Test registration, search and persistence of points of interest."""
+
+    def test_unique_ids(self):
+        """Test that places sharing a name get distinct ids."""
+        index = POIIndex()
+        first = index.add("North Shrine", "shrine", 0, 0)
+        second = index.add("North Shrine", "shrine", 50, 0)
+
+        assert first.id == "shrine:north_shrine"
+        assert second.id != first.id
+        assert index.get(second.id) is second
+
+    def test_search_by_text_and_kind(self):
+        """Test filtering by name and kind, nearest first."""
+        index = POIIndex()
+        index.add("Far Town", "town", 100, 0)
+        index.add("Near Town", "town", 10, 0)
+        index.add("Town Ruins", "landmark", 5, 0)
+
+        towns = index.search("town", kind="town", near=(0, 0))
+
+        assert [poi.name for poi in towns] == ["Near Town", "Far Town"]
+        assert len(index.search("ruins")) == 1
+
+    def test_within_radius(self):
+        """Test that only places in range on the same Z-level are returned."""
+        index = POIIndex()
+        index.add("Camp", "landmark", 3, 4)
+        index.add("Cave", "dungeon", 3, 4, z=-1)
+        index.add("Keep", "landmark", 30, 40)
+
+        assert [poi.name for poi in index.within(0, 0, 5)] == ["Camp"]
+
+    def test_round_trip(self):
+        """Test saving and restoring the index as plain data."""
+        index = POIIndex()
+        index.add("Town Square", "town", 1, 2, waypoint="Town Square")
+
+        restored = POIIndex.from_list(index.to_list())
+
+        poi = restored.get("town:town_square")
+        assert (poi.x, poi.y, poi.waypoint) == (1, 2, "Town Square")
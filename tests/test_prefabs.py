@@ -0,0 +1,127 @@
+"""
+Tests for prefab structures stamped during world generation.
+"""
+
+import random
+
+import numpy as np
+import pytest
+
+from world.map import TILE_DOOR, TILE_FLOOR, TILE_GRASS, TILE_WALL, TILE_WATER
+from world.prefabs import (
+    PREFABS_PATH,
+    PrefabError,
+    fits,
+    load_prefabs,
+    parse_prefab,
+    place_prefabs,
+    stamp,
+)
+
+HUT = {
+    "name": "Hut",
+    "biomes": ["plains"],
+    "layout": "###\n#.+\n ##",
+    "fg_layout": "\n t",
+}
+
+
+def make_world(width=100, height=100, biome="plains"):
+    world_map = np.full((height, width), TILE_GRASS, dtype=np.uint8)
+    biome_map = np.full((height, width), biome, dtype=object)
+    return world_map, biome_map
+
+
+class TestPrefabFormat:
+    """Test parsing prefab definitions."""
+
+    def test_parse(self):
+        """Test that rows, size and the entity layout are read as drawn."""
+        prefab = parse_prefab(HUT)
+
+        assert (prefab.width, prefab.height) == (3, 3)
+        assert prefab.fg_layout == ["", " t"]
+        assert prefab.weight == 1.0
+
+    @pytest.mark.parametrize(
+        "change",
+        [
+            {"biomes": []},
+            {"weight": 0},
+            {"layout": "#?#"},
+            {"fg_layout": "\n Z"},
+            {"layout": "#" * 30},
+            {"fg_layout": "\n\n\n t"},
+        ],
+    )
+    def test_malformed(self, change):
+        """Test that malformed definitions are refused."""
+        with pytest.raises(PrefabError):
+            parse_prefab(dict(HUT, **change))
+
+    def test_library(self):
+        """Test that every shipped prefab parses."""
+        assert len(load_prefabs(PREFABS_PATH)) >= 5
+
+
+class TestStamping:
+    """Test fitting and stamping prefabs into the world."""
+
+    def test_stamp(self):
+        """Test that tiles are written, spaces keep terrain and entities are offset."""
+        world_map, _ = make_world(10, 10)
+
+        entities = stamp(world_map, parse_prefab(HUT), 4, 5)
+
+        assert world_map[5, 4] == TILE_WALL
+        assert world_map[6, 5] == TILE_FLOOR
+        assert world_map[6, 6] == TILE_DOOR
+        assert world_map[7, 4] == TILE_GRASS
+        assert entities == [{"type": "light", "subtype": "wall_torch", "x": 5, "y": 6}]
+
+    def test_fits(self):
+        """Test that prefabs stay in bounds, off water and out of excluded biomes."""
+        world_map, biome_map = make_world(10, 10)
+        prefab = parse_prefab(HUT)
+        world_map[1, 1] = TILE_WATER
+        biome_map[8, 8] = "town"
+
+        assert fits(world_map, biome_map, prefab, 5, 0)
+        assert not fits(world_map, biome_map, prefab, 0, 0)
+        assert not fits(world_map, biome_map, prefab, 8, 0)
+        assert not fits(world_map, biome_map, prefab, 7, 7)
+        # The kept corner may sit on water
+        world_map[2, 5] = TILE_WATER
+        assert fits(world_map, biome_map, prefab, 5, 0)
+
+    def test_density_and_biomes(self):
+        """Test that every chunk of a certain biome gets one and others none."""
+        prefabs = [parse_prefab(HUT)]
+        world_map, biome_map = make_world()
+
+        rng = random.Random(1)
+        _, placed = place_prefabs(world_map, biome_map, prefabs, {"plains": 1.0}, rng)
+        assert len(placed) == 4
+
+        world_map, biome_map = make_world(biome="desert")
+        _, placed = place_prefabs(world_map, biome_map, prefabs, {"default": 1.0}, rng)
+        assert placed == []
+
+    def test_skip_and_determinism(self):
+        """Test that skipped chunks are left alone and a seed repeats its layout."""
+        prefabs = [parse_prefab(HUT)]
+        runs = []
+        for _ in range(2):
+            world_map, biome_map = make_world()
+            runs.append(
+                place_prefabs(
+                    world_map, biome_map, prefabs, {"default": 1.0}, random.Random(7),
+                    skip={(0, 0)},
+                )
+            )
+
+        entities, placed = runs[0]
+        assert runs[0] == runs[1]
+        assert len(placed) == 3
+        assert all(x >= 50 or y >= 50 for _, x, y in placed)
+        assert len(entities) == 3
@@ -0,0 +1,119 @@
+"""
+Tests for named regions on the chunk grid.
+"""
+
+from world.regions import Region, RegionMap
+
+
+class TestRegionMap:
+    """Test region lookup, priority and biome fallback."""
+
+    def test_region_lookup_by_chunk(self):
+        """Test that positions resolve to the region covering their chunk."""
+        regions = RegionMap(
+            [Region(id="town", name="Town", chunks=[0, 0, 1, 1])],
+            center_x=100,
+            center_y=100,
+            chunk_size=10,
+        )
+
+        assert regions.region_at(100, 100).id == "town"
+        assert regions.region_at(119, 119).id == "town"
+        assert regions.region_at(120, 100).id != "town"
+        assert regions.region_at(99, 100).id != "town"
+
+    def test_first_region_wins(self):
+        """Test that earlier definitions take priority where regions overlap."""
+        regions = RegionMap(
+            [
+                Region(id="inner", name="Inner", chunks=[0, 0, 0, 0]),
+                Region(id="outer", name="Outer", chunks=[-1, -1, 1, 1]),
+            ],
+            center_x=0,
+            center_y=0,
+            chunk_size=10,
+        )
+
+        assert regions.region_at(5, 5).id == "inner"
+        assert regions.region_at(-5, -5).id == "outer"
+
+    def test_biome_fallback(self):
+        """Test that undefined areas are named after their biome."""
+        regions = RegionMap([], 0, 0, 10, biome_lookup=lambda x, y: "dense_forest")
+
+        region = regions.region_at(3, 3)
+
+        assert region.id == "biome:dense_forest"
+        assert region.name == "The Dense Forest"
+        assert not region.pvp
+        assert region.spawns is None
+        assert region.ambience == "dense_forest" and region.music == ""
+
+    def test_biome_soundscapes(self):
+        """Test that biome regions take their ambience and music from the soundscapes."""
+        soundscapes = {"snow": {"ambience": "howling_blizzard", "music": "frostbound"}}
+        regions = RegionMap([], 0, 0, 10, lambda x, y: "snow", soundscapes)
+
+        info = regions.region_at(3, 3).info()
+
+        assert (info["ambience"], info["music"]) == ("howling_blizzard", "frostbound")
+
+    def test_safe_spawn_region(self):
+        """Test that new players start at the first spawn region's centre."""
+        regions = RegionMap(
+            [
+                Region(id="wilds", name="Wilds", chunks=[-1, -1, 1, 1], spawn=True),
+                Region(
+                    id="town", name="Town", chunks=[0, 0, 1, 0], safe=True, spawn=True
+                ),
+            ],
+            center_x=100,
+            center_y=100,
+            chunk_size=10,
+        )
+
+        assert regions.spawn_point() == (105, 105)
+        regions.regions.reverse()
+        assert regions.spawn_point() == (110, 105)
+        assert regions.is_safe(110, 105) and not regions.is_safe(99, 105)
+        assert RegionMap([], 0, 0).spawn_point() is None
+
+    def test_zone_info_payload(self):
+        """Test the zone_info payload carries the region's rules."""
+        region = Region(
+            id="wilds",
+            name="Wilds",
+            pvp=True,
+            spawns={"goblin": 5},
+            ambience="wind",
+            music="lost_roads",
+        )
+
+        info = region.info()
+
+        assert info == {
+            "id": "wilds",
+            "name": "Wilds",
+            "pvp": True,
+            "safe": False,
+            "ambience": "wind",
+            "music": "lost_roads",
+            "spawns": {"goblin": 5},
+        }
+
+    def test_content_file_loads(self):
+        """Test that the shipped regions file parses into regions."""
+        regions = RegionMap.from_content(0, 0)
+
+        assert regions.regions
+        assert regions.region_at(0, 0).id == "starter_town"
+        assert regions.region_at(-1, -1).id == "heartland"
+        assert regions.region_at(0, 0).music == "hearthside"
+        assert regions.soundscapes["ocean"]["music"] == "open_sea"
+
+    def test_region_catches(self):
+        """Test that a region may name its own catches, and others leave it to the biome."""
+        regions = {region.id: region for region in RegionMap.from_content(0, 0).regions}
+
+        assert regions["frozen_reach"].catches["ice_char"] == 70
+        assert regions["heartland"].catches is None
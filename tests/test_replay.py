@@ -0,0 +1,88 @@
+"""
+Tests for replay recording and playback.
+"""
+
+import os
+import tempfile
+
+import pytest
+
+from input.handler import InputEvent
+from systems.replay import ReplayPlayer, ReplayRecorder, load_replay
+
+
+def record_session(path):
+    recorder = ReplayRecorder(path, seed=42, profile={"reputation": {"crown": 5}})
+    recorder.event(0, "Welcome to the dungeon!")
+    recorder.input(3, InputEvent("move", dx=1))
+    recorder.event(3, "You step east.")
+    recorder.request(3, {"type": "text_command", "text": "look"})
+    recorder.input(7, InputEvent("pickup"))
+    recorder.close()
+
+
+class TestReplay:
+    """Test writing, loading and stepping through replay logs."""
+
+    def test_round_trip(self):
+        """Test that the seed, profile and entries come back as recorded."""
+        with tempfile.TemporaryDirectory() as tmp:
+            path = os.path.join(tmp, "replays", "session.jsonl")
+            record_session(path)
+
+            replay = load_replay(path)
+
+        assert replay.seed == 42
+        assert replay.profile == {"reputation": {"crown": 5}}
+        assert [entry["kind"] for entry in replay.entries] == [
+            "event", "input", "event", "request", "input"
+        ]
+        assert InputEvent(**replay.entries[1]["event"]) == InputEvent("move", dx=1)
+
+    def test_inbound_entries_play_on_their_tick(self):
+        """Test that inputs and requests are handed back once their tick is reached."""
+        with tempfile.TemporaryDirectory() as tmp:
+            path = os.path.join(tmp, "session.jsonl")
+            record_session(path)
+            player = ReplayPlayer(load_replay(path))
+
+        assert player.due(2) == []
+        assert [entry["kind"] for entry in player.due(3)] == ["input", "request"]
+        assert player.due(3) == []
+        assert not player.finished
+        assert player.due(10)[0]["event"]["action_type"] == "pickup"
+        assert player.finished
+
+    def test_first_divergence_is_reported(self):
+        """Test that events matching the recording pass and the first mismatch is kept."""
+        with tempfile.TemporaryDirectory() as tmp:
+            path = os.path.join(tmp, "session.jsonl")
+            record_session(path)
+            player = ReplayPlayer(load_replay(path))
+
+        assert player.check_event(0, "Welcome to the dungeon!") is None
+        divergence = player.check_event(3, "You bump into a wall.")
+
+        assert "tick 3" in divergence and "You step east." in divergence
+        assert player.check_event(4, "Anything else") is None
+        assert player.divergence == divergence
+
+    def test_torn_last_line_is_skipped(self):
+        """Test that a log cut off by a crash still loads."""
+        with tempfile.TemporaryDirectory() as tmp:
+            path = os.path.join(tmp, "session.jsonl")
+            record_session(path)
+            with open(path, "a", encoding="utf-8") as f:
+                f.write('{"tick": 9, "kind": "inp')
+
+            assert len(load_replay(path).entries) == 5
+
+    def test_rejects_other_files(self):
+        """Test that a file without a replay header is refused."""
+        with tempfile.TemporaryDirectory() as tmp:
+            path = os.path.join(tmp, "notes.jsonl")
+            with open(path, "w", encoding="utf-8") as f:
+                f.write('{"txid": "abc", "status": "begin"}\n')
+
+            with pytest.raises(ValueError):
+                load_replay(path)
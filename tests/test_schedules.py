@@ -0,0 +1,88 @@
+"""
+Tests for the day/night clock and NPC schedules.
+"""
+
+from entities.components import Position, Schedule
+from entities.schedule_system import ScheduleSystem
+from systems.clock import GameClock, hour_in_window
+
+ROUTINES = {
+    "shopkeeper": [
+        {"from": 6, "to": 20, "at": "work", "available": True},
+        {"from": 20, "to": 6, "at": "home", "available": False},
+    ]
+}
+
+
+class OpenMap:
+    """Map where every tile is walkable."""
+
+    def is_walkable(self, x, y):
+        return True
+
+    def move_cost(self, x, y):
+        return 1.0
+
+
+class TestGameClock:
+    """Test the passage of in-game time."""
+
+    def test_day_rolls_over(self):
+        """Test that hours wrap into the next day."""
+        clock = GameClock({"day_length": 24.0, "start_hour": 23.0})
+        clock.advance(2.0)
+
+        assert clock.day == 2
+        assert clock.time_string() == "Day 2 01:00"
+        assert clock.period == "night"
+
+    def test_windows_wrap_midnight(self):
+        """Test hour windows that span midnight."""
+        assert hour_in_window(22, 20, 6)
+        assert hour_in_window(3, 20, 6)
+        assert not hour_in_window(12, 20, 6)
+
+
+class TestScheduleSystem:
+    """Test NPCs following their routines."""
+
+    def make_npc(self, entity_manager, system):
+        eid = entity_manager.create_entity()
+        entity_manager.add_component(eid, Position(0, 0))
+        system.assign(eid, "shopkeeper", (3, 0))
+        return eid
+
+    def test_walks_home_at_night(self, entity_manager):
+        """Test that a shopkeeper closes and walks home after hours."""
+        system = ScheduleSystem(entity_manager, routines=ROUTINES)
+        eid = self.make_npc(entity_manager, system)
+        clock = GameClock({"day_length": 24.0, "start_hour": 21.0})
+
+        for _ in range(4):
+            system.update(1.0, clock, OpenMap())
+
+        schedule = entity_manager.get_component(eid, Schedule)
+        pos = entity_manager.get_component(eid, Position)
+        assert not schedule.available
+        assert (pos.x, pos.y) == (3, 0)
+
+    def test_path_reused_next_day(self, entity_manager):
+        """Test that paths between places are computed once and cached."""
+        system = ScheduleSystem(entity_manager, routines=ROUTINES)
+        eid = self.make_npc(entity_manager, system)
+        schedule = entity_manager.get_component(eid, Schedule)
+        clock = GameClock({"day_length": 24.0, "start_hour": 21.0})
+
+        system.update(0.0, clock, OpenMap())
+        cached = schedule.paths[((0, 0), (3, 0))]
+
+        schedule.target = ""
+        system.update(0.0, clock, OpenMap())
+        assert schedule.paths[((0, 0), (3, 0))] is cached
+
+    def test_next_open_hour(self, entity_manager):
+        """Test when a closed NPC opens again."""
+        system = ScheduleSystem(entity_manager, routines=ROUTINES)
+
+        assert system.next_open_hour("shopkeeper", 22.0) == 6
+        assert system.next_open_hour("shopkeeper", 3.0) == 6
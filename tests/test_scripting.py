@@ -0,0 +1,204 @@
+"""
+Tests for content scripts (NPC talk and item on-use effects).
+"""
+
+import random
+
+import pytest
+
+from entities.components import Health, Inventory, Item, Name, Position
+from systems.scripting import ScriptError, ScriptHost, run_script, validate_script
+from systems.transactions import ItemTransaction
+
+
+class FakeEngine:
+    """The parts of GameEngine a ScriptHost uses."""
+
+    def __init__(self, entity_wrapper, entity_manager):
+        self.entity_manager = entity_manager
+        self.entity_wrapper = entity_wrapper
+        self.script_flags = set()
+        self.logged = []
+        self.xp = 0
+        self.respawned = None
+        self.player_id = entity_wrapper.factory.create_player(5, 5)
+
+    def log(self, text, color=(255, 255, 255)):
+        self.logged.append(text)
+
+    def transaction(self, reason=""):
+        return ItemTransaction(self.entity_manager, None, reason)
+
+    def gain_xp(self, eid, amount):
+        self.xp += amount
+
+    def respawn_player(self, cause):
+        self.respawned = cause
+
+
+def host_for(entity_wrapper, entity_manager):
+    engine = FakeEngine(entity_wrapper, entity_manager)
+    elder = entity_manager.create_entity()
+    entity_manager.add_component(elder, Name("Elder"))
+    entity_manager.add_component(elder, Position(6, 5))
+    return engine, ScriptHost(engine, engine.player_id, elder)
+
+
+class TestScriptValidation:
+    """Test that scripts can only use the curated API."""
+
+    def test_valid_script_passes(self):
+        """Test a script using effects, conditions, chance and branches."""
+        validate_script(
+            [
+                {"do": "say", "lines": ["Hello."], "color": [1, 2, 3]},
+                {"do": "heal", "amount": 5, "chance": 0.5},
+                {
+                    "if": {"not_flag": "met", "min_level": 2},
+                    "then": [{"do": "set_flag", "flag": "met"}],
+                    "else": [{"do": "log", "text": "Again?"}],
+                },
+            ]
+        )
+
+    @pytest.mark.parametrize(
+        "script, problem",
+        [
+            ({"do": "heal"}, "list of steps"),
+            ([{"do": "exec", "code": "import os"}], "unknown effect"),
+            ([{"do": "heal"}], "needs 'amount'"),
+            ([{"do": "heal", "amount": 1, "target": 3}], "does not take 'target'"),
+            ([{"do": "heal", "amount": "9999"}], "whole number"),
+            ([{"do": "identify", "price": "free"}], "price must be a whole number"),
+            ([{"do": "summon"}], "needs 'creature'"),
+            ([{"if": {"is_admin": True}, "then": []}], "unknown condition"),
+            ([{"say": "hi"}], "needs 'do' or 'then'"),
+        ],
+    )
+    def test_bad_scripts_are_refused(self, script, problem):
+        """Test that unknown effects, fields and conditions are rejected."""
+        with pytest.raises(ScriptError, match=problem):
+            validate_script(script)
+
+    def test_size_and_depth_are_bounded(self):
+        """Test that huge or deeply nested scripts are rejected."""
+        with pytest.raises(ScriptError, match="more than"):
+            validate_script([{"do": "heal", "amount": 1}] * 100)
+
+        nested = [{"do": "heal", "amount": 1}]
+        for _ in range(6):
+            nested = [{"then": nested}]
+        with pytest.raises(ScriptError, match="nested"):
+            validate_script(nested)
+
+
+class TestScriptEffects:
+    """Test running scripts against a player and an NPC."""
+
+    def test_dialogue_remembers_flags(self, entity_wrapper, entity_manager):
+        """Test that a first-meeting branch runs once and rewards the player."""
+        engine, host = host_for(entity_wrapper, entity_manager)
+        script = [
+            {
+                "if": {"not_flag": "met_elder"},
+                "then": [
+                    {"do": "say", "lines": ["Welcome, {player}."]},
+                    {"do": "give_gold", "amount": 10},
+                    {"do": "give_xp", "amount": 5},
+                    {"do": "set_flag", "flag": "met_elder"},
+                ],
+                "else": [{"do": "log", "text": "{name} nods at you."}],
+            }
+        ]
+        engine.economy = type("Economy", (), {"record_created": lambda *a: None})()
+        inventory = entity_manager.get_component(engine.player_id, Inventory)
+        gold = inventory.gold
+
+        run_script(script, host)
+        run_script(script, host)
+
+        assert engine.logged[0].startswith("Elder says: 'Welcome, ")
+        assert engine.logged[1] == "Elder nods at you."
+        assert inventory.gold == gold + 10
+        assert engine.xp == 5
+        assert engine.script_flags == {"met_elder"}
+
+    def test_healing_items_and_chance(self, entity_wrapper, entity_manager):
+        """Test heal, give_item and that a zero chance step never runs."""
+        engine, host = host_for(entity_wrapper, entity_manager)
+        health = entity_manager.get_component(engine.player_id, Health)
+        health.current = health.maximum - 3
+        random.seed(1)
+
+        run_script(
+            [
+                {"do": "heal", "amount": 50},
+                {"do": "give_item", "item": "health_potion"},
+                {"do": "give_item", "item": "sword", "chance": 0.0},
+            ],
+            host,
+        )
+
+        inventory = entity_manager.get_component(engine.player_id, Inventory)
+        items = [entity_manager.get_component(i, Item) for i in inventory.items]
+        types = [item.item_type for item in items]
+        assert health.current == health.maximum
+        assert types.count("health_potion") == 1
+        assert "sword" not in types
+        assert host.check({"has_item": "health_potion"})
+
+    def test_lethal_damage_respawns(self, entity_wrapper, entity_manager):
+        """Test that a script that kills the player goes through respawn."""
+        engine, host = host_for(entity_wrapper, entity_manager)
+
+        run_script([{"do": "damage", "amount": 10_000}], host)
+
+        assert engine.respawned == "slain by Elder"
+
+    def test_identify_one_or_all_for_a_price(self, entity_wrapper, entity_manager):
+        """Test that a scroll names one item and a paid service names what it can."""
+        from systems.identification import conceal, is_identified
+
+        engine, host = host_for(entity_wrapper, entity_manager)
+        engine.economy = type("Economy", (), {"record_destroyed": lambda *a: None})()
+        inventory = entity_manager.get_component(engine.player_id, Inventory)
+        finds = []
+        for _ in range(3):
+            item_id = entity_wrapper.factory.create_item(0, 0, "sword")
+            conceal(entity_manager, item_id, "Iron Sword")
+            inventory.items.append(item_id)
+            finds.append(item_id)
+        inventory.gold = 25
+
+        def identified():
+            return [is_identified(entity_manager, i) for i in finds]
+
+        run_script([{"do": "identify", "count": 1}], host)
+        assert identified() == [True, False, False]
+
+        run_script([{"do": "identify", "price": 20}], host)
+        assert identified() == [True, True, False]
+        assert inventory.gold == 5
+        assert engine.logged[-1] == "That costs 20 gold, which you lack."
+
+    def test_remove_curse(self, entity_wrapper, entity_manager):
+        """Test that remove_curse frees worn gear and says when there is none."""
+        from entities.components import Equipment
+        from systems.identification import curse, is_cursed
+
+        engine, host = host_for(entity_wrapper, entity_manager)
+        item_id = entity_wrapper.factory.create_item(0, 0, "sword")
+        curse(entity_manager, item_id)
+        entity_manager.get_component(engine.player_id, Equipment).weapon = item_id
+
+        run_script([{"do": "remove_curse"}], host)
+        run_script([{"do": "remove_curse"}], host)
+
+        assert not is_cursed(entity_manager, item_id)
+        assert engine.logged[-1] == "You feel no curse upon you."
+
+    def test_text_only_fills_known_names(self, entity_wrapper, entity_manager):
+        """Test that text templates cannot reach attributes like str.format can."""
+        _, host = host_for(entity_wrapper, entity_manager)
+
+        assert host.text("{name} {name.__class__} {0}") == "Elder {name.__class__} {0}"
@@ -0,0 +1,86 @@
+"""
+Tests for seasons and their ladders.
+"""
+
+import os
+import tempfile
+
+from systems.seasons import DAY, SeasonSystem
+
+
+class FakeClock:
+    """Wall clock that only moves when told to."""
+
+    def __init__(self, now=1000.0):
+        self.now = now
+
+    def __call__(self):
+        return self.now
+
+
+class TestSeasons:
+    """Test season rollover, ladder archiving and season info."""
+
+    def test_first_season_starts_now(self):
+        """Test that a fresh realm begins season 1 lasting the configured length."""
+        clock = FakeClock()
+        seasons = SeasonSystem(None, {"length_days": 30}, clock=clock)
+
+        info = seasons.season_info()
+
+        assert info["type"] == "season_info"
+        assert info["season"] == 1
+        assert info["remaining"] == 30 * DAY
+
+    def test_ladder_ranks_by_level(self):
+        """Test that the ladder lists the highest characters first, up to its size."""
+        seasons = SeasonSystem(None, {"ladder_size": 2}, clock=FakeClock())
+        seasons.record("Wren", 3, 10, False)
+        seasons.record("Rook", 5, 0, True)
+        seasons.record("Ash", 5, 40, False)
+
+        ladder = seasons.ladder()
+
+        assert [(entry["rank"], entry["name"]) for entry in ladder] == [(1, "Ash"), (2, "Rook")]
+        assert ladder[1]["seasonal"]
+
+    def test_rollover_archives_ladder(self):
+        """Test that ending a season archives its standings and starts a clean ladder."""
+        clock = FakeClock()
+        seasons = SeasonSystem(None, {"length_days": 1}, clock=clock)
+        seasons.record("Wren", 4, 0, False)
+
+        assert seasons.rollover() is None
+
+        clock.now += DAY
+        assert seasons.rollover() == 1
+        assert seasons.season_id == 2
+        assert seasons.ladder() == []
+        assert seasons.archived_ladder(1)[0]["name"] == "Wren"
+        assert seasons.season_info()["past_seasons"] == [1]
+
+    def test_missed_seasons_roll_over_together(self):
+        """Test that seasons that ended while nobody played are all archived."""
+        clock = FakeClock()
+        seasons = SeasonSystem(None, {"length_days": 1}, clock=clock)
+
+        clock.now += 3.5 * DAY
+
+        assert seasons.rollover() == 3
+        assert seasons.season_id == 4
+        assert seasons.season_info()["remaining"] == 0.5 * DAY
+
+    def test_state_persists(self):
+        """Test that the season and ladder survive a restart."""
+        with tempfile.TemporaryDirectory() as tmp:
+            path = os.path.join(tmp, "seasons.json")
+            clock = FakeClock()
+            seasons = SeasonSystem(path, {"length_days": 1}, clock=clock)
+            clock.now += DAY
+            seasons.rollover()
+            seasons.record("Wren", 2, 0, True)
+
+            reloaded = SeasonSystem(path, {"length_days": 1}, clock=clock)
+
+            assert reloaded.season_id == 2
+            assert reloaded.ladder()[0]["name"] == "Wren"
@@ -0,0 +1,84 @@
+"""
+Tests for per-player settings.
+"""
+
+import pytest
+
+from systems.settings import DEFAULTS, PlayerSettings, SettingsError, daltonize
+
+
+class TestPlayerSettings:
+    """Test checking, saving and applying player settings."""
+
+    def test_starts_with_defaults(self):
+        """Test that a new player has the default settings and saves nothing."""
+        settings = PlayerSettings()
+
+        assert settings.snapshot() == DEFAULTS
+        assert settings.to_profile() == {}
+
+    def test_update_reports_what_changed(self):
+        """Test that only settings whose value moved are reported changed."""
+        settings = PlayerSettings()
+
+        changed = settings.update({"auto_loot": True, "locale": "en", "colorblind": "Tritanopia"})
+
+        assert changed == ["auto_loot", "colorblind"]
+        assert settings["colorblind"] == "tritanopia"
+
+    @pytest.mark.parametrize(
+        "changes",
+        [
+            {"volume": 3},
+            {"auto_loot": "yes"},
+            {"locale": "english please"},
+            {"update_rate": "fast"},
+            {"chat_filter": ["x" * 30]},
+            {"chat_filter": 5},
+        ],
+    )
+    def test_bad_values_are_refused(self, changes):
+        """Test that unknown settings and bad values raise SettingsError."""
+        with pytest.raises(SettingsError):
+            PlayerSettings().update(changes)
+
+    def test_refused_update_changes_nothing(self):
+        """Test that one bad value keeps the good ones beside it from applying."""
+        settings = PlayerSettings()
+
+        with pytest.raises(SettingsError, match="update_rate"):
+            settings.update({"auto_loot": True, "update_rate": "fast"})
+
+        assert settings["auto_loot"] is False
+
+    def test_chat_filter_masks_words(self):
+        """Test that filter words are masked whole, in any case, and can be typed as text."""
+        settings = PlayerSettings()
+        settings.update({"chat_filter": "Spoiler, boss"})
+
+        assert settings["chat_filter"] == ["boss", "spoiler"]
+        assert settings.filter_chat("SPOILER: the Boss dies") == "*******: the **** dies"
+        assert settings.filter_chat("bossy") == "bossy"
+
+    def test_profile_round_trip(self):
+        """Test that saved settings come back, and stale entries are skipped."""
+        settings = PlayerSettings()
+        settings.update({"auto_loot": True, "update_rate": "low"})
+
+        restored = PlayerSettings()
+        restored.from_profile({**settings.to_profile(), "colorblind": "purple", "old": 1})
+
+        assert restored.snapshot() == settings.snapshot()
+
+    def test_colorblind_colours(self):
+        """Test that red and green drawn for a deuteranope no longer look alike,
+        and greys are left alone."""
+        settings = PlayerSettings()
+        assert settings.color((255, 0, 0)) == (255, 0, 0)
+
+        settings.update({"colorblind": "deuteranopia"})
+        red, green = settings.color((255, 0, 0)), settings.color((0, 255, 0))
+
+        assert red != (255, 0, 0)
+        assert red[2] > green[2] + 50  # Red picks up blue that green does not
+        assert daltonize((128, 128, 128), "protanopia") == (128, 128, 128)
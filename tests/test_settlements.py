@@ -0,0 +1,150 @@
+"""
+Tests for town generation and the roads between towns.
+"""
+
+import random
+
+import numpy as np
+
+from world.map import (
+    TILE_BRIDGE,
+    TILE_DOOR,
+    TILE_GRASS,
+    TILE_PAVEMENT,
+    TILE_WALL,
+    TILE_WATER,
+)
+from world.settlements import (
+    SHOP_STOCK,
+    Settlement,
+    build_town,
+    lay_road,
+    road_network,
+)
+
+
+def make_world(width=60, height=60, tile=TILE_GRASS):
+    world_map = np.full((height, width), tile, dtype=np.uint8)
+    biome_map = np.full((height, width), "plains", dtype=object)
+    return world_map, biome_map
+
+
+def build(seed=1):
+    world_map, biome_map = make_world()
+    town = Settlement("Test Town", 10, 10, 30, 30)
+    entities = build_town(world_map, biome_map, town, random.Random(seed))
+    return town, entities, world_map, biome_map
+
+
+class TestTowns:
+    """Test the layout and population of a generated town."""
+
+    def test_streets_and_biome(self):
+        """Test that the plaza and streets are paved and the area becomes town."""
+        town, _, world_map, biome_map = build()
+        cx, cy = town.center
+
+        assert world_map[cy, cx] == TILE_PAVEMENT
+        assert world_map[10, cx] == TILE_PAVEMENT
+        assert world_map[cy, 39] == TILE_PAVEMENT
+        assert biome_map[10, 10] == "town" and biome_map[9, 9] == "plains"
+
+    def test_buildings_have_doors_onto_open_ground(self):
+        """Test that every building is walled with a door leading outside."""
+        town, _, world_map, _ = build()
+
+        assert len(town.buildings) >= 4
+        for building in town.buildings:
+            dx, dy = building.door
+            assert world_map[dy, dx] == TILE_DOOR
+            assert world_map[building.y, building.x] == TILE_WALL
+            outside = dy + (1 if dy == building.y + building.height - 1 else -1)
+            assert world_map[outside, dx] in (TILE_GRASS, TILE_PAVEMENT)
+
+    def test_townsfolk(self):
+        """Test that the shops, the elder and residents are placed inside buildings."""
+        town, entities, _, _ = build()
+        shops = [e["subtype"] for e in entities if e["type"] == "shopkeeper"]
+        uses = [building.use for building in town.buildings]
+
+        assert town.buildings[0].use == "shop:general"
+        assert "elder" in uses and "inn" in uses
+        assert sorted(shops) == sorted(SHOP_STOCK)
+        assert all(e["name"].startswith("Test Town") for e in entities if "name" in e)
+        for building in town.buildings:
+            assert any(
+                building.x < e["x"] < building.x + building.width - 1
+                and building.y < e["y"] < building.y + building.height - 1
+                and e["type"] != "light"
+                for e in entities
+            )
+
+    def test_safe_flag_round_trip(self):
+        """Test that a town and its safe flag survive saving as plain data."""
+        town, _, _, _ = build()
+
+        restored = Settlement.from_dict(town.to_dict())
+
+        assert restored == town
+        assert restored.safe and restored.contains(*town.center)
+        assert not restored.contains(9, 10)
+
+
+class TestRoads:
+    """Test paving roads and choosing which towns to join."""
+
+    def test_road_joins_points(self):
+        """Test that a road is one connected line of pavement."""
+        world_map, _ = make_world(20, 20)
+
+        paved = lay_road(world_map, (2, 3), (15, 9))
+
+        assert paved == 13 + 6 + 1
+        assert world_map[3, 2] == TILE_PAVEMENT and world_map[9, 15] == TILE_PAVEMENT
+        tiles = {
+            (x, y) for y in range(20) for x in range(20) if world_map[y, x] == TILE_PAVEMENT
+        }
+        for x, y in tiles - {(15, 9)}:
+            neighbours = {(x + 1, y), (x - 1, y), (x, y + 1), (x, y - 1)}
+            assert neighbours & tiles
+
+    def test_road_bridges_water(self):
+        """Test that a road crosses a river on a bridge."""
+        world_map, _ = make_world(10, 3)
+        world_map[:, 4] = TILE_WATER
+
+        lay_road(world_map, (0, 1), (9, 1))
+
+        assert world_map[1, 4] == TILE_BRIDGE
+        assert world_map[0, 4] == TILE_WATER
+        assert world_map[1, 3] == TILE_PAVEMENT
+
+    def test_town_bridges_its_river(self):
+        """Test that a river through town keeps flowing under the streets."""
+        world_map, biome_map = make_world()
+        world_map[:, 12] = TILE_WATER
+        town = Settlement("River Town", 10, 10, 30, 30)
+
+        build_town(world_map, biome_map, town, random.Random(1))
+
+        assert world_map[town.center[1], 12] == TILE_BRIDGE
+        assert any(world_map[y, 12] == TILE_WATER for y in range(10, 40))
+
+    def test_road_leaves_walls(self):
+        """Test that buildings in the way are not paved through."""
+        world_map, _ = make_world(10, 3)
+        world_map[1, 5] = TILE_WALL
+
+        lay_road(world_map, (0, 1), (9, 1))
+
+        assert world_map[1, 5] == TILE_WALL
+        assert world_map[1, 4] == TILE_PAVEMENT and world_map[1, 6] == TILE_PAVEMENT
+
+    def test_network(self):
+        """Test that every point is joined along the shortest links."""
+        points = [(0, 0), (10, 0), (100, 0), (10, 5)]
+
+        edges = road_network(points)
+
+        assert edges == [(0, 1), (1, 3), (1, 2)]
+        assert road_network([]) == []
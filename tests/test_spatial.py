@@ -0,0 +1,93 @@
+"""
+Tests for proximity queries on the spatial index.
+"""
+
+from core.spatial import CELL_SIZE, SpatialIndex
+from entities.ai_system import AISystem
+from entities.components import Monster, Position
+
+
+def place(entity_manager, x, y, monster_type=None):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, y))
+    if monster_type:
+        entity_manager.add_component(
+            eid,
+            Monster(ai_type="aggressive", monster_type=monster_type, name=monster_type),
+        )
+    return eid
+
+
+def brute_force(entity_manager, x, y, radius):
+    """What the index should find, by checking every entity."""
+    found = []
+    for eid, pos in entity_manager.components_by_type.get(Position, {}).items():
+        if max(abs(pos.x - x), abs(pos.y - y)) <= radius:
+            found.append(eid)
+    return sorted(found)
+
+
+class TestSpatialQueries:
+    """Test radius queries and keeping cells in sync with movement."""
+
+    def test_radius_matches_full_scan(self, entity_manager, rng):
+        """Test that queries across cell borders find exactly what a full scan does."""
+        index = SpatialIndex(entity_manager)
+        for _ in range(300):
+            place(entity_manager, rng.randint(-50, 150), rng.randint(-50, 150))
+
+        queries = ((0, 0, 5), (CELL_SIZE, CELL_SIZE, 1), (60, 40, 30), (-20, 90, 0))
+        for x, y, radius in queries:
+            assert sorted(index.query_radius(x, y, radius)) == brute_force(
+                entity_manager, x, y, radius
+            )
+
+    def test_results_are_nearest_first(self, entity_manager):
+        """Test that results are ordered by distance."""
+        index = SpatialIndex(entity_manager)
+        far = place(entity_manager, 10, 0)
+        near = place(entity_manager, 1, 1)
+        here = place(entity_manager, 0, 0)
+
+        assert index.query_radius(0, 0, 10) == [here, near, far]
+
+    def test_moves_and_removals_update_cells(self, entity_manager):
+        """Test that moving across cells and destroying entities keep queries right."""
+        index = SpatialIndex(entity_manager)
+        eid = place(entity_manager, 1, 1, "wolf")
+
+        pos = entity_manager.get_component(eid, Position)
+        pos.x, pos.y = CELL_SIZE * 3 + 2, 1
+        entity_manager.notify_component_change(eid, Position)
+
+        assert index.monsters_near(1, 1, 2) == []
+        assert index.monsters_near(CELL_SIZE * 3, 0, 2) == [eid]
+
+        entity_manager.destroy_entity(eid)
+
+        assert index.query_radius(CELL_SIZE * 3, 0, 5) == []
+        assert not index.cell_to_entities
+
+    def test_rebuild_fills_cells(self, entity_manager):
+        """Test that a rebuild indexes entities added before the index existed."""
+        eid = place(entity_manager, 40, 40)
+        index = SpatialIndex(entity_manager)
+        index.rebuild()
+
+        assert index.query_radius(41, 41, 1) == [eid]
+
+    def test_ai_uses_index_for_packmates(self, entity_manager):
+        """Test that calling for help through the index alerts the same monsters."""
+        index = SpatialIndex(entity_manager)
+        ai = AISystem(entity_manager)
+        caller = place(entity_manager, 5, 5, "wolf")
+        ally = place(entity_manager, 7, 5, "wolf")
+        place(entity_manager, 6, 5, "goblin")
+        place(entity_manager, 60, 60, "wolf")
+        monster = entity_manager.get_component(caller, Monster)
+        monster.call_radius = 4
+
+        answered = ai._call_allies(caller, monster, Position(5, 5), index)
+
+        assert answered == 1
+        assert entity_manager.get_component(ally, Monster).alerted
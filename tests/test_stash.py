@@ -0,0 +1,71 @@
+"""
+Tests for the account stash shared by an account's characters.
+"""
+
+import os
+
+import pytest
+
+from core.ecs import EntityManager
+from entities.components import Inventory, Item, Position
+from systems.stash import AccountStash
+from systems.transactions import ItemTransaction, TransactionError
+
+
+def carried_item(factory, item_type="iron_ore"):
+    eid = factory.create_item(0, 0, item_type)
+    factory.entity_manager.remove_component(eid, Position)
+    return eid
+
+
+class TestAccountStash:
+    """Test storing items between characters and sessions."""
+
+    def test_items_outlive_the_session(self, entity_factory, tmp_path):
+        """Test that stashed items come back, with fresh IDs, in a new game."""
+        path = os.path.join(tmp_path, "stash.json")
+        stash = AccountStash(path)
+        ore = carried_item(entity_factory)
+        sword = carried_item(entity_factory, "sword")
+        name = entity_factory.entity_manager.get_component(sword, Item).name
+        stash.items = [ore, sword]
+        stash.save(entity_factory.entity_manager)
+
+        later = EntityManager()
+        taken = later.create_entity()
+        restored = AccountStash(path)
+        restored.load(later)
+
+        assert len(restored.items) == 2 and taken not in restored.items
+        items = [later.get_component(eid, Item) for eid in restored.items]
+        assert [item.item_type for item in items] == ["iron_ore", "sword"]
+        assert items[1].name == name
+
+    def test_missing_file_is_empty(self, entity_manager, tmp_path):
+        """Test that an account with no stash file starts with an empty stash."""
+        stash = AccountStash(os.path.join(tmp_path, "none.json"))
+        stash.load(entity_manager)
+
+        assert stash.items == []
+
+    def test_bound_items_stay_out(self, entity_factory, tmp_path):
+        """Test that stash moves are transactions that refuse bound items."""
+        em = entity_factory.entity_manager
+        stash = AccountStash(os.path.join(tmp_path, "stash.json"), capacity=1)
+        inventory = Inventory(capacity=5, items=[], gold=0)
+        bound, ore, more = (carried_item(entity_factory) for _ in range(3))
+        inventory.items.extend([bound, ore, more])
+        em.get_component(bound, Item).bound_to = "Ayla"
+
+        def stash_item(item_id):
+            moving = ItemTransaction(em, reason="stash")
+            moving.move_item(inventory, stash, item_id).commit()
+
+        with pytest.raises(TransactionError, match="bound"):
+            stash_item(bound)
+        stash_item(ore)
+        with pytest.raises(TransactionError, match="full"):
+            stash_item(more)
+
+        assert stash.items == [ore]
+        assert inventory.items == [bound, more]
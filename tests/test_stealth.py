@@ -0,0 +1,132 @@
+"""
+Tests for stealth and perception checks.
+"""
+
+import random
+
+from entities.ai_system import AISystem
+from entities.components import Monster, Position, Skills, Stealth
+from entities.stealth_system import DETECTION_RANGE, StealthSystem, detection_chance
+
+
+class OpenMap:
+    """Unbounded map where every tile is walkable."""
+
+    def is_walkable(self, x, y):
+        return True
+
+    def move_cost(self, x, y):
+        return 1.0
+
+
+class FixedRandom(random.Random):
+    """Random source whose rolls always return the same value."""
+
+    def __init__(self, value):
+        super().__init__()
+        self.value = value
+
+    def random(self):
+        return self.value
+
+
+def make_player(entity_manager, hidden=True):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(5, 5))
+    entity_manager.add_component(eid, Skills())
+    entity_manager.add_component(eid, Stealth(active=hidden))
+    return eid
+
+
+def make_monster(entity_manager, x, y, perception=5):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, y))
+    entity_manager.add_component(
+        eid,
+        Monster(ai_type="aggressive", monster_type="goblin", name="Goblin", perception=perception),
+    )
+    return eid
+
+
+def full_light(x, y):
+    return 1.0
+
+
+class TestDetectionChance:
+    """Test how light, movement and distance affect detection."""
+
+    def test_adjacent_always_detected(self):
+        """Test that nobody can hide right next to a monster."""
+        assert detection_chance(0, 50, 1, 0.0, False) == 1.0
+
+    def test_out_of_range_never_detected(self):
+        """Test that hidden players cannot be spotted beyond detection range."""
+        assert detection_chance(50, 0, DETECTION_RANGE + 1, 1.0, True) == 0.0
+
+    def test_modifiers(self):
+        """Test that darkness, standing still and distance all help the hider."""
+        base = detection_chance(5, 5, 4, 1.0, False)
+
+        assert detection_chance(5, 5, 4, 0.0, False) < base
+        assert detection_chance(5, 5, 4, 1.0, True) > base
+        assert detection_chance(5, 5, 8, 1.0, False) < base
+        assert detection_chance(10, 5, 4, 1.0, False) > base
+
+
+class TestStealthSystem:
+    """Test perception checks and their effect on monster AI."""
+
+    def test_failed_check_keeps_player_hidden(self, entity_manager):
+        """Test that monsters that fail their check do not perceive the player."""
+        stealth = StealthSystem(entity_manager, rng=FixedRandom(0.99))
+        player = make_player(entity_manager)
+        monster = make_monster(entity_manager, 9, 5)
+
+        stealth.update(1.0, player, full_light)
+
+        assert not stealth.perceives(monster, player)
+
+    def test_passed_check_spots_player_for_a_while(self, entity_manager):
+        """Test that a successful check reveals the player to that monster only."""
+        stealth = StealthSystem(entity_manager, rng=FixedRandom(0.0))
+        player = make_player(entity_manager)
+        monster = make_monster(entity_manager, 9, 5)
+        far_monster = make_monster(entity_manager, 40, 5)
+
+        stealth.update(1.0, player, full_light)
+
+        assert stealth.perceives(monster, player)
+        assert not stealth.perceives(far_monster, player)
+
+        # Awareness fades once the monster stops noticing
+        stealth.rng = FixedRandom(0.99)
+        for _ in range(10):
+            stealth.update(1.0, player, full_light)
+        assert not stealth.perceives(monster, player)
+
+    def test_visible_player_always_perceived(self, entity_manager):
+        """Test that a player who is not sneaking is seen by everyone."""
+        stealth = StealthSystem(entity_manager, rng=FixedRandom(0.99))
+        player = make_player(entity_manager, hidden=False)
+        monster = make_monster(entity_manager, 40, 5)
+
+        assert stealth.perceives(monster, player)
+
+    def test_hidden_player_not_hunted(self, entity_manager):
+        """Test that aggressive monsters ignore a hidden player they have not spotted."""
+        stealth = StealthSystem(entity_manager, rng=FixedRandom(0.99))
+        ai = AISystem(entity_manager, stealth=stealth)
+        player = make_player(entity_manager)
+        monster = make_monster(entity_manager, 8, 5)
+        monster_pos = entity_manager.get_component(monster, Position)
+
+        ai.think(
+            monster,
+            entity_manager.get_component(monster, Monster),
+            monster_pos,
+            entity_manager.get_component(player, Position),
+            OpenMap(),
+            player_id=player,
+        )
+
+        assert not entity_manager.get_component(monster, Monster).alerted
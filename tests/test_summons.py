@@ -0,0 +1,173 @@
+"""
+Tests for summoned allies and the ally behavior tree.
+"""
+
+import pytest
+
+from entities.ai_system import AISystem
+from entities.components import Monster, PartyMember, Player, Position, Summoned
+from entities.summon_system import (
+    EXPIRED,
+    LEFT_BEHIND,
+    REPLACED,
+    SummonError,
+    SummonSystem,
+)
+
+
+class OpenMap:
+    """Unbounded map where every tile is walkable."""
+
+    def is_walkable(self, x, y):
+        return True
+
+    def move_cost(self, x, y):
+        return 1.0
+
+
+def make_player(entity_manager, x, y):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, y))
+    entity_manager.add_component(eid, Player())
+    return eid
+
+
+def make_monster(entity_manager, x, y, ai_type="aggressive"):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, y))
+    entity_manager.add_component(eid, Monster(ai_type=ai_type, name="Goblin"))
+    return eid
+
+
+def anywhere(x, y):
+    return True
+
+
+class TestSummonSystem:
+    """Test calling up allies, the cap and their timers."""
+
+    def test_summon_joins_the_owners_party(self, entity_manager, entity_factory):
+        """Test that a summon appears beside its owner and follows their lead."""
+        summons = SummonSystem(entity_manager, entity_factory)
+        owner = make_player(entity_manager, 5, 5)
+
+        eid = summons.summon(owner, "bone_servant", anywhere)
+
+        pos = entity_manager.get_component(eid, Position)
+        assert max(abs(pos.x - 5), abs(pos.y - 5)) == 1
+        assert entity_manager.get_component(eid, PartyMember).leader == owner
+        assert entity_manager.get_component(eid, Summoned).remaining == 60
+        assert summons.summons_of(owner) == [eid]
+
+    def test_needs_a_free_tile_and_a_real_creature(self, entity_manager, entity_factory):
+        """Test that a summon is refused with nowhere to stand or nothing to call."""
+        summons = SummonSystem(entity_manager, entity_factory)
+        owner = make_player(entity_manager, 5, 5)
+
+        with pytest.raises(SummonError, match="no room"):
+            summons.summon(owner, "bone_servant", lambda x, y: False)
+        with pytest.raises(SummonError, match="no such creature"):
+            summons.summon(owner, "dragon_god", anywhere)
+        assert summons.summons_of(owner) == []
+
+    def test_new_summon_replaces_the_oldest(self, entity_manager, entity_factory):
+        """Test that going over max_per_player dismisses the summon with least time left."""
+        summons = SummonSystem(entity_manager, entity_factory, {"max_per_player": 2})
+        dismissed = []
+        summons.on_dismissed = lambda eid, reason: dismissed.append((eid, reason))
+        owner = make_player(entity_manager, 5, 5)
+
+        first = summons.summon(owner, "bound_flame", anywhere)
+        second = summons.summon(owner, "bone_servant", anywhere)
+        third = summons.summon(owner, "bone_servant", anywhere)
+
+        assert dismissed == [(first, REPLACED)]
+        assert sorted(summons.summons_of(owner)) == sorted([second, third])
+        assert not entity_manager.has_component(first, Monster)
+
+    def test_summons_expire(self, entity_manager, entity_factory):
+        """Test that a summon unravels once its seconds run out."""
+        summons = SummonSystem(entity_manager, entity_factory)
+        dismissed = []
+        summons.on_dismissed = lambda eid, reason: dismissed.append(reason)
+        owner = make_player(entity_manager, 5, 5)
+        eid = summons.summon(owner, "bound_flame", anywhere)
+
+        summons.update(29)
+        assert summons.summons_of(owner) == [eid]
+        summons.update(1)
+
+        assert dismissed == [EXPIRED]
+        assert summons.summons_of(owner) == []
+
+    def test_summons_left_on_another_level_unravel(self, entity_manager, entity_factory):
+        """Test that taking the stairs leaves summons behind."""
+        summons = SummonSystem(entity_manager, entity_factory)
+        dismissed = []
+        summons.on_dismissed = lambda eid, reason: dismissed.append(reason)
+        owner = make_player(entity_manager, 5, 5)
+        summons.summon(owner, "bone_servant", anywhere)
+
+        entity_manager.get_component(owner, Position).z = 1
+        summons.update(0.1)
+
+        assert dismissed == [LEFT_BEHIND]
+
+
+class TestAllyBehavior:
+    """Test how the ally behavior tree follows and defends its owner."""
+
+    def think(self, entity_manager, eid, owner, attacks):
+        ai = AISystem(entity_manager)
+        return ai.think(
+            eid,
+            entity_manager.get_component(eid, Monster),
+            entity_manager.get_component(eid, Position),
+            entity_manager.get_component(owner, Position),
+            OpenMap(),
+            combat_callback=lambda attacker, target: attacks.append((attacker, target)),
+            player_id=owner,
+        )
+
+    def make_ally(self, entity_manager, owner, x, y):
+        eid = make_monster(entity_manager, x, y, ai_type="ally")
+        entity_manager.add_component(eid, PartyMember(leader=owner))
+        return eid
+
+    def test_attacks_an_enemy_near_its_owner(self, entity_manager):
+        """Test that an ally goes for a hostile next to it, not its owner or friends."""
+        owner = make_player(entity_manager, 5, 5)
+        ally = self.make_ally(entity_manager, owner, 6, 5)
+        self.make_ally(entity_manager, owner, 7, 6)
+        make_monster(entity_manager, 8, 5, ai_type="passive")
+        goblin = make_monster(entity_manager, 7, 4)
+        attacks = []
+
+        self.think(entity_manager, ally, owner, attacks)
+
+        assert attacks == [(ally, goblin)]
+
+    def test_chases_an_enemy_threatening_its_owner(self, entity_manager):
+        """Test that an ally closes on a hostile that is not yet in reach."""
+        owner = make_player(entity_manager, 5, 5)
+        ally = self.make_ally(entity_manager, owner, 5, 6)
+        make_monster(entity_manager, 8, 5)
+        attacks = []
+
+        self.think(entity_manager, ally, owner, attacks)
+
+        assert attacks == []
+        assert entity_manager.get_component(ally, Position).x == 6
+
+    def test_follows_a_distant_owner(self, entity_manager):
+        """Test that an ally with nothing to fight keeps up with its owner."""
+        owner = make_player(entity_manager, 10, 5)
+        ally = self.make_ally(entity_manager, owner, 5, 5)
+        attacks = []
+
+        self.think(entity_manager, ally, owner, attacks)
+        assert entity_manager.get_component(ally, Position).x == 6
+
+        entity_manager.get_component(ally, Position).x = 8
+        self.think(entity_manager, ally, owner, attacks)
+        assert entity_manager.get_component(ally, Position).x == 8  # Close enough
@@ -0,0 +1,96 @@
+"""
+Tests for swimming, breath and drowning.
+"""
+
+from entities.components import Breath, Health, Position, Skills
+from entities.swimming_system import (
+    BASE_BREATH,
+    SwimmingSystem,
+    is_underwater,
+    max_breath,
+    struggle_chance,
+)
+from world.map import Tile
+
+
+WATER = Tile(3, False, True, "  ", (255, 255, 255), name="water", struggle_chance=0.4, swim_skill=1)
+GRASS = Tile(4, True, True, "  ", (100, 180, 100), name="grass")
+
+
+class PondMap:
+    """Map that is water west of x=5 and grass elsewhere."""
+
+    def __init__(self, is_dark=False):
+        self.is_dark = is_dark
+
+    def get_tile(self, x, y):
+        return WATER if x < 5 else GRASS
+
+
+def make_swimmer(entity_manager, x, z=0, swimming=1):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, 0, z))
+    entity_manager.add_component(eid, Skills(swimming=swimming))
+    entity_manager.add_component(eid, Health(current=100, maximum=100))
+    entity_manager.add_component(eid, Breath())
+    return eid
+
+
+class TestSwimming:
+    """Test underwater detection, breath and swimming practice."""
+
+    def test_only_lower_levels_are_underwater(self):
+        """Test that surface water is swum while deeper or cave water is submerged."""
+        assert not is_underwater(PondMap(), 0, 0, 0)
+        assert is_underwater(PondMap(), 0, 0, -1)
+        assert is_underwater(PondMap(is_dark=True), 0, 0, 0)
+        assert not is_underwater(PondMap(), 8, 0, -1)
+
+    def test_breath_runs_out_then_drowns(self, entity_manager):
+        """Test that breath drains underwater and damage follows once it is gone."""
+        swimming = SwimmingSystem(entity_manager)
+        eid = make_swimmer(entity_manager, 0, z=-1)
+
+        assert swimming.update(BASE_BREATH, eid, PondMap()) == 0
+        assert entity_manager.get_component(eid, Breath).current == 0.0
+
+        assert swimming.update(1.0, eid, PondMap()) == 10
+        assert entity_manager.get_component(eid, Health).current == 90
+
+    def test_breath_recovers_at_surface(self, entity_manager):
+        """Test that surfacing refills breath without further damage."""
+        swimming = SwimmingSystem(entity_manager)
+        eid = make_swimmer(entity_manager, 0, z=0)
+        breath = entity_manager.get_component(eid, Breath)
+        breath.current = 0.0
+
+        assert swimming.update(1.0, eid, PondMap()) == 0
+        assert not breath.underwater
+        assert breath.current > 0.0
+
+    def test_practice_raises_skill_and_breath(self, entity_manager):
+        """Test that swimming levels up with practice and holds more air."""
+        swimming = SwimmingSystem(entity_manager)
+        eid = make_swimmer(entity_manager, 0)
+        skills = entity_manager.get_component(eid, Skills)
+
+        leveled = [swimming.swam(eid) for _ in range(25)]
+
+        assert leveled[-1]
+        assert skills.swimming == 2
+        assert entity_manager.get_component(eid, Breath).maximum == max_breath(2)
+        assert max_breath(2) > max_breath(1)
+
+    def test_non_swimmers_do_not_train(self, entity_manager):
+        """Test that entities without the swimming skill gain nothing."""
+        swimming = SwimmingSystem(entity_manager)
+        eid = make_swimmer(entity_manager, 0, swimming=0)
+
+        assert not swimming.swam(eid)
+        assert entity_manager.get_component(eid, Skills).swimming_xp == 0
+
+    def test_water_slows_weak_swimmers_most(self):
+        """Test that better swimmers struggle less in water but not on land."""
+        assert struggle_chance(WATER, 1) == 0.4
+        assert struggle_chance(WATER, 4) == 0.1
+        assert struggle_chance(GRASS, 4) == 0.0
@@ -0,0 +1,560 @@
+"""
+Tests for the telnet gateway.
+"""
+
+import socket
+import time
+import tracemalloc
+
+from core import recovery
+from systems.connection_guard import ConnectionGuard
+from systems.tokens import issue_token, verify_token
+from systems.outbound import OutboundQueue
+from systems.telnet_gateway import (
+    DO,
+    FLUSH_TIMEOUT,
+    IAC,
+    SB,
+    SE,
+    WILL,
+    TelnetGateway,
+    _TelnetHandler,
+    render_ansi,
+    strip_telnet_commands,
+)
+
+
+class FakeTile:
+    def __init__(self, tile_type, fg_color):
+        self.tile_type = tile_type
+        self.fg_color = fg_color
+
+
+class FakeGrid:
+    """Indexable like a numpy bool array: grid[y, x]."""
+
+    def __init__(self, value):
+        self.value = value
+
+    def __getitem__(self, key):
+        return self.value
+
+
+class FakeMap:
+    """A 3x3 floor room ringed by walls."""
+
+    width = height = 5
+
+    def __init__(self, visible=True):
+        self.explored = FakeGrid(True)
+        self.visible = FakeGrid(visible)
+
+    def get_tile(self, x, y):
+        if not (0 <= x < self.width and 0 <= y < self.height):
+            return None
+        if x in (0, 4) or y in (0, 4):
+            return FakeTile(1, (100, 100, 100))  # Wall
+        return FakeTile(0, (50, 50, 50))  # Floor
+
+
+class FieldMap(FakeMap):
+    """A wide open field of floor, all one colour."""
+
+    width, height = 80, 40
+
+    def get_tile(self, x, y):
+        if not (0 <= x < self.width and 0 <= y < self.height):
+            return None
+        return FakeTile(0, (50, 50, 50))
+
+
+def strip_ansi(text):
+    import re
+
+    return re.sub(r"\x1b\[[0-9;]*m", "", text)
+
+
+class TestTelnetGateway:
+    """Test telnet input cleanup, ANSI rendering and the game loop bridge."""
+
+    def test_negotiation_is_stripped(self):
+        """Test that option negotiation and subnegotiation never reach the parser."""
+        data = bytes([IAC, DO, 1]) + b"go " + bytes([IAC, SB, 31, 0, 80, IAC, SE]) + b"north"
+        data += bytes([IAC, WILL, 3, IAC, IAC])
+
+        assert strip_telnet_commands(data) == b"go north\xff"
+
+    def test_view_draws_tiles_and_entities(self):
+        """Test that the view is centred, uses ASCII tiles and puts entities on top."""
+        view = render_ansi(FakeMap(), 2, 2, 5, 5, {(2, 2): ("@", (255, 255, 255))})
+        rows = strip_ansi(view).split("\r\n")
+
+        assert rows == ["#####", "#...#", "#.@.#", "#...#", "#####"]
+        assert "\x1b[38;2;255;255;255m@" in view
+
+    def test_entities_hidden_outside_view(self):
+        """Test that remembered tiles are dimmed and show no entities."""
+        view = render_ansi(FakeMap(visible=False), 2, 2, 3, 3, {(2, 2): ("g", (0, 255, 0))})
+
+        assert strip_ansi(view).split("\r\n") == ["...", "...", "..."]
+        assert "\x1b[38;2;0;0;0m." in view
+
+    def test_recolor_applies_to_tiles_and_entities(self):
+        """Test that a recolor function changes every colour drawn."""
+        view = render_ansi(
+            FakeMap(), 2, 2, 3, 3, {(2, 2): ("g", (0, 255, 0))},
+            recolor=lambda fg: (fg[1], fg[0], fg[2]),
+        )
+
+        assert "\x1b[38;2;255;0;0mg" in view
+        assert "38;2;0;255;0m" not in view
+
+    def test_colour_codes_only_on_change(self):
+        """Test that a run of same-coloured tiles is sent with one colour code."""
+        view = render_ansi(FieldMap(), 40, 20, 10, 2, {(40, 20): ("g", (0, 255, 0))})
+
+        assert view.split("\r\n")[0] == "\x1b[38;2;50;50;50m" + "." * 10 + "\x1b[0m"
+        # Start of each row, then again after the glyph
+        assert view.count("\x1b[38;2;50;50;50m") == 3
+
+    def test_view_allocation_stays_bounded(self):
+        """Allocation benchmark: drawing a view must not build garbage per tile."""
+        field, glyphs = FieldMap(), {(40, 20): ("@", (255, 255, 255))}
+        render_ansi(field, 40, 20, 40, 15, glyphs)  # Warm the colour cache
+
+        tracemalloc.start()
+        try:
+            view = render_ansi(field, 40, 20, 40, 15, glyphs)
+            _, peak = tracemalloc.get_traced_memory()
+        finally:
+            tracemalloc.stop()
+
+        assert len(view) < 40 * 15 * 2
+        assert peak < 8 * 1024
+
+    def test_lines_are_answered_by_the_game_loop(self):
+        """Test that a client's line is run by poll and the reply is sent back."""
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello")
+        gateway.start()
+        try:
+            with socket.create_connection(("127.0.0.1", gateway.port), timeout=5) as client:
+                client.sendall(bytes([IAC, DO, 1]) + b"look\r\n")
+
+                handled = 0
+                deadline = time.time() + 5
+                while not handled and time.time() < deadline:
+                    handled = gateway.poll(lambda line: f"you typed {line}")
+                    time.sleep(0.01)
+
+                received = b""
+                while b"you typed" not in received and time.time() < deadline:
+                    received += client.recv(1024)
+        finally:
+            gateway.stop()
+
+        assert handled == 1
+        assert b"hello" in received
+        assert b"you typed look\r\n> " in received
+
+    def test_failing_session_is_closed_alone(self, monkeypatch, tmp_path):
+        """Test that a command that crashes disconnects only the client who sent it."""
+        monkeypatch.setattr(recovery, "DEBUG_LOG", str(tmp_path / "debug.log"))
+
+        def handle(line):
+            if line == "crash":
+                raise RuntimeError("handler bug")
+            return f"ok {line}"
+
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello")
+        gateway.start()
+        try:
+            bad = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            good = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            bad.sendall(b"crash\r\n")
+            good.sendall(b"look\r\n")
+
+            deadline = time.time() + 5
+            handled = 0
+            while handled < 2 and time.time() < deadline:
+                handled += gateway.poll(handle)
+                time.sleep(0.01)
+
+            received_bad = b""
+            while time.time() < deadline:
+                chunk = bad.recv(1024)
+                if not chunk:
+                    break  # Server closed the session
+                received_bad += chunk
+            received_good = b""
+            while b"ok look" not in received_good and time.time() < deadline:
+                received_good += good.recv(1024)
+            bad.close()
+            good.close()
+        finally:
+            gateway.stop()
+
+        assert b"Disconnecting" in received_bad
+        assert b"ok look" in received_good
+        assert "handler bug" in (tmp_path / "debug.log").read_text()
+
+    def test_one_snapshot_per_tick(self):
+        """Test that lines answered in the same poll share a single map view."""
+        views = []
+
+        def snapshot():
+            views.append(len(views))
+            return f"VIEW{len(views)}"
+
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello")
+        gateway.start()
+        try:
+            first = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            second = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            first.sendall(b"north\r\n")
+            second.sendall(b"south\r\n")
+
+            deadline = time.time() + 5
+            while gateway.inbox.qsize() < 2 and time.time() < deadline:
+                time.sleep(0.01)
+            handled = gateway.poll(lambda line: f"went {line}", snapshot=snapshot)
+
+            received = [b"", b""]
+            for i, client in enumerate((first, second)):
+                while b"went" not in received[i] and time.time() < deadline:
+                    received[i] += client.recv(1024)
+            first.close()
+            second.close()
+        finally:
+            gateway.stop()
+
+        assert handled == 2
+        assert len(views) == 1
+        assert b"VIEW1\r\nwent north" in received[0]
+        assert b"VIEW1\r\nwent south" in received[1]
+
+    def read_until_closed(self, client, deadline):
+        received = b""
+        while time.time() < deadline:
+            chunk = client.recv(1024)
+            if not chunk:
+                break
+            received += chunk
+        return received
+
+    def test_second_login_takes_over(self):
+        """Test that with kick_old the earlier session is told and disconnected."""
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello", sessions="kick_old")
+        gateway.start()
+        try:
+            old = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            deadline = time.time() + 5
+            received_old = b""
+            while b"hello" not in received_old and time.time() < deadline:
+                received_old += old.recv(1024)
+            new = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+
+            received_old += self.read_until_closed(old, deadline)
+            received_new = b""
+            while b"hello" not in received_new and time.time() < deadline:
+                received_new += new.recv(1024)
+            old.close()
+            new.close()
+        finally:
+            gateway.stop()
+
+        assert b"another connection. Disconnecting. [disconnect: duplicate-login]" in received_old
+        assert b"hello" in received_new
+
+    def test_second_login_refused(self):
+        """Test that with reject_new the newcomer is turned away and the player kept."""
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello", sessions="reject_new")
+        gateway.start()
+        try:
+            first = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            deadline = time.time() + 5
+            received_first = b""
+            while b"hello" not in received_first and time.time() < deadline:
+                received_first += first.recv(1024)
+            second = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            received_second = self.read_until_closed(second, deadline)
+
+            first.sendall(b"look\r\n")
+            while not gateway.poll(lambda line: f"ok {line}") and time.time() < deadline:
+                time.sleep(0.01)
+            while b"ok look" not in received_first and time.time() < deadline:
+                received_first += first.recv(1024)
+            first.close()
+            second.close()
+        finally:
+            gateway.stop()
+
+        assert b"already playing" in received_second
+        assert b"hello" not in received_second
+        assert b"ok look" in received_first
+
+    def test_idle_sessions_marked_afk_then_dropped(self):
+        """Test that who shows an idle session as AFK, and the timeout disconnects it."""
+        gateway = TelnetGateway(
+            "127.0.0.1", 0, banner="hello", afk_after=0.3, idle_timeout=1.0
+        )
+        gateway.start()
+        try:
+            client = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            deadline = time.time() + 5
+            while not gateway.who() and time.time() < deadline:
+                time.sleep(0.01)
+            fresh = gateway.who()
+            time.sleep(0.4)
+            idle = gateway.who()
+
+            received = self.read_until_closed(client, deadline)
+            client.close()
+            while gateway.who() and time.time() < deadline:
+                time.sleep(0.01)
+            after = gateway.who()
+        finally:
+            gateway.stop()
+
+        assert [session["afk"] for session in fresh] == [False]
+        assert [session["afk"] for session in idle] == [True]
+        assert b"idle too long. Disconnecting. [disconnect: idle]" in received
+        assert after == []
+
+    def test_connections_over_the_cap_are_refused(self):
+        """Test that the guard turns away extra connections before they get a session."""
+        guard = ConnectionGuard(max_per_ip=1, attempts=0)
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello", guard=guard)
+        gateway.start()
+        try:
+            first = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            deadline = time.time() + 5
+            received = b""
+            while b"hello" not in received and time.time() < deadline:
+                received += first.recv(1024)
+            second = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            refused = self.read_until_closed(second, deadline)
+            second.close()
+            first.close()
+            while guard.open and time.time() < deadline:
+                time.sleep(0.01)
+        finally:
+            gateway.stop()
+
+        assert b"Too many connections" in refused
+        assert b"hello" not in refused
+        assert not guard.open
+
+    def test_motd_and_broadcast(self):
+        """Test that clients get the MOTD on login and announcements unprompted."""
+        gateway = TelnetGateway(
+            "127.0.0.1", 0, banner="hello", motd=lambda: "Double XP weekend"
+        )
+        gateway.start()
+        try:
+            with socket.create_connection(("127.0.0.1", gateway.port), timeout=5) as client:
+                deadline = time.time() + 5
+                received = b""
+                while b"Double XP" not in received and time.time() < deadline:
+                    received += client.recv(1024)
+                while not gateway.who() and time.time() < deadline:
+                    time.sleep(0.01)
+
+                gateway.broadcast("Announcement: Restart in 5 minutes")
+                while b"Restart" not in received and time.time() < deadline:
+                    received += client.recv(1024)
+        finally:
+            gateway.stop()
+
+        assert b"hello\r\nMessage of the day: Double XP weekend\r\n> " in received
+        assert b"*** Announcement: Restart in 5 minutes ***\r\n> " in received
+
+    def test_closed_gateway_refuses_and_disconnects(self):
+        """Test that closing for a restart kicks sessions and turns new ones away."""
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello")
+        gateway.start()
+        try:
+            deadline = time.time() + 5
+            client = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            received = b""
+            while b"hello" not in received and time.time() < deadline:
+                received += client.recv(1024)
+            while not gateway.who() and time.time() < deadline:
+                time.sleep(0.01)
+
+            gateway.closed = "Restarting."
+            gateway.disconnect_all("The server is restarting.")
+            received += self.read_until_closed(client, deadline)
+            client.close()
+
+            late = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            refused = self.read_until_closed(late, deadline)
+            late.close()
+        finally:
+            gateway.stop()
+
+        assert b"The server is restarting. Disconnecting. [disconnect: shutdown]" in received
+        assert refused == b"Restarting.\r\n"
+
+    def test_kick_and_ban(self):
+        """Test that a kicked session is told why and a ban keeps its address out."""
+        guard = ConnectionGuard(attempts=0)
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello", guard=guard)
+        gateway.start()
+        try:
+            deadline = time.time() + 5
+            client = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            received = b""
+            while b"hello" not in received and time.time() < deadline:
+                received += client.recv(1024)
+            while not gateway.who() and time.time() < deadline:
+                time.sleep(0.01)
+
+            missed = gateway.kick("nobody")
+            closed = gateway.kick("127.0.0.1", "Cheating.", ban_seconds=60)
+            received += self.read_until_closed(client, deadline)
+            client.close()
+            late = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            refused = self.read_until_closed(late, deadline)
+            late.close()
+        finally:
+            gateway.stop()
+
+        assert (missed, closed) == (0, 1)
+        assert received.endswith(b"Cheating. Disconnecting. [disconnect: banned]\r\n")
+        assert b"Try again later" in refused
+
+    def test_kick_does_not_wait_for_a_stalled_client(self):
+        """Test that kicking returns at once and the socket still closes once
+        the notice has had its time to go out."""
+        ours, theirs = socket.socketpair()
+        handler = _TelnetHandler.__new__(_TelnetHandler)
+        handler.request = ours
+        handler.outbox = OutboundQueue()  # No writer: the notice never leaves
+
+        started = time.monotonic()
+        handler.kick("Cheating.")
+        returned = time.monotonic() - started
+        theirs.settimeout(FLUSH_TIMEOUT + 3)
+        closed = theirs.recv(1024)
+        waited = time.monotonic() - started
+        ours.close()
+        theirs.close()
+
+        assert returned < 0.5
+        assert closed == b"" and waited >= FLUSH_TIMEOUT - 0.1
+
+    def test_netstat_counts_traffic(self):
+        """Test that netstat counts bytes and lines by command for a connection."""
+        gateway = TelnetGateway("127.0.0.1", 0, banner="hello")
+        gateway.start()
+        try:
+            with socket.create_connection(("127.0.0.1", gateway.port), timeout=5) as client:
+                client.sendall(b"look\r\nlook around\r\nsay hi\r\n")
+                deadline = time.time() + 5
+                handled = 0
+                while handled < 3 and time.time() < deadline:
+                    handled += gateway.poll(lambda line: "ok", snapshot=lambda: "VIEW")
+                    time.sleep(0.01)
+                received = b""
+                while received.count(b"ok") < 3 and time.time() < deadline:
+                    received += client.recv(1024)
+                (stats,) = gateway.netstat()
+        finally:
+            gateway.stop()
+
+        assert stats["messages_in"] == {"look": 2, "say": 1}
+        assert stats["bytes_in"] == len(b"look\r\nlook around\r\nsay hi\r\n")
+        assert 0 < stats["bytes_out"] <= len(received)
+        assert stats["queue"]["high_water"] >= 1
+
+    def test_sign_in_with_token(self):
+        """Test that with an authenticator only a valid token gets a session."""
+        secret = "s3cret"
+        gateway = TelnetGateway(
+            "127.0.0.1",
+            0,
+            banner="hello",
+            authenticate=lambda token: verify_token(token, secret)["sub"],
+        )
+        gateway.start()
+        try:
+            deadline = time.time() + 5
+            rejected = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            rejected.sendall(b"login nonsense\r\nlogin a.b.c\r\nforged\r\n")
+            received_rejected = self.read_until_closed(rejected, deadline)
+            rejected.close()
+
+            client = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            token = issue_token({"sub": "wren"}, secret)
+            client.sendall(f"login {token}\r\n".encode())
+            received = b""
+            while b"hello" not in received and time.time() < deadline:
+                received += client.recv(1024)
+            sessions = gateway.who()
+            client.close()
+        finally:
+            gateway.stop()
+
+        assert received_rejected.count(b"Sign-in failed") == 3
+        assert b"hello" not in received_rejected
+        assert b"Signed in as wren." in received
+        assert [session["user"] for session in sessions] == ["wren"]
+        assert "address" not in sessions[0]
+
+    def test_sign_in_times_out(self):
+        """Test that a client that never sends a token is disconnected."""
+        gateway = TelnetGateway(
+            "127.0.0.1",
+            0,
+            banner="hello",
+            idle_timeout=30.0,
+            authenticate=lambda token: token,
+            login_timeout=0.3,
+        )
+        gateway.start()
+        try:
+            client = socket.create_connection(("127.0.0.1", gateway.port), timeout=5)
+            started = time.time()
+            received = self.read_until_closed(client, started + 5)
+            waited = time.time() - started
+            client.close()
+        finally:
+            gateway.stop()
+
+        assert b"Sign-in timed out. Disconnecting." in received
+        assert b"hello" not in received
+        assert waited < 5
+
+    def test_trickling_sign_in_times_out(self):
+        """Test that sending a byte now and then does not stretch the sign-in."""
+        gateway = TelnetGateway(
+            "127.0.0.1",
+            0,
+            banner="hello",
+            idle_timeout=30.0,
+            authenticate=lambda token: token,
+            login_timeout=0.5,
+        )
+        gateway.start()
+        try:
+            client = socket.create_connection(("127.0.0.1", gateway.port), timeout=0.1)
+            started = time.time()
+            received = b""
+            while time.time() < started + 5:
+                try:
+                    client.sendall(b"l")
+                    chunk = client.recv(1024)
+                except socket.timeout:
+                    continue
+                except OSError:
+                    break
+                if not chunk:
+                    break
+                received += chunk
+            waited = time.time() - started
+            client.close()
+        finally:
+            gateway.stop()
+
+        assert b"Sign-in timed out. Disconnecting." in received
+        assert waited < 3
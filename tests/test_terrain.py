@@ -0,0 +1,52 @@
+"""
+Tests for terrain tile properties.
+"""
+
+from world.map import (
+    GameMap,
+    TILE_CACTUS,
+    TILE_FLOOR,
+    TILE_ICE,
+    TILE_LAVA,
+    TILE_SAND,
+    TILE_WATER,
+)
+
+
+class TestTerrainProperties:
+    """Test the tile-properties table loaded from tiles.json."""
+
+    def test_properties_loaded(self):
+        """Test that terrain properties are read from the tile data."""
+        game_map = GameMap(3, 3)
+        tiles = game_map.tile_definitions
+
+        assert tiles[TILE_ICE].slippery
+        assert tiles[TILE_SAND].struggle_chance > 0
+        assert tiles[TILE_CACTUS].contact_damage > 0
+        assert tiles[TILE_FLOOR].move_cost == 1.0
+
+    def test_water_requires_swimming(self):
+        """Test that deep water blocks non-swimmers."""
+        game_map = GameMap(3, 1)
+        game_map.tiles[0, 1] = TILE_WATER
+
+        assert not game_map.can_enter(1, 0)
+        assert game_map.can_enter(1, 0, swimming=1)
+
+    def test_hazards_enterable_but_not_walkable(self):
+        """Test that lava can be stepped into but never counts as ground."""
+        game_map = GameMap(3, 1)
+        game_map.tiles[0, 1] = TILE_LAVA
+
+        assert game_map.can_enter(1, 0)
+        assert not game_map.can_enter(1, 0, allow_hazards=False)
+        assert not game_map.is_walkable(1, 0)
+
+    def test_move_cost(self):
+        """Test that slow terrain costs more to path through."""
+        game_map = GameMap(2, 1)
+        game_map.tiles[0, 0] = TILE_FLOOR
+        game_map.tiles[0, 1] = TILE_SAND
+
+        assert game_map.move_cost(1, 0) > game_map.move_cost(0, 0)
@@ -0,0 +1,66 @@
+"""
+Tests for MUD-style text command parsing.
+"""
+
+from input.text_commands import direction_name, parse, resolve_target
+
+CREATURES = [
+    (10, "Goblin", 4),
+    (11, "Elite Goblin", 2),
+    (12, "Citizen", 1),
+]
+
+
+class TestParse:
+    """Test verbs, aliases and arguments."""
+
+    def test_directions(self):
+        """Test that bare directions and 'go' both move."""
+        assert parse("n").verb == "go"
+        assert parse("n").args == ["n"]
+        assert parse("walk South").args == ["south"]
+
+    def test_aliases_and_filler(self):
+        """Test that verb aliases map to one verb and filler words are dropped."""
+        command = parse("kill the goblin")
+
+        assert command.verb == "attack"
+        assert command.args == ["goblin"]
+        assert parse("talk to tutor").args == ["tutor"]
+
+    def test_say_keeps_text(self):
+        """Test that speech keeps its original wording."""
+        assert parse("say Hello there").text == "Hello there"
+        assert parse("'Hi!").verb == "say"
+        assert parse("'Hi!").text == "Hi!"
+
+    def test_blank_and_unknown(self):
+        """Test that blank lines parse to nothing and unknown verbs pass through."""
+        assert parse("   ") is None
+        assert parse("stealth").verb == "stealth"
+
+
+class TestTargeting:
+    """Test resolving target names against nearby creatures."""
+
+    def test_nearest_match_wins(self):
+        """Test that the closest creature whose name matches is chosen."""
+        assert resolve_target(["goblin"], CREATURES) == 11
+        assert resolve_target(["cit"], CREATURES) == 12
+
+    def test_indexed_target(self):
+        """Test that 2.goblin picks the second nearest goblin."""
+        assert resolve_target(["2.goblin"], CREATURES) == 10
+        assert resolve_target(["3.goblin"], CREATURES) is None
+
+    def test_no_match(self):
+        """Test that unknown names resolve to nobody."""
+        assert resolve_target(["dragon"], CREATURES) is None
+        assert resolve_target([], CREATURES) is None
+
+    def test_direction_names(self):
+        """Test compass directions for offsets."""
+        assert direction_name(0, -3) == "north"
+        assert direction_name(2, 2) == "southeast"
+        assert direction_name(5, 1) == "east"
+        assert direction_name(0, 0) == "here"
@@ -0,0 +1,48 @@
+"""
+Tests for run-length encoding tile grids in client messages.
+"""
+
+import json
+
+import pytest
+
+from world.tile_codec import TileCodecError, decode_tiles, encode_tiles
+
+
+class TestTileCodec:
+    """Test encoding and decoding tile grids."""
+
+    def test_round_trip(self):
+        """Test that a grid decodes back to itself, palette in first-seen order."""
+        rows = [[4, 4, 4], [4, 0, 0]]
+
+        encoded = encode_tiles(rows)
+
+        assert encoded["palette"] == [4, 0]
+        assert encoded["runs"] == [0, 4, 1, 2]
+        assert decode_tiles(encoded) == rows
+
+    def test_view_is_much_smaller(self):
+        """Test that a mostly uniform 21x21 view shrinks well below raw JSON."""
+        rows = [[1] * 21 for _ in range(21)]
+        rows[10][10] = 7
+
+        raw = len(json.dumps(rows))
+        encoded = len(json.dumps(encode_tiles(rows)))
+
+        assert encoded * 10 < raw
+
+    def test_bad_payloads_are_refused(self):
+        """Test runs that overflow, underflow or point outside the palette."""
+        good = encode_tiles([[1, 2], [2, 2]])
+        for change in (
+            {"runs": [0, 1, 1, 2]},
+            {"runs": [0, 1, 1, 9]},
+            {"runs": [0, 1, 5, 3]},
+            {"runs": [0, 0, 1, 4]},
+            {"runs": [0, 1, 1]},
+            {"width": -1},
+            {"encoding": "raw"},
+        ):
+            with pytest.raises(TileCodecError):
+                decode_tiles({**good, **change})
@@ -0,0 +1,70 @@
+"""
+Tests for signed sign-in tokens.
+"""
+
+import base64
+import json
+
+import pytest
+
+from systems.tokens import TokenError, issue_token, verify_token
+
+SECRET = "correct horse battery staple"
+
+
+def forge_unsigned(claims):
+    """A token claiming alg "none", as an attacker would send."""
+    def part(data):
+        return base64.urlsafe_b64encode(json.dumps(data).encode()).rstrip(b"=").decode()
+
+    return f"{part({'alg': 'none'})}.{part(claims)}."
+
+
+class TestTokens:
+    """Test token signatures and claim checks."""
+
+    def test_valid_token_returns_claims(self):
+        """Test that a well-formed token comes back with its claims."""
+        token = issue_token(
+            {"sub": "wren", "exp": 2000, "iss": "idp", "aud": ["terminus-realm"]}, SECRET
+        )
+
+        claims = verify_token(token, SECRET, "idp", "terminus-realm", now=1000)
+
+        assert claims["sub"] == "wren"
+
+    def test_tampered_or_foreign_tokens_rejected(self):
+        """Test that a changed payload, a different secret and alg none all fail."""
+        token = issue_token({"sub": "wren"}, SECRET)
+        header, _, signature = token.split(".")
+        payload = base64.urlsafe_b64encode(b'{"sub":"admin"}').rstrip(b"=").decode()
+
+        for bad in (
+            f"{header}.{payload}.{signature}",
+            issue_token({"sub": "wren"}, "another secret"),
+            forge_unsigned({"sub": "wren"}),
+            "not a token",
+            "a.b.c",
+        ):
+            with pytest.raises(TokenError):
+                verify_token(bad, SECRET)
+
+    def test_claims_are_enforced(self):
+        """Test expiry, not-before, issuer, audience and subject checks."""
+        cases = [
+            ({"sub": "wren", "exp": 900}, {}, "expired"),
+            ({"sub": "wren", "nbf": 1100}, {}, "not valid yet"),
+            ({"sub": "wren", "iss": "elsewhere"}, {"issuer": "idp"}, "issuer"),
+            ({"sub": "wren", "aud": "other-game"}, {"audience": "terminus-realm"}, "audience"),
+            ({"exp": 2000}, {}, "subject"),
+            ({"sub": "wren", "exp": "soon"}, {}, "malformed"),
+        ]
+        for claims, options, message in cases:
+            with pytest.raises(TokenError, match=message):
+                verify_token(issue_token(claims, SECRET), SECRET, now=1000, **options)
+
+    def test_leeway_allows_small_clock_skew(self):
+        """Test that a token just past expiry still passes within the leeway."""
+        token = issue_token({"sub": "wren", "exp": 1000}, SECRET)
+
+        assert verify_token(token, SECRET, now=1020)["sub"] == "wren"
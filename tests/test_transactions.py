@@ -0,0 +1,249 @@
+"""
+Tests for transactional item and gold operations.
+"""
+
+import pytest
+from core.ecs import EntityManager
+from entities.components import Inventory, BankAccount, Position, Item
+from systems.transactions import ItemTransaction, TransactionError, TransactionJournal
+
+
+def make_item(entity_manager, x=0, y=0):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Position(x, y))
+    entity_manager.add_component(eid, Item(name="Pebble", description=""))
+    return eid
+
+
+class TestItemTransaction:
+    """Test all-or-nothing application of staged operations."""
+
+    def test_gold_transfer(self, entity_manager):
+        """Test moving gold between containers."""
+        inv = Inventory(capacity=5, items=[], gold=50)
+        bank = BankAccount()
+
+        ItemTransaction(entity_manager).transfer_gold(inv, bank, 20).commit()
+
+        assert inv.gold == 30
+        assert bank.gold == 20
+
+    def test_insufficient_gold_changes_nothing(self, entity_manager):
+        """Test that a failing step leaves every container untouched."""
+        inv = Inventory(capacity=5, items=[], gold=10)
+        item = make_item(entity_manager)
+
+        with pytest.raises(TransactionError):
+            ItemTransaction(entity_manager).add_item(inv, item).remove_gold(
+                inv, 20
+            ).commit()
+
+        assert inv.gold == 10
+        assert inv.items == []
+
+    def test_full_container_rejected(self, entity_manager):
+        """Test that capacity is enforced before anything is applied."""
+        inv = Inventory(capacity=1, items=[], gold=0)
+        bank = BankAccount(capacity=0)
+        item = make_item(entity_manager)
+        inv.items.append(item)
+
+        with pytest.raises(TransactionError):
+            ItemTransaction(entity_manager).move_item(inv, bank, item).commit()
+
+        assert inv.items == [item]
+        assert bank.items == []
+
+    def test_no_duplicate_items(self, entity_manager):
+        """Test that the same item cannot be granted twice."""
+        inv = Inventory(capacity=5, items=[], gold=0)
+        item = make_item(entity_manager)
+
+        with pytest.raises(TransactionError):
+            ItemTransaction(entity_manager).add_item(inv, item).add_item(
+                inv, item
+            ).commit()
+
+        assert inv.items == []
+
+    def test_pickup_removes_from_ground(self, entity_manager):
+        """Test that picking up takes the item off the map."""
+        inv = Inventory(capacity=5, items=[], gold=0)
+        item = make_item(entity_manager, 3, 3)
+
+        ItemTransaction(entity_manager).pickup_item(inv, item).commit()
+
+        assert item in inv.items
+        assert not entity_manager.has_component(item, Position)
+
+    def test_destroy_after_sale(self, entity_manager):
+        """Test that sold items are destroyed only after the sale applies."""
+        inv = Inventory(capacity=5, items=[], gold=0)
+        item = make_item(entity_manager)
+        inv.items.append(item)
+
+        with ItemTransaction(entity_manager) as tx:
+            tx.remove_item(inv, item).add_gold(inv, 7).destroy_item(item)
+
+        assert inv.gold == 7
+        assert item not in entity_manager.entities
+
+
+class TestTransactionJournal:
+    """Test the write-ahead journal."""
+
+    def test_bound_items_cannot_change_hands(self, entity_manager):
+        """Test that a bound item is refused by trades but may still be banked."""
+        inv = Inventory(capacity=5, items=[], gold=0)
+        other = Inventory(capacity=5, items=[], gold=0)
+        bank = BankAccount()
+        item = make_item(entity_manager)
+        inv.items.append(item)
+        entity_manager.get_component(item, Item).bound_to = "Ayla"
+
+        with pytest.raises(TransactionError, match="bound to Ayla"):
+            ItemTransaction(entity_manager, reason="trade").move_item(
+                inv, other, item
+            ).commit()
+        assert inv.items == [item] and other.items == []
+
+        banking = ItemTransaction(entity_manager, reason="bank")
+        banking.move_item(inv, bank, item).commit()
+        assert bank.items == [item]
+
+    def test_price_in_several_currencies(self, entity_manager):
+        """Test that a mixed price is paid in full or not at all."""
+        inv = Inventory(capacity=5, items=[], gold=30)
+        inv.currencies["event_token"] = 2
+
+        with pytest.raises(TransactionError, match="Not enough event_token"):
+            ItemTransaction(entity_manager).spend(
+                inv, {"gold": 10, "event_token": 3}
+            ).commit()
+        assert inv.gold == 30 and inv.currencies == {"event_token": 2}
+
+        ItemTransaction(entity_manager).spend(
+            inv, {"gold": 10, "event_token": 2}
+        ).grant(inv, {"crown": 5}).commit()
+        assert inv.gold == 20 and inv.currencies == {"crown": 5}
+
+    def test_currencies_need_a_wallet(self, entity_manager):
+        """Test that only wallets hold currencies other than gold."""
+        bank = BankAccount()
+
+        with pytest.raises(TransactionError, match="cannot hold"):
+            ItemTransaction(entity_manager).add_currency(bank, "silver", 1).commit()
+        ItemTransaction(entity_manager).add_currency(bank, "gold", 4).commit()
+        assert bank.gold == 4
+
+    def test_unknown_currency_rejected(self, entity_manager):
+        """Test that a grant of a currency that does not exist fails."""
+        inv = Inventory(capacity=5, items=[], gold=0)
+
+        with pytest.raises(TransactionError, match="Unknown currency"):
+            ItemTransaction(entity_manager).grant(inv, {"doubloon": 1}).commit()
+        assert inv.currencies == {}
+
+    def test_commit_is_journaled(self, entity_manager, tmp_path):
+        """Test that committed transactions leave no incomplete records."""
+        journal = TransactionJournal(str(tmp_path / "journal.jsonl"))
+        inv = Inventory(capacity=5, items=[], gold=5)
+
+        ItemTransaction(entity_manager, journal, "test").remove_gold(inv, 5).commit()
+
+        records = journal.read()
+        assert [r["status"] for r in records] == ["begin", "commit"]
+        assert records[0]["reason"] == "test"
+        assert journal.incomplete() == []
+
+    def test_incomplete_detected(self, tmp_path):
+        """Test that a begin without an outcome is reported as incomplete."""
+        journal = TransactionJournal(str(tmp_path / "journal.jsonl"))
+        journal.begin("tx-1", "crash", [])
+
+        assert [r["txid"] for r in journal.incomplete()] == ["tx-1"]
+
+    def test_journal_names_containers(self, entity_manager, tmp_path):
+        """Test that begin records name each container's entity and slot and
+        what it held beforehand."""
+        journal = TransactionJournal(str(tmp_path / "journal.jsonl"))
+        player = entity_manager.create_entity()
+        inv = Inventory(capacity=5, items=[], gold=10)
+        entity_manager.add_component(player, inv)
+        bank = BankAccount(gold=3)
+
+        ItemTransaction(entity_manager, journal, "bank", player=player).transfer_gold(
+            inv, bank, 4
+        ).commit()
+
+        begin = journal.read()[0]
+        assert [(op["entity"], op["slot"]) for op in begin["ops"]] == [
+            ("player", "Inventory"),
+            (None, "BankAccount"),
+        ]
+        assert [(held["slot"], held["gold"]) for held in begin["before"]] == [
+            ("Inventory", 10),
+            ("BankAccount", 3),
+        ]
+
+    def test_interrupted_transaction_rolled_back(self, entity_manager, tmp_path):
+        """Test that a transaction cut off after its begin record is undone on
+        load, only in what is saved, and only once."""
+        path = str(tmp_path / "journal.jsonl")
+        player = entity_manager.create_entity()
+        inv = Inventory(capacity=5, items=[], gold=50, currencies={"event_token": 5})
+        entity_manager.add_component(player, inv)
+        stash = BankAccount()
+
+        class Crash(TransactionJournal):
+            def commit(self, txid):
+                raise SystemExit("power cut")
+
+        with pytest.raises(SystemExit):
+            ItemTransaction(entity_manager, Crash(path), "crash", player=player).transfer_gold(
+                inv, stash, 20
+            ).remove_currency(inv, "event_token", 2).commit()
+        assert (inv.gold, stash.gold, inv.currencies) == (30, 20, {"event_token": 3})
+
+        journal = TransactionJournal(path)
+        rolled_back = journal.recover(entity_manager, player, {"BankAccount": stash})
+
+        assert [r["reason"] for r in rolled_back] == ["crash"]
+        # Carried gold is not saved, so it is left as it is
+        assert (inv.gold, stash.gold, inv.currencies) == (30, 0, {"event_token": 5})
+        assert journal.incomplete() == []
+        assert journal.recover(entity_manager, player, {"BankAccount": stash}) == []
+
+    def test_items_rolled_back_after_restart(self, entity_manager, tmp_path):
+        """Test that items are rebuilt from the journal when a new process,
+        whose entity IDs mean other things, rolls a transaction back."""
+        path = str(tmp_path / "journal.jsonl")
+        player = entity_manager.create_entity()
+        inv = Inventory(capacity=5, items=[], gold=0)
+        entity_manager.add_component(player, inv)
+        pebble = make_item(entity_manager)
+        entity_manager.remove_component(pebble, Position)
+        stash = BankAccount(items=[pebble])
+
+        class Crash(TransactionJournal):
+            def commit(self, txid):
+                raise SystemExit("power cut")
+
+        with pytest.raises(SystemExit):
+            ItemTransaction(entity_manager, Crash(path), "stash", player=player).move_item(
+                stash, inv, pebble
+            ).commit()
+
+        restarted = EntityManager()
+        player = restarted.create_entity()
+        restarted.add_component(player, Inventory(capacity=5, items=[], gold=0))
+        stranger = make_item(restarted)
+        assert stranger == pebble
+        stash = BankAccount()
+
+        TransactionJournal(path).recover(restarted, player, {"BankAccount": stash})
+
+        assert len(stash.items) == 1 and stash.items[0] != stranger
+        assert restarted.get_component(stash.items[0], Item).name == "Pebble"
+        assert restarted.get_component(stash.items[0], Position) is None
+        assert restarted.get_component(stranger, Position) is not None
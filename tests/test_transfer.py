@@ -0,0 +1,103 @@
+"""
+Tests for moving characters between worlds' rosters.
+"""
+
+import pytest
+
+from world.transfer import (
+    Roster,
+    TransferError,
+    merge,
+    remap_position,
+    transfer,
+    unique_name,
+)
+
+
+def make_rosters(tmp_path):
+    source = Roster(str(tmp_path / "isles.json"))
+    target = Roster(str(tmp_path / "main.json"))
+    return source, target
+
+
+def to_start(record):
+    return (1, 1, 0)
+
+
+class TestRoster:
+    """Test recording where characters are in a world."""
+
+    def test_put_and_reload(self, tmp_path):
+        """Test that a roster keeps positions and first arrival across saves."""
+        roster = Roster(str(tmp_path / "players" / "main.json"))
+        roster.put("Rook", 3, 4, 1)
+        joined = roster.get("Rook")["joined"]
+        roster.put("Rook", 5, 6)
+        roster.save()
+
+        record = Roster(roster.path).get("Rook")
+
+        assert (record["x"], record["y"], record["z"]) == (5, 6, 0)
+        assert record["joined"] == joined
+
+    def test_empty(self, tmp_path):
+        """Test that a world nobody has played in has an empty roster."""
+        roster = Roster(str(tmp_path / "nobody.json"))
+
+        assert roster.players == {} and roster.get("Rook") is None
+        with pytest.raises(TransferError):
+            roster.remove("Rook")
+
+
+class TestTransfer:
+    """Test moving characters and resolving name collisions."""
+
+    def test_unique_name(self):
+        """Test that taken names get the source world appended, then numbered."""
+        assert unique_name("Rook", ["Ash"], "isles") == "Rook"
+        assert unique_name("Rook", ["Rook"], "isles") == "Rook-isles"
+        assert unique_name("Rook", ["Rook", "Rook-isles"], "isles") == "Rook-isles2"
+
+    def test_transfer(self, tmp_path):
+        """Test that a character leaves the source and arrives remapped in the target."""
+        source, target = make_rosters(tmp_path)
+        source.put("Rook", 30, 40, 2)
+
+        name = transfer(source, target, "Rook", "isles", to_start)
+
+        assert name == "Rook" and source.get("Rook") is None
+        record = target.get("Rook")
+        assert (record["x"], record["y"], record["z"]) == (1, 1, 0)
+        assert record["from"] == "isles" and "renamed_from" not in record
+
+    def test_merge_renames_collisions(self, tmp_path):
+        """Test that merging moves everyone and renames those whose names are taken."""
+        source, target = make_rosters(tmp_path)
+        source.put("Rook", 30, 40)
+        source.put("Ash", 10, 10)
+        target.put("Rook", 2, 2)
+        target.put("Rook-isles", 2, 2)
+
+        names = merge(source, target, "isles", to_start)
+
+        assert names == {"Ash": "Ash", "Rook": "Rook-isles2"}
+        assert source.players == {}
+        assert target.get("Rook-isles2")["renamed_from"] == "Rook"
+        assert (target.get("Rook")["x"], target.get("Rook")["y"]) == (2, 2)
+
+
+class TestRemap:
+    """Test where a transferred character arrives."""
+
+    def test_same_map_keeps_the_spot(self):
+        """Test that a spot carries over between copies of the same map."""
+        record = {"x": 5, "y": 6, "z": 1}
+
+        assert remap_position(record, True, (0, 0), lambda x, y, z: True) == (5, 6, 1)
+
+    def test_blocked_or_other_map_goes_to_start(self):
+        """Test that a blocked spot or a different map sends the character to the start."""
+        record = {"x": 5, "y": 6, "z": 1}
+
+        assert remap_position(record, True, (9, 9), lambda x, y, z: False) == (9, 9, 0)
+        assert remap_position(record, False, (9, 9), lambda x, y, z: True) == (9, 9, 0)
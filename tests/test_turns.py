@@ -0,0 +1,79 @@
+"""
+Tests for turn-based combat order.
+"""
+
+import pytest
+
+from systems.turns import TURNS, TurnOrder
+
+PLAYER = 1
+
+
+def rolls(*values):
+    """A roll function that returns values in turn."""
+    queue = list(values)
+    return lambda: queue.pop(0)
+
+
+def turn_order(*values):
+    return TurnOrder({"mode": TURNS, "turn_seconds": 0.5}, roll=rolls(*values))
+
+
+class TestTurnOrder:
+    """Test who acts when in a turn-based fight."""
+
+    def test_realtime_by_default(self):
+        """Test that turns are off unless configured, and bad modes are refused."""
+        assert not TurnOrder().enabled
+        assert TurnOrder({"mode": "turns"}).enabled
+        with pytest.raises(ValueError):
+            TurnOrder({"mode": "bullet_time"})
+
+    def test_initiative_order(self):
+        """Test that the order is by roll plus speed, the fight starts at the top,
+        and the player wins ties."""
+        turns = turn_order(5, 14, 5)  # Player, then monsters 7 and 8
+
+        turns.sync(PLAYER, {7: 0.5, 8: 1.0})
+
+        assert turns.order == [7, PLAYER, 8]
+        assert turns.current == 7 and turns.round == 1
+        turns.advance()
+        assert turns.player_turn
+        turns.advance()
+        turns.advance()
+        assert turns.current == 7 and turns.round == 2
+
+    def test_monster_turns_wait_turn_seconds(self):
+        """Test that a monster's turn comes due only after the pause."""
+        turns = turn_order(1, 20)
+        turns.sync(PLAYER, {7: 1.0})
+
+        assert not turns.due(0.25)
+        assert turns.due(0.25)
+        turns.advance()
+        assert not turns.due(5.0)  # The player's turn is never due
+
+    def test_newcomers_wait_for_the_next_round(self):
+        """Test that a monster joining ahead of whoever is acting goes next round."""
+        turns = turn_order(10, 1, 20)
+        turns.sync(PLAYER, {7: 0.0})
+        assert turns.player_turn
+
+        joined = turns.sync(PLAYER, {7: 0.0, 8: 0.5})
+
+        assert joined == [8]
+        assert turns.order == [8, PLAYER, 7]
+        assert turns.player_turn
+
+    def test_leaving_and_ending(self):
+        """Test that monsters out of range drop out and the fight ends with none left."""
+        turns = turn_order(10, 15, 1)
+        turns.sync(PLAYER, {7: 1.0, 8: 0.0})
+        assert turns.current == 7
+
+        turns.sync(PLAYER, {8: 0.0})
+        assert turns.order == [PLAYER, 8] and turns.player_turn
+
+        turns.sync(PLAYER, {})
+        assert not turns.active and turns.current is None
@@ -0,0 +1,63 @@
+"""
+Tests for the new-player tutorial.
+"""
+
+from entities.components import Onboarding
+from systems.tutorial import TutorialSystem
+
+STEPS = {
+    "steps": [
+        {"id": "move", "event": "move", "count": 2, "hint": "Walk."},
+        {"id": "fight", "event": "kill", "target": "training_dummy", "hint": "Fight."},
+        {"id": "talk", "event": "talk", "target": "tutor", "hint": "Talk."},
+    ],
+    "complete": "Done!",
+}
+
+
+def make_newcomer(entity_manager):
+    eid = entity_manager.create_entity()
+    entity_manager.add_component(eid, Onboarding())
+    return eid
+
+
+class TestTutorial:
+    """Test tutorial step progression and saved progress."""
+
+    def test_steps_complete_in_order(self, entity_manager):
+        """Test that each step needs its own action, the right target and enough repeats."""
+        tutorial = TutorialSystem(entity_manager, STEPS)
+        eid = make_newcomer(entity_manager)
+
+        assert tutorial.record(eid, "kill", "training_dummy") is None
+        assert tutorial.record(eid, "move") is None
+        assert tutorial.record(eid, "move") == "step"
+        assert tutorial.hint(eid) == "Fight."
+
+        assert tutorial.record(eid, "kill", "goblin") is None
+        assert tutorial.record(eid, "kill", "training_dummy") == "step"
+        assert tutorial.record(eid, "talk", "tutor") == "complete"
+
+        assert not tutorial.in_progress(eid)
+        assert tutorial.current_step(eid) is None
+        assert tutorial.record(eid, "move") is None
+
+    def test_progress_round_trips_through_profile(self, entity_manager):
+        """Test that a player resumes the tutorial where they left off."""
+        tutorial = TutorialSystem(entity_manager, STEPS)
+        eid = make_newcomer(entity_manager)
+        tutorial.record(eid, "move")
+        tutorial.record(eid, "move")
+
+        saved = tutorial.to_profile(eid)
+        resumed = make_newcomer(entity_manager)
+        tutorial.from_profile(resumed, saved)
+
+        assert tutorial.hint(resumed) == "Fight."
+        assert tutorial.in_progress(resumed)
+
+    def test_bundled_steps_load(self, entity_manager):
+        """Test that the shipped tutorial covers moving, fighting, looting and talking."""
+        tutorial = TutorialSystem(entity_manager)
+
+        assert [step["event"] for step in tutorial.steps] == ["move", "kill", "pickup", "talk"]
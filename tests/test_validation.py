@@ -0,0 +1,164 @@
+"""
+Tests for the action validator the command registry checks before dispatching.
+"""
+
+from core.commands import CommandRegistry
+from core.validation import (
+    INVALID_STATE,
+    NOT_AUTHORIZED,
+    NOT_YOUR_TURN,
+    OUT_OF_RANGE,
+    RATE_LIMITED,
+    ActionError,
+    ActionValidator,
+)
+
+
+class FakeWorld:
+    """Clock, caller roles, player position and game state the validator reads."""
+
+    def __init__(self):
+        self.now = 0.0
+        self.roles = {"admin"}
+        self.position = (10, 10)
+        self.alive = True
+
+
+def make_registry(world, calls, cadence=0.0):
+    validator = ActionValidator(
+        cadence=cadence,
+        clock=lambda: world.now,
+        roles=lambda: world.roles,
+        position=lambda: world.position,
+    )
+    validator.state("alive", lambda: world.alive, "You are dead.")
+    commands = CommandRegistry(validator)
+    commands.action(
+        "move", lambda dx, dy: calls.append(("move", dx, dy)), "Step", ("dx", "dy"),
+        requires=("alive",), target=("dx", "dy"), reach=1, relative=True,
+    )
+    commands.action(
+        "move_to", lambda x, y: calls.append(("move_to", x, y)), "Walk", ("x", "y"),
+        target=("x", "y"), reach=5,
+    )
+    commands.action("wait", lambda: calls.append(("wait",)), "Wait")
+    commands.request("stats", lambda: {"type": "stats"}, "Stats")
+    commands.request("kick", lambda: {"type": "kicked"}, "Kick someone")
+    commands.restrict("admin", ["kick", "not_registered"])
+    return commands
+
+
+class TestActionValidator:
+    """Test that commands are refused with standard codes before their handlers run."""
+
+    def test_cadence(self):
+        """Test that actions closer together than the cadence are refused."""
+        world, calls, refused = FakeWorld(), [], []
+        commands = make_registry(world, calls, cadence=0.1)
+        commands.on_refused = lambda command, error: refused.append(error.code)
+
+        assert commands.run_action("wait")
+        assert not commands.run_action("wait")
+        world.now = 0.1
+        assert commands.run_action("wait")
+
+        assert calls == [("wait",), ("wait",)]
+        assert refused == [RATE_LIMITED]
+        assert commands.validator.refused == {RATE_LIMITED: 1}
+
+    def test_requests_are_not_paced(self):
+        """Test that data requests never count against the action cadence."""
+        world = FakeWorld()
+        commands = make_registry(world, [], cadence=1.0)
+
+        for _ in range(3):
+            assert commands.handle_request({"type": "stats"}) == {"type": "stats"}
+        assert commands.run_action("wait")
+
+    def test_roles(self):
+        """Test that restricted requests need the caller to have the role."""
+        world = FakeWorld()
+        commands = make_registry(world, [])
+
+        assert commands.handle_request({"type": "kick"}) == {"type": "kicked"}
+        world.roles = set()
+        reply = commands.handle_request({"type": "kick"})
+
+        assert reply["type"] == "error"
+        assert reply["code"] == NOT_AUTHORIZED
+        assert commands.handle_request({"type": "stats"}) == {"type": "stats"}
+        assert {"name": "kick", "description": "Kick someone", "args": [], "role": "admin", "response": "kick"} in commands.help()["requests"]
+
+    def test_state_prerequisites(self):
+        """Test that an action needing a state is refused, with its message, when it fails."""
+        world, calls, refused = FakeWorld(), [], []
+        commands = make_registry(world, calls)
+        commands.on_refused = lambda command, error: refused.append((error.code, error.message))
+
+        world.alive = False
+        assert not commands.run_action("move", 1, 0)
+        assert commands.run_action("wait")
+
+        assert calls == [("wait",)]
+        assert refused == [(INVALID_STATE, "You are dead.")]
+
+    def test_range(self):
+        """Test that relative and absolute targets beyond reach are refused."""
+        world, calls, refused = FakeWorld(), [], []
+        commands = make_registry(world, calls)
+        commands.on_refused = lambda command, error: refused.append(error.code)
+
+        assert commands.run_action("move", 1, -1)
+        assert not commands.run_action("move", 0, 2)
+        assert commands.run_action("move_to", 15, 5)
+        assert not commands.run_action("move_to", 16, 10)
+        assert not commands.run_action("move_to", "15", 10)
+
+        assert calls == [("move", 1, -1), ("move_to", 15, 5)]
+        assert refused == [OUT_OF_RANGE] * 3
+
+    def test_lock(self):
+        """Test that a lock refuses every action but the allowed ones, and not requests."""
+        world, calls, refused = FakeWorld(), [], []
+        commands = make_registry(world, calls)
+        commands.on_refused = lambda command, error: refused.append((error.code, error.message))
+
+        commands.validator.lock("Wait for the scene to finish.", ("wait",))
+        assert not commands.run_action("move", 1, 0)
+        assert commands.run_action("wait")
+        assert commands.handle_request({"type": "stats"}) == {"type": "stats"}
+        commands.validator.unlock()
+        assert commands.run_action("move", 1, 0)
+
+        assert calls == [("wait",), ("move", 1, 0)]
+        assert refused == [(INVALID_STATE, "Wait for the scene to finish.")]
+
+    def test_out_of_turn(self):
+        """Test that actions pacing holds back are refused with its error, and
+        each action that ran is reported so the turn can end."""
+        world, calls, refused, acted = FakeWorld(), [], [], []
+        commands = make_registry(world, calls)
+        commands.on_refused = lambda command, error: refused.append(error.code)
+        commands.on_acted = lambda command: acted.append(command.name)
+        commands.validator.pacing = lambda name: (
+            ActionError(NOT_YOUR_TURN, "Wait for your turn.") if name == "move" else None
+        )
+
+        assert not commands.run_action("move", 1, 0)
+        assert commands.run_action("wait")
+
+        assert calls == [("wait",)]
+        assert refused == [NOT_YOUR_TURN]
+        assert acted == ["wait"]
+
+    def test_no_validator(self):
+        """Test that a registry without a validator dispatches everything."""
+        calls = []
+        commands = CommandRegistry()
+        commands.action("wait", lambda: calls.append("wait"), "Wait")
+        commands.request("kick", lambda: {"type": "kicked"}, "Kick someone")
+        commands.restrict("admin", ["kick"])
+
+        assert commands.run_action("wait") and commands.run_action("wait")
+        assert commands.handle_request({"type": "kick"}) == {"type": "kicked"}
+        assert calls == ["wait", "wait"]
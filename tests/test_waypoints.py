@@ -0,0 +1,63 @@
+"""
+Tests for the waypoint and portal network.
+"""
+
+from world.waypoints import TeleportNetwork
+
+
+class TestTeleportNetwork:
+    """Test waypoint lookup, portal links and travel pricing."""
+
+    def test_waypoint_lookup(self):
+        """Test that waypoints are found by position."""
+        network = TeleportNetwork({"Town Square": (10, 10)})
+        network.add_waypoint("North Shrine", 10, 500)
+
+        assert network.waypoint_at(10, 10) == "Town Square"
+        assert network.waypoint_at(10, 500) == "North Shrine"
+        assert network.waypoint_at(0, 0) is None
+
+    def test_portals_link_both_ways(self):
+        """Test that a portal pair leads in both directions."""
+        network = TeleportNetwork()
+        network.add_portal((1, 1, 0), (900, 900, 0))
+
+        assert network.portal_at(1, 1) == (900, 900, 0)
+        assert network.portal_at(900, 900) == (1, 1, 0)
+
+    def test_places_are_per_level(self):
+        """Test that a waypoint or portal is only on its own Z-level."""
+        network = TeleportNetwork()
+        network.add_waypoint("Deep Camp", 10, 10, z=2)
+        network.add_portal((1, 1, 0), (1, 1, 1))
+
+        assert network.waypoint_at(10, 10, 2) == "Deep Camp"
+        assert network.waypoint_at(10, 10) is None
+        assert network.portal_at(1, 1, 0) == (1, 1, 1)
+        assert network.portal_at(1, 1, 1) == (1, 1, 0)
+
+    def test_saves_without_levels_are_on_the_surface(self):
+        """Test that (x, y) waypoints and portals from older saves load at z 0."""
+        network = TeleportNetwork({"Town Square": (10, 10)}, {(1, 1): (900, 900, 0)})
+
+        assert network.waypoints["Town Square"] == (10, 10, 0)
+        assert network.waypoint_at(10, 10, 0) == "Town Square"
+        assert network.portal_at(1, 1, 0) == (900, 900, 0)
+
+    def test_travel_cost_scales_with_distance(self):
+        """Test the flat cost plus distance-based cost."""
+        network = TeleportNetwork(
+            {"Near": (0, 100), "Far": (0, 1000)},
+            settings={"base_cost": 10, "cost_per_100_tiles": 5},
+        )
+
+        assert network.travel_cost(0, 0, "Near") == 15
+        assert network.travel_cost(0, 0, "Far") == 60
+
+    def test_destinations_only_attuned(self):
+        """Test that only attuned waypoints other than the current one are offered."""
+        network = TeleportNetwork({"Home": (0, 0), "Camp": (0, 100), "Cave": (0, 200)})
+
+        options = network.destinations(["Home", "Cave"], 0, 0)
+
+        assert [name for name, _ in options] == ["Cave"]
@@ -0,0 +1,86 @@
+"""
+Tests for the registry of named worlds a server hosts.
+"""
+
+import os
+import random
+
+import pytest
+
+from world.worlds import DEFAULT_SEED, DEFAULT_WORLD, WorldError, WorldInfo, WorldRegistry
+
+
+def make_registry(tmp_path):
+    return WorldRegistry(str(tmp_path / "worlds.json"), str(tmp_path))
+
+
+class TestWorldRegistry:
+    """Test creating, listing and archiving worlds."""
+
+    def test_main_world_always_there(self, tmp_path):
+        """Test that the original world is listed with its old seed and save file."""
+        registry = make_registry(tmp_path)
+
+        (main,) = registry.available()
+
+        assert (main.name, main.seed) == (DEFAULT_WORLD, DEFAULT_SEED)
+        assert main.ruleset == "standard"
+        assert registry.world_file("main") == str(tmp_path / "persistent_world.pkl")
+        assert not os.path.exists(registry.path)
+
+    def test_create(self, tmp_path):
+        """Test that a created world is saved with its own seed, ruleset and save file."""
+        registry = make_registry(tmp_path)
+
+        registry.create("frontier", ruleset="hardcore", rng=random.Random(1))
+        registry.create("isles", seed=42)
+
+        reloaded = WorldRegistry(registry.path, str(tmp_path))
+        names = [world.name for world in reloaded.available()]
+        frontier = reloaded.get("frontier")
+
+        assert names == ["main", "frontier", "isles"]
+        assert frontier.hardcore and frontier.seed > 0
+        assert reloaded.get("isles").seed == 42 and not reloaded.get("isles").hardcore
+        assert reloaded.world_file("isles") == str(tmp_path / "worlds" / "isles.pkl")
+
+    def test_create_rejects(self, tmp_path):
+        """Test that bad names, unknown rulesets and duplicates are refused."""
+        registry = make_registry(tmp_path)
+
+        registry.create("isles", seed=1)
+
+        for name, ruleset in (
+            ("Isles", "standard"),
+            ("x", "standard"),
+            ("../up", "standard"),
+            ("isles", "standard"),
+            ("main", "standard"),
+            ("moon", "peaceful"),
+        ):
+            with pytest.raises(WorldError):
+                registry.create(name, seed=1, ruleset=ruleset)
+        assert len(registry.available()) == 2
+
+    def test_archive(self, tmp_path):
+        """Test that an archived world is kept but can no longer be entered."""
+        registry = make_registry(tmp_path)
+
+        registry.create("isles", seed=1)
+
+        registry.archive("isles")
+
+        assert [world.name for world in registry.available()] == ["main"]
+        assert [world.name for world in registry.worlds()] == ["main", "isles"]
+        with pytest.raises(WorldError):
+            registry.get("isles")
+        with pytest.raises(WorldError):
+            registry.archive("isles")
+        with pytest.raises(WorldError):
+            registry.archive("nowhere")
+
+    def test_round_trip(self):
+        """Test that a world survives saving as plain data."""
+        world = WorldInfo("isles", 7, "hardcore", 10.0, True)
+
+        assert WorldInfo.from_dict(world.to_dict()) == world
@@ -0,0 +1,166 @@
+"""
+Integration test harness for Terminus Realm.
+Runs a real GameEngine in-process with every save file redirected to a
+temporary directory, and hands out fake client connections that capture each
+message the game sends back. Helpers drive the common flows (joining, moving,
+fighting) so tests can exercise handlers and systems end to end.
+"""
+
+import os
+from typing import Any, Dict, List, Optional, Tuple
+
+from config import CONFIG
+from core.engine import GameEngine
+from entities.components import Health, Position
+from systems.announcements import Announcements
+from systems.audit import AuditLog
+from systems.channels import ChannelRouter
+from systems.memorial import Memorial
+from systems.profile import save_profile
+from systems.seasons import SeasonSystem
+from systems.stash import AccountStash
+from systems.transactions import TransactionJournal
+from world.worlds import WorldRegistry
+
+DIRECTIONS = [(0, -1), (1, 0), (0, 1), (-1, 0), (1, -1), (1, 1), (-1, 1), (-1, -1)]
+
+
+class FakeConnection:
+    """A client that talks to the engine through requests and keeps every reply."""
+
+    def __init__(self, engine: GameEngine):
+        self.engine = engine
+        self.received: List[Dict[str, Any]] = []
+
+    def request(self, message_type: str, **args) -> Dict[str, Any]:
+        """Send a request and return (and capture) the reply."""
+        reply = self.engine.handle_request({"type": message_type, **args})
+        self.received.append(reply)
+        return reply
+
+    def type(self, text: str) -> List[str]:
+        """Send a text command; returns the lines it logged."""
+        return self.request("text_command", text=text)["lines"]
+
+    def messages(self, message_type: Optional[str] = None) -> List[Dict[str, Any]]:
+        """Captured replies, optionally only those of one type."""
+        if message_type is None:
+            return list(self.received)
+        return [m for m in self.received if m.get("type") == message_type]
+
+    def last(self, message_type: str) -> Optional[Dict[str, Any]]:
+        matching = self.messages(message_type)
+        return matching[-1] if matching else None
+
+
+class GameHarness:
+    """A started game whose saves live in save_dir."""
+
+    def __init__(self, save_dir: str, tutorial: bool = False):
+        self.engine = engine = GameEngine()
+
+        # Keep the real profile, memorial, ladder and journal untouched
+        engine.profile_path = os.path.join(save_dir, "player_profile.json")
+        save_profile(engine.profile_path, {} if tutorial else {"tutorial": {"complete": True}})
+        engine.memorial = Memorial(os.path.join(save_dir, "memorial.json"), engine.memorial.size)
+        engine.seasons = SeasonSystem(os.path.join(save_dir, "seasons.json"), CONFIG.season)
+        engine.journal = TransactionJournal(os.path.join(save_dir, "transactions.jsonl"))
+        engine.audit = AuditLog(os.path.join(save_dir, "audit.jsonl"))
+        engine.announcements = Announcements(
+            os.path.join(save_dir, "announcements.json"), "Welcome!"
+        )
+        engine.anticheat = engine.make_anticheat(os.path.join(save_dir, "anticheat.json"))
+        engine.stash = AccountStash(os.path.join(save_dir, "stash.json"))
+        engine.channels = ChannelRouter(os.path.join(save_dir, "channels.json"))
+        # World maps are shared with the game; only the list of worlds is kept here
+        engine.worlds = WorldRegistry(
+            os.path.join(save_dir, "worlds.json"), engine.worlds.save_dir
+        )
+
+        # Tests act many times per tick, faster than the cadence allows a player
+        engine.commands.validator.cadence = 0.0
+
+        engine.initialize_game()
+
+    @property
+    def player(self) -> int:
+        return self.engine.player_id
+
+    def connect(self) -> FakeConnection:
+        return FakeConnection(self.engine)
+
+    def position(self, eid: Optional[int] = None) -> Tuple[int, int]:
+        eid = self.player if eid is None else eid
+        pos = self.engine.entity_manager.get_component(eid, Position)
+        return (pos.x, pos.y)
+
+    def health(self, eid: Optional[int] = None) -> Optional[float]:
+        """Current health, or None once the entity is gone."""
+        eid = self.player if eid is None else eid
+        health = self.engine.entity_manager.get_component(eid, Health)
+        return health.current if health else None
+
+    def tick(self, count: int = 1):
+        """Advance the simulation by fixed updates."""
+        for _ in range(count):
+            self.engine.update(self.engine.fixed_timestep)
+
+    def open_direction(self) -> Tuple[int, int]:
+        """A direction the player can step in right now."""
+        x, y = self.position()
+        for dx, dy in DIRECTIONS:
+            if self.engine.game_map.is_walkable(x + dx, y + dy) and not (
+                self.engine.spatial_index.is_occupied(x + dx, y + dy)
+            ):
+                return dx, dy
+        raise AssertionError(f"Player is boxed in at {(x, y)}")
+
+    def move(self, dx: int, dy: int) -> bool:
+        """Step the player; returns whether they actually moved."""
+        before = self.position()
+        self.engine.commands.run_action("move", dx, dy)
+        return self.position() != before
+
+    def leave_safe_zone(self):
+        """Teleport the player to the nearest open ground clear of any safe zone."""
+        engine = self.engine
+        if not engine.in_safe_zone(self.player):
+            return
+        x, y = self.position()
+        for r in range(1, 200):
+            for tx in range(x - r, x + r + 1):
+                for ty in range(y - r, y + r + 1):
+                    if max(abs(tx - x), abs(ty - y)) != r:
+                        continue
+                    around = [(tx + dx, ty + dy) for dx, dy in DIRECTIONS]
+                    if engine.regions.is_safe(tx, ty) or any(
+                        engine.regions.is_safe(*tile) for tile in around
+                    ):
+                        continue
+                    if engine.game_map.is_walkable(tx, ty) and any(
+                        engine.game_map.is_walkable(*tile) for tile in around
+                    ):
+                        engine.teleport_player(tx, ty)
+                        return
+        raise AssertionError(f"No open ground outside the safe zone near {(x, y)}")
+
+    def spawn_monster(self, monster_type: str = "goblin", health: Optional[float] = None) -> int:
+        """Put a monster on a free tile next to the player, outside any safe zone."""
+        self.leave_safe_zone()
+        dx, dy = self.open_direction()
+        x, y = self.position()
+        eid = self.engine.entity_wrapper.factory.create_monster(x + dx, y + dy, monster_type)
+        if health is not None:
+            monster_health = self.engine.entity_manager.get_component(eid, Health)
+            monster_health.current = monster_health.maximum = health
+        return eid
+
+    def fight(self, monster: int, max_rounds: int = 100) -> bool:
+        """Bump-attack a neighbouring monster until it dies; returns whether it did."""
+        for _ in range(max_rounds):
+            if self.health(monster) is None:
+                return True
+            mx, my = self.position(monster)
+            x, y = self.position()
+            self.move(mx - x, my - y)
+        return self.health(monster) is None